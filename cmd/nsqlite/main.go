@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"os"
 
 	"github.com/nsqlite/nsqlite/internal/nsqlite"
 )
 
 func main() {
-	if err := nsqlite.Run(context.Background()); err != nil {
-		log.Fatal(err)
+	err := nsqlite.Run(context.Background(), os.Args, os.Stdin, os.Stdout, os.Stderr)
+	if err == nil {
+		return
 	}
+
+	// The error has already been written to stderr by Run.
+	var exitErr *nsqlite.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+	os.Exit(1)
 }
@@ -3,12 +3,13 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 
 	"github.com/nsqlite/nsqlite/internal/nsqlitebench"
 )
 
 func main() {
-	if err := nsqlitebench.Run(context.Background()); err != nil {
+	if err := nsqlitebench.Run(context.Background(), os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
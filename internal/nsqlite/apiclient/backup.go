@@ -0,0 +1,43 @@
+package apiclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Backup requests a snapshot of the database to the server. The caller must
+// close the returned body, size is -1 if the server didn't send it.
+func (c *Client) Backup(ctx context.Context) (body io.ReadCloser, size int64, err error) {
+	request, err := c.newRequest(ctx, http.MethodGet, "/backup", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response, err := c.do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return response.Body, response.ContentLength, nil
+}
+
+// Restore uploads a database file to the server to replace its current
+// database.
+func (c *Client) Restore(ctx context.Context, database io.Reader, size int64) error {
+	request, err := c.newRequest(ctx, http.MethodPost, "/restore", database)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = size
+	request.Header.Set("Content-Type", "application/vnd.sqlite3")
+
+	response, err := c.do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	return nil
+}
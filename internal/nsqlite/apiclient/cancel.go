@@ -0,0 +1,115 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header with the ID generated by the client for a
+// query request, used to cancel it with CancelQuery while it runs.
+const RequestIDHeader = "NSQLite-Request-Id"
+
+// InFlightQuery is a query request sent to the server that has not finished.
+type InFlightQuery struct {
+	ID        string
+	StartedAt time.Time
+}
+
+// InFlightQueries tracks the query requests that are waiting for the
+// response of the server, so they can be canceled on the server too.
+type InFlightQueries struct {
+	mu      sync.Mutex
+	queries map[string]InFlightQuery
+}
+
+// NewInFlightQueries creates an empty InFlightQueries.
+func NewInFlightQueries() *InFlightQueries {
+	return &InFlightQueries{queries: map[string]InFlightQuery{}}
+}
+
+// List returns the in-flight queries, the oldest first.
+func (q *InFlightQueries) List() []InFlightQuery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	list := make([]InFlightQuery, 0, len(q.queries))
+	for _, query := range q.queries {
+		list = append(list, query)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+	return list
+}
+
+// add tracks a query until the returned function is called.
+func (q *InFlightQueries) add(id string) (remove func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.queries[id] = InFlightQuery{ID: id, StartedAt: time.Now()}
+	return func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.queries, id)
+	}
+}
+
+// NewRequestIDTransport returns an http.RoundTripper that sends the requests
+// with base, adding a generated request ID to the query requests and
+// tracking them in inFlight until their response is received.
+func NewRequestIDTransport(base http.RoundTripper, inFlight *InFlightQueries) http.RoundTripper {
+	return requestIDTransport{base: base, inFlight: inFlight}
+}
+
+// requestIDTransport is the http.RoundTripper created by
+// NewRequestIDTransport.
+type requestIDTransport struct {
+	base     http.RoundTripper
+	inFlight *InFlightQueries
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodPost || !strings.HasSuffix(req.URL.Path, "/query") {
+		return base.RoundTrip(req)
+	}
+
+	id := req.Header.Get(RequestIDHeader)
+	if id == "" {
+		id = uuid.NewString()
+		req = req.Clone(req.Context())
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	remove := t.inFlight.add(id)
+	defer remove()
+	return base.RoundTrip(req)
+}
+
+// CancelQuery asks the server to interrupt the query request with the given
+// ID. The server returns a *ServerError with status 404 if the query already
+// finished, or if it doesn't support canceling queries.
+func (c *Client) CancelQuery(ctx context.Context, requestID string) error {
+	request, err := c.newRequest(ctx, http.MethodDelete, "/query/"+url.PathEscape(requestID), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.do(request)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
@@ -0,0 +1,85 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDTransport(t *testing.T) {
+	inFlight := NewInFlightQueries()
+	requestIDs := map[string]string{}
+	inFlightIDs := []InFlightQuery{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs[r.Method+" "+r.URL.Path] = r.Header.Get(RequestIDHeader)
+		inFlightIDs = inFlight.List()
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: NewRequestIDTransport(http.DefaultTransport, inFlight)}
+
+	t.Run("Query requests", func(t *testing.T) {
+		res, err := client.Post(ts.URL+"/query", "application/json", strings.NewReader(`[]`))
+		require.NoError(t, err)
+		res.Body.Close()
+
+		id := requestIDs["POST /query"]
+		assert.Len(t, id, 36)
+		require.Len(t, inFlightIDs, 1)
+		assert.Equal(t, id, inFlightIDs[0].ID)
+		assert.Empty(t, inFlight.List(), "the request is removed once finished")
+	})
+
+	t.Run("Generated for each request", func(t *testing.T) {
+		res, err := client.Post(ts.URL+"/query", "application/json", strings.NewReader(`[]`))
+		require.NoError(t, err)
+		res.Body.Close()
+		first := requestIDs["POST /query"]
+
+		res, err = client.Post(ts.URL+"/query", "application/json", strings.NewReader(`[]`))
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.NotEqual(t, first, requestIDs["POST /query"])
+	})
+
+	t.Run("Other requests", func(t *testing.T) {
+		res, err := client.Get(ts.URL + "/stats")
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Empty(t, requestIDs["GET /stats"])
+		assert.Empty(t, inFlightIDs)
+	})
+}
+
+func TestCancelQuery(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete || r.URL.Path != "/query/running" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"Not Found","message":"No running query"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"requestId":"running","canceled":true}`))
+	}))
+	defer ts.Close()
+
+	connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+	require.NoError(t, err)
+	client := NewClient(connStr)
+
+	require.NoError(t, client.CancelQuery(context.Background(), "running"))
+
+	err = client.CancelQuery(context.Background(), "finished")
+	serverErr := &ServerError{}
+	require.ErrorAs(t, err, &serverErr)
+	assert.Equal(t, http.StatusNotFound, serverErr.Status)
+}
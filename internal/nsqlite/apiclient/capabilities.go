@@ -0,0 +1,105 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Capability is an optional feature of the server.
+type Capability string
+
+// Capabilities that a server can report.
+const (
+	CapabilityStreaming   Capability = "streaming"
+	CapabilityCursors     Capability = "cursors"
+	CapabilityNamedParams Capability = "namedParams"
+	CapabilityBackup      Capability = "backup"
+	CapabilityMultiDB     Capability = "multiDb"
+	CapabilityCancel      Capability = "cancel"
+)
+
+// legacyCapabilities are the capabilities of the servers that don't have
+// the /capabilities endpoint.
+var legacyCapabilities = []Capability{CapabilityNamedParams}
+
+// Capabilities are the optional features supported by the server.
+type Capabilities struct {
+	// Version is the version of the server, empty for the servers that
+	// don't have the /capabilities endpoint.
+	Version  string       `json:"version"`
+	Features []Capability `json:"capabilities"`
+}
+
+// Has reports whether the server supports the capability.
+func (c Capabilities) Has(capability Capability) bool {
+	return slices.Contains(c.Features, capability)
+}
+
+// Require returns an *UnsupportedError if the server doesn't support the
+// capability.
+func (c Capabilities) Require(capability Capability) error {
+	if c.Has(capability) {
+		return nil
+	}
+	return &UnsupportedError{Capability: capability}
+}
+
+// UnsupportedError is returned when the server doesn't support a capability
+// needed by a feature, according to the capabilities it advertises.
+type UnsupportedError struct {
+	Capability Capability
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("server does not support %s", e.Capability)
+}
+
+// Capabilities requests the capabilities of the server. They are cached
+// after the first successful request, so they are only requested once per
+// session. Servers without the /capabilities endpoint have the
+// capabilities that every server has.
+func (c *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+
+	if c.caps != nil {
+		return *c.caps, nil
+	}
+
+	caps, err := c.fetchCapabilities(ctx)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	c.caps = &caps
+	return caps, nil
+}
+
+// fetchCapabilities requests the capabilities of the server, without
+// caching them.
+func (c *Client) fetchCapabilities(ctx context.Context) (Capabilities, error) {
+	request, err := c.newRequest(ctx, http.MethodGet, "/capabilities", nil)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	response, err := c.do(request)
+	serverErr := &ServerError{}
+	if errors.As(err, &serverErr) && serverErr.Status == http.StatusNotFound {
+		return Capabilities{Features: legacyCapabilities}, nil
+	}
+	if err != nil {
+		return Capabilities{}, err
+	}
+	defer response.Body.Close()
+
+	caps := Capabilities{}
+	if err := json.NewDecoder(response.Body).Decode(&caps); err != nil {
+		return Capabilities{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return caps, nil
+}
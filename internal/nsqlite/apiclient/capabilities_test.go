@@ -0,0 +1,98 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	// newServer returns a client for a stub server that answers the
+	// /capabilities requests with handler, and the number of requests it
+	// received.
+	newServer := func(t *testing.T, handler http.HandlerFunc) (*Client, *int) {
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/capabilities" {
+				http.NotFound(w, r)
+				return
+			}
+			requests++
+			handler(w, r)
+		}))
+		t.Cleanup(ts.Close)
+
+		connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+		require.NoError(t, err)
+		return NewClient(connStr), &requests
+	}
+
+	t.Run("Full featured server", func(t *testing.T) {
+		client, requests := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"version":"v0.2.0","capabilities":[
+				"streaming","cursors","namedParams","backup","multiDb","cancel"
+			]}`))
+		})
+
+		caps, err := client.Capabilities(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "v0.2.0", caps.Version)
+		for _, capability := range []Capability{
+			CapabilityStreaming, CapabilityCursors, CapabilityNamedParams,
+			CapabilityBackup, CapabilityMultiDB, CapabilityCancel,
+		} {
+			assert.True(t, caps.Has(capability), capability)
+			assert.NoError(t, caps.Require(capability), capability)
+		}
+
+		_, err = client.Capabilities(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, *requests, "the capabilities are cached")
+	})
+
+	t.Run("Older server without the endpoint", func(t *testing.T) {
+		client, _ := newServer(t, http.NotFound)
+
+		caps, err := client.Capabilities(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, caps.Version)
+		assert.True(t, caps.Has(CapabilityNamedParams))
+		assert.False(t, caps.Has(CapabilityCancel))
+
+		err = caps.Require(CapabilityBackup)
+		unsupportedErr := &UnsupportedError{}
+		require.ErrorAs(t, err, &unsupportedErr)
+		assert.Equal(t, CapabilityBackup, unsupportedErr.Capability)
+		assert.EqualError(t, err, "server does not support backup")
+	})
+
+	t.Run("Failed requests are not cached", func(t *testing.T) {
+		fail := true
+		client, requests := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if fail {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"version":"v0.2.0","capabilities":["cancel"]}`))
+		})
+
+		_, err := client.Capabilities(context.Background())
+		assert.Error(t, err)
+
+		fail = false
+		caps, err := client.Capabilities(context.Background())
+		require.NoError(t, err)
+		assert.True(t, caps.Has(CapabilityCancel))
+		assert.Equal(t, 2, *requests)
+	})
+}
+
+func TestUnsupportedError(t *testing.T) {
+	err := &UnsupportedError{Capability: "future"}
+	assert.Equal(t, "server does not support future", err.Error())
+}
@@ -0,0 +1,125 @@
+// Package apiclient implements the requests to the NSQLite server endpoints
+// that are not covered by the nsqlitehttp client used for the queries.
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+)
+
+// Client is an HTTP client for the NSQLite server endpoints.
+type Client struct {
+	connStr *nsqlitedsn.ConnStr
+	httpc   *http.Client
+	// database is the database used by the requests, empty for the default
+	// database of the server.
+	database string
+
+	capsMu sync.Mutex
+	// caps are the cached capabilities of the server, nil until they are
+	// requested.
+	caps *Capabilities
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTransport sets the transport used to send the requests.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpc.Transport = transport
+	}
+}
+
+// NewClient creates a new Client for the given connection string. The
+// default HTTP client has no timeout because backups and restores can take
+// long, use the request context to cancel them.
+func NewClient(connStr *nsqlitedsn.ConnStr, options ...Option) *Client {
+	c := &Client{
+		connStr: connStr,
+		httpc:   &http.Client{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// ServerError is a structured error returned by the server.
+type ServerError struct {
+	Status int    `json:"-"`
+	ID     string `json:"id"`
+	Err    string `json:"error"`
+	// Code is the code of the error, like NSQLITE_INTERNAL, empty for the
+	// servers that don't send it.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ServerError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Err
+	}
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	if e.ID != "" {
+		return fmt.Sprintf("%s (status %d, error id %s)", msg, e.Status, e.ID)
+	}
+	return fmt.Sprintf("%s (status %d)", msg, e.Status)
+}
+
+// newRequest creates a new HTTP request with the NSQLite URL and
+// authentication.
+func (c *Client) newRequest(
+	ctx context.Context, method string, path string, body io.Reader,
+) (*http.Request, error) {
+	url, err := c.connStr.CreateUrlStr(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create URL: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.connStr.AuthToken != "" {
+		request.Header.Set("Authorization", c.connStr.AuthToken)
+	}
+	if c.database != "" {
+		request.Header.Set(DatabaseHeader, c.database)
+	}
+
+	return request, nil
+}
+
+// do sends the request and returns the response if its status is 200 OK,
+// any other status is returned as a *ServerError.
+func (c *Client) do(request *http.Request) (*http.Response, error) {
+	response, err := c.httpc.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if response.StatusCode == http.StatusOK {
+		return response, nil
+	}
+	defer response.Body.Close()
+
+	serverErr := &ServerError{}
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 64<<10))
+	if err := json.Unmarshal(body, serverErr); err != nil {
+		serverErr.Message = string(body)
+	}
+	serverErr.Status = response.StatusCode
+
+	return nil, serverErr
+}
@@ -0,0 +1,38 @@
+package apiclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ServerError
+		want string
+	}{
+		{
+			name: "Structured error",
+			err:  ServerError{Status: 400, ID: "abc", Err: "Bad Request", Message: "Invalid file"},
+			want: "Invalid file (status 400, error id abc)",
+		},
+		{
+			name: "Without message",
+			err:  ServerError{Status: 500, Err: "Internal Server Error"},
+			want: "Internal Server Error (status 500)",
+		},
+		{
+			name: "Empty",
+			err:  ServerError{Status: http.StatusNotFound},
+			want: "Not Found (status 404)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.err.Error())
+		})
+	}
+}
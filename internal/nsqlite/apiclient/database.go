@@ -0,0 +1,45 @@
+package apiclient
+
+import "net/http"
+
+// DatabaseHeader is the header that selects the database of the server used
+// by a request. Without it the server uses its default database.
+const DatabaseHeader = "NSQLite-Database"
+
+// WithDatabase sets the database used by the requests, empty for the default
+// database of the server.
+func WithDatabase(database string) Option {
+	return func(c *Client) {
+		c.database = database
+	}
+}
+
+// NewDatabaseTransport returns an http.RoundTripper that sends the requests
+// with base, selecting the given database. It returns base if the database
+// is empty.
+func NewDatabaseTransport(base http.RoundTripper, database string) http.RoundTripper {
+	if database == "" {
+		return base
+	}
+	return databaseTransport{base: base, database: database}
+}
+
+// databaseTransport is the http.RoundTripper created by NewDatabaseTransport.
+type databaseTransport struct {
+	base     http.RoundTripper
+	database string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t databaseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(DatabaseHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(DatabaseHeader, t.database)
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,48 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabase(t *testing.T) {
+	databases := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		databases = append(databases, r.Header.Get(DatabaseHeader))
+		_, _ = w.Write([]byte(`{"id":"tx"}`))
+	}))
+	defer ts.Close()
+
+	connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+	require.NoError(t, err)
+
+	t.Run("Client", func(t *testing.T) {
+		databases = databases[:0]
+
+		_, err := NewClient(connStr, WithDatabase("mydb")).Transaction(context.Background(), "tx")
+		require.NoError(t, err)
+		_, err = NewClient(connStr).Transaction(context.Background(), "tx")
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"mydb", ""}, databases)
+	})
+
+	t.Run("Transport", func(t *testing.T) {
+		databases = databases[:0]
+
+		assert.Equal(t, http.DefaultTransport, NewDatabaseTransport(http.DefaultTransport, ""))
+
+		client := &http.Client{Transport: NewDatabaseTransport(http.DefaultTransport, "mydb")}
+		res, err := client.Get(ts.URL + "/query")
+		require.NoError(t, err)
+		res.Body.Close()
+
+		assert.Equal(t, []string{"mydb"}, databases)
+	})
+}
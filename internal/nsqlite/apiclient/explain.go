@@ -0,0 +1,61 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// PlanNode is a node of a query plan, as returned by EXPLAIN QUERY PLAN.
+type PlanNode struct {
+	ID int `json:"id"`
+	// Parent is the ID of the parent node, 0 for the top level nodes.
+	Parent int    `json:"parent"`
+	Detail string `json:"detail"`
+}
+
+// explainRequest is the body of an explain request.
+type explainRequest struct {
+	Query  string                   `json:"query"`
+	Params []nsqlitehttp.QueryParam `json:"params,omitempty"`
+	TxId   string                   `json:"txId,omitempty"`
+}
+
+// explainResponse is the body of an explain response.
+type explainResponse struct {
+	Plan []PlanNode `json:"plan"`
+}
+
+// Explain requests the query plan of the given query to the server, in the
+// context of the given transaction if txId is not empty.
+func (c *Client) Explain(
+	ctx context.Context, query string, params []nsqlitehttp.QueryParam, txId string,
+) ([]PlanNode, error) {
+	body, err := json.Marshal(explainRequest{Query: query, Params: params, TxId: txId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	request, err := c.newRequest(ctx, http.MethodPost, "/explain", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	explained := explainResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&explained); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return explained.Plan, nil
+}
@@ -0,0 +1,53 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// queryResponse is the body of a query response.
+type queryResponse struct {
+	Results []nsqlitehttp.QueryResponse `json:"results"`
+}
+
+// SendQueries sends the queries to the server in a single request and
+// returns their results in the same order. Unlike the nsqlitehttp client,
+// the requests use the transport of this client, which controls the
+// connections, like disabling keep-alive.
+func (c *Client) SendQueries(
+	ctx context.Context, queries []nsqlitehttp.Query,
+) ([]nsqlitehttp.QueryResponse, error) {
+	body, err := json.Marshal(queries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	request, err := c.newRequest(ctx, http.MethodPost, "/query", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := c.do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	results := queryResponse{}
+	decoder := json.NewDecoder(response.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(results.Results) != len(queries) {
+		return nil, fmt.Errorf("got %d results for %d queries", len(results.Results), len(queries))
+	}
+
+	return results.Results, nil
+}
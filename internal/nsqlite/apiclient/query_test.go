@@ -0,0 +1,60 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendQueries(t *testing.T) {
+	var received []nsqlitehttp.Query
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/query" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		received = nil
+		_ = json.NewDecoder(r.Body).Decode(&received)
+
+		if len(received) == 3 {
+			_, _ = w.Write([]byte(`{"results":[{"rowsAffected":1}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"time":0.1,"results":[
+			{"columns":["x"],"types":["INTEGER"],"rows":[[1]]},
+			{"error":"no such table: missing"}
+		]}`))
+	}))
+	defer ts.Close()
+
+	connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+	require.NoError(t, err)
+	client := NewClient(connStr)
+
+	t.Run("Batch", func(t *testing.T) {
+		results, err := client.SendQueries(context.Background(), []nsqlitehttp.Query{
+			{Query: "SELECT ?", Params: []nsqlitehttp.QueryParam{{Value: 1}}},
+			{Query: "SELECT * FROM missing"},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, received, 2)
+		assert.Equal(t, "SELECT ?", received[0].Query)
+		require.Len(t, results, 2)
+		assert.Equal(t, []string{"x"}, results[0].Columns)
+		assert.Equal(t, [][]any{{json.Number("1")}}, results[0].Rows)
+		assert.Equal(t, "no such table: missing", results[1].Error)
+	})
+
+	t.Run("Missing results", func(t *testing.T) {
+		_, err := client.SendQueries(context.Background(), make([]nsqlitehttp.Query, 3))
+		assert.ErrorContains(t, err, "got 1 results for 3 queries")
+	})
+}
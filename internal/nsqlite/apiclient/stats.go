@@ -0,0 +1,75 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Stats are the database stats reported by the server. The fields that
+// older servers don't report are nil.
+type Stats struct {
+	StartedAt string `json:"startedAt"`
+	Uptime    string `json:"uptime"`
+	// QueuedWrites and QueuedHTTPRequests are the writes and the requests
+	// waiting to be processed right now.
+	QueuedWrites       *int64 `json:"queuedWrites"`
+	QueuedHTTPRequests *int64 `json:"queuedHttpRequests"`
+	// WALSize is the size in bytes of the write-ahead log of the database.
+	WALSize *int64        `json:"walSize"`
+	Totals  StatsCounters `json:"totals"`
+	// Stats are the counters of each minute, newest first.
+	Stats []StatsMinute `json:"stats"`
+}
+
+// StatsCounters are the counters of the stats of a period.
+type StatsCounters struct {
+	Reads        *int64 `json:"reads"`
+	Writes       *int64 `json:"writes"`
+	Begins       *int64 `json:"begins"`
+	Commits      *int64 `json:"commits"`
+	Rollbacks    *int64 `json:"rollbacks"`
+	Errors       *int64 `json:"errors"`
+	HTTPRequests *int64 `json:"httpRequests"`
+	// Latency are the percentiles of the query latency.
+	Latency *LatencyPercentiles `json:"latency"`
+	// ErrorsByKind is the number of errors of each kind, like "sql" or
+	// "auth".
+	ErrorsByKind map[string]int64 `json:"errorsByKind"`
+}
+
+// StatsMinute are the counters of a minute.
+type StatsMinute struct {
+	// Minute is the start of the minute in RFC 3339 format.
+	Minute string `json:"minute"`
+	StatsCounters
+}
+
+// LatencyPercentiles are percentiles of a latency, in milliseconds.
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// Stats requests the database stats to the server.
+func (c *Client) Stats(ctx context.Context) (Stats, error) {
+	request, err := c.newRequest(ctx, http.MethodGet, "/stats", nil)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	response, err := c.do(request)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer response.Body.Close()
+
+	stats := Stats{}
+	if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+		return Stats{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return stats, nil
+}
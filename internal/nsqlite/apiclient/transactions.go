@@ -0,0 +1,44 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransactionStatus is the state of a transaction reported by the server.
+type TransactionStatus struct {
+	ID string `json:"id"`
+	// StartedAt is when the transaction was started.
+	StartedAt time.Time `json:"startedAt"`
+	// IdleTimeout is the time after which the server rolls back the
+	// transaction if it is not used, in seconds.
+	IdleTimeout float64 `json:"idleTimeout"`
+	// IdleRemaining is the time left before the server rolls back the
+	// transaction if it is not used, in seconds.
+	IdleRemaining float64 `json:"idleRemaining"`
+}
+
+// Transaction requests the state of the given transaction to the server.
+func (c *Client) Transaction(ctx context.Context, txId string) (TransactionStatus, error) {
+	request, err := c.newRequest(ctx, http.MethodGet, "/transactions/"+url.PathEscape(txId), nil)
+	if err != nil {
+		return TransactionStatus{}, err
+	}
+
+	response, err := c.do(request)
+	if err != nil {
+		return TransactionStatus{}, err
+	}
+	defer response.Body.Close()
+
+	status := TransactionStatus{}
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		return TransactionStatus{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,33 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/nsqlite/nsqlite/internal/version"
+)
+
+// RemoteVersion requests the version and the build metadata of the server.
+// The older servers only answer the version, as plain text, so the other
+// fields of their Info are empty.
+func (c *Client) RemoteVersion(ctx context.Context) (version.Info, error) {
+	request, err := c.newRequest(ctx, http.MethodGet, "/version", nil)
+	if err != nil {
+		return version.Info{}, err
+	}
+	request.Header.Set("Accept", "application/json, text/plain;q=0.9")
+
+	response, err := c.do(request)
+	if err != nil {
+		return version.Info{}, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 64<<10))
+	if err != nil {
+		return version.Info{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	return version.ParseInfo(body), nil
+}
@@ -0,0 +1,67 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/version"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteVersion(t *testing.T) {
+	// newClient returns a client for a stub server that answers the
+	// /version requests with handler.
+	newClient := func(t *testing.T, handler http.HandlerFunc) *Client {
+		ts := httptest.NewServer(handler)
+		t.Cleanup(ts.Close)
+
+		connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+		require.NoError(t, err)
+		return NewClient(connStr)
+	}
+
+	t.Run("Server with build metadata", func(t *testing.T) {
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json, text/plain;q=0.9", r.Header.Get("Accept"))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"server":"v0.2.0","commit":"abc123","goVersion":"go1.24.0",` +
+				`"sqliteVersion":"3.48.0","builtAt":"2025-01-02T03:04:05Z"}`))
+		})
+
+		info, err := client.RemoteVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, version.Info{
+			Server:        "v0.2.0",
+			Commit:        "abc123",
+			GoVersion:     "go1.24.0",
+			SQLiteVersion: "3.48.0",
+			BuiltAt:       "2025-01-02T03:04:05Z",
+		}, info)
+	})
+
+	t.Run("Older server answering plain text", func(t *testing.T) {
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("v0.1.0\n"))
+		})
+
+		info, err := client.RemoteVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, version.Info{Server: "v0.1.0"}, info)
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"Unauthorized","message":"Unauthorized"}`))
+		})
+
+		_, err := client.RemoteVersion(context.Background())
+		serverErr := &ServerError{}
+		require.ErrorAs(t, err, &serverErr)
+		assert.Equal(t, http.StatusUnauthorized, serverErr.Status)
+	})
+}
@@ -1,28 +1,65 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alexflint/go-arg"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/exitcode"
 	"github.com/nsqlite/nsqlite/internal/version"
 	"github.com/nsqlite/nsqlitego/nsqlitedsn"
 )
 
 // Config represents the configuration for nsqlite.
 type Config struct {
-	ConnectionString string              `arg:"positional" help:"Connection string for the NSQLite database server in format http(s)://host:port?authToken=value (default to http://localhost:9876)" default:"http://localhost:9876"`
+	ConnectionString string              `arg:"positional" help:"Connection string for the NSQLite database server in format http(s)://host:port[/database]?authToken=value, it also accepts the timeout, format and retries options (default to http://localhost:9876)" default:"http://localhost:9876"`
+	Execute          string              `arg:"-e,--execute" help:"Execute the given SQL statements and exit"`
+	Format           string              `arg:"--format,env:NSQLITE_FORMAT" help:"Output format of the query results (table, json, csv, line, markdown)" default:"table"`
+	Timer            bool                `arg:"--timer,env:NSQLITE_TIMER" help:"Show the timing footer after each query in the REPL" default:"true"`
+	TxIdleTimeout    time.Duration       `arg:"--tx-idle-timeout,env:NSQLITE_TX_IDLE_TIMEOUT" help:"Transaction idle timeout of the server, used to warn before an idle transaction is rolled back when the server doesn't report it" default:"10s"`
+	RequestTimeout   time.Duration       `arg:"--request-timeout,env:NSQLITE_REQUEST_TIMEOUT" help:"Maximum time to wait for each request to the server, 0 means no limit" default:"30s"`
+	Retries          int                 `arg:"--retries,env:NSQLITE_RETRIES" help:"Maximum number of retries of the requests that failed because of a network error, 0 disables them" default:"0"`
+	RetryBackoff     time.Duration       `arg:"--retry-backoff,env:NSQLITE_RETRY_BACKOFF" help:"Wait before the first retry, it doubles on every following retry" default:"200ms"`
+	JSONErrors       bool                `arg:"--json-errors,env:NSQLITE_JSON_ERRORS" help:"Print the errors as a JSON object with the code, message and sqliteCode fields to stderr"`
+	Verbose          bool                `arg:"--verbose,env:NSQLITE_VERBOSE" help:"Log the HTTP requests to the server and their responses to stderr"`
+	HistoryFile      string              `arg:"--history-file,env:NSQLITE_HISTORY_FILE" help:"File where the REPL history is stored (default to history-<host>_<port> in $XDG_CONFIG_HOME/nsqlite or ~/.config/nsqlite)"`
+	HistorySize      int                 `arg:"--history-size,env:NSQLITE_HISTORY_SIZE" help:"Maximum number of entries kept in the REPL history, 0 means no limit" default:"1000"`
+	HistoryIgnore    string              `arg:"--history-ignore,env:NSQLITE_HISTORY_IGNORE" help:"Regular expression matching the statements that are never stored in the REPL history, empty to store all of them" default:"(?i)password|passwd|secret|token"`
 	ParsedConnStr    *nsqlitedsn.ConnStr `arg:"-"`
+	// Database is the database given in the path of the connection string,
+	// empty for the default database of the server.
+	Database string `arg:"-"`
+	// ParsedHistoryIgnore is the compiled HistoryIgnore, nil if it is empty.
+	ParsedHistoryIgnore *regexp.Regexp `arg:"-"`
 }
 
 func (Config) Version() string {
 	return fmt.Sprintf("%s\n", version.CLIVersion())
 }
 
-// MustParse parses and validates the configuration from the command
-// line arguments. It returns a Config struct or exits the program
-// with an error.
-func MustParse(args []string) Config {
+// Epilogue returns the text shown at the end of the help.
+func (Config) Epilogue() string {
+	return exitcode.Help()
+}
+
+// ErrHelpShown is returned by Parse after writing the help or the version,
+// the program must exit without error.
+var ErrHelpShown = errors.New("help shown")
+
+// Parse parses and validates the configuration from the command line
+// arguments. The help and the version are written to stdout when requested,
+// returning ErrHelpShown.
+//
+// The configuration is returned even on error, with the fields parsed
+// before the error set.
+func Parse(args []string, stdout io.Writer) (Config, error) {
 	cfg := Config{}
 
 	parser, err := arg.NewParser(
@@ -30,14 +67,215 @@ func MustParse(args []string) Config {
 		&cfg,
 	)
 	if err != nil {
-		log.Fatal(err)
+		return cfg, err
+	}
+
+	switch err := parser.Parse(args[1:]); {
+	case errors.Is(err, arg.ErrHelp):
+		parser.WriteHelp(stdout)
+		return cfg, ErrHelpShown
+	case errors.Is(err, arg.ErrVersion):
+		fmt.Fprint(stdout, cfg.Version())
+		return cfg, ErrHelpShown
+	case err != nil:
+		return cfg, fmt.Errorf("%w, run with --help for usage", err)
+	}
+
+	var connOpts connStrOptions
+	cfg.ParsedConnStr, connOpts, err = parseConnectionString(cfg.ConnectionString)
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := applyConnStrOptions(&cfg, connOpts, explicitFlags(args[1:], os.Getenv)); err != nil {
+		return cfg, err
+	}
+
+	if err := validateFormat(cfg.Format); err != nil {
+		return cfg, err
+	}
+
+	if cfg.RequestTimeout < 0 {
+		return cfg, errors.New("invalid request timeout, must not be negative")
+	}
+
+	if err := validateRetries(cfg.Retries, cfg.RetryBackoff); err != nil {
+		return cfg, err
+	}
+
+	if cfg.HistorySize < 0 {
+		return cfg, errors.New("invalid history size, must not be negative")
+	}
+
+	cfg.ParsedHistoryIgnore, err = parseHistoryIgnore(cfg.HistoryIgnore)
+	if err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// validateFormat validates if format is a valid output format.
+func validateFormat(format string) error {
+	valid := []string{"table", "json", "csv", "line", "markdown"}
+
+	for _, v := range valid {
+		if format == v {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"invalid format, valid values are: %s",
+		strings.Join(valid, ", "),
+	)
+}
+
+// validateRetries validates if the retries are not negative and the backoff
+// is greater than zero.
+func validateRetries(retries int, backoff time.Duration) error {
+	if retries < 0 {
+		return errors.New("invalid retries, must not be negative")
+	}
+	if backoff <= 0 {
+		return errors.New("invalid retry backoff, must be greater than zero")
+	}
+	return nil
+}
+
+// parseHistoryIgnore compiles the pattern of the statements that are not
+// stored in the history, it returns nil if the pattern is empty.
+func parseHistoryIgnore(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid history ignore pattern: %w", err)
 	}
-	parser.MustParse(args[1:])
+	return re, nil
+}
+
+// connStrOptions are the client options given in the path and the query of
+// the connection string. The pointers are nil for the options not given.
+type connStrOptions struct {
+	database string
+	timeout  *time.Duration
+	format   string
+	retries  *int
+}
 
-	cfg.ParsedConnStr, err = nsqlitedsn.NewConnStrFromText(cfg.ConnectionString)
+// connStrOptionFlags maps the options of the connection string to the flag
+// and the environment variable that also set them.
+var connStrOptionFlags = map[string]struct{ flag, env string }{
+	"timeout": {flag: "--request-timeout", env: "NSQLITE_REQUEST_TIMEOUT"},
+	"format":  {flag: "--format", env: "NSQLITE_FORMAT"},
+	"retries": {flag: "--retries", env: "NSQLITE_RETRIES"},
+}
+
+// parseConnectionString parses a connection string in the format
+// http(s)://host:port[/database]?authToken=value&option=value. The options
+// are timeout, format and retries, any other query parameter is an error.
+func parseConnectionString(text string) (*nsqlitedsn.ConnStr, connStrOptions, error) {
+	opts := connStrOptions{}
+
+	connStr, err := nsqlitedsn.NewConnStrFromText(text)
+	if err != nil {
+		return nil, opts, err
+	}
+
+	parsed, err := url.Parse(text)
 	if err != nil {
-		log.Fatal(err)
+		return nil, opts, err
+	}
+
+	opts.database = strings.Trim(parsed.Path, "/")
+	if strings.Contains(opts.database, "/") {
+		return nil, opts, fmt.Errorf("invalid database name %q in connection string", opts.database)
+	}
+
+	for name, values := range parsed.Query() {
+		value := values[len(values)-1]
+
+		switch name {
+		case "authToken":
+		case "timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout < 0 {
+				return nil, opts, fmt.Errorf("invalid timeout %q in connection string", value)
+			}
+			opts.timeout = &timeout
+		case "format":
+			if err := validateFormat(value); err != nil {
+				return nil, opts, err
+			}
+			opts.format = value
+		case "retries":
+			retries, err := strconv.Atoi(value)
+			if err != nil || retries < 0 {
+				return nil, opts, fmt.Errorf("invalid retries %q in connection string", value)
+			}
+			opts.retries = &retries
+		default:
+			return nil, opts, fmt.Errorf("unknown connection string option %q", name)
+		}
+	}
+
+	return connStr, opts, nil
+}
+
+// explicitFlags returns the options of the connection string whose flag or
+// environment variable is set.
+func explicitFlags(args []string, getenv func(string) string) map[string]bool {
+	explicit := map[string]bool{}
+	for option, source := range connStrOptionFlags {
+		if getenv(source.env) != "" {
+			explicit[option] = true
+		}
+		for _, arg := range args {
+			if arg == source.flag || strings.HasPrefix(arg, source.flag+"=") {
+				explicit[option] = true
+			}
+		}
+	}
+	return explicit
+}
+
+// applyConnStrOptions sets the options of the connection string in the
+// configuration. An option also set with its flag or environment variable,
+// as reported by explicit, must have the same value.
+func applyConnStrOptions(cfg *Config, opts connStrOptions, explicit map[string]bool) error {
+	cfg.Database = opts.database
+
+	conflict := func(option string, connValue any, flagValue any) error {
+		source := connStrOptionFlags[option]
+		return fmt.Errorf(
+			"conflicting %s: %v in the connection string and %v in %s or %s",
+			option, connValue, flagValue, source.flag, source.env,
+		)
+	}
+
+	if opts.timeout != nil {
+		if explicit["timeout"] && cfg.RequestTimeout != *opts.timeout {
+			return conflict("timeout", *opts.timeout, cfg.RequestTimeout)
+		}
+		cfg.RequestTimeout = *opts.timeout
+	}
+
+	if opts.format != "" {
+		if explicit["format"] && cfg.Format != opts.format {
+			return conflict("format", opts.format, cfg.Format)
+		}
+		cfg.Format = opts.format
+	}
+
+	if opts.retries != nil {
+		if explicit["retries"] && cfg.Retries != *opts.retries {
+			return conflict("retries", *opts.retries, cfg.Retries)
+		}
+		cfg.Retries = *opts.retries
 	}
 
-	return cfg
+	return nil
 }
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConnectionString(t *testing.T) {
+	duration := func(d time.Duration) *time.Duration { return &d }
+	integer := func(n int) *int { return &n }
+
+	tests := []struct {
+		name      string
+		text      string
+		host      string
+		port      string
+		authToken string
+		opts      connStrOptions
+		wantErr   string
+	}{
+		{
+			name: "Host and port",
+			text: "http://localhost:9876",
+			host: "localhost",
+			port: "9876",
+		},
+		{
+			name:      "Database and auth token",
+			text:      "https://db.example.com/mydb?authToken=secret",
+			host:      "db.example.com",
+			port:      "9876",
+			authToken: "secret",
+			opts:      connStrOptions{database: "mydb"},
+		},
+		{
+			name: "Trailing slash",
+			text: "http://localhost:9876/mydb/",
+			host: "localhost",
+			port: "9876",
+			opts: connStrOptions{database: "mydb"},
+		},
+		{
+			name:      "Options",
+			text:      "http://localhost:1234/mydb?authToken=abc&timeout=10s&format=json&retries=3",
+			host:      "localhost",
+			port:      "1234",
+			authToken: "abc",
+			opts: connStrOptions{
+				database: "mydb",
+				timeout:  duration(10 * time.Second),
+				format:   "json",
+				retries:  integer(3),
+			},
+		},
+		{
+			name:    "Nested path",
+			text:    "http://localhost:9876/my/db",
+			wantErr: `invalid database name "my/db" in connection string`,
+		},
+		{
+			name:    "Unknown option",
+			text:    "http://localhost:9876?authToken=abc&colour=red",
+			wantErr: `unknown connection string option "colour"`,
+		},
+		{
+			name:    "Invalid timeout",
+			text:    "http://localhost:9876?timeout=soon",
+			wantErr: `invalid timeout "soon" in connection string`,
+		},
+		{
+			name:    "Invalid format",
+			text:    "http://localhost:9876?format=xml",
+			wantErr: "invalid format",
+		},
+		{
+			name:    "Invalid retries",
+			text:    "http://localhost:9876?retries=-1",
+			wantErr: `invalid retries "-1" in connection string`,
+		},
+		{
+			name:    "Invalid protocol",
+			text:    "ftp://localhost:9876",
+			wantErr: "invalid protocol",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connStr, opts, err := parseConnectionString(tt.text)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.host, connStr.Host)
+			assert.Equal(t, tt.port, connStr.Port)
+			assert.Equal(t, tt.authToken, connStr.AuthToken)
+			assert.Equal(t, tt.opts, opts)
+		})
+	}
+}
+
+func TestApplyConnStrOptions(t *testing.T) {
+	defaults := func() Config {
+		return Config{Format: "table", RequestTimeout: 30 * time.Second}
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		args    []string
+		env     map[string]string
+		flags   func(cfg *Config)
+		want    func(cfg *Config)
+		wantErr string
+	}{
+		{
+			name: "Options override the defaults",
+			text: "http://localhost:9876/mydb?timeout=5s&format=csv&retries=2",
+			want: func(cfg *Config) {
+				cfg.Database = "mydb"
+				cfg.RequestTimeout = 5 * time.Second
+				cfg.Format = "csv"
+				cfg.Retries = 2
+			},
+		},
+		{
+			name:  "Same value in the flag",
+			text:  "http://localhost:9876?format=json",
+			args:  []string{"--format=json"},
+			flags: func(cfg *Config) { cfg.Format = "json" },
+			want:  func(cfg *Config) { cfg.Format = "json" },
+		},
+		{
+			name:    "Conflicting flag",
+			text:    "http://localhost:9876?format=json",
+			args:    []string{"--format", "csv"},
+			flags:   func(cfg *Config) { cfg.Format = "csv" },
+			wantErr: "conflicting format: json in the connection string and csv in --format or NSQLITE_FORMAT",
+		},
+		{
+			name:    "Conflicting environment variable",
+			text:    "http://localhost:9876?timeout=5s",
+			env:     map[string]string{"NSQLITE_REQUEST_TIMEOUT": "1s"},
+			flags:   func(cfg *Config) { cfg.RequestTimeout = time.Second },
+			wantErr: "conflicting timeout: 5s in the connection string and 1s in --request-timeout",
+		},
+		{
+			name: "Flags of other options",
+			text: "http://localhost:9876?retries=4",
+			args: []string{"--format=json", "--request-timeout=1s"},
+			flags: func(cfg *Config) {
+				cfg.Format = "json"
+				cfg.RequestTimeout = time.Second
+			},
+			want: func(cfg *Config) {
+				cfg.Format = "json"
+				cfg.RequestTimeout = time.Second
+				cfg.Retries = 4
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, opts, err := parseConnectionString(tt.text)
+			require.NoError(t, err)
+
+			cfg := defaults()
+			if tt.flags != nil {
+				tt.flags(&cfg)
+			}
+			getenv := func(name string) string { return tt.env[name] }
+
+			err = applyConnStrOptions(&cfg, opts, explicitFlags(tt.args, getenv))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			want := defaults()
+			tt.want(&want)
+			assert.Equal(t, want, cfg)
+		})
+	}
+}
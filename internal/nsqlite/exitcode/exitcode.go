@@ -0,0 +1,37 @@
+// Package exitcode defines the exit codes of the NSQLite CLI when running
+// non-interactively, so scripts can tell the kind of failure.
+package exitcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Exit codes of the NSQLite CLI.
+const (
+	Success         = 0
+	SQLError        = 1
+	ConnectionError = 2
+	UsageError      = 3
+)
+
+// Codes are the exit codes with their description, in order.
+var Codes = []struct {
+	Code        int
+	Description string
+}{
+	{Code: Success, Description: "all the statements were executed"},
+	{Code: SQLError, Description: "a statement failed with a SQL error"},
+	{Code: ConnectionError, Description: "connection, authentication or I/O error"},
+	{Code: UsageError, Description: "invalid command line arguments"},
+}
+
+// Help returns the description of the exit codes for the help text.
+func Help() string {
+	sb := strings.Builder{}
+	sb.WriteString("Exit codes:")
+	for _, code := range Codes {
+		fmt.Fprintf(&sb, "\n  %d  %s", code.Code, code.Description)
+	}
+	return sb.String()
+}
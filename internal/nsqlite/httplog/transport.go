@@ -0,0 +1,200 @@
+// Package httplog implements an http.RoundTripper that logs the HTTP
+// exchanges with the NSQLite server, to debug mismatches between the client
+// and the server.
+package httplog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
+)
+
+// maxLoggedBody is the maximum number of bytes logged of each body, longer
+// bodies are truncated.
+const maxLoggedBody = 16 << 10
+
+// Transport is an http.RoundTripper that writes each request and its
+// response to a writer while it is enabled: method, URL, headers, body,
+// status and timing. The credentials are redacted and the binary bodies are
+// summarized by their length.
+type Transport struct {
+	base    http.RoundTripper
+	w       io.Writer
+	enabled atomic.Bool
+	// mu serializes the writes of concurrent requests.
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// NewTransport creates a new Transport that sends the requests with base and
+// logs them to w if enabled is true.
+func NewTransport(base http.RoundTripper, w io.Writer, enabled bool) *Transport {
+	t := &Transport{
+		base: base,
+		w:    w,
+		now:  time.Now,
+	}
+	t.enabled.Store(enabled)
+	return t
+}
+
+// SetEnabled enables or disables the logging.
+func (t *Transport) SetEnabled(enabled bool) {
+	t.enabled.Store(enabled)
+}
+
+// Enabled reports whether the logging is enabled.
+func (t *Transport) Enabled() bool {
+	return t.enabled.Load()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.Enabled() {
+		return t.base.RoundTrip(req)
+	}
+
+	var requestBody string
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(req.Context())
+		requestBody, req.Body = captureBody(req.Body, req.Header.Get("Content-Type"), req.ContentLength)
+	}
+
+	start := t.now()
+	res, err := t.base.RoundTrip(req)
+	elapsed := t.now().Sub(start)
+
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "> %s %s\n", req.Method, req.URL.Redacted())
+	writeHeaders(&sb, "> ", req.Header)
+	if requestBody != "" {
+		fmt.Fprintf(&sb, "%s\n", requestBody)
+	}
+
+	if err != nil {
+		fmt.Fprintf(&sb, "! %s (%s)\n\n", err, elapsed.Round(time.Microsecond))
+		t.write(sb.String())
+		return res, err
+	}
+
+	fmt.Fprintf(&sb, "< %s %s (%s)\n", res.Proto, res.Status, elapsed.Round(time.Microsecond))
+	writeHeaders(&sb, "< ", res.Header)
+	if res.Body != nil && res.Body != http.NoBody {
+		var responseBody string
+		responseBody, res.Body = captureBody(res.Body, res.Header.Get("Content-Type"), res.ContentLength)
+		if responseBody != "" {
+			fmt.Fprintf(&sb, "%s\n", responseBody)
+		}
+	}
+	sb.WriteString("\n")
+	t.write(sb.String())
+
+	return res, nil
+}
+
+// write writes the log of an exchange, ignoring the errors because logging
+// must never make a request fail.
+func (t *Transport) write(s string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = io.WriteString(t.w, s)
+}
+
+// writeHeaders writes the headers sorted by name, one per line, with the
+// credentials redacted.
+func writeHeaders(sb *strings.Builder, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(sb, "%s%s: %s\n", prefix, name, httputil.RedactHeaderValue(name, value))
+		}
+	}
+}
+
+// captureBody returns the text of the body to log and a body that replaces
+// the given one, with the read bytes put back. Only the first maxLoggedBody
+// bytes of textual bodies are read, binary bodies are summarized by their
+// length without reading them.
+func captureBody(body io.ReadCloser, contentType string, length int64) (string, io.ReadCloser) {
+	if !isTextual(contentType) {
+		return binarySummary(length), body
+	}
+
+	head, err := io.ReadAll(io.LimitReader(body, maxLoggedBody+1))
+	restored := readCloser{
+		Reader: io.MultiReader(bytes.NewReader(head), errReader{err: err}, body),
+		Closer: body,
+	}
+	if err != nil {
+		return fmt.Sprintf("<failed to read body: %s>", err), restored
+	}
+
+	if !utf8.Valid(head) {
+		return binarySummary(length), restored
+	}
+	if len(head) > maxLoggedBody {
+		return string(head[:maxLoggedBody]) + "… (truncated)", restored
+	}
+	return string(bytes.TrimRight(head, "\n")), restored
+}
+
+// isTextual reports whether a body of the given content type can be logged
+// as text. Bodies without a content type are checked once read.
+func isTextual(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// binarySummary describes a binary body of the given length, -1 if unknown.
+func binarySummary(length int64) string {
+	if length < 0 {
+		return "<binary body of unknown length>"
+	}
+	return fmt.Sprintf("<binary body, %s>", numutil.Bytes(length))
+}
+
+// readCloser combines a reader with the closer of the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader returns the error that interrupted the reading of a body, so the
+// caller gets it after the bytes that were read. A nil error is an empty
+// reader.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,142 @@
+package httplog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureStderr returns what is written to os.Stderr while fn runs.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+
+	stderr := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	fn()
+	require.NoError(t, writer.Close())
+	return <-output
+}
+
+func TestTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/backup":
+			w.Header().Set("Content-Type", "application/vnd.sqlite3")
+			_, _ = w.Write([]byte("SQLite format 3\x00\x01\x02"))
+		default:
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"results":[{"echo":` + string(body) + `}]}`))
+		}
+	}))
+	defer ts.Close()
+
+	t.Run("Logs the exchange with the auth token redacted", func(t *testing.T) {
+		output := captureStderr(t, func() {
+			client, err := nsqlitehttp.NewClient(
+				ts.URL+"?authToken=super-secret-token",
+				nsqlitehttp.WithHTTPClient(&http.Client{
+					Transport: NewTransport(http.DefaultTransport, os.Stderr, true),
+				}),
+			)
+			require.NoError(t, err)
+
+			_, err = client.SendQuery(context.Background(), nsqlitehttp.Query{Query: "SELECT 1"})
+			require.NoError(t, err)
+		})
+
+		assert.NotContains(t, output, "super-secret-token")
+		assert.Contains(t, output, "> POST "+ts.URL+"/query\n")
+		assert.Contains(t, output, "> Authorization: [REDACTED]\n")
+		assert.Contains(t, output, "> Content-Type: application/json\n")
+		assert.Contains(t, output, `"query":"SELECT 1"`)
+		assert.Contains(t, output, "< HTTP/1.1 200 OK (")
+		assert.Contains(t, output, `{"results":[{"echo":`)
+	})
+
+	t.Run("The bodies are still sent and received", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		client := &http.Client{Transport: NewTransport(http.DefaultTransport, buf, true)}
+
+		res, err := client.Post(ts.URL+"/query", "application/json", strings.NewReader(`"ping"`))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"results":[{"echo":"ping"}]}`, string(body))
+		assert.Contains(t, buf.String(), "\"ping\"\n")
+	})
+
+	t.Run("Binary bodies are summarized", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		client := &http.Client{Transport: NewTransport(http.DefaultTransport, buf, true)}
+
+		res, err := client.Get(ts.URL + "/backup")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SQLite format 3\x00\x01\x02", string(body))
+		assert.Contains(t, buf.String(), "<binary body, 18 B>\n")
+		assert.NotContains(t, buf.String(), "SQLite format 3")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		failing := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})
+		client := &http.Client{Transport: NewTransport(failing, buf, true)}
+
+		_, err := client.Get(ts.URL + "/health")
+		require.Error(t, err)
+		assert.Contains(t, buf.String(), "> GET "+ts.URL+"/health\n")
+		assert.Contains(t, buf.String(), "! connection refused (")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		transport := NewTransport(http.DefaultTransport, buf, false)
+		client := &http.Client{Transport: transport}
+
+		res, err := client.Get(ts.URL + "/health")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Empty(t, buf.String())
+
+		transport.SetEnabled(true)
+		res, err = client.Get(ts.URL + "/health")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Contains(t, buf.String(), "> GET "+ts.URL+"/health\n")
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,41 @@
+package repl
+
+import (
+	"context"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// queryClient is the subset of the NSQLite client used by the commands that
+// only need to send queries, so they can be tested without a server.
+type queryClient interface {
+	SendQuery(ctx context.Context, query nsqlitehttp.Query) (nsqlitehttp.QueryResponse, error)
+	SendQueries(ctx context.Context, queries []nsqlitehttp.Query) ([]nsqlitehttp.QueryResponse, error)
+}
+
+// sendQuery sends a query and returns the error reported by the server as a
+// *SQLError, if any.
+func sendQuery(
+	ctx context.Context, client queryClient, query string, params ...nsqlitehttp.QueryParam,
+) (nsqlitehttp.QueryResponse, error) {
+	return sendTxQuery(ctx, client, "", query, params...)
+}
+
+// sendTxQuery is the same as sendQuery but runs the query in the context of
+// the given transaction.
+func sendTxQuery(
+	ctx context.Context, client queryClient, txId string, query string, params ...nsqlitehttp.QueryParam,
+) (nsqlitehttp.QueryResponse, error) {
+	res, err := client.SendQuery(ctx, nsqlitehttp.Query{
+		TxId:   txId,
+		Query:  query,
+		Params: params,
+	})
+	if err != nil {
+		return res, err
+	}
+	if res.Error != "" {
+		return res, &SQLError{Message: res.Error}
+	}
+	return res, nil
+}
@@ -0,0 +1,119 @@
+package repl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueryClient is a queryClient that runs the queries against a local
+// SQLite connection.
+type fakeQueryClient struct {
+	conn *sqlitec.Conn
+	// sendQueryErr, if set, is returned by SendQuery to simulate a
+	// connection error.
+	sendQueryErr error
+	// sendQueriesErr, if set, is returned by SendQueries to simulate a
+	// connection error.
+	sendQueriesErr error
+}
+
+func (c fakeQueryClient) SendQuery(
+	_ context.Context, query nsqlitehttp.Query,
+) (nsqlitehttp.QueryResponse, error) {
+	if c.sendQueryErr != nil {
+		return nsqlitehttp.QueryResponse{}, c.sendQueryErr
+	}
+
+	params := make([]sqlitec.QueryParam, len(query.Params))
+	for i, param := range query.Params {
+		params[i] = sqlitec.QueryParam{Name: param.Name, Value: param.Value}
+	}
+
+	res, err := c.conn.Query(query.Query, params)
+	if err != nil {
+		return nsqlitehttp.QueryResponse{Error: err.Error()}, nil
+	}
+
+	txId := ""
+	if strings.EqualFold(strings.TrimSpace(query.Query), "BEGIN") {
+		txId = "fake-tx"
+	}
+
+	return nsqlitehttp.QueryResponse{
+		TxId:         txId,
+		RowsAffected: res.RowsAffected,
+		Columns:      res.Columns,
+		Rows:         res.Rows,
+	}, nil
+}
+
+func (c fakeQueryClient) SendQueries(
+	ctx context.Context, queries []nsqlitehttp.Query,
+) ([]nsqlitehttp.QueryResponse, error) {
+	if c.sendQueriesErr != nil {
+		return nil, c.sendQueriesErr
+	}
+
+	responses := make([]nsqlitehttp.QueryResponse, len(queries))
+	for i, query := range queries {
+		res, err := c.SendQuery(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = res
+	}
+	return responses, nil
+}
+
+// openTestConn opens an in-memory SQLite connection and runs the given
+// script, which must have one statement per ";\n" terminated chunk.
+func openTestConn(t *testing.T, script string) *sqlitec.Conn {
+	t.Helper()
+
+	conn, err := sqlitec.Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	for _, stmt := range strings.Split(script, ";\n") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		_, err := conn.Query(stmt, nil)
+		require.NoError(t, err, stmt)
+	}
+
+	return conn
+}
+
+// queryValue returns the first column of the first row of the query.
+func queryValue(t *testing.T, conn *sqlitec.Conn, query string) any {
+	t.Helper()
+
+	res, err := conn.Query(query, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, res.Rows)
+
+	return res.Rows[0][0]
+}
+
+func TestSendQuery(t *testing.T) {
+	client := fakeQueryClient{conn: openTestConn(t, "")}
+
+	t.Run("Returns the result", func(t *testing.T) {
+		res, err := sendQuery(context.Background(), client, "SELECT ?", nsqlitehttp.QueryParam{Value: 1})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{1}}, res.Rows)
+	})
+
+	t.Run("Converts the response error", func(t *testing.T) {
+		res, err := sendQuery(context.Background(), client, "SELECT * FROM missing")
+		assert.Error(t, err)
+		assert.Equal(t, res.Error, err.Error())
+	})
+}
@@ -0,0 +1,187 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
+	"github.com/schollz/progressbar/v3"
+)
+
+// sqliteHeader is the magic string at the start of every SQLite database.
+const sqliteHeader = "SQLite format 3\x00"
+
+func cmdBackup(r *Repl, args string) {
+	path := strings.TrimSpace(args)
+	if path == "" {
+		fmt.Println("Usage: .backup file")
+		return
+	}
+	if !r.requireCapability(apiclient.CapabilityBackup) {
+		return
+	}
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	size, err := downloadBackup(ctx, r.api, path, os.Stderr)
+	if err != nil {
+		fmt.Println("Failed to backup database:", err)
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("Database backed up to %s (%s)\n", path, numutil.Bytes(size))
+	fmt.Println()
+}
+
+func cmdRestore(r *Repl, args string) {
+	path := strings.TrimSpace(args)
+	if path == "" {
+		fmt.Println("Usage: .restore file")
+		return
+	}
+	if !r.requireCapability(apiclient.CapabilityBackup) {
+		return
+	}
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	restored, err := restoreBackup(
+		ctx, r.api, path, r.conf.ParsedConnStr.String(), r.confirm, os.Stderr,
+	)
+	if err != nil {
+		fmt.Println("Failed to restore database:", err)
+		fmt.Println()
+		return
+	}
+
+	if restored {
+		fmt.Println("Database restored")
+	} else {
+		fmt.Println("Restore canceled")
+	}
+	fmt.Println()
+}
+
+// downloadBackup downloads a snapshot of the database to path and returns
+// its size. The snapshot is written to a temporary file that is only renamed
+// to path once it is complete and has the SQLite header.
+func downloadBackup(
+	ctx context.Context, api *apiclient.Client, path string, progress io.Writer,
+) (int64, error) {
+	body, size, err := api.Backup(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	bar := newBytesBar(size, "Downloading backup", progress)
+	written, err := io.Copy(io.MultiWriter(tmp, bar), body)
+	_ = bar.Finish()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to download backup: %w", err)
+	}
+
+	if size >= 0 && written != size {
+		return 0, fmt.Errorf("incomplete backup, received %d of %d bytes", written, size)
+	}
+	if err := verifySQLiteHeader(tmp.Name()); err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to save backup: %w", err)
+	}
+	return written, nil
+}
+
+// restoreBackup uploads the SQLite database at path to replace the database
+// of the target server, after the user confirms it. It reports whether the
+// database was restored.
+func restoreBackup(
+	ctx context.Context, api *apiclient.Client, path string, target string,
+	confirm func(question string) bool, progress io.Writer,
+) (bool, error) {
+	if err := verifySQLiteHeader(path); err != nil {
+		return false, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, fmt.Errorf("failed to read file info: %w", err)
+	}
+
+	question := fmt.Sprintf(
+		"This will replace the database of %s with %s (%s), continue?",
+		target, path, numutil.Bytes(info.Size()),
+	)
+	if !confirm(question) {
+		return false, nil
+	}
+
+	bar := newBytesBar(info.Size(), "Uploading backup", progress)
+	err = api.Restore(ctx, io.TeeReader(file, bar), info.Size())
+	_ = bar.Finish()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// verifySQLiteHeader checks that the file at path starts with the SQLite
+// magic header.
+func verifySQLiteHeader(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(file, header); err != nil || string(header) != sqliteHeader {
+		return errors.New("the file is not an SQLite database")
+	}
+	return nil
+}
+
+// newBytesBar creates a progress bar for a transfer of size bytes, size can
+// be -1 if it is unknown.
+func newBytesBar(size int64, description string, w io.Writer) *progressbar.ProgressBar {
+	return progressbar.NewOptions64(
+		size,
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWriter(w),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionShowTotalBytes(true),
+		progressbar.OptionSetWidth(20),
+		progressbar.OptionThrottle(65*time.Millisecond),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Fprintln(w)
+		}),
+	)
+}
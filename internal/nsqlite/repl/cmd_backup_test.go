@@ -0,0 +1,160 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFixtureDatabase creates an SQLite database file with some rows and
+// returns its content.
+func newFixtureDatabase(t *testing.T) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fixture.sqlite")
+	conn, err := sqlitec.Open(path)
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)",
+		"INSERT INTO items (name) VALUES ('a'), ('b'), ('c')",
+	} {
+		_, err := conn.Query(query, nil)
+		require.NoError(t, err)
+	}
+	require.NoError(t, conn.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
+
+// newTestAPIClient returns an apiclient.Client for the given test server.
+func newTestAPIClient(t *testing.T, ts *httptest.Server) *apiclient.Client {
+	t.Helper()
+
+	connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL + "?authToken=secret")
+	require.NoError(t, err)
+	return apiclient.NewClient(connStr)
+}
+
+func TestDownloadBackup(t *testing.T) {
+	ctx := context.Background()
+	fixture := newFixtureDatabase(t)
+
+	t.Run("Saves the snapshot", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/backup", r.URL.Path)
+			assert.Equal(t, "secret", r.Header.Get("Authorization"))
+			_, _ = w.Write(fixture)
+		}))
+		defer ts.Close()
+
+		path := filepath.Join(t.TempDir(), "backup.sqlite")
+		progress := bytes.Buffer{}
+		size, err := downloadBackup(ctx, newTestAPIClient(t, ts), path, &progress)
+		require.NoError(t, err)
+		assert.EqualValues(t, len(fixture), size)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, fixture, data)
+		assert.Contains(t, progress.String(), "Downloading backup")
+
+		conn, err := sqlitec.Open(path)
+		require.NoError(t, err)
+		defer conn.Close()
+		res, err := conn.Query("SELECT COUNT(*) FROM items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{3}}, res.Rows)
+	})
+
+	t.Run("Rejects files that are not SQLite databases", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("not a database"))
+		}))
+		defer ts.Close()
+
+		dir := t.TempDir()
+		_, err := downloadBackup(ctx, newTestAPIClient(t, ts), filepath.Join(dir, "backup.sqlite"), io.Discard)
+		assert.ErrorContains(t, err, "not an SQLite database")
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "no partial files must be left")
+	})
+
+	t.Run("Surfaces server errors", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"id":"abc","error":"Unauthorized","message":"Invalid auth token"}`))
+		}))
+		defer ts.Close()
+
+		_, err := downloadBackup(ctx, newTestAPIClient(t, ts), filepath.Join(t.TempDir(), "b"), io.Discard)
+		var serverErr *apiclient.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		assert.Equal(t, http.StatusUnauthorized, serverErr.Status)
+		assert.Equal(t, "Invalid auth token (status 401, error id abc)", err.Error())
+	})
+}
+
+func TestRestoreBackup(t *testing.T) {
+	ctx := context.Background()
+	fixture := newFixtureDatabase(t)
+	path := filepath.Join(t.TempDir(), "restore.sqlite")
+	require.NoError(t, os.WriteFile(path, fixture, 0644))
+
+	var received []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/restore", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+	api := newTestAPIClient(t, ts)
+
+	t.Run("Canceled", func(t *testing.T) {
+		question := ""
+		confirm := func(q string) bool {
+			question = q
+			return false
+		}
+
+		restored, err := restoreBackup(ctx, api, path, "http://target:9876", confirm, io.Discard)
+		require.NoError(t, err)
+		assert.False(t, restored)
+		assert.Nil(t, received)
+		assert.Contains(t, question, "http://target:9876")
+		assert.Contains(t, question, "KiB")
+	})
+
+	t.Run("Confirmed", func(t *testing.T) {
+		confirm := func(string) bool { return true }
+
+		restored, err := restoreBackup(ctx, api, path, "http://target:9876", confirm, io.Discard)
+		require.NoError(t, err)
+		assert.True(t, restored)
+		assert.Equal(t, fixture, received)
+	})
+
+	t.Run("Rejects files that are not SQLite databases", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.sqlite")
+		require.NoError(t, os.WriteFile(invalid, []byte("nope"), 0644))
+
+		_, err := restoreBackup(ctx, api, invalid, "", func(string) bool { return true }, io.Discard)
+		assert.ErrorContains(t, err, "not an SQLite database")
+	})
+}
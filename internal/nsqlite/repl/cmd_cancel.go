@@ -0,0 +1,44 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+)
+
+// cancelQueryTimeout is the timeout of the requests that cancel a query on
+// the server.
+const cancelQueryTimeout = 5 * time.Second
+
+func cmdCancel(r *Repl, args string) {
+	requestID := strings.TrimSpace(args)
+	if requestID == "" || strings.ContainsAny(requestID, " \t") {
+		fmt.Println("Usage: .cancel request_id")
+		return
+	}
+
+	if !r.requireCapability(apiclient.CapabilityCancel) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, cancelQueryTimeout)
+	defer cancel()
+
+	err := r.api.CancelQuery(ctx, requestID)
+	serverErr := &apiclient.ServerError{}
+	if errors.As(err, &serverErr) && serverErr.Status == http.StatusNotFound {
+		fmt.Printf("No running query with request ID %s\n", requestID)
+		return
+	}
+	if err != nil {
+		fmt.Println("Failed to cancel query:", err)
+		return
+	}
+
+	fmt.Printf("Query %s canceled\n", requestID)
+}
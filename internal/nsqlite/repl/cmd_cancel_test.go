@@ -0,0 +1,54 @@
+package repl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCmdCancelCapabilities(t *testing.T) {
+	// newServer returns a REPL for a stub server that answers the
+	// /capabilities requests with capabilities, or 404 if it is empty like
+	// older servers, and the cancel requests it received.
+	newServer := func(t *testing.T, capabilities string) (*Repl, *[]string) {
+		canceled := []string{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/capabilities" && capabilities != "":
+				_, _ = w.Write([]byte(capabilities))
+			case r.Method == http.MethodDelete:
+				canceled = append(canceled, r.URL.Path)
+				_, _ = w.Write([]byte(`{"canceled":true}`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		t.Cleanup(ts.Close)
+
+		return newTxTestRepl(t, ts), &canceled
+	}
+
+	t.Run("Full featured server", func(t *testing.T) {
+		r, canceled := newServer(t, `{"version":"v0.2.0","capabilities":["namedParams","backup","multiDb","cancel"]}`)
+
+		assert.True(t, r.requireCapability(apiclient.CapabilityCancel))
+		assert.True(t, r.requireCapability(apiclient.CapabilityBackup))
+
+		cmdCancel(r, " abc")
+		assert.Equal(t, []string{"/query/abc"}, *canceled)
+	})
+
+	t.Run("Older server", func(t *testing.T) {
+		r, canceled := newServer(t, "")
+
+		assert.True(t, r.requireCapability(apiclient.CapabilityNamedParams))
+		assert.False(t, r.requireCapability(apiclient.CapabilityCancel))
+		assert.False(t, r.requireCapability(apiclient.CapabilityBackup))
+
+		cmdCancel(r, " abc")
+		assert.Empty(t, *canceled, "the request is not sent")
+	})
+}
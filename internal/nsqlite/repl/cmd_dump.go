@@ -0,0 +1,352 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// dumpBatchSize is the number of rows fetched per request when dumping
+// the data of a table.
+const dumpBatchSize = 1000
+
+func cmdDump(r *Repl, args string) {
+	tables, outPath, err := parseDumpArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	dump := func(w io.Writer) error {
+		return dumpDatabase(ctx, r.client, r.txId, w, os.Stderr, tables)
+	}
+
+	if outPath == "" {
+		bw := bufio.NewWriter(os.Stdout)
+		if err := dump(bw); err != nil {
+			fmt.Println("Failed to dump database:", err)
+			return
+		}
+		if err := bw.Flush(); err != nil {
+			fmt.Println("Failed to write dump:", err)
+			return
+		}
+		fmt.Println()
+		return
+	}
+
+	if err := writeDumpFile(outPath, dump); err != nil {
+		fmt.Println("Failed to dump database:", err)
+		return
+	}
+	fmt.Printf("Database dumped to %s\n", outPath)
+	fmt.Println()
+}
+
+// writeDumpFile writes the dump to a temporary file next to path, renamed
+// to path once the dump is complete, so a failed dump does not leave a
+// truncated file or replace an existing one.
+func writeDumpFile(path string, dump func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	bw := bufio.NewWriter(tmp)
+	if err := dump(bw); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	err = bw.Flush()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save dump: %w", err)
+	}
+	return nil
+}
+
+// parseDumpArgs parses the arguments of the .dump command in the format
+// "[table ...] [> file]". Without ">", a last argument ending in ".sql" is
+// also used as the output file.
+func parseDumpArgs(args string) ([]string, string, error) {
+	tablesPart, outPath, hasOut := strings.Cut(args, ">")
+	outPath = strings.TrimSpace(outPath)
+	if hasOut && outPath == "" {
+		return nil, "", errors.New("missing output file after >")
+	}
+
+	tables := strings.Fields(tablesPart)
+	if !hasOut && len(tables) > 0 && strings.HasSuffix(tables[len(tables)-1], ".sql") {
+		outPath = tables[len(tables)-1]
+		tables = tables[:len(tables)-1]
+	}
+
+	return tables, outPath, nil
+}
+
+// dumpDatabase writes the SQL text needed to recreate the given tables (or
+// all of them if none is given) with their data, indexes, triggers and views.
+// Progress for large tables is written to progress.
+//
+// The dump is read in the transaction txId, or in a transaction of its own
+// if it is empty, so the schema and the rows are read from the same version
+// of the database.
+func dumpDatabase(
+	ctx context.Context, client queryClient, txId string,
+	w io.Writer, progress io.Writer, tables []string,
+) error {
+	if txId != "" {
+		return writeDump(ctx, client, txId, w, progress, tables)
+	}
+
+	res, err := sendQuery(ctx, client, "BEGIN")
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	txId = res.TxId
+
+	if err := writeDump(ctx, client, txId, w, progress, tables); err != nil {
+		// The transaction is rolled back even if the context has been
+		// canceled, so it isn't left open in the server.
+		_, _ = sendTxQuery(context.WithoutCancel(ctx), client, txId, "ROLLBACK")
+		return err
+	}
+	if _, err := sendTxQuery(ctx, client, txId, "COMMIT"); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// writeDump writes the dump of dumpDatabase, reading it in the transaction
+// txId.
+func writeDump(
+	ctx context.Context, client queryClient, txId string,
+	w io.Writer, progress io.Writer, tables []string,
+) error {
+	filter, filterParams := dumpTableFilter("name", tables)
+	res, err := sendTxQuery(ctx, client, txId, `
+		SELECT name, sql FROM sqlite_master
+		WHERE type = 'table' AND sql IS NOT NULL `+filter+`
+		ORDER BY name
+	`, filterParams...)
+	if err != nil {
+		return fmt.Errorf("failed to read tables: %w", err)
+	}
+
+	fmt.Fprintln(w, "PRAGMA foreign_keys=OFF;")
+	fmt.Fprintln(w, "BEGIN TRANSACTION;")
+
+	hasSequence := false
+	for _, row := range res.Rows {
+		name, sql := fmt.Sprint(row[0]), fmt.Sprint(row[1])
+
+		if name == "sqlite_sequence" {
+			hasSequence = true
+			continue
+		}
+		if strings.HasPrefix(name, "sqlite_") {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s;\n", sql)
+		if strings.HasPrefix(strings.ToUpper(sql), "CREATE VIRTUAL TABLE") {
+			continue
+		}
+
+		if err := dumpTableData(ctx, client, txId, w, progress, name); err != nil {
+			return fmt.Errorf("failed to dump data of %s: %w", name, err)
+		}
+	}
+
+	if hasSequence {
+		fmt.Fprintln(w, "DELETE FROM sqlite_sequence;")
+		if err := dumpTableData(ctx, client, txId, w, progress, "sqlite_sequence"); err != nil {
+			return fmt.Errorf("failed to dump data of sqlite_sequence: %w", err)
+		}
+	}
+
+	filter, filterParams = dumpTableFilter("tbl_name", tables)
+	res, err = sendTxQuery(ctx, client, txId, `
+		SELECT sql FROM sqlite_master
+		WHERE type IN ('index', 'trigger', 'view') AND sql IS NOT NULL `+filter+`
+		ORDER BY CASE type WHEN 'view' THEN 1 ELSE 0 END, name
+	`, filterParams...)
+	if err != nil {
+		return fmt.Errorf("failed to read indexes, triggers and views: %w", err)
+	}
+	for _, row := range res.Rows {
+		fmt.Fprintf(w, "%s;\n", fmt.Sprint(row[0]))
+	}
+
+	fmt.Fprintln(w, "COMMIT;")
+	return nil
+}
+
+// dumpTableData writes one INSERT statement per row of the given table. The
+// values are converted to SQL literals by the server using quote(), so blobs
+// are written as X'..' literals.
+//
+// The rows are read in batches ordered by the rowid, or by the primary key
+// for the tables WITHOUT ROWID, each batch starting after the key of the
+// last row of the previous one.
+func dumpTableData(
+	ctx context.Context, client queryClient, txId string,
+	w io.Writer, progress io.Writer, table string,
+) error {
+	res, err := sendTxQuery(
+		ctx, client, txId, `SELECT name, pk FROM pragma_table_info(:table_name)`,
+		nsqlitehttp.QueryParam{Name: "table_name", Value: table},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read columns: %w", err)
+	}
+	if len(res.Rows) == 0 {
+		return nil
+	}
+	columns := res.Rows
+
+	quotedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = "quote(" + quoteIdentifier(fmt.Sprint(column[0])) + ")"
+	}
+	valuesExpr := strings.Join(quotedColumns, " || ',' || ")
+	quotedTable := quoteIdentifier(table)
+
+	keys, err := dumpTableKeys(ctx, client, txId, table, columns)
+	if err != nil {
+		return err
+	}
+	keysExpr := strings.Join(keys, ", ")
+	quotedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		quotedKeys[i] = "quote(" + key + ")"
+	}
+
+	res, err = sendTxQuery(ctx, client, txId, `SELECT COUNT(*) FROM `+quotedTable)
+	if err != nil {
+		return fmt.Errorf("failed to count rows: %w", err)
+	}
+	total, err := strconv.ParseInt(fmt.Sprint(res.Rows[0][0]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse row count: %w", err)
+	}
+	showProgress := total > dumpBatchSize
+
+	after := ""
+	dumped := int64(0)
+	for {
+		res, err = sendTxQuery(ctx, client, txId, fmt.Sprintf(
+			`SELECT %s, %s FROM %s %s ORDER BY %s LIMIT %d`,
+			strings.Join(quotedKeys, ", "), valuesExpr, quotedTable, after, keysExpr, dumpBatchSize,
+		))
+		if err != nil {
+			return fmt.Errorf("failed to read rows: %w", err)
+		}
+
+		for _, row := range res.Rows {
+			fmt.Fprintf(w, "INSERT INTO %s VALUES(%s);\n", quotedTable, fmt.Sprint(row[len(keys)]))
+		}
+		dumped += int64(len(res.Rows))
+
+		if showProgress {
+			fmt.Fprintf(progress, "\rDumping %s: %d/%d rows", table, dumped, total)
+		}
+		if len(res.Rows) < dumpBatchSize {
+			break
+		}
+
+		// The keys are quoted by the server, so they are valid literals.
+		last := res.Rows[len(res.Rows)-1]
+		lastKeys := make([]string, len(keys))
+		for i := range keys {
+			lastKeys[i] = fmt.Sprint(last[i])
+		}
+		after = fmt.Sprintf("WHERE (%s) > (%s)", keysExpr, strings.Join(lastKeys, ", "))
+	}
+
+	if showProgress {
+		fmt.Fprintln(progress)
+	}
+	return nil
+}
+
+// dumpTableKeys returns the quoted columns that identify the rows of the
+// table, given its columns as the name and pk of pragma_table_info: the
+// columns of the primary key for the tables WITHOUT ROWID, or a name of the
+// rowid not used by any of the columns otherwise.
+func dumpTableKeys(
+	ctx context.Context, client queryClient, txId string, table string, columns [][]any,
+) ([]string, error) {
+	res, err := sendTxQuery(
+		ctx, client, txId,
+		`SELECT wr FROM pragma_table_list WHERE schema = 'main' AND name = :table_name`,
+		nsqlitehttp.QueryParam{Name: "table_name", Value: table},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read table: %w", err)
+	}
+
+	if len(res.Rows) > 0 && fmt.Sprint(res.Rows[0][0]) == "1" {
+		keys := []string{}
+		for pk := 1; ; pk++ {
+			i := slices.IndexFunc(columns, func(column []any) bool {
+				return fmt.Sprint(column[1]) == strconv.Itoa(pk)
+			})
+			if i < 0 {
+				return keys, nil
+			}
+			keys = append(keys, quoteIdentifier(fmt.Sprint(columns[i][0])))
+		}
+	}
+
+	for _, rowid := range []string{"rowid", "_rowid_", "oid"} {
+		used := slices.ContainsFunc(columns, func(column []any) bool {
+			return strings.EqualFold(fmt.Sprint(column[0]), rowid)
+		})
+		if !used {
+			return []string{rowid}, nil
+		}
+	}
+	return nil, errors.New("the names of the rowid are all used by columns")
+}
+
+// dumpTableFilter returns the SQL condition and params to filter the given
+// column by the tables, or an empty filter if there are no tables.
+func dumpTableFilter(column string, tables []string) (string, []nsqlitehttp.QueryParam) {
+	if len(tables) == 0 {
+		return "", nil
+	}
+
+	placeholders := make([]string, len(tables))
+	params := make([]nsqlitehttp.QueryParam, len(tables))
+	for i, table := range tables {
+		placeholders[i] = "?"
+		params[i] = nsqlitehttp.QueryParam{Value: table}
+	}
+
+	return fmt.Sprintf("AND %s IN (%s)", column, strings.Join(placeholders, ", ")), params
+}
+
+// quoteIdentifier quotes an SQLite identifier using double quotes.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,207 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpDatabase(t *testing.T) {
+	ctx := context.Background()
+	source := openTestConn(t, `
+		CREATE TABLE users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			score REAL,
+			avatar BLOB
+		);
+		CREATE INDEX users_name_idx ON users (name);
+		CREATE TABLE "odd ""table""" ("my col" TEXT);
+		CREATE VIEW users_view AS SELECT name FROM users;
+		INSERT INTO users (name, score, avatar) VALUES
+			('alice', 1.5, X'00FF10'),
+			('o''brien', NULL, NULL),
+			(NULL, -2, X'');
+		INSERT INTO "odd ""table""" ("my col")
+			WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2500)
+			SELECT 'row ' || i FROM n;
+	`)
+
+	dump := func(t *testing.T, tables []string) string {
+		out := bytes.Buffer{}
+		progress := bytes.Buffer{}
+		err := dumpDatabase(ctx, fakeQueryClient{conn: source}, "", &out, &progress, tables)
+		require.NoError(t, err)
+		return out.String()
+	}
+
+	countRows := func(t *testing.T, conn *sqlitec.Conn, table string) any {
+		return queryValue(t, conn, `SELECT COUNT(*) FROM `+quoteIdentifier(table))
+	}
+
+	t.Run("Full dump restores the database", func(t *testing.T) {
+		out := dump(t, nil)
+		assert.Contains(t, out, `X'00FF10'`)
+		assert.Contains(t, out, `INSERT INTO "odd ""table""" VALUES('row 1');`)
+
+		target := openTestConn(t, out)
+
+		for _, table := range []string{"users", `odd "table"`, "sqlite_sequence"} {
+			assert.Equal(t, countRows(t, source, table), countRows(t, target, table), table)
+		}
+
+		avatar := queryValue(t, target, `SELECT avatar FROM users WHERE name = 'alice'`)
+		assert.Equal(t, []byte{0x00, 0xFF, 0x10}, avatar)
+
+		name := queryValue(t, target, `SELECT name FROM users WHERE score IS NULL`)
+		assert.Equal(t, "o'brien", name)
+
+		objects := queryValue(t, target, `
+			SELECT COUNT(*) FROM sqlite_master WHERE name IN ('users_name_idx', 'users_view')
+		`)
+		assert.EqualValues(t, 2, objects)
+	})
+
+	t.Run("Dump of given tables", func(t *testing.T) {
+		out := dump(t, []string{"users"})
+		assert.Contains(t, out, "CREATE INDEX users_name_idx")
+		assert.NotContains(t, out, "odd")
+		assert.NotContains(t, out, "sqlite_sequence")
+
+		target := openTestConn(t, out)
+		assert.EqualValues(t, 3, countRows(t, target, "users"))
+	})
+
+	t.Run("Missing table has no data", func(t *testing.T) {
+		out := bytes.Buffer{}
+		err := dumpTableData(ctx, fakeQueryClient{conn: source}, "", &out, &bytes.Buffer{}, "missing")
+		assert.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("Rows read by key in one transaction", func(t *testing.T) {
+		source := openTestConn(t, `
+			CREATE TABLE pairs (a INTEGER, b TEXT, PRIMARY KEY (a, b)) WITHOUT ROWID;
+			INSERT INTO pairs
+				WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2500)
+				SELECT i % 3, 'b' || i FROM n;
+			CREATE TABLE shadowed (rowid TEXT);
+			INSERT INTO shadowed
+				WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1500)
+				SELECT 'same' FROM n;
+		`)
+		client := &recordingQueryClient{fakeQueryClient: fakeQueryClient{conn: source}}
+
+		out := bytes.Buffer{}
+		require.NoError(t, dumpDatabase(ctx, client, "", &out, &bytes.Buffer{}, nil))
+
+		target := openTestConn(t, out.String())
+		assert.EqualValues(t, 2500, countRows(t, target, "pairs"))
+		assert.EqualValues(t, 1500, countRows(t, target, "shadowed"))
+
+		require.GreaterOrEqual(t, len(client.queries), 2)
+		assert.Equal(t, "BEGIN", client.queries[0])
+		assert.Equal(t, "COMMIT", client.queries[len(client.queries)-1])
+		for i, query := range client.queries[1:] {
+			assert.Equal(t, "fake-tx", client.txIds[i+1], query)
+			assert.NotContains(t, query, "OFFSET")
+		}
+	})
+
+	t.Run("Open transaction of the REPL", func(t *testing.T) {
+		client := &recordingQueryClient{fakeQueryClient: fakeQueryClient{conn: source}}
+
+		require.NoError(t, dumpDatabase(ctx, client, "repl-tx", &bytes.Buffer{}, &bytes.Buffer{}, []string{"users"}))
+		assert.NotContains(t, client.queries, "BEGIN")
+		assert.NotContains(t, client.queries, "COMMIT")
+		for _, txId := range client.txIds {
+			assert.Equal(t, "repl-tx", txId)
+		}
+	})
+}
+
+func TestWriteDumpFile(t *testing.T) {
+	t.Run("Complete dump", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.sql")
+		err := writeDumpFile(path, func(w io.Writer) error {
+			_, err := io.WriteString(w, "CREATE TABLE t (id INTEGER);\n")
+			return err
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE t (id INTEGER);\n", string(data))
+	})
+
+	t.Run("Failed dump keeps the existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "dump.sql")
+		require.NoError(t, os.WriteFile(path, []byte("previous"), 0o644))
+
+		err := writeDumpFile(path, func(w io.Writer) error {
+			_, _ = io.WriteString(w, "CREATE TABLE t (id INTEGER);\n")
+			return errors.New("connection lost")
+		})
+		assert.ErrorContains(t, err, "connection lost")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "previous", string(data))
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "the temporary file is removed")
+	})
+
+	t.Run("Failed dump leaves no file", func(t *testing.T) {
+		dir := t.TempDir()
+		err := writeDumpFile(filepath.Join(dir, "dump.sql"), func(w io.Writer) error {
+			return errors.New("connection lost")
+		})
+		assert.Error(t, err)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestParseDumpArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		tables  []string
+		outPath string
+		wantErr bool
+	}{
+		{args: "", tables: []string{}, outPath: ""},
+		{args: " users posts", tables: []string{"users", "posts"}, outPath: ""},
+		{args: " > out.sql", tables: []string{}, outPath: "out.sql"},
+		{args: " users > out.sql", tables: []string{"users"}, outPath: "out.sql"},
+		{args: " users out.sql", tables: []string{"users"}, outPath: "out.sql"},
+		{args: " users >", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", strings.TrimSpace(tt.args)), func(t *testing.T) {
+			tables, outPath, err := parseDumpArgs(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.tables, tables)
+			assert.Equal(t, tt.outPath, outPath)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+func cmdExplain(r *Repl, args string) {
+	query := strings.TrimSpace(args)
+	if query == "" {
+		fmt.Println("Usage: .explain query")
+		return
+	}
+
+	params, missing := bindParams(r.params, query)
+	for _, placeholder := range missing {
+		fmt.Printf("Warning: no value set for %s, it is bound as NULL\n", placeholder)
+	}
+
+	if err := showQueryPlan(r, query, params); err != nil {
+		fmt.Println("Failed to explain query:", err)
+	}
+}
+
+func cmdEqp(r *Repl, args string) {
+	switch strings.TrimSpace(args) {
+	case "on":
+		r.eqp = true
+	case "off":
+		r.eqp = false
+	case "":
+		fmt.Printf("Automatic query plans are %s\n", onOff(r.eqp))
+	default:
+		fmt.Println("Usage: .eqp on|off")
+	}
+}
+
+// showQueryPlan writes the query plan of the query as a tree. Nothing is
+// written for the statements without a plan.
+func showQueryPlan(r *Repl, query string, params []nsqlitehttp.QueryParam) error {
+	ctx, done := r.commandContext()
+	defer done()
+
+	nodes, err := explainPlan(ctx, r.api, r.client, r.txId, query, params)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	r.writePaged([]byte(renderPlan(nodes, r.output == os.Stdout)))
+	return nil
+}
+
+// isExplainStatement reports whether the statement is already an EXPLAIN, so
+// it is not explained again with .eqp on.
+func isExplainStatement(statement string) bool {
+	fields := strings.Fields(statement)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "EXPLAIN")
+}
+
+// explainPlan returns the query plan of the query. It uses the explain
+// endpoint of the server and, if the server doesn't have it, falls back to
+// running EXPLAIN QUERY PLAN and building the plan from the returned rows.
+func explainPlan(
+	ctx context.Context,
+	api *apiclient.Client,
+	client queryClient,
+	txId string,
+	query string,
+	params []nsqlitehttp.QueryParam,
+) ([]apiclient.PlanNode, error) {
+	nodes, err := api.Explain(ctx, query, params, txId)
+	if err == nil {
+		return nodes, nil
+	}
+
+	serverErr := &apiclient.ServerError{}
+	if !errors.As(err, &serverErr) || !isUnsupportedEndpoint(serverErr.Status) {
+		return nil, err
+	}
+
+	res, err := sendTxQuery(ctx, client, txId, "EXPLAIN QUERY PLAN "+query, params...)
+	if err != nil {
+		return nil, err
+	}
+	return planFromRows(res.Columns, res.Rows), nil
+}
+
+// isUnsupportedEndpoint reports whether the status means that the server
+// doesn't have the requested endpoint.
+func isUnsupportedEndpoint(status int) bool {
+	return status == http.StatusNotFound ||
+		status == http.StatusMethodNotAllowed ||
+		status == http.StatusNotImplemented
+}
+
+// planFromRows builds the query plan from the rows of EXPLAIN QUERY PLAN,
+// which have the id, parent, notused and detail columns.
+func planFromRows(columns []string, rows [][]any) []apiclient.PlanNode {
+	idCol, parentCol, detailCol := 0, 1, 3
+	for i, column := range columns {
+		switch strings.ToLower(column) {
+		case "id":
+			idCol = i
+		case "parent":
+			parentCol = i
+		case "detail":
+			detailCol = i
+		}
+	}
+
+	nodes := make([]apiclient.PlanNode, 0, len(rows))
+	for _, row := range rows {
+		if len(row) <= max(idCol, parentCol, detailCol) {
+			continue
+		}
+		id, _, _ := numericValue(row[idCol])
+		parent, _, _ := numericValue(row[parentCol])
+		nodes = append(nodes, apiclient.PlanNode{
+			ID:     int(id),
+			Parent: int(parent),
+			Detail: fmt.Sprint(row[detailCol]),
+		})
+	}
+	return nodes
+}
+
+// renderPlan renders the query plan as an indented tree under a "QUERY PLAN"
+// title. Nodes whose parent is not in the plan are shown at the top level.
+// If highlight is true, full scans are shown in red and searches in green.
+func renderPlan(nodes []apiclient.PlanNode, highlight bool) string {
+	ids := map[int]bool{}
+	for _, node := range nodes {
+		ids[node.ID] = true
+	}
+
+	children := map[int][]apiclient.PlanNode{}
+	roots := []apiclient.PlanNode{}
+	for _, node := range nodes {
+		if node.Parent == node.ID || !ids[node.Parent] {
+			roots = append(roots, node)
+			continue
+		}
+		children[node.Parent] = append(children[node.Parent], node)
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("QUERY PLAN\n")
+
+	var writeNodes func(nodes []apiclient.PlanNode, prefix string)
+	writeNodes = func(nodes []apiclient.PlanNode, prefix string) {
+		for i, node := range nodes {
+			branch, indent := "├── ", "│   "
+			if i == len(nodes)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			detail := node.Detail
+			if highlight {
+				detail = highlightPlanDetail(detail)
+			}
+			sb.WriteString(prefix + branch + detail + "\n")
+
+			// The IDs are unique in a plan, deleting the children makes sure
+			// that a malformed plan with cycles is not written forever.
+			nested := children[node.ID]
+			delete(children, node.ID)
+			writeNodes(nested, prefix+indent)
+		}
+	}
+	writeNodes(roots, "")
+
+	return sb.String()
+}
+
+// highlightPlanDetail colors the detail of a plan node: full table scans in
+// red and index searches in green.
+func highlightPlanDetail(detail string) string {
+	switch {
+	case strings.HasPrefix(detail, "SCAN"):
+		return styled.DangerColor().Sprint(detail)
+	case strings.HasPrefix(detail, "SEARCH") && strings.Contains(detail, " USING "):
+		return styled.SuccessColor().Sprint(detail)
+	}
+	return detail
+}
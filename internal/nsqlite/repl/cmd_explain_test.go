@@ -0,0 +1,119 @@
+package repl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []apiclient.PlanNode
+		want  string
+	}{
+		{
+			name:  "Single node",
+			nodes: []apiclient.PlanNode{{ID: 2, Parent: 0, Detail: "SCAN users"}},
+			want:  "QUERY PLAN\n└── SCAN users\n",
+		},
+		{
+			name: "Nested nodes",
+			nodes: []apiclient.PlanNode{
+				{ID: 2, Parent: 0, Detail: "SEARCH users USING INTEGER PRIMARY KEY (rowid=?)"},
+				{ID: 5, Parent: 0, Detail: "CORRELATED SCALAR SUBQUERY 1"},
+				{ID: 9, Parent: 5, Detail: "SEARCH posts USING INDEX posts_user_id (user_id=?)"},
+				{ID: 12, Parent: 5, Detail: "USE TEMP B-TREE FOR ORDER BY"},
+				{ID: 20, Parent: 0, Detail: "SCAN tags"},
+			},
+			want: "QUERY PLAN\n" +
+				"├── SEARCH users USING INTEGER PRIMARY KEY (rowid=?)\n" +
+				"├── CORRELATED SCALAR SUBQUERY 1\n" +
+				"│   ├── SEARCH posts USING INDEX posts_user_id (user_id=?)\n" +
+				"│   └── USE TEMP B-TREE FOR ORDER BY\n" +
+				"└── SCAN tags\n",
+		},
+		{
+			name: "Unknown parent",
+			nodes: []apiclient.PlanNode{
+				{ID: 3, Parent: 1, Detail: "SCAN a"},
+				{ID: 4, Parent: 3, Detail: "SCAN b"},
+			},
+			want: "QUERY PLAN\n└── SCAN a\n    └── SCAN b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, renderPlan(tt.nodes, false))
+		})
+	}
+}
+
+func TestPlanFromRows(t *testing.T) {
+	nodes := planFromRows(
+		[]string{"id", "parent", "notused", "detail"},
+		[][]any{
+			{json.Number("2"), json.Number("0"), json.Number("0"), "SCAN users"},
+			{json.Number("7"), json.Number("2"), json.Number("0"), "SEARCH posts USING INDEX posts_user_id (user_id=?)"},
+		},
+	)
+	assert.Equal(t, []apiclient.PlanNode{
+		{ID: 2, Parent: 0, Detail: "SCAN users"},
+		{ID: 7, Parent: 2, Detail: "SEARCH posts USING INDEX posts_user_id (user_id=?)"},
+	}, nodes)
+}
+
+func TestExplainPlan(t *testing.T) {
+	newAPI := func(t *testing.T, handler http.HandlerFunc) *apiclient.Client {
+		ts := httptest.NewServer(handler)
+		t.Cleanup(ts.Close)
+		connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+		require.NoError(t, err)
+		return apiclient.NewClient(connStr)
+	}
+
+	conn := openTestConn(t, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);
+		CREATE INDEX users_email ON users (email);
+	`)
+	client := fakeQueryClient{conn: conn}
+
+	t.Run("Structured explain", func(t *testing.T) {
+		api := newAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/explain", r.URL.Path)
+			_, _ = w.Write([]byte(`{"plan":[{"id":3,"parent":0,"detail":"SCAN users"}]}`))
+		})
+
+		nodes, err := explainPlan(context.Background(), api, client, "", "SELECT * FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []apiclient.PlanNode{{ID: 3, Parent: 0, Detail: "SCAN users"}}, nodes)
+	})
+
+	t.Run("Fallback to EXPLAIN QUERY PLAN", func(t *testing.T) {
+		api := newAPI(t, http.NotFound)
+
+		nodes, err := explainPlan(
+			context.Background(), api, client, "", "SELECT * FROM users WHERE email = 'a'", nil,
+		)
+		require.NoError(t, err)
+		require.Len(t, nodes, 1)
+		assert.Contains(t, nodes[0].Detail, "USING COVERING INDEX users_email")
+	})
+
+	t.Run("Server error", func(t *testing.T) {
+		api := newAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"message":"no such table: missing"}`, http.StatusBadRequest)
+		})
+
+		_, err := explainPlan(context.Background(), api, client, "", "SELECT * FROM missing", nil)
+		assert.ErrorContains(t, err, "no such table: missing")
+	})
+}
@@ -0,0 +1,396 @@
+package repl
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// exportPageSize is the number of rows fetched per request when exporting
+// the result of a query that can be paginated.
+const exportPageSize = 1000
+
+// exportFormats are the formats supported by .export.
+var exportFormats = []string{"csv", "json", "ndjson", "sql-inserts"}
+
+// exportOptions are the options of the .export command.
+type exportOptions struct {
+	format string
+	path   string
+	query  string
+	// table is the table used in the INSERT statements of the sql-inserts
+	// format.
+	table string
+	// force overwrites the file without asking.
+	force bool
+}
+
+func cmdExport(r *Repl, args string) {
+	opts, err := parseExportArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if _, err := os.Stat(opts.path); err == nil && !opts.force {
+		if !r.confirm(fmt.Sprintf("The file %s already exists, overwrite it?", opts.path)) {
+			fmt.Println("Export canceled")
+			return
+		}
+	}
+
+	params, missing := bindParams(r.params, opts.query)
+	for _, placeholder := range missing {
+		fmt.Printf("Warning: no value set for %s, it is bound as NULL\n", placeholder)
+	}
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	start := time.Now()
+	rows, size, err := exportQuery(ctx, r.client, r.txId, opts, params, os.Stderr)
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("Export canceled")
+		return
+	}
+	if err != nil {
+		fmt.Println("Failed to export:", err)
+		return
+	}
+
+	fmt.Printf("Exported %s to %s (%s)\n", pluralize(rows, "row"), opts.path, numutil.Bytes(size))
+	styled.DimmedColor().Printf("Total time: %s\n", formatDuration(time.Since(start)))
+	fmt.Println()
+}
+
+// parseExportArgs parses the arguments of the .export command in the format
+// "[--force] [--table name] format path query".
+func parseExportArgs(args string) (exportOptions, error) {
+	usage := fmt.Errorf(
+		"usage: .export [--force] [--table name] format path query, format is one of %s",
+		strings.Join(exportFormats, ", "),
+	)
+
+	opts := exportOptions{table: "export"}
+	rest := strings.TrimSpace(args)
+	next := func() string {
+		field, tail, _ := strings.Cut(rest, " ")
+		rest = strings.TrimSpace(tail)
+		return field
+	}
+
+	for strings.HasPrefix(rest, "--") {
+		switch flag := next(); flag {
+		case "--force":
+			opts.force = true
+		case "--table":
+			opts.table = next()
+			if opts.table == "" {
+				return exportOptions{}, usage
+			}
+		default:
+			return exportOptions{}, fmt.Errorf("unknown option: %s", flag)
+		}
+	}
+
+	opts.format = strings.ToLower(next())
+	opts.path = next()
+	opts.query = rest
+	if !slices.Contains(exportFormats, opts.format) || opts.path == "" || opts.query == "" {
+		return exportOptions{}, usage
+	}
+	return opts, nil
+}
+
+// exportQuery writes the result of the query to the file of the options and
+// returns the number of exported rows and the size of the file. Queries that
+// return rows and can be wrapped in a subquery are fetched in pages of
+// exportPageSize rows, so big results are streamed, and the progress is
+// written to progress. The result is written to a temporary file that is
+// only renamed to the path once complete.
+func exportQuery(
+	ctx context.Context, client queryClient, txId string, opts exportOptions,
+	params []nsqlitehttp.QueryParam, progress io.Writer,
+) (int64, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(opts.path), "."+filepath.Base(opts.path)+".*.tmp")
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	bw := bufio.NewWriter(tmp)
+	rows, err := writeExport(ctx, client, txId, opts, params, bw, progress)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read file info: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), opts.path); err != nil {
+		return 0, 0, fmt.Errorf("failed to save file: %w", err)
+	}
+	return rows, info.Size(), nil
+}
+
+// writeExport fetches the result of the query and writes it to w in the
+// format of the options, returning the number of written rows.
+func writeExport(
+	ctx context.Context, client queryClient, txId string, opts exportOptions,
+	params []nsqlitehttp.QueryParam, w io.Writer, progress io.Writer,
+) (int64, error) {
+	query := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(opts.query), ";"))
+	paginated := isPaginableQuery(query)
+
+	var exporter *resultExporter
+	for offset := int64(0); ; offset += exportPageSize {
+		pageQuery := query
+		if paginated {
+			pageQuery = fmt.Sprintf("SELECT * FROM (%s) LIMIT %d OFFSET %d", query, exportPageSize, offset)
+		}
+
+		res, err := sendTxQuery(ctx, client, txId, pageQuery, params...)
+		if err != nil {
+			return 0, err
+		}
+		if exporter == nil {
+			exporter = newResultExporter(w, opts.format, opts.table, res.Columns, res.Types)
+			if err := exporter.begin(); err != nil {
+				return 0, fmt.Errorf("failed to write file: %w", err)
+			}
+		}
+		for _, row := range res.Rows {
+			if err := exporter.write(row); err != nil {
+				return 0, fmt.Errorf("failed to write file: %w", err)
+			}
+		}
+
+		if !paginated || len(res.Rows) < exportPageSize {
+			break
+		}
+		fmt.Fprintf(progress, "\rExported %s", pluralize(exporter.rows, "row"))
+	}
+	if exporter.rows >= exportPageSize {
+		fmt.Fprintln(progress)
+	}
+
+	if err := exporter.end(); err != nil {
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+	return exporter.rows, nil
+}
+
+// isPaginableQuery reports whether the query is a single SELECT, VALUES or
+// WITH statement that can be wrapped in a subquery to fetch it in pages.
+func isPaginableQuery(query string) bool {
+	statements, rest, _ := splitStatements(query)
+	if len(statements) != 0 {
+		return false
+	}
+
+	tokens := tokenizeSQL(rest.text)
+	if len(tokens) == 0 {
+		return false
+	}
+	if tokens[0].is("WITH") {
+		tokens = skipWithClause(tokens)
+	}
+	return len(tokens) > 0 && (tokens[0].is("SELECT") || tokens[0].is("VALUES"))
+}
+
+// resultExporter writes the rows of a query result in one of the export
+// formats.
+type resultExporter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	format  string
+	table   string
+	columns []string
+	types   []string
+	rows    int64
+}
+
+// newResultExporter creates a resultExporter that writes the rows with the
+// given columns to w.
+func newResultExporter(w io.Writer, format string, table string, columns []string, types []string) *resultExporter {
+	return &resultExporter{
+		w:       w,
+		csv:     csv.NewWriter(w),
+		format:  format,
+		table:   table,
+		columns: columns,
+		types:   types,
+	}
+}
+
+// begin writes what comes before the rows: the CSV header or the opening
+// bracket of the JSON array.
+func (e *resultExporter) begin() error {
+	switch e.format {
+	case "csv":
+		return e.csv.Write(e.columns)
+	case "json":
+		_, err := io.WriteString(e.w, "[")
+		return err
+	}
+	return nil
+}
+
+// write writes a row.
+func (e *resultExporter) write(row []any) error {
+	var err error
+	switch e.format {
+	case "csv":
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = e.csvValue(value, columnType(e.types, i))
+		}
+		err = e.csv.Write(record)
+	case "json", "ndjson":
+		prefix, suffix := "", "\n"
+		if e.format == "json" {
+			prefix, suffix = "\n  ", ""
+			if e.rows > 0 {
+				prefix = ",\n  "
+			}
+		}
+		var object string
+		object, err = e.jsonObject(row)
+		if err == nil {
+			_, err = io.WriteString(e.w, prefix+object+suffix)
+		}
+	case "sql-inserts":
+		values := make([]string, len(row))
+		for i, value := range row {
+			values[i] = e.sqlLiteral(value, columnType(e.types, i))
+		}
+		_, err = fmt.Fprintf(e.w, "INSERT INTO %s VALUES(%s);\n", quoteIdentifier(e.table), strings.Join(values, ","))
+	}
+	if err != nil {
+		return err
+	}
+
+	e.rows++
+	return nil
+}
+
+// end writes what comes after the rows.
+func (e *resultExporter) end() error {
+	switch e.format {
+	case "csv":
+		e.csv.Flush()
+		return e.csv.Error()
+	case "json":
+		closing := "]\n"
+		if e.rows > 0 {
+			closing = "\n]\n"
+		}
+		_, err := io.WriteString(e.w, closing)
+		return err
+	}
+	return nil
+}
+
+// csvValue formats a value as a CSV field, NULL values are empty fields.
+func (e *resultExporter) csvValue(value any, typ string) string {
+	if value == nil {
+		return ""
+	}
+	if data, ok := blobValue(value, typ); ok {
+		return "X'" + strings.ToUpper(hex.EncodeToString(data)) + "'"
+	}
+	return formatOutputValue(value, typ)
+}
+
+// jsonObject formats a row as a JSON object keyed by column name, keeping
+// the order of the columns. Blobs are base64 strings.
+func (e *resultExporter) jsonObject(row []any) (string, error) {
+	sb := strings.Builder{}
+	sb.WriteString("{")
+	for i, column := range e.columns {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+
+		key, err := json.Marshal(column)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode column name: %w", err)
+		}
+		value := row[i]
+		if data, ok := value.([]byte); ok {
+			value = base64.StdEncoding.EncodeToString(data)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode value of %s: %w", column, err)
+		}
+
+		sb.Write(key)
+		sb.WriteString(":")
+		sb.Write(encoded)
+	}
+	sb.WriteString("}")
+	return sb.String(), nil
+}
+
+// sqlLiteral formats a value as an SQLite literal.
+func (e *resultExporter) sqlLiteral(value any, typ string) string {
+	if value == nil {
+		return "NULL"
+	}
+	if data, ok := blobValue(value, typ); ok {
+		return "X'" + strings.ToUpper(hex.EncodeToString(data)) + "'"
+	}
+	if number, isInt, ok := numericValue(value); ok {
+		if isInt {
+			return strconv.FormatInt(int64(number), 10)
+		}
+		return strconv.FormatFloat(number, 'g', -1, 64)
+	}
+	if b, ok := value.(bool); ok {
+		if b {
+			return "1"
+		}
+		return "0"
+	}
+	return "'" + strings.ReplaceAll(fmt.Sprint(value), "'", "''") + "'"
+}
+
+// blobValue returns the bytes of a blob value, received from the server as
+// a base64 string in a BLOB column.
+func blobValue(value any, typ string) ([]byte, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return v, true
+	case string:
+		if typ != "BLOB" {
+			return nil, false
+		}
+		data, err := base64.StdEncoding.DecodeString(v)
+		return data, err == nil
+	}
+	return nil, false
+}
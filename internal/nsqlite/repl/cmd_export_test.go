@@ -0,0 +1,238 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportFixture = `
+CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, price REAL, data BLOB);
+INSERT INTO items VALUES
+	(1, 'plain', 1.5, X'00FF10'),
+	(2, 'it''s, "quoted"', NULL, NULL),
+	(3, NULL, -2, X'');
+CREATE TABLE numbers (n INTEGER);
+INSERT INTO numbers
+	WITH RECURSIVE s(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM s WHERE i < 2500)
+	SELECT i FROM s;
+`
+
+func TestExportQuery(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingQueryClient{fakeQueryClient: fakeQueryClient{conn: openTestConn(t, exportFixture)}}
+
+	export := func(t *testing.T, format string, query string) (string, int64) {
+		client.queries = nil
+		path := filepath.Join(t.TempDir(), "export."+format)
+		opts := exportOptions{format: format, path: path, query: query, table: "items_copy"}
+
+		rows, size, err := exportQuery(ctx, client, "", opts, nil, &bytes.Buffer{})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), size)
+		return string(content), rows
+	}
+
+	itemsQuery := "SELECT * FROM items ORDER BY id;"
+
+	t.Run("csv", func(t *testing.T) {
+		content, rows := export(t, "csv", itemsQuery)
+		assert.Equal(t, int64(3), rows)
+
+		records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"id", "name", "price", "data"},
+			{"1", "plain", "1.5", "X'00FF10'"},
+			{"2", `it's, "quoted"`, "", ""},
+			{"3", "", "-2", "X''"},
+		}, records)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		content, rows := export(t, "json", itemsQuery)
+		assert.Equal(t, int64(3), rows)
+		assert.True(t, strings.HasPrefix(content, `[`+"\n"+`  {"id":1,"name":"plain"`), content)
+
+		var objects []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(content), &objects))
+		require.Len(t, objects, 3)
+		assert.Equal(t, map[string]any{"id": 1.0, "name": "plain", "price": 1.5, "data": "AP8Q"}, objects[0])
+		assert.Equal(t, map[string]any{"id": 2.0, "name": `it's, "quoted"`, "price": nil, "data": nil}, objects[1])
+		assert.Equal(t, map[string]any{"id": 3.0, "name": nil, "price": -2.0, "data": ""}, objects[2])
+	})
+
+	t.Run("json without rows", func(t *testing.T) {
+		content, rows := export(t, "json", "SELECT * FROM items WHERE id < 0")
+		assert.Equal(t, int64(0), rows)
+
+		var objects []map[string]any
+		require.NoError(t, json.Unmarshal([]byte(content), &objects))
+		assert.Empty(t, objects)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		content, rows := export(t, "ndjson", itemsQuery)
+		assert.Equal(t, int64(3), rows)
+
+		scanner := bufio.NewScanner(strings.NewReader(content))
+		lines := 0
+		for scanner.Scan() {
+			var object map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &object), scanner.Text())
+			assert.Len(t, object, 4)
+			lines++
+		}
+		assert.Equal(t, 3, lines)
+	})
+
+	t.Run("sql-inserts", func(t *testing.T) {
+		content, rows := export(t, "sql-inserts", itemsQuery)
+		assert.Equal(t, int64(3), rows)
+		assert.Contains(t, content, `INSERT INTO "items_copy" VALUES(1,'plain',1.5,X'00FF10');`)
+
+		target := openTestConn(t, "CREATE TABLE items_copy (id INTEGER PRIMARY KEY, name TEXT, price REAL, data BLOB);\n"+content)
+		res, err := target.Query("SELECT id, name, price, hex(data), typeof(data) FROM items_copy ORDER BY id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{
+			{1, "plain", 1.5, "00FF10", "blob"},
+			{2, `it's, "quoted"`, nil, "", "null"},
+			{3, nil, -2.0, "", "blob"},
+		}, res.Rows)
+	})
+
+	t.Run("Queries are fetched in pages", func(t *testing.T) {
+		content, rows := export(t, "csv", "SELECT n FROM numbers ORDER BY n")
+		assert.Equal(t, int64(2500), rows)
+		assert.Len(t, client.queries, 3)
+		assert.Equal(t, "SELECT * FROM (SELECT n FROM numbers ORDER BY n) LIMIT 1000 OFFSET 2000", client.queries[2])
+
+		records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2501)
+		assert.Equal(t, []string{"2500"}, records[2500])
+	})
+
+	t.Run("Other statements are sent once", func(t *testing.T) {
+		_, rows := export(t, "csv", "PRAGMA table_info(items)")
+		assert.Equal(t, int64(4), rows)
+		assert.Equal(t, []string{"PRAGMA table_info(items)"}, client.queries)
+	})
+
+	t.Run("Failed query keeps the existing file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "export.csv")
+		require.NoError(t, os.WriteFile(path, []byte("old"), 0o644))
+
+		opts := exportOptions{format: "csv", path: path, query: "SELECT * FROM missing"}
+		_, _, err := exportQuery(ctx, client, "", opts, nil, &bytes.Buffer{})
+		assert.Error(t, err)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "old", string(content))
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "the temporary file is removed")
+	})
+}
+
+func TestCmdExportOverwrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"columns":["id","data"],"types":["INTEGER","BLOB"],"rows":[[1,"AP8Q"]]}]}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	want := "id,data\n1,X'00FF10'\n"
+
+	run := func(args string, answer string) string {
+		require.NoError(t, os.WriteFile(path, []byte("old"), 0o644))
+		r := newTxTestRepl(t, ts)
+		r.reader = bufio.NewReader(strings.NewReader(answer))
+		cmdExport(r, args)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	t.Run("Declined confirmation keeps the file", func(t *testing.T) {
+		assert.Equal(t, "old", run(" csv "+path+" SELECT 1", "n\n"))
+	})
+
+	t.Run("Accepted confirmation overwrites the file", func(t *testing.T) {
+		assert.Equal(t, want, run(" csv "+path+" SELECT 1", "y\n"))
+	})
+
+	t.Run("Force overwrites without asking", func(t *testing.T) {
+		assert.Equal(t, want, run(" --force csv "+path+" SELECT 1", ""))
+	})
+}
+
+func TestParseExportArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    exportOptions
+		wantErr bool
+	}{
+		{
+			args: " csv out.csv SELECT * FROM users",
+			want: exportOptions{format: "csv", path: "out.csv", query: "SELECT * FROM users", table: "export"},
+		},
+		{
+			args: " --force --table users_copy sql-inserts out.sql SELECT * FROM users",
+			want: exportOptions{format: "sql-inserts", path: "out.sql", query: "SELECT * FROM users", table: "users_copy", force: true},
+		},
+		{
+			args: " NDJSON out.ndjson SELECT 1",
+			want: exportOptions{format: "ndjson", path: "out.ndjson", query: "SELECT 1", table: "export"},
+		},
+		{args: "", wantErr: true},
+		{args: " csv out.csv", wantErr: true},
+		{args: " xml out.xml SELECT 1", wantErr: true},
+		{args: " --table", wantErr: true},
+		{args: " --append csv out.csv SELECT 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", strings.TrimSpace(tt.args)), func(t *testing.T) {
+			opts, err := parseExportArgs(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, opts)
+		})
+	}
+}
+
+func TestBlobValue(t *testing.T) {
+	data, ok := blobValue("AP8Q", "BLOB")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0x00, 0xFF, 0x10}, data)
+
+	_, ok = blobValue("AP8Q", "TEXT")
+	assert.False(t, ok)
+
+	data, ok = blobValue([]byte{0x01}, "")
+	assert.True(t, ok)
+	assert.Equal(t, []byte{0x01}, data)
+}
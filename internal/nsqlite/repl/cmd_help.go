@@ -1,6 +1,7 @@
 package repl
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -20,17 +21,43 @@ func cmdHelpCommands() []dotCmd {
 	cmds := []dotCmd{
 		{name: ".count [table_name]", autocomplete: ".count", help: "Count the number of rows in a table", args: "table_name (required)"},
 		{name: ".columns [table_name]", autocomplete: ".columns", help: "List all columns in a table", args: "table_name (required)"},
-		{name: ".stats [minutes]", autocomplete: ".stats", help: "Shows the server stats of last specified minutes", args: "minutes (optional, default 5)"},
+		{name: ".dump [table ...] [> file]", autocomplete: ".dump", help: "Dump the database or the given tables as SQL text", args: "table (optional, default all), file (optional, default stdout)"},
+		{name: ".import [options] [file] [table]", autocomplete: ".import", help: "Import a CSV file into a table", args: "file and table (required), --header, --delimiter d, --create, --batch-size n (optional, default 500)"},
+		{name: ".mode [mode]", autocomplete: ".mode", help: "Set the output mode of the query results", args: "mode (table, json, csv, line or markdown)"},
+		{name: ".output [file]", autocomplete: ".output", help: "Write the query results to a file", args: "file (optional, default stdout)"},
+		{name: ".read [--transaction] [file]", autocomplete: ".read", help: "Execute the SQL statements of a local file", args: "file (required), --transaction to run the file in a transaction (optional)"},
+		{name: ".nullvalue [text]", autocomplete: ".nullvalue", help: "Set the text shown for NULL values", args: "text (optional, shows the current setting)"},
+		{name: ".headers [on|off]", autocomplete: ".headers", help: "Show or hide the column names of the results", args: "on or off (optional, shows the current setting)"},
+		{name: ".width [n]", autocomplete: ".width", help: "Truncate the displayed values to n characters", args: "n (optional, 0 for unlimited)"},
+		{name: ".paging [on|off]", autocomplete: ".paging", help: "Page the results that don't fit in the terminal", args: "on or off (optional, shows the current setting)"},
+		{name: ".settings", autocomplete: ".settings", help: "List the current display settings"},
+		{name: ".timer [on|off]", autocomplete: ".timer", help: "Show or hide the timing footer of the queries", args: "on or off (optional, shows the current setting)"},
+		{name: ".export [format] [path] [query]", autocomplete: ".export", help: "Write the result of a query to a file, asking before overwriting it unless --force is given", args: "format (csv, json, ndjson or sql-inserts), path and query (required), --force and --table name for sql-inserts (optional, default export)"},
+		{name: ".backup [file]", autocomplete: ".backup", help: "Download a snapshot of the database to a local file", args: "file (required)"},
+		{name: ".restore [file]", autocomplete: ".restore", help: "Replace the database with a local SQLite file", args: "file (required)"},
+		{name: ".watch [seconds] [query]", autocomplete: ".watch", help: "Execute a query on an interval until CTRL+C is pressed", args: "seconds and query (required)"},
+		{name: ".param [set|list|clear]", autocomplete: ".param", help: "Manage the parameters bound to the placeholders of the queries", args: "set name value (value can use @int:, @real:, @text: or @blob: with base64), list or clear"},
+		{name: ".verbose [on|off]", autocomplete: ".verbose", help: "Log the HTTP requests to the server and their responses to stderr", args: "on or off (optional, shows the current setting)"},
+		{name: ".history [n]", autocomplete: ".history", help: "List the last entries of the history, run !n to execute entry n again", args: "n (optional, default 20)"},
+		{name: ".explain [query]", autocomplete: ".explain", help: "Show the query plan of a query as a tree", args: "query (required)"},
+		{name: ".eqp [on|off]", autocomplete: ".eqp", help: "Show the query plan before the results of every statement", args: "on or off (optional, shows the current setting)"},
+		{name: ".safe [on|off]", autocomplete: ".safe", help: "Confirm DELETE and UPDATE without WHERE, DROP TABLE and DROP INDEX before executing them", args: "on or off (optional, shows the current setting)"},
+		{name: ".cancel [request_id]", autocomplete: ".cancel", help: "Cancel a query running on the server, by the request ID sent in the NSQLite-Request-Id header", args: "request_id (required)"},
+		{name: ".stats [minutes] [metric]", autocomplete: ".stats", help: "Shows the server stats of last specified minutes with their trend", args: "minutes (optional, default 5) and metric to show sorted (optional, reads, writes, begins, commits, rollbacks, errors, requests, p50, p95 or p99)"},
 
-		{name: ".tables", autocomplete: ".tables", help: "List all tables in the database"},
-		{name: ".indexes", autocomplete: ".indexes", help: "List all indexes in the database"},
+		{name: ".begin", autocomplete: ".begin", help: "Start a transaction"},
+		{name: ".commit", autocomplete: ".commit", help: "Commit the current transaction"},
+		{name: ".rollback", autocomplete: ".rollback", help: "Roll back the current transaction"},
+		{name: ".tx", autocomplete: ".tx", help: "Show the current transaction and its idle time left"},
+		{name: ".tables [--detail]", autocomplete: ".tables", help: "List all tables in the database, with their row, column and index counts with --detail", args: "--detail (optional)"},
+		{name: ".indexes [table]", autocomplete: ".indexes", help: "List all indexes in the database, or show the indexes of a table"},
 		{name: ".functions", autocomplete: ".functions", help: "List all functions in the database"},
-		{name: ".schema", autocomplete: ".schema", help: "List all schema in the database"},
+		{name: ".schema [table]", autocomplete: ".schema", help: "Show the schema of the database, or of a table with its indexes and triggers"},
 		{name: ".clear", autocomplete: ".clear", help: "Clear the terminal screen"},
 		{name: ".help", autocomplete: ".help", help: "Show the help message"},
 		{name: ".quit", autocomplete: ".quit", help: "Exit the application"},
 		{name: ".exit", autocomplete: ".exit", help: "Exit the application"},
-		{name: "CTRL+c", help: "Exit the application"},
+		{name: "CTRL+c", help: "Cancel the running statement, or exit the application"},
 	}
 
 	sort.Slice(cmds, func(i, j int) bool {
@@ -54,7 +81,11 @@ func cmdHelp() {
 	fmt.Println(tw.Render())
 }
 
-func cmdHelpCompleter(line string) []string {
+// cmdHelpCompleter returns the completions of the line. SQL keywords and
+// dot-commands are completed at the start of the line and the table and
+// column names of the schema after it, falling back to the keywords only if
+// the schema can't be fetched.
+func cmdHelpCompleter(schema *schemaCache, line string) []string {
 	suggestions := []string{
 		"SELECT ",
 		"SELECT * FROM ",
@@ -80,5 +111,16 @@ func cmdHelpCompleter(line string) []string {
 		}
 	}
 
-	return results
+	if schema == nil || !strings.ContainsAny(strings.TrimSpace(line), " \t\n") {
+		return results
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaFetchTimeout)
+	defer cancel()
+
+	completions, err := completeSchema(ctx, schema, line)
+	if err != nil {
+		return results
+	}
+	return append(results, completions...)
 }
@@ -0,0 +1,30 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func cmdHistory(r *Repl, args string) {
+	count := 20
+	if args = strings.TrimSpace(args); args != "" {
+		n, err := strconv.Atoi(args)
+		if err != nil || n < 1 {
+			fmt.Println("Usage: .history [n]")
+			return
+		}
+		count = n
+	}
+
+	start, entries := r.history.last(count)
+	if len(entries) == 0 {
+		fmt.Println("The history is empty")
+		return
+	}
+
+	width := len(strconv.Itoa(start + len(entries) - 1))
+	for i, entry := range entries {
+		fmt.Printf("%*d  %s\n", width, start+i, entry)
+	}
+}
@@ -0,0 +1,396 @@
+package repl
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+const (
+	// importDefaultBatchSize is the default number of rows inserted per
+	// transaction by the .import command.
+	importDefaultBatchSize = 500
+	// importMaxReportedSkips is the maximum number of skipped rows whose
+	// reason is printed in the import summary.
+	importMaxReportedSkips = 10
+)
+
+// importOptions are the parsed arguments of the .import command.
+type importOptions struct {
+	filePath  string
+	table     string
+	header    bool
+	delimiter rune
+	create    bool
+	batchSize int
+}
+
+// importSkippedRow is a row of the file that could not be imported.
+type importSkippedRow struct {
+	line   int
+	reason string
+}
+
+// importSummary is the result of an import.
+type importSummary struct {
+	inserted int
+	skipped  []importSkippedRow
+}
+
+// importRow is a record of the file with the line where it starts.
+type importRow struct {
+	line   int
+	record []string
+}
+
+func cmdImport(r *Repl, args string) {
+	opts, err := parseImportArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	file, err := os.Open(opts.filePath)
+	if err != nil {
+		fmt.Println("Failed to open file:", err)
+		return
+	}
+	defer file.Close()
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	summary, err := importCSV(ctx, r.client, file, opts, os.Stderr)
+	printImportSummary(os.Stdout, summary)
+	if err != nil {
+		fmt.Println("Import stopped:", err)
+	}
+	fmt.Println()
+}
+
+// parseImportArgs parses the arguments of the .import command in the format
+// "[--header] [--delimiter d] [--create] [--batch-size n] file table".
+func parseImportArgs(args string) (importOptions, error) {
+	opts := importOptions{
+		delimiter: ',',
+		batchSize: importDefaultBatchSize,
+	}
+
+	fields := strings.Fields(args)
+	positional := []string{}
+
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "--header":
+			opts.header = true
+		case "--create":
+			opts.create = true
+		case "--delimiter":
+			i++
+			if i >= len(fields) {
+				return opts, errors.New("missing value for --delimiter")
+			}
+			delimiter, err := parseImportDelimiter(fields[i])
+			if err != nil {
+				return opts, err
+			}
+			opts.delimiter = delimiter
+		case "--batch-size":
+			i++
+			if i >= len(fields) {
+				return opts, errors.New("missing value for --batch-size")
+			}
+			batchSize, err := strconv.Atoi(fields[i])
+			if err != nil || batchSize <= 0 {
+				return opts, fmt.Errorf("invalid batch size: %s", fields[i])
+			}
+			opts.batchSize = batchSize
+		default:
+			if strings.HasPrefix(fields[i], "--") {
+				return opts, fmt.Errorf("unknown option: %s", fields[i])
+			}
+			positional = append(positional, fields[i])
+		}
+	}
+
+	if len(positional) != 2 {
+		return opts, errors.New("usage: .import [--header] [--delimiter d] [--create] [--batch-size n] file table")
+	}
+
+	opts.filePath = positional[0]
+	opts.table = positional[1]
+	return opts, nil
+}
+
+// parseImportDelimiter parses a single character delimiter, "tab" and "\t"
+// can be used for tab separated files.
+func parseImportDelimiter(value string) (rune, error) {
+	if value == "tab" || value == `\t` {
+		return '\t', nil
+	}
+
+	runes := []rune(value)
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("delimiter must be a single character: %s", value)
+	}
+	return runes[0], nil
+}
+
+// importCSV reads the CSV records from r and inserts them into the table in
+// batches, each one in its own transaction.
+//
+// Records that can't be parsed, that have a different amount of fields than
+// the first one or that fail to insert are skipped and reported in the
+// summary. Any other error stops the import, the transaction of the current
+// batch is rolled back and the summary contains the rows of the batches
+// already committed.
+func importCSV(
+	ctx context.Context, client queryClient, r io.Reader, opts importOptions, progress io.Writer,
+) (importSummary, error) {
+	summary := importSummary{}
+
+	reader := csv.NewReader(r)
+	reader.Comma = opts.delimiter
+	reader.FieldsPerRecord = -1
+
+	var columns []string
+	if opts.header {
+		header, err := reader.Read()
+		if err == io.EOF {
+			return summary, errors.New("the file is empty")
+		}
+		if err != nil {
+			return summary, fmt.Errorf("failed to read header: %w", err)
+		}
+		columns = header
+	}
+
+	fieldCount := len(columns)
+	tableReady := !opts.create
+	hasProgress := false
+	batch := []importRow{}
+
+	flush := func() error {
+		if !tableReady {
+			err := createImportTable(ctx, client, opts.table, columns, fieldCount, batch)
+			if err != nil {
+				return err
+			}
+			tableReady = true
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		inserted, skipped, err := insertImportBatch(ctx, client, opts.table, columns, batch)
+		if err != nil {
+			return err
+		}
+
+		summary.inserted += inserted
+		summary.skipped = append(summary.skipped, skipped...)
+		batch = batch[:0]
+
+		fmt.Fprintf(progress, "\rImported %d rows", summary.inserted)
+		hasProgress = true
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return summary, fmt.Errorf("failed to read file: %w", err)
+			}
+
+			summary.skipped = append(summary.skipped, importSkippedRow{
+				line:   parseErr.StartLine,
+				reason: parseErr.Err.Error(),
+			})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		if fieldCount == 0 {
+			fieldCount = len(record)
+		}
+		if len(record) != fieldCount {
+			summary.skipped = append(summary.skipped, importSkippedRow{
+				line:   line,
+				reason: fmt.Sprintf("expected %d fields, got %d", fieldCount, len(record)),
+			})
+			continue
+		}
+
+		batch = append(batch, importRow{line: line, record: record})
+		if len(batch) >= opts.batchSize {
+			if err := flush(); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	if fieldCount == 0 {
+		return summary, nil
+	}
+	if err := flush(); err != nil {
+		return summary, err
+	}
+
+	if hasProgress {
+		fmt.Fprintln(progress)
+	}
+	return summary, nil
+}
+
+// createImportTable creates the table for the imported file, the type of each
+// column is inferred from the given rows.
+func createImportTable(
+	ctx context.Context, client queryClient, table string, columns []string, fieldCount int, rows []importRow,
+) error {
+	columnDefs := make([]string, fieldCount)
+	for i := range fieldCount {
+		values := make([]string, len(rows))
+		for j, row := range rows {
+			values[j] = row.record[i]
+		}
+		columnDefs[i] = quoteIdentifier(importColumnName(columns, i)) + " " + inferImportType(values)
+	}
+
+	_, err := sendQuery(ctx, client, fmt.Sprintf(
+		"CREATE TABLE %s (%s)", quoteIdentifier(table), strings.Join(columnDefs, ", "),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+	return nil
+}
+
+// importColumnName returns the name of the column at the given index, it
+// defaults to c1, c2, ... when the file has no header.
+func importColumnName(columns []string, index int) string {
+	if index < len(columns) {
+		return columns[index]
+	}
+	return fmt.Sprintf("c%d", index+1)
+}
+
+// inferImportType returns INTEGER or REAL if all the non empty values are
+// numbers of that kind, or TEXT otherwise.
+func inferImportType(values []string) string {
+	isInteger, isReal, hasValues := true, true, false
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		hasValues = true
+
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			isInteger = false
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			isReal = false
+		}
+	}
+
+	switch {
+	case !hasValues:
+		return "TEXT"
+	case isInteger:
+		return "INTEGER"
+	case isReal:
+		return "REAL"
+	default:
+		return "TEXT"
+	}
+}
+
+// insertImportBatch inserts the rows in a single transaction and returns the
+// number of inserted rows and the rows that failed to insert.
+func insertImportBatch(
+	ctx context.Context, client queryClient, table string, columns []string, rows []importRow,
+) (int, []importSkippedRow, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(rows[0].record)), ", ")
+	insert := fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteIdentifier(table), placeholders)
+	if len(columns) > 0 {
+		quotedColumns := make([]string, len(columns))
+		for i, column := range columns {
+			quotedColumns[i] = quoteIdentifier(column)
+		}
+		insert = fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES (%s)",
+			quoteIdentifier(table), strings.Join(quotedColumns, ", "), placeholders,
+		)
+	}
+
+	res, err := sendQuery(ctx, client, "BEGIN")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	txId := res.TxId
+
+	queries := make([]nsqlitehttp.Query, len(rows))
+	for i, row := range rows {
+		params := make([]nsqlitehttp.QueryParam, len(row.record))
+		for j, value := range row.record {
+			params[j] = nsqlitehttp.QueryParam{Value: value}
+		}
+		queries[i] = nsqlitehttp.Query{TxId: txId, Query: insert, Params: params}
+	}
+
+	results, err := client.SendQueries(ctx, queries)
+	if err != nil {
+		rollbackImport(ctx, client, txId)
+		return 0, nil, fmt.Errorf("failed to insert rows: %w", err)
+	}
+
+	inserted := 0
+	skipped := []importSkippedRow{}
+	for i, result := range results {
+		if result.Error != "" {
+			skipped = append(skipped, importSkippedRow{line: rows[i].line, reason: result.Error})
+			continue
+		}
+		inserted++
+	}
+
+	if _, err := sendTxQuery(ctx, client, txId, "COMMIT"); err != nil {
+		rollbackImport(ctx, client, txId)
+		return 0, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, skipped, nil
+}
+
+// rollbackImport rolls back the transaction of a failed batch, even if the
+// context has been canceled, so it isn't left open in the server.
+func rollbackImport(ctx context.Context, client queryClient, txId string) {
+	_, _ = sendTxQuery(context.WithoutCancel(ctx), client, txId, "ROLLBACK")
+}
+
+// printImportSummary prints the inserted and skipped rows of an import.
+func printImportSummary(w io.Writer, summary importSummary) {
+	fmt.Fprintf(w, "Rows inserted: %d\n", summary.inserted)
+	fmt.Fprintf(w, "Rows skipped: %d\n", len(summary.skipped))
+
+	for i, skipped := range summary.skipped {
+		if i == importMaxReportedSkips {
+			fmt.Fprintf(w, "  ... and %d more\n", len(summary.skipped)-importMaxReportedSkips)
+			break
+		}
+		fmt.Fprintf(w, "  line %d: %s\n", skipped.line, skipped.reason)
+	}
+}
@@ -0,0 +1,132 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCSV(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Imports into an existing table", func(t *testing.T) {
+		conn := openTestConn(t, `
+			CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT, note TEXT);
+		`)
+
+		file := strings.Join([]string{
+			`id,name,note`,
+			`1,"Smith, John","said ""hi"""`,
+			`2,Jane,"multi`,
+			`line"`,
+			`3,bad row`,
+			`4,"bad "quote",x`,
+			`1,Duplicated,x`,
+			`5,Last,`,
+		}, "\n")
+
+		opts := importOptions{table: "people", header: true, delimiter: ',', batchSize: 2}
+		progress := bytes.Buffer{}
+		summary, err := importCSV(ctx, fakeQueryClient{conn: conn}, strings.NewReader(file), opts, &progress)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, summary.inserted)
+		require.Len(t, summary.skipped, 3)
+		assert.Equal(t, 5, summary.skipped[0].line)
+		assert.Equal(t, "expected 3 fields, got 2", summary.skipped[0].reason)
+		assert.Equal(t, 6, summary.skipped[1].line)
+		assert.Contains(t, summary.skipped[1].reason, "quote")
+		assert.Equal(t, 7, summary.skipped[2].line)
+		assert.Contains(t, summary.skipped[2].reason, "constraint failed")
+		assert.Contains(t, progress.String(), "Imported 3 rows")
+
+		assert.Equal(t, "Smith, John", queryValue(t, conn, `SELECT name FROM people WHERE id = 1`))
+		assert.Equal(t, `said "hi"`, queryValue(t, conn, `SELECT note FROM people WHERE id = 1`))
+		assert.Equal(t, "multi\nline", queryValue(t, conn, `SELECT note FROM people WHERE id = 2`))
+		assert.EqualValues(t, 3, queryValue(t, conn, `SELECT COUNT(*) FROM people`))
+
+		out := bytes.Buffer{}
+		printImportSummary(&out, summary)
+		assert.Contains(t, out.String(), "Rows inserted: 3\nRows skipped: 3\n")
+		assert.Contains(t, out.String(), "line 5: expected 3 fields, got 2\n")
+	})
+
+	t.Run("Creates the table", func(t *testing.T) {
+		conn := openTestConn(t, "")
+
+		file := "1;1.5;a\n2;;b\n3;2;c\n"
+		opts := importOptions{table: "new table", delimiter: ';', create: true, batchSize: 10}
+		summary, err := importCSV(ctx, fakeQueryClient{conn: conn}, strings.NewReader(file), opts, &bytes.Buffer{})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, summary.inserted)
+		assert.Empty(t, summary.skipped)
+
+		res, err := conn.Query(`SELECT name, type FROM pragma_table_info('new table')`, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{
+			{"c1", "INTEGER"},
+			{"c2", "REAL"},
+			{"c3", "TEXT"},
+		}, res.Rows)
+	})
+
+	t.Run("Stops on connection errors without a dangling transaction", func(t *testing.T) {
+		conn := openTestConn(t, `
+			CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT);
+		`)
+
+		client := fakeQueryClient{conn: conn, sendQueriesErr: errors.New("connection reset")}
+		opts := importOptions{table: "people", delimiter: ',', batchSize: 10}
+		summary, err := importCSV(ctx, client, strings.NewReader("1,a\n2,b\n"), opts, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "connection reset")
+		assert.Equal(t, 0, summary.inserted)
+
+		_, err = conn.Query("BEGIN", nil)
+		assert.NoError(t, err, "the import transaction must be rolled back")
+		_, err = conn.Query("ROLLBACK", nil)
+		assert.NoError(t, err)
+	})
+}
+
+func TestParseImportArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		want    importOptions
+		wantErr bool
+	}{
+		{
+			args: " data.csv people",
+			want: importOptions{filePath: "data.csv", table: "people", delimiter: ',', batchSize: importDefaultBatchSize},
+		},
+		{
+			args: " --header --create --delimiter tab --batch-size 10 data.tsv people",
+			want: importOptions{
+				filePath: "data.tsv", table: "people", header: true, create: true,
+				delimiter: '\t', batchSize: 10,
+			},
+		},
+		{args: " --delimiter ;; data.csv people", wantErr: true},
+		{args: " --batch-size 0 data.csv people", wantErr: true},
+		{args: " --unknown data.csv people", wantErr: true},
+		{args: " data.csv", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.args), func(t *testing.T) {
+			opts, err := parseImportArgs(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, opts)
+		})
+	}
+}
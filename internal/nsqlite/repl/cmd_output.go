@@ -0,0 +1,53 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+func cmdMode(r *Repl, args string) {
+	mode := strings.TrimSpace(args)
+	if mode == "" {
+		fmt.Printf("Current output mode: %s\n", r.render.mode)
+		return
+	}
+
+	if !slices.Contains(outputModes, mode) {
+		fmt.Printf("Unknown output mode, use one of: %s\n", strings.Join(outputModes, ", "))
+		return
+	}
+
+	r.render.mode = mode
+}
+
+func cmdOutput(r *Repl, args string) {
+	path := strings.TrimSpace(args)
+	if path == "" || path == "stdout" {
+		r.resetOutput()
+		return
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Println("Failed to open output file:", err)
+		return
+	}
+
+	r.resetOutput()
+	r.output = file
+	r.outputFile = file
+}
+
+// resetOutput closes the output file, if any, and writes the results to
+// stdout again.
+func (r *Repl) resetOutput() {
+	if r.outputFile != nil {
+		if err := r.outputFile.Close(); err != nil {
+			fmt.Println("Failed to close output file:", err)
+		}
+		r.outputFile = nil
+	}
+	r.output = os.Stdout
+}
@@ -0,0 +1,255 @@
+package repl
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+func cmdParam(r *Repl, args string) {
+	subcommand, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+
+	switch subcommand {
+	case "set":
+		name, value, err := parseParamSet(rest)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		r.params[name] = value
+
+	case "list":
+		cmdParamList(r)
+
+	case "clear":
+		clear(r.params)
+
+	default:
+		fmt.Println("Usage: .param set name value | .param list | .param clear")
+	}
+}
+
+func cmdParamList(r *Repl) {
+	if len(r.params) == 0 {
+		fmt.Println("No parameters set")
+		return
+	}
+
+	names := make([]string, 0, len(r.params))
+	for name := range r.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Name", "Type", "Value"})
+	for _, name := range names {
+		value := r.params[name]
+		typ := paramType(value)
+		display := fmt.Sprint(value)
+		switch v := value.(type) {
+		case nil:
+			display = "NULL"
+		case []byte:
+			display = base64.StdEncoding.EncodeToString(v)
+		}
+		tw.AppendRow(table.Row{name, typ, display})
+	}
+	fmt.Println(tw.Render())
+}
+
+// parseParamSet parses the arguments of .param set in the format
+// "name value" and returns the normalized name and the typed value.
+func parseParamSet(args string) (string, any, error) {
+	usage := errors.New("usage: .param set name value")
+
+	name, value, ok := strings.Cut(strings.TrimSpace(args), " ")
+	if !ok {
+		return "", nil, usage
+	}
+
+	name = normalizeParamName(name)
+	if name == "" || name == "?" {
+		return "", nil, usage
+	}
+
+	typed, err := parseParamValue(strings.TrimSpace(value))
+	if err != nil {
+		return "", nil, err
+	}
+	return name, typed, nil
+}
+
+// normalizeParamName returns the name used to store a parameter: named
+// parameters without their prefix and positional ones as ?NNN, so ":id",
+// "@id" and "id" are the same parameter and so are "?1" and "1".
+func normalizeParamName(name string) string {
+	if strings.HasPrefix(name, "?") {
+		return name
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return "?" + name
+	}
+	return strings.TrimLeft(name, ":@$")
+}
+
+// parseParamValue parses the value of a parameter. The type can be given
+// with the @int:, @real:, @text: and @blob: prefixes, blobs are base64
+// encoded. Otherwise it is inferred: NULL, integers, reals, single-quoted
+// text and any other text.
+func parseParamValue(value string) (any, error) {
+	if prefix, rest, ok := strings.Cut(value, ":"); ok && strings.HasPrefix(prefix, "@") {
+		switch prefix {
+		case "@int":
+			n, err := strconv.ParseInt(rest, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer: %s", rest)
+			}
+			return n, nil
+		case "@real":
+			n, err := strconv.ParseFloat(rest, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid real: %s", rest)
+			}
+			return n, nil
+		case "@text":
+			return rest, nil
+		case "@blob":
+			data, err := base64.StdEncoding.DecodeString(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base64 blob: %w", err)
+			}
+			return data, nil
+		}
+	}
+
+	if strings.EqualFold(value, "NULL") {
+		return nil, nil
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n, nil
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	return value, nil
+}
+
+// paramType returns the SQLite type name of a parameter value.
+func paramType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return "INTEGER"
+	case float64:
+		return "REAL"
+	case []byte:
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
+// bindParams returns the parameters to send with the statement for its
+// placeholders and the placeholders that have no value set. Nameless "?"
+// placeholders take the values of ?1, ?2, ... in order and are sent first,
+// because the server binds the nameless parameters by their position.
+// Blobs are sent as base64 strings.
+func bindParams(params map[string]any, statement string) ([]nsqlitehttp.QueryParam, []string) {
+	nameless := []nsqlitehttp.QueryParam{}
+	named := []nsqlitehttp.QueryParam{}
+	missing := []string{}
+
+	for _, placeholder := range findPlaceholders(statement) {
+		if placeholder == "?" {
+			key := "?" + strconv.Itoa(len(nameless)+1)
+			value, ok := params[key]
+			if !ok {
+				missing = append(missing, key)
+			}
+			nameless = append(nameless, nsqlitehttp.QueryParam{Value: value})
+			continue
+		}
+
+		value, ok := params[normalizeParamName(placeholder)]
+		if !ok {
+			missing = append(missing, placeholder)
+			continue
+		}
+		named = append(named, nsqlitehttp.QueryParam{Name: placeholder, Value: value})
+	}
+
+	return append(nameless, named...), missing
+}
+
+// findPlaceholders returns the parameter placeholders of the statement in
+// order of appearance, skipping the ones inside strings, quoted identifiers
+// and comments. Named and numbered placeholders are returned once, each
+// nameless "?" is returned.
+func findPlaceholders(statement string) []string {
+	placeholders := []string{}
+	seen := map[string]bool{}
+
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+
+		switch {
+		case c == '-' && i+1 < len(statement) && statement[i+1] == '-':
+			end := strings.IndexByte(statement[i:], '\n')
+			if end == -1 {
+				return placeholders
+			}
+			i += end
+
+		case c == '/' && i+1 < len(statement) && statement[i+1] == '*':
+			end := strings.Index(statement[i+2:], "*/")
+			if end == -1 {
+				return placeholders
+			}
+			i += end + 3
+
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(statement[i+1:], closing)
+			if end == -1 {
+				return placeholders
+			}
+			i += end + 1
+
+		case c == '?' || c == ':' || c == '@' || c == '$':
+			start := i
+			for i+1 < len(statement) && isWordChar(statement[i+1]) {
+				i++
+			}
+			placeholder := statement[start : i+1]
+			if placeholder != "?" && len(placeholder) == 1 {
+				continue
+			}
+			if placeholder == "?" || !seen[placeholder] {
+				placeholders = append(placeholders, placeholder)
+				seen[placeholder] = true
+			}
+
+		case isWordChar(c):
+			for i+1 < len(statement) && isWordChar(statement[i+1]) {
+				i++
+			}
+		}
+	}
+
+	return placeholders
+}
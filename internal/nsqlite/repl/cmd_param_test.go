@@ -0,0 +1,117 @@
+package repl
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseParamValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  any
+	}{
+		{value: "42", want: int64(42)},
+		{value: "-1.5", want: -1.5},
+		{value: "NULL", want: nil},
+		{value: "hello world", want: "hello world"},
+		{value: "'it''s 42'", want: "it's 42"},
+		{value: "@text:42", want: "42"},
+		{value: "@int:7", want: int64(7)},
+		{value: "@real:7", want: 7.0},
+		{value: "@blob:AP8Q", want: []byte{0x00, 0xff, 0x10}},
+		{value: "@other:1", want: "@other:1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseParamValue(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	for _, value := range []string{"@int:abc", "@real:x", "@blob:%%%"} {
+		_, err := parseParamValue(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFindPlaceholders(t *testing.T) {
+	assert.Equal(t,
+		[]string{":id", "?", "@name", "?2", "$tag", "?"},
+		findPlaceholders(`SELECT :id, ?, @name, ?2, $tag, ?, :id, ':skip', "?" -- ?
+			/* @skip */ FROM t WHERE a$b = 1`),
+	)
+	assert.Empty(t, findPlaceholders("SELECT 1"))
+}
+
+func TestParamCommand(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		_, _ = w.Write([]byte(`{"results":[{}]}`))
+	}))
+	defer ts.Close()
+
+	r := newTxTestRepl(t, ts)
+	r.params = map[string]any{}
+
+	cmdParam(r, " set :id 42")
+	cmdParam(r, " set @name 'Smith, John'")
+	cmdParam(r, " set ratio 0.5")
+	cmdParam(r, " set 1 @text:007")
+	cmdParam(r, " set ?2 @blob:AP8Q")
+	cmdParam(r, " set nothing NULL")
+	assert.Len(t, r.params, 6)
+
+	t.Run("Named placeholders", func(t *testing.T) {
+		query := "SELECT :id, @name, $ratio, :nothing, :missing"
+		params, missing := bindParams(r.params, query)
+		assert.Equal(t, []string{":missing"}, missing)
+
+		cmdQuery(r, query, params)
+		assert.JSONEq(t, `[{
+			"query": "SELECT :id, @name, $ratio, :nothing, :missing",
+			"params": [
+				{"name": ":id", "value": 42},
+				{"name": "@name", "value": "Smith, John"},
+				{"name": "$ratio", "value": 0.5},
+				{"name": ":nothing", "value": null}
+			]
+		}]`, body)
+	})
+
+	t.Run("Positional placeholders", func(t *testing.T) {
+		query := "SELECT ?, ?, ?"
+		params, missing := bindParams(r.params, query)
+		assert.Equal(t, []string{"?3"}, missing)
+
+		cmdQuery(r, query, params)
+		assert.JSONEq(t, `[{
+			"query": "SELECT ?, ?, ?",
+			"params": [{"value": "007"}, {"value": "AP8Q"}, {"value": null}]
+		}]`, body)
+
+		params, missing = bindParams(r.params, "SELECT ?2, ?1")
+		assert.Empty(t, missing)
+		cmdQuery(r, "SELECT ?2, ?1", params)
+		assert.JSONEq(t, `[{
+			"query": "SELECT ?2, ?1",
+			"params": [{"name": "?2", "value": "AP8Q"}, {"name": "?1", "value": "007"}]
+		}]`, body)
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		cmdParam(r, " clear")
+		assert.Empty(t, r.params)
+
+		_, missing := bindParams(r.params, "SELECT :id")
+		assert.Equal(t, []string{":id"}, missing)
+	})
+}
@@ -1,9 +1,13 @@
 package repl
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
@@ -12,11 +16,23 @@ import (
 )
 
 func cmdQuery(r *Repl, input string, params []nsqlitehttp.QueryParam) {
-	res, err := r.client.SendQuery(context.TODO(), nsqlitehttp.Query{
+	ctx, done := r.commandContext()
+	defer done()
+
+	start := time.Now()
+	stopSpinner := startQuerySpinner()
+	res, err := r.client.SendQuery(ctx, nsqlitehttp.Query{
 		TxId:   r.txId,
 		Query:  input,
 		Params: params,
 	})
+	roundTrip := time.Since(start)
+	stopSpinner()
+	if errors.Is(err, context.Canceled) {
+		fmt.Printf("Query canceled after %s\n", formatDuration(roundTrip))
+		fmt.Println()
+		return
+	}
 	if err != nil && res.Error == "" {
 		tw := styled.NewTableWriter()
 		tw.AppendHeader(table.Row{"Error"})
@@ -28,7 +44,7 @@ func cmdQuery(r *Repl, input string, params []nsqlitehttp.QueryParam) {
 	hasReads := len(res.Columns) > 0
 	hasWrites := res.RowsAffected > 0
 	hasTxId := res.TxId != ""
-	isOk := !isError && !hasReads && !hasWrites
+	isOk := err == nil && !isError && !hasReads && !hasWrites
 
 	if isError {
 		tw := styled.NewTableWriter()
@@ -41,12 +57,32 @@ func cmdQuery(r *Repl, input string, params []nsqlitehttp.QueryParam) {
 		}
 	}
 
+	if err == nil && !isError && r.txId != "" {
+		r.txLastUsedAt = time.Now()
+	}
+
 	if hasTxId {
+		// The server returns the transaction ID for COMMIT and ROLLBACK too,
+		// so the statement tells whether the transaction was finished.
+		message := "Transaction started"
+		switch txStatementKind(input) {
+		case "COMMIT":
+			message = "Transaction committed"
+			r.setTxId("")
+		case "END":
+			message = "Transaction ended"
+			r.setTxId("")
+		case "ROLLBACK":
+			message = "Transaction rolled back"
+			r.setTxId("")
+		default:
+			r.setTxId(res.TxId)
+		}
+
 		tw := styled.NewTableWriter()
 		tw.AppendHeader(table.Row{"OK"})
-		tw.AppendRow(table.Row{"Transaction started"})
+		tw.AppendRow(table.Row{message})
 		fmt.Println(tw.Render())
-		r.setTxId(res.TxId)
 	}
 
 	if isOk {
@@ -64,23 +100,27 @@ func cmdQuery(r *Repl, input string, params []nsqlitehttp.QueryParam) {
 	}
 
 	if hasReads {
-		tw := styled.NewTableWriter()
-
-		header := table.Row{}
-		for _, col := range res.Columns {
-			header = append(header, col)
-		}
-		tw.AppendHeader(header)
-
-		for _, row := range res.Rows {
-			tw.AppendRow(row)
+		rendered := bytes.Buffer{}
+		err := renderRows(&rendered, r.render, res.Columns, res.Types, res.Rows)
+		if err != nil {
+			fmt.Println("Failed to render results:", err)
+		} else {
+			r.writePaged(rendered.Bytes())
 		}
+	}
 
-		fmt.Println(tw.Render())
+	if err == nil && !isError && isDDLStatement(input) {
+		r.schema.invalidate()
 	}
 
-	if res.Time > 0 {
-		styled.DimmedColor().Printf("Time: %f seconds\n", res.Time)
+	if err == nil && !isError {
+		writeTimingFooter(os.Stdout, r.timer, queryTiming{
+			roundTrip:    roundTrip,
+			server:       time.Duration(res.Time * float64(time.Second)),
+			isRead:       hasReads,
+			rows:         len(res.Rows),
+			rowsAffected: res.RowsAffected,
+		})
 	}
 	fmt.Println()
 }
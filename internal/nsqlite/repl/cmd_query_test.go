@@ -0,0 +1,122 @@
+package repl
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCmdQueryInterrupt(t *testing.T) {
+	started := make(chan struct{})
+	mu := sync.Mutex{}
+	received := []string{}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries := []nsqlitehttp.Query{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&queries))
+
+		mu.Lock()
+		received = append(received, queries[0].Query)
+		mu.Unlock()
+
+		if queries[0].Query == "SELECT slow" {
+			close(started)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"columns":["1"],"rows":[[1]]}]}`))
+	}))
+	defer ts.Close()
+
+	r := newTxTestRepl(t, ts)
+	assert.False(t, r.Interrupt(), "there is nothing to interrupt")
+
+	finished := make(chan struct{})
+	go func() {
+		cmdQuery(r, "SELECT slow", nil)
+		close(finished)
+	}()
+
+	<-started
+	assert.True(t, r.Interrupt())
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query was not canceled")
+	}
+	assert.False(t, r.Interrupt(), "the command finished")
+
+	cmdQuery(r, "SELECT 1", nil)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"SELECT slow", "SELECT 1"}, received)
+}
+
+func TestCmdQueryInterruptCancelsOnServer(t *testing.T) {
+	started := make(chan string, 1)
+	canceled := make(chan string, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/capabilities":
+			_, _ = w.Write([]byte(`{"version":"v0.2.0","capabilities":["cancel"]}`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/query/"):
+			canceled <- strings.TrimPrefix(r.URL.Path, "/query/")
+			_, _ = w.Write([]byte(`{"canceled":true}`))
+		case r.URL.Path == "/query":
+			_, _ = io.Copy(io.Discard, r.Body)
+			started <- r.Header.Get(apiclient.RequestIDHeader)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+		}
+	}))
+	defer ts.Close()
+
+	inFlight := apiclient.NewInFlightQueries()
+	r := newTxTestRepl(t, ts)
+	r.inFlight = inFlight
+	client, err := nsqlitehttp.NewClient(ts.URL, nsqlitehttp.WithHTTPClient(&http.Client{
+		Transport: apiclient.NewRequestIDTransport(http.DefaultTransport, inFlight),
+	}))
+	require.NoError(t, err)
+	r.client = client
+
+	finished := make(chan struct{})
+	go func() {
+		cmdQuery(r, "SELECT slow", nil)
+		close(finished)
+	}()
+
+	requestID := <-started
+	require.NotEmpty(t, requestID)
+	assert.True(t, r.Interrupt())
+
+	select {
+	case id := <-canceled:
+		assert.Equal(t, requestID, id, "the server is asked to interrupt the same request")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query was not canceled on the server")
+	}
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query was not canceled")
+	}
+	assert.Empty(t, inFlight.List())
+}
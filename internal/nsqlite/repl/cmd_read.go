@@ -0,0 +1,112 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+)
+
+// readOptions are the parsed arguments of the .read command.
+type readOptions struct {
+	filePath    string
+	transaction bool
+}
+
+func cmdRead(r *Repl, args string) {
+	opts, err := parseReadArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	script, err := os.ReadFile(opts.filePath)
+	if err != nil {
+		fmt.Println("Failed to read file:", err)
+		return
+	}
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	start := time.Now()
+	runner := txRunner{client: r.client, txId: r.txId}
+	executed, err := readScript(ctx, &runner, string(script), opts, r.render, r.output, os.Stderr)
+	r.setTxId(runner.txId)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		fmt.Printf("Script stopped after %d statements: %s\n", executed, r.cleanError(err.Error()))
+		if opts.transaction {
+			fmt.Println("The transaction has been rolled back")
+		}
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("Executed %d statements\n", executed)
+	if r.timer {
+		styled.DimmedColor().Printf("Total time: %s\n", formatDuration(elapsed))
+	}
+	fmt.Println()
+}
+
+// parseReadArgs parses the arguments of the .read command in the format
+// "[--transaction] file".
+func parseReadArgs(args string) (readOptions, error) {
+	opts := readOptions{}
+
+	for _, field := range strings.Fields(args) {
+		switch {
+		case field == "--transaction":
+			opts.transaction = true
+		case strings.HasPrefix(field, "--"):
+			return opts, fmt.Errorf("unknown option: %s", field)
+		case opts.filePath != "":
+			return opts, errors.New("usage: .read [--transaction] file")
+		default:
+			opts.filePath = field
+		}
+	}
+
+	if opts.filePath == "" {
+		return opts, errors.New("usage: .read [--transaction] file")
+	}
+	return opts, nil
+}
+
+// readScript executes the statements of the script with the runner. With
+// the transaction option the statements are wrapped in a transaction that
+// is committed at the end or rolled back on the first error.
+func readScript(
+	ctx context.Context, runner *txRunner, script string, opts readOptions,
+	render renderOptions, w io.Writer, progress io.Writer,
+) (int, error) {
+	if !opts.transaction {
+		return runScript(ctx, runner, script, render, w, progress)
+	}
+
+	if runner.txId != "" {
+		return 0, errors.New("a transaction is already active, commit or rollback it first")
+	}
+	if _, err := runner.exec(ctx, "BEGIN"); err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	executed, err := runScript(ctx, runner, script, render, w, progress)
+	if err != nil {
+		runner.rollback(ctx)
+		return executed, err
+	}
+
+	if _, err := runner.exec(ctx, "COMMIT"); err != nil {
+		runner.rollback(ctx)
+		return executed, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return executed, nil
+}
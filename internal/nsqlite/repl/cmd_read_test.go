@@ -0,0 +1,95 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadScript(t *testing.T) {
+	ctx := context.Background()
+	script := strings.Join([]string{
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);",
+		"INSERT INTO items (name) VALUES ('a');",
+		"INSERT INTO items (name)",
+		"  VALUES ('b');",
+		"INSERT INTO missing VALUES (1);",
+		"INSERT INTO items (name) VALUES ('c');",
+	}, "\n")
+
+	t.Run("Stops at the first error", func(t *testing.T) {
+		conn := openTestConn(t, "")
+		runner := txRunner{client: fakeQueryClient{conn: conn}}
+
+		executed, err := readScript(ctx, &runner, script, readOptions{}, newRenderOptions(outputModeTable), &bytes.Buffer{}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "statement 4 at line 5")
+		assert.ErrorContains(t, err, "no such table: missing")
+		assert.Equal(t, 3, executed)
+		assert.EqualValues(t, 2, queryValue(t, conn, "SELECT COUNT(*) FROM items"))
+	})
+
+	t.Run("Rolls back the transaction on error", func(t *testing.T) {
+		conn := openTestConn(t, "")
+		runner := txRunner{client: fakeQueryClient{conn: conn}}
+
+		opts := readOptions{transaction: true}
+		executed, err := readScript(ctx, &runner, script, opts, newRenderOptions(outputModeTable), &bytes.Buffer{}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "statement 4 at line 5")
+		assert.Equal(t, 3, executed)
+		assert.Empty(t, runner.txId)
+		assert.EqualValues(t, 0, queryValue(t, conn, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'items'"))
+	})
+
+	t.Run("Commits the transaction", func(t *testing.T) {
+		conn := openTestConn(t, "")
+		runner := txRunner{client: fakeQueryClient{conn: conn}}
+
+		valid := strings.Replace(script, "INSERT INTO missing VALUES (1);\n", "", 1) +
+			"\nSELECT name FROM items ORDER BY id;"
+		out := bytes.Buffer{}
+		opts := readOptions{transaction: true}
+		executed, err := readScript(ctx, &runner, valid, opts, newRenderOptions(outputModeCSV), &out, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, 5, executed)
+		assert.Empty(t, runner.txId)
+		assert.Equal(t, "name\na\nb\nc\n", out.String())
+	})
+
+	t.Run("Refuses to nest transactions", func(t *testing.T) {
+		runner := txRunner{client: fakeQueryClient{conn: openTestConn(t, "")}, txId: "active"}
+
+		opts := readOptions{transaction: true}
+		_, err := readScript(ctx, &runner, script, opts, newRenderOptions(outputModeTable), &bytes.Buffer{}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "already active")
+		assert.Equal(t, "active", runner.txId)
+	})
+
+	t.Run("Reports progress of long scripts", func(t *testing.T) {
+		runner := txRunner{client: fakeQueryClient{conn: openTestConn(t, "")}}
+
+		long := strings.Repeat("SELECT 1;\n", scriptProgressMinStatements)
+		progress := bytes.Buffer{}
+		_, err := readScript(ctx, &runner, long, readOptions{}, newRenderOptions(outputModeTable), &bytes.Buffer{}, &progress)
+		require.NoError(t, err)
+		assert.Contains(t, progress.String(), fmt.Sprintf(
+			"Executed %d/%d statements\n", scriptProgressMinStatements, scriptProgressMinStatements,
+		))
+	})
+}
+
+func TestParseReadArgs(t *testing.T) {
+	opts, err := parseReadArgs(" --transaction script.sql")
+	require.NoError(t, err)
+	assert.Equal(t, readOptions{filePath: "script.sql", transaction: true}, opts)
+
+	_, err = parseReadArgs("")
+	assert.Error(t, err)
+
+	_, err = parseReadArgs(" a.sql b.sql")
+	assert.Error(t, err)
+}
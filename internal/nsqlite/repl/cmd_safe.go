@@ -0,0 +1,167 @@
+package repl
+
+import (
+	"fmt"
+	"strings"
+)
+
+func cmdSafe(r *Repl, args string) {
+	switch strings.TrimSpace(args) {
+	case "on":
+		r.safe = true
+	case "off":
+		r.safe = false
+	case "":
+		fmt.Printf("Safe mode is %s\n", onOff(r.safe))
+	default:
+		fmt.Println("Usage: .safe on|off")
+	}
+}
+
+// confirmDestructive asks the user to confirm the destructive statements of
+// the input when the safe mode is on. It reports whether the input can be
+// sent to the server.
+func confirmDestructive(r *Repl, input string) bool {
+	if !r.safe {
+		return true
+	}
+
+	statements, rest, _ := splitStatements(input)
+	if rest.text != "" {
+		statements = append(statements, rest)
+	}
+	for _, statement := range statements {
+		warning := destructiveWarning(statement.text)
+		if warning == "" {
+			continue
+		}
+		if !r.confirm(warning + " Continue?") {
+			return false
+		}
+	}
+	return true
+}
+
+// destructiveWarning returns the warning shown before executing the
+// statement if it can't be undone and affects a whole table: DELETE and
+// UPDATE without a WHERE clause, DROP TABLE and DROP INDEX. It returns an
+// empty string for any other statement.
+//
+// The statement is tokenized, so keywords in comments, strings and quoted
+// identifiers are ignored, and only a WHERE outside of parentheses limits
+// the rows of the statement, not one in a subquery.
+func destructiveWarning(statement string) string {
+	tokens := tokenizeSQL(statement)
+	tokens = skipWithClause(tokens)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	switch {
+	case tokens[0].is("DELETE"), tokens[0].is("UPDATE"):
+		table := statementTable(tokens)
+		if table == "" || hasTopLevelWhere(tokens) {
+			return ""
+		}
+		return fmt.Sprintf("This will affect all rows in %s.", table)
+
+	case tokens[0].is("DROP") && len(tokens) > 1 && tokens[1].is("TABLE"):
+		table := qualifiedName(skipIfExists(tokens[2:]))
+		if table == "" {
+			return ""
+		}
+		return fmt.Sprintf("This will drop the table %s and all its rows.", table)
+
+	case tokens[0].is("DROP") && len(tokens) > 1 && tokens[1].is("INDEX"):
+		index := qualifiedName(skipIfExists(tokens[2:]))
+		if index == "" {
+			return ""
+		}
+		return fmt.Sprintf("This will drop the index %s.", index)
+	}
+
+	return ""
+}
+
+// skipWithClause returns the tokens after the common table expressions of
+// a statement starting with WITH, or the given tokens otherwise.
+func skipWithClause(tokens []sqlToken) []sqlToken {
+	if len(tokens) == 0 || !tokens[0].is("WITH") {
+		return tokens
+	}
+
+	depth := 0
+	for i, token := range tokens {
+		switch {
+		case token.text == "(":
+			depth++
+		case token.text == ")":
+			depth--
+		case depth == 0 && i > 0 && (token.is("DELETE") || token.is("UPDATE") ||
+			token.is("INSERT") || token.is("REPLACE") || token.is("SELECT")):
+			return tokens[i:]
+		}
+	}
+	return nil
+}
+
+// skipIfExists returns the tokens after an "IF EXISTS" prefix.
+func skipIfExists(tokens []sqlToken) []sqlToken {
+	if len(tokens) >= 2 && tokens[0].is("IF") && tokens[1].is("EXISTS") {
+		return tokens[2:]
+	}
+	return tokens
+}
+
+// qualifiedName returns the name at the start of the tokens, with its schema
+// if it has one, like "main.users".
+func qualifiedName(tokens []sqlToken) string {
+	if len(tokens) == 0 || !isNameToken(tokens[0]) {
+		return ""
+	}
+	if len(tokens) >= 3 && tokens[1].text == "." && isNameToken(tokens[2]) {
+		return tokens[0].text + "." + tokens[2].text
+	}
+	return tokens[0].text
+}
+
+// isNameToken reports whether the token can be the name of a table or an
+// index: a word or a quoted identifier.
+func isNameToken(token sqlToken) bool {
+	c := token.text[0]
+	return c == '"' || c == '`' || c == '[' || isWordChar(c)
+}
+
+// statementTable returns the table of a DELETE or UPDATE statement, like
+// "users" in "DELETE FROM users" or "UPDATE OR IGNORE users SET ...".
+func statementTable(tokens []sqlToken) string {
+	rest := tokens[1:]
+	if tokens[0].is("DELETE") {
+		if len(rest) == 0 || !rest[0].is("FROM") {
+			return ""
+		}
+		return qualifiedName(rest[1:])
+	}
+
+	if len(rest) >= 2 && rest[0].is("OR") {
+		rest = rest[2:]
+	}
+	return qualifiedName(rest)
+}
+
+// hasTopLevelWhere reports whether the statement has a WHERE clause outside
+// of parentheses, so it applies to the statement itself.
+func hasTopLevelWhere(tokens []sqlToken) bool {
+	depth := 0
+	for _, token := range tokens {
+		switch {
+		case token.text == "(":
+			depth++
+		case token.text == ")":
+			depth--
+		case depth == 0 && token.is("WHERE"):
+			return true
+		}
+	}
+	return false
+}
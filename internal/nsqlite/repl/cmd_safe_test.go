@@ -0,0 +1,151 @@
+package repl
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestructiveWarning(t *testing.T) {
+	tests := []struct {
+		name      string
+		statement string
+		want      string
+	}{
+		{
+			name:      "Delete without where",
+			statement: "DELETE FROM users;",
+			want:      "This will affect all rows in users.",
+		},
+		{
+			name:      "Lowercase with schema",
+			statement: "delete from main.users",
+			want:      "This will affect all rows in main.users.",
+		},
+		{
+			name:      "Delete with where",
+			statement: "DELETE FROM users WHERE id = 1",
+		},
+		{
+			name:      "Where in a line comment",
+			statement: "DELETE FROM users -- WHERE id = 1\n;",
+			want:      "This will affect all rows in users.",
+		},
+		{
+			name:      "Where in a block comment of a subquery",
+			statement: "DELETE FROM users RETURNING (SELECT 1 /* WHERE id = 1 */)",
+			want:      "This will affect all rows in users.",
+		},
+		{
+			name:      "Where in a subquery with a comment",
+			statement: "DELETE FROM users WHERE id IN (SELECT user_id /* ; WHERE */ FROM bans)",
+		},
+		{
+			name:      "Where in a string",
+			statement: "UPDATE users SET note = 'set WHERE needed'",
+			want:      "This will affect all rows in users.",
+		},
+		{
+			name:      "Where in a quoted identifier",
+			statement: `UPDATE "where" SET "WHERE" = 1`,
+			want:      `This will affect all rows in "where".`,
+		},
+		{
+			name:      "Where only in a subquery",
+			statement: "UPDATE users SET plan = (SELECT plan FROM plans WHERE plans.id = 1)",
+			want:      "This will affect all rows in users.",
+		},
+		{
+			name:      "Update or ignore with where",
+			statement: "UPDATE OR IGNORE users SET name = 'x' WHERE id = 1",
+		},
+		{
+			name:      "Update or replace without where",
+			statement: "UPDATE OR REPLACE [users] SET name = 'x'",
+			want:      "This will affect all rows in [users].",
+		},
+		{
+			name:      "Update from with where",
+			statement: "UPDATE users SET plan = p.name FROM plans AS p WHERE p.id = users.plan_id",
+		},
+		{
+			name:      "Common table expression with where",
+			statement: "WITH old AS (SELECT id FROM users WHERE age > 99) DELETE FROM users",
+			want:      "This will affect all rows in users.",
+		},
+		{
+			name:      "Common table expression of a select",
+			statement: "WITH t AS (SELECT 1) SELECT * FROM t",
+		},
+		{
+			name:      "Drop table",
+			statement: `DROP TABLE IF EXISTS main."my table"`,
+			want:      `This will drop the table main."my table" and all its rows.`,
+		},
+		{
+			name:      "Drop index",
+			statement: "drop index users_email",
+			want:      "This will drop the index users_email.",
+		},
+		{
+			name:      "Drop view",
+			statement: "DROP VIEW active_users",
+		},
+		{
+			name:      "Trigger body",
+			statement: "CREATE TRIGGER cleanup AFTER DELETE ON users BEGIN DELETE FROM sessions; END;",
+		},
+		{
+			name:      "Statement in a string",
+			statement: "SELECT 'DELETE FROM users'",
+		},
+		{
+			name:      "Commented statement",
+			statement: "/* DROP TABLE users; */ SELECT 1",
+		},
+		{
+			name:      "Incomplete",
+			statement: "DELETE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, destructiveWarning(tt.statement))
+		})
+	}
+}
+
+func TestConfirmDestructive(t *testing.T) {
+	newRepl := func(answers string) *Repl {
+		return &Repl{safe: true, reader: bufio.NewReader(strings.NewReader(answers))}
+	}
+
+	t.Run("Safe statements are not confirmed", func(t *testing.T) {
+		r := newRepl("")
+		assert.True(t, confirmDestructive(r, "DELETE FROM users WHERE id = 1;"))
+	})
+
+	t.Run("Confirmed", func(t *testing.T) {
+		r := newRepl("y\n")
+		assert.True(t, confirmDestructive(r, "DELETE FROM users;"))
+	})
+
+	t.Run("Declined by default", func(t *testing.T) {
+		r := newRepl("\n")
+		assert.False(t, confirmDestructive(r, "DROP TABLE users;"))
+	})
+
+	t.Run("Every destructive statement is confirmed", func(t *testing.T) {
+		r := newRepl("y\nn\n")
+		assert.False(t, confirmDestructive(r, "DELETE FROM a; SELECT 1; DELETE FROM b;"))
+	})
+
+	t.Run("Safe mode off", func(t *testing.T) {
+		r := newRepl("")
+		r.safe = false
+		assert.True(t, confirmDestructive(r, "DROP TABLE users;"))
+	})
+}
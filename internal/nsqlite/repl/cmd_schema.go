@@ -0,0 +1,400 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// schemaIndent is the indentation of the column definitions and the trigger
+// statements in the formatted DDL.
+const schemaIndent = "  "
+
+// schemaObject is an object of the database schema as stored in
+// sqlite_master.
+type schemaObject struct {
+	typ     string
+	name    string
+	tblName string
+	sql     string
+}
+
+func cmdSchema(r *Repl, args string) {
+	table := strings.TrimSpace(args)
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	objects, err := fetchSchemaObjects(ctx, r.client, r.txId, table, "")
+	if err != nil {
+		fmt.Println("Failed to fetch schema:", err)
+		return
+	}
+	if len(objects) == 0 {
+		if table != "" {
+			fmt.Printf("No table named %s\n", table)
+		} else {
+			fmt.Println("The database has no schema")
+		}
+		return
+	}
+
+	r.writePaged([]byte(formatSchema(objects, r.output == os.Stdout)))
+}
+
+func cmdIndexes(r *Repl, args string) {
+	table := strings.TrimSpace(args)
+	if table == "" {
+		cmdQuery(r, `
+			SELECT name
+			FROM sqlite_master
+			WHERE type = 'index'
+			ORDER BY 1
+		`, nil)
+		return
+	}
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	objects, err := fetchSchemaObjects(ctx, r.client, r.txId, table, "index")
+	if err != nil {
+		fmt.Println("Failed to fetch indexes:", err)
+		return
+	}
+	if len(objects) == 0 {
+		exists, err := fetchSchemaObjects(ctx, r.client, r.txId, table, "")
+		if err != nil {
+			fmt.Println("Failed to fetch indexes:", err)
+			return
+		}
+		if len(exists) == 0 {
+			fmt.Printf("No table named %s\n", table)
+		} else {
+			fmt.Printf("Table %s has no indexes\n", table)
+		}
+		return
+	}
+
+	r.writePaged([]byte(formatSchema(objects, r.output == os.Stdout)))
+}
+
+// fetchSchemaObjects returns the objects of the schema ordered by table, with
+// each table followed by its indexes and triggers. The objects are filtered
+// by table name and type when they are not empty. Automatic indexes, which
+// have no SQL, are included.
+func fetchSchemaObjects(
+	ctx context.Context, client queryClient, txId string, table string, typ string,
+) ([]schemaObject, error) {
+	res, err := sendTxQuery(ctx, client, txId, `
+		SELECT type, name, tbl_name, COALESCE(sql, '')
+		FROM sqlite_master
+		WHERE (:table_name = '' OR tbl_name = :table_name COLLATE NOCASE)
+			AND (:type = '' OR type = :type)
+			AND name NOT LIKE 'sqlite_stat%'
+		ORDER BY
+			tbl_name,
+			CASE type WHEN 'table' THEN 0 WHEN 'view' THEN 1 WHEN 'index' THEN 2 ELSE 3 END,
+			name
+	`,
+		nsqlitehttp.QueryParam{Name: "table_name", Value: table},
+		nsqlitehttp.QueryParam{Name: "type", Value: typ},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]schemaObject, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		if len(row) < 4 {
+			continue
+		}
+		objects = append(objects, schemaObject{
+			typ:     fmt.Sprint(row[0]),
+			name:    fmt.Sprint(row[1]),
+			tblName: fmt.Sprint(row[2]),
+			sql:     fmt.Sprint(row[3]),
+		})
+	}
+	return objects, nil
+}
+
+// formatSchema formats the objects as SQL blocks grouped under a comment with
+// the name of their table. Keywords are highlighted if highlight is true.
+func formatSchema(objects []schemaObject, highlight bool) string {
+	sb := strings.Builder{}
+
+	for i, object := range objects {
+		if i == 0 || object.tblName != objects[i-1].tblName {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			header := "-- " + object.tblName
+			if highlight {
+				header = styled.DimmedColor().Sprint(header)
+			}
+			sb.WriteString(header + "\n")
+		}
+
+		if object.sql == "" {
+			comment := fmt.Sprintf("-- %s (automatic index)", object.name)
+			if highlight {
+				comment = styled.DimmedColor().Sprint(comment)
+			}
+			sb.WriteString(comment + "\n")
+			continue
+		}
+
+		ddl := formatDDL(object.sql)
+		if highlight {
+			ddl = highlightSQL(ddl)
+		}
+		sb.WriteString(ddl + ";\n")
+	}
+
+	return sb.String()
+}
+
+// formatDDL formats a CREATE statement: whitespace outside of strings and
+// quoted identifiers is collapsed, the column definitions of a table are
+// written one per line and the statements of a trigger body are written one
+// per line between BEGIN and END.
+func formatDDL(sql string) string {
+	tokens := tokenizeSQL(sql)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	isTable := isCreateOf(tokens, "TABLE")
+	isTrigger := isCreateOf(tokens, "TRIGGER")
+
+	sb := strings.Builder{}
+	depth := 0
+	inBody := false
+	lineStart := true
+
+	write := func(token sqlToken) {
+		if !lineStart && token.spaced {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(token.text)
+		lineStart = false
+	}
+	newline := func(indent int) {
+		sb.WriteString("\n" + strings.Repeat(schemaIndent, indent))
+		lineStart = true
+	}
+
+	for _, token := range tokens {
+		switch {
+		case isTable && token.text == "(" && depth == 0:
+			write(token)
+			depth++
+			newline(1)
+		case isTable && token.text == ")" && depth == 1:
+			depth--
+			newline(0)
+			write(sqlToken{text: token.text})
+		case isTable && token.text == "," && depth == 1:
+			sb.WriteString(token.text)
+			newline(1)
+
+		case isTrigger && !inBody && token.is("BEGIN"):
+			write(token)
+			inBody = true
+			newline(1)
+		case isTrigger && inBody && token.text == ";":
+			sb.WriteString(token.text)
+			newline(1)
+		case isTrigger && inBody && lineStart && token.is("END"):
+			// An END at the start of a line follows the last statement of
+			// the body, the END of a CASE expression never does.
+			trimmed := strings.TrimRight(sb.String(), " ")
+			sb.Reset()
+			sb.WriteString(trimmed)
+			inBody = false
+			write(token)
+
+		default:
+			switch token.text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			}
+			write(token)
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// sqlToken is a token of a SQL statement.
+type sqlToken struct {
+	text string
+	// spaced reports whether the token was preceded by whitespace or a
+	// comment.
+	spaced bool
+}
+
+// is reports whether the token is the given keyword, ignoring the case.
+func (t sqlToken) is(keyword string) bool {
+	return strings.EqualFold(t.text, keyword)
+}
+
+// isCreateOf reports whether the tokens are a CREATE statement of the given
+// object type, like "CREATE TEMP TABLE" or "CREATE TRIGGER".
+func isCreateOf(tokens []sqlToken, typ string) bool {
+	if len(tokens) == 0 || !tokens[0].is("CREATE") {
+		return false
+	}
+	for _, token := range tokens[1:min(len(tokens), 4)] {
+		if token.is(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenizeSQL splits the statement into words, quoted strings and
+// identifiers, and punctuation, dropping whitespace and comments.
+func tokenizeSQL(sql string) []sqlToken {
+	tokens := []sqlToken{}
+	spaced := false
+	add := func(text string) {
+		tokens = append(tokens, sqlToken{text: text, spaced: spaced})
+		spaced = false
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			spaced = true
+
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end == -1 {
+				return tokens
+			}
+			i += end
+			spaced = true
+
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				return tokens
+			}
+			i += end + 3
+			spaced = true
+
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			start := i
+			for i++; i < len(sql); i++ {
+				if sql[i] != closing {
+					continue
+				}
+				// Quotes are escaped by doubling them.
+				if closing != ']' && i+1 < len(sql) && sql[i+1] == closing {
+					i++
+					continue
+				}
+				break
+			}
+			add(sql[start:min(i+1, len(sql))])
+
+		case isWordChar(c):
+			start := i
+			for i+1 < len(sql) && isWordChar(sql[i+1]) {
+				i++
+			}
+			add(sql[start : i+1])
+
+		default:
+			add(string(c))
+		}
+	}
+
+	return tokens
+}
+
+// ddlKeywords are the keywords highlighted in the formatted DDL.
+var ddlKeywords = map[string]bool{
+	"CREATE": true, "TABLE": true, "VIEW": true, "INDEX": true, "UNIQUE": true,
+	"TRIGGER": true, "VIRTUAL": true, "TEMP": true, "TEMPORARY": true, "IF": true,
+	"NOT": true, "EXISTS": true, "ON": true, "PRIMARY": true, "KEY": true,
+	"FOREIGN": true, "REFERENCES": true, "NULL": true, "DEFAULT": true,
+	"CHECK": true, "CONSTRAINT": true, "AUTOINCREMENT": true, "COLLATE": true,
+	"ASC": true, "DESC": true, "WITHOUT": true, "ROWID": true, "STRICT": true,
+	"GENERATED": true, "ALWAYS": true, "STORED": true, "AS": true, "CASCADE": true,
+	"DELETE": true, "UPDATE": true, "INSERT": true, "INTO": true, "VALUES": true,
+	"SET": true, "SELECT": true, "FROM": true, "WHERE": true, "AND": true,
+	"OR": true, "BEGIN": true, "END": true, "BEFORE": true, "AFTER": true,
+	"INSTEAD": true, "OF": true, "FOR": true, "EACH": true, "ROW": true,
+	"WHEN": true, "NEW": true, "OLD": true, "USING": true, "INTEGER": true,
+	"TEXT": true, "REAL": true, "BLOB": true, "NUMERIC": true, "ANY": true,
+}
+
+// highlightSQL colors the keywords and the strings of the formatted SQL.
+func highlightSQL(sql string) string {
+	keywordColor := color.New(color.FgCyan, color.Bold)
+	stringColor := color.New(color.FgGreen)
+
+	sb := strings.Builder{}
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == '\'':
+			start := i
+			for i++; i < len(sql); i++ {
+				if sql[i] == '\'' && (i+1 >= len(sql) || sql[i+1] != '\'') {
+					break
+				}
+				if sql[i] == '\'' {
+					i++
+				}
+			}
+			sb.WriteString(stringColor.Sprint(sql[start:min(i+1, len(sql))]))
+
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(sql[i+1:], closing)
+			if end == -1 {
+				sb.WriteString(sql[i:])
+				return sb.String()
+			}
+			sb.WriteString(sql[i : i+end+2])
+			i += end + 1
+
+		case isWordChar(c):
+			start := i
+			for i+1 < len(sql) && isWordChar(sql[i+1]) {
+				i++
+			}
+			word := sql[start : i+1]
+			if ddlKeywords[strings.ToUpper(word)] {
+				word = keywordColor.Sprint(word)
+			}
+			sb.WriteString(word)
+
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
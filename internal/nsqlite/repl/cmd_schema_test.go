@@ -0,0 +1,127 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchSchemaObjects(t *testing.T) {
+	conn := openTestConn(t, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT UNIQUE);
+		CREATE INDEX users_email ON users (email);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER);
+		CREATE INDEX posts_user_id ON posts (user_id);
+		CREATE TRIGGER users_delete AFTER DELETE ON users BEGIN DELETE FROM posts WHERE user_id = OLD.id; END;
+	`)
+	client := fakeQueryClient{conn: conn}
+
+	names := func(objects []schemaObject) []string {
+		result := []string{}
+		for _, object := range objects {
+			result = append(result, object.name)
+		}
+		return result
+	}
+
+	t.Run("all tables grouped with their indexes and triggers", func(t *testing.T) {
+		objects, err := fetchSchemaObjects(context.Background(), client, "", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"posts", "posts_user_id",
+			"users", "sqlite_autoindex_users_1", "users_email", "users_delete",
+		}, names(objects))
+	})
+
+	t.Run("filter by table", func(t *testing.T) {
+		objects, err := fetchSchemaObjects(context.Background(), client, "", "USERS", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"users", "sqlite_autoindex_users_1", "users_email", "users_delete",
+		}, names(objects))
+		assert.Equal(t, "", objects[1].sql)
+	})
+
+	t.Run("filter by table and type", func(t *testing.T) {
+		objects, err := fetchSchemaObjects(context.Background(), client, "", "posts", "index")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"posts_user_id"}, names(objects))
+	})
+
+	t.Run("unknown table", func(t *testing.T) {
+		objects, err := fetchSchemaObjects(context.Background(), client, "", "missing", "")
+		require.NoError(t, err)
+		assert.Empty(t, objects)
+	})
+}
+
+func TestFormatDDL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "multi-line table",
+			sql: `CREATE TABLE users (
+				id    INTEGER PRIMARY KEY,   -- the id
+				name  VARCHAR(100) NOT NULL DEFAULT 'a,  b',
+				  CHECK (length(name) > 0)
+			)`,
+			want: "CREATE TABLE users (\n" +
+				"  id INTEGER PRIMARY KEY,\n" +
+				"  name VARCHAR(100) NOT NULL DEFAULT 'a,  b',\n" +
+				"  CHECK (length(name) > 0)\n" +
+				")",
+		},
+		{
+			name: "single-line table",
+			sql:  `CREATE TABLE IF NOT EXISTS "my table"(a, b)`,
+			want: "CREATE TABLE IF NOT EXISTS \"my table\"(\n  a,\n  b\n)",
+		},
+		{
+			name: "index",
+			sql:  "CREATE INDEX users_name\n  ON users (name,\n email)",
+			want: "CREATE INDEX users_name ON users (name, email)",
+		},
+		{
+			name: "trigger",
+			sql: `CREATE TRIGGER users_delete AFTER DELETE ON users
+				BEGIN
+					DELETE FROM posts WHERE user_id = OLD.id;
+					UPDATE stats SET n = CASE WHEN n > 0 THEN n - 1 ELSE 0 END;
+				END`,
+			want: "CREATE TRIGGER users_delete AFTER DELETE ON users BEGIN\n" +
+				"  DELETE FROM posts WHERE user_id = OLD.id;\n" +
+				"  UPDATE stats SET n = CASE WHEN n > 0 THEN n - 1 ELSE 0 END;\n" +
+				"END",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatDDL(tt.sql))
+		})
+	}
+}
+
+func TestFormatSchema(t *testing.T) {
+	objects := []schemaObject{
+		{typ: "table", name: "posts", tblName: "posts", sql: "CREATE TABLE posts (id INTEGER)"},
+		{typ: "table", name: "users", tblName: "users", sql: "CREATE TABLE users (email TEXT UNIQUE)"},
+		{typ: "index", name: "sqlite_autoindex_users_1", tblName: "users"},
+		{typ: "index", name: "users_email", tblName: "users", sql: "CREATE INDEX users_email ON users (email)"},
+	}
+
+	assert.Equal(t, "-- posts\n"+
+		"CREATE TABLE posts (\n  id INTEGER\n);\n"+
+		"\n"+
+		"-- users\n"+
+		"CREATE TABLE users (\n  email TEXT UNIQUE\n);\n"+
+		"-- sqlite_autoindex_users_1 (automatic index)\n"+
+		"CREATE INDEX users_email ON users (email);\n",
+		formatSchema(objects, false),
+	)
+}
@@ -0,0 +1,114 @@
+package repl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+)
+
+func cmdNullValue(r *Repl, args string) {
+	token := strings.TrimSpace(args)
+	if token == "" {
+		fmt.Printf("Current NULL value: %s\n", r.render.displayNull())
+		return
+	}
+
+	r.render.nullValue = token
+}
+
+func cmdHeaders(r *Repl, args string) {
+	switch strings.TrimSpace(args) {
+	case "on":
+		r.render.headers = true
+	case "off":
+		r.render.headers = false
+	case "":
+		fmt.Printf("Headers are %s\n", onOff(r.render.headers))
+	default:
+		fmt.Println("Usage: .headers on|off")
+	}
+}
+
+func cmdWidth(r *Repl, args string) {
+	arg := strings.TrimSpace(args)
+	if arg == "" {
+		fmt.Printf("Current width: %s\n", formatWidth(r.render.width))
+		return
+	}
+
+	width, err := strconv.Atoi(arg)
+	if err != nil || width < 0 {
+		fmt.Println("Usage: .width n (0 for unlimited)")
+		return
+	}
+
+	r.render.width = width
+}
+
+func cmdPaging(r *Repl, args string) {
+	switch strings.TrimSpace(args) {
+	case "on":
+		r.paging = true
+	case "off":
+		r.paging = false
+	case "":
+		fmt.Printf("Paging is %s\n", onOff(r.paging))
+	default:
+		fmt.Println("Usage: .paging on|off")
+	}
+}
+
+func cmdVerbose(r *Repl, args string) {
+	switch strings.TrimSpace(args) {
+	case "on":
+		r.httpLog.SetEnabled(true)
+	case "off":
+		r.httpLog.SetEnabled(false)
+	case "":
+		fmt.Printf("Verbose mode is %s\n", onOff(r.httpLog.Enabled()))
+	default:
+		fmt.Println("Usage: .verbose on|off")
+	}
+}
+
+func cmdSettings(r *Repl) {
+	output := "stdout"
+	if r.outputFile != nil {
+		output = r.outputFile.Name()
+	}
+
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Setting", "Value"})
+	tw.AppendRows([]table.Row{
+		{"mode", r.render.mode},
+		{"output", output},
+		{"headers", onOff(r.render.headers)},
+		{"nullvalue", r.render.displayNull()},
+		{"width", formatWidth(r.render.width)},
+		{"timer", onOff(r.timer)},
+		{"paging", onOff(r.paging)},
+		{"eqp", onOff(r.eqp)},
+		{"safe", onOff(r.safe)},
+		{"verbose", onOff(r.httpLog.Enabled())},
+	})
+	fmt.Println(tw.Render())
+}
+
+// onOff formats a boolean setting as "on" or "off".
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// formatWidth formats the maximum width of the cells.
+func formatWidth(width int) string {
+	if width == 0 {
+		return "unlimited"
+	}
+	return strconv.Itoa(width)
+}
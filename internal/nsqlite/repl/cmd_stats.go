@@ -1,64 +1,348 @@
 package repl
 
 import (
-	"context"
 	"fmt"
 	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
 	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
 	"github.com/nsqlite/nsqlite/internal/util/numutil"
 )
 
-func cmdStats(r *Repl, statsQty int) {
-	stats, err := r.client.GetStats(context.Background())
+// defaultStatsMinutes is the number of minutes shown by .stats by default.
+const defaultStatsMinutes = 5
+
+// sparkBlocks are the characters of the sparklines, from the lowest value to
+// the highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// statsMetric is a metric shown by .stats.
+type statsMetric struct {
+	// name is the name used to select the metric in the .stats arguments.
+	name   string
+	header string
+	// value returns the value of the metric in the counters, ok is false if
+	// the server didn't report it.
+	value  func(c apiclient.StatsCounters) (value float64, ok bool)
+	format func(value float64) string
+}
+
+// counterMetric returns a statsMetric for a counter.
+func counterMetric(name string, header string, field func(c apiclient.StatsCounters) *int64) statsMetric {
+	return statsMetric{
+		name:   name,
+		header: header,
+		value: func(c apiclient.StatsCounters) (float64, bool) {
+			if v := field(c); v != nil {
+				return float64(*v), true
+			}
+			return 0, false
+		},
+		format: func(value float64) string {
+			return numutil.IntWithCommas(int64(value))
+		},
+	}
+}
+
+// latencyMetric returns a statsMetric for a latency percentile.
+func latencyMetric(name string, header string, field func(l apiclient.LatencyPercentiles) float64) statsMetric {
+	return statsMetric{
+		name:   name,
+		header: header,
+		value: func(c apiclient.StatsCounters) (float64, bool) {
+			if c.Latency != nil {
+				return field(*c.Latency), true
+			}
+			return 0, false
+		},
+		format: func(value float64) string {
+			return strconv.FormatFloat(value, 'f', 1, 64)
+		},
+	}
+}
+
+// statsMetrics are the metrics shown by .stats, in order.
+var statsMetrics = []statsMetric{
+	counterMetric("reads", "Reads", func(c apiclient.StatsCounters) *int64 { return c.Reads }),
+	counterMetric("writes", "Writes", func(c apiclient.StatsCounters) *int64 { return c.Writes }),
+	counterMetric("begins", "Begins", func(c apiclient.StatsCounters) *int64 { return c.Begins }),
+	counterMetric("commits", "Commits", func(c apiclient.StatsCounters) *int64 { return c.Commits }),
+	counterMetric("rollbacks", "Rollbacks", func(c apiclient.StatsCounters) *int64 { return c.Rollbacks }),
+	counterMetric("errors", "Errors", func(c apiclient.StatsCounters) *int64 { return c.Errors }),
+	counterMetric("requests", "Requests", func(c apiclient.StatsCounters) *int64 { return c.HTTPRequests }),
+	latencyMetric("p50", "p50 (ms)", func(l apiclient.LatencyPercentiles) float64 { return l.P50 }),
+	latencyMetric("p95", "p95 (ms)", func(l apiclient.LatencyPercentiles) float64 { return l.P95 }),
+	latencyMetric("p99", "p99 (ms)", func(l apiclient.LatencyPercentiles) float64 { return l.P99 }),
+}
+
+func cmdStats(r *Repl, args string) {
+	minutes, metric, err := parseStatsArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	stats, err := r.api.Stats(ctx)
 	if err != nil {
 		fmt.Println("Failed to get stats:", err)
 		return
 	}
 
-	tw := styled.NewTableWriter()
-	tw.AppendHeader(table.Row{"Minute (UTC)", "Reads", "Writes", "Begins", "Commits", "Rollbacks", "Errors", "Requests"})
+	fmt.Print(renderStats(stats, minutes, metric))
+	fmt.Println()
+}
+
+// parseStatsArgs parses the arguments of the .stats command in the format
+// "[minutes] [metric]".
+func parseStatsArgs(args string) (int, string, error) {
+	names := make([]string, len(statsMetrics))
+	for i, metric := range statsMetrics {
+		names[i] = metric.name
+	}
+	usage := fmt.Errorf("usage: .stats [minutes] [metric], metric is one of %s", strings.Join(names, ", "))
+
+	fields := strings.Fields(args)
+	if len(fields) > 2 {
+		return 0, "", usage
+	}
 
-	rows := []table.Row{}
-	for i, stat := range stats.Stats {
-		if i >= statsQty {
-			break
+	minutes := defaultStatsMinutes
+	if len(fields) > 0 {
+		if n, err := strconv.Atoi(fields[0]); err == nil {
+			if n < 1 {
+				return 0, "", usage
+			}
+			minutes = n
+			fields = fields[1:]
 		}
+	}
+
+	metric := ""
+	if len(fields) == 1 {
+		metric = strings.ToLower(fields[0])
+		if !slices.Contains(names, metric) {
+			return 0, "", usage
+		}
+	} else if len(fields) > 1 {
+		return 0, "", usage
+	}
+
+	return minutes, metric, nil
+}
 
-		minute, err := time.Parse(time.RFC3339, stat.Minute)
+// statsWindowMinute is a minute of the window shown by .stats.
+type statsWindowMinute struct {
+	start time.Time
+	stat  *apiclient.StatsMinute
+}
+
+// statsWindow returns the last minutes of the stats ending at the newest
+// minute reported by the server, oldest first. The minutes without activity
+// have a nil stat.
+func statsWindow(stats []apiclient.StatsMinute, minutes int) []statsWindowMinute {
+	byMinute := map[time.Time]*apiclient.StatsMinute{}
+	newest := time.Time{}
+	for i := range stats {
+		start, err := time.Parse(time.RFC3339, stats[i].Minute)
 		if err != nil {
 			continue
 		}
+		byMinute[start] = &stats[i]
+		if start.After(newest) {
+			newest = start
+		}
+	}
+	if newest.IsZero() {
+		return nil
+	}
+
+	window := make([]statsWindowMinute, minutes)
+	for i := range window {
+		start := newest.Add(-time.Duration(minutes-1-i) * time.Minute)
+		window[i] = statsWindowMinute{start: start, stat: byMinute[start]}
+	}
+	return window
+}
+
+// renderStats renders the stats of the last minutes: a table with the
+// metrics of each minute, a sparkline of each metric, the errors by kind and
+// the current queues. If metric is not empty, only that metric is shown and
+// the minutes are sorted by it. The metrics that the server doesn't report
+// are not shown.
+func renderStats(stats apiclient.Stats, minutes int, metric string) string {
+	window := statsWindow(stats.Stats, minutes)
+
+	metrics := []statsMetric{}
+	for _, m := range statsMetrics {
+		if metric != "" && m.name != metric {
+			continue
+		}
+		if isMetricReported(m, stats.Totals, window) {
+			metrics = append(metrics, m)
+		}
+	}
+	if metric != "" && len(metrics) == 0 {
+		return fmt.Sprintf("The server doesn't report the %s metric\n", metric)
+	}
+
+	sb := strings.Builder{}
+
+	tw := styled.NewTableWriter()
+	header := table.Row{"Minute (UTC)"}
+	for _, m := range metrics {
+		header = append(header, m.header)
+	}
+	tw.AppendHeader(header)
+
+	reported := []statsWindowMinute{}
+	for _, minute := range window {
+		if minute.stat != nil {
+			reported = append(reported, minute)
+		}
+	}
+	if metric != "" {
+		sort.SliceStable(reported, func(i, j int) bool {
+			vi, _ := metrics[0].value(reported[i].stat.StatsCounters)
+			vj, _ := metrics[0].value(reported[j].stat.StatsCounters)
+			return vi > vj
+		})
+	}
+	for _, minute := range reported {
+		row := table.Row{minute.start.Format("2006-01-02 15:04")}
+		for _, m := range metrics {
+			row = append(row, formatMetric(m, minute.stat.StatsCounters))
+		}
+		tw.AppendRow(row)
+	}
+
+	footer := table.Row{"Total"}
+	for _, m := range metrics {
+		footer = append(footer, formatMetric(m, stats.Totals))
+	}
+	tw.AppendFooter(footer)
+	sb.WriteString(tw.Render() + "\n")
+
+	if len(window) > 0 {
+		sb.WriteString(renderSparklines(metrics, window) + "\n")
+	}
+
+	if len(stats.Totals.ErrorsByKind) > 0 && (metric == "" || metric == "errors") {
+		sb.WriteString(renderErrorsByKind(stats.Totals.ErrorsByKind) + "\n")
+	}
+
+	if metric == "" && (stats.QueuedWrites != nil || stats.QueuedHTTPRequests != nil) {
+		tw := styled.NewTableWriter()
+		tw.AppendHeader(table.Row{"Queued Now", "Count"})
+		if stats.QueuedWrites != nil {
+			tw.AppendRow(table.Row{"Writes", numutil.IntWithCommas(*stats.QueuedWrites)})
+		}
+		if stats.QueuedHTTPRequests != nil {
+			tw.AppendRow(table.Row{"Requests", numutil.IntWithCommas(*stats.QueuedHTTPRequests)})
+		}
+		sb.WriteString(tw.Render() + "\n")
+	}
+
+	sb.WriteString(styled.DimmedColor().Sprintf("Showing the last %d minutes of stats\n", minutes))
+	if stats.Uptime != "" {
+		sb.WriteString(styled.DimmedColor().Sprintf("Uptime: %s\n", stats.Uptime))
+	}
+	return sb.String()
+}
+
+// isMetricReported reports whether the server reported the metric in the
+// totals or in any minute of the window.
+func isMetricReported(m statsMetric, totals apiclient.StatsCounters, window []statsWindowMinute) bool {
+	if _, ok := m.value(totals); ok {
+		return true
+	}
+	for _, minute := range window {
+		if minute.stat == nil {
+			continue
+		}
+		if _, ok := m.value(minute.stat.StatsCounters); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatMetric formats the value of the metric in the counters, or returns
+// an empty string if it is not reported.
+func formatMetric(m statsMetric, counters apiclient.StatsCounters) string {
+	value, ok := m.value(counters)
+	if !ok {
+		return ""
+	}
+	return m.format(value)
+}
+
+// renderSparklines renders a table with the sparkline of each metric over
+// the window, with its minimum and maximum values. The minutes without
+// activity count as zero.
+func renderSparklines(metrics []statsMetric, window []statsWindowMinute) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Metric", fmt.Sprintf("Last %d Minutes", len(window)), "Min", "Max"})
 
-		rows = append(rows, table.Row{
-			minute.Format("2006-01-02 15:04"),
-			numutil.IntWithCommas(stat.Reads),
-			numutil.IntWithCommas(stat.Writes),
-			numutil.IntWithCommas(stat.Begins),
-			numutil.IntWithCommas(stat.Commits),
-			numutil.IntWithCommas(stat.Rollbacks),
-			numutil.IntWithCommas(stat.Errors),
-			numutil.IntWithCommas(stat.HTTPRequests),
+	for _, m := range metrics {
+		values := make([]float64, len(window))
+		for i, minute := range window {
+			if minute.stat != nil {
+				values[i], _ = m.value(minute.stat.StatsCounters)
+			}
+		}
+		tw.AppendRow(table.Row{
+			m.header, sparkline(values), m.format(slices.Min(values)), m.format(slices.Max(values)),
 		})
 	}
-	slices.Reverse(rows)
-	tw.AppendRows(rows)
-
-	tw.AppendFooter(table.Row{
-		"Total",
-		numutil.IntWithCommas(stats.Totals.Reads),
-		numutil.IntWithCommas(stats.Totals.Writes),
-		numutil.IntWithCommas(stats.Totals.Begins),
-		numutil.IntWithCommas(stats.Totals.Commits),
-		numutil.IntWithCommas(stats.Totals.Rollbacks),
-		numutil.IntWithCommas(stats.Totals.Errors),
-		numutil.IntWithCommas(stats.Totals.HTTPRequests),
+
+	return tw.Render()
+}
+
+// sparkline returns a line of block characters with the height of each value
+// relative to the minimum and maximum values.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	lo, hi := slices.Min(values), slices.Max(values)
+	sb := strings.Builder{}
+	for _, value := range values {
+		level := 0
+		if hi > lo {
+			level = int((value-lo)/(hi-lo)*float64(len(sparkBlocks)-1) + 0.5)
+		}
+		sb.WriteRune(sparkBlocks[level])
+	}
+	return sb.String()
+}
+
+// renderErrorsByKind renders a table with the number of errors of each kind,
+// the most frequent first.
+func renderErrorsByKind(errorsByKind map[string]int64) string {
+	kinds := make([]string, 0, len(errorsByKind))
+	for kind := range errorsByKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if errorsByKind[kinds[i]] != errorsByKind[kinds[j]] {
+			return errorsByKind[kinds[i]] > errorsByKind[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
 	})
 
-	fmt.Println(tw.Render())
-	styled.DimmedColor().Printf("Showing the last %d minutes of stats\n", statsQty)
-	styled.DimmedColor().Printf("Uptime: %s\n", stats.Uptime)
-	fmt.Println()
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Error Kind", "Count"})
+	for _, kind := range kinds {
+		tw.AppendRow(table.Row{kind, numutil.IntWithCommas(errorsByKind[kind])})
+	}
+	return tw.Render()
 }
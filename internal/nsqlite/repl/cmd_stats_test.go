@@ -0,0 +1,172 @@
+package repl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oldServerStats is the stats fixture of a server that doesn't report the
+// latency and the errors by kind.
+const oldServerStats = `{
+	"startedAt": "2026-10-16T09:00:00Z",
+	"uptime": "1h5m0s",
+	"queuedWrites": 2,
+	"queuedHttpRequests": 0,
+	"totals": {"reads": 120, "writes": 30, "begins": 3, "commits": 2, "rollbacks": 1, "errors": 4, "httpRequests": 160},
+	"stats": [
+		{"minute": "2026-10-16T10:04:00Z", "reads": 50, "writes": 10, "begins": 1, "commits": 1, "rollbacks": 0, "errors": 0, "httpRequests": 61},
+		{"minute": "2026-10-16T10:02:00Z", "reads": 20, "writes": 15, "begins": 2, "commits": 1, "rollbacks": 1, "errors": 4, "httpRequests": 40},
+		{"minute": "2026-10-16T10:01:00Z", "reads": 10, "writes": 5, "begins": 0, "commits": 0, "rollbacks": 0, "errors": 0, "httpRequests": 15},
+		{"minute": "2026-10-16T09:30:00Z", "reads": 40, "writes": 0, "begins": 0, "commits": 0, "rollbacks": 0, "errors": 0, "httpRequests": 44}
+	]
+}`
+
+// newServerStats is the stats fixture of a server that reports the latency
+// percentiles and the errors by kind, but not the queues.
+const newServerStats = `{
+	"uptime": "10m0s",
+	"totals": {
+		"reads": 30, "errors": 3,
+		"latency": {"p50": 1.25, "p95": 8, "p99": 20.5},
+		"errorsByKind": {"sql": 2, "auth": 1}
+	},
+	"stats": [
+		{"minute": "2026-10-16T10:01:00Z", "reads": 20, "errors": 1, "latency": {"p50": 1, "p95": 4, "p99": 9}},
+		{"minute": "2026-10-16T10:00:00Z", "reads": 10, "errors": 2, "latency": {"p50": 2, "p95": 12, "p99": 30}}
+	]
+}`
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "", sparkline(nil))
+	assert.Equal(t, "▁▁▁", sparkline([]float64{3, 3, 3}))
+	assert.Equal(t, "▁▅█", sparkline([]float64{0, 10, 20}))
+	assert.Equal(t, "▁▂▃▄▅▆▇█", sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}))
+}
+
+func TestParseStatsArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		minutes int
+		metric  string
+		wantErr bool
+	}{
+		{args: "", minutes: 5},
+		{args: " 15 ", minutes: 15},
+		{args: "Reads", minutes: 5, metric: "reads"},
+		{args: "10 p99", minutes: 10, metric: "p99"},
+		{args: "0", wantErr: true},
+		{args: "latency", wantErr: true},
+		{args: "10 reads writes", wantErr: true},
+		{args: "reads 10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			minutes, metric, err := parseStatsArgs(tt.args)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "usage: .stats [minutes] [metric]")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.minutes, minutes)
+			assert.Equal(t, tt.metric, metric)
+		})
+	}
+}
+
+func TestRenderStats(t *testing.T) {
+	text.DisableColors()
+	defer text.EnableColors()
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	load := func(t *testing.T, fixture string) apiclient.Stats {
+		stats := apiclient.Stats{}
+		require.NoError(t, json.Unmarshal([]byte(fixture), &stats))
+		return stats
+	}
+
+	t.Run("Old server", func(t *testing.T) {
+		want := strings.Join([]string{
+			"┌──────────────────┬───────┬────────┬────────┬─────────┬───────────┬────────┬──────────┐",
+			"│ Minute (UTC)     │ Reads │ Writes │ Begins │ Commits │ Rollbacks │ Errors │ Requests │",
+			"├──────────────────┼───────┼────────┼────────┼─────────┼───────────┼────────┼──────────┤",
+			"│ 2026-10-16 10:01 │ 10    │ 5      │ 0      │ 0       │ 0         │ 0      │ 15       │",
+			"│ 2026-10-16 10:02 │ 20    │ 15     │ 2      │ 1       │ 1         │ 4      │ 40       │",
+			"│ 2026-10-16 10:04 │ 50    │ 10     │ 1      │ 1       │ 0         │ 0      │ 61       │",
+			"├──────────────────┼───────┼────────┼────────┼─────────┼───────────┼────────┼──────────┤",
+			"│ TOTAL            │ 120   │ 30     │ 3      │ 2       │ 1         │ 4      │ 160      │",
+			"└──────────────────┴───────┴────────┴────────┴─────────┴───────────┴────────┴──────────┘",
+			"┌───────────┬────────────────┬─────┬─────┐",
+			"│ Metric    │ Last 5 Minutes │ Min │ Max │",
+			"├───────────┼────────────────┼─────┼─────┤",
+			"│ Reads     │ ▁▂▄▁█          │ 0   │ 50  │",
+			"│ Writes    │ ▁▃█▁▆          │ 0   │ 15  │",
+			"│ Begins    │ ▁▁█▁▅          │ 0   │ 2   │",
+			"│ Commits   │ ▁▁█▁█          │ 0   │ 1   │",
+			"│ Rollbacks │ ▁▁█▁▁          │ 0   │ 1   │",
+			"│ Errors    │ ▁▁█▁▁          │ 0   │ 4   │",
+			"│ Requests  │ ▁▃▆▁█          │ 0   │ 61  │",
+			"└───────────┴────────────────┴─────┴─────┘",
+			"┌────────────┬───────┐",
+			"│ Queued Now │ Count │",
+			"├────────────┼───────┤",
+			"│ Writes     │ 2     │",
+			"│ Requests   │ 0     │",
+			"└────────────┴───────┘",
+			"Showing the last 5 minutes of stats",
+			"Uptime: 1h5m0s",
+			"",
+		}, "\n")
+		assert.Equal(t, want, renderStats(load(t, oldServerStats), 5, ""))
+	})
+
+	t.Run("Sorted by a metric", func(t *testing.T) {
+		want := strings.Join([]string{
+			"┌──────────────────┬───────┐",
+			"│ Minute (UTC)     │ Reads │",
+			"├──────────────────┼───────┤",
+			"│ 2026-10-16 10:04 │ 50    │",
+			"│ 2026-10-16 10:02 │ 20    │",
+			"│ 2026-10-16 10:01 │ 10    │",
+			"├──────────────────┼───────┤",
+			"│ TOTAL            │ 120   │",
+			"└──────────────────┴───────┘",
+			"┌────────┬────────────────┬─────┬─────┐",
+			"│ Metric │ Last 5 Minutes │ Min │ Max │",
+			"├────────┼────────────────┼─────┼─────┤",
+			"│ Reads  │ ▁▂▄▁█          │ 0   │ 50  │",
+			"└────────┴────────────────┴─────┴─────┘",
+			"Showing the last 5 minutes of stats",
+			"Uptime: 1h5m0s",
+			"",
+		}, "\n")
+		assert.Equal(t, want, renderStats(load(t, oldServerStats), 5, "reads"))
+	})
+
+	t.Run("Latency and errors by kind", func(t *testing.T) {
+		output := renderStats(load(t, newServerStats), 3, "")
+
+		assert.Contains(t, output, "│ Minute (UTC)     │ Reads │ Errors │ p50 (ms) │ p95 (ms) │ p99 (ms) │\n")
+		assert.Contains(t, output, "│ TOTAL            │ 30    │ 3      │ 1.2      │ 8.0      │ 20.5     │\n")
+		assert.Contains(t, output, "│ p95 (ms) │ ▁█▃            │ 0.0 │ 12.0 │\n")
+		assert.Contains(t, output, "│ sql        │ 2     │\n│ auth       │ 1     │\n")
+		assert.NotContains(t, output, "Writes")
+		assert.NotContains(t, output, "Queued Now")
+	})
+
+	t.Run("Metric not reported", func(t *testing.T) {
+		assert.Equal(
+			t, "The server doesn't report the p95 metric\n",
+			renderStats(load(t, oldServerStats), 3, "p95"),
+		)
+	})
+}
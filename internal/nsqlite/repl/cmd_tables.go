@@ -0,0 +1,190 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
+)
+
+const (
+	// exactRowCountThreshold is the highest rowid of the tables whose rows
+	// are counted with COUNT(*), bigger tables use their highest rowid as an
+	// approximate row count to avoid scanning them.
+	exactRowCountThreshold = 100_000
+	// rowCountBatchSize is the maximum number of tables counted in a single
+	// query, below the SQLite limit of terms in a compound SELECT.
+	rowCountBatchSize = 100
+)
+
+// tableDetail is a table or view listed by .tables --detail.
+type tableDetail struct {
+	typ     string
+	name    string
+	sql     string
+	columns int64
+	indexes int64
+	rows    int64
+	// approximate reports whether rows is estimated from the highest rowid.
+	approximate bool
+}
+
+// isView reports whether the object is a view, whose rows are not counted.
+func (t tableDetail) isView() bool {
+	return t.typ == "view"
+}
+
+// hasRowid reports whether the highest rowid of the table can be used to
+// estimate its row count.
+func (t tableDetail) hasRowid() bool {
+	sql := strings.ToUpper(t.sql)
+	return !strings.Contains(sql, "WITHOUT ROWID") && !strings.HasPrefix(sql, "CREATE VIRTUAL")
+}
+
+func cmdTables(r *Repl, args string) {
+	switch strings.TrimSpace(args) {
+	case "":
+		cmdQuery(r, `
+			SELECT name
+			FROM sqlite_master
+			WHERE type IN ('table','view')
+			ORDER BY 1
+		`, nil)
+	case "--detail":
+		ctx, done := r.commandContext()
+		defer done()
+
+		tables, err := fetchTableDetails(ctx, r.client, r.txId)
+		if err != nil {
+			fmt.Println("Failed to fetch tables:", err)
+			return
+		}
+		if len(tables) == 0 {
+			fmt.Println("The database has no tables")
+			return
+		}
+
+		r.writePaged([]byte(renderTableDetails(tables) + "\n"))
+	default:
+		fmt.Println("Usage: .tables [--detail]")
+	}
+}
+
+// fetchTableDetails returns the tables and views of the database ordered by
+// name, with their column and index counts and the row count of the tables.
+// It sends one query for the schema and one query per batch of tables for
+// the row counts, instead of one query per table.
+func fetchTableDetails(ctx context.Context, client queryClient, txId string) ([]tableDetail, error) {
+	res, err := sendTxQuery(ctx, client, txId, `
+		SELECT
+			m.type,
+			m.name,
+			COALESCE(m.sql, ''),
+			(SELECT COUNT(*) FROM pragma_table_info(m.name)),
+			(SELECT COUNT(*) FROM sqlite_master i WHERE i.type = 'index' AND i.tbl_name = m.name)
+		FROM sqlite_master m
+		WHERE m.type IN ('table', 'view')
+		ORDER BY m.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]tableDetail, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		if len(row) < 5 {
+			continue
+		}
+		columns, _, _ := numericValue(row[3])
+		indexes, _, _ := numericValue(row[4])
+		tables = append(tables, tableDetail{
+			typ:     fmt.Sprint(row[0]),
+			name:    fmt.Sprint(row[1]),
+			sql:     fmt.Sprint(row[2]),
+			columns: int64(columns),
+			indexes: int64(indexes),
+		})
+	}
+
+	counted := []int{}
+	for i, t := range tables {
+		if !t.isView() {
+			counted = append(counted, i)
+		}
+	}
+	for start := 0; start < len(counted); start += rowCountBatchSize {
+		batch := counted[start:min(start+rowCountBatchSize, len(counted))]
+
+		res, err := sendTxQuery(ctx, client, txId, buildRowCountQuery(tables, batch))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range res.Rows {
+			if len(row) < 3 {
+				continue
+			}
+			i, _, ok := numericValue(row[0])
+			if !ok || int(i) < 0 || int(i) >= len(tables) {
+				continue
+			}
+			rows, _, _ := numericValue(row[1])
+			approximate, _, _ := numericValue(row[2])
+			tables[int(i)].rows = int64(rows)
+			tables[int(i)].approximate = approximate != 0
+		}
+	}
+
+	return tables, nil
+}
+
+// buildRowCountQuery builds a query that returns the index, the row count
+// and whether the count is approximate of each of the given tables, one
+// table per row. The tables are identified by their index so their names
+// are only used as identifiers. Tables with more than
+// exactRowCountThreshold rowids are estimated from their highest rowid.
+func buildRowCountQuery(tables []tableDetail, indexes []int) string {
+	selects := make([]string, 0, len(indexes))
+	for _, i := range indexes {
+		name := quoteIdentifier(tables[i].name)
+		if !tables[i].hasRowid() {
+			selects = append(selects, fmt.Sprintf("SELECT %d, (SELECT COUNT(*) FROM %s), 0", i, name))
+			continue
+		}
+
+		maxRowid := fmt.Sprintf("(SELECT MAX(rowid) FROM %s)", name)
+		selects = append(selects, fmt.Sprintf(
+			"SELECT %d, CASE WHEN %s > %d THEN %s ELSE (SELECT COUNT(*) FROM %s) END, COALESCE(%s > %d, 0)",
+			i, maxRowid, exactRowCountThreshold, maxRowid, name, maxRowid, exactRowCountThreshold,
+		))
+	}
+	return strings.Join(selects, "\nUNION ALL\n")
+}
+
+// renderTableDetails renders the tables and views as a table. Approximate
+// row counts are prefixed with "~" and views have no row count.
+func renderTableDetails(tables []tableDetail) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Name", "Type", "Rows", "Columns", "Indexes"})
+
+	for _, t := range tables {
+		if t.isView() {
+			tw.AppendRow(table.Row{
+				t.name, styled.DimmedColor().Sprint("view"), "-", numutil.IntWithCommas(t.columns), "-",
+			})
+			continue
+		}
+
+		rows := numutil.IntWithCommas(t.rows)
+		if t.approximate {
+			rows = "~" + rows
+		}
+		tw.AppendRow(table.Row{
+			t.name, t.typ, rows, numutil.IntWithCommas(t.columns), numutil.IntWithCommas(t.indexes),
+		})
+	}
+
+	return tw.Render()
+}
@@ -0,0 +1,128 @@
+package repl
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingQueryClient is a fakeQueryClient that records the sent queries
+// and their transaction IDs.
+type recordingQueryClient struct {
+	fakeQueryClient
+	queries []string
+	txIds   []string
+}
+
+func (c *recordingQueryClient) SendQuery(
+	ctx context.Context, query nsqlitehttp.Query,
+) (nsqlitehttp.QueryResponse, error) {
+	c.queries = append(c.queries, query.Query)
+	c.txIds = append(c.txIds, query.TxId)
+	return c.fakeQueryClient.SendQuery(ctx, query)
+}
+
+const tablesFixture = `
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT);
+CREATE INDEX users_name ON users (name);
+CREATE UNIQUE INDEX users_email ON users (email);
+INSERT INTO users (name, email) VALUES ('alice', 'a@example.com'), ('bob', 'b@example.com');
+CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT);
+INSERT INTO events (id, kind) VALUES (1, 'login'), (250000, 'logout');
+CREATE TABLE settings (key TEXT PRIMARY KEY, value TEXT) WITHOUT ROWID;
+INSERT INTO settings VALUES ('theme', 'dark');
+CREATE TABLE "odd ""name""" (x);
+CREATE VIEW active_users AS SELECT id, name FROM users;
+`
+
+func TestBuildRowCountQuery(t *testing.T) {
+	tables := []tableDetail{
+		{typ: "table", name: "users", sql: "CREATE TABLE users (id)"},
+		{typ: "table", name: "settings", sql: "CREATE TABLE settings (k PRIMARY KEY) WITHOUT ROWID"},
+		{typ: "table", name: `odd "name"`, sql: `CREATE TABLE "odd ""name""" (x)`},
+	}
+
+	want := strings.Join([]string{
+		`SELECT 0, CASE WHEN (SELECT MAX(rowid) FROM "users") > 100000 THEN (SELECT MAX(rowid) FROM "users") ` +
+			`ELSE (SELECT COUNT(*) FROM "users") END, COALESCE((SELECT MAX(rowid) FROM "users") > 100000, 0)`,
+		"UNION ALL",
+		`SELECT 1, (SELECT COUNT(*) FROM "settings"), 0`,
+		"UNION ALL",
+		`SELECT 2, CASE WHEN (SELECT MAX(rowid) FROM "odd ""name""") > 100000 THEN (SELECT MAX(rowid) FROM "odd ""name""") ` +
+			`ELSE (SELECT COUNT(*) FROM "odd ""name""") END, COALESCE((SELECT MAX(rowid) FROM "odd ""name""") > 100000, 0)`,
+	}, "\n")
+	assert.Equal(t, want, buildRowCountQuery(tables, []int{0, 1, 2}))
+	assert.Equal(t, `SELECT 1, (SELECT COUNT(*) FROM "settings"), 0`, buildRowCountQuery(tables, []int{1}))
+}
+
+func TestFetchTableDetails(t *testing.T) {
+	t.Run("Counts with batched queries", func(t *testing.T) {
+		client := &recordingQueryClient{fakeQueryClient: fakeQueryClient{conn: openTestConn(t, tablesFixture)}}
+
+		tables, err := fetchTableDetails(context.Background(), client, "")
+		require.NoError(t, err)
+		assert.Len(t, client.queries, 2, "one query for the schema and one for the row counts")
+
+		for i := range tables {
+			tables[i].sql = ""
+		}
+		assert.Equal(t, []tableDetail{
+			{typ: "view", name: "active_users", columns: 2},
+			{typ: "table", name: "events", columns: 2, rows: 250000, approximate: true},
+			{typ: "table", name: `odd "name"`, columns: 1},
+			{typ: "table", name: "settings", columns: 2, rows: 1},
+			{typ: "table", name: "users", columns: 3, indexes: 2, rows: 2},
+		}, tables)
+	})
+
+	t.Run("Batches of tables", func(t *testing.T) {
+		script := strings.Builder{}
+		for i := range rowCountBatchSize + 1 {
+			script.WriteString("CREATE TABLE t" + strings.Repeat("x", i) + " (a);\n")
+		}
+		client := &recordingQueryClient{fakeQueryClient: fakeQueryClient{conn: openTestConn(t, script.String())}}
+
+		tables, err := fetchTableDetails(context.Background(), client, "")
+		require.NoError(t, err)
+		assert.Len(t, tables, rowCountBatchSize+1)
+		assert.Len(t, client.queries, 3)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		client := fakeQueryClient{conn: openTestConn(t, ""), sendQueryErr: assert.AnError}
+
+		_, err := fetchTableDetails(context.Background(), client, "")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestRenderTableDetails(t *testing.T) {
+	text.DisableColors()
+	defer text.EnableColors()
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	tables := []tableDetail{
+		{typ: "view", name: "active_users", columns: 2},
+		{typ: "table", name: "events", columns: 2, rows: 250000, approximate: true},
+		{typ: "table", name: "users", columns: 3, indexes: 2, rows: 1234},
+	}
+
+	want := strings.Join([]string{
+		"┌──────────────┬───────┬──────────┬─────────┬─────────┐",
+		"│ Name         │ Type  │ Rows     │ Columns │ Indexes │",
+		"├──────────────┼───────┼──────────┼─────────┼─────────┤",
+		"│ active_users │ view  │ -        │ 2       │ -       │",
+		"│ events       │ table │ ~250,000 │ 2       │ 0       │",
+		"│ users        │ table │ 1,234    │ 3       │ 2       │",
+		"└──────────────┴───────┴──────────┴─────────┴─────────┘",
+	}, "\n")
+	assert.Equal(t, want, renderTableDetails(tables))
+}
@@ -0,0 +1,82 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+)
+
+func cmdTimer(r *Repl, args string) {
+	switch strings.TrimSpace(args) {
+	case "on":
+		r.timer = true
+	case "off":
+		r.timer = false
+	case "":
+		if r.timer {
+			fmt.Println("Timer is on")
+		} else {
+			fmt.Println("Timer is off")
+		}
+	default:
+		fmt.Println("Usage: .timer on|off")
+	}
+}
+
+// queryTiming holds the timing information of a query for the footer.
+type queryTiming struct {
+	// roundTrip is the time since the query was sent until the response
+	// was received.
+	roundTrip time.Duration
+	// server is the execution time reported by the server.
+	server time.Duration
+	// isRead reports whether the query returned rows.
+	isRead       bool
+	rows         int
+	rowsAffected int64
+}
+
+// writeTimingFooter writes the timing footer of a query if the timer is
+// enabled.
+func writeTimingFooter(w io.Writer, enabled bool, timing queryTiming) {
+	if !enabled {
+		return
+	}
+
+	parts := []string{
+		"Time: " + formatDuration(timing.roundTrip) + " round-trip",
+		formatDuration(timing.server) + " server",
+	}
+	if timing.isRead {
+		parts = append(parts, pluralize(int64(timing.rows), "row")+" returned")
+	} else {
+		parts = append(parts, pluralize(timing.rowsAffected, "row")+" affected")
+	}
+
+	styled.DimmedColor().Fprintln(w, strings.Join(parts, ", "))
+}
+
+// formatDuration formats a duration with a unit that keeps sub-millisecond
+// values readable.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.3fs", d.Seconds())
+	}
+}
+
+// pluralize returns the count followed by the noun, adding an "s" if the
+// count is not one.
+func pluralize(count int64, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
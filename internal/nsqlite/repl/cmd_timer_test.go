@@ -0,0 +1,58 @@
+package repl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTimingFooter(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		out := bytes.Buffer{}
+		writeTimingFooter(&out, false, queryTiming{roundTrip: time.Second})
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		out := bytes.Buffer{}
+		writeTimingFooter(&out, true, queryTiming{
+			roundTrip: 1500 * time.Microsecond,
+			server:    250 * time.Microsecond,
+			isRead:    true,
+			rows:      1,
+		})
+		assert.Equal(t, "Time: 1.50ms round-trip, 250µs server, 1 row returned\n", out.String())
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		out := bytes.Buffer{}
+		writeTimingFooter(&out, true, queryTiming{
+			roundTrip:    2 * time.Second,
+			server:       999 * time.Nanosecond,
+			rowsAffected: 3,
+		})
+		assert.Equal(t, "Time: 2.000s round-trip, 0µs server, 3 rows affected\n", out.String())
+	})
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{duration: 0, want: "0µs"},
+		{duration: 42 * time.Microsecond, want: "42µs"},
+		{duration: 999 * time.Microsecond, want: "999µs"},
+		{duration: time.Millisecond, want: "1.00ms"},
+		{duration: 12345 * time.Microsecond, want: "12.35ms"},
+		{duration: 1500 * time.Millisecond, want: "1.500s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatDuration(tt.duration))
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+)
+
+// txStatusTimeout is the maximum time .tx waits for the transaction status
+// reported by the server.
+const txStatusTimeout = 2 * time.Second
+
+func cmdBegin(r *Repl) {
+	if r.txId != "" {
+		fmt.Println("A transaction is already active, use .commit or .rollback to finish it")
+		return
+	}
+
+	cmdQuery(r, "BEGIN", nil)
+}
+
+func cmdCommit(r *Repl) {
+	if r.txId == "" {
+		fmt.Println("No active transaction")
+		return
+	}
+
+	cmdQuery(r, "COMMIT", nil)
+}
+
+func cmdRollback(r *Repl) {
+	if r.txId == "" {
+		fmt.Println("No active transaction")
+		return
+	}
+
+	cmdQuery(r, "ROLLBACK", nil)
+}
+
+func cmdTx(r *Repl) {
+	if r.txId == "" {
+		fmt.Println("No active transaction")
+		return
+	}
+
+	now := time.Now()
+	idleRemaining := formatIdleRemaining(r.txIdleRemaining(now)) + " (estimated)"
+
+	ctx, cancel := context.WithTimeout(r.ctx, txStatusTimeout)
+	defer cancel()
+	if status, err := r.api.Transaction(ctx, r.txId); err == nil {
+		remaining := time.Duration(status.IdleRemaining * float64(time.Second))
+		idleRemaining = formatIdleRemaining(remaining)
+
+		if status.IdleTimeout > 0 {
+			r.txIdleTimeout = time.Duration(status.IdleTimeout * float64(time.Second))
+		}
+		r.txLastUsedAt = now.Add(remaining - r.txIdleTimeout)
+	}
+
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Transaction ID", "Age", "Idle Remaining"})
+	tw.AppendRow(table.Row{
+		r.txId,
+		now.Sub(r.txStartedAt).Round(time.Second).String(),
+		idleRemaining,
+	})
+	fmt.Println(tw.Render())
+}
+
+// txIdleRemaining returns the estimated time left before the server rolls
+// back the current transaction if it is not used.
+func (r *Repl) txIdleRemaining(now time.Time) time.Duration {
+	return r.txIdleTimeout - now.Sub(r.txLastUsedAt)
+}
+
+// txIdleWarning returns a warning to show before the prompt when the current
+// transaction is close to the idle timeout of the server, or an empty string
+// if there is nothing to warn about. expired reports whether the timeout has
+// already passed.
+func (r *Repl) txIdleWarning(now time.Time) (warning string, expired bool) {
+	if r.txId == "" || r.txIdleTimeout <= 0 {
+		return "", false
+	}
+
+	remaining := r.txIdleRemaining(now)
+	if remaining <= 0 {
+		return "The transaction has been idle for too long and was probably rolled back by the server", true
+	}
+	if remaining > r.txIdleTimeout/3 {
+		return "", false
+	}
+
+	return fmt.Sprintf(
+		"The transaction will be rolled back by the server if idle for %s more", formatIdleRemaining(remaining),
+	), false
+}
+
+// formatIdleRemaining formats the time left before an idle transaction is
+// rolled back.
+func formatIdleRemaining(remaining time.Duration) string {
+	if remaining <= 0 {
+		return "expired"
+	}
+	return remaining.Round(time.Second).String()
+}
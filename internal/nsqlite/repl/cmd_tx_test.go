@@ -0,0 +1,201 @@
+package repl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/util/syncutil"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txStubServer is a stub NSQLite server that only tracks the transaction
+// lifecycle.
+type txStubServer struct {
+	mu      sync.Mutex
+	txId    string
+	queries []nsqlitehttp.Query
+	// transactions reports whether the /transactions endpoint is available.
+	transactions bool
+}
+
+func (s *txStubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/transactions/") {
+		if !s.transactions {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(apiclient.TransactionStatus{
+			ID:            strings.TrimPrefix(r.URL.Path, "/transactions/"),
+			IdleTimeout:   30,
+			IdleRemaining: 25,
+		})
+		return
+	}
+
+	queries := []nsqlitehttp.Query{}
+	if err := json.NewDecoder(r.Body).Decode(&queries); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results := []nsqlitehttp.QueryResponse{}
+	for _, query := range queries {
+		s.queries = append(s.queries, query)
+
+		res := nsqlitehttp.QueryResponse{}
+		switch kind := txStatementKind(query.Query); {
+		case kind == "BEGIN" && s.txId != "":
+			res.Error = "transaction already active"
+		case kind == "BEGIN":
+			s.txId = "tx-1234567890"
+			res.TxId = s.txId
+		case kind != "" && query.TxId != s.txId:
+			res.Error = "transaction not found"
+		case kind != "":
+			res.TxId = s.txId
+			s.txId = ""
+		}
+		results = append(results, res)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"results": results})
+}
+
+// newTxTestRepl returns a Repl connected to the given test server.
+func newTxTestRepl(t *testing.T, ts *httptest.Server) *Repl {
+	t.Helper()
+
+	client, err := nsqlitehttp.NewClient(ts.URL)
+	require.NoError(t, err)
+	connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+	require.NoError(t, err)
+
+	return &Repl{
+		ctx:           context.Background(),
+		client:        client,
+		api:           apiclient.NewClient(connStr),
+		schema:        newSchemaCache(client),
+		render:        newRenderOptions(outputModeTable),
+		output:        io.Discard,
+		txIdleTimeout: 10 * time.Second,
+		cancelCommand: syncutil.NewAtomic[context.CancelFunc](nil),
+	}
+}
+
+func TestTransactionCommands(t *testing.T) {
+	t.Run("Lifecycle", func(t *testing.T) {
+		stub := &txStubServer{}
+		ts := httptest.NewServer(stub)
+		defer ts.Close()
+		r := newTxTestRepl(t, ts)
+
+		cmdCommit(r)
+		cmdRollback(r)
+		assert.Empty(t, stub.queries, "nothing is sent without a transaction")
+
+		cmdBegin(r)
+		assert.Equal(t, "tx-1234567890", r.txId)
+		assert.False(t, r.txStartedAt.IsZero())
+
+		cmdBegin(r)
+		assert.Len(t, stub.queries, 1, "a second BEGIN is not sent")
+
+		cmdCommit(r)
+		assert.Empty(t, r.txId)
+		assert.Equal(t, nsqlitehttp.Query{Query: "COMMIT", TxId: "tx-1234567890"}, stub.queries[1])
+
+		cmdBegin(r)
+		cmdRollback(r)
+		assert.Empty(t, r.txId)
+		assert.Equal(t, nsqlitehttp.Query{Query: "ROLLBACK", TxId: "tx-1234567890"}, stub.queries[3])
+	})
+
+	t.Run("Raw SQL statements", func(t *testing.T) {
+		stub := &txStubServer{}
+		ts := httptest.NewServer(stub)
+		defer ts.Close()
+		r := newTxTestRepl(t, ts)
+
+		cmdQuery(r, "BEGIN;", nil)
+		assert.Equal(t, "tx-1234567890", r.txId)
+
+		cmdQuery(r, "commit;", nil)
+		assert.Empty(t, r.txId, "the transaction is finished even if the server returns its ID")
+	})
+
+	t.Run("Status from the server", func(t *testing.T) {
+		stub := &txStubServer{transactions: true}
+		ts := httptest.NewServer(stub)
+		defer ts.Close()
+		r := newTxTestRepl(t, ts)
+
+		cmdBegin(r)
+		cmdTx(r)
+		assert.Equal(t, 30*time.Second, r.txIdleTimeout)
+		assert.InDelta(t, 25*time.Second, r.txIdleRemaining(time.Now()), float64(time.Second))
+	})
+
+	t.Run("Status without the transactions endpoint", func(t *testing.T) {
+		stub := &txStubServer{}
+		ts := httptest.NewServer(stub)
+		defer ts.Close()
+		r := newTxTestRepl(t, ts)
+
+		cmdBegin(r)
+		cmdTx(r)
+		assert.Equal(t, 10*time.Second, r.txIdleTimeout)
+	})
+}
+
+func TestTxIdleWarning(t *testing.T) {
+	lastUsed := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &Repl{txId: "tx", txLastUsedAt: lastUsed, txIdleTimeout: 30 * time.Second}
+
+	tests := []struct {
+		name    string
+		idle    time.Duration
+		warning string
+		expired bool
+	}{
+		{name: "Far from the timeout", idle: 5 * time.Second},
+		{
+			name:    "Close to the timeout",
+			idle:    25 * time.Second,
+			warning: "The transaction will be rolled back by the server if idle for 5s more",
+		},
+		{
+			name:    "Expired",
+			idle:    31 * time.Second,
+			warning: "The transaction has been idle for too long and was probably rolled back by the server",
+			expired: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning, expired := r.txIdleWarning(lastUsed.Add(tt.idle))
+			assert.Equal(t, tt.warning, warning)
+			assert.Equal(t, tt.expired, expired)
+		})
+	}
+
+	t.Run("Without transaction", func(t *testing.T) {
+		r := &Repl{txIdleTimeout: 30 * time.Second}
+		warning, _ := r.txIdleWarning(time.Now())
+		assert.Empty(t, warning)
+	})
+}
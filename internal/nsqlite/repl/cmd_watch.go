@@ -0,0 +1,191 @@
+package repl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/util/sysutil"
+)
+
+// clearScreenSequence moves the cursor to the top left corner and clears
+// the terminal screen.
+const clearScreenSequence = "\033[H\033[2J"
+
+// watchOptions are the options of the .watch command.
+type watchOptions struct {
+	interval time.Duration
+	query    string
+	txId     string
+	render   renderOptions
+	// clearScreen reports whether the screen is cleared before each result.
+	clearScreen bool
+}
+
+// watchClock is the clock used by the watch loop, so tests can control it.
+type watchClock struct {
+	now   func() time.Time
+	after func(d time.Duration) <-chan time.Time
+}
+
+// realWatchClock is the watchClock backed by the time package.
+var realWatchClock = watchClock{now: time.Now, after: time.After}
+
+func cmdWatch(r *Repl, args string) {
+	opts, err := parseWatchArgs(args)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	opts.txId = r.txId
+	opts.render = r.render
+	opts.clearScreen = sysutil.IsTerminal(r.output)
+
+	ctx, done := r.commandContext()
+	defer done()
+
+	if err := runWatch(ctx, r.client, opts, r.output, realWatchClock); err != nil {
+		fmt.Println("Failed to write results:", err)
+		return
+	}
+	fmt.Println()
+	fmt.Println("Watch stopped")
+}
+
+// parseWatchArgs parses the arguments of the .watch command in the format
+// "seconds query".
+func parseWatchArgs(args string) (watchOptions, error) {
+	usage := errors.New("usage: .watch seconds query")
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return watchOptions{}, usage
+	}
+
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || seconds <= 0 {
+		return watchOptions{}, usage
+	}
+
+	query := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(args), fields[0]))
+	return watchOptions{
+		interval: time.Duration(seconds * float64(time.Second)),
+		query:    query,
+	}, nil
+}
+
+// runWatch executes the query on every interval and writes its result to w
+// with a timestamp, until the context is canceled. Numeric values are shown
+// with their change since the previous result in the display modes. Query
+// errors are shown and the query is executed again on the next interval.
+func runWatch(ctx context.Context, client queryClient, opts watchOptions, w io.Writer, clock watchClock) error {
+	var previous [][]any
+
+	for {
+		res, err := sendTxQuery(ctx, client, opts.txId, opts.query)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		frame := strings.Builder{}
+		if opts.clearScreen {
+			frame.WriteString(clearScreenSequence)
+		}
+		fmt.Fprintf(
+			&frame, "Every %s: %s    %s\n\n",
+			opts.interval, opts.query, clock.now().Format(time.DateTime),
+		)
+
+		switch {
+		case err != nil:
+			fmt.Fprintln(&frame, "Error:", err)
+		case len(res.Columns) > 0:
+			rows := res.Rows
+			if showsDeltas(opts.render.mode) {
+				rows = withDeltas(res.Rows, previous)
+			}
+			if err := renderRows(&frame, opts.render, res.Columns, res.Types, rows); err != nil {
+				return err
+			}
+			previous = res.Rows
+		default:
+			fmt.Fprintf(&frame, "OK, %s affected\n", pluralize(res.RowsAffected, "row"))
+		}
+
+		if _, err := io.WriteString(w, frame.String()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-clock.after(opts.interval):
+		}
+	}
+}
+
+// showsDeltas reports whether the changes of the numeric values are shown
+// in the given mode, the JSON and CSV modes are kept machine readable.
+func showsDeltas(mode string) bool {
+	return mode != outputModeJSON && mode != outputModeCSV
+}
+
+// withDeltas returns the current rows with the numeric values that changed
+// since the previous rows, at the same row and column, annotated with the
+// difference, like "42 (+3)".
+func withDeltas(current [][]any, previous [][]any) [][]any {
+	rows := make([][]any, len(current))
+	for i, row := range current {
+		rows[i] = append([]any{}, row...)
+		if i >= len(previous) {
+			continue
+		}
+
+		for j, value := range row {
+			if j >= len(previous[i]) {
+				continue
+			}
+
+			cur, curIsInt, ok := numericValue(value)
+			if !ok {
+				continue
+			}
+			prev, prevIsInt, ok := numericValue(previous[i][j])
+			if !ok || cur == prev {
+				continue
+			}
+
+			delta := fmt.Sprintf("%+g", cur-prev)
+			if curIsInt && prevIsInt {
+				delta = fmt.Sprintf("%+d", int64(cur)-int64(prev))
+			}
+			rows[i][j] = fmt.Sprintf("%v (%s)", value, delta)
+		}
+	}
+	return rows
+}
+
+// numericValue returns the value of a number returned by the server and
+// whether it is an integer.
+func numericValue(value any) (number float64, isInt bool, ok bool) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return float64(n), true, true
+		}
+		n, err := v.Float64()
+		return n, false, err == nil
+	case int:
+		return float64(v), true, true
+	case int64:
+		return float64(v), true, true
+	case float64:
+		return v, false, true
+	}
+	return 0, false, false
+}
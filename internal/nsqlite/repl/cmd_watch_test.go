@@ -0,0 +1,143 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWatchArgs(t *testing.T) {
+	opts, err := parseWatchArgs(" 2 SELECT count(*) FROM jobs")
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, opts.interval)
+	assert.Equal(t, "SELECT count(*) FROM jobs", opts.query)
+
+	opts, err = parseWatchArgs("0.5 SELECT 1")
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, opts.interval)
+
+	for _, args := range []string{"", "2", "SELECT 1", "0 SELECT 1", "-1 SELECT 1"} {
+		_, err := parseWatchArgs(args)
+		assert.Error(t, err, args)
+	}
+}
+
+func TestRunWatch(t *testing.T) {
+	conn := openTestConn(t, `
+		CREATE TABLE jobs (id INTEGER PRIMARY KEY, status TEXT);
+		INSERT INTO jobs (status) VALUES ('done'), ('done');
+	`)
+	client := fakeQueryClient{conn: conn}
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// newScriptedClock returns a clock that advances the interval on every
+	// tick, runs the step of the script for that tick and interrupts the
+	// loop when the script ends.
+	newScriptedClock := func(cancel context.CancelFunc, steps ...func()) watchClock {
+		now := start
+		ticks := 0
+		return watchClock{
+			now: func() time.Time { return now },
+			after: func(d time.Duration) <-chan time.Time {
+				ch := make(chan time.Time, 1)
+				if ticks == len(steps) {
+					cancel()
+					return ch
+				}
+				steps[ticks]()
+				ticks++
+				now = now.Add(d)
+				ch <- now
+				return ch
+			},
+		}
+	}
+
+	insertJob := func() {
+		_, err := conn.Query("INSERT INTO jobs (status) VALUES ('pending')", nil)
+		require.NoError(t, err)
+	}
+
+	t.Run("Shows the deltas of the numeric columns", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock := newScriptedClock(cancel, insertJob, func() {})
+
+		opts := watchOptions{
+			interval: 2 * time.Second,
+			query:    "SELECT count(*) AS total, 'jobs' AS name FROM jobs",
+			render:   newRenderOptions(outputModeLine),
+		}
+
+		out := bytes.Buffer{}
+		require.NoError(t, runWatch(ctx, client, opts, &out, clock))
+		assert.Equal(t,
+			"Every 2s: SELECT count(*) AS total, 'jobs' AS name FROM jobs    2025-01-01 12:00:00\n\n"+
+				"total: 2\n name: jobs\n"+
+				"Every 2s: SELECT count(*) AS total, 'jobs' AS name FROM jobs    2025-01-01 12:00:02\n\n"+
+				"total: 3 (+1)\n name: jobs\n"+
+				"Every 2s: SELECT count(*) AS total, 'jobs' AS name FROM jobs    2025-01-01 12:00:04\n\n"+
+				"total: 3\n name: jobs\n",
+			out.String(),
+		)
+	})
+
+	t.Run("Keeps the CSV mode machine readable", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock := newScriptedClock(cancel, insertJob)
+
+		opts := watchOptions{
+			interval:    time.Second,
+			query:       "SELECT count(*) AS total FROM jobs",
+			render:      newRenderOptions(outputModeCSV),
+			clearScreen: true,
+		}
+
+		out := bytes.Buffer{}
+		require.NoError(t, runWatch(ctx, client, opts, &out, clock))
+		assert.Contains(t, out.String(), clearScreenSequence+"Every 1s")
+		assert.Contains(t, out.String(), "total\n3\n")
+		assert.Contains(t, out.String(), "total\n4\n")
+		assert.NotContains(t, out.String(), "(+1)")
+	})
+
+	t.Run("Keeps watching after errors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock := newScriptedClock(cancel, func() {
+			_, err := conn.Query("CREATE TABLE missing (id INTEGER)", nil)
+			require.NoError(t, err)
+		})
+
+		opts := watchOptions{
+			interval: time.Second,
+			query:    "SELECT count(*) AS total FROM missing",
+			render:   newRenderOptions(outputModeCSV),
+		}
+
+		out := bytes.Buffer{}
+		require.NoError(t, runWatch(ctx, client, opts, &out, clock))
+		assert.Contains(t, out.String(), "Error: ")
+		assert.Contains(t, out.String(), "total\n0\n")
+	})
+}
+
+func TestWithDeltas(t *testing.T) {
+	previous := [][]any{{json.Number("10"), json.Number("1.5"), "a", nil}}
+	current := [][]any{
+		{json.Number("7"), json.Number("2"), "b", json.Number("1")},
+		{json.Number("1"), json.Number("1"), "c", nil},
+	}
+
+	assert.Equal(t, [][]any{
+		{"7 (-3)", "2 (+0.5)", "b", json.Number("1")},
+		{json.Number("1"), json.Number("1"), "c", nil},
+	}, withDeltas(current, previous))
+	assert.Equal(t, json.Number("7"), current[0][0], "the current rows are not modified")
+}
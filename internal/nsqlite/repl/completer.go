@@ -0,0 +1,187 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// schemaFetchTimeout is the maximum time the completion waits for the schema,
+// so a slow server doesn't block the prompt.
+const schemaFetchTimeout = 2 * time.Second
+
+// schemaCache lazily fetches the table and column names used by the
+// completion and keeps them for the session until they are invalidated.
+type schemaCache struct {
+	client  queryClient
+	tables  []string
+	columns map[string][]string
+}
+
+func newSchemaCache(client queryClient) *schemaCache {
+	return &schemaCache{client: client}
+}
+
+// invalidate discards the cached schema, so it is fetched again the next
+// time it is needed.
+func (s *schemaCache) invalidate() {
+	s.tables = nil
+	s.columns = nil
+}
+
+// tableNames returns the names of the tables and views of the database.
+func (s *schemaCache) tableNames(ctx context.Context) ([]string, error) {
+	if s.tables != nil {
+		return s.tables, nil
+	}
+
+	res, err := sendQuery(ctx, s.client, `
+		SELECT name
+		FROM sqlite_master
+		WHERE type IN ('table','view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	s.tables = firstColumnValues(res.Rows)
+	return s.tables, nil
+}
+
+// columnNames returns the names of the columns of the given table.
+func (s *schemaCache) columnNames(ctx context.Context, table string) ([]string, error) {
+	if columns, ok := s.columns[table]; ok {
+		return columns, nil
+	}
+
+	res, err := sendQuery(ctx, s.client, `SELECT name FROM pragma_table_info(:table_name)`, nsqlitehttp.QueryParam{
+		Name: "table_name", Value: table,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.columns == nil {
+		s.columns = map[string][]string{}
+	}
+	s.columns[table] = firstColumnValues(res.Rows)
+	return s.columns[table], nil
+}
+
+// firstColumnValues returns the values of the first column of the rows as
+// strings.
+func firstColumnValues(rows [][]any) []string {
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if len(row) > 0 && row[0] != nil {
+			values = append(values, fmt.Sprint(row[0]))
+		}
+	}
+	return values
+}
+
+// isDDLStatement reports whether the statement changes the schema of the
+// database, so the cached schema must be refreshed after it.
+func isDDLStatement(statement string) bool {
+	fields := strings.Fields(strings.ToUpper(statement))
+	if len(fields) == 0 {
+		return false
+	}
+
+	switch fields[0] {
+	case "CREATE", "DROP", "ALTER":
+		return true
+	}
+	return false
+}
+
+// tableKeywords are the keywords that are followed by a table name.
+var tableKeywords = map[string]bool{
+	"FROM": true, "JOIN": true, "INTO": true, "UPDATE": true, "TABLE": true,
+}
+
+// completeSchema returns the completions of the last word of the line with
+// the table and column names of the database. Table names are suggested
+// after the keywords in tableKeywords, column names are suggested after a
+// "table." prefix and for the tables that appear on the line.
+func completeSchema(ctx context.Context, schema *schemaCache, line string) ([]string, error) {
+	wordStart := strings.LastIndexFunc(line, func(r rune) bool {
+		return r < 0x80 && !isWordChar(byte(r)) && r != '.'
+	}) + 1
+	head, word := line[:wordStart], line[wordStart:]
+
+	if table, prefix, ok := strings.Cut(word, "."); ok {
+		if table == "" {
+			return nil, nil
+		}
+		columns, err := schema.columnNames(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		return matchCompletions(head+table+".", prefix, columns), nil
+	}
+
+	tables, err := schema.tableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.FieldsFunc(strings.ToUpper(head), func(r rune) bool {
+		return r < 0x80 && !isWordChar(byte(r))
+	})
+	if len(words) > 0 && tableKeywords[words[len(words)-1]] {
+		return matchCompletions(head, word, tables), nil
+	}
+
+	candidates := []string{}
+	for _, table := range tables {
+		if !containsFold(words, table) {
+			continue
+		}
+		columns, err := schema.columnNames(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, columns...)
+	}
+	candidates = append(candidates, tables...)
+
+	return matchCompletions(head, word, candidates), nil
+}
+
+// matchCompletions returns head followed by each candidate that starts with
+// the prefix, ignoring case and duplicates.
+func matchCompletions(head string, prefix string, candidates []string) []string {
+	if prefix == "" && strings.TrimSpace(head) == "" {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	results := []string{}
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) {
+			continue
+		}
+		seen[candidate] = true
+		results = append(results, head+candidate)
+	}
+
+	sort.Strings(results)
+	return results
+}
+
+// containsFold reports whether the upper-cased words contain the given name.
+func containsFold(words []string, name string) bool {
+	name = strings.ToUpper(name)
+	for _, word := range words {
+		if word == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package repl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingQueryClient is a queryClient that counts the sent queries.
+type countingQueryClient struct {
+	fakeQueryClient
+	queries int
+}
+
+func (c *countingQueryClient) SendQuery(
+	ctx context.Context, query nsqlitehttp.Query,
+) (nsqlitehttp.QueryResponse, error) {
+	c.queries++
+	return c.fakeQueryClient.SendQuery(ctx, query)
+}
+
+func TestCmdHelpCompleter(t *testing.T) {
+	conn := openTestConn(t, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, name TEXT);
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, total REAL);
+	`)
+	schema := newSchemaCache(fakeQueryClient{conn: conn})
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{line: "SELECT * FROM us", want: []string{"SELECT * FROM users"}},
+		{line: "SELECT * FROM ", want: []string{"SELECT * FROM ", "SELECT * FROM orders", "SELECT * FROM users"}},
+		{line: "SELECT users.em", want: []string{"SELECT users.email"}},
+		{line: "SELECT users.", want: []string{"SELECT users.email", "SELECT users.id", "SELECT users.name"}},
+		{line: "SELECT * FROM orders o JOIN users u ON u.id = o.us", want: []string{}},
+		{line: "SELECT * FROM orders WHERE us", want: []string{"SELECT * FROM orders WHERE user_id", "SELECT * FROM orders WHERE users"}},
+		{line: "select to", want: []string{}},
+		{line: "select total, na", want: []string{}},
+		{line: ".ti", want: []string{".timer"}},
+		{line: ".columns us", want: []string{".columns users"}},
+		{line: "sel", want: []string{"SELECT ", "SELECT * FROM ", "SELECT COUNT(*) FROM "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got := cmdHelpCompleter(schema, tt.line)
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("Caches the schema until it is invalidated", func(t *testing.T) {
+		client := &countingQueryClient{fakeQueryClient: fakeQueryClient{conn: conn}}
+		schema := newSchemaCache(client)
+
+		cmdHelpCompleter(schema, "SELECT * FROM us")
+		cmdHelpCompleter(schema, "SELECT * FROM or")
+		assert.Equal(t, 1, client.queries)
+
+		_, err := conn.Query("CREATE TABLE products (id INTEGER PRIMARY KEY)", nil)
+		assert.NoError(t, err)
+		assert.Empty(t, cmdHelpCompleter(schema, "SELECT * FROM pro"))
+
+		schema.invalidate()
+		assert.Equal(t, []string{"SELECT * FROM products"}, cmdHelpCompleter(schema, "SELECT * FROM pro"))
+		assert.Equal(t, 2, client.queries)
+	})
+
+	t.Run("Falls back to keywords when the schema can't be fetched", func(t *testing.T) {
+		schema := newSchemaCache(fakeQueryClient{sendQueryErr: errors.New("connection refused")})
+
+		assert.Empty(t, cmdHelpCompleter(schema, "SELECT * FROM us"))
+		assert.Equal(t, []string{"SELECT * FROM "}, cmdHelpCompleter(schema, "SELECT * FROM "))
+		assert.Equal(t, []string{".timer"}, cmdHelpCompleter(schema, ".ti"))
+	})
+}
+
+func TestIsDDLStatement(t *testing.T) {
+	tests := map[string]bool{
+		"CREATE TABLE t (id INTEGER)": true,
+		"  drop index idx;":           true,
+		"ALTER TABLE t ADD c TEXT":    true,
+		"SELECT * FROM t":             false,
+		"INSERT INTO t VALUES (1)":    false,
+		"":                            false,
+	}
+
+	for statement, want := range tests {
+		assert.Equal(t, want, isDDLStatement(statement), statement)
+	}
+}
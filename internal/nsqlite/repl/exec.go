@@ -0,0 +1,155 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// SQLError is an error reported by the server while executing a query, as
+// opposed to the errors sending the query or reading its response.
+type SQLError struct {
+	Message string
+}
+
+func (e *SQLError) Error() string {
+	return e.Message
+}
+
+// sqliteCodePattern matches the SQLite result code in the errors reported by
+// the server, like "failed to prepare statement: 1: SQL logic error: ...".
+var sqliteCodePattern = regexp.MustCompile(`(?:^|: )(\d+): `)
+
+// SQLiteCode returns the SQLite result code of the error, if the server
+// reported it.
+func (e *SQLError) SQLiteCode() (int, bool) {
+	match := sqliteCodePattern.FindStringSubmatch(e.Message)
+	if match == nil {
+		return 0, false
+	}
+	code, err := strconv.Atoi(match[1])
+	return code, err == nil
+}
+
+// txRunner sends statements keeping track of the transaction started by
+// them, so the following statements are sent in its context.
+type txRunner struct {
+	client queryClient
+	txId   string
+}
+
+// exec sends the statement in the context of the current transaction, if
+// any, and updates the transaction after BEGIN, COMMIT and ROLLBACK.
+func (t *txRunner) exec(ctx context.Context, statement string) (nsqlitehttp.QueryResponse, error) {
+	res, err := sendTxQuery(ctx, t.client, t.txId, statement)
+	if err != nil {
+		return res, err
+	}
+
+	switch txStatementKind(statement) {
+	case "BEGIN":
+		t.txId = res.TxId
+	case "COMMIT", "END", "ROLLBACK":
+		t.txId = ""
+	}
+
+	return res, nil
+}
+
+// rollback rolls back the current transaction, if any, even if the context
+// has been canceled.
+func (t *txRunner) rollback(ctx context.Context) {
+	if t.txId == "" {
+		return
+	}
+	_, _ = t.exec(context.WithoutCancel(ctx), "ROLLBACK")
+}
+
+// txStatementKind returns the first keyword of a statement that starts or
+// finishes a transaction, or an empty string for any other statement.
+// ROLLBACK TO only rolls back to a savepoint, so it is not reported.
+func txStatementKind(statement string) string {
+	fields := strings.Fields(strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(statement), ";")))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch fields[0] {
+	case "BEGIN", "COMMIT", "END":
+		return fields[0]
+	case "ROLLBACK":
+		for _, field := range fields[1:] {
+			if field == "TO" {
+				return ""
+			}
+		}
+		return fields[0]
+	}
+	return ""
+}
+
+// scriptProgressMinStatements is the minimum number of statements of a
+// script to report its progress.
+const scriptProgressMinStatements = 20
+
+// Exec executes the statements of the given SQL text in order and writes
+// the rows they return to w using the given output mode. The text after the
+// last semicolon is executed as the last statement.
+//
+// It stops at the first error, SQL errors reported by the server are
+// returned as *SQLError. A transaction left open by the statements is rolled
+// back when an error occurs.
+func Exec(ctx context.Context, client queryClient, sql string, mode string, w io.Writer) error {
+	runner := txRunner{client: client}
+	if _, err := runScript(ctx, &runner, sql, newRenderOptions(mode), w, io.Discard); err != nil {
+		runner.rollback(ctx)
+		return err
+	}
+	return nil
+}
+
+// runScript executes the statements of the given SQL text in order with the
+// runner and writes the rows they return to w using the given options.
+// It returns the number of executed statements and stops at the first
+// error, reporting the number and line of the failed statement.
+//
+// The progress is written to progress for scripts with many statements.
+func runScript(
+	ctx context.Context, runner *txRunner, sql string, render renderOptions, w io.Writer, progress io.Writer,
+) (int, error) {
+	statements, rest, pending := splitStatements(sql)
+	if pending {
+		statements = append(statements, rest)
+	}
+	showProgress := len(statements) >= scriptProgressMinStatements
+
+	for i, statement := range statements {
+		res, err := runner.exec(ctx, statement.text)
+		if err != nil {
+			if showProgress {
+				fmt.Fprintln(progress)
+			}
+			return i, fmt.Errorf("statement %d at line %d: %w", i+1, statement.line, err)
+		}
+
+		if len(res.Columns) > 0 {
+			if err := renderRows(w, render, res.Columns, res.Types, res.Rows); err != nil {
+				return i + 1, fmt.Errorf("failed to write results: %w", err)
+			}
+		}
+
+		if showProgress {
+			fmt.Fprintf(progress, "\rExecuted %d/%d statements", i+1, len(statements))
+		}
+	}
+
+	if showProgress {
+		fmt.Fprintln(progress)
+	}
+	return len(statements), nil
+}
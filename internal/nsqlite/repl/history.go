@@ -0,0 +1,171 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/config"
+)
+
+// historyStore is the history of the inputs entered in the REPL, persisted
+// to a file with one entry per line.
+type historyStore struct {
+	path string
+	// limit is the maximum number of entries kept, the oldest entries are
+	// discarded first. 0 means no limit.
+	limit int
+	// sensitive matches the entries that are never stored, if set.
+	sensitive *regexp.Regexp
+	entries   []string
+}
+
+func newHistoryStore(path string, limit int, sensitive *regexp.Regexp) *historyStore {
+	return &historyStore{
+		path:      path,
+		limit:     limit,
+		sensitive: sensitive,
+		entries:   []string{},
+	}
+}
+
+// newReplHistory creates the history of the REPL from the configuration and
+// loads its entries. If the history can't be loaded, the REPL starts with an
+// empty one.
+func newReplHistory(conf config.Config) *historyStore {
+	path := conf.HistoryFile
+	if path == "" {
+		path = filepath.Join(os.TempDir(), ".nsqlite_history")
+		if conf.ParsedConnStr != nil {
+			defaultPath, err := defaultHistoryPath(conf.ParsedConnStr.Host, conf.ParsedConnStr.Port)
+			if err == nil {
+				path = defaultPath
+			}
+		}
+	}
+
+	history := newHistoryStore(path, conf.HistorySize, conf.ParsedHistoryIgnore)
+	if err := history.load(); err != nil {
+		fmt.Println("Failed to load history:", err)
+	}
+	return history
+}
+
+// defaultHistoryPath returns the history file of the given server, in the
+// nsqlite directory of $XDG_CONFIG_HOME or ~/.config.
+func defaultHistoryPath(host string, port string) (string, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+
+	name := fmt.Sprintf("history-%s_%s", sanitizeFileName(host), sanitizeFileName(port))
+	return filepath.Join(configDir, "nsqlite", name), nil
+}
+
+// sanitizeFileName replaces the characters that are not safe in a file name,
+// like the colons of an IPv6 host.
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || (r < 0x80 && isWordChar(byte(r))) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// load reads the entries of the history file, a missing file is an empty
+// history.
+func (h *historyStore) load() error {
+	file, err := os.Open(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	h.entries = h.entries[:0]
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		h.add(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// add appends the input to the history and reports whether it was added.
+// Multi-line inputs are stored as a single line. Empty inputs, inputs that
+// repeat the last entry and inputs that match the sensitive pattern are not
+// added.
+func (h *historyStore) add(input string) bool {
+	entry := strings.TrimSpace(strings.ReplaceAll(input, "\n", " "))
+	if entry == "" {
+		return false
+	}
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return false
+	}
+	if h.sensitive != nil && h.sensitive.MatchString(entry) {
+		return false
+	}
+
+	h.entries = append(h.entries, entry)
+	if h.limit > 0 && len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+	return true
+}
+
+// save writes the entries to the history file, creating its directory if
+// needed. The file is only readable by the user because it contains the
+// executed statements.
+func (h *historyStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+
+	content := strings.Join(h.entries, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	return os.WriteFile(h.path, []byte(content), 0o600)
+}
+
+// get returns the entry with the given 1-based index.
+func (h *historyStore) get(index int) (string, bool) {
+	if index < 1 || index > len(h.entries) {
+		return "", false
+	}
+	return h.entries[index-1], true
+}
+
+// last returns the 1-based index of the first of the last n entries and
+// the entries.
+func (h *historyStore) last(n int) (int, []string) {
+	start := max(len(h.entries)-n, 0)
+	return start + 1, h.entries[start:]
+}
+
+// parseHistoryReference parses a "!n" reference to the history entry with
+// the 1-based index n.
+func parseHistoryReference(input string) (int, bool) {
+	if !strings.HasPrefix(input, "!") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(input[1:])
+	if err != nil || index < 1 {
+		return 0, false
+	}
+	return index, true
+}
@@ -0,0 +1,137 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/config"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultHistoryPath(t *testing.T) {
+	t.Run("Home directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", "")
+
+		path, err := defaultHistoryPath("localhost", "9876")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, ".config", "nsqlite", "history-localhost_9876"), path)
+	})
+
+	t.Run("XDG config directory", func(t *testing.T) {
+		configDir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", configDir)
+
+		path, err := defaultHistoryPath("::1", "80")
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(configDir, "nsqlite", "history-__1_80"), path)
+	})
+}
+
+func TestHistoryStore(t *testing.T) {
+	t.Run("Dedup and redact", func(t *testing.T) {
+		h := newHistoryStore("", 0, regexp.MustCompile(`(?i)password`))
+
+		assert.True(t, h.add("SELECT 1;"))
+		assert.False(t, h.add("SELECT 1;"), "consecutive repeats are skipped")
+		assert.False(t, h.add("  "))
+		assert.False(t, h.add("UPDATE users SET Password = 'x';"))
+		assert.True(t, h.add("SELECT\n2;"))
+		assert.True(t, h.add("SELECT 1;"), "non consecutive repeats are kept")
+
+		assert.Equal(t, []string{"SELECT 1;", "SELECT 2;", "SELECT 1;"}, h.entries)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		h := newHistoryStore("", 2, nil)
+		h.add("a")
+		h.add("b")
+		h.add("c")
+		assert.Equal(t, []string{"b", "c"}, h.entries)
+
+		start, entries := h.last(5)
+		assert.Equal(t, 1, start)
+		assert.Equal(t, []string{"b", "c"}, entries)
+
+		entry, ok := h.get(2)
+		assert.True(t, ok)
+		assert.Equal(t, "c", entry)
+		_, ok = h.get(3)
+		assert.False(t, ok)
+	})
+
+	t.Run("Store and load", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("XDG_CONFIG_HOME", "")
+
+		connStr, err := nsqlitedsn.NewConnStrFromText("http://db.example.com:9000")
+		require.NoError(t, err)
+		conf := config.Config{
+			ParsedConnStr:       connStr,
+			HistorySize:         3,
+			ParsedHistoryIgnore: regexp.MustCompile(`secret`),
+		}
+
+		h := newReplHistory(conf)
+		assert.Empty(t, h.entries)
+		for _, input := range []string{".tables", "SELECT 1;", "SELECT 2;", "SELECT 3;"} {
+			h.add(input)
+		}
+		require.NoError(t, h.save())
+
+		path := filepath.Join(home, ".config", "nsqlite", "history-db.example.com_9000")
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		// Entries written by older versions are deduplicated and redacted
+		// on load.
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteString("SELECT 3;\nSELECT 'secret';\n.schema\n")
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		loaded := newReplHistory(conf)
+		assert.Equal(t, []string{"SELECT 2;", "SELECT 3;", ".schema"}, loaded.entries)
+	})
+
+	t.Run("Configured file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "history")
+		h := newReplHistory(config.Config{HistoryFile: path})
+		h.add("SELECT 1;")
+		require.NoError(t, h.save())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT 1;\n", string(content))
+	})
+}
+
+func TestParseHistoryReference(t *testing.T) {
+	tests := []struct {
+		input string
+		index int
+		ok    bool
+	}{
+		{input: "!12", index: 12, ok: true},
+		{input: "!0"},
+		{input: "!x"},
+		{input: "!"},
+		{input: "12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			index, ok := parseHistoryReference(tt.input)
+			assert.Equal(t, tt.index, index)
+			assert.Equal(t, tt.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package repl
+
+import (
+	"strings"
+)
+
+// sqlStatement is a statement found by splitStatements.
+type sqlStatement struct {
+	// text is the statement including the terminating semicolon, if any.
+	text string
+	// line is the 1-based line where the statement starts.
+	line int
+}
+
+// splitStatements splits the SQL text into the statements terminated by a
+// semicolon that is outside of strings, quoted identifiers and comments,
+// similar to sqlite3_complete. Empty statements are skipped.
+//
+// For CREATE TRIGGER statements the semicolon must also follow the END
+// keyword, because the trigger body contains semicolons on its own.
+//
+// The text after the last complete statement is returned as rest, pending
+// reports whether it contains anything other than whitespace and complete
+// comments.
+func splitStatements(sql string) (statements []sqlStatement, rest sqlStatement, pending bool) {
+	start, startLine, line := 0, 1, 1
+	firstWords := []string{}
+	lastWord := ""
+	hasContent := false
+
+	markContent := func() {
+		if !hasContent {
+			hasContent = true
+			startLine = line
+		}
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+
+		switch {
+		case c == ';':
+			if hasContent && isTriggerComplete(firstWords, lastWord == "END") {
+				statements = append(statements, sqlStatement{
+					text: strings.TrimSpace(sql[start : i+1]),
+					line: startLine,
+				})
+				start = i + 1
+				firstWords = firstWords[:0]
+				hasContent = false
+			}
+			if !hasContent {
+				start = i + 1
+			}
+			lastWord = ""
+
+		case c == '\n':
+			line++
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end == -1 {
+				i = len(sql)
+				break
+			}
+			i += end - 1
+
+		case c == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := strings.Index(sql[i+2:], "*/")
+			if end == -1 {
+				return statements, sqlStatement{text: strings.TrimSpace(sql[start:]), line: startLine}, true
+			}
+			line += strings.Count(sql[i:i+2+end], "\n")
+			i += end + 3
+
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			markContent()
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(sql[i+1:], closing)
+			if end == -1 {
+				return statements, sqlStatement{text: strings.TrimSpace(sql[start:]), line: startLine}, true
+			}
+			line += strings.Count(sql[i:i+1+end], "\n")
+			i += end + 1
+			lastWord = ""
+
+		case isWordChar(c):
+			markContent()
+			wordStart := i
+			for i+1 < len(sql) && isWordChar(sql[i+1]) {
+				i++
+			}
+			lastWord = strings.ToUpper(sql[wordStart : i+1])
+			if len(firstWords) < 3 {
+				firstWords = append(firstWords, lastWord)
+			}
+
+		default:
+			markContent()
+			lastWord = ""
+		}
+	}
+
+	return statements, sqlStatement{text: strings.TrimSpace(sql[start:]), line: startLine}, hasContent
+}
+
+// isCompleteStatement reports whether the given SQL text contains at least
+// one statement and ends with its terminating semicolon.
+func isCompleteStatement(sql string) bool {
+	statements, _, pending := splitStatements(sql)
+	return len(statements) > 0 && !pending
+}
+
+// isTriggerComplete reports whether a statement starting with the given
+// words is complete, only CREATE TRIGGER statements require the END keyword
+// before the final semicolon.
+func isTriggerComplete(firstWords []string, endsWithEnd bool) bool {
+	if len(firstWords) < 2 || firstWords[0] != "CREATE" {
+		return true
+	}
+
+	isTrigger := firstWords[1] == "TRIGGER"
+	if len(firstWords) > 2 && (firstWords[1] == "TEMP" || firstWords[1] == "TEMPORARY") {
+		isTrigger = firstWords[2] == "TRIGGER"
+	}
+
+	return !isTrigger || endsWithEnd
+}
+
+// isWordChar reports whether c can be part of an identifier or keyword.
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isSingleLineInput reports whether the first line of the input is a command
+// that doesn't need to be terminated by a semicolon.
+func isSingleLineInput(line string) bool {
+	if strings.HasPrefix(line, ".") {
+		return true
+	}
+
+	switch line {
+	case "exit", "clear", "help":
+		return true
+	}
+	return false
+}
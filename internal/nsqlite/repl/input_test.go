@@ -0,0 +1,109 @@
+package repl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCompleteStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want bool
+	}{
+		{name: "Empty", sql: "", want: false},
+		{name: "Without semicolon", sql: "SELECT 1", want: false},
+		{name: "With semicolon", sql: "SELECT 1;", want: true},
+		{name: "Trailing whitespace", sql: "SELECT 1; \n", want: true},
+		{name: "Semicolon in string", sql: "SELECT 'a;b'", want: false},
+		{name: "Semicolon in string terminated", sql: "SELECT 'a;b';", want: true},
+		{name: "Escaped quote in string", sql: "SELECT 'it''s;';", want: true},
+		{name: "Unterminated string", sql: "INSERT INTO t VALUES ('a;", want: false},
+		{name: "Semicolon in quoted identifier", sql: `SELECT "a;b" FROM t`, want: false},
+		{name: "Semicolon in bracket identifier", sql: "SELECT [a;b] FROM t", want: false},
+		{name: "Semicolon in backtick identifier", sql: "SELECT `a;b` FROM t;", want: true},
+		{name: "Semicolon in line comment", sql: "SELECT 1 -- done;", want: false},
+		{name: "Line comment after semicolon", sql: "SELECT 1; -- done", want: true},
+		{name: "Semicolon in block comment", sql: "SELECT 1 /* ; */", want: false},
+		{name: "Unterminated block comment", sql: "SELECT 1; /* ", want: false},
+		{name: "Statement after semicolon", sql: "SELECT 1; SELECT 2", want: false},
+		{
+			name: "Multi-line create table",
+			sql: strings.Join([]string{
+				"CREATE TABLE users (",
+				"  id INTEGER PRIMARY KEY,",
+				"  name TEXT DEFAULT 'a;b'",
+				");",
+			}, "\n"),
+			want: true,
+		},
+		{
+			name: "Trigger without end",
+			sql:  "CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE t SET a = 1;",
+			want: false,
+		},
+		{
+			name: "Trigger with end",
+			sql:  "CREATE TRIGGER tr AFTER INSERT ON t BEGIN UPDATE t SET a = 1; END;",
+			want: true,
+		},
+		{
+			name: "Temp trigger without end",
+			sql:  "create temp trigger tr after insert on t begin select 1;",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isCompleteStatement(tt.sql))
+		})
+	}
+}
+
+func TestIsCompleteStatementAccumulatingLines(t *testing.T) {
+	lines := []string{
+		"INSERT INTO t (a, b)",
+		"VALUES ('first;",
+		"second', 2)",
+		";",
+	}
+
+	input := ""
+	for i, line := range lines {
+		if i > 0 {
+			input += "\n"
+		}
+		input += line
+
+		assert.Equal(t, i == len(lines)-1, isCompleteStatement(input), input)
+	}
+}
+
+func TestIsSingleLineInput(t *testing.T) {
+	assert.True(t, isSingleLineInput(".tables"))
+	assert.True(t, isSingleLineInput("exit"))
+	assert.False(t, isSingleLineInput("SELECT 1"))
+}
+
+func TestSplitStatements(t *testing.T) {
+	sql := strings.Join([]string{
+		"-- create the table",
+		"CREATE TABLE t (a TEXT);",
+		"INSERT INTO t VALUES ('x;",
+		"y'); INSERT INTO t VALUES (/* ; */ 'z');",
+		";",
+		"SELECT a FROM t",
+	}, "\n")
+
+	statements, rest, pending := splitStatements(sql)
+	assert.Equal(t, []sqlStatement{
+		{text: "-- create the table\nCREATE TABLE t (a TEXT);", line: 2},
+		{text: "INSERT INTO t VALUES ('x;\ny');", line: 3},
+		{text: "INSERT INTO t VALUES (/* ; */ 'z');", line: 4},
+	}, statements)
+	assert.Equal(t, sqlStatement{text: "SELECT a FROM t", line: 6}, rest)
+	assert.True(t, pending)
+}
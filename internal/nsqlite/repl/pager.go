@@ -0,0 +1,154 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/util/sysutil"
+)
+
+// defaultPager is the pager used when $PAGER is not set, -S keeps the wide
+// tables unwrapped and -R keeps the colors.
+const defaultPager = "less -S -R"
+
+// morePrompt is shown by the internal paginator between pages.
+const morePrompt = "-- More --"
+
+// writePaged writes the rendered output to stdout, through a pager if paging
+// is enabled and it doesn't fit in the terminal. Output that is not written
+// to a terminal is never paged.
+func (r *Repl) writePaged(output []byte) {
+	if !r.paging || r.output != os.Stdout {
+		r.writeOutput(output)
+		return
+	}
+
+	_, height, err := sysutil.TerminalSize(os.Stdout)
+	if err != nil || !exceedsHeight(output, height) {
+		r.writeOutput(output)
+		return
+	}
+
+	if err := runExternalPager(os.Getenv("PAGER"), output); err == nil {
+		return
+	}
+
+	restore, err := sysutil.MakeRaw(os.Stdin)
+	if err != nil {
+		r.writeOutput(output)
+		return
+	}
+	defer restore()
+
+	if err := paginate(newRawWriter(os.Stdout), r.reader, output, height); err != nil {
+		fmt.Println("Failed to write results:", err)
+	}
+}
+
+// writeOutput writes the rendered output as is.
+func (r *Repl) writeOutput(output []byte) {
+	if _, err := r.output.Write(output); err != nil {
+		fmt.Println("Failed to write results:", err)
+	}
+}
+
+// exceedsHeight reports whether the output has more lines than fit in a
+// terminal of the given height, leaving a line for the prompt.
+func exceedsHeight(output []byte, height int) bool {
+	return height > 0 && bytes.Count(output, []byte("\n")) >= height
+}
+
+// runExternalPager writes the output through the given pager command, or
+// the default pager if it is empty. It returns an error if the pager is not
+// available.
+func runExternalPager(pager string, output []byte) error {
+	if strings.TrimSpace(pager) == "" {
+		pager = defaultPager
+	}
+
+	fields := strings.Fields(pager)
+	path, err := exec.LookPath(fields[0])
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(path, fields[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// paginate writes the output to w one page at a time, showing a "-- More --"
+// prompt after each page and reading the keys from keys: space shows the next
+// page, enter the next line and q quits. The end of the keys also quits.
+func paginate(w io.Writer, keys io.Reader, output []byte, height int) error {
+	lines := strings.SplitAfter(string(output), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	pageSize := max(height-1, 1)
+	keyReader := bufio.NewReader(keys)
+
+	next := min(pageSize, len(lines))
+	if _, err := io.WriteString(w, strings.Join(lines[:next], "")); err != nil {
+		return err
+	}
+
+	for next < len(lines) {
+		if _, err := io.WriteString(w, morePrompt); err != nil {
+			return err
+		}
+
+		key, readErr := keyReader.ReadByte()
+		if _, err := io.WriteString(w, "\r\033[K"); err != nil {
+			return err
+		}
+		if readErr != nil {
+			return nil
+		}
+
+		count := 0
+		switch key {
+		case ' ':
+			count = pageSize
+		case '\r', '\n':
+			count = 1
+		case 'q', 'Q', 3: // 3 is CTRL+C in raw mode
+			return nil
+		default:
+			continue
+		}
+
+		end := min(next+count, len(lines))
+		if _, err := io.WriteString(w, strings.Join(lines[next:end], "")); err != nil {
+			return err
+		}
+		next = end
+	}
+
+	return nil
+}
+
+// rawWriter translates the line feeds to carriage return and line feed, as
+// needed by a terminal in raw mode.
+type rawWriter struct {
+	w io.Writer
+}
+
+func newRawWriter(w io.Writer) rawWriter {
+	return rawWriter{w: w}
+}
+
+func (rw rawWriter) Write(p []byte) (int, error) {
+	if _, err := rw.w.Write(bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
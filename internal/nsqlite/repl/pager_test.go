@@ -0,0 +1,95 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExceedsHeight(t *testing.T) {
+	output := []byte("1\n2\n3\n")
+
+	assert.False(t, exceedsHeight(output, 4))
+	assert.True(t, exceedsHeight(output, 3))
+	assert.True(t, exceedsHeight(output, 2))
+	assert.False(t, exceedsHeight(output, 0), "unknown heights are never paged")
+}
+
+func TestPaginate(t *testing.T) {
+	lines := []string{}
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, fmt.Sprintf("row %d\n", i))
+	}
+	output := []byte(strings.Join(lines, ""))
+	erased := morePrompt + "\r\033[K"
+
+	tests := []struct {
+		name   string
+		height int
+		keys   string
+		want   string
+	}{
+		{
+			name:   "Quits after the first page",
+			height: 4,
+			keys:   "q",
+			want:   strings.Join(lines[:3], "") + erased,
+		},
+		{
+			name:   "Shows the next page with space",
+			height: 4,
+			keys:   " q",
+			want:   strings.Join(lines[:3], "") + erased + strings.Join(lines[3:6], "") + erased,
+		},
+		{
+			name:   "Shows the next line with enter",
+			height: 4,
+			keys:   "\rq",
+			want:   strings.Join(lines[:3], "") + erased + lines[3] + erased,
+		},
+		{
+			name:   "Ignores other keys",
+			height: 4,
+			keys:   "xq",
+			want:   strings.Join(lines[:3], "") + erased + erased,
+		},
+		{
+			name:   "Stops at the end of the output",
+			height: 6,
+			keys:   "    ",
+			want:   strings.Join(lines[:5], "") + erased + strings.Join(lines[5:], ""),
+		},
+		{
+			name:   "Quits when the keys end",
+			height: 6,
+			keys:   "",
+			want:   strings.Join(lines[:5], "") + erased,
+		},
+		{
+			name:   "Output that fits is written at once",
+			height: 20,
+			keys:   "",
+			want:   string(output),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.Buffer{}
+			require.NoError(t, paginate(&out, strings.NewReader(tt.keys), output, tt.height))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestRawWriter(t *testing.T) {
+	out := bytes.Buffer{}
+	n, err := newRawWriter(&out).Write([]byte("a\nb\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, "a\r\nb\r\n", out.String())
+}
@@ -0,0 +1,275 @@
+package repl
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+)
+
+// Output modes supported by the .mode command.
+const (
+	outputModeTable    = "table"
+	outputModeJSON     = "json"
+	outputModeCSV      = "csv"
+	outputModeLine     = "line"
+	outputModeMarkdown = "markdown"
+)
+
+// outputModes are all the supported output modes.
+var outputModes = []string{
+	outputModeTable, outputModeJSON, outputModeCSV, outputModeLine, outputModeMarkdown,
+}
+
+// renderOptions are the display settings used to render the rows of the
+// query results.
+type renderOptions struct {
+	mode string
+	// nullValue is the text shown for NULL values, empty to use the default
+	// of the mode.
+	nullValue string
+	// headers reports whether the column names are written in the table,
+	// CSV and markdown modes.
+	headers bool
+	// width is the maximum number of runes shown per cell in the table, line
+	// and markdown modes, 0 means unlimited.
+	width int
+}
+
+// newRenderOptions returns the default render options for the given mode.
+func newRenderOptions(mode string) renderOptions {
+	return renderOptions{mode: mode, headers: true}
+}
+
+// displayNull returns the text shown for NULL values in the display modes.
+func (o renderOptions) displayNull() string {
+	if o.nullValue == "" {
+		return "NULL"
+	}
+	return o.nullValue
+}
+
+// formatCell formats a value for the display modes, applying the NULL text
+// and the maximum width.
+func (o renderOptions) formatCell(value any, typ string) string {
+	if value == nil {
+		return truncateRunes(o.displayNull(), o.width)
+	}
+	return truncateRunes(formatOutputValue(value, typ), o.width)
+}
+
+// renderRows writes the rows of a query result to w using the given options.
+func renderRows(w io.Writer, opts renderOptions, columns []string, types []string, rows [][]any) error {
+	switch opts.mode {
+	case outputModeJSON:
+		return renderJSON(w, columns, rows)
+	case outputModeCSV:
+		return renderCSV(w, opts, columns, types, rows)
+	case outputModeLine:
+		return renderLine(w, opts, columns, types, rows)
+	case outputModeMarkdown:
+		return renderMarkdown(w, opts, columns, types, rows)
+	default:
+		return renderTable(w, opts, columns, types, rows)
+	}
+}
+
+// renderTable writes the rows as a table, NULL values are dimmed so they can
+// be told apart from text.
+func renderTable(w io.Writer, opts renderOptions, columns []string, types []string, rows [][]any) error {
+	tw := styled.NewTableWriter()
+
+	if opts.headers {
+		header := table.Row{}
+		for _, col := range columns {
+			header = append(header, col)
+		}
+		tw.AppendHeader(header)
+	}
+
+	for _, row := range rows {
+		cells := make(table.Row, len(row))
+		for i, value := range row {
+			cells[i] = opts.formatCell(value, columnType(types, i))
+			if value == nil {
+				cells[i] = styled.DimmedColor().Sprint(cells[i])
+			}
+		}
+		tw.AppendRow(cells)
+	}
+
+	_, err := fmt.Fprintln(w, tw.Render())
+	return err
+}
+
+// renderJSON writes the rows as an array of objects keyed by column name,
+// keeping the order of the columns. Blobs are kept as base64 strings.
+func renderJSON(w io.Writer, columns []string, rows [][]any) error {
+	sb := strings.Builder{}
+	sb.WriteString("[")
+
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n  {")
+
+		for j, col := range columns {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+
+			key, err := json.Marshal(col)
+			if err != nil {
+				return fmt.Errorf("failed to encode column name: %w", err)
+			}
+			value, err := json.Marshal(row[j])
+			if err != nil {
+				return fmt.Errorf("failed to encode value of %s: %w", col, err)
+			}
+
+			sb.Write(key)
+			sb.WriteString(":")
+			sb.Write(value)
+		}
+
+		sb.WriteString("}")
+	}
+
+	if len(rows) > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("]\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// renderCSV writes the rows as CSV, NULL values are written as empty fields
+// unless a NULL text is set. Values are never truncated.
+func renderCSV(w io.Writer, opts renderOptions, columns []string, types []string, rows [][]any) error {
+	cw := csv.NewWriter(w)
+	if opts.headers {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value == nil {
+				record[i] = opts.nullValue
+				continue
+			}
+			record[i] = formatOutputValue(value, columnType(types, i))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderLine writes one "column: value" pair per line and an empty line
+// between rows.
+func renderLine(w io.Writer, opts renderOptions, columns []string, types []string, rows [][]any) error {
+	width := 0
+	for _, col := range columns {
+		width = max(width, len(col))
+	}
+
+	sb := strings.Builder{}
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		for j, col := range columns {
+			fmt.Fprintf(&sb, "%*s: %s\n", width, col, opts.formatCell(row[j], columnType(types, j)))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// renderMarkdown writes the rows as a GitHub flavored markdown table, without
+// the header the rows are written alone.
+func renderMarkdown(w io.Writer, opts renderOptions, columns []string, types []string, rows [][]any) error {
+	escape := strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+	sb := strings.Builder{}
+
+	writeRow := func(cells []string) {
+		sb.WriteString("|")
+		for _, cell := range cells {
+			sb.WriteString(" " + escape.Replace(cell) + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	if opts.headers {
+		writeRow(columns)
+
+		separator := make([]string, len(columns))
+		for i := range separator {
+			separator[i] = "---"
+		}
+		writeRow(separator)
+	}
+
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = opts.formatCell(value, columnType(types, i))
+		}
+		writeRow(cells)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// columnType returns the type of the column at the given index, or an empty
+// string if it is unknown.
+func columnType(types []string, index int) string {
+	if index < len(types) {
+		return types[index]
+	}
+	return ""
+}
+
+// formatOutputValue formats a value returned by the server as text. Blobs
+// are received as base64 strings and are formatted as X'..' literals.
+func formatOutputValue(value any, typ string) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	if str, ok := value.(string); ok && typ == "BLOB" {
+		if data, err := base64.StdEncoding.DecodeString(str); err == nil {
+			return "X'" + strings.ToUpper(hex.EncodeToString(data)) + "'"
+		}
+	}
+
+	return fmt.Sprint(value)
+}
+
+// truncateRunes truncates the text to the given number of runes, replacing
+// the last one with an ellipsis. A width of 0 means unlimited.
+func truncateRunes(text string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	return string(runes[:width-1]) + "…"
+}
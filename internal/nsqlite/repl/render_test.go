@@ -0,0 +1,180 @@
+package repl
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderRows(t *testing.T) {
+	columns := []string{"id", "name", "avatar"}
+	types := []string{"INTEGER", "TEXT", "BLOB"}
+	rows := [][]any{
+		{json.Number("1"), "Smith, John", "AP8Q"},
+		{json.Number("2"), nil, nil},
+		{json.Number("3"), "a|b\nc", ""},
+	}
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{
+			mode: outputModeJSON,
+			want: "[\n" +
+				`  {"id":1,"name":"Smith, John","avatar":"AP8Q"},` + "\n" +
+				`  {"id":2,"name":null,"avatar":null},` + "\n" +
+				`  {"id":3,"name":"a|b\nc","avatar":""}` + "\n" +
+				"]\n",
+		},
+		{
+			mode: outputModeCSV,
+			want: "id,name,avatar\n" +
+				"1,\"Smith, John\",X'00FF10'\n" +
+				"2,,\n" +
+				"3,\"a|b\nc\",X''\n",
+		},
+		{
+			mode: outputModeLine,
+			want: "    id: 1\n" +
+				"  name: Smith, John\n" +
+				"avatar: X'00FF10'\n" +
+				"\n" +
+				"    id: 2\n" +
+				"  name: NULL\n" +
+				"avatar: NULL\n" +
+				"\n" +
+				"    id: 3\n" +
+				"  name: a|b\nc\n" +
+				"avatar: X''\n",
+		},
+		{
+			mode: outputModeMarkdown,
+			want: "| id | name | avatar |\n" +
+				"| --- | --- | --- |\n" +
+				"| 1 | Smith, John | X'00FF10' |\n" +
+				"| 2 | NULL | NULL |\n" +
+				"| 3 | a\\|b<br>c | X'' |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			out := bytes.Buffer{}
+			require.NoError(t, renderRows(&out, newRenderOptions(tt.mode), columns, types, rows))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+
+	t.Run(outputModeTable, func(t *testing.T) {
+		out := bytes.Buffer{}
+		require.NoError(t, renderRows(&out, newRenderOptions(outputModeTable), columns, types, rows))
+		assert.Contains(t, out.String(), "avatar")
+		assert.Contains(t, out.String(), "Smith, John")
+	})
+
+	t.Run("Empty JSON result", func(t *testing.T) {
+		out := bytes.Buffer{}
+		require.NoError(t, renderRows(&out, newRenderOptions(outputModeJSON), columns, types, nil))
+		assert.Equal(t, "[]\n", out.String())
+	})
+}
+
+func TestRenderOptions(t *testing.T) {
+	text.DisableColors()
+	defer text.EnableColors()
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	columns := []string{"id", "bio"}
+	types := []string{"INTEGER", "TEXT"}
+	rows := [][]any{
+		{json.Number("1"), "A very long biography"},
+		{json.Number("2"), nil},
+		{json.Number("3"), ""},
+	}
+
+	tests := []struct {
+		name string
+		opts renderOptions
+		want string
+	}{
+		{
+			name: "Defaults",
+			opts: newRenderOptions(outputModeTable),
+			want: "┌────┬───────────────────────┐\n" +
+				"│ id │ bio                   │\n" +
+				"├────┼───────────────────────┤\n" +
+				"│ 1  │ A very long biography │\n" +
+				"│ 2  │ NULL                  │\n" +
+				"│ 3  │                       │\n" +
+				"└────┴───────────────────────┘\n",
+		},
+		{
+			name: "Custom NULL value, width and no headers",
+			opts: renderOptions{mode: outputModeTable, nullValue: "∅", width: 8},
+			want: "┌───┬──────────┐\n" +
+				"│ 1 │ A very … │\n" +
+				"│ 2 │ ∅        │\n" +
+				"│ 3 │          │\n" +
+				"└───┴──────────┘\n",
+		},
+		{
+			name: "CSV without headers",
+			opts: renderOptions{mode: outputModeCSV, nullValue: "NULL", width: 8},
+			want: "1,A very long biography\n" +
+				"2,NULL\n" +
+				"3,\n",
+		},
+		{
+			name: "Line with width",
+			opts: renderOptions{mode: outputModeLine, headers: true, width: 6},
+			want: " id: 1\n" +
+				"bio: A ver…\n" +
+				"\n" +
+				" id: 2\n" +
+				"bio: NULL\n" +
+				"\n" +
+				" id: 3\n" +
+				"bio: \n",
+		},
+		{
+			name: "Markdown without headers",
+			opts: renderOptions{mode: outputModeMarkdown, nullValue: "-", width: 4},
+			want: "| 1 | A v… |\n" +
+				"| 2 | - |\n" +
+				"| 3 |  |\n",
+		},
+		{
+			name: "JSON keeps NULL and full values",
+			opts: renderOptions{mode: outputModeJSON, nullValue: "-", width: 4},
+			want: "[\n" +
+				`  {"id":1,"bio":"A very long biography"},` + "\n" +
+				`  {"id":2,"bio":null},` + "\n" +
+				`  {"id":3,"bio":""}` + "\n" +
+				"]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := bytes.Buffer{}
+			require.NoError(t, renderRows(&out, tt.opts, columns, types, rows))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "hello", truncateRunes("hello", 0))
+	assert.Equal(t, "hello", truncateRunes("hello", 5))
+	assert.Equal(t, "hel…", truncateRunes("hello", 4))
+	assert.Equal(t, "ñá…", truncateRunes("ñáéíó", 3))
+	assert.Equal(t, "…", truncateRunes("hello", 1))
+}
@@ -4,12 +4,16 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
 	"github.com/nsqlite/nsqlite/internal/nsqlite/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/httplog"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/util/syncutil"
 	"github.com/nsqlite/nsqlite/internal/util/sysutil"
 	"github.com/nsqlite/nsqlite/internal/version"
 	"github.com/nsqlite/nsqlitego/nsqlitehttp"
@@ -17,13 +21,40 @@ import (
 )
 
 type Repl struct {
-	conf        config.Config
-	client      *nsqlitehttp.Client
-	ctx         context.Context
-	stop        context.CancelFunc
-	reader      *bufio.Reader
-	txId        string
-	historyPath string
+	conf   config.Config
+	client *nsqlitehttp.Client
+	api    *apiclient.Client
+	schema *schemaCache
+	ctx    context.Context
+	stop   context.CancelFunc
+	reader *bufio.Reader
+	txId   string
+	// txStartedAt and txLastUsedAt are the local times when the current
+	// transaction was started and last used, to estimate its idle timeout.
+	txStartedAt   time.Time
+	txLastUsedAt  time.Time
+	txIdleTimeout time.Duration
+	history       *historyStore
+	render        renderOptions
+	output        io.Writer
+	outputFile    *os.File
+	timer         bool
+	paging        bool
+	// eqp reports whether the query plan is shown before the results of
+	// every statement.
+	eqp bool
+	// safe reports whether the destructive statements are confirmed before
+	// sending them to the server.
+	safe   bool
+	params map[string]any
+	// httpLog logs the HTTP exchanges with the server while the verbose
+	// mode is enabled.
+	httpLog *httplog.Transport
+	// cancelCommand cancels the running command, if any.
+	cancelCommand *syncutil.Atomic[context.CancelFunc]
+	// inFlight are the query requests waiting for the server, which are
+	// also canceled on the server when the command is interrupted.
+	inFlight *apiclient.InFlightQueries
 }
 
 func NewRepl(
@@ -31,41 +62,75 @@ func NewRepl(
 	stop context.CancelFunc,
 	conf config.Config,
 	client *nsqlitehttp.Client,
+	httpLog *httplog.Transport,
+	inFlight *apiclient.InFlightQueries,
 ) Repl {
+	mode := conf.Format
+	if mode == "" {
+		mode = outputModeTable
+	}
+
+	api := apiclient.NewClient(
+		conf.ParsedConnStr, apiclient.WithTransport(httpLog), apiclient.WithDatabase(conf.Database),
+	)
+
 	return Repl{
-		conf:        conf,
-		client:      client,
-		ctx:         ctx,
-		stop:        stop,
-		reader:      bufio.NewReader(os.Stdin),
-		historyPath: filepath.Join(os.TempDir(), ".nsqlite_history"),
+		conf:          conf,
+		client:        client,
+		api:           api,
+		httpLog:       httpLog,
+		schema:        newSchemaCache(client),
+		ctx:           ctx,
+		stop:          stop,
+		reader:        bufio.NewReader(os.Stdin),
+		history:       newReplHistory(conf),
+		render:        newRenderOptions(mode),
+		output:        os.Stdout,
+		timer:         conf.Timer,
+		txIdleTimeout: conf.TxIdleTimeout,
+		paging:        true,
+		safe:          true,
+		params:        map[string]any{},
+		cancelCommand: syncutil.NewAtomic[context.CancelFunc](nil),
+		inFlight:      inFlight,
 	}
 }
 
 func (r *Repl) Start() error {
 	remoteURL := r.conf.ParsedConnStr.String()
 
-	if err := r.client.IsHealthy(context.TODO()); err != nil {
+	ctx, cancel := r.requestContext()
+	err := r.client.IsHealthy(ctx)
+	cancel()
+	if err != nil {
 		return fmt.Errorf("failed to connect to %s: %w", remoteURL, err)
 	}
 
-	remoteVersion, err := r.client.GetVersion(context.TODO())
+	ctx, cancel = r.requestContext()
+	remoteVersion, err := r.api.RemoteVersion(ctx)
+	cancel()
 	if err != nil {
 		return fmt.Errorf("failed to get remote NSQLite version: %w", err)
 	}
 
 	fmt.Println()
-	fmt.Printf("Connected to %s running NSQLite %s\n", remoteURL, remoteVersion)
+	fmt.Printf("Connected to %s running NSQLite %s\n", remoteURL, remoteVersion.Server)
 	fmt.Println(`Enter ".help" for usage hints and ".quit" or "CTRL+C" to quit`)
+	fmt.Println(`Enter SQL statements terminated with a ";"`)
 	fmt.Println()
-
-	if version.Version != remoteVersion {
-		fmt.Printf(
-			"Warning: Your CLI version is %s, but the server is running %s\n",
-			version.Version, remoteVersion,
-		)
-		fmt.Println("To avoid compatibility issues, consider using the same version on both sides")
-		fmt.Println()
+	printVersionMismatch(os.Stdout, remoteVersion)
+
+	if r.conf.Database != "" {
+		ctx, cancel := r.requestContext()
+		caps, err := r.api.Capabilities(ctx)
+		cancel()
+		if err == nil && !caps.Has(apiclient.CapabilityMultiDB) {
+			fmt.Printf(
+				"Warning: %s, the database %s of the connection string is ignored\n",
+				caps.Require(apiclient.CapabilityMultiDB), r.conf.Database,
+			)
+			fmt.Println()
+		}
 	}
 
 	for {
@@ -94,13 +159,8 @@ func (r *Repl) Start() error {
 				continue
 			}
 
-			if input == ".tables" {
-				cmdQuery(r, `
-					SELECT name
-					FROM sqlite_master
-					WHERE type IN ('table','view')
-					ORDER BY 1
-				`, nil)
+			if strings.HasPrefix(input, ".tables") {
+				cmdTables(r, strings.TrimPrefix(input, ".tables"))
 				continue
 			}
 
@@ -126,13 +186,8 @@ func (r *Repl) Start() error {
 				continue
 			}
 
-			if input == ".indexes" {
-				cmdQuery(r, `
-					SELECT name
-					FROM sqlite_master
-					WHERE type = 'index'
-					ORDER BY 1
-				`, nil)
+			if strings.HasPrefix(input, ".indexes") {
+				cmdIndexes(r, strings.TrimPrefix(input, ".indexes"))
 				continue
 			}
 
@@ -146,22 +201,146 @@ func (r *Repl) Start() error {
 				continue
 			}
 
-			if input == ".schema" {
-				cmdQuery(r, `SELECT sql FROM sqlite_master`, nil)
+			if strings.HasPrefix(input, ".schema") {
+				cmdSchema(r, strings.TrimPrefix(input, ".schema"))
 				continue
 			}
 
-			if strings.HasPrefix(input, ".stats") {
-				statsQty := 5
-				numStr := strings.TrimSpace(strings.TrimPrefix(input, ".stats"))
-				if numStr != "" {
-					num, err := strconv.Atoi(numStr)
-					if err == nil {
-						statsQty = num
-					}
-				}
+			if strings.HasPrefix(input, ".mode") {
+				cmdMode(r, strings.TrimPrefix(input, ".mode"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".output") {
+				cmdOutput(r, strings.TrimPrefix(input, ".output"))
+				continue
+			}
+
+			if input == ".begin" {
+				cmdBegin(r)
+				continue
+			}
+
+			if input == ".commit" {
+				cmdCommit(r)
+				continue
+			}
+
+			if input == ".rollback" {
+				cmdRollback(r)
+				continue
+			}
+
+			if input == ".tx" {
+				cmdTx(r)
+				continue
+			}
+
+			if strings.HasPrefix(input, ".watch") {
+				cmdWatch(r, strings.TrimPrefix(input, ".watch"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".param") {
+				cmdParam(r, strings.TrimPrefix(input, ".param"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".nullvalue") {
+				cmdNullValue(r, strings.TrimPrefix(input, ".nullvalue"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".headers") {
+				cmdHeaders(r, strings.TrimPrefix(input, ".headers"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".width") {
+				cmdWidth(r, strings.TrimPrefix(input, ".width"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".paging") {
+				cmdPaging(r, strings.TrimPrefix(input, ".paging"))
+				continue
+			}
+
+			if input == ".settings" {
+				cmdSettings(r)
+				continue
+			}
 
-				cmdStats(r, statsQty)
+			if strings.HasPrefix(input, ".timer") {
+				cmdTimer(r, strings.TrimPrefix(input, ".timer"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".backup") {
+				cmdBackup(r, strings.TrimPrefix(input, ".backup"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".restore") {
+				cmdRestore(r, strings.TrimPrefix(input, ".restore"))
+				r.schema.invalidate()
+				continue
+			}
+
+			if strings.HasPrefix(input, ".read") {
+				cmdRead(r, strings.TrimPrefix(input, ".read"))
+				r.schema.invalidate()
+				continue
+			}
+
+			if strings.HasPrefix(input, ".dump") {
+				cmdDump(r, strings.TrimPrefix(input, ".dump"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".export") {
+				cmdExport(r, strings.TrimPrefix(input, ".export"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".import") {
+				cmdImport(r, strings.TrimPrefix(input, ".import"))
+				r.schema.invalidate()
+				continue
+			}
+
+			if strings.HasPrefix(input, ".verbose") {
+				cmdVerbose(r, strings.TrimPrefix(input, ".verbose"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".history") {
+				cmdHistory(r, strings.TrimPrefix(input, ".history"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".explain") {
+				cmdExplain(r, strings.TrimPrefix(input, ".explain"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".eqp") {
+				cmdEqp(r, strings.TrimPrefix(input, ".eqp"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".safe") {
+				cmdSafe(r, strings.TrimPrefix(input, ".safe"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".cancel") {
+				cmdCancel(r, strings.TrimPrefix(input, ".cancel"))
+				continue
+			}
+
+			if strings.HasPrefix(input, ".stats") {
+				cmdStats(r, strings.TrimPrefix(input, ".stats"))
 				continue
 			}
 
@@ -170,19 +349,126 @@ func (r *Repl) Start() error {
 				continue
 			}
 
-			cmdQuery(r, input, nil)
+			if !confirmDestructive(r, input) {
+				fmt.Println("Statement canceled")
+				fmt.Println()
+				continue
+			}
+
+			params, missing := bindParams(r.params, input)
+			for _, placeholder := range missing {
+				fmt.Printf("Warning: no value set for %s, it is bound as NULL\n", placeholder)
+			}
+			if r.eqp && !isExplainStatement(input) {
+				// The plan is a hint, the statement is executed anyway and
+				// reports its own errors.
+				_ = showQueryPlan(r, input, params)
+			}
+			cmdQuery(r, input, params)
 		}
 	}
 }
 
 // Shutdown stops the REPL.
 func (r *Repl) Shutdown() {
+	r.resetOutput()
 	r.stop()
 }
 
+// Interrupt cancels the running REPL command, like a query or .watch, and
+// its in-flight queries on the server. It reports whether there was a
+// command to cancel, so it returns false when called again while the
+// command is still being canceled.
+func (r *Repl) Interrupt() bool {
+	cancel := r.cancelCommand.Swap(nil)
+	if cancel == nil {
+		return false
+	}
+
+	var running []apiclient.InFlightQuery
+	if r.inFlight != nil {
+		running = r.inFlight.List()
+	}
+	cancel()
+
+	// The server keeps executing the statements of a canceled request, so
+	// it is asked to interrupt them too if it supports it.
+	for _, query := range running {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cancelQueryTimeout)
+			defer cancel()
+			if caps, err := r.api.Capabilities(ctx); err == nil && !caps.Has(apiclient.CapabilityCancel) {
+				return
+			}
+			_ = r.api.CancelQuery(ctx, query.ID)
+		}()
+	}
+	return true
+}
+
+// commandContext returns the context for a command that is canceled by
+// Interrupt without exiting the REPL. done must be called when the command
+// finishes.
+func (r *Repl) commandContext() (ctx context.Context, done func()) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	r.cancelCommand.Store(cancel)
+
+	return ctx, func() {
+		r.cancelCommand.Store(nil)
+		cancel()
+	}
+}
+
+// requestContext returns a context for a single request to the server, done
+// after the request timeout, if any, or when the REPL stops.
+func (r *Repl) requestContext() (context.Context, context.CancelFunc) {
+	if r.conf.RequestTimeout <= 0 {
+		return context.WithCancel(r.ctx)
+	}
+	return context.WithTimeout(r.ctx, r.conf.RequestTimeout)
+}
+
+// requireCapability reports whether the server supports the capability
+// needed by a command, printing why the command can't be used otherwise. If
+// the capabilities can't be requested the command is tried anyway, so it
+// reports its own error.
+func (r *Repl) requireCapability(capability apiclient.Capability) bool {
+	ctx, cancel := context.WithTimeout(r.ctx, cancelQueryTimeout)
+	defer cancel()
+
+	caps, err := r.api.Capabilities(ctx)
+	if err != nil {
+		return true
+	}
+	if err := caps.Require(capability); err != nil {
+		fmt.Println(err)
+		fmt.Println()
+		return false
+	}
+	return true
+}
+
+// confirm asks a yes or no question to the user and reports whether the
+// answer is yes.
+func (r *Repl) confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+
+	answer, err := r.reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 // setTxId sets the current transaction ID for the REPL. Send empty string to
 // reset the transaction ID.
 func (r *Repl) setTxId(txId string) {
+	if txId != "" && txId != r.txId {
+		r.txStartedAt = time.Now()
+		r.txLastUsedAt = r.txStartedAt
+	}
 	r.txId = txId
 }
 
@@ -194,7 +480,9 @@ func (r *Repl) cleanError(errStr string) string {
 	return strings.TrimSpace(errStr)
 }
 
-// prompt shows the prompt and reads the input from the user.
+// prompt shows the prompt and reads the input from the user. SQL statements
+// can span multiple lines and are read until they are terminated by a
+// semicolon or a lone ";" line, dot-commands are always single-line.
 func (r *Repl) prompt() string {
 	label := "NSQLite> "
 	if r.txId != "" {
@@ -204,31 +492,92 @@ func (r *Repl) prompt() string {
 		}
 		label = fmt.Sprintf("NSQLite(%s)> ", txId)
 	}
+	continuationLabel := "   ...> "
 
 	line := liner.NewLiner()
 	defer line.Close()
 	line.SetCtrlCAborts(true)
-	line.SetCompleter(cmdHelpCompleter)
+	line.SetCompleter(func(line string) []string {
+		return cmdHelpCompleter(r.schema, line)
+	})
 
-	if file, err := os.Open(r.historyPath); err == nil {
-		_, _ = line.ReadHistory(file)
-		file.Close()
+	for _, entry := range r.history.entries {
+		line.AppendHistory(entry)
 	}
 
-	prompt, err := line.Prompt(label)
-	if err != nil {
-		if err == liner.ErrPromptAborted {
-			fmt.Println("CTRL+C pressed, exiting...")
-			return ".quit"
+	lines := []string{}
+	for {
+		if warning, expired := r.txIdleWarning(time.Now()); warning != "" {
+			if expired {
+				styled.DangerColor().Println(warning)
+			} else {
+				styled.WarningColor().Println(warning)
+			}
 		}
+
+		prompt, err := line.Prompt(label)
+		if err != nil {
+			if err == liner.ErrPromptAborted {
+				fmt.Println("CTRL+C pressed, exiting...")
+				return ".quit"
+			}
+			return ""
+		}
+
+		trimmed := strings.TrimSpace(prompt)
+		if index, ok := parseHistoryReference(trimmed); ok && len(lines) == 0 {
+			entry, ok := r.history.get(index)
+			if !ok {
+				fmt.Printf("No history entry %d\n", index)
+				return ""
+			}
+			fmt.Println(entry)
+			lines = append(lines, entry)
+			break
+		}
+		if len(lines) == 0 && isSingleLineInput(trimmed) {
+			lines = append(lines, trimmed)
+			break
+		}
+		if len(lines) == 0 && trimmed == "" {
+			return ""
+		}
+		if trimmed == ";" {
+			break
+		}
+
+		lines = append(lines, prompt)
+		if isCompleteStatement(strings.Join(lines, "\n")) {
+			break
+		}
+		label = continuationLabel
+	}
+
+	input := strings.TrimSpace(strings.Join(lines, "\n"))
+	if input == "" {
 		return ""
 	}
 
-	line.AppendHistory(prompt)
-	if file, err := os.Create(r.historyPath); err == nil {
-		_, _ = line.WriteHistory(file)
-		file.Close()
+	if r.history.add(input) {
+		if err := r.history.save(); err != nil {
+			fmt.Println("Failed to save history:", err)
+		}
+	}
+
+	return input
+}
+
+// printVersionMismatch warns if the server runs another NSQLite version
+// than the CLI.
+func printVersionMismatch(w io.Writer, remote version.Info) {
+	if remote.Server == version.Version {
+		return
 	}
 
-	return strings.TrimSpace(prompt)
+	fmt.Fprintf(
+		w, "Warning: Your CLI version is %s, but the server is running %s\n",
+		version.Version, remote.Server,
+	)
+	fmt.Fprintln(w, "To avoid compatibility issues, consider using the same version on both sides")
+	fmt.Fprintln(w)
 }
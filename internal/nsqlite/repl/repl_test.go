@@ -0,0 +1,86 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/config"
+	"github.com/nsqlite/nsqlite/internal/version"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintVersionMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		warning bool
+	}{
+		{name: "Older server answering plain text", body: "v0.0.9\n", warning: true},
+		{name: "Server with build metadata", body: `{"server":"v0.0.9","goVersion":"go1.23.5"}`, warning: true},
+		{name: "Same version in plain text", body: version.Version},
+		{name: "Same version with build metadata", body: `{"server":"` + version.Version + `"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			t.Cleanup(ts.Close)
+
+			connStr, err := nsqlitedsn.NewConnStrFromText(ts.URL)
+			require.NoError(t, err)
+			info, err := apiclient.NewClient(connStr).RemoteVersion(context.Background())
+			require.NoError(t, err)
+
+			out := bytes.Buffer{}
+			printVersionMismatch(&out, info)
+			if !tt.warning {
+				assert.Empty(t, out.String())
+				return
+			}
+			assert.Contains(t, out.String(), "Your CLI version is "+version.Version+", but the server is running v0.0.9\n")
+			assert.Contains(t, out.String(), "consider using the same version on both sides")
+		})
+	}
+}
+
+func TestStartRequestTimeout(t *testing.T) {
+	// The server answers the health checks but never the other requests.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			w.Header().Set("X-Server", "NSQLite")
+			_, _ = w.Write([]byte("OK"))
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	conf := config.Config{RequestTimeout: 50 * time.Millisecond}
+	var err error
+	conf.ParsedConnStr, err = nsqlitedsn.NewConnStrFromText(ts.URL)
+	require.NoError(t, err)
+	client, err := nsqlitehttp.NewClient(ts.URL)
+	require.NoError(t, err)
+	r := &Repl{
+		ctx:    context.Background(),
+		conf:   conf,
+		client: client,
+		api:    apiclient.NewClient(conf.ParsedConnStr),
+	}
+
+	start := time.Now()
+	err = r.Start()
+	assert.ErrorContains(t, err, "failed to get remote NSQLite version")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,96 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/util/sysutil"
+)
+
+const (
+	// spinnerDelay is how long a statement runs before the spinner is shown,
+	// so the fast statements don't flicker.
+	spinnerDelay = time.Second
+	// spinnerInterval is how often the spinner is redrawn.
+	spinnerInterval = 100 * time.Millisecond
+)
+
+// spinnerFrames are the frames of the spinner animation.
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// clearLineSequence moves the cursor to the start of the line and clears it.
+const clearLineSequence = "\r\033[K"
+
+// spinner draws an inline spinner with the elapsed time while a statement
+// is waiting for the response of the server.
+type spinner struct {
+	w     io.Writer
+	start time.Time
+	// stop is closed to stop the spinner, and stopped is closed once the
+	// line of the spinner has been cleared.
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// startSpinner starts a spinner that writes to w after delay, redrawing it
+// every interval until Stop is called.
+func startSpinner(w io.Writer, delay time.Duration, interval time.Duration) *spinner {
+	s := &spinner{
+		w:       w,
+		start:   time.Now(),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run(delay, interval)
+	return s
+}
+
+// startQuerySpinner starts the spinner of a statement when stdout is a
+// terminal. The returned function stops it and must be called before
+// printing the result.
+func startQuerySpinner() (stop func()) {
+	if !sysutil.IsTerminal(os.Stdout) {
+		return func() {}
+	}
+	return startSpinner(os.Stdout, spinnerDelay, spinnerInterval).Stop
+}
+
+func (s *spinner) run(delay time.Duration, interval time.Duration) {
+	defer close(s.stopped)
+
+	select {
+	case <-s.stop:
+		return
+	case <-time.After(delay):
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		elapsed := time.Since(s.start).Truncate(100 * time.Millisecond)
+		styled.DimmedColor().Fprintf(
+			s.w, "%s%c Running... %s (CTRL+C to cancel)",
+			clearLineSequence, spinnerFrames[frame%len(spinnerFrames)], elapsed,
+		)
+
+		select {
+		case <-s.stop:
+			fmt.Fprint(s.w, clearLineSequence)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops the spinner and waits until its line is cleared. It is safe to
+// call it more than once.
+func (s *spinner) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.stopped
+}
@@ -0,0 +1,99 @@
+package repl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockedBuffer is a bytes.Buffer that can be written by the spinner while
+// the test reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSpinner(t *testing.T) {
+	t.Run("Not shown before the delay", func(t *testing.T) {
+		buf := &lockedBuffer{}
+		s := startSpinner(buf, time.Hour, time.Millisecond)
+		s.Stop()
+		s.Stop()
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("Shown while waiting for a slow server until canceled", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The body is read so the server notices when the client goes away.
+			_, _ = io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+		}))
+		defer ts.Close()
+
+		client, err := nsqlitehttp.NewClient(ts.URL)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		buf := &lockedBuffer{}
+		s := startSpinner(buf, 0, 5*time.Millisecond)
+
+		sent := make(chan error)
+		go func() {
+			_, err := client.SendQuery(ctx, nsqlitehttp.Query{Query: "SELECT slow"})
+			sent <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return strings.Count(buf.String(), "Running...") >= 2
+		}, 5*time.Second, time.Millisecond, "the spinner was not redrawn")
+
+		cancel()
+		select {
+		case err := <-sent:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the query was not canceled")
+		}
+
+		s.Stop()
+		output := buf.String()
+		assert.Contains(t, output, "(CTRL+C to cancel)")
+		assert.True(t, strings.HasSuffix(output, clearLineSequence), "the spinner line is cleared")
+
+		select {
+		case <-s.stopped:
+		default:
+			t.Fatal("the spinner goroutine is still running")
+		}
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, output, buf.String(), "nothing is written once stopped")
+	})
+}
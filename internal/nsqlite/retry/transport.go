@@ -0,0 +1,236 @@
+// Package retry implements an http.RoundTripper that retries the requests to
+// the NSQLite server that failed because of a momentary network error.
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nsqlite/nsqlite/internal/version"
+)
+
+// IdempotencyKeyHeader is the header that identifies a write request across
+// its retries.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Options are the options of a Transport.
+type Options struct {
+	// Retries is the maximum number of retries of a request, 0 disables
+	// them.
+	Retries int
+	// Backoff is the wait before the first retry, it doubles on every
+	// following retry.
+	Backoff time.Duration
+	// OnVersionChange, if set, is called when the server reports a
+	// different version after a retry, which means it was restarted with
+	// another release during the session.
+	OnVersionChange func(previous string, current string)
+}
+
+// Transport is an http.RoundTripper that retries the idempotent requests
+// with exponential backoff, checking the /health endpoint of the server
+// before each retry.
+//
+// GET and HEAD requests are retried on any network error and on the 502,
+// 503 and 504 statuses. Other requests get an Idempotency-Key header and,
+// since they may have been applied, they are only retried when the
+// connection could not be established or the server answered 503.
+type Transport struct {
+	base    http.RoundTripper
+	opts    Options
+	sleep   func(ctx context.Context, d time.Duration) error
+	mu      sync.Mutex
+	version string
+}
+
+// NewTransport creates a new Transport that sends the requests with base.
+func NewTransport(base http.RoundTripper, opts Options) *Transport {
+	return &Transport{
+		base:  base,
+		opts:  opts,
+		sleep: sleepContext,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.opts.Retries <= 0 {
+		res, err := t.base.RoundTrip(req)
+		return t.observeVersion(req, res, err)
+	}
+
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead
+	if !idempotent && req.Header.Get(IdempotencyKeyHeader) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(IdempotencyKeyHeader, uuid.NewString())
+	}
+
+	res, err := t.base.RoundTrip(req)
+	for attempt := 1; attempt <= t.opts.Retries && shouldRetry(idempotent, res, err); attempt++ {
+		if req.Body != nil && req.GetBody == nil {
+			break
+		}
+		if res != nil {
+			drainBody(res.Body)
+		}
+
+		backoff := t.opts.Backoff << (attempt - 1)
+		if err := t.sleep(req.Context(), backoff); err != nil {
+			return nil, err
+		}
+		if !t.isHealthy(req) {
+			if err == nil {
+				err = fmt.Errorf("the server answered %s and is not healthy", res.Status)
+			}
+			res = nil
+			continue
+		}
+
+		retryReq := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			retryReq.Body = body
+		}
+		res, err = t.base.RoundTrip(retryReq)
+	}
+
+	return t.observeVersion(req, res, err)
+}
+
+// shouldRetry reports whether a request should be retried after the given
+// response or error.
+func shouldRetry(idempotent bool, res *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return idempotent || isDialError(err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// isDialError reports whether the error happened while connecting to the
+// server, so the request was never sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isHealthy checks the /health endpoint of the server of the request.
+func (t *Transport) isHealthy(req *http.Request) bool {
+	healthReq, err := t.newServerRequest(req, "/health")
+	if err != nil {
+		return false
+	}
+
+	res, err := t.base.RoundTrip(healthReq)
+	if err != nil {
+		return false
+	}
+	defer drainBody(res.Body)
+	if res.StatusCode != http.StatusOK {
+		return false
+	}
+
+	t.checkVersion(req)
+	return true
+}
+
+// checkVersion requests the version of the server and reports it to
+// OnVersionChange if it changed since the last known version.
+func (t *Transport) checkVersion(req *http.Request) {
+	versionReq, err := t.newServerRequest(req, "/version")
+	if err != nil {
+		return
+	}
+
+	res, err := t.base.RoundTrip(versionReq)
+	if err != nil {
+		return
+	}
+	_, _ = t.observeVersion(versionReq, res, nil)
+	drainBody(res.Body)
+}
+
+// observeVersion records the version returned by the /version endpoint and
+// calls OnVersionChange if it changed. The response body is kept readable.
+func (t *Transport) observeVersion(req *http.Request, res *http.Response, err error) (*http.Response, error) {
+	if err != nil || res.StatusCode != http.StatusOK || req.Method != http.MethodGet ||
+		!strings.HasSuffix(req.URL.Path, "/version") {
+		return res, err
+	}
+
+	body, readErr := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		return res, nil
+	}
+
+	version := version.ParseInfo(body).Server
+	t.mu.Lock()
+	previous := t.version
+	t.version = version
+	t.mu.Unlock()
+
+	if previous != "" && previous != version && t.opts.OnVersionChange != nil {
+		t.opts.OnVersionChange(previous, version)
+	}
+	return res, nil
+}
+
+// newServerRequest creates a GET request to the given path of the server of
+// req, keeping its authentication.
+func (t *Transport) newServerRequest(req *http.Request, path string) (*http.Request, error) {
+	url := *req.URL
+	url.Path = path
+	url.RawPath = ""
+
+	serverReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		serverReq.Header.Set("Authorization", auth)
+	}
+	return serverReq, nil
+}
+
+// drainBody reads and closes a response body so its connection can be
+// reused.
+func drainBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	_ = body.Close()
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
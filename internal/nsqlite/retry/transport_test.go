@@ -0,0 +1,205 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyServer is a test server that fails the first requests with the given
+// status, except the ones to /health and /version.
+type flakyServer struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	version  string
+	attempts []*http.Request
+	bodies   []string
+	health   int
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/health":
+		s.health++
+		_, _ = w.Write([]byte("OK"))
+		return
+	case "/version":
+		_, _ = w.Write([]byte(s.version))
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	s.attempts = append(s.attempts, r)
+	s.bodies = append(s.bodies, string(body))
+	if s.failures > 0 {
+		s.failures--
+		w.WriteHeader(s.status)
+		return
+	}
+}
+
+func newTestClient(opts Options) *http.Client {
+	transport := NewTransport(http.DefaultTransport, opts)
+	transport.sleep = func(context.Context, time.Duration) error { return nil }
+	return &http.Client{Transport: transport}
+}
+
+func TestTransport(t *testing.T) {
+	t.Run("Retries GET requests", func(t *testing.T) {
+		server := &flakyServer{failures: 1, status: http.StatusServiceUnavailable, version: "v1"}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		res, err := newTestClient(Options{Retries: 2, Backoff: time.Millisecond}).Get(ts.URL + "/stats")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Len(t, server.attempts, 2)
+		assert.Equal(t, 1, server.health, "the health is checked before retrying")
+	})
+
+	t.Run("Retries queries with the same idempotency key and body", func(t *testing.T) {
+		server := &flakyServer{failures: 1, status: http.StatusServiceUnavailable}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		client := newTestClient(Options{Retries: 2, Backoff: time.Millisecond})
+		res, err := client.Post(ts.URL+"/query", "application/json", strings.NewReader(`[{"query":"SELECT 1"}]`))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		require.Len(t, server.attempts, 2)
+		key := server.attempts[0].Header.Get(IdempotencyKeyHeader)
+		assert.NotEmpty(t, key)
+		assert.Equal(t, key, server.attempts[1].Header.Get(IdempotencyKeyHeader))
+		assert.Equal(t, []string{`[{"query":"SELECT 1"}]`, `[{"query":"SELECT 1"}]`}, server.bodies)
+	})
+
+	t.Run("Does not retry queries that may have been applied", func(t *testing.T) {
+		server := &flakyServer{failures: 1, status: http.StatusGatewayTimeout}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		client := newTestClient(Options{Retries: 2, Backoff: time.Millisecond})
+		res, err := client.Post(ts.URL+"/query", "application/json", strings.NewReader("[]"))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusGatewayTimeout, res.StatusCode)
+		assert.Len(t, server.attempts, 1)
+	})
+
+	t.Run("Gives up after the retries", func(t *testing.T) {
+		server := &flakyServer{failures: 5, status: http.StatusBadGateway}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		res, err := newTestClient(Options{Retries: 2, Backoff: time.Millisecond}).Get(ts.URL + "/stats")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+		assert.Len(t, server.attempts, 3)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		server := &flakyServer{failures: 1, status: http.StatusServiceUnavailable}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		client := newTestClient(Options{})
+		res, err := client.Post(ts.URL+"/query", "application/json", strings.NewReader("[]"))
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Empty(t, server.attempts[0].Header.Get(IdempotencyKeyHeader))
+	})
+
+	t.Run("Retries when the server can't be reached", func(t *testing.T) {
+		ts := httptest.NewServer(&flakyServer{})
+		url := ts.URL
+		ts.Close()
+
+		attempts := 0
+		transport := NewTransport(http.DefaultTransport, Options{Retries: 3, Backoff: time.Millisecond})
+		transport.sleep = func(context.Context, time.Duration) error {
+			attempts++
+			return nil
+		}
+
+		_, err := (&http.Client{Transport: transport}).Post(url+"/query", "application/json", strings.NewReader("[]"))
+		assert.Error(t, err)
+		assert.Equal(t, 3, attempts, "connection errors are retried for queries too")
+	})
+
+	t.Run("Reports version changes", func(t *testing.T) {
+		server := &flakyServer{version: "v1"}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		changes := []string{}
+		client := newTestClient(Options{
+			Retries: 1,
+			Backoff: time.Millisecond,
+			OnVersionChange: func(previous string, current string) {
+				changes = append(changes, previous+" -> "+current)
+			},
+		})
+
+		res, err := client.Get(ts.URL + "/version")
+		require.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "v1", string(body), "the version response is still readable")
+
+		server.mu.Lock()
+		server.version = "v2"
+		server.failures = 1
+		server.status = http.StatusServiceUnavailable
+		server.mu.Unlock()
+
+		res, err = client.Get(ts.URL + "/stats")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, []string{"v1 -> v2"}, changes)
+	})
+
+	t.Run("Reports upgrades to servers with build metadata", func(t *testing.T) {
+		server := &flakyServer{version: "v1"}
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		changes := []string{}
+		client := newTestClient(Options{
+			OnVersionChange: func(previous string, current string) {
+				changes = append(changes, previous+" -> "+current)
+			},
+		})
+
+		for _, version := range []string{"v1", `{"server":"v2","goVersion":"go1.23.5"}`, `{"server":"v2"}`} {
+			server.mu.Lock()
+			server.version = version
+			server.mu.Unlock()
+
+			res, err := client.Get(ts.URL + "/version")
+			require.NoError(t, err)
+			res.Body.Close()
+		}
+		assert.Equal(t, []string{"v1 -> v2"}, changes)
+	})
+}
@@ -2,33 +2,124 @@ package nsqlite
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
 	"github.com/nsqlite/nsqlite/internal/nsqlite/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/exitcode"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/httplog"
 	"github.com/nsqlite/nsqlite/internal/nsqlite/repl"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/retry"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/util/sysutil"
 	"github.com/nsqlite/nsqlite/internal/version"
 	"github.com/nsqlite/nsqlitego/nsqlitehttp"
 )
 
+// Exit codes of the NSQLite CLI when running non-interactively.
+const (
+	ExitCodeSQLError        = exitcode.SQLError
+	ExitCodeConnectionError = exitcode.ConnectionError
+	ExitCodeUsageError      = exitcode.UsageError
+)
+
+// ExitError is an error that should terminate the CLI with the given exit
+// code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
 // Run runs the NSQLite CLI.
-func Run(ctx context.Context) error {
-	conf := config.MustParse(os.Args)
+//
+// The statements given with --execute or piped through stdin are executed
+// non-interactively, otherwise the REPL is started. The banner is only
+// printed when stdout is a terminal.
+//
+// The returned error has already been written to stderr, as text or as JSON
+// with --json-errors, and is an *ExitError with the exit code to use.
+func Run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	conf, err := config.Parse(args, stdout)
+	if errors.Is(err, config.ErrHelpShown) {
+		return nil
+	}
+	if err != nil {
+		err = &ExitError{Code: ExitCodeUsageError, Err: err}
+	} else {
+		err = run(ctx, conf, stdin, stdout)
+	}
+	if err == nil {
+		return nil
+	}
 
-	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
-	defer stop()
+	exitErr := &ExitError{}
+	if !errors.As(err, &exitErr) {
+		exitErr = &ExitError{Code: ExitCodeConnectionError, Err: err}
+	}
+	writeError(stderr, exitErr, conf.JSONErrors)
+	return exitErr
+}
 
-	fmt.Println(version.CLIVersion())
+// run runs the NSQLite CLI with the parsed configuration.
+func run(ctx context.Context, conf config.Config, stdin io.Reader, stdout io.Writer) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM)
+	defer stop()
 
-	client, err := nsqlitehttp.NewClient(conf.ConnectionString)
+	httpLog := newHTTPLog(conf)
+	inFlight := apiclient.NewInFlightQueries()
+	client, err := newClient(conf, apiclient.NewRequestIDTransport(
+		apiclient.NewDatabaseTransport(httpLog, conf.Database), inFlight,
+	))
 	if err != nil {
 		return err
 	}
 
-	rp := repl.NewRepl(ctx, stop, conf, client)
+	if conf.Execute != "" || !sysutil.IsTerminal(stdin) {
+		ctx, stopInterrupt := signal.NotifyContext(ctx, os.Interrupt)
+		defer stopInterrupt()
+		return runNonInteractive(ctx, conf, client, stdin, stdout)
+	}
+
+	if sysutil.IsTerminal(stdout) {
+		fmt.Fprintln(stdout, version.CLIVersion())
+	}
+
+	rp := repl.NewRepl(ctx, stop, conf, client, httpLog, inFlight)
 	defer rp.Shutdown()
+
+	// CTRL+C interrupts the running REPL command, like .watch, and only
+	// exits when there is nothing to interrupt, so a second CTRL+C exits if
+	// the command doesn't stop.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-interrupts:
+				if !rp.Interrupt() {
+					stop()
+				}
+			}
+		}
+	}()
 	go func() {
 		if err := rp.Start(); err != nil {
 			fmt.Println(err)
@@ -40,3 +131,102 @@ func Run(ctx context.Context) error {
 	fmt.Printf("\nGoodbye!\n\n")
 	return nil
 }
+
+// jsonError is the JSON representation of an error written with
+// --json-errors.
+type jsonError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// SQLiteCode is the SQLite result code of the SQL errors, if the server
+	// reported it.
+	SQLiteCode *int `json:"sqliteCode"`
+}
+
+// writeError writes the error to w as a line of text, or as a single line
+// JSON object if asJSON is true.
+func writeError(w io.Writer, exitErr *ExitError, asJSON bool) {
+	if !asJSON {
+		fmt.Fprintln(w, "Error:", exitErr.Err)
+		return
+	}
+
+	out := jsonError{Code: exitErr.Code, Message: exitErr.Err.Error()}
+	var sqlErr *repl.SQLError
+	if errors.As(exitErr, &sqlErr) {
+		if code, ok := sqlErr.SQLiteCode(); ok {
+			out.SQLiteCode = &code
+		}
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		fmt.Fprintln(w, "Error:", exitErr.Err)
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
+
+// runNonInteractive executes the statements given with --execute, or read
+// from stdin, and writes the results to stdout using the selected format.
+func runNonInteractive(
+	ctx context.Context, conf config.Config, client *nsqlitehttp.Client, stdin io.Reader, stdout io.Writer,
+) error {
+	if err := client.IsHealthy(ctx); err != nil {
+		return &ExitError{
+			Code: ExitCodeConnectionError,
+			Err:  fmt.Errorf("failed to connect to %s: %w", conf.ParsedConnStr.String(), err),
+		}
+	}
+
+	script := conf.Execute
+	if script == "" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		script = string(data)
+	}
+
+	if err := repl.Exec(ctx, client, script, conf.Format, stdout); err != nil {
+		code := ExitCodeConnectionError
+		var sqlErr *repl.SQLError
+		if errors.As(err, &sqlErr) {
+			code = ExitCodeSQLError
+		}
+		return &ExitError{Code: code, Err: err}
+	}
+
+	return nil
+}
+
+// newHTTPLog creates the transport used to send the requests to the server,
+// which logs them to stderr while the verbose mode is enabled.
+func newHTTPLog(conf config.Config) *httplog.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 100
+
+	return httplog.NewTransport(transport, os.Stderr, conf.Verbose)
+}
+
+// newClient creates the NSQLite client that sends the requests with
+// transport, with the configured request timeout and retrying the requests
+// that failed because of a network error if enabled.
+func newClient(conf config.Config, transport http.RoundTripper) (*nsqlitehttp.Client, error) {
+	httpClient := &http.Client{
+		Timeout: conf.RequestTimeout,
+		Transport: retry.NewTransport(transport, retry.Options{
+			Retries: conf.Retries,
+			Backoff: conf.RetryBackoff,
+			OnVersionChange: func(previous string, current string) {
+				styled.WarningColor().Fprintf(
+					os.Stderr,
+					"\nWarning: the server was restarted with NSQLite %s, it was running %s when connected\n",
+					current, previous,
+				)
+			},
+		}),
+	}
+
+	return nsqlitehttp.NewClient(conf.ConnectionString, nsqlitehttp.WithHTTPClient(httpClient))
+}
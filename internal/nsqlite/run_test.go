@@ -0,0 +1,246 @@
+package nsqlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/server"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer starts an NSQLite server backed by a database in a
+// temporary directory and returns its URL.
+func newTestServer(t *testing.T) string {
+	t.Helper()
+
+	logger := log.NewLogger(io.Discard)
+	dbStats := stats.NewDBStats()
+	t.Cleanup(dbStats.Close)
+
+	dbInstance, err := db.NewDB(db.Config{
+		Logger:        logger,
+		DBStats:       dbStats,
+		DataDirectory: t.TempDir(),
+		TxIdleTimeout: 10 * time.Second,
+		BusyTimeout:   5 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbInstance.Close() })
+
+	s, err := server.NewServer(server.Config{
+		Logger:  logger,
+		DBStats: dbStats,
+		DB:      dbInstance,
+	})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+
+	return ts.URL
+}
+
+func TestRunNonInteractive(t *testing.T) {
+	url := newTestServer(t)
+
+	run := func(stdin string, args ...string) (string, error) {
+		stdout := bytes.Buffer{}
+		args = append([]string{"nsqlite", url}, args...)
+		err := Run(context.Background(), args, strings.NewReader(stdin), &stdout, io.Discard)
+		return stdout.String(), err
+	}
+
+	t.Run("Execute flag", func(t *testing.T) {
+		out, err := run("", "-e", "SELECT 1 AS one", "--format", "json")
+		require.NoError(t, err)
+		assert.Equal(t, "[\n  {\"one\":1}\n]\n", out)
+	})
+
+	t.Run("Script from stdin", func(t *testing.T) {
+		script := strings.Join([]string{
+			"CREATE TABLE users (id INTEGER, name TEXT);",
+			"INSERT INTO users VALUES (1, 'a;b'), (2, NULL);",
+			"SELECT id, name FROM users ORDER BY id;",
+		}, "\n")
+
+		out, err := run(script, "--format", "csv")
+		require.NoError(t, err)
+		assert.Equal(t, "id,name\n1,a;b\n2,\n", out)
+	})
+
+	t.Run("Transaction in script", func(t *testing.T) {
+		script := "BEGIN; INSERT INTO users VALUES (3, 'c'); COMMIT; SELECT COUNT(*) AS n FROM users;"
+		out, err := run(script, "--format", "line")
+		require.NoError(t, err)
+		assert.Equal(t, "n: 3\n", out)
+	})
+
+	t.Run("SQL error", func(t *testing.T) {
+		_, err := run("SELECT 1;\nSELECT * FROM missing;")
+
+		var exitErr *ExitError
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, ExitCodeSQLError, exitErr.Code)
+		assert.Contains(t, exitErr.Error(), "statement 2 at line 2")
+		assert.Contains(t, exitErr.Error(), "no such table")
+	})
+
+	t.Run("Connection error", func(t *testing.T) {
+		closed := httptest.NewServer(nil)
+		closed.Close()
+
+		stdout := bytes.Buffer{}
+		args := []string{"nsqlite", closed.URL, "-e", "SELECT 1"}
+		err := Run(context.Background(), args, strings.NewReader(""), &stdout, io.Discard)
+
+		var exitErr *ExitError
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, ExitCodeConnectionError, exitErr.Code)
+		assert.Empty(t, stdout.String())
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+	url := newTestServer(t)
+
+	closed := httptest.NewServer(nil)
+	closed.Close()
+
+	unauthorized := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}))
+	defer unauthorized.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStderr string
+		// wantJSON is the expected error with --json-errors, without the
+		// message.
+		wantJSON map[string]any
+	}{
+		{
+			name:       "SQL error",
+			args:       []string{url, "-e", "SELECT * FROM missing"},
+			wantCode:   ExitCodeSQLError,
+			wantStderr: "Error: statement 1 at line 1: ",
+			wantJSON:   map[string]any{"code": float64(1), "sqliteCode": float64(1)},
+		},
+		{
+			name:       "SQL error from stdin",
+			args:       []string{url},
+			stdin:      "SELECT 1;\nINSERT INTO missing VALUES (1);",
+			wantCode:   ExitCodeSQLError,
+			wantStderr: "Error: statement 2 at line 2: ",
+			wantJSON:   map[string]any{"code": float64(1), "sqliteCode": float64(1)},
+		},
+		{
+			name:       "Connection error",
+			args:       []string{closed.URL, "-e", "SELECT 1"},
+			wantCode:   ExitCodeConnectionError,
+			wantStderr: "Error: failed to connect to ",
+			wantJSON:   map[string]any{"code": float64(2), "sqliteCode": nil},
+		},
+		{
+			name:       "Authentication error",
+			args:       []string{unauthorized.URL, "-e", "SELECT 1"},
+			wantCode:   ExitCodeConnectionError,
+			wantStderr: "Error: failed to connect to ",
+			wantJSON:   map[string]any{"code": float64(2), "sqliteCode": nil},
+		},
+		{
+			name:       "Invalid flag value",
+			args:       []string{url, "-e", "SELECT 1", "--format", "xml"},
+			wantCode:   ExitCodeUsageError,
+			wantStderr: "Error: invalid format, valid values are: ",
+			wantJSON:   map[string]any{"code": float64(3), "sqliteCode": nil},
+		},
+		{
+			name:       "Unknown flag",
+			args:       []string{url, "--colour"},
+			wantCode:   ExitCodeUsageError,
+			wantStderr: "Error: unknown argument --colour, run with --help for usage",
+			wantJSON:   map[string]any{"code": float64(3), "sqliteCode": nil},
+		},
+	}
+
+	run := func(stdin string, args []string) (string, string, error) {
+		stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+		args = append([]string{"nsqlite"}, args...)
+		err := Run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String(), stderr.String(), err
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, err := run(tt.stdin, tt.args)
+
+			var exitErr *ExitError
+			require.True(t, errors.As(err, &exitErr))
+			assert.Equal(t, tt.wantCode, exitErr.Code)
+			assert.True(t, strings.HasPrefix(stderr, tt.wantStderr), stderr)
+			assert.Equal(t, 1, strings.Count(stderr, "\n"))
+			assert.NotContains(t, stdout, "Error")
+		})
+
+		t.Run(tt.name+" as JSON", func(t *testing.T) {
+			// The flag goes first so it is parsed even if a later argument
+			// is invalid.
+			_, stderr, err := run(tt.stdin, append([]string{"--json-errors"}, tt.args...))
+			require.Error(t, err)
+
+			assert.Equal(t, 1, strings.Count(stderr, "\n"), "a single line")
+			got := map[string]any{}
+			require.NoError(t, json.Unmarshal([]byte(stderr), &got))
+			assert.NotEmpty(t, got["message"])
+			delete(got, "message")
+			assert.Equal(t, tt.wantJSON, got)
+		})
+	}
+
+	t.Run("Help", func(t *testing.T) {
+		stdout, stderr, err := run("", []string{"--help"})
+		require.NoError(t, err)
+		assert.Empty(t, stderr)
+		assert.Contains(t, stdout, "Exit codes:\n  0  ")
+		assert.Contains(t, stdout, "  3  invalid command line arguments")
+	})
+}
+
+func TestNewClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	client, err := newClient(config.Config{
+		ConnectionString: ts.URL,
+		RequestTimeout:   50 * time.Millisecond,
+		RetryBackoff:     time.Millisecond,
+	}, http.DefaultTransport)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.SendQuery(context.Background(), nsqlitehttp.Query{Query: "SELECT 1"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second, "the request is aborted by the timeout")
+}
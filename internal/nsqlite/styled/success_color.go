@@ -0,0 +1,8 @@
+package styled
+
+import "github.com/fatih/color"
+
+// SuccessColor returns a green *color.Color to print positive outcomes.
+func SuccessColor() *color.Color {
+	return color.New(color.FgGreen)
+}
@@ -0,0 +1,13 @@
+package styled
+
+import "github.com/fatih/color"
+
+// WarningColor returns a yellow *color.Color to print warnings.
+func WarningColor() *color.Color {
+	return color.New(color.FgYellow)
+}
+
+// DangerColor returns a red *color.Color to print critical warnings.
+func DangerColor() *color.Color {
+	return color.New(color.FgRed)
+}
@@ -0,0 +1,209 @@
+package nsqlitebench
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/httpbench"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+)
+
+type benchmarkBulkConfig struct {
+	// rows is the number of users inserted with each strategy.
+	rows int
+	// rowsPerStatement is the number of rows of each multi-row VALUES
+	// statement.
+	rowsPerStatement int
+	// batchRows is the number of rows sent in each request of the batch
+	// API, zero to skip it, as only the nsqlite driver has it.
+	batchRows int
+	// dsn is the connection string of the NSQLite server of the batch API.
+	dsn string
+}
+
+// bulkInsertRow is the statement inserting a single user.
+const bulkInsertRow = "INSERT INTO users (created, email, active) VALUES (?, ?, ?)"
+
+// bulkStrategy is a strategy of the bulk benchmark, insert inserts the rows
+// and returns the result without its name.
+type bulkStrategy struct {
+	name   string
+	insert func(*sql.DB, benchmarkBulkConfig, [][]any) (benchmarkResult, error)
+}
+
+// runBenchmarkBulk inserts N users from a single goroutine with each bulk
+// loading strategy: a prepared statement inserting one row at a time, the
+// baseline, multi-row VALUES statements, and for the nsqlite driver the
+// batch API of the server. The schema is recreated between strategies.
+//
+// It returns a result for each strategy, the rows/sec are their writes/sec
+// and their speedup is over the baseline.
+func runBenchmarkBulk(
+	db *sql.DB, fullConfig benchmarksConfig,
+) ([]benchmarkResult, error) {
+	conf := fullConfig.benchmarkBulkConfig
+	users := newDataGenerator(fullConfig.seed, "bulk users")
+	rows := make([][]any, conf.rows)
+	for idx := range rows {
+		user := users.user(idx)
+		rows[idx] = []any{user.created, user.email, user.active}
+	}
+
+	strategies := []bulkStrategy{
+		{name: "Bulk (single-row)", insert: runBulkSingleRow},
+		{name: "Bulk (multi-row VALUES)", insert: runBulkMultiRow},
+	}
+	if conf.batchRows > 0 && conf.dsn != "" {
+		strategies = append(strategies, bulkStrategy{name: "Bulk (batch API)", insert: runBulkBatchAPI})
+	}
+
+	var results []benchmarkResult
+	for i, strategy := range strategies {
+		if i > 0 {
+			if err := recreateSchema(db, fullConfig.schema); err != nil {
+				return nil, err
+			}
+		}
+
+		start := resources.Take()
+		result, err := strategy.insert(db, conf, rows)
+		if err != nil {
+			return nil, fmt.Errorf("error with %s: %w", strategy.name, err)
+		}
+		result.Name = strategy.name
+		result.Resources = resources.Take().Since(start)
+		if i > 0 && result.Duration > 0 {
+			result.Speedup = float64(results[0].Duration) / float64(result.Duration)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// runBulkSingleRow inserts the rows one at a time with a prepared
+// statement.
+func runBulkSingleRow(db *sql.DB, conf benchmarkBulkConfig, rows [][]any) (benchmarkResult, error) {
+	start := time.Now()
+	latency := histogram.New()
+	var totalWrites uint64
+
+	stmt, err := db.Prepare(bulkInsertRow)
+	if err != nil {
+		return benchmarkResult{}, err
+	}
+	defer stmt.Close()
+
+	bar := NewBar(fmt.Sprintf("Inserting %d users one at a time", len(rows)), len(rows))
+	for _, row := range rows {
+		opStart := time.Now()
+		res, err := stmt.Exec(row...)
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when inserting: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when inserting: %w", err)
+		}
+		latency.Record(time.Since(opStart))
+
+		bar.Inc()
+		totalWrites += uint64(affected)
+	}
+	bar.Finish()
+
+	return benchmarkResult{
+		Duration:    time.Since(start),
+		TotalWrites: totalWrites,
+		Config:      map[string]int{"rows": conf.rows},
+		Latency:     latency,
+	}, nil
+}
+
+// runBulkMultiRow inserts the rows with statements of rowsPerStatement rows,
+// the last one with the remaining rows.
+func runBulkMultiRow(db *sql.DB, conf benchmarkBulkConfig, rows [][]any) (benchmarkResult, error) {
+	width := max(conf.rowsPerStatement, 1)
+	start := time.Now()
+	latency := histogram.New()
+	var totalWrites uint64
+
+	stmt, err := db.Prepare(multiRowInsert(width))
+	if err != nil {
+		return benchmarkResult{}, err
+	}
+	defer stmt.Close()
+
+	statements := (len(rows) + width - 1) / width
+	bar := NewBar(
+		fmt.Sprintf("Inserting %d users with %d rows per statement", len(rows), width), statements,
+	)
+	for first := 0; first < len(rows); first += width {
+		batch := rows[first:min(first+width, len(rows))]
+		args := make([]any, 0, len(batch)*3)
+		for _, row := range batch {
+			args = append(args, row...)
+		}
+
+		opStart := time.Now()
+		var res sql.Result
+		if len(batch) == width {
+			res, err = stmt.Exec(args...)
+		} else {
+			res, err = db.Exec(multiRowInsert(len(batch)), args...)
+		}
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when inserting: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when inserting: %w", err)
+		}
+		latency.Record(time.Since(opStart))
+
+		bar.Inc()
+		totalWrites += uint64(affected)
+	}
+	bar.Finish()
+
+	return benchmarkResult{
+		Duration:    time.Since(start),
+		TotalWrites: totalWrites,
+		Config:      map[string]int{"rows": conf.rows, "rowsPerStatement": width},
+		Latency:     latency,
+	}, nil
+}
+
+// multiRowInsert returns a statement inserting the given number of users
+// with a multi-row VALUES.
+func multiRowInsert(rows int) string {
+	values := strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", max(rows, 1)), ", ")
+	return "INSERT INTO users (created, email, active) VALUES " + values
+}
+
+// runBulkBatchAPI inserts the rows by sending batchRows single-row inserts
+// in each request to the NSQLite server, in a transaction, bypassing
+// database/sql.
+func runBulkBatchAPI(_ *sql.DB, conf benchmarkBulkConfig, rows [][]any) (benchmarkResult, error) {
+	start := time.Now()
+	requests := (len(rows) + conf.batchRows - 1) / conf.batchRows
+	bar := NewBar(
+		fmt.Sprintf("Inserting %d users with %d rows per request", len(rows), conf.batchRows), requests,
+	)
+
+	result, err := httpbench.Insert(context.Background(), conf.dsn, bulkInsertRow, rows, conf.batchRows, bar)
+	if err != nil {
+		return benchmarkResult{}, err
+	}
+
+	return benchmarkResult{
+		Duration:    time.Since(start),
+		TotalWrites: uint64(result.RowsAffected),
+		Config:      map[string]int{"rows": conf.rows, "batchRows": conf.batchRows, "requests": result.Requests},
+		Latency:     result.Latency,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package nsqlitebench
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBenchmarkBulk(t *testing.T) {
+	for _, local := range localDrivers {
+		t.Run(local.name, func(t *testing.T) {
+			db, err := local.create(filepath.Join(t.TempDir(), "bench.sqlite"))
+			require.NoError(t, err)
+			defer db.Close()
+			require.NoError(t, recreateSchema(db, config.SchemaIndexed))
+
+			// The rows are not a multiple of the rows per statement, so the
+			// last statement is shorter.
+			cfg := benchmarksConfig{seed: 1, schema: config.SchemaIndexed, benchmarkBulkConfig: benchmarkBulkConfig{
+				rows:             250,
+				rowsPerStatement: 40,
+			}}
+
+			results, err := runBenchmarkBulk(db, cfg)
+			require.NoError(t, err)
+			require.Len(t, results, 2, "the batch API is only benchmarked for nsqlite")
+
+			assert.Equal(t, "Bulk (single-row)", results[0].Name)
+			assert.Equal(t, "Bulk (multi-row VALUES)", results[1].Name)
+			for _, result := range results {
+				assert.Equal(t, uint64(250), result.TotalWrites, result.Name)
+				assert.Positive(t, result.Resources.Allocs, result.Name)
+			}
+			assert.Equal(t, uint64(250), results[0].Latency.Count())
+			assert.Equal(t, uint64(7), results[1].Latency.Count())
+			assert.Zero(t, results[0].Speedup)
+			assert.Positive(t, results[1].Speedup)
+
+			var users, distinct int
+			require.NoError(t, db.QueryRow("SELECT COUNT(*), COUNT(DISTINCT email) FROM users").Scan(&users, &distinct))
+			assert.Equal(t, 250, users, "the schema is recreated between strategies")
+			assert.Equal(t, 250, distinct)
+		})
+	}
+}
+
+func TestMultiRowInsert(t *testing.T) {
+	assert.Equal(t, "INSERT INTO users (created, email, active) VALUES (?, ?, ?)", multiRowInsert(1))
+	assert.Equal(
+		t, "INSERT INTO users (created, email, active) VALUES (?, ?, ?), (?, ?, ?), (?, ?, ?)", multiRowInsert(3),
+	)
+}
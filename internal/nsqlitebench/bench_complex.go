@@ -6,6 +6,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
 )
 
 type benchmarkComplexConfig struct {
@@ -24,8 +26,12 @@ func runBenchmarkComplex(
 	db *sql.DB, fullConfig benchmarksConfig,
 ) (benchmarkResult, error) {
 	conf := fullConfig.benchmarkComplexConfig
+	users := newDataGenerator(fullConfig.seed, "complex users")
+	articles := newDataGenerator(fullConfig.seed, "complex articles")
+	comments := newDataGenerator(fullConfig.seed, "complex comments")
 	start := time.Now()
 	var totalReads, totalWrites uint64
+	latency := histogram.New()
 
 	wgU := sync.WaitGroup{}
 	chU := make(chan bool, conf.insertGoroutines)
@@ -42,9 +48,11 @@ func runBenchmarkComplex(
 				wgU.Done()
 				<-chU
 			}()
+			user := users.user(idx)
+			opStart := time.Now()
 			res, err := db.Exec(
 				"INSERT INTO users (created, email, active) VALUES (?, ?, ?)",
-				time.Now().Unix(), fmt.Sprintf("user%d@example.com", idx), 1,
+				user.created, user.email, user.active,
 			)
 			if err != nil {
 				errU <- err
@@ -55,6 +63,7 @@ func runBenchmarkComplex(
 				errU <- err
 				return
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 			atomic.AddUint64(&totalWrites, uint64(affected))
@@ -88,10 +97,11 @@ func runBenchmarkComplex(
 				wgA.Done()
 				<-chA
 			}()
-			userID := (idx % conf.insertXUsers) + 1
+			userID := articles.id(idx, conf.insertXUsers)
+			opStart := time.Now()
 			res, err := db.Exec(
 				"INSERT INTO articles (created, userId, text) VALUES (?, ?, ?)",
-				time.Now().Unix(), userID, fmt.Sprintf("article for user %d", userID),
+				articles.created(idx), userID, articles.text(idx, 20, 200),
 			)
 			if err != nil {
 				errA <- err
@@ -102,6 +112,7 @@ func runBenchmarkComplex(
 				errA <- err
 				return
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 			atomic.AddUint64(&totalWrites, uint64(affected))
@@ -135,10 +146,11 @@ func runBenchmarkComplex(
 				wgC.Done()
 				<-chC
 			}()
-			articleID := (idx % totalArticles) + 1
+			articleID := comments.id(idx, totalArticles)
+			opStart := time.Now()
 			res, err := db.Exec(
 				"INSERT INTO comments (created, articleId, text) VALUES (?, ?, ?)",
-				time.Now().Unix(), articleID, "comment",
+				comments.created(idx), articleID, comments.text(idx, 10, 100),
 			)
 			if err != nil {
 				errC <- err
@@ -149,6 +161,7 @@ func runBenchmarkComplex(
 				errC <- err
 				return
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 			atomic.AddUint64(&totalWrites, uint64(affected))
@@ -167,6 +180,7 @@ func runBenchmarkComplex(
 	bar.Finish()
 
 	bar = NewBar("Reading users, articles, and comments", 1)
+	opStart := time.Now()
 	rows, err := db.Query(`
 		SELECT
 		users.id, users.created, users.email, users.active,
@@ -200,6 +214,7 @@ func runBenchmarkComplex(
 
 		atomic.AddUint64(&totalReads, 1)
 	}
+	latency.Record(time.Since(opStart))
 
 	bar.Finish()
 	return benchmarkResult{
@@ -207,5 +222,7 @@ func runBenchmarkComplex(
 		Duration:    time.Since(start),
 		TotalReads:  totalReads,
 		TotalWrites: totalWrites,
+		Config:      conf.report(),
+		Latency:     latency,
 	}, nil
 }
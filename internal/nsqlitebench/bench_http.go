@@ -0,0 +1,110 @@
+package nsqlitebench
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/httpbench"
+)
+
+type benchmarkHTTPConfig struct {
+	statements int
+	batchSizes []int
+	inFlight   int
+}
+
+// HTTPBatch is the throughput of a batch size of the HTTP benchmark.
+type HTTPBatch struct {
+	BatchSize int `json:"batchSize"`
+	// KeepAlive reports whether the requests reused the connections, or
+	// each one opened a new connection.
+	KeepAlive        bool    `json:"keepAlive"`
+	StatementsPerSec float64 `json:"statementsPerSec"`
+}
+
+// runBenchmarkHTTP sends statements to the NSQLite server with the given
+// connection string directly over HTTP, bypassing database/sql, in batches
+// of each batch size with and without keep-alive. It returns the results
+// and the throughput of each batch size.
+func runBenchmarkHTTP(
+	ctx context.Context, db *sql.DB, dsn string, cfg benchmarksConfig, opts runOptions,
+) ([]benchmarkResult, []HTTPBatch, error) {
+	conf := cfg.benchmarkHTTPConfig
+	bench := func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error) {
+		httpResults, err := httpbench.Run(ctx, dsn, httpbench.Config{
+			Statements: conf.statements,
+			BatchSizes: conf.batchSizes,
+			InFlight:   conf.inFlight,
+			NewBar: func(description string, requests int) httpbench.Bar {
+				return NewBar(description, requests)
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		results := make([]benchmarkResult, len(httpResults))
+		for i, result := range httpResults {
+			results[i] = benchmarkResult{
+				Name:       "HTTP " + httpbench.Name(result.BatchSize, result.KeepAlive),
+				Duration:   result.Duration,
+				TotalReads: uint64(result.Statements),
+				Config: map[string]int{
+					"statements": result.Statements,
+					"batchSize":  result.BatchSize,
+					"requests":   result.Requests,
+					"inFlight":   conf.inFlight,
+				},
+				Latency:   result.Latency,
+				Resources: result.Resources,
+			}
+		}
+		return results, nil
+	}
+
+	results, err := repeatBenchmark(db, cfg, bench, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The results are in the order of httpbench.Run, every batch size with
+	// keep-alive and then without it.
+	batches := make([]HTTPBatch, len(results))
+	for i, result := range results {
+		batches[i] = HTTPBatch{
+			BatchSize: conf.batchSizes[i%len(conf.batchSizes)],
+			KeepAlive: i < len(conf.batchSizes),
+		}
+		if seconds := result.Duration.Seconds(); seconds > 0 {
+			batches[i].StatementsPerSec = float64(result.TotalReads) / seconds
+		}
+	}
+	return results, batches, nil
+}
+
+// renderHTTPBatches returns a table with the statements per second of each
+// batch size of the HTTP benchmark, with and without keep-alive.
+func renderHTTPBatches(batches []HTTPBatch) string {
+	statementsPerSec := map[bool]map[int]float64{true: {}, false: {}}
+	batchSizes := []int{}
+	for _, batch := range batches {
+		if _, ok := statementsPerSec[true][batch.BatchSize]; !ok && batch.KeepAlive {
+			batchSizes = append(batchSizes, batch.BatchSize)
+		}
+		statementsPerSec[batch.KeepAlive][batch.BatchSize] = batch.StatementsPerSec
+	}
+
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Batch size", "Keep-alive", "New connections"})
+	for _, batchSize := range batchSizes {
+		tw.AppendRow(table.Row{
+			batchSize,
+			fmt.Sprintf("%.0f", statementsPerSec[true][batchSize]),
+			fmt.Sprintf("%.0f", statementsPerSec[false][batchSize]),
+		})
+	}
+	return tw.Render()
+}
@@ -0,0 +1,76 @@
+package nsqlitebench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBenchmarkHTTP(t *testing.T) {
+	// The server answers every statement without running it, the benchmark
+	// against a real server is tested in httpbench.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var queries []json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&queries)
+		results := strings.Repeat(`{"rows":[[1]]},`, len(queries))
+		fmt.Fprintf(w, `{"results":[%s]}`, strings.TrimSuffix(results, ","))
+	}))
+	defer ts.Close()
+
+	db, err := localDrivers[0].create(filepath.Join(t.TempDir(), "bench.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	cfg := benchmarksConfig{schema: config.SchemaIndexed, benchmarkHTTPConfig: benchmarkHTTPConfig{
+		statements: 100,
+		batchSizes: []int{1, 10},
+		inFlight:   4,
+	}}
+
+	results, batches, err := runBenchmarkHTTP(context.Background(), db, ts.URL, cfg, runOptions{repeat: 2})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, result := range results {
+		names = append(names, result.Name)
+		assert.Equal(t, uint64(100), result.TotalReads)
+		assert.Equal(t, 2, result.Runs)
+	}
+	assert.Equal(t, []string{
+		"HTTP batches of 1 (keep-alive)", "HTTP batches of 10 (keep-alive)",
+		"HTTP batches of 1 (new connections)", "HTTP batches of 10 (new connections)",
+	}, names)
+	assert.Equal(t, 10, results[1].Config["requests"])
+
+	require.Len(t, batches, 4)
+	for i, batch := range batches {
+		assert.Equal(t, cfg.batchSizes[i%2], batch.BatchSize)
+		assert.Equal(t, i < 2, batch.KeepAlive)
+		assert.Positive(t, batch.StatementsPerSec)
+	}
+}
+
+func TestRenderHTTPBatches(t *testing.T) {
+	out := renderHTTPBatches([]HTTPBatch{
+		{BatchSize: 1, KeepAlive: true, StatementsPerSec: 1000},
+		{BatchSize: 100, KeepAlive: true, StatementsPerSec: 50000},
+		{BatchSize: 1, StatementsPerSec: 400},
+		{BatchSize: 100, StatementsPerSec: 30000},
+	})
+
+	lines := strings.Split(out, "\n")
+	require.Len(t, lines, 6)
+	assert.Contains(t, lines[1], "Keep-alive")
+	assert.Contains(t, lines[1], "New connections")
+	assert.Regexp(t, `│\s+1 │ 1000\s+│ 400\s+│`, lines[3])
+	assert.Regexp(t, `│\s+100 │ 50000\s+│ 30000\s+│`, lines[4])
+}
@@ -3,37 +3,80 @@ package nsqlitebench
 import (
 	"database/sql"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
 )
 
 type benchmarkLargeConfig struct {
-	insertXUsers     int
-	insertYBytes     int
+	// insertXUsers is the maximum number of users inserted for each size.
+	insertXUsers int
+	// maxBytes is the maximum number of bytes inserted for each size, it
+	// limits the users inserted for the largest sizes.
+	maxBytes         int
+	payloadSizes     []int
 	insertGoroutines int
 }
 
-// runBenchmarkLarge inserts X users with Y Bytes of content and then queries
-// all of them in single query.
+// users returns the number of users inserted with payloads of the given
+// size.
+func (c benchmarkLargeConfig) users(size int) int {
+	return max(min(c.insertXUsers, c.maxBytes/size), 1)
+}
+
+// runBenchmarkLarge runs the large benchmark for each payload size, with
+// the schema recreated between sizes. It returns one result for each size.
 func runBenchmarkLarge(
 	db *sql.DB, fullConfig benchmarksConfig,
-) (benchmarkResult, error) {
+) ([]benchmarkResult, error) {
 	conf := fullConfig.benchmarkLargeConfig
+	var results []benchmarkResult
+
+	for i, size := range conf.payloadSizes {
+		if i > 0 {
+			if err := recreateSchema(db, fullConfig.schema); err != nil {
+				return nil, err
+			}
+		}
+
+		start := resources.Take()
+		result, err := runBenchmarkLargeSize(db, conf, size, newDataGenerator(fullConfig.seed, "large users"))
+		if err != nil {
+			return nil, fmt.Errorf("error with %s payloads: %w", numutil.Bytes(int64(size)), err)
+		}
+		result.Resources = resources.Take().Since(start)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// runBenchmarkLargeSize inserts X users with Y Bytes of content and then
+// queries all of them in single query, measuring the MB/s of both phases.
+func runBenchmarkLargeSize(
+	db *sql.DB, conf benchmarkLargeConfig, size int, users dataGenerator,
+) (benchmarkResult, error) {
+	usersCount := conf.users(size)
 	start := time.Now()
 	var totalReads uint64 = 0
 	var totalWrites uint64 = 0
+	latency := histogram.New()
 
 	wg := sync.WaitGroup{}
 	wgch := make(chan bool, conf.insertGoroutines)
-	errChan := make(chan error, conf.insertXUsers)
+	errChan := make(chan error, usersCount)
 	bar := NewBar(
-		fmt.Sprintf("Inserting %d users", conf.insertXUsers), conf.insertXUsers,
+		fmt.Sprintf("Inserting %d users with %s", usersCount, numutil.Bytes(int64(size))), usersCount,
 	)
 
-	email := strings.Repeat("Y", conf.insertYBytes)
-	for range conf.insertXUsers {
+	filler := strings.Repeat("Y", size)
+	for idx := range usersCount {
 		wg.Add(1)
 		wgch <- true
 
@@ -43,9 +86,11 @@ func runBenchmarkLarge(
 				<-wgch
 			}()
 
+			user := users.user(idx)
+			opStart := time.Now()
 			res, err := db.Exec(
 				"INSERT INTO users (created, email, active) VALUES (?, ?, ?)",
-				time.Now().Unix(), email, 1,
+				user.created, largePayload(filler, idx), user.active,
 			)
 			if err != nil {
 				errChan <- err
@@ -57,6 +102,7 @@ func runBenchmarkLarge(
 				errChan <- err
 				return
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 			atomic.AddUint64(&totalWrites, uint64(rowsAffected))
@@ -72,9 +118,11 @@ func runBenchmarkLarge(
 			return benchmarkResult{}, fmt.Errorf("error when inserting: %w", e)
 		}
 	}
+	insertDuration := time.Since(start)
 	bar.Finish()
 
 	bar = NewBar("Reading all users", 1)
+	opStart := time.Now()
 	rows, err := db.Query(
 		"SELECT id, created, email, active FROM users ORDER BY id",
 	)
@@ -92,12 +140,38 @@ func runBenchmarkLarge(
 
 		atomic.AddUint64(&totalReads, 1)
 	}
+	readDuration := time.Since(opStart)
+	latency.Record(readDuration)
 
 	bar.Finish()
 	return benchmarkResult{
-		Name:        "Simple",
-		Duration:    time.Since(start),
-		TotalReads:  totalReads,
-		TotalWrites: totalWrites,
+		Name:           "Large " + numutil.Bytes(int64(size)),
+		Duration:       time.Since(start),
+		TotalReads:     totalReads,
+		TotalWrites:    totalWrites,
+		Config:         conf.report(usersCount, size),
+		Latency:        latency,
+		InsertMBPerSec: mbPerSec(totalWrites*uint64(size), insertDuration),
+		ReadMBPerSec:   mbPerSec(totalReads*uint64(size), readDuration),
 	}, nil
 }
+
+// mbPerSec returns the MiB per second of transferring the given bytes in
+// duration.
+func mbPerSec(bytes uint64, duration time.Duration) float64 {
+	if duration <= 0 {
+		return 0
+	}
+	return float64(bytes) / (1 << 20) / duration.Seconds()
+}
+
+// largePayload returns the payload of the user of the index, the filler
+// prefixed with the index so the payloads are unique, like the emails of
+// the unique index, and with the size of the filler.
+func largePayload(filler string, idx int) string {
+	prefix := strconv.Itoa(idx) + "@"
+	if len(prefix) >= len(filler) {
+		return prefix
+	}
+	return prefix + filler[len(prefix):]
+}
@@ -0,0 +1,52 @@
+package nsqlitebench
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBenchmarkLarge(t *testing.T) {
+	db, err := localDrivers[0].create(filepath.Join(t.TempDir(), "bench.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, recreateSchema(db, config.SchemaIndexed))
+
+	cfg := benchmarksConfig{schema: config.SchemaIndexed, benchmarkLargeConfig: benchmarkLargeConfig{
+		insertXUsers:     50,
+		maxBytes:         10_000,
+		payloadSizes:     []int{10, 1 << 10},
+		insertGoroutines: 4,
+	}}
+
+	results, err := runBenchmarkLarge(db, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "Large 10 B", results[0].Name)
+	assert.Equal(t, uint64(50), results[0].TotalWrites)
+	assert.Equal(t, uint64(50), results[0].TotalReads)
+	assert.Equal(t, map[string]int{"insertUsers": 50, "insertBytes": 10, "insertGoroutines": 4}, results[0].Config)
+
+	assert.Equal(t, "Large 1.0 KiB", results[1].Name)
+	assert.Equal(t, uint64(9), results[1].TotalWrites, "limited by maxBytes")
+	assert.Equal(t, uint64(9), results[1].TotalReads, "the schema is recreated between sizes")
+
+	for _, result := range results {
+		assert.Positive(t, result.InsertMBPerSec, result.Name)
+		assert.Positive(t, result.ReadMBPerSec, result.Name)
+	}
+}
+
+func TestLargePayload(t *testing.T) {
+	filler := strings.Repeat("Y", 10)
+	assert.Equal(t, "0@YYYYYYYY", largePayload(filler, 0))
+	assert.Equal(t, "1234@YYYYY", largePayload(filler, 1234))
+	assert.Len(t, largePayload(filler, 99), 10)
+	assert.Equal(t, "123456789@", largePayload(filler, 123456789), "the index is kept when it is longer")
+	assert.Equal(t, "12345678901@", largePayload(filler, 12345678901))
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
 )
 
 type benchmarkManyConfig struct {
@@ -21,8 +23,10 @@ func runBenchmarkMany(
 	db *sql.DB, fullConfig benchmarksConfig,
 ) (benchmarkResult, error) {
 	conf := fullConfig.benchmarkManyConfig
+	users := newDataGenerator(fullConfig.seed, "many users")
 	start := time.Now()
 	var totalReads, totalWrites uint64
+	latency := histogram.New()
 
 	tx, err := db.Begin()
 	if err != nil {
@@ -53,9 +57,9 @@ func runBenchmarkMany(
 				wgInsert.Done()
 				<-chInsert
 			}()
-			res, err := stmt.Exec(
-				time.Now().Unix(), fmt.Sprintf("user%d@example.com", idx), 1,
-			)
+			user := users.user(idx)
+			opStart := time.Now()
+			res, err := stmt.Exec(user.created, user.email, user.active)
 			if err != nil {
 				errInsert <- err
 				return
@@ -65,6 +69,7 @@ func runBenchmarkMany(
 				errInsert <- err
 				return
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 			atomic.AddUint64(&totalWrites, uint64(affected))
@@ -101,6 +106,7 @@ func runBenchmarkMany(
 				wgQuery.Done()
 				<-chQuery
 			}()
+			opStart := time.Now()
 			rows, err := db.Query(
 				"SELECT id, created, email, active FROM users ORDER BY id",
 			)
@@ -119,6 +125,7 @@ func runBenchmarkMany(
 				}
 				atomic.AddUint64(&totalReads, 1)
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 		}()
@@ -140,5 +147,7 @@ func runBenchmarkMany(
 		Duration:    time.Since(start),
 		TotalReads:  totalReads,
 		TotalWrites: totalWrites,
+		Config:      conf.report(),
+		Latency:     latency,
 	}, nil
 }
@@ -0,0 +1,313 @@
+package nsqlitebench
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+)
+
+type benchmarkMixedConfig struct {
+	seedUsers  int
+	goroutines int
+	rangeSize  int
+	duration   time.Duration
+	mix        config.Mix
+}
+
+// Operations of the mixed benchmark, the indexes of mixedOperationNames.
+const (
+	mixedPointRead = iota
+	mixedRangeRead
+	mixedInsert
+	mixedUpdate
+	mixedOperations
+)
+
+var mixedOperationNames = [mixedOperations]string{
+	"Point read", "Range read", "Insert", "Update",
+}
+
+// operationResult is the outcome of an operation type of a benchmark.
+type operationResult struct {
+	Name   string
+	Count  uint64
+	Errors uint64
+}
+
+// runBenchmarkMixed seeds X users and then runs G goroutines that perform
+// point reads, range reads, inserts and updates in the ratio of the mix
+// until the duration elapses. This simulates a real workload where reads
+// and writes are interleaved.
+//
+// Failed operations are counted instead of stopping the benchmark, and the
+// duration does not include the seeding.
+func runBenchmarkMixed(
+	db *sql.DB, fullConfig benchmarksConfig,
+) (benchmarkResult, error) {
+	conf := fullConfig.benchmarkMixedConfig
+	counters := newMixedCounters()
+
+	workload, err := newMixedWorkload(conf)
+	if err != nil {
+		return benchmarkResult{}, err
+	}
+	if err := seedUsers(db, conf.seedUsers, newDataGenerator(fullConfig.seed, "mixed users")); err != nil {
+		return benchmarkResult{}, fmt.Errorf("error seeding users: %w", err)
+	}
+
+	seconds := max(int(conf.duration/time.Second), 1)
+	bar := NewBar(
+		fmt.Sprintf("Running a mixed workload for %s with %d goroutines", conf.duration, conf.goroutines),
+		seconds,
+	)
+
+	start := time.Now()
+	running := make(chan struct{})
+	go func() {
+		defer close(running)
+		workload.run(
+			db, newDataGenerator(fullConfig.seed, "mixed workers"), start.Add(conf.duration), counters.record,
+		)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				bar.Inc()
+			}
+		}
+	}()
+
+	<-running
+	close(done)
+	duration := time.Since(start)
+	bar.Finish()
+
+	return counters.result("Mixed", duration, conf.report()), nil
+}
+
+// mixedWorkload are the operations of the mixed benchmark, picked with the
+// weights of the mix.
+type mixedWorkload struct {
+	conf        benchmarkMixedConfig
+	weights     [mixedOperations]int
+	totalWeight int
+}
+
+// newMixedWorkload returns the workload of the mix of conf, it fails if the
+// mix has no operations.
+func newMixedWorkload(conf benchmarkMixedConfig) (mixedWorkload, error) {
+	w := mixedWorkload{
+		conf: conf,
+		weights: [mixedOperations]int{
+			conf.mix.PointReads, conf.mix.RangeReads, conf.mix.Inserts, conf.mix.Updates,
+		},
+	}
+	for _, weight := range w.weights {
+		w.totalWeight += weight
+	}
+	if w.totalWeight == 0 {
+		return mixedWorkload{}, fmt.Errorf("the mix has no operations")
+	}
+	return w, nil
+}
+
+// run runs the goroutines of the workload on db until the deadline, with the
+// random values of workers, and calls record with the outcome of each
+// operation. It returns when every goroutine is done.
+func (w mixedWorkload) run(
+	db *sql.DB, workers dataGenerator, deadline time.Time,
+	record func(op int, latency time.Duration, reads uint64, writes uint64, err error),
+) {
+	wg := sync.WaitGroup{}
+	for g := range w.conf.goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := workers.rand(g)
+
+			for time.Now().Before(deadline) {
+				op := pickOperation(rng, w.weights, w.totalWeight)
+				userID := rng.IntN(max(w.conf.seedUsers, 1)) + 1
+
+				opStart := time.Now()
+				reads, writes, err := runMixedOperation(db, rng, op, userID, w.conf.rangeSize)
+				record(op, time.Since(opStart), reads, writes, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// mixedCounters are the outcomes of the operations of a mixed workload,
+// they are safe to record from several goroutines.
+type mixedCounters struct {
+	reads, writes atomic.Uint64
+	counts, errs  [mixedOperations]atomic.Uint64
+	// latency are the latencies of the operations without errors.
+	latency *histogram.Histogram
+}
+
+// newMixedCounters returns counters without operations.
+func newMixedCounters() *mixedCounters {
+	return &mixedCounters{latency: histogram.New()}
+}
+
+// record counts an operation, failed if err is not nil.
+func (c *mixedCounters) record(op int, latency time.Duration, reads uint64, writes uint64, err error) {
+	if err != nil {
+		c.errs[op].Add(1)
+		return
+	}
+	c.latency.Record(latency)
+	c.counts[op].Add(1)
+	c.reads.Add(reads)
+	c.writes.Add(writes)
+}
+
+// operations returns the operations without errors and the failed ones.
+func (c *mixedCounters) operations() (uint64, uint64) {
+	var ops, errs uint64
+	for op := range mixedOperations {
+		ops += c.counts[op].Load()
+		errs += c.errs[op].Load()
+	}
+	return ops, errs
+}
+
+// result returns the result of the counted operations.
+func (c *mixedCounters) result(name string, duration time.Duration, config map[string]int) benchmarkResult {
+	result := benchmarkResult{
+		Name:        name,
+		Duration:    duration,
+		TotalReads:  c.reads.Load(),
+		TotalWrites: c.writes.Load(),
+		Config:      config,
+		Latency:     c.latency,
+	}
+	for op, name := range mixedOperationNames {
+		result.Errors += c.errs[op].Load()
+		result.Operations = append(result.Operations, operationResult{
+			Name:   name,
+			Count:  c.counts[op].Load(),
+			Errors: c.errs[op].Load(),
+		})
+	}
+	return result
+}
+
+// pickOperation returns a random operation with a probability of its weight
+// divided by totalWeight.
+func pickOperation(rng *rand.Rand, weights [mixedOperations]int, totalWeight int) int {
+	n := rng.IntN(totalWeight)
+	for op, w := range weights {
+		if n < w {
+			return op
+		}
+		n -= w
+	}
+	return mixedOperations - 1
+}
+
+// runMixedOperation runs an operation of the mixed benchmark on the user
+// with the given ID, with the values of the writes taken from rng, and
+// returns the number of read and written rows.
+func runMixedOperation(
+	db *sql.DB, rng *rand.Rand, op int, userID int, rangeSize int,
+) (uint64, uint64, error) {
+	switch op {
+	case mixedPointRead:
+		reads, err := readUsers(db,
+			"SELECT id, created, email, active FROM users WHERE id = ?", userID,
+		)
+		return reads, 0, err
+	case mixedRangeRead:
+		reads, err := readUsers(db,
+			"SELECT id, created, email, active FROM users WHERE id >= ? ORDER BY id LIMIT ?",
+			userID, rangeSize,
+		)
+		return reads, 0, err
+	case mixedInsert:
+		res, err := db.Exec(
+			"INSERT INTO users (created, email, active) VALUES (?, ?, ?)",
+			randomCreated(rng), randomEmail(rng), rng.IntN(2),
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		affected, err := res.RowsAffected()
+		return 0, uint64(affected), err
+	default:
+		res, err := db.Exec(
+			"UPDATE users SET created = ?, active = 1 - active WHERE id = ?",
+			randomCreated(rng), userID,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		affected, err := res.RowsAffected()
+		return 0, uint64(affected), err
+	}
+}
+
+// readUsers runs a query of users and returns the number of read rows.
+func readUsers(db queryer, query string, args ...any) (uint64, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var reads uint64
+	for rows.Next() {
+		var id, created, active int
+		var email string
+		if err := rows.Scan(&id, &created, &email, &active); err != nil {
+			return reads, err
+		}
+		reads++
+	}
+	return reads, rows.Err()
+}
+
+// seedUsers inserts X users generated by gen in a single transaction.
+func seedUsers(db *sql.DB, users int, gen dataGenerator) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(
+		"INSERT INTO users (created, email, active) VALUES (?, ?, ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	bar := NewBar(fmt.Sprintf("Seeding %d users", users), users)
+	for idx := range users {
+		user := gen.user(idx)
+		_, err := stmt.Exec(user.created, user.email, user.active)
+		if err != nil {
+			return err
+		}
+		bar.Inc()
+	}
+	bar.Finish()
+
+	return tx.Commit()
+}
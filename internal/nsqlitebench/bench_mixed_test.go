@@ -0,0 +1,65 @@
+package nsqlitebench
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBenchmarkMixed(t *testing.T) {
+	db, err := localDrivers[0].create(filepath.Join(t.TempDir(), "bench.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, recreateSchema(db, config.SchemaIndexed))
+
+	cfg := benchmarksConfig{schema: config.SchemaIndexed, benchmarkMixedConfig: benchmarkMixedConfig{
+		seedUsers:  100,
+		goroutines: 4,
+		rangeSize:  10,
+		duration:   200 * time.Millisecond,
+		mix:        config.Mix{PointReads: 1, RangeReads: 1, Inserts: 1, Updates: 1},
+	}}
+
+	result, err := runBenchmarkMixed(db, cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Mixed", result.Name)
+	assert.GreaterOrEqual(t, result.Duration, 200*time.Millisecond)
+	assert.Less(t, result.Duration, 5*time.Second)
+	assert.Zero(t, result.Errors)
+	assert.Positive(t, result.TotalReads)
+	assert.Positive(t, result.TotalWrites)
+
+	require.Len(t, result.Operations, mixedOperations)
+	var total uint64
+	for i, op := range result.Operations {
+		assert.Equal(t, mixedOperationNames[i], op.Name)
+		assert.Positive(t, op.Count, op.Name)
+		total += op.Count
+	}
+	assert.Equal(t, total, result.Latency.Count())
+
+	var users int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&users))
+	assert.Equal(t, 100+int(result.Operations[mixedInsert].Count), users)
+}
+
+func TestPickOperation(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	weights := [mixedOperations]int{0, 3, 0, 1}
+
+	counts := [mixedOperations]int{}
+	for range 4000 {
+		counts[pickOperation(rng, weights, 4)]++
+	}
+
+	assert.Zero(t, counts[mixedPointRead])
+	assert.Zero(t, counts[mixedInsert])
+	assert.InDelta(t, 3000, counts[mixedRangeRead], 200)
+	assert.InDelta(t, 1000, counts[mixedUpdate], 200)
+}
@@ -0,0 +1,162 @@
+package nsqlitebench
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+)
+
+type benchmarkPreparedConfig struct {
+	insertXUsers int
+	queryYUsers  int
+}
+
+// runBenchmarkPrepared inserts X users and then queries a user Y times from
+// a single goroutine, first with db.Exec and db.Query for every statement
+// and then with a prepared *sql.Stmt reused across iterations. This
+// isolates the cost of preparing the statements.
+//
+// It returns a result for each variant, the reused one with its speedup
+// over the direct one.
+func runBenchmarkPrepared(
+	db *sql.DB, fullConfig benchmarksConfig,
+) ([]benchmarkResult, error) {
+	conf := fullConfig.benchmarkPreparedConfig
+
+	direct, err := runBenchmarkPreparedVariant(db, conf, fullConfig.seed, false)
+	if err != nil {
+		return nil, fmt.Errorf("error with direct statements: %w", err)
+	}
+
+	if err := recreateSchema(db, fullConfig.schema); err != nil {
+		return nil, err
+	}
+
+	reused, err := runBenchmarkPreparedVariant(db, conf, fullConfig.seed, true)
+	if err != nil {
+		return nil, fmt.Errorf("error with prepared statements: %w", err)
+	}
+	if reused.Duration > 0 {
+		reused.Speedup = float64(direct.Duration) / float64(reused.Duration)
+	}
+
+	return []benchmarkResult{direct, reused}, nil
+}
+
+// preparedStatements are the statements of the prepared benchmark.
+type preparedStatements struct {
+	insert func(args ...any) (sql.Result, error)
+	query  func(args ...any) *sql.Row
+	close  func()
+}
+
+// newPreparedStatements returns the statements of the prepared benchmark,
+// prepared once if prepare is true or sent with every call otherwise.
+func newPreparedStatements(db *sql.DB, prepare bool) (preparedStatements, error) {
+	const (
+		insertQuery = "INSERT INTO users (created, email, active) VALUES (?, ?, ?)"
+		selectQuery = "SELECT id, created, email, active FROM users WHERE id = ?"
+	)
+
+	if !prepare {
+		return preparedStatements{
+			insert: func(args ...any) (sql.Result, error) { return db.Exec(insertQuery, args...) },
+			query:  func(args ...any) *sql.Row { return db.QueryRow(selectQuery, args...) },
+			close:  func() {},
+		}, nil
+	}
+
+	insertStmt, err := db.Prepare(insertQuery)
+	if err != nil {
+		return preparedStatements{}, err
+	}
+	selectStmt, err := db.Prepare(selectQuery)
+	if err != nil {
+		_ = insertStmt.Close()
+		return preparedStatements{}, err
+	}
+
+	return preparedStatements{
+		insert: insertStmt.Exec,
+		query:  selectStmt.QueryRow,
+		close: func() {
+			_ = insertStmt.Close()
+			_ = selectStmt.Close()
+		},
+	}, nil
+}
+
+// runBenchmarkPreparedVariant runs the prepared benchmark with prepared
+// statements if prepare is true, or with db.Exec and db.Query otherwise.
+func runBenchmarkPreparedVariant(
+	db *sql.DB, conf benchmarkPreparedConfig, seed uint64, prepare bool,
+) (benchmarkResult, error) {
+	users := newDataGenerator(seed, "prepared users")
+	queries := newDataGenerator(seed, "prepared queries")
+
+	name := "Prepared (direct)"
+	if prepare {
+		name = "Prepared (reused)"
+	}
+
+	start := time.Now()
+	startResources := resources.Take()
+	var totalReads, totalWrites uint64
+	latency := histogram.New()
+
+	stmts, err := newPreparedStatements(db, prepare)
+	if err != nil {
+		return benchmarkResult{}, err
+	}
+	defer stmts.close()
+
+	bar := NewBar(fmt.Sprintf("%s: inserting %d users", name, conf.insertXUsers), conf.insertXUsers)
+	for idx := range conf.insertXUsers {
+		user := users.user(idx)
+		opStart := time.Now()
+		res, err := stmts.insert(user.created, user.email, user.active)
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when inserting: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when inserting: %w", err)
+		}
+		latency.Record(time.Since(opStart))
+
+		bar.Inc()
+		totalWrites += uint64(affected)
+	}
+	bar.Finish()
+
+	bar = NewBar(fmt.Sprintf("%s: reading users %d times", name, conf.queryYUsers), conf.queryYUsers)
+	for idx := range conf.queryYUsers {
+		userID := queries.id(idx, conf.insertXUsers)
+
+		opStart := time.Now()
+		var id, created, active int
+		var email string
+		err := stmts.query(userID).Scan(&id, &created, &email, &active)
+		if err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when querying: %w", err)
+		}
+		latency.Record(time.Since(opStart))
+
+		bar.Inc()
+		totalReads++
+	}
+	bar.Finish()
+
+	return benchmarkResult{
+		Name:        name,
+		Duration:    time.Since(start),
+		TotalReads:  totalReads,
+		TotalWrites: totalWrites,
+		Config:      conf.report(),
+		Latency:     latency,
+		Resources:   resources.Take().Since(startResources),
+	}, nil
+}
@@ -0,0 +1,47 @@
+package nsqlitebench
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBenchmarkPrepared(t *testing.T) {
+	for _, local := range localDrivers {
+		t.Run(local.name, func(t *testing.T) {
+			db, err := local.create(filepath.Join(t.TempDir(), "bench.sqlite"))
+			require.NoError(t, err)
+			defer db.Close()
+			require.NoError(t, recreateSchema(db, config.SchemaIndexed))
+
+			cfg := benchmarksConfig{schema: config.SchemaIndexed, benchmarkPreparedConfig: benchmarkPreparedConfig{
+				insertXUsers: 50,
+				queryYUsers:  120,
+			}}
+
+			results, err := runBenchmarkPrepared(db, cfg)
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+
+			assert.Equal(t, "Prepared (direct)", results[0].Name)
+			assert.Equal(t, "Prepared (reused)", results[1].Name)
+			for _, result := range results {
+				assert.Equal(t, uint64(50), result.TotalWrites, result.Name)
+				assert.Equal(t, uint64(120), result.TotalReads, result.Name)
+				assert.Equal(t, uint64(170), result.Latency.Count(), result.Name)
+				assert.Positive(t, result.Resources.Allocs, result.Name)
+				assert.Positive(t, result.Resources.AllocBytes, result.Name)
+			}
+
+			assert.Zero(t, results[0].Speedup)
+			assert.InDelta(t, float64(results[0].Duration)/float64(results[1].Duration), results[1].Speedup, 1e-9)
+
+			var users int
+			require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&users))
+			assert.Equal(t, 50, users, "the schema is recreated between variants")
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package nsqlitebench
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+)
+
+type benchmarkPointReadsConfig struct {
+	reads      int
+	goroutines int
+}
+
+// errWriteRefused is returned by readOnlyDB for the statements that are not
+// reads.
+var errWriteRefused = errors.New("refusing to run a statement that is not a read in read-only mode")
+
+// queryer runs queries, it is implemented by *sql.DB and readOnlyDB.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// readOnlyDB runs the queries of the read-only benchmarks and refuses any
+// statement that is not a single SELECT. The local drivers are also opened
+// read-only, but the NSQLite server has no read-only connections.
+type readOnlyDB struct {
+	db *sql.DB
+}
+
+// Query runs the query if it is a single SELECT, otherwise it returns
+// errWriteRefused.
+func (r readOnlyDB) Query(query string, args ...any) (*sql.Rows, error) {
+	if !isReadQuery(query) {
+		return nil, fmt.Errorf("%w: %s", errWriteRefused, strings.TrimSpace(query))
+	}
+	return r.db.Query(query, args...)
+}
+
+// isReadQuery reports whether the query is a single SELECT statement. It
+// errs on the side of refusing, like for a semicolon in a string literal.
+func isReadQuery(query string) bool {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	if strings.Contains(query, ";") {
+		return false
+	}
+	fields := strings.Fields(query)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "SELECT")
+}
+
+// runReadOnlyBenchmark executes the benchmarks that only read the existing
+// data of the database, and returns results.
+//
+// It never recreates the schema nor inserts data.
+func runReadOnlyBenchmark(db *sql.DB, cfg benchmarksConfig, opts runOptions) ([]benchmarkResult, error) {
+	benchs := []func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error){
+		singleResult(runReadOnlySimple),
+		singleResult(runReadOnlyComplex),
+		singleResult(runReadOnlyMany),
+		singleResult(runBenchmarkPointReads),
+	}
+	opts.readOnly = true
+
+	var results []benchmarkResult
+
+	for _, bench := range benchs {
+		res, err := repeatBenchmark(db, cfg, bench, opts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, res...)
+	}
+
+	return results, nil
+}
+
+// runReadOnlySimple runs the reads of the simple benchmark, it queries all
+// users in a single query and then reads Y random users of the existing
+// IDs.
+func runReadOnlySimple(
+	db *sql.DB, fullConfig benchmarksConfig,
+) (benchmarkResult, error) {
+	conf := fullConfig.benchmarkSimpleConfig
+	ro := readOnlyDB{db: db}
+	queries := newDataGenerator(fullConfig.seed, "simple queries")
+	start := time.Now()
+	latency := histogram.New()
+
+	minID, maxID, err := userIDRange(ro)
+	if err != nil {
+		return benchmarkResult{}, err
+	}
+
+	bar := NewBar("Reading all users in single query", 1)
+	opStart := time.Now()
+	totalReads, err := readUsers(ro, "SELECT id, created, email, active FROM users ORDER BY id")
+	if err != nil {
+		return benchmarkResult{}, fmt.Errorf("error reading all users: %w", err)
+	}
+	latency.Record(time.Since(opStart))
+	bar.Inc()
+	bar.Finish()
+
+	reads, err := runReads(
+		fmt.Sprintf("Reading users %d times", conf.queryYUsers),
+		conf.queryYUsers, conf.queryGoroutines, latency,
+		func(idx int) (uint64, error) {
+			return readUsers(
+				ro, "SELECT id, created, email, active FROM users WHERE id = ?",
+				randomID(queries, idx, minID, maxID),
+			)
+		},
+	)
+	if err != nil {
+		return benchmarkResult{}, fmt.Errorf("error reading users: %w", err)
+	}
+
+	return benchmarkResult{
+		Name:       "Simple (read-only)",
+		Duration:   time.Since(start),
+		TotalReads: totalReads + reads,
+		Config: map[string]int{
+			"queryUsers":      conf.queryYUsers,
+			"queryGoroutines": conf.queryGoroutines,
+		},
+		Latency: latency,
+	}, nil
+}
+
+// runReadOnlyComplex runs the read of the complex benchmark, it queries all
+// users, articles, and comments with a JOIN query.
+func runReadOnlyComplex(
+	db *sql.DB, _ benchmarksConfig,
+) (benchmarkResult, error) {
+	ro := readOnlyDB{db: db}
+	start := time.Now()
+	latency := histogram.New()
+
+	bar := NewBar("Reading users, articles, and comments", 1)
+	opStart := time.Now()
+	rows, err := ro.Query(`
+		SELECT
+		users.id, users.created, users.email, users.active,
+		articles.id, articles.created, articles.userId, articles.text,
+		comments.id, comments.created, comments.articleId, comments.text
+		FROM users
+		LEFT JOIN articles ON articles.userId = users.id
+		LEFT JOIN comments ON comments.articleId = articles.id
+		ORDER BY users.created, articles.created, comments.created
+	`)
+	if err != nil {
+		return benchmarkResult{}, fmt.Errorf("error querying: %w", err)
+	}
+	defer rows.Close()
+
+	// The articles and comments are NULL for the users without them.
+	values := make([]any, 12)
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var totalReads uint64
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return benchmarkResult{}, fmt.Errorf("error when scanning: %w", err)
+		}
+		totalReads++
+	}
+	if err := rows.Err(); err != nil {
+		return benchmarkResult{}, fmt.Errorf("error querying: %w", err)
+	}
+	latency.Record(time.Since(opStart))
+	bar.Inc()
+	bar.Finish()
+
+	return benchmarkResult{
+		Name:       "Complex (read-only)",
+		Duration:   time.Since(start),
+		TotalReads: totalReads,
+		Config:     map[string]int{},
+		Latency:    latency,
+	}, nil
+}
+
+// runReadOnlyMany runs the reads of the many benchmark, it queries all users
+// Y times.
+func runReadOnlyMany(
+	db *sql.DB, fullConfig benchmarksConfig,
+) (benchmarkResult, error) {
+	conf := fullConfig.benchmarkManyConfig
+	ro := readOnlyDB{db: db}
+	start := time.Now()
+	latency := histogram.New()
+
+	totalReads, err := runReads(
+		fmt.Sprintf("Querying all users %d times", conf.queryUsersYTimes),
+		conf.queryUsersYTimes, conf.queryGoroutines, latency,
+		func(int) (uint64, error) {
+			return readUsers(ro, "SELECT id, created, email, active FROM users ORDER BY id")
+		},
+	)
+	if err != nil {
+		return benchmarkResult{}, fmt.Errorf("error querying users: %w", err)
+	}
+
+	return benchmarkResult{
+		Name:       "Many (read-only)",
+		Duration:   time.Since(start),
+		TotalReads: totalReads,
+		Config: map[string]int{
+			"queryUsersTimes": conf.queryUsersYTimes,
+			"queryGoroutines": conf.queryGoroutines,
+		},
+		Latency: latency,
+	}, nil
+}
+
+// runBenchmarkPointReads reads random users by ID, between the smallest and
+// the largest ID of the existing users. The IDs in the gaps of deleted
+// users are read too, they just return no rows.
+func runBenchmarkPointReads(
+	db *sql.DB, fullConfig benchmarksConfig,
+) (benchmarkResult, error) {
+	conf := fullConfig.benchmarkPointReadsConfig
+	ro := readOnlyDB{db: db}
+	queries := newDataGenerator(fullConfig.seed, "point reads")
+	start := time.Now()
+	latency := histogram.New()
+
+	minID, maxID, err := userIDRange(ro)
+	if err != nil {
+		return benchmarkResult{}, err
+	}
+
+	totalReads, err := runReads(
+		fmt.Sprintf("Reading %d random users", conf.reads),
+		conf.reads, conf.goroutines, latency,
+		func(idx int) (uint64, error) {
+			return readUsers(
+				ro, "SELECT id, created, email, active FROM users WHERE id = ?",
+				randomID(queries, idx, minID, maxID),
+			)
+		},
+	)
+	if err != nil {
+		return benchmarkResult{}, fmt.Errorf("error reading users: %w", err)
+	}
+
+	return benchmarkResult{
+		Name:       "Point reads",
+		Duration:   time.Since(start),
+		TotalReads: totalReads,
+		Config:     conf.report(),
+		Latency:    latency,
+	}, nil
+}
+
+// userIDRange returns the smallest and the largest ID of the users, it
+// fails if there are no users to read.
+func userIDRange(db queryer) (int64, int64, error) {
+	rows, err := db.Query("SELECT MIN(id), MAX(id) FROM users")
+	if err != nil {
+		return 0, 0, fmt.Errorf("error reading the range of user IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var minID, maxID sql.NullInt64
+	if rows.Next() {
+		if err := rows.Scan(&minID, &maxID); err != nil {
+			return 0, 0, fmt.Errorf("error reading the range of user IDs: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("error reading the range of user IDs: %w", err)
+	}
+	if !minID.Valid || !maxID.Valid {
+		return 0, 0, errors.New("there are no users to read, the read-only mode needs a database with data")
+	}
+	return minID.Int64, maxID.Int64, nil
+}
+
+// randomID returns the ID of the index generated by gen, between minID and
+// maxID.
+func randomID(gen dataGenerator, idx int, minID int64, maxID int64) int64 {
+	return minID + int64(gen.id(idx, int(maxID-minID+1))) - 1
+}
+
+// runReads runs read n times with up to goroutines at once, recording the
+// latency of each one, and returns the sum of their rows. It stops at the
+// first error.
+func runReads(
+	description string, n int, goroutines int, latency *histogram.Histogram,
+	read func(idx int) (uint64, error),
+) (uint64, error) {
+	var totalReads uint64
+	var firstErr error
+	errOnce := sync.Once{}
+
+	wg := sync.WaitGroup{}
+	ch := make(chan bool, max(goroutines, 1))
+	bar := NewBar(description, n)
+
+	for idx := range n {
+		wg.Add(1)
+		ch <- true
+		go func() {
+			defer func() {
+				wg.Done()
+				<-ch
+			}()
+
+			opStart := time.Now()
+			reads, err := read(idx)
+			if err != nil {
+				errOnce.Do(func() { firstErr = err })
+				return
+			}
+			latency.Record(time.Since(opStart))
+
+			bar.Inc()
+			atomic.AddUint64(&totalReads, reads)
+		}()
+	}
+
+	wg.Wait()
+	close(ch)
+	bar.Finish()
+
+	return totalReads, firstErr
+}
@@ -0,0 +1,116 @@
+package nsqlitebench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readOnlyFixture is the database of the read-only benchmark tests, built
+// from testdata/readonly.sql. It has 43 users with IDs between 101 and 150.
+const readOnlyFixture = "testdata/readonly.sqlite"
+
+func TestRunReadOnlyBenchmark(t *testing.T) {
+	db, err := localDrivers[0].createReadOnly(readOnlyFixture)
+	require.NoError(t, err)
+	defer db.Close()
+
+	cfg := benchmarksConfig{
+		seed: 1,
+		benchmarkSimpleConfig: benchmarkSimpleConfig{
+			queryYUsers:     100,
+			queryGoroutines: 4,
+		},
+		benchmarkManyConfig: benchmarkManyConfig{
+			queryUsersYTimes: 5,
+			queryGoroutines:  2,
+		},
+		benchmarkPointReadsConfig: benchmarkPointReadsConfig{
+			reads:      100,
+			goroutines: 4,
+		},
+	}
+
+	results, err := runReadOnlyBenchmark(db, cfg, runOptions{repeat: 2})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	names := []string{}
+	for _, result := range results {
+		names = append(names, result.Name)
+		assert.Zero(t, result.TotalWrites, result.Name)
+		assert.Equal(t, 2, result.Runs, result.Name)
+	}
+	assert.Equal(t, []string{
+		"Simple (read-only)", "Complex (read-only)", "Many (read-only)", "Point reads",
+	}, names)
+
+	// The point reads of the IDs in the gaps of the users return no rows.
+	assert.Greater(t, results[0].TotalReads, uint64(43))
+	assert.Less(t, results[0].TotalReads, uint64(43+100))
+	assert.Equal(t, uint64(210), results[1].TotalReads)
+	assert.Equal(t, uint64(5*43), results[2].TotalReads)
+	assert.Positive(t, results[3].TotalReads)
+	assert.LessOrEqual(t, results[3].TotalReads, uint64(100))
+	assert.Equal(t, uint64(2*100), results[3].Latency.Count())
+
+	var users int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&users))
+	assert.Equal(t, 43, users)
+}
+
+func TestReadOnlyRefusesWrites(t *testing.T) {
+	db, err := localDrivers[0].createReadOnly(readOnlyFixture)
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("Benchmark queries", func(t *testing.T) {
+		_, err := readOnlyDB{db: db}.Query("DELETE FROM users")
+		assert.ErrorIs(t, err, errWriteRefused)
+	})
+
+	t.Run("Driver", func(t *testing.T) {
+		_, err := db.Exec("DELETE FROM users")
+		assert.Error(t, err)
+	})
+}
+
+func TestIsReadQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "SELECT id FROM users", want: true},
+		{query: "\n\t select id FROM users WHERE id = ?;", want: true},
+		{query: "INSERT INTO users (email) VALUES ('a')", want: false},
+		{query: "SELECT 1; DROP TABLE users", want: false},
+		{query: "WITH x AS (SELECT 1) DELETE FROM users", want: false},
+		{query: "PRAGMA journal_mode = WAL", want: false},
+		{query: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, isReadQuery(tt.query))
+		})
+	}
+}
+
+func TestUserIDRange(t *testing.T) {
+	db, err := localDrivers[0].createReadOnly(readOnlyFixture)
+	require.NoError(t, err)
+	defer db.Close()
+
+	minID, maxID, err := userIDRange(readOnlyDB{db: db})
+	require.NoError(t, err)
+	assert.Equal(t, int64(101), minID)
+	assert.Equal(t, int64(150), maxID)
+
+	gen := newDataGenerator(1, "point reads")
+	for idx := range 1000 {
+		id := randomID(gen, idx, minID, maxID)
+		assert.GreaterOrEqual(t, id, minID)
+		assert.LessOrEqual(t, id, maxID)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
 )
 
 type benchmarkSimpleConfig struct {
@@ -23,9 +25,12 @@ func runBenchmarkSimple(
 	db *sql.DB, fullConfig benchmarksConfig,
 ) (benchmarkResult, error) {
 	conf := fullConfig.benchmarkSimpleConfig
+	users := newDataGenerator(fullConfig.seed, "simple users")
+	queries := newDataGenerator(fullConfig.seed, "simple queries")
 	start := time.Now()
 	var totalReads uint64 = 0
 	var totalWrites uint64 = 0
+	latency := histogram.New()
 
 	wg := sync.WaitGroup{}
 	wgch := make(chan bool, conf.insertGoroutines)
@@ -41,9 +46,11 @@ func runBenchmarkSimple(
 				<-wgch
 			}()
 
+			user := users.user(idx)
+			opStart := time.Now()
 			res, err := db.Exec(
 				"INSERT INTO users (created, email, active) VALUES (?, ?, ?)",
-				time.Now().Unix(), fmt.Sprintf("user%d@example.com", idx), 1,
+				user.created, user.email, user.active,
 			)
 			if err != nil {
 				panic(err)
@@ -53,6 +60,7 @@ func runBenchmarkSimple(
 			if err != nil {
 				panic(err)
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 			atomic.AddUint64(&totalWrites, uint64(rowsAffected))
@@ -65,6 +73,7 @@ func runBenchmarkSimple(
 	bar.Finish()
 	bar = NewBar("Reading all users in single query", 1)
 
+	opStart := time.Now()
 	rows, err := db.Query(
 		"SELECT id, created, email, active FROM users ORDER BY id",
 	)
@@ -81,6 +90,7 @@ func runBenchmarkSimple(
 		}
 		atomic.AddUint64(&totalReads, 1)
 	}
+	latency.Record(time.Since(opStart))
 	bar.Finish()
 
 	bar = NewBar(fmt.Sprintf("Reading users %d times", conf.queryYUsers), conf.queryYUsers)
@@ -90,7 +100,7 @@ func runBenchmarkSimple(
 	for idx := range conf.queryYUsers {
 		wg.Add(1)
 		wgch <- true
-		userID := max(idx%conf.insertXUsers, 1)
+		userID := queries.id(idx, conf.insertXUsers)
 
 		go func() {
 			defer func() {
@@ -98,6 +108,7 @@ func runBenchmarkSimple(
 				<-wgch
 			}()
 
+			opStart := time.Now()
 			rows, err := db.Query(
 				"SELECT id, created, email, active FROM users WHERE id = ?",
 				userID,
@@ -114,6 +125,7 @@ func runBenchmarkSimple(
 					panic(err)
 				}
 			}
+			latency.Record(time.Since(opStart))
 
 			bar.Inc()
 			atomic.AddUint64(&totalReads, 1)
@@ -129,5 +141,7 @@ func runBenchmarkSimple(
 		Duration:    time.Since(start),
 		TotalReads:  totalReads,
 		TotalWrites: totalWrites,
+		Config:      conf.report(),
+		Latency:     latency,
 	}, nil
 }
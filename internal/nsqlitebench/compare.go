@@ -0,0 +1,214 @@
+package nsqlitebench
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+)
+
+// errRegression is returned by runCompare when a benchmark regresses more
+// than --fail-on-regression.
+var errRegression = errors.New("benchmark regression")
+
+// benchmarkDelta is the change of a benchmark of a driver between two runs.
+// Old is nil for the added benchmarks and New for the removed ones.
+type benchmarkDelta struct {
+	Driver string
+	Name   string
+	Old    *BenchmarkReport
+	New    *BenchmarkReport
+}
+
+// throughputChange returns the percent change of the operations per second,
+// negative when the new run is slower.
+func (d benchmarkDelta) throughputChange() (float64, bool) {
+	if d.Old == nil || d.New == nil {
+		return 0, false
+	}
+	return percentChange(d.Old.OpsPerSec, d.New.OpsPerSec)
+}
+
+// p99Change returns the percent change of the p99 latency, positive when
+// the new run is slower.
+func (d benchmarkDelta) p99Change() (float64, bool) {
+	if d.Old == nil || d.New == nil {
+		return 0, false
+	}
+	return percentChange(d.Old.P99Ms, d.New.P99Ms)
+}
+
+// regression returns the percent of the worst regression of the benchmark,
+// the throughput loss or the p99 increase, zero if none regressed.
+func (d benchmarkDelta) regression() float64 {
+	regression := 0.0
+	if change, ok := d.throughputChange(); ok {
+		regression = max(regression, -change)
+	}
+	if change, ok := d.p99Change(); ok {
+		regression = max(regression, change)
+	}
+	return regression
+}
+
+// percentChange returns the percent change from old to new, it is unknown
+// if old is zero.
+func percentChange(old float64, new float64) (float64, bool) {
+	if old == 0 {
+		return 0, false
+	}
+	return (new - old) / old * 100, true
+}
+
+// runCompare compares the JSON results of two runs, writing the change of
+// each benchmark to w. It returns an error wrapping errRegression if a
+// benchmark regresses more than --fail-on-regression.
+func runCompare(args []string, w io.Writer) error {
+	conf, err := config.ParseCompare(args, w)
+	if errors.Is(err, config.ErrHelpShown) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	oldReport, err := readReportFile(conf.Old)
+	if err != nil {
+		return err
+	}
+	newReport, err := readReportFile(conf.New)
+	if err != nil {
+		return err
+	}
+
+	deltas := compareReports(oldReport, newReport)
+	fmt.Fprintf(w, "--- Changes from %s to %s ---\n", conf.Old, conf.New)
+	fmt.Fprintln(w, renderDeltas(deltas, conf.Threshold))
+
+	if conf.FailOnRegression == nil {
+		return nil
+	}
+	regressions := 0
+	for _, delta := range deltas {
+		if delta.regression() > *conf.FailOnRegression {
+			regressions++
+		}
+	}
+	if regressions > 0 {
+		return fmt.Errorf(
+			"%w, %d benchmarks regressed more than %g%%", errRegression, regressions, *conf.FailOnRegression,
+		)
+	}
+	return nil
+}
+
+// readReportFile reads the JSON results written with --output json.
+func readReportFile(path string) (Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Report{}, fmt.Errorf("error opening the results: %w", err)
+	}
+	defer f.Close()
+
+	report := Report{}
+	if err := json.NewDecoder(f).Decode(&report); err != nil {
+		return Report{}, fmt.Errorf("error reading the results of %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// compareReports returns the change of each benchmark of each driver, in
+// the order of the new report followed by the removed benchmarks.
+func compareReports(oldReport Report, newReport Report) []benchmarkDelta {
+	deltas := []benchmarkDelta{}
+	for _, driver := range newReport.Drivers {
+		oldDriver, _ := findDriver(oldReport, driver.Driver)
+		for _, bench := range driver.Benchmarks {
+			delta := benchmarkDelta{Driver: driver.Driver, Name: bench.Name, New: &bench}
+			if old, ok := findBenchmark(oldDriver, bench.Name); ok {
+				delta.Old = &old
+			}
+			deltas = append(deltas, delta)
+		}
+	}
+
+	for _, driver := range oldReport.Drivers {
+		newDriver, _ := findDriver(newReport, driver.Driver)
+		for _, bench := range driver.Benchmarks {
+			if _, ok := findBenchmark(newDriver, bench.Name); !ok {
+				deltas = append(deltas, benchmarkDelta{Driver: driver.Driver, Name: bench.Name, Old: &bench})
+			}
+		}
+	}
+	return deltas
+}
+
+// findDriver returns the results of the driver in the report.
+func findDriver(report Report, driver string) (DriverReport, bool) {
+	for _, d := range report.Drivers {
+		if d.Driver == driver {
+			return d, true
+		}
+	}
+	return DriverReport{}, false
+}
+
+// renderDeltas returns a table with the change of each benchmark, the
+// regressions above thresholdPct are colored red.
+func renderDeltas(deltas []benchmarkDelta, thresholdPct float64) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{
+		"Driver", "Name", "Old ops/sec", "New ops/sec", "Change", "Old p99", "New p99", "Change",
+	})
+
+	for _, delta := range deltas {
+		label := driverLabels[delta.Driver]
+		if label == "" {
+			label = delta.Driver
+		}
+
+		switch {
+		case delta.Old == nil:
+			tw.AppendRow(table.Row{
+				label, delta.Name, "-", fmt.Sprintf("%.2f", delta.New.OpsPerSec), "added",
+				"-", msDuration(delta.New.P99Ms), "added",
+			})
+		case delta.New == nil:
+			tw.AppendRow(table.Row{
+				label, delta.Name, fmt.Sprintf("%.2f", delta.Old.OpsPerSec), "-", "removed",
+				msDuration(delta.Old.P99Ms), "-", "removed",
+			})
+		default:
+			throughput, throughputOk := delta.throughputChange()
+			p99, p99Ok := delta.p99Change()
+			tw.AppendRow(table.Row{
+				label, delta.Name,
+				fmt.Sprintf("%.2f", delta.Old.OpsPerSec), fmt.Sprintf("%.2f", delta.New.OpsPerSec),
+				formatChange(throughput, throughputOk, -throughput > thresholdPct),
+				msDuration(delta.Old.P99Ms), msDuration(delta.New.P99Ms),
+				formatChange(p99, p99Ok, p99 > thresholdPct),
+			})
+		}
+	}
+
+	return tw.Render()
+}
+
+// formatChange formats a percent change, colored red if it is a
+// regression.
+func formatChange(change float64, ok bool, regression bool) string {
+	if !ok {
+		return "-"
+	}
+	formatted := fmt.Sprintf("%+.2f%%", change)
+	if regression {
+		return color.RedString(formatted)
+	}
+	return formatted
+}
@@ -0,0 +1,96 @@
+package nsqlitebench
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareReports(t *testing.T) {
+	oldReport, err := readReportFile("testdata/compare_old.json")
+	require.NoError(t, err)
+	newReport, err := readReportFile("testdata/compare_new.json")
+	require.NoError(t, err)
+
+	deltas := compareReports(oldReport, newReport)
+	require.Len(t, deltas, 5)
+
+	names := []string{}
+	for _, delta := range deltas {
+		assert.Equal(t, "mattn", delta.Driver)
+		names = append(names, delta.Name)
+	}
+	assert.Equal(t, []string{"Simple", "Many", "Mixed", "Point reads", "Complex"}, names)
+
+	t.Run("Improvement", func(t *testing.T) {
+		throughput, ok := deltas[0].throughputChange()
+		assert.True(t, ok)
+		assert.InDelta(t, 25, throughput, 0.001)
+		p99, _ := deltas[0].p99Change()
+		assert.InDelta(t, -25, p99, 0.001)
+		assert.Zero(t, deltas[0].regression())
+	})
+
+	t.Run("Throughput regression", func(t *testing.T) {
+		throughput, _ := deltas[1].throughputChange()
+		assert.InDelta(t, -20, throughput, 0.001)
+		assert.InDelta(t, 20, deltas[1].regression(), 0.001)
+	})
+
+	t.Run("Latency regression", func(t *testing.T) {
+		throughput, _ := deltas[2].throughputChange()
+		assert.Zero(t, throughput)
+		assert.InDelta(t, 50, deltas[2].regression(), 0.001)
+	})
+
+	t.Run("Added and removed", func(t *testing.T) {
+		assert.Nil(t, deltas[3].Old)
+		assert.NotNil(t, deltas[3].New)
+		assert.Nil(t, deltas[4].New)
+		assert.NotNil(t, deltas[4].Old)
+
+		_, ok := deltas[3].throughputChange()
+		assert.False(t, ok)
+		assert.Zero(t, deltas[3].regression())
+		assert.Zero(t, deltas[4].regression())
+	})
+}
+
+func TestRunCompare(t *testing.T) {
+	t.Run("Table", func(t *testing.T) {
+		out := bytes.Buffer{}
+		err := runCompare([]string{
+			"compare", "testdata/compare_old.json", "testdata/compare_new.json",
+		}, &out)
+		require.NoError(t, err)
+
+		for _, want := range []string{
+			"Changes from testdata/compare_old.json to testdata/compare_new.json",
+			"mattn/go-sqlite3", "+25.00%", "-20.00%", "+50.00%", "added", "removed",
+		} {
+			assert.Contains(t, out.String(), want)
+		}
+	})
+
+	t.Run("Fail on regression", func(t *testing.T) {
+		err := runCompare([]string{
+			"compare", "testdata/compare_old.json", "testdata/compare_new.json", "--fail-on-regression", "10",
+		}, &bytes.Buffer{})
+		assert.ErrorIs(t, err, errRegression)
+		assert.ErrorContains(t, err, "2 benchmarks regressed more than 10%")
+
+		err = runCompare([]string{
+			"compare", "testdata/compare_old.json", "testdata/compare_new.json", "--fail-on-regression", "50",
+		}, &bytes.Buffer{})
+		assert.NoError(t, err, "the regressions are not above the limit")
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		err := runCompare([]string{
+			"compare", "testdata/compare_old.json", "testdata/missing.json",
+		}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "error opening the results")
+	})
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alexflint/go-arg"
+	"github.com/nsqlite/nsqlite/internal/version"
+)
+
+// CompareCommand is the first argument of the subcommand that compares the
+// JSON results of two runs.
+const CompareCommand = "compare"
+
+// CompareConfig represents the configuration of nsqlitebench compare.
+type CompareConfig struct {
+	Old       string  `arg:"positional,required" help:"JSON results of the baseline run"`
+	New       string  `arg:"positional,required" help:"JSON results of the run compared to the baseline"`
+	Threshold float64 `arg:"--threshold" help:"Percent of throughput loss or p99 increase above which a change is shown as a regression" default:"5"`
+	// FailOnRegression is the percent of regression above which the
+	// comparison fails, nil if it never fails.
+	FailOnRegression *float64 `arg:"--fail-on-regression" help:"Exit with an error when a benchmark regresses more than this percent, for CI"`
+}
+
+func (CompareConfig) Version() string {
+	return fmt.Sprintf("%s\n", version.BenchVersion())
+}
+
+func (CompareConfig) Description() string {
+	return "Compares the JSON results of two runs of nsqlitebench, written with --output json."
+}
+
+// ParseCompare parses and validates the configuration of the compare
+// subcommand from its command line arguments, the first one being the
+// subcommand. The help and the version are written to stdout when
+// requested, returning ErrHelpShown.
+func ParseCompare(args []string, stdout io.Writer) (CompareConfig, error) {
+	cfg := CompareConfig{}
+
+	parser, err := arg.NewParser(
+		arg.Config{Program: "nsqlitebench " + CompareCommand},
+		&cfg,
+	)
+	if err != nil {
+		return cfg, err
+	}
+
+	switch err := parser.Parse(args[1:]); {
+	case errors.Is(err, arg.ErrHelp):
+		parser.WriteHelp(stdout)
+		return cfg, ErrHelpShown
+	case errors.Is(err, arg.ErrVersion):
+		fmt.Fprint(stdout, cfg.Version())
+		return cfg, ErrHelpShown
+	case err != nil:
+		return cfg, fmt.Errorf("%w, run with --help for usage", err)
+	}
+
+	if cfg.Threshold < 0 {
+		return cfg, errors.New("invalid threshold, must not be negative")
+	}
+
+	if cfg.FailOnRegression != nil && *cfg.FailOnRegression < 0 {
+		return cfg, errors.New("invalid fail on regression, must not be negative")
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompare(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := ParseCompare([]string{"compare", "old.json", "new.json"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "old.json", cfg.Old)
+		assert.Equal(t, "new.json", cfg.New)
+		assert.Equal(t, 5.0, cfg.Threshold)
+		assert.Nil(t, cfg.FailOnRegression)
+	})
+
+	t.Run("All flags", func(t *testing.T) {
+		cfg, err := ParseCompare([]string{
+			"compare", "old.json", "new.json", "--threshold", "2.5", "--fail-on-regression", "10",
+		}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, 2.5, cfg.Threshold)
+		require.NotNil(t, cfg.FailOnRegression)
+		assert.Equal(t, 10.0, *cfg.FailOnRegression)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := ParseCompare([]string{"compare", "old.json"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "run with --help for usage")
+	})
+
+	t.Run("Invalid fail on regression", func(t *testing.T) {
+		_, err := ParseCompare([]string{
+			"compare", "old.json", "new.json", "--fail-on-regression", "-1",
+		}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid fail on regression")
+	})
+
+	t.Run("Help", func(t *testing.T) {
+		out := bytes.Buffer{}
+		_, err := ParseCompare([]string{"compare", "--help"}, &out)
+		assert.ErrorIs(t, err, ErrHelpShown)
+		assert.Contains(t, out.String(), "nsqlitebench compare")
+		assert.Contains(t, out.String(), "--fail-on-regression")
+	})
+}
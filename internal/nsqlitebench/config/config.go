@@ -0,0 +1,357 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexflint/go-arg"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
+	"github.com/nsqlite/nsqlite/internal/version"
+)
+
+// Drivers that can be benchmarked.
+const (
+	DriverMattn   = "mattn"
+	DriverSqlitec = "sqlitec"
+	DriverNsqlite = "nsqlite"
+)
+
+// Output formats of the results.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+// Benchmarks that can be run in the concurrency sweep.
+const (
+	BenchmarkSimple  = "simple"
+	BenchmarkComplex = "complex"
+	BenchmarkMany    = "many"
+	BenchmarkLarge   = "large"
+	BenchmarkMixed   = "mixed"
+)
+
+// Schemas of the benchmark tables.
+const (
+	// SchemaPlain has no secondary indexes.
+	SchemaPlain = "plain"
+	// SchemaIndexed has a unique index on the emails and indexes on the
+	// created timestamps and the foreign keys.
+	SchemaIndexed = "indexed"
+	// SchemaWALHeavy is SchemaIndexed plus triggers logging every change in
+	// another table.
+	SchemaWALHeavy = "wal-heavy"
+)
+
+// validSchemas are the schemas accepted by --schema.
+var validSchemas = []string{SchemaPlain, SchemaIndexed, SchemaWALHeavy}
+
+// validSweepBenchmarks are the benchmarks accepted by --sweep-benchmark.
+var validSweepBenchmarks = []string{
+	BenchmarkSimple, BenchmarkComplex, BenchmarkMany, BenchmarkLarge, BenchmarkMixed,
+}
+
+// validOutputs are the formats accepted by --output.
+var validOutputs = []string{OutputTable, OutputJSON, OutputCSV}
+
+// validDrivers are the drivers accepted by --drivers, in the order they are
+// benchmarked.
+var validDrivers = []string{DriverMattn, DriverSqlitec, DriverNsqlite}
+
+// Config represents the configuration for nsqlitebench.
+type Config struct {
+	NsqliteDSN   string        `arg:"--nsqlite-dsn" help:"Connection string of the NSQLite server to benchmark in format http(s)://host:port?authToken=value" default:"http://localhost:9876"`
+	SqlitePath   string        `arg:"--sqlite-path" help:"SQLite database file to benchmark with the local drivers, each driver uses its own file with its name appended, like bench-mattn.sqlite for bench.sqlite, or the file itself with --read-only (default to temporary files removed after the benchmark)"`
+	Drivers      string        `arg:"--drivers" help:"Comma separated list of the drivers to benchmark (mattn, sqlitec, nsqlite), sqlitec is only built with -tags sqlitec, which leaves out mattn" default:"mattn,sqlitec,nsqlite"`
+	Yes          bool          `arg:"-y,--yes" help:"Start the benchmark without asking for confirmation, for CI"`
+	Quiet        bool          `arg:"-q,--quiet" help:"Print a line for each phase instead of progress bars, for CI logs, default to true when stdout is not a terminal"`
+	Schema       string        `arg:"--schema" help:"Indexes of the benchmark tables (plain, indexed, wal-heavy), wal-heavy adds triggers logging every change" default:"indexed"`
+	ReadOnly     bool          `arg:"--read-only" help:"Only benchmark reads of the existing data of --sqlite-path and --nsqlite-dsn, without any write"`
+	Force        bool          `arg:"--force" help:"Benchmark the databases even if they are not empty, the benchmark drops and recreates its tables"`
+	Output       string        `arg:"--output" help:"Format of the results (table, json, csv)" default:"table"`
+	OutputFile   string        `arg:"--output-file" help:"File where the results are written (default to stdout)"`
+	Duration     time.Duration `arg:"--duration" help:"Duration of the mixed read/write benchmark" default:"10s"`
+	Mix          string        `arg:"--mix" help:"Comma separated weights of the point reads, range reads, inserts and updates of the mixed benchmark" default:"70,20,5,5"`
+	Soak         time.Duration `arg:"--soak" help:"Run the mixed workload continuously for this duration instead of the benchmarks, like 30m, reporting its throughput every --soak-interval to detect degradation over time"`
+	SoakInterval time.Duration `arg:"--soak-interval" help:"Interval of the results reported during --soak" default:"10s"`
+	Warmup       int           `arg:"--warmup" help:"Runs of each benchmark before the measured ones, their results are discarded" default:"0"`
+	Repeat       int           `arg:"--repeat" help:"Measured runs of each benchmark, the results are their mean and standard deviation" default:"1"`
+	Noise        float64       `arg:"--noise-threshold" help:"Percent of the standard deviation of the duration over its mean above which a result is flagged as noisy" default:"10"`
+	Sweep        string        `arg:"--sweep-concurrency" help:"Comma separated goroutine counts to run --sweep-benchmark with, like 1,2,4,8,16,32, instead of running all the benchmarks"`
+	SweepBench   string        `arg:"--sweep-benchmark" help:"Benchmark of the concurrency sweep (simple, complex, many, large, mixed)" default:"simple"`
+	Seed         *uint64       `arg:"--seed" help:"Seed of the generated data, runs with the same seed insert the same data (default to a random seed)"`
+	LargeSizes   string        `arg:"--large-sizes" help:"Comma separated payload sizes of the large benchmark, like 1KB or 8MB" default:"1KB,64KB,1MB,8MB"`
+	// ParsedDrivers are the drivers of Drivers, without duplicates and in the
+	// order they are benchmarked.
+	ParsedDrivers []string `arg:"-"`
+	// ParsedMix are the weights of Mix.
+	ParsedMix Mix `arg:"-"`
+	// ParsedLargeSizes are the sizes in bytes of LargeSizes.
+	ParsedLargeSizes []int `arg:"-"`
+	// ParsedSweep are the goroutine counts of Sweep, empty if the sweep is
+	// not enabled.
+	ParsedSweep []int `arg:"-"`
+	// ParsedSeed is Seed, or a random seed if it is not set.
+	ParsedSeed uint64 `arg:"-"`
+}
+
+// Mix are the weights of the operations of the mixed benchmark, each
+// operation is picked with a probability of its weight divided by the sum
+// of the weights.
+type Mix struct {
+	PointReads int
+	RangeReads int
+	Inserts    int
+	Updates    int
+}
+
+func (Config) Version() string {
+	return fmt.Sprintf("%s\n", version.BenchVersion())
+}
+
+func (Config) Epilogue() string {
+	return "Run nsqlitebench " + CompareCommand + " --help to compare the JSON results of two runs."
+}
+
+// Has reports whether the driver is benchmarked.
+func (c Config) Has(driver string) bool {
+	return slices.Contains(c.ParsedDrivers, driver)
+}
+
+// ErrHelpShown is returned by Parse after writing the help or the version,
+// the program must exit without error.
+var ErrHelpShown = errors.New("help shown")
+
+// Parse parses and validates the configuration from the command line
+// arguments. The help and the version are written to stdout when requested,
+// returning ErrHelpShown.
+func Parse(args []string, stdout io.Writer) (Config, error) {
+	cfg := Config{}
+
+	parser, err := arg.NewParser(
+		arg.Config{},
+		&cfg,
+	)
+	if err != nil {
+		return cfg, err
+	}
+
+	switch err := parser.Parse(args[1:]); {
+	case errors.Is(err, arg.ErrHelp):
+		parser.WriteHelp(stdout)
+		return cfg, ErrHelpShown
+	case errors.Is(err, arg.ErrVersion):
+		fmt.Fprint(stdout, cfg.Version())
+		return cfg, ErrHelpShown
+	case err != nil:
+		return cfg, fmt.Errorf("%w, run with --help for usage", err)
+	}
+
+	cfg.ParsedDrivers, err = parseDrivers(cfg.Drivers)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.ParsedMix, err = parseMix(cfg.Mix)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.ParsedLargeSizes, err = parseSizes(cfg.LargeSizes)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.ParsedSeed = randomSeed()
+	if cfg.Seed != nil {
+		cfg.ParsedSeed = *cfg.Seed
+	}
+
+	cfg.ParsedSweep, err = parseSweep(cfg.Sweep)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.SweepBench = strings.ToLower(strings.TrimSpace(cfg.SweepBench))
+	if !slices.Contains(validSweepBenchmarks, cfg.SweepBench) {
+		return cfg, fmt.Errorf(
+			"invalid sweep benchmark, valid values are: %s", strings.Join(validSweepBenchmarks, ", "),
+		)
+	}
+
+	cfg.Schema = strings.ToLower(strings.TrimSpace(cfg.Schema))
+	if !slices.Contains(validSchemas, cfg.Schema) {
+		return cfg, fmt.Errorf(
+			"invalid schema, valid values are: %s", strings.Join(validSchemas, ", "),
+		)
+	}
+
+	if cfg.Warmup < 0 {
+		return cfg, errors.New("invalid warmup, must not be negative")
+	}
+
+	if cfg.Repeat < 1 {
+		return cfg, errors.New("invalid repeat, must be at least 1")
+	}
+
+	if cfg.Noise < 0 {
+		return cfg, errors.New("invalid noise threshold, must not be negative")
+	}
+
+	if cfg.Duration <= 0 {
+		return cfg, errors.New("invalid duration, must be greater than zero")
+	}
+
+	if !slices.Contains(validOutputs, cfg.Output) {
+		return cfg, fmt.Errorf(
+			"invalid output, valid values are: %s", strings.Join(validOutputs, ", "),
+		)
+	}
+
+	if cfg.Has(DriverNsqlite) && strings.TrimSpace(cfg.NsqliteDSN) == "" {
+		return cfg, errors.New("invalid NSQLite DSN, must not be empty")
+	}
+
+	if cfg.Soak < 0 {
+		return cfg, errors.New("invalid soak duration, must not be negative")
+	}
+
+	if cfg.Soak > 0 {
+		if cfg.SoakInterval <= 0 || cfg.SoakInterval > cfg.Soak {
+			return cfg, errors.New("invalid soak interval, must be greater than zero and not longer than the soak")
+		}
+		if len(cfg.ParsedSweep) > 0 {
+			return cfg, errors.New("invalid soak, it cannot run with the concurrency sweep")
+		}
+		if cfg.ReadOnly {
+			return cfg, errors.New("invalid soak, the mixed workload writes to the database")
+		}
+	}
+
+	if cfg.ReadOnly {
+		if len(cfg.ParsedSweep) > 0 {
+			return cfg, errors.New("invalid read-only mode, the concurrency sweep writes to the database")
+		}
+		if (cfg.Has(DriverMattn) || cfg.Has(DriverSqlitec)) && cfg.SqlitePath == "" {
+			return cfg, errors.New("invalid read-only mode, the local drivers need --sqlite-path with existing data")
+		}
+	}
+
+	return cfg, nil
+}
+
+// parseDrivers parses a comma separated list of drivers and returns them in
+// the order they are benchmarked.
+func parseDrivers(list string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, driver := range strings.Split(list, ",") {
+		driver = strings.ToLower(strings.TrimSpace(driver))
+		if driver == "" {
+			continue
+		}
+		if !slices.Contains(validDrivers, driver) {
+			return nil, fmt.Errorf(
+				"invalid driver %q, valid values are: %s",
+				driver, strings.Join(validDrivers, ", "),
+			)
+		}
+		selected[driver] = true
+	}
+
+	drivers := []string{}
+	for _, driver := range validDrivers {
+		if selected[driver] {
+			drivers = append(drivers, driver)
+		}
+	}
+	if len(drivers) == 0 {
+		return nil, errors.New("no driver selected, valid values are: " + strings.Join(validDrivers, ", "))
+	}
+	return drivers, nil
+}
+
+// parseMix parses the comma separated weights of the point reads, range
+// reads, inserts and updates of the mixed benchmark.
+func parseMix(list string) (Mix, error) {
+	parts := strings.Split(list, ",")
+	if len(parts) != 4 {
+		return Mix{}, errors.New("invalid mix, must be 4 comma separated weights for point reads, range reads, inserts and updates")
+	}
+
+	weights := make([]int, len(parts))
+	for i, part := range parts {
+		weight, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || weight < 0 {
+			return Mix{}, fmt.Errorf("invalid mix weight %q, must be a non negative integer", strings.TrimSpace(part))
+		}
+		weights[i] = weight
+	}
+
+	mix := Mix{PointReads: weights[0], RangeReads: weights[1], Inserts: weights[2], Updates: weights[3]}
+	if mix.PointReads+mix.RangeReads+mix.Inserts+mix.Updates == 0 {
+		return Mix{}, errors.New("invalid mix, at least one weight must be greater than zero")
+	}
+	return mix, nil
+}
+
+// maxLargeSize is the largest payload size of the large benchmark.
+const maxLargeSize = 512 << 20
+
+// parseSizes parses the comma separated payload sizes of the large
+// benchmark.
+func parseSizes(list string) ([]int, error) {
+	sizes := []int{}
+	for _, part := range strings.Split(list, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		size, err := numutil.ParseBytes(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid large benchmark size: %w", err)
+		}
+		if size == 0 || size > maxLargeSize {
+			return nil, fmt.Errorf(
+				"invalid large benchmark size %q, must be between 1B and %s",
+				strings.TrimSpace(part), numutil.Bytes(maxLargeSize),
+			)
+		}
+		sizes = append(sizes, int(size))
+	}
+	if len(sizes) == 0 {
+		return nil, errors.New("no large benchmark size selected")
+	}
+	return sizes, nil
+}
+
+// parseSweep parses the comma separated goroutine counts of the concurrency
+// sweep, in the given order and without duplicates.
+func parseSweep(list string) ([]int, error) {
+	levels := []int{}
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		level, err := strconv.Atoi(part)
+		if err != nil || level < 1 {
+			return nil, fmt.Errorf("invalid sweep concurrency %q, must be a positive integer", part)
+		}
+		if !slices.Contains(levels, level) {
+			levels = append(levels, level)
+		}
+	}
+	return levels, nil
+}
+
+// randomSeed returns a random seed. It is below 2^53 so it is kept exactly
+// by the JSON parsers that use float64 numbers.
+func randomSeed() uint64 {
+	return rand.Uint64N(1 << 53)
+}
@@ -0,0 +1,282 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlitebench"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "http://localhost:9876", cfg.NsqliteDSN)
+		assert.Empty(t, cfg.SqlitePath)
+		assert.Equal(t, []string{DriverMattn, DriverSqlitec, DriverNsqlite}, cfg.ParsedDrivers)
+		assert.False(t, cfg.Yes)
+		assert.False(t, cfg.Quiet)
+		assert.False(t, cfg.ReadOnly)
+		assert.Equal(t, SchemaIndexed, cfg.Schema)
+		assert.False(t, cfg.Force)
+		assert.Equal(t, OutputTable, cfg.Output)
+		assert.Empty(t, cfg.OutputFile)
+		assert.Equal(t, 10*time.Second, cfg.Duration)
+		assert.Zero(t, cfg.Soak)
+		assert.Equal(t, 10*time.Second, cfg.SoakInterval)
+		assert.Equal(t, Mix{PointReads: 70, RangeReads: 20, Inserts: 5, Updates: 5}, cfg.ParsedMix)
+		assert.Equal(t, []int{1 << 10, 64 << 10, 1 << 20, 8 << 20}, cfg.ParsedLargeSizes)
+		assert.Equal(t, 0, cfg.Warmup)
+		assert.Equal(t, 1, cfg.Repeat)
+		assert.Equal(t, 10.0, cfg.Noise)
+		assert.Empty(t, cfg.ParsedSweep)
+		assert.Equal(t, BenchmarkSimple, cfg.SweepBench)
+		assert.Less(t, cfg.ParsedSeed, uint64(1<<53))
+	})
+
+	t.Run("All flags", func(t *testing.T) {
+		cfg, err := Parse([]string{
+			"nsqlitebench",
+			"--nsqlite-dsn", "https://db.example.com:9000?authToken=secret",
+			"--sqlite-path", "/tmp/bench.sqlite",
+			"--drivers", "nsqlite",
+			"--yes",
+			"--quiet",
+			"--force",
+			"--output", "json",
+			"--output-file", "results.json",
+			"--duration", "1m30s",
+			"--mix", "99, 0, 1, 0",
+			"--large-sizes", "100,2KiB",
+			"--warmup", "2",
+			"--repeat", "5",
+			"--noise-threshold", "2.5",
+			"--sweep-concurrency", "1,2,4",
+			"--sweep-benchmark", "Mixed",
+			"--seed", "0",
+			"--schema", "WAL-heavy",
+		}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, "https://db.example.com:9000?authToken=secret", cfg.NsqliteDSN)
+		assert.Equal(t, "/tmp/bench.sqlite", cfg.SqlitePath)
+		assert.Equal(t, []string{DriverNsqlite}, cfg.ParsedDrivers)
+		assert.True(t, cfg.Has(DriverNsqlite))
+		assert.False(t, cfg.Has(DriverMattn))
+		assert.True(t, cfg.Yes)
+		assert.True(t, cfg.Quiet)
+		assert.True(t, cfg.Force)
+		assert.Equal(t, OutputJSON, cfg.Output)
+		assert.Equal(t, "results.json", cfg.OutputFile)
+		assert.Equal(t, 90*time.Second, cfg.Duration)
+		assert.Equal(t, Mix{PointReads: 99, Inserts: 1}, cfg.ParsedMix)
+		assert.Equal(t, []int{100, 2048}, cfg.ParsedLargeSizes)
+		assert.Equal(t, 2, cfg.Warmup)
+		assert.Equal(t, 5, cfg.Repeat)
+		assert.Equal(t, 2.5, cfg.Noise)
+		assert.Equal(t, []int{1, 2, 4}, cfg.ParsedSweep)
+		assert.Equal(t, BenchmarkMixed, cfg.SweepBench)
+		assert.Equal(t, uint64(0), cfg.ParsedSeed)
+		assert.Equal(t, SchemaWALHeavy, cfg.Schema)
+	})
+
+	t.Run("Invalid repeat", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlitebench", "--repeat", "0"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid repeat")
+
+		_, err = Parse([]string{"nsqlitebench", "--warmup", "-1"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid warmup")
+	})
+
+	t.Run("Read-only", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlitebench", "--read-only", "--sqlite-path", "bench.sqlite"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.True(t, cfg.ReadOnly)
+
+		_, err = Parse([]string{"nsqlitebench", "--read-only", "--drivers", "nsqlite"}, &bytes.Buffer{})
+		assert.NoError(t, err, "the NSQLite server has a default DSN")
+
+		_, err = Parse([]string{"nsqlitebench", "--read-only"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "need --sqlite-path")
+
+		_, err = Parse([]string{
+			"nsqlitebench", "--read-only", "--drivers", "nsqlite", "--sweep-concurrency", "1,2",
+		}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid read-only mode")
+	})
+
+	t.Run("Soak", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlitebench", "--soak", "30m", "--soak-interval", "1m"}, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Minute, cfg.Soak)
+		assert.Equal(t, time.Minute, cfg.SoakInterval)
+
+		_, err = Parse([]string{"nsqlitebench", "--soak", "-1s"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid soak duration")
+
+		_, err = Parse([]string{"nsqlitebench", "--soak", "5s", "--soak-interval", "10s"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid soak interval")
+
+		_, err = Parse([]string{"nsqlitebench", "--soak", "1m", "--sweep-concurrency", "1,2"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "cannot run with the concurrency sweep")
+
+		_, err = Parse([]string{"nsqlitebench", "--soak", "1m", "--read-only", "--drivers", "nsqlite"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "the mixed workload writes")
+	})
+
+	t.Run("Invalid sweep benchmark", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlitebench", "--sweep-benchmark", "prepared"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid sweep benchmark")
+	})
+
+	t.Run("Invalid schema", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlitebench", "--schema", "unique"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "valid values are: plain, indexed, wal-heavy")
+	})
+
+	t.Run("Invalid duration", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlitebench", "--duration", "0s"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid duration")
+	})
+
+	t.Run("Invalid output", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlitebench", "--output", "xml"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "valid values are: table, json, csv")
+	})
+
+	t.Run("Help", func(t *testing.T) {
+		out := bytes.Buffer{}
+		_, err := Parse([]string{"nsqlitebench", "--help"}, &out)
+		assert.ErrorIs(t, err, ErrHelpShown)
+		assert.Contains(t, out.String(), "--drivers")
+		assert.Contains(t, out.String(), "nsqlitebench compare --help")
+	})
+
+	t.Run("Unknown flag", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlitebench", "--unknown"}, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "run with --help for usage")
+	})
+
+	t.Run("Empty DSN", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlitebench", "--nsqlite-dsn", " "}, &bytes.Buffer{})
+		assert.Error(t, err)
+
+		_, err = Parse([]string{"nsqlitebench", "--nsqlite-dsn", "", "--drivers", "mattn"}, &bytes.Buffer{})
+		assert.NoError(t, err, "the DSN is not used without the nsqlite driver")
+	})
+}
+
+func TestParseDrivers(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{list: "mattn,nsqlite", want: []string{"mattn", "nsqlite"}},
+		{list: "nsqlite, mattn", want: []string{"mattn", "nsqlite"}},
+		{list: "NSQLITE,nsqlite,", want: []string{"nsqlite"}},
+		{list: "mattn", want: []string{"mattn"}},
+		{list: "nsqlite,sqlitec,mattn", want: []string{"mattn", "sqlitec", "nsqlite"}},
+		{list: "", wantErr: true},
+		{list: " , ", wantErr: true},
+		{list: "mattn,postgres", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			drivers, err := parseDrivers(tt.list)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, drivers)
+		})
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    Mix
+		wantErr bool
+	}{
+		{list: "90,5,3,2", want: Mix{PointReads: 90, RangeReads: 5, Inserts: 3, Updates: 2}},
+		{list: " 0, 0, 1 ,0 ", want: Mix{Inserts: 1}},
+		{list: "0,0,0,0", wantErr: true},
+		{list: "90,10", wantErr: true},
+		{list: "90,5,3,-2", wantErr: true},
+		{list: "90,5,3,x", wantErr: true},
+		{list: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			mix, err := parseMix(tt.list)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, mix)
+		})
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []int
+		wantErr bool
+	}{
+		{list: "1KB,64KB,1MB,8MB", want: []int{1 << 10, 64 << 10, 1 << 20, 8 << 20}},
+		{list: " 10 , 1k,", want: []int{10, 1024}},
+		{list: "0", wantErr: true},
+		{list: "1GB", wantErr: true},
+		{list: "1KB,large", wantErr: true},
+		{list: " , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			sizes, err := parseSizes(tt.list)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sizes)
+		})
+	}
+}
+
+func TestParseSweep(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []int
+		wantErr bool
+	}{
+		{list: "", want: []int{}},
+		{list: "1,2,4,8,16,32", want: []int{1, 2, 4, 8, 16, 32}},
+		{list: " 8, 2 ,8,", want: []int{8, 2}},
+		{list: "0", wantErr: true},
+		{list: "1,two", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			levels, err := parseSweep(tt.list)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, levels)
+		})
+	}
+}
@@ -1,11 +1,28 @@
 package nsqlitebench
 
+import (
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+)
+
 // benchmarksConfig holds all parameters for each benchmark.
 type benchmarksConfig struct {
+	// seed is the seed of the generated data.
+	seed uint64
+	// schema is the schema of the benchmark tables, one of the
+	// config.Schema* values.
+	schema string
+
 	benchmarkSimpleConfig
 	benchmarkComplexConfig
 	benchmarkManyConfig
 	benchmarkLargeConfig
+	benchmarkMixedConfig
+	benchmarkPreparedConfig
+	benchmarkPointReadsConfig
+	benchmarkHTTPConfig
+	benchmarkBulkConfig
 }
 
 func getMattnConfig() benchmarksConfig {
@@ -36,13 +53,48 @@ func getMattnConfig() benchmarksConfig {
 
 		benchmarkLargeConfig: benchmarkLargeConfig{
 			insertXUsers:     10_000,
-			insertYBytes:     10_000,
+			maxBytes:         100 << 20,
+			payloadSizes:     []int{1 << 10, 64 << 10, 1 << 20, 8 << 20},
 			insertGoroutines: insertGoroutines,
 		},
+
+		benchmarkMixedConfig: benchmarkMixedConfig{
+			seedUsers:  10_000,
+			goroutines: 50,
+			rangeSize:  100,
+			duration:   10 * time.Second,
+			mix:        config.Mix{PointReads: 70, RangeReads: 20, Inserts: 5, Updates: 5},
+		},
+
+		benchmarkPreparedConfig: benchmarkPreparedConfig{
+			insertXUsers: 10_000,
+			queryYUsers:  20_000,
+		},
+
+		benchmarkPointReadsConfig: benchmarkPointReadsConfig{
+			reads:      200_000,
+			goroutines: queryGoroutines,
+		},
+
+		benchmarkBulkConfig: benchmarkBulkConfig{
+			rows:             50_000,
+			rowsPerStatement: 100,
+		},
 	}
 }
 
 func getNsqliteConfig() benchmarksConfig {
+	mattnConfig := getMattnConfig()
+	mattnConfig.benchmarkHTTPConfig = benchmarkHTTPConfig{
+		statements: 100_000,
+		batchSizes: []int{1, 10, 100},
+		inFlight:   50,
+	}
+	mattnConfig.benchmarkBulkConfig.batchRows = 1_000
+	return mattnConfig
+}
+
+func getSqlitecConfig() benchmarksConfig {
 	mattnConfig := getMattnConfig()
 	return mattnConfig
 }
@@ -0,0 +1,101 @@
+package nsqlitebench
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// baseCreated is the earliest created timestamp of the generated rows, so
+// they do not depend on when the benchmark runs.
+var baseCreated = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+// createdSpan is the span in seconds of the generated created timestamps.
+const createdSpan = 365 * 24 * 60 * 60
+
+// dataGenerator generates the data of a benchmark from the seed of the run,
+// so every driver inserts the same data and runs with the same seed are
+// reproducible.
+//
+// The values of each index are derived from the seed and the index alone,
+// so they do not depend on the order in which the goroutines of the
+// benchmark ask for them.
+type dataGenerator struct {
+	seed   uint64
+	stream uint64
+}
+
+// newDataGenerator returns the generator of the named stream of values of
+// the seed, different streams of the same seed are independent.
+func newDataGenerator(seed uint64, stream string) dataGenerator {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(stream))
+	return dataGenerator{seed: seed, stream: h.Sum64()}
+}
+
+// rand returns the source of random values of the index.
+func (g dataGenerator) rand(idx int) *rand.Rand {
+	return rand.New(rand.NewPCG(g.seed^g.stream, uint64(idx)))
+}
+
+// generatedUser are the values of a row of the users table.
+type generatedUser struct {
+	created int64
+	email   string
+	active  int
+}
+
+// user returns the user of the index. The emails of the indexes are unique,
+// for the unique index of the indexed schemas.
+func (g dataGenerator) user(idx int) generatedUser {
+	rng := g.rand(idx)
+	return generatedUser{
+		created: randomCreated(rng),
+		email:   fmt.Sprintf("%s%d@example.com", randomText(rng, 6, 12), idx),
+		active:  rng.IntN(2),
+	}
+}
+
+// created returns the created timestamp of the index.
+func (g dataGenerator) created(idx int) int64 {
+	return randomCreated(g.rand(idx))
+}
+
+// text returns the text of the index, with a length between minLen and
+// maxLen.
+func (g dataGenerator) text(idx int, minLen int, maxLen int) string {
+	return randomText(g.rand(idx), minLen, maxLen)
+}
+
+// id returns the ID of the index, between 1 and n.
+func (g dataGenerator) id(idx int, n int) int {
+	return g.rand(idx).IntN(max(n, 1)) + 1
+}
+
+// randomCreated returns a created timestamp within a year of baseCreated.
+func randomCreated(rng *rand.Rand) int64 {
+	return baseCreated + rng.Int64N(createdSpan)
+}
+
+// randomEmail returns an email with a random user name.
+func randomEmail(rng *rand.Rand) string {
+	return randomText(rng, 6, 12) + "@example.com"
+}
+
+// randomText returns lowercase letters with a length between minLen and
+// maxLen.
+func randomText(rng *rand.Rand, minLen int, maxLen int) string {
+	n := minLen
+	if maxLen > minLen {
+		n += rng.IntN(maxLen - minLen + 1)
+	}
+
+	var sb strings.Builder
+	sb.Grow(n)
+	for range n {
+		sb.WriteByte(byte('a' + rng.IntN(26)))
+	}
+	return sb.String()
+}
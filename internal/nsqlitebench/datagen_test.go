@@ -0,0 +1,59 @@
+package nsqlitebench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// generateStream returns values of every kind of the generator.
+func generateStream(g dataGenerator) []any {
+	values := []any{}
+	for idx := range 200 {
+		values = append(values, g.user(idx), g.created(idx), g.text(idx, 10, 50), g.id(idx, 1000))
+	}
+	return values
+}
+
+func TestDataGenerator(t *testing.T) {
+	t.Run("Same seed, same stream", func(t *testing.T) {
+		assert.Equal(t,
+			generateStream(newDataGenerator(42, "simple users")),
+			generateStream(newDataGenerator(42, "simple users")),
+		)
+	})
+
+	t.Run("Different seeds or streams", func(t *testing.T) {
+		stream := generateStream(newDataGenerator(42, "simple users"))
+		assert.NotEqual(t, stream, generateStream(newDataGenerator(43, "simple users")))
+		assert.NotEqual(t, stream, generateStream(newDataGenerator(42, "many users")))
+	})
+
+	t.Run("Values do not depend on the order", func(t *testing.T) {
+		g := newDataGenerator(7, "users")
+		last := g.user(99)
+		for idx := range 99 {
+			g.user(idx)
+		}
+		assert.Equal(t, last, g.user(99))
+	})
+
+	t.Run("Ranges", func(t *testing.T) {
+		g := newDataGenerator(1, "ranges")
+		for idx := range 1000 {
+			user := g.user(idx)
+			assert.GreaterOrEqual(t, user.created, baseCreated)
+			assert.Less(t, user.created, baseCreated+createdSpan)
+			assert.Contains(t, []int{0, 1}, user.active)
+			assert.Regexp(t, `^[a-z]{6,12}\d+@example\.com$`, user.email)
+
+			text := g.text(idx, 3, 5)
+			assert.GreaterOrEqual(t, len(text), 3)
+			assert.LessOrEqual(t, len(text), 5)
+
+			id := g.id(idx, 10)
+			assert.GreaterOrEqual(t, id, 1)
+			assert.LessOrEqual(t, id, 10)
+		}
+	})
+}
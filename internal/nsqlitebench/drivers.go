@@ -1,34 +1,89 @@
 package nsqlitebench
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
-	_ "github.com/mattn/go-sqlite3"
-	_ "github.com/nsqlite/nsqlitego"
+	"net/http"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+	"github.com/nsqlite/nsqlitego"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
 )
 
-func createMattnDriver(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+// createNsqliteDriver opens the NSQLite database of the server with the
+// given connection string. The connector is created from its own client
+// because the one of sql.Open is shared by every DSN.
+func createNsqliteDriver(dsn string) (*sql.DB, error) {
+	// The transport is the default one of the client, with its connections
+	// counting the bytes sent and received for the resource usage.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 100
+	transport.DialContext = resources.CountingDialer(transport.DialContext)
+
+	client, err := nsqlitehttp.NewClient(dsn, nsqlitehttp.WithHTTPTransport(transport))
 	if err != nil {
 		return nil, err
 	}
 
+	db := sql.OpenDB(nsqlitego.NewConnector(client))
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
 
-func createNsqliteDriver(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("nsqlite", dsn)
+// errDatabaseNotEmpty is returned by checkEmptyDatabase when the database
+// has tables or views.
+var errDatabaseNotEmpty = errors.New("database is not empty")
+
+// checkEmptyDatabase returns an error wrapping errDatabaseNotEmpty if the
+// database has any table or view, so the benchmark doesn't modify a
+// database that may be important.
+func checkEmptyDatabase(ctx context.Context, db *sql.DB) error {
+	var count int64
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+	`).Scan(&count)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to list the tables: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, err
+	if count > 0 {
+		return fmt.Errorf("%w, it has %d tables or views", errDatabaseNotEmpty, count)
+	}
+	return nil
+}
+
+// sqliteVersion returns the version of SQLite used by the database.
+func sqliteVersion(ctx context.Context, db *sql.DB) (string, error) {
+	var version string
+	if err := db.QueryRowContext(ctx, `SELECT sqlite_version()`).Scan(&version); err != nil {
+		return "", err
 	}
+	return version, nil
+}
 
-	return db, nil
+// nsqliteServerVersion returns the version of the NSQLite server with the
+// given connection string.
+func nsqliteServerVersion(ctx context.Context, dsn string) (string, error) {
+	connStr, err := nsqlitedsn.NewConnStrFromText(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid connection string: %w", err)
+	}
+
+	info, err := apiclient.NewClient(connStr).RemoteVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return info.Server, nil
 }
@@ -0,0 +1,41 @@
+//go:build !sqlitec
+
+package nsqlitebench
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+)
+
+// localDrivers are the local drivers built into nsqlitebench. mattn/go-sqlite3
+// and sqlitec both embed SQLite, so they cannot be linked together and
+// sqlitec is only built with -tags sqlitec.
+var localDrivers = []localDriver{
+	{
+		name:           config.DriverMattn,
+		create:         createMattnDriver,
+		createReadOnly: createMattnReadOnlyDriver,
+		cfg:            getMattnConfig(),
+	},
+}
+
+func createMattnDriver(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// createMattnReadOnlyDriver opens the SQLite database at dbPath in read-only
+// mode, with query_only so SQLite refuses any write.
+func createMattnReadOnlyDriver(dbPath string) (*sql.DB, error) {
+	return createMattnDriver("file:" + dbPath + "?mode=ro&_query_only=true")
+}
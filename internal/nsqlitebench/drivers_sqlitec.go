@@ -0,0 +1,57 @@
+//go:build sqlitec
+
+package nsqlitebench
+
+import (
+	"database/sql"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitedrv"
+)
+
+// localDrivers are the local drivers built into nsqlitebench. mattn/go-sqlite3
+// and sqlitec both embed SQLite, so they cannot be linked together and
+// building with -tags sqlitec replaces mattn/go-sqlite3 with sqlitec.
+var localDrivers = []localDriver{
+	{
+		name:           config.DriverSqlitec,
+		create:         createSqlitecDriver,
+		createReadOnly: createSqlitecReadOnlyDriver,
+		cfg:            getSqlitecConfig(),
+	},
+}
+
+// createSqlitecDriver opens the SQLite database at dbPath with the sqlitec
+// wrapper used by the NSQLite server, with the same busy timeout and
+// foreign keys as mattn/go-sqlite3.
+func createSqlitecDriver(dbPath string) (*sql.DB, error) {
+	return openSqlitecDriver(dbPath, []string{
+		"PRAGMA BUSY_TIMEOUT = 5000;",
+		"PRAGMA FOREIGN_KEYS = true;",
+	})
+}
+
+// createSqlitecReadOnlyDriver opens the SQLite database at dbPath with
+// query_only, so SQLite refuses any write.
+func createSqlitecReadOnlyDriver(dbPath string) (*sql.DB, error) {
+	return openSqlitecDriver(dbPath, []string{
+		"PRAGMA BUSY_TIMEOUT = 5000;",
+		"PRAGMA QUERY_ONLY = true;",
+	})
+}
+
+// openSqlitecDriver opens the SQLite database at dbPath and runs the
+// queries on each new connection.
+func openSqlitecDriver(dbPath string, postConnectQueries []string) (*sql.DB, error) {
+	db := sql.OpenDB(sqlitedrv.NewConnector(
+		dbPath,
+		sqlitedrv.WithPostConnectQueries(postConnectQueries),
+	))
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,123 @@
+package nsqlitebench
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStubServer returns a stub NSQLite server that reports the given number
+// of tables in its database.
+func newStubServer(t *testing.T, tables int) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Server", "NSQLite")
+		switch r.URL.Path {
+		case "/health":
+			_, _ = w.Write([]byte("OK"))
+		case "/query":
+			var queries []struct {
+				Query string `json:"query"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&queries))
+			require.Len(t, queries, 1)
+			assert.Contains(t, queries[0].Query, "sqlite_master")
+
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"results": []map[string]any{{
+					"columns": []string{"COUNT(*)"},
+					"types":   []string{"INTEGER"},
+					"rows":    [][]any{{tables}},
+				}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestOpenDriver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Empty server", func(t *testing.T) {
+		ts := newStubServer(t, 0)
+
+		db, err := openDriver(ctx, createNsqliteDriver, ts.URL, false)
+		require.NoError(t, err)
+		db.Close()
+	})
+
+	t.Run("Server with tables", func(t *testing.T) {
+		ts := newStubServer(t, 3)
+
+		_, err := openDriver(ctx, createNsqliteDriver, ts.URL, false)
+		assert.ErrorIs(t, err, errDatabaseNotEmpty)
+		assert.ErrorContains(t, err, "it has 3 tables or views, run with --force")
+
+		db, err := openDriver(ctx, createNsqliteDriver, ts.URL, true)
+		require.NoError(t, err, "--force skips the check")
+		db.Close()
+	})
+
+	t.Run("Unreachable server", func(t *testing.T) {
+		ts := newStubServer(t, 0)
+		ts.Close()
+
+		_, err := openDriver(ctx, createNsqliteDriver, ts.URL, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("SQLite file with tables", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bench.sqlite")
+
+		create := localDrivers[0].create
+		db, err := openDriver(ctx, create, path, false)
+		require.NoError(t, err)
+		_, err = db.Exec(`CREATE TABLE important (id INTEGER)`)
+		require.NoError(t, err)
+		db.Close()
+
+		_, err = openDriver(ctx, create, path, false)
+		assert.ErrorIs(t, err, errDatabaseNotEmpty)
+		assert.True(t, strings.Contains(err.Error(), "1 tables"))
+	})
+}
+
+func TestLocalDrivers(t *testing.T) {
+	for _, local := range localDrivers {
+		t.Run(local.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bench.sqlite")
+
+			db, err := local.create(path)
+			require.NoError(t, err)
+			defer db.Close()
+
+			require.NoError(t, recreateSchema(db, config.SchemaIndexed))
+			_, err = db.Exec(`INSERT INTO users (created, email, active) VALUES (?, ?, ?)`, time.Now().Unix(), "user@example.com", 1)
+			require.NoError(t, err)
+
+			var count int
+			require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+			assert.Equal(t, 1, count)
+			assert.FileExists(t, path)
+		})
+	}
+}
+
+func TestLocalDBPath(t *testing.T) {
+	assert.Equal(t, "/tmp/bench-mattn.sqlite", localDBPath("/tmp/bench.sqlite", "mattn"))
+	assert.Equal(t, "data/bench-sqlitec", localDBPath("data/bench", "sqlitec"))
+}
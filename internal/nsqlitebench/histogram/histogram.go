@@ -0,0 +1,151 @@
+// Package histogram records latencies in a histogram with bounded memory to
+// report their percentiles.
+//
+// Values are stored in buckets that split every power of two in subBuckets
+// linear parts, so a value is reported with a relative error below
+// 1/subBuckets no matter how many values are recorded.
+package histogram
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+const (
+	// subBucketBits is log2 of subBuckets.
+	subBucketBits = 5
+	// subBuckets is the number of buckets of every power of two.
+	subBuckets = 1 << subBucketBits
+	// numBuckets is the number of buckets needed for any positive int64.
+	numBuckets = (64 - subBucketBits) * subBuckets
+)
+
+// Histogram is a histogram of durations, it is safe for concurrent use.
+type Histogram struct {
+	mu     sync.Mutex
+	counts [numBuckets]uint64
+	count  uint64
+	min    time.Duration
+	max    time.Duration
+}
+
+// New returns an empty histogram.
+func New() *Histogram {
+	return &Histogram{}
+}
+
+// Record adds a duration to the histogram, negative durations are recorded
+// as zero.
+func (h *Histogram) Record(d time.Duration) {
+	d = max(d, 0)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[bucketIndex(uint64(d))]++
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	h.max = max(h.max, d)
+	h.count++
+}
+
+// Merge adds the durations recorded in other to the histogram.
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other == h {
+		return
+	}
+
+	other.mu.Lock()
+	counts, count, otherMin, otherMax := other.counts, other.count, other.min, other.max
+	other.mu.Unlock()
+
+	if count == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, c := range counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || otherMin < h.min {
+		h.min = otherMin
+	}
+	h.max = max(h.max, otherMax)
+	h.count += count
+}
+
+// Count returns the number of recorded durations.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Min returns the smallest recorded duration, or zero if the histogram is
+// empty.
+func (h *Histogram) Min() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.min
+}
+
+// Max returns the largest recorded duration, or zero if the histogram is
+// empty.
+func (h *Histogram) Max() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.max
+}
+
+// Percentile returns the duration below or equal to which p percent of the
+// recorded durations are, with p between 0 and 100. It returns the upper
+// bound of the bucket of the duration, capped to the recorded range, and
+// zero if the histogram is empty.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+
+	p = min(max(p, 0), 100)
+	rank := uint64(math.Ceil(p / 100 * float64(h.count)))
+	rank = max(rank, 1)
+
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen >= rank {
+			value := time.Duration(bucketUpperBound(i))
+			return min(max(value, h.min), h.max)
+		}
+	}
+	return h.max
+}
+
+// bucketIndex returns the index of the bucket of v. Values below subBuckets
+// have their own bucket, larger ones are shifted so that their subBucketBits
+// + 1 most significant bits pick the bucket.
+func bucketIndex(v uint64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - subBucketBits - 1
+	return (shift+1)*subBuckets + int(v>>shift) - subBuckets
+}
+
+// bucketUpperBound returns the largest value of the bucket at index i.
+func bucketUpperBound(i int) uint64 {
+	if i < subBuckets {
+		return uint64(i)
+	}
+	shift := i/subBuckets - 1
+	sub := uint64(i%subBuckets + subBuckets)
+	return (sub+1)<<shift - 1
+}
@@ -0,0 +1,130 @@
+package histogram
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuckets(t *testing.T) {
+	for _, v := range []uint64{0, 1, 31, 32, 33, 63, 64, 65, 1000, 123456789, math.MaxInt64} {
+		i := bucketIndex(v)
+		assert.Less(t, i, numBuckets, v)
+		assert.GreaterOrEqual(t, bucketUpperBound(i), v, v)
+		if i > 0 {
+			assert.Less(t, bucketUpperBound(i-1), v, "%d is in the first bucket that fits it", v)
+		}
+	}
+
+	for v := uint64(0); v < subBuckets; v++ {
+		assert.Equal(t, v, bucketUpperBound(bucketIndex(v)), "small values are exact")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		h := New()
+		assert.Equal(t, time.Duration(0), h.Percentile(50))
+		assert.Equal(t, time.Duration(0), h.Max())
+		assert.Equal(t, uint64(0), h.Count())
+	})
+
+	t.Run("Single value", func(t *testing.T) {
+		h := New()
+		h.Record(1234 * time.Microsecond)
+		for _, p := range []float64{0, 50, 99, 100} {
+			assert.Equal(t, 1234*time.Microsecond, h.Percentile(p))
+		}
+	})
+
+	t.Run("Uniform values", func(t *testing.T) {
+		h := New()
+		for i := 1; i <= 10000; i++ {
+			h.Record(time.Duration(i) * time.Microsecond)
+		}
+
+		assert.Equal(t, uint64(10000), h.Count())
+		assert.Equal(t, time.Microsecond, h.Min())
+		assert.Equal(t, 10000*time.Microsecond, h.Max())
+		assert.Equal(t, 10000*time.Microsecond, h.Percentile(100))
+
+		for _, p := range []float64{50, 95, 99} {
+			want := p * 100 * float64(time.Microsecond)
+			got := float64(h.Percentile(p))
+			assert.GreaterOrEqual(t, got, want, "p%v", p)
+			assert.InEpsilon(t, want, got, 1.0/subBuckets, "p%v", p)
+		}
+	})
+
+	t.Run("Tail latency", func(t *testing.T) {
+		h := New()
+		for range 98 {
+			h.Record(time.Millisecond)
+		}
+		h.Record(time.Second)
+		h.Record(2 * time.Second)
+
+		assert.InEpsilon(t, float64(time.Millisecond), float64(h.Percentile(50)), 1.0/subBuckets)
+		assert.InEpsilon(t, float64(time.Second), float64(h.Percentile(99)), 1.0/subBuckets)
+		assert.Equal(t, 2*time.Second, h.Percentile(100))
+	})
+
+	t.Run("Negative durations are zero", func(t *testing.T) {
+		h := New()
+		h.Record(-time.Second)
+		assert.Equal(t, time.Duration(0), h.Max())
+		assert.Equal(t, uint64(1), h.Count())
+	})
+}
+
+func TestMerge(t *testing.T) {
+	a := New()
+	b := New()
+	all := New()
+	for i := 1; i <= 1000; i++ {
+		d := time.Duration(i*i) * time.Microsecond
+		all.Record(d)
+		if i%3 == 0 {
+			a.Record(d)
+		} else {
+			b.Record(d)
+		}
+	}
+
+	merged := New()
+	merged.Merge(a)
+	merged.Merge(b)
+	merged.Merge(nil)
+	merged.Merge(New())
+
+	assert.Equal(t, all.Count(), merged.Count())
+	assert.Equal(t, all.Min(), merged.Min())
+	assert.Equal(t, all.Max(), merged.Max())
+	for _, p := range []float64{1, 50, 95, 99, 99.9} {
+		assert.Equal(t, all.Percentile(p), merged.Percentile(p), "p%v", p)
+	}
+
+	merged.Merge(merged)
+	assert.Equal(t, all.Count(), merged.Count(), "merging into itself is a no-op")
+}
+
+func TestConcurrentRecord(t *testing.T) {
+	h := New()
+	wg := sync.WaitGroup{}
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				h.Record(time.Duration(g*1000+i) * time.Microsecond)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(8000), h.Count())
+	assert.Equal(t, 7999*time.Microsecond, h.Max())
+}
@@ -0,0 +1,211 @@
+// Package httpbench benchmarks the HTTP protocol of the NSQLite server. It
+// sends the statements to /query directly, without database/sql and the
+// nsqlitego driver, to separate the cost of the protocol from the cost of
+// the driver.
+package httpbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// statement is the statement sent by the benchmark, it is cheap to run so
+// the protocol is measured rather than the storage.
+const statement = "SELECT ?"
+
+// Bar shows the progress of the requests of a run.
+type Bar interface {
+	Inc()
+	Finish()
+}
+
+// Config are the parameters of the benchmark.
+type Config struct {
+	// Statements is the number of statements sent with each batch size.
+	Statements int
+	// BatchSizes are the numbers of statements sent in each request.
+	BatchSizes []int
+	// InFlight is the number of requests sent at once.
+	InFlight int
+	// NewBar returns the progress bar of a run with the given number of
+	// requests, nil to not show the progress.
+	NewBar func(description string, requests int) Bar
+}
+
+// Result is the outcome of the statements sent with a batch size, either
+// over kept-alive connections or with a new connection for each request.
+type Result struct {
+	BatchSize  int
+	KeepAlive  bool
+	Statements int
+	Requests   int
+	Duration   time.Duration
+	// Latency are the latencies of the requests.
+	Latency *histogram.Histogram
+	// Resources are the resources used by the process while sending the
+	// statements.
+	Resources resources.Usage
+}
+
+// StatementsPerSec returns the statements run per second.
+func (r Result) StatementsPerSec() float64 {
+	if seconds := r.Duration.Seconds(); seconds > 0 {
+		return float64(r.Statements) / seconds
+	}
+	return 0
+}
+
+// Run sends the statements with each batch size to the NSQLite server with
+// the given connection string, first over kept-alive connections and then
+// with a new connection for each request.
+func Run(ctx context.Context, dsn string, cfg Config) ([]Result, error) {
+	connStr, err := nsqlitedsn.NewConnStrFromText(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection string: %w", err)
+	}
+
+	results := []Result{}
+	for _, keepAlive := range []bool{true, false} {
+		for _, batchSize := range cfg.BatchSizes {
+			result, err := run(ctx, connStr, cfg, batchSize, keepAlive)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// run sends the statements in batches of batchSize, with up to
+// cfg.InFlight requests at once.
+func run(
+	ctx context.Context, connStr *nsqlitedsn.ConnStr, cfg Config, batchSize int, keepAlive bool,
+) (Result, error) {
+	batchSize = max(batchSize, 1)
+	inFlight := max(cfg.InFlight, 1)
+
+	client, closeClient := newClient(connStr, inFlight, keepAlive)
+	defer closeClient()
+
+	requests := (cfg.Statements + batchSize - 1) / batchSize
+	var bar Bar = noBar{}
+	if cfg.NewBar != nil {
+		bar = cfg.NewBar(
+			fmt.Sprintf("Sending %d statements in %s", cfg.Statements, Name(batchSize, keepAlive)), requests,
+		)
+	}
+	latency := histogram.New()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error
+	errOnce := sync.Once{}
+
+	start := time.Now()
+	startResources := resources.Take()
+	wg := sync.WaitGroup{}
+	ch := make(chan bool, inFlight)
+
+	for request := range requests {
+		wg.Add(1)
+		ch <- true
+		go func() {
+			defer func() {
+				wg.Done()
+				<-ch
+			}()
+
+			first := request * batchSize
+			queries := make([]nsqlitehttp.Query, 0, batchSize)
+			for idx := first; idx < min(first+batchSize, cfg.Statements); idx++ {
+				queries = append(queries, nsqlitehttp.Query{
+					Query:  statement,
+					Params: []nsqlitehttp.QueryParam{{Value: idx}},
+				})
+			}
+
+			opStart := time.Now()
+			if err := send(ctx, client, queries); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			latency.Record(time.Since(opStart))
+
+			bar.Inc()
+		}()
+	}
+
+	wg.Wait()
+	close(ch)
+	bar.Finish()
+
+	if firstErr != nil {
+		return Result{}, fmt.Errorf("error sending batches of %d statements: %w", batchSize, firstErr)
+	}
+	return Result{
+		BatchSize:  batchSize,
+		KeepAlive:  keepAlive,
+		Statements: cfg.Statements,
+		Requests:   requests,
+		Duration:   time.Since(start),
+		Latency:    latency,
+		Resources:  resources.Take().Since(startResources),
+	}, nil
+}
+
+// newClient returns a client of the server with up to inFlight idle
+// connections, which are reused if keepAlive is true. The bytes of its
+// connections are counted in the resource usage. The returned function
+// closes its idle connections.
+func newClient(connStr *nsqlitedsn.ConnStr, inFlight int, keepAlive bool) (*apiclient.Client, func()) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = inFlight
+	transport.MaxIdleConnsPerHost = inFlight
+	transport.DisableKeepAlives = !keepAlive
+	transport.DialContext = resources.CountingDialer(transport.DialContext)
+	return apiclient.NewClient(connStr, apiclient.WithTransport(transport)), transport.CloseIdleConnections
+}
+
+// send sends the queries in a single request and fails if any of them
+// fails.
+func send(ctx context.Context, client *apiclient.Client, queries []nsqlitehttp.Query) error {
+	results, err := client.SendQueries(ctx, queries)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			return errors.New(result.Error)
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the results of a batch size.
+func Name(batchSize int, keepAlive bool) string {
+	connections := "keep-alive"
+	if !keepAlive {
+		connections = "new connections"
+	}
+	return fmt.Sprintf("batches of %d (%s)", batchSize, connections)
+}
+
+// noBar is the progress bar used when Config.NewBar is nil.
+type noBar struct{}
+
+func (noBar) Inc()    {}
+func (noBar) Finish() {}
@@ -0,0 +1,179 @@
+package httpbench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/server"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer starts an NSQLite server with a new database and returns
+// its URL.
+func newTestServer(t *testing.T) string {
+	t.Helper()
+
+	logger := log.NewLogger(io.Discard)
+	dbStats := stats.NewDBStats()
+	t.Cleanup(dbStats.Close)
+
+	dbInstance, err := db.NewDB(db.Config{
+		Logger:        logger,
+		DBStats:       dbStats,
+		DataDirectory: t.TempDir(),
+		TxIdleTimeout: 10 * time.Second,
+		BusyTimeout:   5 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbInstance.Close() })
+
+	s, err := server.NewServer(server.Config{Logger: logger, DBStats: dbStats, DB: dbInstance})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+// countingBar counts the increments of a progress bar.
+type countingBar struct {
+	incs     *int
+	finished *bool
+}
+
+func (b countingBar) Inc()    { *b.incs++ }
+func (b countingBar) Finish() { *b.finished = true }
+
+func TestRun(t *testing.T) {
+	url := newTestServer(t)
+
+	incs := map[string]*int{}
+	finished := map[string]*bool{}
+	cfg := Config{
+		Statements: 250,
+		BatchSizes: []int{1, 10, 100},
+		// A single request at once, so the bar is incremented sequentially.
+		InFlight: 1,
+		NewBar: func(description string, requests int) Bar {
+			incs[description], finished[description] = new(int), new(bool)
+			return countingBar{incs: incs[description], finished: finished[description]}
+		},
+	}
+
+	results, err := Run(context.Background(), url, cfg)
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+
+	wantRequests := map[int]int{1: 250, 10: 25, 100: 3}
+	for i, result := range results {
+		assert.Equal(t, cfg.BatchSizes[i%3], result.BatchSize)
+		assert.Equal(t, i < 3, result.KeepAlive)
+		assert.Equal(t, 250, result.Statements)
+		assert.Equal(t, wantRequests[result.BatchSize], result.Requests)
+		assert.Equal(t, uint64(result.Requests), result.Latency.Count())
+		assert.Positive(t, result.StatementsPerSec())
+		assert.Positive(t, result.Resources.Allocs)
+		assert.Positive(t, result.Resources.BytesSent)
+		assert.Positive(t, result.Resources.BytesReceived)
+
+		description := "Sending 250 statements in " + Name(result.BatchSize, result.KeepAlive)
+		require.Contains(t, incs, description)
+		assert.Equal(t, result.Requests, *incs[description])
+		assert.True(t, *finished[description])
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	t.Run("Invalid DSN", func(t *testing.T) {
+		_, err := Run(context.Background(), "ftp://localhost", Config{Statements: 1, BatchSizes: []int{1}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Unreachable server", func(t *testing.T) {
+		ts := httptest.NewServer(nil)
+		url := ts.URL
+		ts.Close()
+
+		_, err := Run(context.Background(), url, Config{Statements: 10, BatchSizes: []int{5}, InFlight: 2})
+		assert.ErrorContains(t, err, "error sending batches of 5 statements")
+	})
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "batches of 10 (keep-alive)", Name(10, true))
+	assert.Equal(t, "batches of 1 (new connections)", Name(1, false))
+}
+
+// countRows returns the rows of the table of the server.
+func countRows(t *testing.T, url string, table string) int64 {
+	t.Helper()
+
+	connStr, err := nsqlitedsn.NewConnStrFromText(url)
+	require.NoError(t, err)
+	client, closeClient := newClient(connStr, 1, true)
+	defer closeClient()
+
+	responses, err := client.SendQueries(context.Background(), []nsqlitehttp.Query{
+		{Query: "SELECT COUNT(*) FROM " + table},
+	})
+	require.NoError(t, err)
+	require.Empty(t, responses[0].Error)
+	count, err := responses[0].Rows[0][0].(json.Number).Int64()
+	require.NoError(t, err)
+	return count
+}
+
+func TestInsert(t *testing.T) {
+	url := newTestServer(t)
+	connStr, err := nsqlitedsn.NewConnStrFromText(url)
+	require.NoError(t, err)
+	client, closeClient := newClient(connStr, 1, true)
+	defer closeClient()
+	_, err = client.SendQueries(context.Background(), []nsqlitehttp.Query{
+		{Query: "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL UNIQUE)"},
+	})
+	require.NoError(t, err)
+
+	const insert = "INSERT INTO users (email) VALUES (?)"
+	rows := make([][]any, 250)
+	for i := range rows {
+		rows[i] = []any{fmt.Sprintf("user%d@example.com", i)}
+	}
+
+	t.Run("Batches", func(t *testing.T) {
+		incs, finished := 0, false
+		result, err := Insert(context.Background(), url, insert, rows, 100, countingBar{&incs, &finished})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(250), result.RowsAffected)
+		assert.Equal(t, 3, result.Requests)
+		assert.Equal(t, uint64(3), result.Latency.Count())
+		assert.Equal(t, 3, incs)
+		assert.True(t, finished)
+		assert.Equal(t, int64(250), countRows(t, url, "users"))
+	})
+
+	t.Run("Rolled back on errors", func(t *testing.T) {
+		// The last row repeats the email of the first one.
+		duplicated := [][]any{{"new1@example.com"}, {"new2@example.com"}, {"new1@example.com"}}
+		_, err := Insert(context.Background(), url, insert, duplicated, 2, nil)
+		assert.ErrorContains(t, err, "error inserting rows 2 to 2")
+		assert.Equal(t, int64(250), countRows(t, url, "users"), "the first batch is rolled back")
+
+		// The transaction is not left open.
+		_, err = Insert(context.Background(), url, insert, [][]any{{"new3@example.com"}}, 1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(251), countRows(t, url, "users"))
+	})
+}
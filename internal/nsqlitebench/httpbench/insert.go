@@ -0,0 +1,117 @@
+package httpbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+)
+
+// InsertResult is the outcome of inserting rows with Insert.
+type InsertResult struct {
+	// RowsAffected is the sum of the rows affected by the statements.
+	RowsAffected int64
+	Requests     int
+	// Latency are the latencies of the requests.
+	Latency *histogram.Histogram
+}
+
+// Insert inserts the rows by running the insert statement, which inserts a
+// single row, once for each row with the row as parameters. The statements
+// are sent batchRows at a time, each batch in a single request.
+//
+// The server has no endpoint that runs a batch atomically, so every request
+// joins a transaction begun before the first one, and the rows are
+// committed together after the last one. The transaction is rolled back on
+// errors.
+func Insert(
+	ctx context.Context, dsn string, insert string, rows [][]any, batchRows int, bar Bar,
+) (InsertResult, error) {
+	connStr, err := nsqlitedsn.NewConnStrFromText(dsn)
+	if err != nil {
+		return InsertResult{}, fmt.Errorf("invalid connection string: %w", err)
+	}
+	client, closeClient := newClient(connStr, 1, true)
+	defer closeClient()
+	if bar == nil {
+		bar = noBar{}
+	}
+
+	txId, err := sendTxStatement(ctx, client, "BEGIN", "")
+	if err != nil {
+		return InsertResult{}, err
+	}
+
+	result, err := insertBatches(ctx, client, txId, insert, rows, max(batchRows, 1), bar)
+	if err != nil {
+		// The rollback uses its own context, the error may be the
+		// cancellation of ctx.
+		_, _ = sendTxStatement(context.Background(), client, "ROLLBACK", txId)
+		return InsertResult{}, err
+	}
+
+	if _, err := sendTxStatement(ctx, client, "COMMIT", txId); err != nil {
+		return InsertResult{}, err
+	}
+	bar.Finish()
+	return result, nil
+}
+
+// insertBatches sends the insert statements of the rows in the transaction
+// txId, batchRows in each request.
+func insertBatches(
+	ctx context.Context, client *apiclient.Client, txId string, insert string, rows [][]any,
+	batchRows int, bar Bar,
+) (InsertResult, error) {
+	result := InsertResult{Latency: histogram.New()}
+
+	for first := 0; first < len(rows); first += batchRows {
+		batch := rows[first:min(first+batchRows, len(rows))]
+		queries := make([]nsqlitehttp.Query, len(batch))
+		for i, row := range batch {
+			params := make([]nsqlitehttp.QueryParam, len(row))
+			for j, value := range row {
+				params[j] = nsqlitehttp.QueryParam{Value: value}
+			}
+			queries[i] = nsqlitehttp.Query{Query: insert, Params: params, TxId: txId}
+		}
+
+		opStart := time.Now()
+		responses, err := client.SendQueries(ctx, queries)
+		if err != nil {
+			return InsertResult{}, fmt.Errorf("error inserting rows %d to %d: %w", first, first+len(batch)-1, err)
+		}
+		for _, response := range responses {
+			if response.Error != "" {
+				return InsertResult{}, fmt.Errorf(
+					"error inserting rows %d to %d: %w", first, first+len(batch)-1, errors.New(response.Error),
+				)
+			}
+			result.RowsAffected += response.RowsAffected
+		}
+		result.Latency.Record(time.Since(opStart))
+		result.Requests++
+
+		bar.Inc()
+	}
+
+	return result, nil
+}
+
+// sendTxStatement sends a BEGIN, COMMIT or ROLLBACK statement of the
+// transaction txId, empty for BEGIN, and returns the ID of the transaction.
+func sendTxStatement(ctx context.Context, client *apiclient.Client, statement string, txId string) (string, error) {
+	responses, err := client.SendQueries(ctx, []nsqlitehttp.Query{{Query: statement, TxId: txId}})
+	if err != nil {
+		return "", fmt.Errorf("error sending %s: %w", statement, err)
+	}
+	if responses[0].Error != "" {
+		return "", fmt.Errorf("error sending %s: %s", statement, responses[0].Error)
+	}
+	return responses[0].TxId, nil
+}
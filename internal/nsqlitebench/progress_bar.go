@@ -1,31 +1,159 @@
 package nsqlitebench
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
 	"github.com/schollz/progressbar/v3"
 )
 
+// rateWindow is the minimum time between two samples of the rate of a
+// progress bar.
+const rateWindow = 250 * time.Millisecond
+
+// rateSmoothing is the weight of the last sample in the moving rate of a
+// progress bar.
+const rateSmoothing = 0.3
+
+// bars are the settings of the progress bars, set by Run.
+var bars = struct {
+	// quiet replaces the progress bars with a line for each phase, for CI
+	// logs.
+	quiet bool
+	w     io.Writer
+}{w: os.Stderr}
+
+// setQuietBars sets whether the progress bars are replaced with a line for
+// each phase.
+func setQuietBars(quiet bool) {
+	bars.quiet = quiet
+}
+
 type progressBar struct {
 	pb          *progressbar.ProgressBar
 	description string
 	maxItems    int
+
+	mu    sync.Mutex
+	done  int
+	start time.Time
+	eta   *etaEstimator
 }
 
 func NewBar(description string, maxItems int) *progressBar {
-	pb := progressbar.Default(int64(maxItems), description)
-	_ = pb.Set(0)
-
-	return &progressBar{
-		pb:          pb,
+	start := time.Now()
+	p := &progressBar{
 		description: description,
 		maxItems:    maxItems,
+		start:       start,
+		eta:         newETAEstimator(start),
 	}
+	if bars.quiet {
+		return p
+	}
+
+	p.pb = progressbar.NewOptions64(
+		int64(maxItems),
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWriter(bars.w),
+		progressbar.OptionSetWidth(10),
+		progressbar.OptionThrottle(65*time.Millisecond),
+		progressbar.OptionShowCount(),
+		progressbar.OptionShowIts(),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Fprint(bars.w, "\n")
+		}),
+		progressbar.OptionSpinnerType(14),
+		progressbar.OptionFullWidth(),
+		progressbar.OptionSetRenderBlankState(true),
+		progressbar.OptionSetPredictTime(false),
+	)
+	_ = p.pb.Set(0)
+
+	return p
 }
 
 func (p *progressBar) Inc() {
+	p.mu.Lock()
+	p.done++
+	sampled := p.eta.observe(time.Now(), p.done)
+	eta, ok := p.eta.eta(p.maxItems - p.done)
+	p.mu.Unlock()
+
+	if p.pb == nil {
+		return
+	}
+	if sampled && ok {
+		p.pb.Describe(fmt.Sprintf("%s (ETA %s)", p.description, eta.Round(time.Second)))
+	}
 	_ = p.pb.Add(1)
 }
 
 func (p *progressBar) Finish() {
-	_ = p.pb.Finish()
-	_ = p.pb.Close()
+	if p.pb != nil {
+		p.pb.Describe(p.description)
+		_ = p.pb.Finish()
+		_ = p.pb.Close()
+		return
+	}
+
+	p.mu.Lock()
+	done := p.done
+	p.mu.Unlock()
+
+	elapsed := time.Since(p.start)
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(done) / elapsed.Seconds()
+	}
+	fmt.Fprintf(bars.w, "%s: %d in %s (%.0f/s)\n", p.description, done, elapsed.Round(time.Millisecond), rate)
+}
+
+// etaEstimator estimates the remaining time of a progress bar from the
+// moving rate of the completed items.
+type etaEstimator struct {
+	lastTime time.Time
+	lastDone int
+	// rate is the moving rate in items per second, zero until the first
+	// sample.
+	rate float64
+}
+
+// newETAEstimator returns an estimator of a bar started at start.
+func newETAEstimator(start time.Time) *etaEstimator {
+	return &etaEstimator{lastTime: start}
+}
+
+// observe records the items done at now and reports whether the rate was
+// sampled, which happens at most once every rateWindow.
+func (e *etaEstimator) observe(now time.Time, done int) bool {
+	elapsed := now.Sub(e.lastTime)
+	if elapsed < rateWindow {
+		return false
+	}
+
+	sample := float64(done-e.lastDone) / elapsed.Seconds()
+	if e.rate == 0 {
+		e.rate = sample
+	} else {
+		e.rate = rateSmoothing*sample + (1-rateSmoothing)*e.rate
+	}
+	e.lastTime = now
+	e.lastDone = done
+	return true
+}
+
+// eta returns the estimated time to complete the remaining items, it is not
+// known until the rate is sampled.
+func (e *etaEstimator) eta(remaining int) (time.Duration, bool) {
+	if e.rate <= 0 {
+		return 0, false
+	}
+	if remaining <= 0 {
+		return 0, true
+	}
+	return time.Duration(float64(remaining) / e.rate * float64(time.Second)), true
 }
@@ -0,0 +1,65 @@
+package nsqlitebench
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETAEstimator(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := newETAEstimator(start)
+
+	_, ok := e.eta(100)
+	assert.False(t, ok, "unknown before the first sample")
+
+	assert.False(t, e.observe(start.Add(100*time.Millisecond), 10), "samples wait for the window")
+
+	assert.True(t, e.observe(start.Add(time.Second), 100))
+	eta, ok := e.eta(900)
+	assert.True(t, ok)
+	assert.Equal(t, 9*time.Second, eta, "100 items/s")
+
+	// The rate drops to 40 items/s, the moving rate is 0.3*40 + 0.7*100 = 82.
+	assert.True(t, e.observe(start.Add(2*time.Second), 140))
+	eta, _ = e.eta(820)
+	assert.Equal(t, 10*time.Second, eta)
+
+	eta, ok = e.eta(0)
+	assert.True(t, ok)
+	assert.Zero(t, eta)
+}
+
+func TestQuietBar(t *testing.T) {
+	out := bytes.Buffer{}
+	bars.w = &out
+	setQuietBars(true)
+	t.Cleanup(func() {
+		bars.w = os.Stderr
+		setQuietBars(false)
+	})
+
+	bar := NewBar("Inserting 3 users", 3)
+	bar.Inc()
+	bar.Inc()
+	bar.Inc()
+	assert.Empty(t, out.String(), "nothing is printed until the phase ends")
+
+	bar.Finish()
+	assert.Regexp(t, `^Inserting 3 users: 3 in \S+ \(\d+/s\)\n$`, out.String())
+}
+
+func TestQuietOutput(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	assert.True(t, quietOutput(false, file), "files are not terminals")
+	assert.True(t, quietOutput(false, &bytes.Buffer{}), "other writers are not terminals")
+	assert.True(t, quietOutput(true, file))
+}
@@ -0,0 +1,198 @@
+package nsqlitebench
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+)
+
+// runOptions are the runs of each benchmark.
+type runOptions struct {
+	// warmup are the runs whose results are discarded, so the first
+	// iteration costs like opening connections or filling the page cache
+	// are not measured.
+	warmup int
+	// repeat are the measured runs, whose results are aggregated.
+	repeat int
+	// readOnly keeps the existing data, the schema is not recreated before
+	// each run.
+	readOnly bool
+}
+
+// repeatBenchmark runs the warm-up runs of a benchmark and then its measured
+// runs, and returns the aggregated results of the measured ones. The schema
+// is recreated before each run, unless the runs are read-only.
+func repeatBenchmark(
+	db *sql.DB, cfg benchmarksConfig,
+	bench func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error),
+	opts runOptions,
+) ([]benchmarkResult, error) {
+	for range opts.warmup {
+		if err := prepareRun(db, cfg, opts); err != nil {
+			return nil, err
+		}
+		if _, err := bench(db, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	runs := make([][]benchmarkResult, 0, max(opts.repeat, 1))
+	for range max(opts.repeat, 1) {
+		if err := prepareRun(db, cfg, opts); err != nil {
+			return nil, err
+		}
+		results, err := bench(db, cfg)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, results)
+	}
+
+	return aggregateResults(runs)
+}
+
+// prepareRun recreates the schema before a run of a benchmark, unless the
+// runs are read-only.
+func prepareRun(db *sql.DB, cfg benchmarksConfig, opts runOptions) error {
+	if opts.readOnly {
+		return nil
+	}
+	return recreateSchema(db, cfg.schema)
+}
+
+// aggregateResults returns the mean of the results of the runs of a
+// benchmark, with the standard deviation of their duration. The latencies
+// of all runs are merged. Every run must have the same results in the same
+// order.
+func aggregateResults(runs [][]benchmarkResult) ([]benchmarkResult, error) {
+	if len(runs) == 0 {
+		return nil, nil
+	}
+
+	aggregated := make([]benchmarkResult, len(runs[0]))
+	for i, first := range runs[0] {
+		results := make([]benchmarkResult, len(runs))
+		for run, runResults := range runs {
+			if len(runResults) != len(runs[0]) || runResults[i].Name != first.Name {
+				return nil, fmt.Errorf("run %d of %s has different results than the first one", run+1, first.Name)
+			}
+			results[run] = runResults[i]
+		}
+		aggregated[i] = aggregateResult(results)
+	}
+	return aggregated, nil
+}
+
+// aggregateResult returns the mean of the results of the runs of a
+// benchmark.
+func aggregateResult(results []benchmarkResult) benchmarkResult {
+	n := len(results)
+	aggregated := benchmarkResult{
+		Name:       results[0].Name,
+		Config:     results[0].Config,
+		Runs:       n,
+		Latency:    histogram.New(),
+		Operations: make([]operationResult, len(results[0].Operations)),
+	}
+
+	durations := make([]float64, n)
+	usages := make([]resources.Usage, n)
+	var reads, writes, errs uint64
+	var insertMBPerSec, readMBPerSec, speedup float64
+	for i, result := range results {
+		durations[i] = float64(result.Duration)
+		reads += result.TotalReads
+		writes += result.TotalWrites
+		errs += result.Errors
+		insertMBPerSec += result.InsertMBPerSec
+		readMBPerSec += result.ReadMBPerSec
+		speedup += result.Speedup
+		usages[i] = result.Resources
+		aggregated.Latency.Merge(result.Latency)
+
+		for j, op := range result.Operations {
+			if j < len(aggregated.Operations) {
+				aggregated.Operations[j].Name = op.Name
+				aggregated.Operations[j].Count += op.Count
+				aggregated.Operations[j].Errors += op.Errors
+			}
+		}
+	}
+
+	mean, stddev := meanStdDev(durations)
+	aggregated.Duration = time.Duration(math.Round(mean))
+	aggregated.DurationStdDev = time.Duration(math.Round(stddev))
+	aggregated.TotalReads = meanUint(reads, n)
+	aggregated.TotalWrites = meanUint(writes, n)
+	aggregated.Errors = meanUint(errs, n)
+	aggregated.InsertMBPerSec = insertMBPerSec / float64(n)
+	aggregated.ReadMBPerSec = readMBPerSec / float64(n)
+	aggregated.Speedup = speedup / float64(n)
+	aggregated.Resources = meanUsage(usages)
+	for j := range aggregated.Operations {
+		aggregated.Operations[j].Count = meanUint(aggregated.Operations[j].Count, n)
+		aggregated.Operations[j].Errors = meanUint(aggregated.Operations[j].Errors, n)
+	}
+	return aggregated
+}
+
+// meanUsage returns the mean of the resources used by the runs, the CPU
+// time is only known if it is for every run.
+func meanUsage(usages []resources.Usage) resources.Usage {
+	n := len(usages)
+	mean := resources.Usage{HasCPU: true}
+	var gcs uint64
+	var cpu time.Duration
+	for _, usage := range usages {
+		mean.Allocs += usage.Allocs
+		mean.AllocBytes += usage.AllocBytes
+		gcs += uint64(usage.GCs)
+		cpu += usage.CPU
+		mean.HasCPU = mean.HasCPU && usage.HasCPU
+		mean.BytesSent += usage.BytesSent
+		mean.BytesReceived += usage.BytesReceived
+	}
+
+	mean.Allocs = meanUint(mean.Allocs, n)
+	mean.AllocBytes = meanUint(mean.AllocBytes, n)
+	mean.GCs = uint32(meanUint(gcs, n))
+	mean.CPU = cpu / time.Duration(n)
+	mean.BytesSent = meanUint(mean.BytesSent, n)
+	mean.BytesReceived = meanUint(mean.BytesReceived, n)
+	if !mean.HasCPU {
+		mean.CPU = 0
+	}
+	return mean
+}
+
+// meanStdDev returns the mean and the sample standard deviation of values,
+// the standard deviation of a single value is zero.
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) == 1 {
+		return mean, 0
+	}
+
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)-1))
+}
+
+// meanUint returns the rounded mean of n values that add up to sum.
+func meanUint(sum uint64, n int) uint64 {
+	return (sum + uint64(n)/2) / uint64(n)
+}
@@ -0,0 +1,160 @@
+package nsqlitebench
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResult returns a result of a benchmark with the given duration in
+// milliseconds.
+func fakeResult(name string, ms int, reads uint64) benchmarkResult {
+	latency := histogram.New()
+	latency.Record(time.Duration(ms) * time.Millisecond)
+	return benchmarkResult{
+		Name:        name,
+		Duration:    time.Duration(ms) * time.Millisecond,
+		TotalReads:  reads,
+		TotalWrites: reads / 2,
+		Latency:     latency,
+		Config:      map[string]int{"users": 10},
+	}
+}
+
+func TestAggregateResults(t *testing.T) {
+	t.Run("Mean and standard deviation", func(t *testing.T) {
+		runs := [][]benchmarkResult{
+			{fakeResult("Large 1 B", 100, 10), fakeResult("Large 2 B", 40, 1)},
+			{fakeResult("Large 1 B", 110, 11), fakeResult("Large 2 B", 40, 1)},
+			{fakeResult("Large 1 B", 90, 12), fakeResult("Large 2 B", 40, 2)},
+		}
+
+		results, err := aggregateResults(runs)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		assert.Equal(t, "Large 1 B", results[0].Name)
+		assert.Equal(t, 3, results[0].Runs)
+		assert.Equal(t, 100*time.Millisecond, results[0].Duration)
+		assert.Equal(t, 10*time.Millisecond, results[0].DurationStdDev)
+		assert.Equal(t, uint64(11), results[0].TotalReads)
+		assert.Equal(t, uint64(5), results[0].TotalWrites)
+		assert.Equal(t, uint64(3), results[0].Latency.Count(), "latencies are merged")
+		assert.Equal(t, 110*time.Millisecond, results[0].Latency.Max())
+		assert.Equal(t, map[string]int{"users": 10}, results[0].Config)
+
+		assert.Equal(t, 40*time.Millisecond, results[1].Duration)
+		assert.Zero(t, results[1].DurationStdDev)
+		assert.Equal(t, uint64(1), results[1].TotalReads, "4/3 is rounded")
+	})
+
+	t.Run("Single run", func(t *testing.T) {
+		results, err := aggregateResults([][]benchmarkResult{{fakeResult("Simple", 50, 4)}})
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, 1, results[0].Runs)
+		assert.Equal(t, 50*time.Millisecond, results[0].Duration)
+		assert.Zero(t, results[0].DurationStdDev)
+	})
+
+	t.Run("Operations", func(t *testing.T) {
+		first := fakeResult("Mixed", 10, 0)
+		first.Operations = []operationResult{{Name: "Insert", Count: 10, Errors: 1}}
+		second := fakeResult("Mixed", 10, 0)
+		second.Operations = []operationResult{{Name: "Insert", Count: 20, Errors: 2}}
+		second.Errors = 2
+
+		results, err := aggregateResults([][]benchmarkResult{{first}, {second}})
+		require.NoError(t, err)
+		assert.Equal(t, []operationResult{{Name: "Insert", Count: 15, Errors: 2}}, results[0].Operations)
+		assert.Equal(t, uint64(1), results[0].Errors)
+	})
+
+	t.Run("Resources", func(t *testing.T) {
+		first := fakeResult("Simple", 10, 0)
+		first.Resources = resources.Usage{Allocs: 100, GCs: 1, CPU: time.Second, HasCPU: true, BytesSent: 10}
+		second := fakeResult("Simple", 10, 0)
+		second.Resources = resources.Usage{Allocs: 200, GCs: 2, CPU: 2 * time.Second, HasCPU: true, BytesSent: 20}
+
+		results, err := aggregateResults([][]benchmarkResult{{first}, {second}})
+		require.NoError(t, err)
+		assert.Equal(t, resources.Usage{
+			Allocs: 150, GCs: 2, CPU: 1500 * time.Millisecond, HasCPU: true, BytesSent: 15,
+		}, results[0].Resources)
+
+		second.Resources.HasCPU = false
+		results, err = aggregateResults([][]benchmarkResult{{first}, {second}})
+		require.NoError(t, err)
+		assert.False(t, results[0].Resources.HasCPU, "the CPU time must be known for every run")
+		assert.Zero(t, results[0].Resources.CPU)
+	})
+
+	t.Run("Different results", func(t *testing.T) {
+		_, err := aggregateResults([][]benchmarkResult{
+			{fakeResult("Simple", 10, 1)},
+			{fakeResult("Many", 10, 1)},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestMeanStdDev(t *testing.T) {
+	mean, stddev := meanStdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	assert.Equal(t, 5.0, mean)
+	assert.InDelta(t, 2.138, stddev, 0.001)
+
+	mean, stddev = meanStdDev(nil)
+	assert.Zero(t, mean)
+	assert.Zero(t, stddev)
+}
+
+func TestRepeatBenchmark(t *testing.T) {
+	db, err := localDrivers[0].create(filepath.Join(t.TempDir(), "bench.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	durations := []int{1000, 100, 120, 80}
+	calls := 0
+	bench := func(db *sql.DB, cfg benchmarksConfig) ([]benchmarkResult, error) {
+		var users int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM users").Scan(&users))
+		assert.Zero(t, users, "the schema is recreated before each run")
+		_, err := db.Exec("INSERT INTO users (created, email, active) VALUES (1, 'a', 1)")
+		require.NoError(t, err)
+
+		result := fakeResult("Fake", durations[calls], 1)
+		calls++
+		return []benchmarkResult{result}, nil
+	}
+
+	results, err := repeatBenchmark(db, benchmarksConfig{schema: config.SchemaIndexed}, bench, runOptions{warmup: 1, repeat: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+	require.Len(t, results, 1)
+	assert.Equal(t, 3, results[0].Runs)
+	assert.Equal(t, 100*time.Millisecond, results[0].Duration, "the warm-up run is discarded")
+	assert.Equal(t, 20*time.Millisecond, results[0].DurationStdDev)
+
+	t.Run("Errors stop the runs", func(t *testing.T) {
+		failing := func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error) {
+			return nil, errors.New("boom")
+		}
+		_, err := repeatBenchmark(db, benchmarksConfig{schema: config.SchemaIndexed}, failing, runOptions{repeat: 2})
+		assert.EqualError(t, err, "boom")
+	})
+}
+
+func TestIsNoisy(t *testing.T) {
+	assert.True(t, isNoisy(BenchmarkReport{Runs: 3, DurationMs: 100, DurationStdDevMs: 11}, 10))
+	assert.False(t, isNoisy(BenchmarkReport{Runs: 3, DurationMs: 100, DurationStdDevMs: 10}, 10))
+	assert.False(t, isNoisy(BenchmarkReport{Runs: 1, DurationMs: 100, DurationStdDevMs: 50}, 10))
+	assert.False(t, isNoisy(BenchmarkReport{Runs: 3}, 10))
+}
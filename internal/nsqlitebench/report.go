@@ -0,0 +1,639 @@
+package nsqlitebench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
+	"github.com/nsqlite/nsqlite/internal/version"
+)
+
+// Report is the result of a benchmark run written with --output json or
+// csv. The names of its JSON fields are stable, so the results of different
+// runs can be compared.
+type Report struct {
+	Metadata ReportMetadata `json:"metadata"`
+	Drivers  []DriverReport `json:"drivers"`
+}
+
+// ReportMetadata describes the environment of a benchmark run.
+type ReportMetadata struct {
+	// BenchVersion is the version of nsqlitebench.
+	BenchVersion string `json:"benchVersion"`
+	GoVersion    string `json:"goVersion"`
+	GOOS         string `json:"goos"`
+	GOARCH       string `json:"goarch"`
+	// Timestamp is the time when the benchmark started.
+	Timestamp time.Time `json:"timestamp"`
+	// Warmup and Repeat are the discarded and measured runs of each
+	// benchmark.
+	Warmup int `json:"warmup"`
+	Repeat int `json:"repeat"`
+	// Seed is the seed of the generated data, a run with the same seed
+	// inserts the same data.
+	Seed uint64 `json:"seed"`
+	// Schema is the schema of the benchmark tables, the results of
+	// different schemas are not comparable.
+	Schema string `json:"schema"`
+	// ReadOnly reports whether only the reads of the existing data were
+	// benchmarked.
+	ReadOnly bool `json:"readOnly"`
+	// NoiseThresholdPct is the percent of the standard deviation of the
+	// duration over its mean above which a result is noisy.
+	NoiseThresholdPct float64 `json:"noiseThresholdPct"`
+}
+
+// DriverReport are the results of the benchmarks of a driver.
+type DriverReport struct {
+	// Driver is the name of the driver, as given in --drivers.
+	Driver        string `json:"driver"`
+	SQLiteVersion string `json:"sqliteVersion"`
+	// ServerVersion is the version of the NSQLite server, only reported for
+	// the nsqlite driver.
+	ServerVersion string            `json:"serverVersion,omitempty"`
+	Benchmarks    []BenchmarkReport `json:"benchmarks"`
+	// Sweep is the throughput of each number of goroutines of the
+	// concurrency sweep, and BestGoroutines the one with the best
+	// throughput. They are only reported with --sweep-concurrency.
+	Sweep          []SweepPoint `json:"sweep,omitempty"`
+	BestGoroutines int          `json:"bestGoroutines,omitempty"`
+	// HTTPBatches are the statements per second of each batch size sent
+	// directly over HTTP, only reported for the nsqlite driver.
+	HTTPBatches []HTTPBatch `json:"httpBatches,omitempty"`
+	// Soak is the time series of the mixed workload run continuously, only
+	// reported with --soak.
+	Soak *SoakReport `json:"soak,omitempty"`
+}
+
+// BenchmarkReport is the result of a benchmark.
+type BenchmarkReport struct {
+	Name string `json:"name"`
+	// DurationMs is the mean duration of the runs, and DurationStdDevMs its
+	// standard deviation.
+	DurationMs       float64 `json:"durationMs"`
+	DurationStdDevMs float64 `json:"durationStdDevMs"`
+	Runs             int     `json:"runs"`
+	Reads            uint64  `json:"reads"`
+	Writes           uint64  `json:"writes"`
+	// OpsPerSec are the reads and writes per second.
+	OpsPerSec    float64 `json:"opsPerSec"`
+	ReadsPerSec  float64 `json:"readsPerSec"`
+	WritesPerSec float64 `json:"writesPerSec"`
+	// P50Ms, P95Ms, P99Ms and MaxMs are percentiles of the latency of the
+	// reads and writes in milliseconds.
+	P50Ms float64 `json:"p50Ms"`
+	P95Ms float64 `json:"p95Ms"`
+	P99Ms float64 `json:"p99Ms"`
+	MaxMs float64 `json:"maxMs"`
+	// InsertMBPerSec and ReadMBPerSec are the MiB per second of the payloads
+	// of the large benchmark.
+	InsertMBPerSec float64 `json:"insertMBPerSec,omitempty"`
+	ReadMBPerSec   float64 `json:"readMBPerSec,omitempty"`
+	// Speedup is the duration of the baseline variant of the benchmark
+	// divided by the duration of this one, only for the prepared statements
+	// reused over the direct ones and the bulk strategies over the
+	// single-row inserts.
+	Speedup float64 `json:"speedup,omitempty"`
+	// AllocsPerOp are the heap allocations of the process for each read and
+	// write, and GCs the garbage collections during the benchmark.
+	AllocsPerOp float64 `json:"allocsPerOp"`
+	GCs         uint32  `json:"gcs"`
+	// CPUSeconds is the user and system CPU time of the process, it is not
+	// reported on the platforms without it.
+	CPUSeconds *float64 `json:"cpuSeconds,omitempty"`
+	// BytesSent and BytesReceived are the bytes sent to and received from
+	// the NSQLite server, only reported for the nsqlite driver.
+	BytesSent     uint64 `json:"bytesSent,omitempty"`
+	BytesReceived uint64 `json:"bytesReceived,omitempty"`
+	// Errors is the number of failed operations, only the mixed benchmark
+	// counts them instead of stopping.
+	Errors uint64 `json:"errors"`
+	// Operations are the results of each operation type of the mixed
+	// benchmark.
+	Operations []OperationReport `json:"operations,omitempty"`
+	// Config are the parameters of the benchmark, like the number of
+	// inserted rows and goroutines.
+	Config map[string]int `json:"config"`
+}
+
+// OperationReport is the result of an operation type of a benchmark.
+type OperationReport struct {
+	Name      string  `json:"name"`
+	Count     uint64  `json:"count"`
+	Errors    uint64  `json:"errors"`
+	OpsPerSec float64 `json:"opsPerSec"`
+}
+
+// newReportMetadata returns the metadata of a run started at start.
+func newReportMetadata(start time.Time, conf config.Config) ReportMetadata {
+	return ReportMetadata{
+		BenchVersion:      version.Version,
+		GoVersion:         runtime.Version(),
+		GOOS:              runtime.GOOS,
+		GOARCH:            runtime.GOARCH,
+		Timestamp:         start.UTC().Truncate(time.Second),
+		Warmup:            conf.Warmup,
+		Repeat:            conf.Repeat,
+		Seed:              conf.ParsedSeed,
+		Schema:            conf.Schema,
+		ReadOnly:          conf.ReadOnly,
+		NoiseThresholdPct: conf.Noise,
+	}
+}
+
+// newBenchmarkReport returns the report of a benchmark result.
+func newBenchmarkReport(result benchmarkResult) BenchmarkReport {
+	perSec := func(count uint64) float64 {
+		if seconds := result.Duration.Seconds(); seconds > 0 {
+			return float64(count) / seconds
+		}
+		return 0
+	}
+
+	report := BenchmarkReport{
+		Name:             result.Name,
+		DurationMs:       durationMs(result.Duration),
+		DurationStdDevMs: durationMs(result.DurationStdDev),
+		Runs:             max(result.Runs, 1),
+		Reads:            result.TotalReads,
+		Writes:           result.TotalWrites,
+		OpsPerSec:        perSec(result.TotalReads + result.TotalWrites),
+		ReadsPerSec:      perSec(result.TotalReads),
+		WritesPerSec:     perSec(result.TotalWrites),
+		Errors:           result.Errors,
+		InsertMBPerSec:   result.InsertMBPerSec,
+		ReadMBPerSec:     result.ReadMBPerSec,
+		Speedup:          result.Speedup,
+		GCs:              result.Resources.GCs,
+		BytesSent:        result.Resources.BytesSent,
+		BytesReceived:    result.Resources.BytesReceived,
+		Config:           result.Config,
+	}
+	if ops := result.TotalReads + result.TotalWrites; ops > 0 {
+		report.AllocsPerOp = float64(result.Resources.Allocs) / float64(ops)
+	}
+	if result.Resources.HasCPU {
+		cpuSeconds := result.Resources.CPU.Seconds()
+		report.CPUSeconds = &cpuSeconds
+	}
+	for _, op := range result.Operations {
+		report.Operations = append(report.Operations, OperationReport{
+			Name:      op.Name,
+			Count:     op.Count,
+			Errors:    op.Errors,
+			OpsPerSec: perSec(op.Count),
+		})
+	}
+	if result.Latency != nil {
+		report.P50Ms = durationMs(result.Latency.Percentile(50))
+		report.P95Ms = durationMs(result.Latency.Percentile(95))
+		report.P99Ms = durationMs(result.Latency.Percentile(99))
+		report.MaxMs = durationMs(result.Latency.Max())
+	}
+	return report
+}
+
+// durationMs returns d in milliseconds with microsecond precision.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// msDuration returns the duration of ms milliseconds.
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// writeReport writes the report to w in the given output format.
+func writeReport(w io.Writer, format string, report Report) error {
+	switch format {
+	case config.OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case config.OutputCSV:
+		return writeReportCSV(w, report)
+	default:
+		for _, driver := range report.Drivers {
+			fmt.Fprintf(w, "\n--- Benchmarks for %s ---\n", driverLabels[driver.Driver])
+			fmt.Fprintln(w, renderResults(driver.Benchmarks, report.Metadata.NoiseThresholdPct))
+			if operations := renderOperations(driver.Benchmarks); operations != "" {
+				fmt.Fprintln(w, operations)
+			}
+			fmt.Fprintln(w, "\n--- Resource usage ---")
+			fmt.Fprintln(w, renderResources(driver.Benchmarks))
+			if len(driver.Sweep) > 0 {
+				fmt.Fprintln(w, renderSweep(driver.Sweep))
+			}
+			if len(driver.HTTPBatches) > 0 {
+				fmt.Fprintln(w, "\n--- Statements/sec per batch size over HTTP ---")
+				fmt.Fprintln(w, renderHTTPBatches(driver.HTTPBatches))
+			}
+			if driver.Soak != nil {
+				fmt.Fprintln(w, "\n--- Trend of the soak ---")
+				fmt.Fprintln(w, renderSoakTrend(*driver.Soak, report.Metadata.NoiseThresholdPct))
+			}
+		}
+		if len(report.Drivers) > 1 {
+			fmt.Fprintln(w, "\n--- Comparison, relative to the fastest driver ---")
+			fmt.Fprintln(w, renderComparison(report.Drivers))
+		}
+		if throughput := renderThroughput(report.Drivers); throughput != "" {
+			fmt.Fprintln(w, "\n--- Throughput by payload size, in MiB/s ---")
+			fmt.Fprintln(w, throughput)
+		}
+		if speedups := renderSpeedups(report.Drivers); speedups != "" {
+			fmt.Fprintln(w, "\n--- Speedup over the baseline variant ---")
+			fmt.Fprintln(w, speedups)
+		}
+		return nil
+	}
+}
+
+// reportCSVHeader are the columns of the CSV output, one row is written for
+// each benchmark of each driver.
+var reportCSVHeader = []string{
+	"driver", "benchmark", "duration_ms", "duration_stddev_ms", "runs", "reads", "writes", "ops_per_sec",
+	"reads_per_sec", "writes_per_sec", "p50_ms", "p95_ms", "p99_ms", "max_ms", "errors",
+	"insert_mb_per_sec", "read_mb_per_sec", "speedup", "config",
+	"sqlite_version", "server_version", "bench_version", "go_version", "goos", "goarch", "timestamp",
+	"schema", "allocs_per_op", "gcs", "cpu_seconds", "bytes_sent", "bytes_received",
+}
+
+// writeReportCSV writes the report as CSV. The config of each benchmark is
+// written as space separated key=value pairs sorted by key.
+func writeReportCSV(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(reportCSVHeader); err != nil {
+		return err
+	}
+
+	meta := report.Metadata
+	for _, driver := range report.Drivers {
+		for _, bench := range driver.Benchmarks {
+			err := cw.Write([]string{
+				driver.Driver,
+				bench.Name,
+				strconv.FormatFloat(bench.DurationMs, 'f', 3, 64),
+				strconv.FormatFloat(bench.DurationStdDevMs, 'f', 3, 64),
+				strconv.Itoa(bench.Runs),
+				strconv.FormatUint(bench.Reads, 10),
+				strconv.FormatUint(bench.Writes, 10),
+				strconv.FormatFloat(bench.OpsPerSec, 'f', 2, 64),
+				strconv.FormatFloat(bench.ReadsPerSec, 'f', 2, 64),
+				strconv.FormatFloat(bench.WritesPerSec, 'f', 2, 64),
+				strconv.FormatFloat(bench.P50Ms, 'f', 3, 64),
+				strconv.FormatFloat(bench.P95Ms, 'f', 3, 64),
+				strconv.FormatFloat(bench.P99Ms, 'f', 3, 64),
+				strconv.FormatFloat(bench.MaxMs, 'f', 3, 64),
+				strconv.FormatUint(bench.Errors, 10),
+				strconv.FormatFloat(bench.InsertMBPerSec, 'f', 2, 64),
+				strconv.FormatFloat(bench.ReadMBPerSec, 'f', 2, 64),
+				strconv.FormatFloat(bench.Speedup, 'f', 2, 64),
+				formatBenchmarkConfig(bench.Config),
+				driver.SQLiteVersion,
+				driver.ServerVersion,
+				meta.BenchVersion,
+				meta.GoVersion,
+				meta.GOOS,
+				meta.GOARCH,
+				meta.Timestamp.Format(time.RFC3339),
+				meta.Schema,
+				strconv.FormatFloat(bench.AllocsPerOp, 'f', 2, 64),
+				strconv.FormatUint(uint64(bench.GCs), 10),
+				formatCPUSeconds(bench.CPUSeconds),
+				strconv.FormatUint(bench.BytesSent, 10),
+				strconv.FormatUint(bench.BytesReceived, 10),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatCPUSeconds formats the CPU seconds of a benchmark, empty if they
+// are not reported.
+func formatCPUSeconds(cpuSeconds *float64) string {
+	if cpuSeconds == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*cpuSeconds, 'f', 3, 64)
+}
+
+// formatBenchmarkConfig formats the config of a benchmark as space separated
+// key=value pairs sorted by key.
+func formatBenchmarkConfig(cfg map[string]int) string {
+	pairs := []string{}
+	for _, key := range slices.Sorted(maps.Keys(cfg)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", key, cfg[key]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// renderResults renders the results of the benchmarks of a driver as a
+// table. Results of several runs show the standard deviation of their
+// duration, and are flagged as noisy when it exceeds noiseThresholdPct
+// percent of the mean.
+func renderResults(results []BenchmarkReport, noiseThresholdPct float64) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{
+		"Name", "Reads", "Writes", "Reads/sec", "Writes/sec", "p50", "p95", "p99", "Max", "Errors", "Duration",
+	})
+
+	for _, r := range results {
+		name := r.Name
+		if isNoisy(r, noiseThresholdPct) {
+			name += " (noisy)"
+		}
+		duration := msDuration(r.DurationMs).String()
+		if r.Runs > 1 {
+			duration += " ± " + msDuration(r.DurationStdDevMs).String()
+		}
+
+		tw.AppendRow(table.Row{
+			name, r.Reads, r.Writes,
+			fmt.Sprintf("%.0f", r.ReadsPerSec), fmt.Sprintf("%.0f", r.WritesPerSec),
+			msDuration(r.P50Ms), msDuration(r.P95Ms), msDuration(r.P99Ms), msDuration(r.MaxMs),
+			r.Errors, duration,
+		})
+	}
+
+	return tw.Render()
+}
+
+// isNoisy reports whether the standard deviation of the duration of the
+// runs of a benchmark exceeds thresholdPct percent of their mean.
+func isNoisy(r BenchmarkReport, thresholdPct float64) bool {
+	if r.Runs < 2 || r.DurationMs <= 0 {
+		return false
+	}
+	return r.DurationStdDevMs/r.DurationMs*100 > thresholdPct
+}
+
+// renderOperations renders the results of each operation type of the
+// benchmarks that mix them, or an empty string if none does.
+func renderOperations(results []BenchmarkReport) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Name", "Operation", "Count", "Ops/sec", "Errors"})
+
+	rows := 0
+	for _, r := range results {
+		for _, op := range r.Operations {
+			tw.AppendRow(table.Row{r.Name, op.Name, op.Count, fmt.Sprintf("%.0f", op.OpsPerSec), op.Errors})
+			rows++
+		}
+	}
+	if rows == 0 {
+		return ""
+	}
+
+	return tw.Render()
+}
+
+// renderResources renders the resources used by the process during each
+// benchmark of a driver. The bytes are only known for the nsqlite driver.
+func renderResources(results []BenchmarkReport) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Name", "Allocs/op", "GCs", "CPU", "Sent", "Received"})
+
+	for _, r := range results {
+		cpu := "-"
+		if r.CPUSeconds != nil {
+			cpu = time.Duration(*r.CPUSeconds * float64(time.Second)).Round(time.Millisecond).String()
+		}
+		sent, received := "-", "-"
+		if r.BytesSent > 0 || r.BytesReceived > 0 {
+			sent = numutil.Bytes(int64(r.BytesSent))
+			received = numutil.Bytes(int64(r.BytesReceived))
+		}
+
+		tw.AppendRow(table.Row{r.Name, fmt.Sprintf("%.2f", r.AllocsPerOp), r.GCs, cpu, sent, received})
+	}
+
+	return tw.Render()
+}
+
+// renderThroughput renders a table with the insert and read MiB/s of each
+// driver for each payload size of the large benchmark, or an empty string
+// if it did not run.
+func renderThroughput(drivers []DriverReport) string {
+	names := []string{}
+	for _, name := range benchmarkNames(drivers) {
+		for _, driver := range drivers {
+			if bench, ok := findBenchmark(driver, name); ok && (bench.InsertMBPerSec > 0 || bench.ReadMBPerSec > 0) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	header := table.Row{"Name"}
+	for _, driver := range drivers {
+		label := driverLabels[driver.Driver]
+		header = append(header, label+" insert", label+" read")
+	}
+
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(header)
+	for _, name := range names {
+		row := table.Row{name}
+		for _, driver := range drivers {
+			bench, ok := findBenchmark(driver, name)
+			if !ok {
+				row = append(row, "-", "-")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.2f", bench.InsertMBPerSec), fmt.Sprintf("%.2f", bench.ReadMBPerSec))
+		}
+		tw.AppendRow(row)
+	}
+
+	return tw.Render()
+}
+
+// renderSpeedups renders a table with the speedup of each driver for the
+// benchmarks that have one, or an empty string if none does.
+func renderSpeedups(drivers []DriverReport) string {
+	names := []string{}
+	for _, name := range benchmarkNames(drivers) {
+		for _, driver := range drivers {
+			if bench, ok := findBenchmark(driver, name); ok && bench.Speedup > 0 {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	header := table.Row{"Name"}
+	for _, driver := range drivers {
+		header = append(header, driverLabels[driver.Driver])
+	}
+
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(header)
+	for _, name := range names {
+		row := table.Row{name}
+		for _, driver := range drivers {
+			if bench, ok := findBenchmark(driver, name); ok && bench.Speedup > 0 {
+				row = append(row, fmt.Sprintf("%.2fx", bench.Speedup))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		tw.AppendRow(row)
+	}
+
+	return tw.Render()
+}
+
+// renderComparison renders a table with the duration of each benchmark of
+// each driver divided by the duration of the fastest driver, so the fastest
+// one is 1.00x.
+func renderComparison(drivers []DriverReport) string {
+	header := table.Row{"Name"}
+	for _, driver := range drivers {
+		header = append(header, driverLabels[driver.Driver])
+	}
+
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(header)
+
+	for _, name := range benchmarkNames(drivers) {
+		fastest := 0.0
+		for _, driver := range drivers {
+			if bench, ok := findBenchmark(driver, name); ok && (fastest == 0 || bench.DurationMs < fastest) {
+				fastest = bench.DurationMs
+			}
+		}
+
+		row := table.Row{name}
+		for _, driver := range drivers {
+			bench, ok := findBenchmark(driver, name)
+			switch {
+			case !ok:
+				row = append(row, "-")
+			case fastest == 0:
+				row = append(row, "1.00x")
+			default:
+				row = append(row, fmt.Sprintf("%.2fx", bench.DurationMs/fastest))
+			}
+		}
+		tw.AppendRow(row)
+	}
+
+	return tw.Render()
+}
+
+// benchmarkNames returns the names of the benchmarks of the drivers, in the
+// order they were run.
+func benchmarkNames(drivers []DriverReport) []string {
+	names := []string{}
+	for _, driver := range drivers {
+		for _, bench := range driver.Benchmarks {
+			if !slices.Contains(names, bench.Name) {
+				names = append(names, bench.Name)
+			}
+		}
+	}
+	return names
+}
+
+// findBenchmark returns the benchmark of the driver with the given name.
+func findBenchmark(driver DriverReport, name string) (BenchmarkReport, bool) {
+	for _, bench := range driver.Benchmarks {
+		if bench.Name == name {
+			return bench, true
+		}
+	}
+	return BenchmarkReport{}, false
+}
+
+// report returns the parameters of the benchmark for the report.
+func (c benchmarkSimpleConfig) report() map[string]int {
+	return map[string]int{
+		"insertUsers":      c.insertXUsers,
+		"queryUsers":       c.queryYUsers,
+		"insertGoroutines": c.insertGoroutines,
+		"queryGoroutines":  c.queryGoroutines,
+	}
+}
+
+// report returns the parameters of the benchmark for the report.
+func (c benchmarkComplexConfig) report() map[string]int {
+	return map[string]int{
+		"insertUsers":              c.insertXUsers,
+		"insertArticlesPerUser":    c.insertYArticlesPerUser,
+		"insertCommentsPerArticle": c.insertZCommentsPerArticle,
+		"insertGoroutines":         c.insertGoroutines,
+	}
+}
+
+// report returns the parameters of the benchmark for the report.
+func (c benchmarkManyConfig) report() map[string]int {
+	return map[string]int{
+		"insertUsers":      c.insertXUsers,
+		"queryUsersTimes":  c.queryUsersYTimes,
+		"insertGoroutines": c.insertGoroutines,
+		"queryGoroutines":  c.queryGoroutines,
+	}
+}
+
+// report returns the parameters of the benchmark for the report.
+func (c benchmarkPreparedConfig) report() map[string]int {
+	return map[string]int{
+		"insertUsers": c.insertXUsers,
+		"queryUsers":  c.queryYUsers,
+	}
+}
+
+// report returns the parameters of the benchmark for the report.
+func (c benchmarkPointReadsConfig) report() map[string]int {
+	return map[string]int{
+		"reads":      c.reads,
+		"goroutines": c.goroutines,
+	}
+}
+
+// report returns the parameters of the benchmark for the report.
+func (c benchmarkMixedConfig) report() map[string]int {
+	return map[string]int{
+		"seedUsers":        c.seedUsers,
+		"goroutines":       c.goroutines,
+		"rangeSize":        c.rangeSize,
+		"durationMs":       int(c.duration.Milliseconds()),
+		"pointReadsWeight": c.mix.PointReads,
+		"rangeReadsWeight": c.mix.RangeReads,
+		"insertsWeight":    c.mix.Inserts,
+		"updatesWeight":    c.mix.Updates,
+	}
+}
+
+// report returns the parameters of the benchmark with payloads of the
+// given size for the report.
+func (c benchmarkLargeConfig) report(users int, size int) map[string]int {
+	return map[string]int{
+		"insertUsers":      users,
+		"insertBytes":      size,
+		"insertGoroutines": c.insertGoroutines,
+	}
+}
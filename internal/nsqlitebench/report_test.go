@@ -0,0 +1,314 @@
+package nsqlitebench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// simpleCPUSeconds and largeCPUSeconds are the CPU seconds of the
+// benchmarks of reportFixture, the mixed one has none.
+var simpleCPUSeconds, largeCPUSeconds = 1.25, 0.2
+
+// reportFixture is a report with a benchmark of each driver.
+var reportFixture = Report{
+	Metadata: ReportMetadata{
+		BenchVersion:      "v0.1.0",
+		GoVersion:         "go1.23.5",
+		GOOS:              "linux",
+		GOARCH:            "amd64",
+		Timestamp:         time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC),
+		Warmup:            1,
+		Repeat:            3,
+		Seed:              1234,
+		Schema:            config.SchemaIndexed,
+		NoiseThresholdPct: 10,
+	},
+	Drivers: []DriverReport{
+		{
+			Driver:        config.DriverMattn,
+			SQLiteVersion: "3.48.0",
+			Benchmarks: []BenchmarkReport{{
+				Name: "Simple", DurationMs: 1500, DurationStdDevMs: 300, Runs: 3, Reads: 2000, Writes: 1000,
+				OpsPerSec: 2000, ReadsPerSec: 1333.33, WritesPerSec: 666.67,
+				P50Ms: 0.5, P95Ms: 1.25, P99Ms: 3, MaxMs: 12.5,
+				AllocsPerOp: 12.5, GCs: 3, CPUSeconds: &simpleCPUSeconds,
+				Config: map[string]int{"insertUsers": 1000, "insertGoroutines": 10},
+			}},
+		},
+		{
+			Driver:        config.DriverNsqlite,
+			SQLiteVersion: "3.48.0",
+			ServerVersion: "v0.1.0",
+			Benchmarks: []BenchmarkReport{{
+				Name: "Large 100 B", DurationMs: 250.5, DurationStdDevMs: 2.5, Runs: 3, Reads: 10, Writes: 10,
+				OpsPerSec: 79.84, ReadsPerSec: 39.92, WritesPerSec: 39.92,
+				P50Ms: 10, P95Ms: 20, P99Ms: 20, MaxMs: 20,
+				InsertMBPerSec: 0.5, ReadMBPerSec: 2,
+				AllocsPerOp: 40, GCs: 1, CPUSeconds: &largeCPUSeconds, BytesSent: 2048, BytesReceived: 4096,
+				Config: map[string]int{"insertBytes": 100},
+			}, {
+				Name: "Mixed", DurationMs: 1000, Runs: 1, Reads: 90, Writes: 8,
+				OpsPerSec: 98, ReadsPerSec: 90, WritesPerSec: 8,
+				P50Ms: 1, P95Ms: 2, P99Ms: 4, MaxMs: 5,
+				Errors: 2,
+				Operations: []OperationReport{
+					{Name: "Point read", Count: 90, OpsPerSec: 90},
+					{Name: "Insert", Count: 8, Errors: 2, OpsPerSec: 8},
+				},
+				Config: map[string]int{"goroutines": 4},
+			}},
+		},
+	},
+}
+
+func TestWriteReportJSON(t *testing.T) {
+	out := bytes.Buffer{}
+	require.NoError(t, writeReport(&out, config.OutputJSON, reportFixture))
+
+	t.Run("Stable schema", func(t *testing.T) {
+		assert.JSONEq(t, `{
+			"metadata": {
+				"benchVersion": "v0.1.0",
+				"goVersion": "go1.23.5",
+				"goos": "linux",
+				"goarch": "amd64",
+				"timestamp": "2025-03-01T12:30:00Z",
+				"warmup": 1,
+				"repeat": 3,
+				"seed": 1234,
+				"schema": "indexed",
+				"readOnly": false,
+				"noiseThresholdPct": 10
+			},
+			"drivers": [
+				{
+					"driver": "mattn",
+					"sqliteVersion": "3.48.0",
+					"benchmarks": [{
+						"name": "Simple", "durationMs": 1500, "durationStdDevMs": 300, "runs": 3, "reads": 2000, "writes": 1000, "opsPerSec": 2000,
+						"readsPerSec": 1333.33, "writesPerSec": 666.67,
+						"p50Ms": 0.5, "p95Ms": 1.25, "p99Ms": 3, "maxMs": 12.5, "errors": 0,
+						"allocsPerOp": 12.5, "gcs": 3, "cpuSeconds": 1.25,
+						"config": {"insertUsers": 1000, "insertGoroutines": 10}
+					}]
+				},
+				{
+					"driver": "nsqlite",
+					"sqliteVersion": "3.48.0",
+					"serverVersion": "v0.1.0",
+					"benchmarks": [{
+						"name": "Large 100 B", "durationMs": 250.5, "durationStdDevMs": 2.5, "runs": 3, "reads": 10, "writes": 10, "opsPerSec": 79.84,
+						"readsPerSec": 39.92, "writesPerSec": 39.92,
+						"p50Ms": 10, "p95Ms": 20, "p99Ms": 20, "maxMs": 20, "errors": 0,
+						"insertMBPerSec": 0.5, "readMBPerSec": 2,
+						"allocsPerOp": 40, "gcs": 1, "cpuSeconds": 0.2, "bytesSent": 2048, "bytesReceived": 4096,
+						"config": {"insertBytes": 100}
+					}, {
+						"name": "Mixed", "durationMs": 1000, "durationStdDevMs": 0, "runs": 1, "reads": 90, "writes": 8, "opsPerSec": 98,
+						"readsPerSec": 90, "writesPerSec": 8,
+						"p50Ms": 1, "p95Ms": 2, "p99Ms": 4, "maxMs": 5, "errors": 2,
+						"allocsPerOp": 0, "gcs": 0,
+						"operations": [
+							{"name": "Point read", "count": 90, "errors": 0, "opsPerSec": 90},
+							{"name": "Insert", "count": 8, "errors": 2, "opsPerSec": 8}
+						],
+						"config": {"goroutines": 4}
+					}]
+				}
+			]
+		}`, out.String())
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		decoded := Report{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, reportFixture, decoded)
+	})
+}
+
+func TestWriteReportCSV(t *testing.T) {
+	out := bytes.Buffer{}
+	require.NoError(t, writeReport(&out, config.OutputCSV, reportFixture))
+
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		reportCSVHeader,
+		{
+			"mattn", "Simple", "1500.000", "300.000", "3", "2000", "1000", "2000.00",
+			"1333.33", "666.67", "0.500", "1.250", "3.000", "12.500", "0", "0.00", "0.00", "0.00", "insertGoroutines=10 insertUsers=1000",
+			"3.48.0", "", "v0.1.0", "go1.23.5", "linux", "amd64", "2025-03-01T12:30:00Z", "indexed",
+			"12.50", "3", "1.250", "0", "0",
+		},
+		{
+			"nsqlite", "Large 100 B", "250.500", "2.500", "3", "10", "10", "79.84",
+			"39.92", "39.92", "10.000", "20.000", "20.000", "20.000", "0", "0.50", "2.00", "0.00", "insertBytes=100",
+			"3.48.0", "v0.1.0", "v0.1.0", "go1.23.5", "linux", "amd64", "2025-03-01T12:30:00Z", "indexed",
+			"40.00", "1", "0.200", "2048", "4096",
+		},
+		{
+			"nsqlite", "Mixed", "1000.000", "0.000", "1", "90", "8", "98.00",
+			"90.00", "8.00", "1.000", "2.000", "4.000", "5.000", "2", "0.00", "0.00", "0.00", "goroutines=4",
+			"3.48.0", "v0.1.0", "v0.1.0", "go1.23.5", "linux", "amd64", "2025-03-01T12:30:00Z", "indexed",
+			"0.00", "0", "", "0", "0",
+		},
+	}, records)
+}
+
+func TestWriteReportTable(t *testing.T) {
+	out := bytes.Buffer{}
+	require.NoError(t, writeReport(&out, config.OutputTable, reportFixture))
+
+	assert.Contains(t, out.String(), "--- Benchmarks for mattn/go-sqlite3 ---")
+	assert.Contains(t, out.String(), "--- Benchmarks for nsqlite/nsqlitego ---")
+	assert.Contains(t, out.String(), "1.5s ± 300ms")
+	assert.Contains(t, out.String(), "Simple (noisy)")
+	assert.NotContains(t, out.String(), "Large 100 B (noisy)")
+	assert.Contains(t, out.String(), "12.5ms")
+	assert.Contains(t, out.String(), "Point read")
+	assert.Contains(t, out.String(), "--- Throughput by payload size, in MiB/s ---")
+	assert.Contains(t, out.String(), "--- Comparison, relative to the fastest driver ---")
+	assert.Contains(t, out.String(), "--- Resource usage ---")
+}
+
+func TestRenderResources(t *testing.T) {
+	rendered := renderResources(reportFixture.Drivers[1].Benchmarks)
+
+	assert.Regexp(t, `Large 100 B\s*\S\s*40\.00\s*\S\s*1\s*\S\s*200ms\s*\S\s*2\.0 KiB\s*\S\s*4\.0 KiB`, rendered)
+	// The CPU time and the bytes are unknown for the mixed benchmark.
+	assert.Regexp(t, `Mixed\s*\S\s*0\.00\s*\S\s*0\s*\S\s*-\s*\S\s*-\s*\S\s*-`, rendered)
+}
+
+func TestNewBenchmarkReportResources(t *testing.T) {
+	t.Run("Per operation", func(t *testing.T) {
+		report := newBenchmarkReport(benchmarkResult{
+			Name: "Simple", Duration: time.Second, TotalReads: 30, TotalWrites: 10,
+			Resources: resources.Usage{
+				Allocs: 100, GCs: 2, CPU: 1500 * time.Millisecond, HasCPU: true,
+				BytesSent: 10, BytesReceived: 20,
+			},
+		})
+		assert.InDelta(t, 2.5, report.AllocsPerOp, 0.001)
+		assert.Equal(t, uint32(2), report.GCs)
+		require.NotNil(t, report.CPUSeconds)
+		assert.InDelta(t, 1.5, *report.CPUSeconds, 0.001)
+		assert.Equal(t, uint64(10), report.BytesSent)
+		assert.Equal(t, uint64(20), report.BytesReceived)
+	})
+
+	t.Run("Unknown CPU time", func(t *testing.T) {
+		report := newBenchmarkReport(benchmarkResult{Name: "Simple", Duration: time.Second})
+		assert.Zero(t, report.AllocsPerOp)
+		assert.Nil(t, report.CPUSeconds)
+	})
+}
+
+func TestRenderComparison(t *testing.T) {
+	drivers := []DriverReport{
+		{Driver: config.DriverMattn, Benchmarks: []BenchmarkReport{
+			{Name: "Simple", DurationMs: 100},
+			{Name: "Large", DurationMs: 300},
+		}},
+		{Driver: config.DriverSqlitec, Benchmarks: []BenchmarkReport{
+			{Name: "Simple", DurationMs: 150},
+			{Name: "Large", DurationMs: 200},
+		}},
+		{Driver: config.DriverNsqlite, Benchmarks: []BenchmarkReport{
+			{Name: "Simple", DurationMs: 400},
+		}},
+	}
+
+	lines := strings.Split(renderComparison(drivers), "\n")
+	find := func(name string) []string {
+		for _, line := range lines {
+			fields := strings.Fields(strings.NewReplacer("|", " ", "│", " ").Replace(line))
+			if len(fields) > 0 && fields[0] == name {
+				return fields[1:]
+			}
+		}
+		t.Fatalf("no row for %s", name)
+		return nil
+	}
+
+	assert.Equal(t, []string{"1.00x", "1.50x", "4.00x"}, find("Simple"))
+	assert.Equal(t, []string{"1.50x", "1.00x", "-"}, find("Large"))
+}
+
+func TestRenderThroughput(t *testing.T) {
+	assert.Empty(t, renderThroughput([]DriverReport{
+		{Driver: config.DriverMattn, Benchmarks: []BenchmarkReport{{Name: "Simple"}}},
+	}))
+
+	out := renderThroughput([]DriverReport{
+		{Driver: config.DriverMattn, Benchmarks: []BenchmarkReport{
+			{Name: "Simple"},
+			{Name: "Large 1.0 KiB", InsertMBPerSec: 12.5, ReadMBPerSec: 40},
+			{Name: "Large 8.0 MiB", InsertMBPerSec: 300, ReadMBPerSec: 900},
+		}},
+		{Driver: config.DriverNsqlite, Benchmarks: []BenchmarkReport{
+			{Name: "Large 1.0 KiB", InsertMBPerSec: 1.25, ReadMBPerSec: 4},
+		}},
+	})
+
+	assert.NotContains(t, out, "Simple")
+	assert.Contains(t, out, "mattn/go-sqlite3 insert")
+	assert.Contains(t, out, "nsqlite/nsqlitego read")
+	assert.Regexp(t, `Large 1\.0 KiB\W+12\.50\W+40\.00\W+1\.25\W+4\.00`, out)
+	assert.Regexp(t, `Large 8\.0 MiB\W+300\.00\W+900\.00\W+-\W+-`, out)
+}
+
+func TestRenderSpeedups(t *testing.T) {
+	assert.Empty(t, renderSpeedups(reportFixture.Drivers))
+
+	out := renderSpeedups([]DriverReport{
+		{Driver: config.DriverMattn, Benchmarks: []BenchmarkReport{
+			{Name: "Prepared (direct)"},
+			{Name: "Prepared (reused)", Speedup: 1.25},
+		}},
+		{Driver: config.DriverNsqlite, Benchmarks: []BenchmarkReport{
+			{Name: "Prepared (direct)"},
+			{Name: "Prepared (reused)", Speedup: 3},
+		}},
+	})
+
+	assert.NotContains(t, out, "Prepared (direct)")
+	assert.Regexp(t, `Prepared \(reused\)\W+1\.25x\W+3\.00x`, out)
+}
+
+func TestNewBenchmarkReport(t *testing.T) {
+	latency := histogram.New()
+	for i := 1; i <= 100; i++ {
+		latency.Record(time.Duration(i) * time.Microsecond)
+	}
+
+	report := newBenchmarkReport(benchmarkResult{
+		Name:        "Many",
+		Duration:    2 * time.Second,
+		TotalReads:  300,
+		TotalWrites: 100,
+		Config:      benchmarkManyConfig{insertXUsers: 100, queryUsersYTimes: 3}.report(),
+		Latency:     latency,
+	})
+
+	assert.Equal(t, "Many", report.Name)
+	assert.Equal(t, 2000.0, report.DurationMs)
+	assert.Equal(t, 200.0, report.OpsPerSec)
+	assert.Equal(t, 150.0, report.ReadsPerSec)
+	assert.Equal(t, 50.0, report.WritesPerSec)
+	assert.InEpsilon(t, 0.05, report.P50Ms, 0.05)
+	assert.InEpsilon(t, 0.095, report.P95Ms, 0.05)
+	assert.InEpsilon(t, 0.099, report.P99Ms, 0.05)
+	assert.Equal(t, 0.1, report.MaxMs)
+	assert.Equal(t, 100, report.Config["insertUsers"])
+	assert.Equal(t, 3, report.Config["queryUsersTimes"])
+}
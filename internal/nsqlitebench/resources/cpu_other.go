@@ -0,0 +1,11 @@
+//go:build !unix
+
+package resources
+
+import "time"
+
+// processCPUTime reports that the CPU time of the process is not available
+// on this platform.
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,111 @@
+// Package resources samples the resources used by the process, so the
+// benchmarks report their memory, CPU and network cost besides their
+// throughput.
+package resources
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// bytesSent and bytesReceived are the bytes of the connections dialed with
+// CountingDialer.
+var bytesSent, bytesReceived atomic.Uint64
+
+// Sample are the resources used by the process until a point in time, the
+// counters only grow.
+type Sample struct {
+	// Allocs is the number of heap objects allocated, and AllocBytes their
+	// size.
+	Allocs     uint64
+	AllocBytes uint64
+	// GCs is the number of completed garbage collections.
+	GCs uint32
+	// CPU is the user and system CPU time of the process, HasCPU is false
+	// if it is not available on this platform.
+	CPU    time.Duration
+	HasCPU bool
+	// BytesSent and BytesReceived are the bytes of the connections dialed
+	// with CountingDialer.
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+// Take returns the resources used by the process until now. It stops the
+// world to read the memory statistics, so it must not be called while
+// measuring.
+func Take() Sample {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	cpu, hasCPU := processCPUTime()
+
+	return Sample{
+		Allocs:        mem.Mallocs,
+		AllocBytes:    mem.TotalAlloc,
+		GCs:           mem.NumGC,
+		CPU:           cpu,
+		HasCPU:        hasCPU,
+		BytesSent:     bytesSent.Load(),
+		BytesReceived: bytesReceived.Load(),
+	}
+}
+
+// Usage are the resources used between two samples.
+type Usage struct {
+	Allocs     uint64
+	AllocBytes uint64
+	GCs        uint32
+	CPU        time.Duration
+	// HasCPU is false if the CPU time is not available on this platform.
+	HasCPU        bool
+	BytesSent     uint64
+	BytesReceived uint64
+}
+
+// Since returns the resources used from start to s.
+func (s Sample) Since(start Sample) Usage {
+	return Usage{
+		Allocs:        s.Allocs - start.Allocs,
+		AllocBytes:    s.AllocBytes - start.AllocBytes,
+		GCs:           s.GCs - start.GCs,
+		CPU:           s.CPU - start.CPU,
+		HasCPU:        s.HasCPU && start.HasCPU,
+		BytesSent:     s.BytesSent - start.BytesSent,
+		BytesReceived: s.BytesReceived - start.BytesReceived,
+	}
+}
+
+// CountingDialer wraps dial so the bytes sent and received by its
+// connections are counted in the samples, like the DialContext of an
+// http.Transport.
+func CountingDialer(
+	dial func(ctx context.Context, network string, address string) (net.Conn, error),
+) func(ctx context.Context, network string, address string) (net.Conn, error) {
+	return func(ctx context.Context, network string, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return countingConn{Conn: conn}, nil
+	}
+}
+
+// countingConn is a connection that counts its bytes.
+type countingConn struct {
+	net.Conn
+}
+
+func (c countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	bytesReceived.Add(uint64(n))
+	return n, err
+}
+
+func (c countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	bytesSent.Add(uint64(n))
+	return n, err
+}
@@ -0,0 +1,71 @@
+package resources
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sink keeps the allocations of the tests from being optimized away.
+var sink []byte
+
+func TestTake(t *testing.T) {
+	start := Take()
+
+	for range 100 {
+		sink = make([]byte, 1<<10)
+	}
+	runtime.GC()
+	// Burn some CPU so its time advances.
+	sum := 0
+	for i := range 10_000_000 {
+		sum += i % 7
+	}
+	sink = []byte{byte(sum)}
+
+	end := Take()
+	assert.Greater(t, end.Allocs, start.Allocs)
+	assert.GreaterOrEqual(t, end.AllocBytes, start.AllocBytes+100<<10)
+	assert.Greater(t, end.GCs, start.GCs)
+	if runtime.GOOS != "windows" && runtime.GOOS != "plan9" && runtime.GOOS != "js" {
+		assert.True(t, end.HasCPU)
+		assert.Greater(t, end.CPU, start.CPU)
+	}
+
+	usage := end.Since(start)
+	assert.Equal(t, end.Allocs-start.Allocs, usage.Allocs)
+	assert.Positive(t, usage.GCs)
+	assert.Equal(t, end.HasCPU, usage.HasCPU)
+}
+
+func TestCountingDialer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	defer ts.Close()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = CountingDialer((&net.Dialer{}).DialContext)
+	client := &http.Client{Transport: transport}
+	defer transport.CloseIdleConnections()
+
+	previous := Take()
+	for range 3 {
+		res, err := client.Post(ts.URL, "text/plain", strings.NewReader(strings.Repeat("y", 500)))
+		require.NoError(t, err)
+		_, _ = res.Body.Read(make([]byte, 2000))
+		res.Body.Close()
+
+		sample := Take()
+		usage := sample.Since(previous)
+		assert.Greater(t, usage.BytesSent, uint64(500))
+		assert.Greater(t, usage.BytesReceived, uint64(1000))
+		previous = sample
+	}
+}
@@ -3,14 +3,21 @@ package nsqlitebench
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/jedib0t/go-pretty/v6/table"
-	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+	"github.com/nsqlite/nsqlite/internal/util/sysutil"
 	"github.com/nsqlite/nsqlite/internal/version"
 	"github.com/peterh/liner"
 )
@@ -21,114 +28,419 @@ type benchmarkResult struct {
 	Duration    time.Duration
 	TotalReads  uint64
 	TotalWrites uint64
+	// Config are the parameters of the benchmark, for the report.
+	Config map[string]int
+	// Latency are the latencies of the reads and writes of the benchmark.
+	Latency *histogram.Histogram
+	// Errors is the number of failed operations of benchmarks that do not
+	// stop on errors.
+	Errors uint64
+	// Operations are the results of each operation type of benchmarks that
+	// mix them.
+	Operations []operationResult
+	// InsertMBPerSec and ReadMBPerSec are the MiB per second of the payloads
+	// inserted and read by the large benchmark.
+	InsertMBPerSec float64
+	ReadMBPerSec   float64
+	// Runs is the number of measured runs of the benchmark, whose results
+	// are aggregated, and DurationStdDev the standard deviation of their
+	// duration.
+	Runs           int
+	DurationStdDev time.Duration
+	// Speedup is the duration of the baseline variant of the benchmark
+	// divided by the duration of this one, like the prepared statements
+	// over the direct ones.
+	Speedup float64
+	// Resources are the resources used by the process during the
+	// benchmark, for all of its goroutines and those of the drivers.
+	Resources resources.Usage
 }
 
-// Run executes benchmarks for two SQLite drivers and prints the results.
-func Run(ctx context.Context) error {
-	fmt.Println(version.BenchVersion())
-	fmt.Println()
+// driverLabels are the names of the drivers shown in the table output.
+var driverLabels = map[string]string{
+	config.DriverMattn:   "mattn/go-sqlite3",
+	config.DriverSqlitec: "nsqlite/sqlitec",
+	config.DriverNsqlite: "nsqlite/nsqlitego",
+}
+
+// localDriver is a driver that benchmarks a SQLite database file.
+type localDriver struct {
+	name   string
+	create func(string) (*sql.DB, error)
+	// createReadOnly opens the database file so it refuses any write, for
+	// the read-only mode.
+	createReadOnly func(string) (*sql.DB, error)
+	cfg            benchmarksConfig
+}
+
+// benchDriver is a driver to benchmark.
+type benchDriver struct {
+	db     *sql.DB
+	cfg    benchmarksConfig
+	report DriverReport
+	// walSize returns the size of the WAL of the database for the soak,
+	// false if it is not known.
+	walSize func() (int64, bool)
+}
+
+// Run executes benchmarks for the SQLite drivers selected with the command
+// line arguments and writes the results in the selected output format.
+//
+// With compare as first argument, it compares the JSON results of two runs
+// instead.
+func Run(ctx context.Context, args []string) error {
+	if len(args) > 1 && args[1] == config.CompareCommand {
+		return runCompare(args[1:], os.Stdout)
+	}
 
-	tmpDir, err := os.MkdirTemp("", "nsqlitebench_*")
+	conf, err := config.Parse(args, os.Stdout)
+	if errors.Is(err, config.ErrHelpShown) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tmpDir)
-	sqliteDBPath := path.Join(tmpDir, "/benchmark.sqlite")
-	fmt.Printf("The temporary SQLite database to be benchmarked will be stored in %s\n", sqliteDBPath)
 
-	nsqliteDSN := "http://localhost:9876"
-	fmt.Printf("The NSQLite server to be benchmarked is %s\n", color.RedString(nsqliteDSN))
+	// The messages go to stderr when stdout is used for the JSON or CSV
+	// results, so they can be piped.
+	out := io.Writer(os.Stdout)
+	if conf.Output != config.OutputTable && conf.OutputFile == "" {
+		out = os.Stderr
+	}
+	start := time.Now()
+	setQuietBars(quietOutput(conf.Quiet, os.Stdout))
 
-	fmt.Println()
-	color.Red("Make sure the NSQLite server is not important, as the benchmark will make changes to the database.")
-	fmt.Println()
+	fmt.Fprintln(out, version.BenchVersion())
+	fmt.Fprintf(out, "Seed of the generated data: %d\n", conf.ParsedSeed)
+	fmt.Fprintf(out, "Schema of the benchmark tables: %s\n", conf.Schema)
+	fmt.Fprintln(out)
 
-	line := liner.NewLiner()
-	defer line.Close()
-	line.SetCtrlCAborts(true)
+	var drivers []benchDriver
+	defer func() {
+		for _, driver := range drivers {
+			driver.db.Close()
+		}
+	}()
 
-	for {
-		prompt, err := line.Prompt(`Enter "start" to start the benchmark, or press CTRL+C to exit: `)
-		if err != nil {
-			if err == liner.ErrPromptAborted {
-				fmt.Println("CTRL+C pressed, exiting...")
-				return nil
+	for _, driver := range conf.ParsedDrivers {
+		if driver != config.DriverNsqlite && !slices.ContainsFunc(localDrivers, func(local localDriver) bool {
+			return local.name == driver
+		}) {
+			fmt.Fprintf(out, "Skipping %s, it is not built into this nsqlitebench binary\n", driverLabels[driver])
+		}
+	}
+
+	tmpDir := ""
+	for _, local := range localDrivers {
+		if !conf.Has(local.name) {
+			continue
+		}
+
+		if conf.ReadOnly {
+			// The read-only mode reads the existing data of the file itself,
+			// every driver can share it as none writes.
+			if _, err := os.Stat(conf.SqlitePath); err != nil {
+				return fmt.Errorf("error opening the SQLite database to read: %w", err)
 			}
+			fmt.Fprintf(out, "The SQLite database to be read with %s is %s\n", driverLabels[local.name], conf.SqlitePath)
+
+			db, err := openDriver(ctx, local.createReadOnly, conf.SqlitePath, true)
+			if err != nil {
+				return fmt.Errorf("error opening %s db: %w", driverLabels[local.name], err)
+			}
+			drivers = append(drivers, benchDriver{
+				db:     db,
+				cfg:    local.cfg,
+				report: DriverReport{Driver: local.name},
+			})
+			continue
+		}
+
+		var sqliteDBPath string
+		if conf.SqlitePath == "" {
+			if tmpDir == "" {
+				tmpDir, err = os.MkdirTemp("", "nsqlitebench_*")
+				if err != nil {
+					return err
+				}
+				defer os.RemoveAll(tmpDir)
+			}
+			sqliteDBPath = localDBPath(path.Join(tmpDir, "benchmark.sqlite"), local.name)
+			fmt.Fprintf(out, "The temporary SQLite database to be benchmarked with %s will be stored in %s\n", driverLabels[local.name], sqliteDBPath)
+		} else {
+			sqliteDBPath = localDBPath(conf.SqlitePath, local.name)
+			fmt.Fprintf(out, "The SQLite database to be benchmarked with %s is %s\n", driverLabels[local.name], color.RedString(sqliteDBPath))
+		}
+
+		db, err := openDriver(ctx, local.create, sqliteDBPath, conf.Force)
+		if err != nil {
+			return fmt.Errorf("error opening %s db: %w", driverLabels[local.name], err)
+		}
+		drivers = append(drivers, benchDriver{
+			db:      db,
+			cfg:     local.cfg,
+			report:  DriverReport{Driver: local.name},
+			walSize: fileWALSize(sqliteDBPath),
+		})
+	}
+
+	if conf.Has(config.DriverNsqlite) {
+		fmt.Fprintf(out, "The NSQLite server to be benchmarked is %s\n", color.RedString(conf.NsqliteDSN))
+
+		nsqliteDb, err := openDriver(ctx, createNsqliteDriver, conf.NsqliteDSN, conf.Force || conf.ReadOnly)
+		if err != nil {
+			return fmt.Errorf("error opening nsqlite/nsqlitego db: %w", err)
+		}
+		serverVersion, err := nsqliteServerVersion(ctx, conf.NsqliteDSN)
+		if err != nil {
+			nsqliteDb.Close()
+			return fmt.Errorf("error getting the NSQLite server version: %w", err)
+		}
+		walSize, err := statsWALSize(ctx, conf.NsqliteDSN)
+		if err != nil {
+			nsqliteDb.Close()
 			return err
 		}
-		if prompt == "start" {
-			break
+		nsqliteCfg := getNsqliteConfig()
+		nsqliteCfg.benchmarkBulkConfig.dsn = conf.NsqliteDSN
+		drivers = append(drivers, benchDriver{
+			db:      nsqliteDb,
+			cfg:     nsqliteCfg,
+			report:  DriverReport{Driver: config.DriverNsqlite, ServerVersion: serverVersion},
+			walSize: walSize,
+		})
+	}
+
+	if !conf.Yes && !conf.ReadOnly {
+		fmt.Fprintln(out)
+		color.New(color.FgRed).Fprintln(out, "Make sure the databases are not important, as the benchmark will make changes to them.")
+		fmt.Fprintln(out)
+
+		start, err := promptStart()
+		if err != nil || !start {
+			return err
 		}
 	}
 
-	mattnDb, err := createMattnDriver(sqliteDBPath)
-	if err != nil {
-		return fmt.Errorf("error opening mattn/go-sqlite3 db: %w", err)
+	report := Report{Metadata: newReportMetadata(start, conf)}
+	for _, driver := range drivers {
+		label := driverLabels[driver.report.Driver]
+		fmt.Fprintf(out, "\n--- Benchmarks for %s ---\n", label)
+
+		driver.report.SQLiteVersion, err = sqliteVersion(ctx, driver.db)
+		if err != nil {
+			return fmt.Errorf("error getting the SQLite version of %s: %w", label, err)
+		}
+
+		driver.cfg.benchmarkMixedConfig.duration = conf.Duration
+		driver.cfg.benchmarkMixedConfig.mix = conf.ParsedMix
+		driver.cfg.benchmarkLargeConfig.payloadSizes = conf.ParsedLargeSizes
+		driver.cfg.seed = conf.ParsedSeed
+		driver.cfg.schema = conf.Schema
+		opts := runOptions{warmup: conf.Warmup, repeat: conf.Repeat}
+
+		var results []benchmarkResult
+		if conf.ReadOnly {
+			results, err = runReadOnlyBenchmark(driver.db, driver.cfg, opts)
+		} else if conf.Soak > 0 {
+			var result benchmarkResult
+			var soak SoakReport
+			result, soak, err = runSoak(driver.db, driver.cfg, soakOptions{
+				duration: conf.Soak,
+				interval: conf.SoakInterval,
+				walSize:  driver.walSize,
+				emit: func(point SoakPoint) {
+					fmt.Fprintln(out, formatSoakPoint(point))
+				},
+			})
+			results, driver.report.Soak = []benchmarkResult{result}, &soak
+		} else if len(conf.ParsedSweep) > 0 {
+			results, driver.report.Sweep, err = runSweep(
+				driver.db, driver.cfg, sweepBenchmarks[conf.SweepBench], conf.ParsedSweep, opts,
+			)
+			if best, ok := bestSweepPoint(driver.report.Sweep); ok {
+				driver.report.BestGoroutines = best.Goroutines
+			}
+		} else {
+			results, err = runBenchmark(driver.db, driver.cfg, opts)
+			if err == nil && driver.report.Driver == config.DriverNsqlite {
+				var httpResults []benchmarkResult
+				httpResults, driver.report.HTTPBatches, err = runBenchmarkHTTP(
+					ctx, driver.db, conf.NsqliteDSN, driver.cfg, opts,
+				)
+				results = append(results, httpResults...)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("error benchmarking %s: %w", label, err)
+		}
+		for _, result := range results {
+			driver.report.Benchmarks = append(driver.report.Benchmarks, newBenchmarkReport(result))
+		}
+		report.Drivers = append(report.Drivers, driver.report)
+
+		if conf.Output == config.OutputTable && conf.OutputFile == "" {
+			fmt.Fprintln(out, renderResults(driver.report.Benchmarks, conf.Noise))
+			if operations := renderOperations(driver.report.Benchmarks); operations != "" {
+				fmt.Fprintln(out, operations)
+			}
+			fmt.Fprintln(out, "\n--- Resource usage ---")
+			fmt.Fprintln(out, renderResources(driver.report.Benchmarks))
+			if len(driver.report.Sweep) > 0 {
+				fmt.Fprintln(out, renderSweep(driver.report.Sweep))
+			}
+			if len(driver.report.HTTPBatches) > 0 {
+				fmt.Fprintln(out, "\n--- Statements/sec per batch size over HTTP ---")
+				fmt.Fprintln(out, renderHTTPBatches(driver.report.HTTPBatches))
+			}
+			if driver.report.Soak != nil {
+				fmt.Fprintln(out, "\n--- Trend of the soak ---")
+				fmt.Fprintln(out, renderSoakTrend(*driver.report.Soak, conf.Noise))
+			}
+		}
 	}
-	defer mattnDb.Close()
 
-	nsqliteDb, err := createNsqliteDriver(nsqliteDSN)
-	if err != nil {
-		return fmt.Errorf("error opening nsqlite/nsqlitego db: %w", err)
+	if conf.Output == config.OutputTable && conf.OutputFile == "" {
+		if len(report.Drivers) > 1 {
+			fmt.Fprintln(out, "\n--- Comparison, relative to the fastest driver ---")
+			fmt.Fprintln(out, renderComparison(report.Drivers))
+		}
+		if throughput := renderThroughput(report.Drivers); throughput != "" {
+			fmt.Fprintln(out, "\n--- Throughput by payload size, in MiB/s ---")
+			fmt.Fprintln(out, throughput)
+		}
+		if speedups := renderSpeedups(report.Drivers); speedups != "" {
+			fmt.Fprintln(out, "\n--- Speedup over the baseline variant ---")
+			fmt.Fprintln(out, speedups)
+		}
 	}
-	defer nsqliteDb.Close()
 
-	fmt.Println("\n--- Benchmarks for mattn/go-sqlite3 ---")
-	mattnResults, err := runBenchmark(mattnDb, getMattnConfig())
-	if err != nil {
-		return fmt.Errorf("error benchmarking mattn/go-sqlite3: %w", err)
+	if conf.OutputFile != "" {
+		return writeReportFile(conf.OutputFile, conf.Output, report)
 	}
-	printResults(mattnResults)
+	if conf.Output != config.OutputTable {
+		return writeReport(os.Stdout, conf.Output, report)
+	}
+	return nil
+}
 
-	fmt.Println("\n--- Benchmarks for nsqlite/nsqlitego ---")
-	nsqliteResults, err := runBenchmark(nsqliteDb, getNsqliteConfig())
+// writeReportFile writes the report to the file at path in the given output
+// format.
+func writeReportFile(path string, format string, report Report) error {
+	f, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("error benchmarking nsqlite/nsqlitego: %w", err)
+		return fmt.Errorf("error creating the output file: %w", err)
 	}
-	printResults(nsqliteResults)
 
+	err = writeReport(f, format, report)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing the output file: %w", err)
+	}
 	return nil
 }
 
-func printResults(results []benchmarkResult) {
-	tw := styled.NewTableWriter()
-	tw.AppendHeader(table.Row{"Name", "Reads", "Writes", "Duration"})
+// quietOutput reports whether the progress bars are replaced with a line
+// for each phase, either because quiet is set or because stdout is not a
+// terminal, like in CI logs.
+func quietOutput(quiet bool, stdout any) bool {
+	return quiet || !sysutil.IsTerminal(stdout)
+}
+
+// localDBPath returns the path of the SQLite database of a local driver,
+// with the driver name appended to the file name so each driver uses its
+// own file.
+func localDBPath(base string, driver string) string {
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-" + driver + ext
+}
 
-	for _, r := range results {
-		tw.AppendRow(table.Row{r.Name, r.TotalReads, r.TotalWrites, r.Duration})
+// openDriver opens the database with create, which verifies the
+// connection, and checks that it is empty unless force is true.
+func openDriver(
+	ctx context.Context, create func(string) (*sql.DB, error), dsn string, force bool,
+) (*sql.DB, error) {
+	db, err := create(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if force {
+		return db, nil
 	}
 
-	fmt.Println(tw.Render())
+	if err := checkEmptyDatabase(ctx, db); err != nil {
+		db.Close()
+		if errors.Is(err, errDatabaseNotEmpty) {
+			return nil, fmt.Errorf("%w, run with --force to benchmark it anyway", err)
+		}
+		return nil, err
+	}
+	return db, nil
 }
 
-// runBenchmark executes all benchmarks, and returns results.
-//
-// It recreates the schema before each benchmark.
-func runBenchmark(db *sql.DB, cfg benchmarksConfig) ([]benchmarkResult, error) {
-	if err := recreateSchema(db); err != nil {
-		return nil, err
+// promptStart asks the user to start the benchmark and reports whether it
+// must start, it returns false if CTRL+C is pressed.
+func promptStart() (bool, error) {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+
+	for {
+		prompt, err := line.Prompt(`Enter "start" to start the benchmark, or press CTRL+C to exit: `)
+		if err != nil {
+			if err == liner.ErrPromptAborted {
+				fmt.Println("CTRL+C pressed, exiting...")
+				return false, nil
+			}
+			return false, err
+		}
+		if prompt == "start" {
+			return true, nil
+		}
 	}
+}
 
-	benchs := []func(*sql.DB, benchmarksConfig) (benchmarkResult, error){
-		runBenchmarkSimple,
-		runBenchmarkComplex,
-		runBenchmarkMany,
+// runBenchmark executes all benchmarks, and returns results.
+//
+// It recreates the schema before each run of each benchmark.
+func runBenchmark(db *sql.DB, cfg benchmarksConfig, opts runOptions) ([]benchmarkResult, error) {
+	benchs := []func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error){
+		singleResult(runBenchmarkSimple),
+		singleResult(runBenchmarkComplex),
+		singleResult(runBenchmarkMany),
 		runBenchmarkLarge,
+		singleResult(runBenchmarkMixed),
+		runBenchmarkPrepared,
+		runBenchmarkBulk,
 	}
 
 	var results []benchmarkResult
 
 	for _, bench := range benchs {
-		if err := recreateSchema(db); err != nil {
+		res, err := repeatBenchmark(db, cfg, bench, opts)
+		if err != nil {
 			return nil, err
 		}
+		results = append(results, res...)
+	}
+
+	return results, nil
+}
 
+// singleResult adapts a benchmark with a single result to the benchmarks
+// that return one result for each of their variants.
+func singleResult(
+	bench func(*sql.DB, benchmarksConfig) (benchmarkResult, error),
+) func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error) {
+	return func(db *sql.DB, cfg benchmarksConfig) ([]benchmarkResult, error) {
+		start := resources.Take()
 		res, err := bench(db, cfg)
 		if err != nil {
 			return nil, err
 		}
-		results = append(results, res)
+		res.Resources = resources.Take().Since(start)
+		return []benchmarkResult{res}, nil
 	}
-
-	return results, nil
 }
@@ -1,42 +1,96 @@
 package nsqlitebench
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
 
-// recreateSchema drops all tables and recreates them.
-func recreateSchema(db *sql.DB) error {
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+)
+
+// schemaTables are the statements that create the benchmark tables, with
+// their primary keys as the only indexes.
+var schemaTables = []string{
+	`CREATE TABLE users (
+		id INTEGER PRIMARY KEY NOT NULL,
+		created INTEGER NOT NULL,
+		email TEXT NOT NULL,
+		active INTEGER NOT NULL
+	)`,
+
+	`CREATE TABLE articles (
+		id INTEGER PRIMARY KEY NOT NULL,
+		created INTEGER NOT NULL,
+		userId INTEGER NOT NULL REFERENCES users(id),
+		text TEXT NOT NULL
+	)`,
+
+	`CREATE TABLE comments (
+		id INTEGER PRIMARY KEY NOT NULL,
+		created INTEGER NOT NULL,
+		articleId INTEGER NOT NULL REFERENCES articles(id),
+		text TEXT NOT NULL
+	)`,
+}
+
+// schemaIndexes are the secondary indexes of the indexed schema.
+var schemaIndexes = []string{
+	`CREATE UNIQUE INDEX users_email ON users(email)`,
+	`CREATE INDEX users_created ON users(created)`,
+	`CREATE INDEX articles_created ON articles(created)`,
+	`CREATE INDEX articles_userId ON articles(userId)`,
+	`CREATE INDEX comments_created ON comments(created)`,
+	`CREATE INDEX comments_articleId ON comments(articleId)`,
+}
+
+// schemaTriggers are the statements of the wal-heavy schema, every change of
+// the benchmark tables is also logged in the events table by a trigger, so
+// each write changes more pages.
+var schemaTriggers = []string{
+	`CREATE TABLE events (
+		id INTEGER PRIMARY KEY NOT NULL,
+		created INTEGER NOT NULL,
+		tableName TEXT NOT NULL,
+		rowId INTEGER NOT NULL
+	)`,
+	`CREATE INDEX events_created ON events(created)`,
+
+	`CREATE TRIGGER users_insert_event AFTER INSERT ON users BEGIN
+		INSERT INTO events (created, tableName, rowId) VALUES (NEW.created, 'users', NEW.id);
+	END`,
+	`CREATE TRIGGER users_update_event AFTER UPDATE ON users BEGIN
+		INSERT INTO events (created, tableName, rowId) VALUES (NEW.created, 'users', NEW.id);
+	END`,
+	`CREATE TRIGGER articles_insert_event AFTER INSERT ON articles BEGIN
+		INSERT INTO events (created, tableName, rowId) VALUES (NEW.created, 'articles', NEW.id);
+	END`,
+	`CREATE TRIGGER comments_insert_event AFTER INSERT ON comments BEGIN
+		INSERT INTO events (created, tableName, rowId) VALUES (NEW.created, 'comments', NEW.id);
+	END`,
+}
+
+// recreateSchema drops all tables and recreates them with the given schema,
+// one of the config.Schema* values.
+func recreateSchema(db *sql.DB, schema string) error {
 	stmts := []string{
 		`PRAGMA foreign_keys = ON`,
 		`PRAGMA journal_mode = WAL`,
 
+		`DROP TABLE IF EXISTS events`,
 		`DROP TABLE IF EXISTS comments`,
 		`DROP TABLE IF EXISTS articles`,
 		`DROP TABLE IF EXISTS users`,
+	}
+	stmts = append(stmts, schemaTables...)
 
-		`CREATE TABLE users (
-			id INTEGER PRIMARY KEY NOT NULL,
-			created INTEGER NOT NULL,
-			email TEXT NOT NULL,
-			active INTEGER NOT NULL
-		)`,
-		`CREATE INDEX users_created ON users(created)`,
-
-		`CREATE TABLE articles (
-			id INTEGER PRIMARY KEY NOT NULL,
-			created INTEGER NOT NULL,
-			userId INTEGER NOT NULL REFERENCES users(id),
-			text TEXT NOT NULL
-		)`,
-		`CREATE INDEX articles_created ON articles(created)`,
-		`CREATE INDEX articles_userId ON articles(userId)`,
-
-		`CREATE TABLE comments (
-			id INTEGER PRIMARY KEY NOT NULL,
-			created INTEGER NOT NULL,
-			articleId INTEGER NOT NULL REFERENCES articles(id),
-			text TEXT NOT NULL
-		)`,
-		`CREATE INDEX comments_created ON comments(created)`,
-		`CREATE INDEX comments_articleId ON comments(articleId)`,
+	switch schema {
+	case config.SchemaPlain:
+	case config.SchemaIndexed:
+		stmts = append(stmts, schemaIndexes...)
+	case config.SchemaWALHeavy:
+		stmts = append(stmts, schemaIndexes...)
+		stmts = append(stmts, schemaTriggers...)
+	default:
+		return fmt.Errorf("unknown schema %q", schema)
 	}
 
 	for _, s := range stmts {
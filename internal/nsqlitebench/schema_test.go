@@ -0,0 +1,113 @@
+package nsqlitebench
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaObjects returns the names of the tables, indexes and triggers of the
+// database, by type, without the internal ones of SQLite.
+func schemaObjects(t *testing.T, db *sql.DB) map[string][]string {
+	t.Helper()
+
+	rows, err := db.Query(`
+		SELECT type, name FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY type, name
+	`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	objects := map[string][]string{}
+	for rows.Next() {
+		var typ, name string
+		require.NoError(t, rows.Scan(&typ, &name))
+		objects[typ] = append(objects[typ], name)
+	}
+	require.NoError(t, rows.Err())
+	return objects
+}
+
+func TestRecreateSchema(t *testing.T) {
+	tables := []string{"articles", "comments", "users"}
+	indexes := []string{
+		"articles_created", "articles_userId", "comments_articleId", "comments_created",
+		"users_created", "users_email",
+	}
+
+	tests := []struct {
+		schema string
+		want   map[string][]string
+	}{
+		{
+			schema: config.SchemaPlain,
+			want:   map[string][]string{"table": tables},
+		},
+		{
+			schema: config.SchemaIndexed,
+			want:   map[string][]string{"table": tables, "index": indexes},
+		},
+		{
+			schema: config.SchemaWALHeavy,
+			want: map[string][]string{
+				"table": {"articles", "comments", "events", "users"},
+				"index": {
+					"articles_created", "articles_userId", "comments_articleId", "comments_created",
+					"events_created", "users_created", "users_email",
+				},
+				"trigger": {
+					"articles_insert_event", "comments_insert_event", "users_insert_event", "users_update_event",
+				},
+			},
+		},
+	}
+
+	// The schemas are recreated in the same database, each one replaces the
+	// objects of the previous one.
+	db, err := localDrivers[0].create(filepath.Join(t.TempDir(), "bench.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	for _, tt := range append(tests, tests[0]) {
+		t.Run(tt.schema, func(t *testing.T) {
+			require.NoError(t, recreateSchema(db, tt.schema))
+			assert.Equal(t, tt.want, schemaObjects(t, db))
+		})
+	}
+
+	t.Run("Unique emails", func(t *testing.T) {
+		require.NoError(t, recreateSchema(db, config.SchemaIndexed))
+
+		insert := `INSERT INTO users (created, email, active) VALUES (1, 'user@example.com', 1)`
+		_, err := db.Exec(insert)
+		require.NoError(t, err)
+		_, err = db.Exec(insert)
+		assert.ErrorContains(t, err, "constraint failed")
+	})
+
+	t.Run("Events of the wal-heavy schema", func(t *testing.T) {
+		require.NoError(t, recreateSchema(db, config.SchemaWALHeavy))
+
+		res, err := db.Exec(`INSERT INTO users (created, email, active) VALUES (1, 'user@example.com', 1)`)
+		require.NoError(t, err)
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected, "the rows of the triggers are not counted")
+
+		_, err = db.Exec(`UPDATE users SET active = 0`)
+		require.NoError(t, err)
+
+		var events int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM events WHERE tableName = 'users'`).Scan(&events))
+		assert.Equal(t, 2, events)
+	})
+
+	t.Run("Unknown schema", func(t *testing.T) {
+		assert.ErrorContains(t, recreateSchema(db, "unique"), `unknown schema "unique"`)
+	})
+}
@@ -0,0 +1,310 @@
+package nsqlitebench
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/resources"
+	"github.com/nsqlite/nsqlite/internal/util/numutil"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+)
+
+// SoakReport is the time series of a soak, the mixed workload run
+// continuously, with the trend of its metrics.
+type SoakReport struct {
+	// IntervalSec is the length of the interval of each point.
+	IntervalSec float64     `json:"intervalSec"`
+	Points      []SoakPoint `json:"points"`
+	// ThroughputSlope is the change of the operations per second in a
+	// minute, negative when the throughput degrades over time.
+	ThroughputSlope float64 `json:"throughputSlope"`
+	// HeapSlope is the change of the bytes of the heap in a minute.
+	HeapSlope float64 `json:"heapSlope"`
+	// WALSlope is the change of the bytes of the WAL in a minute, only
+	// reported if the size of the WAL is known.
+	WALSlope *float64 `json:"walSlope,omitempty"`
+}
+
+// SoakPoint are the metrics of an interval of a soak.
+type SoakPoint struct {
+	// ElapsedSec is the time since the start of the soak at the end of the
+	// interval.
+	ElapsedSec float64 `json:"elapsedSec"`
+	OpsPerSec  float64 `json:"opsPerSec"`
+	P99Ms      float64 `json:"p99Ms"`
+	Errors     uint64  `json:"errors"`
+	// HeapBytes are the bytes of the heap of nsqlitebench at the end of the
+	// interval.
+	HeapBytes uint64 `json:"heapBytes"`
+	// WALBytes is the size of the WAL at the end of the interval, only
+	// reported if it is known.
+	WALBytes *int64 `json:"walBytes,omitempty"`
+}
+
+// throughputChangePct returns the change of the throughput over the whole
+// soak following its slope, in percent of the mean throughput.
+func (r SoakReport) throughputChangePct() (float64, bool) {
+	if len(r.Points) < 2 {
+		return 0, false
+	}
+
+	mean := 0.0
+	for _, point := range r.Points {
+		mean += point.OpsPerSec
+	}
+	mean /= float64(len(r.Points))
+	if mean == 0 {
+		return 0, false
+	}
+
+	minutes := (r.Points[len(r.Points)-1].ElapsedSec - r.Points[0].ElapsedSec) / 60
+	return r.ThroughputSlope * minutes / mean * 100, true
+}
+
+// degraded reports whether the throughput dropped more than thresholdPct
+// percent over the soak.
+func (r SoakReport) degraded(thresholdPct float64) bool {
+	change, ok := r.throughputChangePct()
+	return ok && -change > thresholdPct
+}
+
+// soakOptions are the parameters of a soak.
+type soakOptions struct {
+	duration time.Duration
+	interval time.Duration
+	// walSize returns the size of the WAL, false if it is not known.
+	walSize func() (int64, bool)
+	// emit is called with each point as soon as its interval ends.
+	emit func(SoakPoint)
+}
+
+// runSoak seeds the users of the mixed benchmark and then runs its workload
+// continuously for the duration, recording a point with the metrics of each
+// interval. It returns the result of the whole soak and its time series.
+func runSoak(db *sql.DB, fullConfig benchmarksConfig, opts soakOptions) (benchmarkResult, SoakReport, error) {
+	conf := fullConfig.benchmarkMixedConfig
+	workload, err := newMixedWorkload(conf)
+	if err != nil {
+		return benchmarkResult{}, SoakReport{}, err
+	}
+	if err := recreateSchema(db, fullConfig.schema); err != nil {
+		return benchmarkResult{}, SoakReport{}, err
+	}
+	if err := seedUsers(db, conf.seedUsers, newDataGenerator(fullConfig.seed, "mixed users")); err != nil {
+		return benchmarkResult{}, SoakReport{}, fmt.Errorf("error seeding users: %w", err)
+	}
+
+	// The operations are recorded in the counters of the whole soak and in
+	// those of the current interval, which are replaced when it ends.
+	total := newMixedCounters()
+	var interval atomic.Pointer[mixedCounters]
+	interval.Store(newMixedCounters())
+	record := func(op int, latency time.Duration, reads uint64, writes uint64, err error) {
+		total.record(op, latency, reads, writes, err)
+		interval.Load().record(op, latency, reads, writes, err)
+	}
+
+	start := time.Now()
+	resourcesStart := resources.Take()
+	running := make(chan struct{})
+	go func() {
+		defer close(running)
+		workload.run(db, newDataGenerator(fullConfig.seed, "mixed workers"), start.Add(opts.duration), record)
+	}()
+
+	report := SoakReport{IntervalSec: opts.interval.Seconds()}
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+	intervalStart := start
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+		case <-running:
+			done = true
+		}
+
+		now := time.Now()
+		counters := interval.Swap(newMixedCounters())
+		length := now.Sub(intervalStart)
+		intervalStart = now
+		// The last interval is cut by the end of the soak, it is dropped if
+		// it is too short for its throughput to be meaningful.
+		tooShort := done && length < opts.interval/2 && len(report.Points) > 0
+		if length <= 0 || tooShort {
+			continue
+		}
+		point := newSoakPoint(counters, now.Sub(start), length, opts.walSize)
+
+		report.Points = append(report.Points, point)
+		if opts.emit != nil {
+			opts.emit(point)
+		}
+	}
+
+	result := total.result("Soak", time.Since(start), conf.report())
+	result.Resources = resources.Take().Since(resourcesStart)
+	report.ThroughputSlope, report.HeapSlope, report.WALSlope = soakSlopes(report.Points)
+	return result, report, nil
+}
+
+// newSoakPoint returns the point of an interval of the given length that
+// ends elapsed after the start of the soak.
+func newSoakPoint(
+	counters *mixedCounters, elapsed time.Duration, length time.Duration, walSize func() (int64, bool),
+) SoakPoint {
+	ops, errs := counters.operations()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	point := SoakPoint{
+		ElapsedSec: elapsed.Seconds(),
+		OpsPerSec:  float64(ops) / length.Seconds(),
+		P99Ms:      durationMs(counters.latency.Percentile(99)),
+		Errors:     errs,
+		HeapBytes:  mem.HeapAlloc,
+	}
+	if walSize != nil {
+		if size, ok := walSize(); ok {
+			point.WALBytes = &size
+		}
+	}
+	return point
+}
+
+// soakSlopes returns the slopes per minute of the throughput, the heap and
+// the WAL of the points. The slope of the WAL is nil unless its size is
+// known in every point.
+func soakSlopes(points []SoakPoint) (float64, float64, *float64) {
+	minutes := make([]float64, len(points))
+	throughput := make([]float64, len(points))
+	heap := make([]float64, len(points))
+	wal := make([]float64, 0, len(points))
+	for i, point := range points {
+		minutes[i] = point.ElapsedSec / 60
+		throughput[i] = point.OpsPerSec
+		heap[i] = float64(point.HeapBytes)
+		if point.WALBytes != nil {
+			wal = append(wal, float64(*point.WALBytes))
+		}
+	}
+
+	var walSlope *float64
+	if len(points) > 0 && len(wal) == len(points) {
+		slope := linearSlope(minutes, wal)
+		walSlope = &slope
+	}
+	return linearSlope(minutes, throughput), linearSlope(minutes, heap), walSlope
+}
+
+// linearSlope returns the slope of the least squares line of the points
+// (xs[i], ys[i]), zero if the xs are all the same.
+func linearSlope(xs []float64, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return covariance / variance
+}
+
+// formatSoakPoint formats a point as the line printed when its interval
+// ends.
+func formatSoakPoint(point SoakPoint) string {
+	line := fmt.Sprintf(
+		"Soak %s: %.0f ops/sec, p99 %s, %d errors, heap %s",
+		time.Duration(point.ElapsedSec*float64(time.Second)).Round(time.Second),
+		point.OpsPerSec, msDuration(point.P99Ms), point.Errors, numutil.Bytes(int64(point.HeapBytes)),
+	)
+	if point.WALBytes != nil {
+		line += ", WAL " + numutil.Bytes(*point.WALBytes)
+	}
+	return line
+}
+
+// renderSoakTrend renders the slopes of the soak, with the throughput
+// colored red if it dropped more than thresholdPct percent over the soak.
+func renderSoakTrend(report SoakReport, thresholdPct float64) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Metric", "Change per minute"})
+
+	throughput := fmt.Sprintf("%+.2f ops/sec", report.ThroughputSlope)
+	if change, ok := report.throughputChangePct(); ok {
+		throughput += fmt.Sprintf(" (%+.2f%% over the soak)", change)
+	}
+	if report.degraded(thresholdPct) {
+		throughput = color.RedString(throughput)
+	}
+	tw.AppendRow(table.Row{"Throughput", throughput})
+	tw.AppendRow(table.Row{"Heap", formatBytesSlope(report.HeapSlope)})
+	if report.WALSlope != nil {
+		tw.AppendRow(table.Row{"WAL", formatBytesSlope(*report.WALSlope)})
+	} else {
+		tw.AppendRow(table.Row{"WAL", "-"})
+	}
+
+	return tw.Render()
+}
+
+// formatBytesSlope formats a change of bytes with its sign.
+func formatBytesSlope(slope float64) string {
+	if slope < 0 {
+		return "-" + numutil.Bytes(int64(-slope))
+	}
+	return "+" + numutil.Bytes(int64(slope))
+}
+
+// fileWALSize returns a function that returns the size of the WAL of the
+// SQLite database at dbPath, it is not known if the file does not exist,
+// like for the databases that are not in WAL mode.
+func fileWALSize(dbPath string) func() (int64, bool) {
+	return func() (int64, bool) {
+		info, err := os.Stat(dbPath + "-wal")
+		if err != nil {
+			return 0, false
+		}
+		return info.Size(), true
+	}
+}
+
+// statsWALSize returns a function that returns the size of the WAL reported
+// by the stats of the NSQLite server with the given connection string, it
+// is not known if the server does not report it.
+func statsWALSize(ctx context.Context, dsn string) (func() (int64, bool), error) {
+	connStr, err := nsqlitedsn.NewConnStrFromText(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection string: %w", err)
+	}
+	client := apiclient.NewClient(connStr)
+
+	return func() (int64, bool) {
+		stats, err := client.Stats(ctx)
+		if err != nil || stats.WALSize == nil {
+			return 0, false
+		}
+		return *stats.WALSize, true
+	}, nil
+}
@@ -0,0 +1,179 @@
+package nsqlitebench
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSoak(t *testing.T) {
+	// A single connection, every connection to :memory: has its own
+	// database.
+	db, err := localDrivers[0].create(":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	cfg := benchmarksConfig{seed: 1, schema: config.SchemaIndexed, benchmarkMixedConfig: benchmarkMixedConfig{
+		seedUsers:  100,
+		goroutines: 4,
+		rangeSize:  10,
+		mix:        config.Mix{PointReads: 70, RangeReads: 20, Inserts: 5, Updates: 5},
+	}}
+
+	emitted := []SoakPoint{}
+	result, report, err := runSoak(db, cfg, soakOptions{
+		duration: 2 * time.Second,
+		interval: 500 * time.Millisecond,
+		walSize:  func() (int64, bool) { return 0, false },
+		emit:     func(point SoakPoint) { emitted = append(emitted, point) },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Soak", result.Name)
+	assert.GreaterOrEqual(t, result.Duration, 2*time.Second)
+	assert.Positive(t, result.TotalReads)
+	assert.Zero(t, result.Errors)
+	assert.Positive(t, result.Resources.Allocs)
+
+	assert.Equal(t, 0.5, report.IntervalSec)
+	assert.Equal(t, emitted, report.Points)
+	require.GreaterOrEqual(t, len(report.Points), 3)
+	require.LessOrEqual(t, len(report.Points), 5)
+	for i, point := range report.Points {
+		if i > 0 {
+			assert.Greater(t, point.ElapsedSec, report.Points[i-1].ElapsedSec)
+		}
+		assert.Positive(t, point.OpsPerSec)
+		assert.Positive(t, point.P99Ms)
+		assert.Positive(t, point.HeapBytes)
+		assert.Nil(t, point.WALBytes, "an in-memory database has no WAL")
+	}
+	assert.InDelta(t, 2, report.Points[len(report.Points)-1].ElapsedSec, 0.5)
+	assert.Nil(t, report.WALSlope)
+}
+
+func TestSoakSlopes(t *testing.T) {
+	wal := func(size int64) *int64 { return &size }
+	points := []SoakPoint{
+		{ElapsedSec: 60, OpsPerSec: 1000, HeapBytes: 1 << 20, WALBytes: wal(0)},
+		{ElapsedSec: 120, OpsPerSec: 900, HeapBytes: 2 << 20, WALBytes: wal(1000)},
+		{ElapsedSec: 180, OpsPerSec: 800, HeapBytes: 3 << 20, WALBytes: wal(2000)},
+	}
+
+	throughput, heap, walSlope := soakSlopes(points)
+	assert.InDelta(t, -100, throughput, 1e-9)
+	assert.InDelta(t, 1<<20, heap, 1e-9)
+	require.NotNil(t, walSlope)
+	assert.InDelta(t, 1000, *walSlope, 1e-9)
+
+	report := SoakReport{Points: points, ThroughputSlope: throughput}
+	change, ok := report.throughputChangePct()
+	require.True(t, ok)
+	assert.InDelta(t, -22.22, change, 0.01, "-200 ops/sec over a mean of 900")
+	assert.True(t, report.degraded(10))
+	assert.False(t, report.degraded(25))
+
+	t.Run("Unknown WAL size", func(t *testing.T) {
+		points[1].WALBytes = nil
+		_, _, walSlope := soakSlopes(points)
+		assert.Nil(t, walSlope)
+	})
+
+	t.Run("Single point", func(t *testing.T) {
+		throughput, heap, _ := soakSlopes(points[:1])
+		assert.Zero(t, throughput)
+		assert.Zero(t, heap)
+		_, ok := SoakReport{Points: points[:1]}.throughputChangePct()
+		assert.False(t, ok)
+	})
+}
+
+func TestLinearSlope(t *testing.T) {
+	assert.InDelta(t, 2, linearSlope([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9}), 1e-9)
+	assert.InDelta(t, 0.5, linearSlope([]float64{0, 2, 4}, []float64{1, 1, 3}), 1e-9)
+	assert.Zero(t, linearSlope([]float64{1, 1}, []float64{1, 5}))
+	assert.Zero(t, linearSlope(nil, nil))
+}
+
+func TestFileWALSize(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "bench.sqlite")
+	walSize := fileWALSize(dbPath)
+
+	_, ok := walSize()
+	assert.False(t, ok)
+
+	require.NoError(t, os.WriteFile(dbPath+"-wal", make([]byte, 4096), 0o600))
+	size, ok := walSize()
+	assert.True(t, ok)
+	assert.Equal(t, int64(4096), size)
+}
+
+func TestFormatSoakPoint(t *testing.T) {
+	wal := int64(2 << 20)
+	assert.Equal(t,
+		"Soak 10s: 1235 ops/sec, p99 2.5ms, 3 errors, heap 1.0 MiB, WAL 2.0 MiB",
+		formatSoakPoint(SoakPoint{
+			ElapsedSec: 10.02, OpsPerSec: 1234.6, P99Ms: 2.5, Errors: 3, HeapBytes: 1 << 20, WALBytes: &wal,
+		}),
+	)
+	assert.Equal(t,
+		"Soak 20s: 0 ops/sec, p99 0s, 0 errors, heap 0 B",
+		formatSoakPoint(SoakPoint{ElapsedSec: 20}),
+	)
+}
+
+func TestWriteReportSoak(t *testing.T) {
+	walSlope := 512.0
+	report := Report{Drivers: []DriverReport{{
+		Driver: config.DriverMattn,
+		Soak: &SoakReport{
+			IntervalSec: 10,
+			Points: []SoakPoint{
+				{ElapsedSec: 10, OpsPerSec: 1000, P99Ms: 2, HeapBytes: 1024},
+				{ElapsedSec: 20, OpsPerSec: 900, P99Ms: 3, Errors: 1, HeapBytes: 2048},
+			},
+			ThroughputSlope: -600,
+			HeapSlope:       6144,
+			WALSlope:        &walSlope,
+		},
+	}}}
+
+	t.Run("JSON", func(t *testing.T) {
+		out := bytes.Buffer{}
+		require.NoError(t, writeReport(&out, config.OutputJSON, report))
+
+		decoded := struct {
+			Drivers []struct {
+				Soak json.RawMessage `json:"soak"`
+			} `json:"drivers"`
+		}{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.JSONEq(t, `{
+			"intervalSec": 10,
+			"points": [
+				{"elapsedSec": 10, "opsPerSec": 1000, "p99Ms": 2, "errors": 0, "heapBytes": 1024},
+				{"elapsedSec": 20, "opsPerSec": 900, "p99Ms": 3, "errors": 1, "heapBytes": 2048}
+			],
+			"throughputSlope": -600,
+			"heapSlope": 6144,
+			"walSlope": 512
+		}`, string(decoded.Drivers[0].Soak))
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		out := bytes.Buffer{}
+		require.NoError(t, writeReport(&out, config.OutputTable, report))
+		assert.Contains(t, out.String(), "--- Trend of the soak ---")
+		assert.Contains(t, out.String(), "-600.00 ops/sec (-10.53% over the soak)")
+		assert.Contains(t, out.String(), "+6.0 KiB")
+		assert.Contains(t, out.String(), "+512 B")
+	})
+}
@@ -0,0 +1,116 @@
+package nsqlitebench
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/nsqlite/nsqlite/internal/nsqlite/styled"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/histogram"
+)
+
+// sweepBenchmarks are the benchmarks that can be run in the concurrency
+// sweep, by their name in --sweep-benchmark.
+var sweepBenchmarks = map[string]func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error){
+	config.BenchmarkSimple:  singleResult(runBenchmarkSimple),
+	config.BenchmarkComplex: singleResult(runBenchmarkComplex),
+	config.BenchmarkMany:    singleResult(runBenchmarkMany),
+	config.BenchmarkLarge:   runBenchmarkLarge,
+	config.BenchmarkMixed:   singleResult(runBenchmarkMixed),
+}
+
+// SweepPoint is the result of the benchmark of the concurrency sweep with a
+// number of goroutines.
+type SweepPoint struct {
+	Goroutines int     `json:"goroutines"`
+	OpsPerSec  float64 `json:"opsPerSec"`
+	P99Ms      float64 `json:"p99Ms"`
+}
+
+// withGoroutines returns the config with every benchmark using n
+// goroutines.
+func (c benchmarksConfig) withGoroutines(n int) benchmarksConfig {
+	c.benchmarkSimpleConfig.insertGoroutines = n
+	c.benchmarkSimpleConfig.queryGoroutines = n
+	c.benchmarkComplexConfig.insertGoroutines = n
+	c.benchmarkManyConfig.insertGoroutines = n
+	c.benchmarkManyConfig.queryGoroutines = n
+	c.benchmarkLargeConfig.insertGoroutines = n
+	c.benchmarkMixedConfig.goroutines = n
+	return c
+}
+
+// runSweep runs the benchmark once for each number of goroutines of levels,
+// with the schema recreated before each run. It returns the results of
+// every level, named after their goroutines, and the throughput and p99
+// latency of each level.
+func runSweep(
+	db *sql.DB, cfg benchmarksConfig,
+	bench func(*sql.DB, benchmarksConfig) ([]benchmarkResult, error),
+	levels []int, opts runOptions,
+) ([]benchmarkResult, []SweepPoint, error) {
+	var results []benchmarkResult
+	var points []SweepPoint
+
+	for _, level := range levels {
+		levelResults, err := repeatBenchmark(db, cfg.withGoroutines(level), bench, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error with %d goroutines: %w", level, err)
+		}
+
+		var ops uint64
+		var duration time.Duration
+		latency := histogram.New()
+		for i := range levelResults {
+			ops += levelResults[i].TotalReads + levelResults[i].TotalWrites
+			duration += levelResults[i].Duration
+			latency.Merge(levelResults[i].Latency)
+			levelResults[i].Name = fmt.Sprintf("%s (%d goroutines)", levelResults[i].Name, level)
+		}
+
+		point := SweepPoint{Goroutines: level, P99Ms: durationMs(latency.Percentile(99))}
+		if duration > 0 {
+			point.OpsPerSec = float64(ops) / duration.Seconds()
+		}
+		points = append(points, point)
+		results = append(results, levelResults...)
+	}
+
+	return results, points, nil
+}
+
+// bestSweepPoint returns the point of the sweep with the highest
+// throughput, the one with fewer goroutines on ties.
+func bestSweepPoint(points []SweepPoint) (SweepPoint, bool) {
+	if len(points) == 0 {
+		return SweepPoint{}, false
+	}
+
+	best := points[0]
+	for _, point := range points[1:] {
+		if point.OpsPerSec > best.OpsPerSec ||
+			(point.OpsPerSec == best.OpsPerSec && point.Goroutines < best.Goroutines) {
+			best = point
+		}
+	}
+	return best, true
+}
+
+// renderSweep renders the throughput and p99 latency of each level of the
+// concurrency sweep of a driver, followed by the level with the best
+// throughput.
+func renderSweep(points []SweepPoint) string {
+	tw := styled.NewTableWriter()
+	tw.AppendHeader(table.Row{"Goroutines", "Ops/sec", "p99"})
+	for _, point := range points {
+		tw.AppendRow(table.Row{point.Goroutines, fmt.Sprintf("%.0f", point.OpsPerSec), msDuration(point.P99Ms)})
+	}
+
+	out := tw.Render()
+	if best, ok := bestSweepPoint(points); ok {
+		out += fmt.Sprintf("\nBest throughput with %d goroutines (%.0f ops/sec)", best.Goroutines, best.OpsPerSec)
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package nsqlitebench
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlitebench/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSweep(t *testing.T) {
+	db, err := localDrivers[0].create(filepath.Join(t.TempDir(), "bench.sqlite"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	cfg := benchmarksConfig{schema: config.SchemaIndexed, benchmarkSimpleConfig: benchmarkSimpleConfig{
+		insertXUsers:     20,
+		queryYUsers:      30,
+		insertGoroutines: 100,
+		queryGoroutines:  100,
+	}}
+
+	results, points, err := runSweep(db, cfg, sweepBenchmarks[config.BenchmarkSimple], []int{1, 2}, runOptions{repeat: 1})
+	require.NoError(t, err)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, "Simple (1 goroutines)", results[0].Name)
+	assert.Equal(t, "Simple (2 goroutines)", results[1].Name)
+	assert.Equal(t, 1, results[0].Config["insertGoroutines"])
+	assert.Equal(t, 2, results[1].Config["queryGoroutines"])
+	for _, result := range results {
+		assert.Equal(t, uint64(20), result.TotalWrites, "the schema is recreated between levels")
+		assert.Equal(t, uint64(50), result.TotalReads)
+	}
+
+	require.Len(t, points, 2)
+	assert.Equal(t, 1, points[0].Goroutines)
+	assert.Equal(t, 2, points[1].Goroutines)
+	for _, point := range points {
+		assert.Positive(t, point.OpsPerSec)
+		assert.Positive(t, point.P99Ms)
+	}
+}
+
+func TestBestSweepPoint(t *testing.T) {
+	_, ok := bestSweepPoint(nil)
+	assert.False(t, ok)
+
+	best, ok := bestSweepPoint([]SweepPoint{
+		{Goroutines: 1, OpsPerSec: 100},
+		{Goroutines: 2, OpsPerSec: 180},
+		{Goroutines: 4, OpsPerSec: 250},
+		{Goroutines: 8, OpsPerSec: 250},
+		{Goroutines: 16, OpsPerSec: 200},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, 4, best.Goroutines, "ties pick fewer goroutines")
+}
+
+func TestWriteReportSweep(t *testing.T) {
+	report := Report{Drivers: []DriverReport{{
+		Driver:         config.DriverMattn,
+		Benchmarks:     []BenchmarkReport{{Name: "Simple (1 goroutines)"}, {Name: "Simple (2 goroutines)"}},
+		Sweep:          []SweepPoint{{Goroutines: 1, OpsPerSec: 100, P99Ms: 2}, {Goroutines: 2, OpsPerSec: 150, P99Ms: 3}},
+		BestGoroutines: 2,
+	}}}
+
+	t.Run("JSON", func(t *testing.T) {
+		out := bytes.Buffer{}
+		require.NoError(t, writeReport(&out, config.OutputJSON, report))
+
+		decoded := Report{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		assert.Equal(t, report.Drivers[0].Sweep, decoded.Drivers[0].Sweep)
+		assert.Contains(t, out.String(), `"bestGoroutines": 2`)
+	})
+
+	t.Run("Table", func(t *testing.T) {
+		out := bytes.Buffer{}
+		require.NoError(t, writeReport(&out, config.OutputTable, report))
+		assert.Contains(t, out.String(), "Goroutines")
+		assert.Contains(t, out.String(), "Best throughput with 2 goroutines (150 ops/sec)")
+	})
+}
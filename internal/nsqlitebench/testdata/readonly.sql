@@ -0,0 +1,48 @@
+-- Fixture database of the read-only benchmark tests, built with:
+--
+--   sqlite3 testdata/readonly.sqlite < testdata/readonly.sql
+--
+-- The user IDs start at 101 and have gaps, so the point reads must use the
+-- actual range of IDs.
+
+CREATE TABLE users (
+  id INTEGER PRIMARY KEY NOT NULL,
+  created INTEGER NOT NULL,
+  email TEXT NOT NULL,
+  active INTEGER NOT NULL
+);
+CREATE INDEX users_created ON users(created);
+
+CREATE TABLE articles (
+  id INTEGER PRIMARY KEY NOT NULL,
+  created INTEGER NOT NULL,
+  userId INTEGER NOT NULL REFERENCES users(id),
+  text TEXT NOT NULL
+);
+CREATE INDEX articles_created ON articles(created);
+CREATE INDEX articles_userId ON articles(userId);
+
+CREATE TABLE comments (
+  id INTEGER PRIMARY KEY NOT NULL,
+  created INTEGER NOT NULL,
+  articleId INTEGER NOT NULL REFERENCES articles(id),
+  text TEXT NOT NULL
+);
+CREATE INDEX comments_created ON comments(created);
+CREATE INDEX comments_articleId ON comments(articleId);
+
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 50)
+INSERT INTO users (id, created, email, active)
+SELECT 100 + i, 1735689600 + i * 3600, 'user' || i || '@example.com', i % 2
+FROM n;
+DELETE FROM users WHERE id % 7 = 0;
+
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 100)
+INSERT INTO articles (created, userId, text)
+SELECT 1735689600 + i * 60, 101 + i % 50 + ((101 + i % 50) % 7 = 0), 'Article ' || i
+FROM n;
+
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 200)
+INSERT INTO comments (created, articleId, text)
+SELECT 1735689600 + i * 30, 1 + i % 90, 'Comment ' || i
+FROM n;
@@ -0,0 +1,131 @@
+// Package authtoken generates and hashes the auth tokens of nsqlited.
+package authtoken
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/util/cryptoutil"
+)
+
+// Algorithms are the hash algorithms of the auth token. The auth token can
+// also be the secret of the signed tokens, with HMACAlgorithm.
+var Algorithms = []string{"plaintext", "argon2", "bcrypt", "scrypt", "pbkdf2"}
+
+// tokenBytes is the number of random bytes of a generated token.
+const tokenBytes = 32
+
+// Generate returns a new random token, safe to use in a connection string.
+func Generate() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Hash returns the hash of the token with the algorithm, the one to
+// configure as the auth token of the server.
+//
+// The argon2 parameters are optional, the default ones are used if not
+// provided.
+func Hash(algorithm string, token string, argon2Params ...cryptoutil.Argon2Params) (string, error) {
+	switch algorithm {
+	case "plaintext":
+		return token, nil
+	case "argon2":
+		return cryptoutil.Argon2GenerateHash(token, argon2Params...)
+	case "bcrypt":
+		return cryptoutil.BcryptGenerateHash(token)
+	case "scrypt":
+		return cryptoutil.ScryptGenerateHash(token)
+	case "pbkdf2":
+		return cryptoutil.PBKDF2GenerateHash(token)
+	case HMACAlgorithm:
+		return "", errors.New("the hmac algorithm signs tokens, issue them with issue-token")
+	default:
+		return "", fmt.Errorf(
+			"invalid auth algorithm, valid values are: %s", strings.Join(Algorithms, ", "),
+		)
+	}
+}
+
+// bootstrapFile is the contents of the bootstrap file, it never has the
+// plaintext token.
+type bootstrapFile struct {
+	Algorithm string `json:"algorithm"`
+	TokenHash string `json:"tokenHash"`
+}
+
+// BootstrapAlgorithm is the algorithm of the hash of the bootstrap token.
+const BootstrapAlgorithm = "argon2"
+
+// LoadOrCreateBootstrap returns the hash of the token of the bootstrap file
+// at path. If the file does not exist and create is true, it generates a
+// token and stores its hash in the file, the plaintext token is only
+// returned then. The hash is empty if there is no file and create is false.
+func LoadOrCreateBootstrap(path string, create bool) (hash string, token string, err error) {
+	contents, err := os.ReadFile(path)
+	if err == nil {
+		file := bootstrapFile{}
+		if err := json.Unmarshal(contents, &file); err != nil {
+			return "", "", fmt.Errorf("invalid bootstrap file %s: %w", path, err)
+		}
+		if file.Algorithm != BootstrapAlgorithm || file.TokenHash == "" {
+			return "", "", fmt.Errorf("invalid bootstrap file %s: missing %s token hash", path, BootstrapAlgorithm)
+		}
+		return file.TokenHash, "", nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", "", fmt.Errorf("failed to read bootstrap file: %w", err)
+	}
+	if !create {
+		return "", "", nil
+	}
+
+	token, err = Generate()
+	if err != nil {
+		return "", "", err
+	}
+	hash, err = Hash(BootstrapAlgorithm, token)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash token: %w", err)
+	}
+	contents, err = json.MarshalIndent(bootstrapFile{Algorithm: BootstrapAlgorithm, TokenHash: hash}, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	if err := writeFileAtomic(path, append(contents, '\n')); err != nil {
+		return "", "", fmt.Errorf("failed to write bootstrap file: %w", err)
+	}
+	return hash, token, nil
+}
+
+// writeFileAtomic writes the file readable only by its owner, through a
+// temporary file so it is never left half written.
+func writeFileAtomic(path string, contents []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,94 @@
+package authtoken
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/util/cryptoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	first, err := Generate()
+	require.NoError(t, err)
+	second, err := Generate()
+	require.NoError(t, err)
+
+	assert.Len(t, first, 43)
+	assert.Regexp(t, `^[A-Za-z0-9_-]+$`, first, "the token is safe in a connection string")
+	assert.NotEqual(t, first, second)
+}
+
+func TestHash(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			hash, err := Hash(algorithm, "token")
+			require.NoError(t, err)
+
+			switch algorithm {
+			case "plaintext":
+				assert.Equal(t, "token", hash)
+			case "argon2":
+				assert.True(t, cryptoutil.Argon2CheckHash("token", hash))
+			case "bcrypt":
+				assert.True(t, cryptoutil.BcryptCheckHash("token", hash))
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Hash("md5", "token")
+		assert.ErrorContains(t, err, "invalid auth algorithm")
+	})
+}
+
+func TestLoadOrCreateBootstrap(t *testing.T) {
+	t.Run("Created and loaded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bootstrap-auth.json")
+
+		hash, token, err := LoadOrCreateBootstrap(path, true)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		assert.True(t, cryptoutil.Argon2CheckHash(token, hash))
+
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(contents), token, "the plaintext token is never stored")
+		assert.Contains(t, string(contents), hash)
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+
+		loadedHash, loadedToken, err := LoadOrCreateBootstrap(path, true)
+		require.NoError(t, err)
+		assert.Equal(t, hash, loadedHash)
+		assert.Empty(t, loadedToken, "the token is only returned when it is generated")
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "no temporary file is left")
+	})
+
+	t.Run("Not created", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bootstrap-auth.json")
+
+		hash, token, err := LoadOrCreateBootstrap(path, false)
+		require.NoError(t, err)
+		assert.Empty(t, hash)
+		assert.Empty(t, token)
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("Invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bootstrap-auth.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"algorithm": "plaintext", "tokenHash": "token"}`), 0600))
+
+		_, _, err := LoadOrCreateBootstrap(path, true)
+		assert.ErrorContains(t, err, "invalid bootstrap file")
+	})
+}
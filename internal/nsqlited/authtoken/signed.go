@@ -0,0 +1,111 @@
+package authtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HMACAlgorithm is the auth token algorithm whose token is a secret that
+// signs short-lived tokens, instead of the hash of the only token accepted.
+const HMACAlgorithm = "hmac"
+
+// DefaultClockSkew is the tolerance of the times of the signed tokens, for
+// the clocks of the issuer and the server that are not in sync.
+const DefaultClockSkew = time.Minute
+
+// The roles of the signed tokens.
+const (
+	// RoleReadWrite allows every query.
+	RoleReadWrite = "rw"
+	// RoleReadOnly only allows the queries that don't write.
+	RoleReadOnly = "ro"
+)
+
+// Roles are the valid roles of the signed tokens.
+var Roles = []string{RoleReadWrite, RoleReadOnly}
+
+// The errors of the verification of the signed tokens.
+var (
+	ErrSignedTokenInvalid  = errors.New("invalid signed token")
+	ErrSignedTokenExpired  = errors.New("signed token expired")
+	ErrSignedTokenNotValid = errors.New("signed token not valid yet")
+)
+
+// Claims are the payload of a signed token.
+type Claims struct {
+	// Role is the role of the client, one of Roles.
+	Role string `json:"role"`
+	// IssuedAt and ExpiresAt are Unix times in seconds.
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// Issue returns a token signed with the secret for the role, valid from now
+// for ttl. The token is base64(payload).base64(HMAC-SHA256(payload)), with
+// the URL-safe base64 without padding, so it is safe in a connection string.
+func Issue(secret string, role string, now time.Time, ttl time.Duration) (string, error) {
+	if secret == "" {
+		return "", errors.New("the secret cannot be empty")
+	}
+	if !slices.Contains(Roles, role) {
+		return "", fmt.Errorf("invalid role %q, valid values are: %s", role, strings.Join(Roles, ", "))
+	}
+	if ttl <= 0 {
+		return "", errors.New("invalid ttl, must be greater than zero")
+	}
+
+	payload, err := json.Marshal(Claims{
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(secret, encoded)), nil
+}
+
+// Verify returns the claims of a token signed with the secret. The token
+// must not be expired at now, nor issued after it, with a tolerance of skew.
+func Verify(secret string, token string, now time.Time, skew time.Duration) (Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return Claims{}, ErrSignedTokenInvalid
+	}
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, sign(secret, encoded)) {
+		return Claims{}, ErrSignedTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrSignedTokenInvalid
+	}
+	claims := Claims{}
+	if err := json.Unmarshal(payload, &claims); err != nil || !slices.Contains(Roles, claims.Role) {
+		return Claims{}, ErrSignedTokenInvalid
+	}
+
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(skew)) {
+		return Claims{}, ErrSignedTokenExpired
+	}
+	if now.Add(skew).Before(time.Unix(claims.IssuedAt, 0)) {
+		return Claims{}, ErrSignedTokenNotValid
+	}
+	return claims, nil
+}
+
+// sign returns the HMAC-SHA256 of the encoded payload with the secret.
+func sign(secret string, encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,69 @@
+package authtoken
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	token, err := Issue("secret", RoleReadOnly, now, time.Hour)
+	require.NoError(t, err)
+	assert.Regexp(t, `^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+$`, token, "the token is safe in a connection string")
+
+	otherToken, err := Issue("secret", RoleReadWrite, now, time.Hour)
+	require.NoError(t, err)
+	payload, signature, _ := strings.Cut(token, ".")
+	otherPayload, _, _ := strings.Cut(otherToken, ".")
+
+	tests := []struct {
+		name    string
+		secret  string
+		token   string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "Valid", secret: "secret", token: token, now: now.Add(30 * time.Minute)},
+		{name: "Valid within the skew", secret: "secret", token: token, now: now.Add(time.Hour + 30*time.Second)},
+		{name: "Expired", secret: "secret", token: token, now: now.Add(2 * time.Hour), wantErr: ErrSignedTokenExpired},
+		{name: "Issued in the future", secret: "secret", token: token, now: now.Add(-2 * time.Minute), wantErr: ErrSignedTokenNotValid},
+		{name: "Wrong key", secret: "other-secret", token: token, now: now, wantErr: ErrSignedTokenInvalid},
+		{name: "Tampered payload", secret: "secret", token: otherPayload + "." + signature, now: now, wantErr: ErrSignedTokenInvalid},
+		{name: "Tampered signature", secret: "secret", token: payload + ".AAAA", now: now, wantErr: ErrSignedTokenInvalid},
+		{name: "No signature", secret: "secret", token: payload, now: now, wantErr: ErrSignedTokenInvalid},
+		{name: "Empty secret", secret: "", token: token, now: now, wantErr: ErrSignedTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := Verify(tt.secret, tt.token, tt.now, DefaultClockSkew)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, Claims{
+				Role:      RoleReadOnly,
+				IssuedAt:  now.Unix(),
+				ExpiresAt: now.Add(time.Hour).Unix(),
+			}, claims)
+		})
+	}
+}
+
+func TestIssueInvalid(t *testing.T) {
+	now := time.Now()
+
+	_, err := Issue("", RoleReadWrite, now, time.Hour)
+	assert.ErrorContains(t, err, "secret cannot be empty")
+
+	_, err = Issue("secret", "admin", now, time.Hour)
+	assert.ErrorContains(t, err, `invalid role "admin"`)
+
+	_, err = Issue("secret", RoleReadWrite, now, 0)
+	assert.ErrorContains(t, err, "invalid ttl")
+}
@@ -0,0 +1,64 @@
+package nsqlited
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
+)
+
+// bootstrapAuth sets the auth token of the configuration to the hash in the
+// bootstrap file of the data directory, if no auth token is configured,
+// neither with --auth-token nor in the auth tokens of the configuration file.
+// With --bootstrap-auth and a fresh data directory, the token is generated
+// first and also returned in plaintext, so it is printed only once.
+func bootstrapAuth(conf config.Config, fresh bool) (config.Config, string, error) {
+	if conf.AuthToken != "" || len(conf.AuthTokens) > 0 {
+		return conf, "", nil
+	}
+
+	path := datadir.NewLayout(conf.DataDirectory).BootstrapAuth
+	hash, token, err := authtoken.LoadOrCreateBootstrap(path, conf.BootstrapAuth && fresh)
+	if err != nil {
+		return conf, "", err
+	}
+	if hash == "" {
+		if conf.BootstrapAuth {
+			return conf, "", errors.New(
+				"--bootstrap-auth only generates a token for a fresh data directory, " +
+					"configure the auth token of an existing one with --auth-token",
+			)
+		}
+		return conf, "", nil
+	}
+
+	conf.AuthTokenAlgorithm = authtoken.BootstrapAlgorithm
+	conf.AuthToken = hash
+	return conf, token, nil
+}
+
+// printBootstrapToken prints the generated token with the connection
+// string of the server.
+func printBootstrapToken(w io.Writer, conf config.Config, token string) {
+	// The token is shown with the first TCP host, unix sockets can't be
+	// used in connection strings.
+	host := "localhost"
+	for _, listenHost := range strings.Split(conf.ListenHost, ",") {
+		listenHost = strings.TrimSpace(listenHost)
+		if listenHost != "" && !strings.HasPrefix(listenHost, "unix:") {
+			if listenHost != "0.0.0.0" {
+				host = listenHost
+			}
+			break
+		}
+	}
+
+	fmt.Fprintf(w, "\nGenerated the auth token of the server, it will not be shown again:\n\n")
+	fmt.Fprintf(w, "  %s\n\n", token)
+	fmt.Fprintf(w, "Only its hash is stored, connect with:\n\n")
+	fmt.Fprintf(w, "  nsqlite \"http://%s:%s?authToken=%s\"\n\n", host, conf.ListenPort, token)
+}
@@ -0,0 +1,62 @@
+package nsqlited
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/config"
+	"github.com/nsqlite/nsqlite/internal/util/cryptoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapAuth(t *testing.T) {
+	t.Run("Fresh data directory", func(t *testing.T) {
+		conf := newBootstrapConfig(t)
+
+		bootstrapped, token, err := bootstrapAuth(conf, true)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		assert.Equal(t, "argon2", bootstrapped.AuthTokenAlgorithm)
+		assert.True(t, cryptoutil.Argon2CheckHash(token, bootstrapped.AuthToken))
+
+		// The hash is loaded on the following starts, even without the
+		// flag, and the token is not shown again.
+		conf.BootstrapAuth = false
+		reloaded, token, err := bootstrapAuth(conf, false)
+		require.NoError(t, err)
+		assert.Empty(t, token)
+		assert.Equal(t, bootstrapped.AuthToken, reloaded.AuthToken)
+	})
+
+	t.Run("Configured auth token", func(t *testing.T) {
+		conf := newBootstrapConfig(t)
+		conf.AuthToken = "token"
+
+		bootstrapped, token, err := bootstrapAuth(conf, true)
+		require.NoError(t, err)
+		assert.Empty(t, token)
+		assert.Equal(t, conf, bootstrapped)
+	})
+
+	t.Run("Existing data directory", func(t *testing.T) {
+		_, _, err := bootstrapAuth(newBootstrapConfig(t), false)
+		assert.ErrorContains(t, err, "only generates a token for a fresh data directory")
+	})
+}
+
+// newBootstrapConfig returns a configuration with --bootstrap-auth and a
+// data directory in a temporary directory.
+func newBootstrapConfig(t *testing.T) config.Config {
+	return config.Config{
+		DataDirectory:      t.TempDir(),
+		AuthTokenAlgorithm: "plaintext",
+		BootstrapAuth:      true,
+	}
+}
+
+func TestPrintBootstrapToken(t *testing.T) {
+	stdout := bytes.Buffer{}
+	printBootstrapToken(&stdout, config.Config{ListenHost: "0.0.0.0", ListenPort: "9876"}, "abc")
+	assert.Contains(t, stdout.String(), `"http://localhost:9876?authToken=abc"`)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuthTokenEntry is one of the auth tokens accepted by the server, besides
+// the one of --auth-token. Several entries allow rotating the tokens without
+// downtime: the new token is added, the clients are moved to it while both
+// are accepted, and the old one expires or is removed, with a reload each
+// time.
+type AuthTokenEntry struct {
+	// Label identifies the token in the stats and the logs.
+	Label string `toml:"label" yaml:"label"`
+	// Algorithm is the hash algorithm of the token, plaintext by default.
+	Algorithm string `toml:"algorithm" yaml:"algorithm"`
+	// Token is the pre-hashed token. Like --auth-token it can be given as
+	// "file:<path>" or "env:<variable>".
+	Token string `toml:"token" yaml:"token"`
+	// ExpiresAt is when the token stops being accepted, never if zero.
+	ExpiresAt time.Time `toml:"expires-at,omitempty" yaml:"expires-at,omitempty"`
+}
+
+// defaultAuthTokenLabel is the label of the token of --auth-token, the
+// entries cannot use it.
+const defaultAuthTokenLabel = "authToken"
+
+// validateAuthTokens validates if the entries have a unique label, a valid
+// algorithm and a token.
+func validateAuthTokens(entries []AuthTokenEntry) error {
+	labels := map[string]bool{defaultAuthTokenLabel: true}
+	for i, entry := range entries {
+		if entry.Label == "" {
+			return fmt.Errorf("invalid auth token %d, missing label", i+1)
+		}
+		if labels[entry.Label] {
+			return fmt.Errorf("invalid auth token %q, duplicated label", entry.Label)
+		}
+		labels[entry.Label] = true
+
+		if entry.Algorithm != "" {
+			if err := validateAuthTokenAlgorithm(entry.Algorithm); err != nil {
+				return fmt.Errorf("invalid auth token %q: %w", entry.Label, err)
+			}
+		}
+		if entry.Token == "" {
+			return fmt.Errorf("invalid auth token %q: %w", entry.Label, errors.New("missing token"))
+		}
+	}
+	return nil
+}
+
+// formatAuthTokens returns the entries as a string to compare them, with
+// their tokens.
+func formatAuthTokens(entries []AuthTokenEntry) string {
+	parts := make([]string, len(entries))
+	for i, entry := range entries {
+		expiresAt := ""
+		if !entry.ExpiresAt.IsZero() {
+			expiresAt = entry.ExpiresAt.Format(time.RFC3339)
+		}
+		parts[i] = strings.Join([]string{entry.Label, entry.Algorithm, expiresAt, entry.Token}, ":")
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
+)
+
+// Check is the result of a check of the configuration, Err is nil if it
+// passed.
+type Check struct {
+	Name string
+	Err  error
+}
+
+// RunChecks runs every check of the configuration without stopping at the
+// first failed one, for --check-config. The data directory is only probed:
+// nothing is left in it and the database is not opened by SQLite.
+func RunChecks(cfg Config) []Check {
+	return append(
+		cfg.valueChecks(),
+		Check{Name: "data directory", Err: checkDataDirectory(cfg.DataDirectory)},
+	)
+}
+
+// PrintChecks writes the result of each check to w and reports whether all
+// of them passed.
+func PrintChecks(w io.Writer, checks []Check) bool {
+	passed := true
+	for _, check := range checks {
+		if check.Err != nil {
+			passed = false
+			fmt.Fprintf(w, "FAIL  %s: %v\n", check.Name, check.Err)
+			continue
+		}
+		fmt.Fprintf(w, "ok    %s\n", check.Name)
+	}
+	return passed
+}
+
+// checkDataDirectory checks that the server can write in the data directory,
+// or create it if it does not exist yet, and open its database file for
+// reading and writing if there is one.
+func checkDataDirectory(dir string) error {
+	if dir == "" {
+		return errors.New("the data directory is empty")
+	}
+
+	// The server creates the missing directories, so the probe is done in
+	// the nearest one that exists.
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", existing)
+			}
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return err
+		}
+		existing = parent
+	}
+
+	probe, err := os.CreateTemp(existing, ".nsqlite-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", existing, err)
+	}
+	probeErr := probe.Close()
+	if err := os.Remove(probe.Name()); err != nil {
+		return err
+	}
+	if probeErr != nil {
+		return probeErr
+	}
+
+	if existing != dir {
+		return nil
+	}
+	path, ok := datadir.NewLayout(dir).ExistingDatabase()
+	if !ok {
+		return nil
+	}
+	db, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("the database cannot be opened for writing: %w", err)
+	}
+	return db.Close()
+}
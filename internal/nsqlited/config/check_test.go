@@ -0,0 +1,134 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a configuration that passes every check, with the
+// data directory in a temporary directory.
+func validConfig(t *testing.T) Config {
+	return Config{
+		DataDirectory:      t.TempDir(),
+		AuthTokenAlgorithm: "plaintext",
+		ListenHost:         "0.0.0.0",
+		ListenPort:         "9876",
+		TxIdleTimeout:      10 * time.Second,
+		BusyBackoffMax:     100 * time.Millisecond,
+		Profile:            "balanced",
+		LogLevel:           "info",
+		LogQueries:         "off",
+		LogMaxSizeMB:       100,
+		LogMaxBackups:      5,
+	}
+}
+
+// failedChecks returns the names of the failed checks.
+func failedChecks(checks []Check) []string {
+	failed := []string{}
+	for _, check := range checks {
+		if check.Err != nil {
+			failed = append(failed, check.Name)
+		}
+	}
+	return failed
+}
+
+func TestRunChecks(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		cfg := validConfig(t)
+		assert.Empty(t, failedChecks(RunChecks(cfg)))
+
+		entries, err := os.ReadDir(cfg.DataDirectory)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "the probe is removed")
+	})
+
+	t.Run("Missing data directory is created by the server", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.DataDirectory = filepath.Join(cfg.DataDirectory, "missing", "data")
+		assert.Empty(t, failedChecks(RunChecks(cfg)))
+		assert.NoDirExists(t, cfg.DataDirectory, "the directory is not created")
+	})
+
+	t.Run("Every failed check is reported", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.ListenPort = "70000"
+		cfg.TrustedProxies = "10.0.0.0/8, proxy"
+		cfg.AuthTokenAlgorithm = "md5"
+		cfg.TxIdleTimeout = 0
+		cfg.LogLevelOverrides = "http=warn"
+		cfg.LogSampleWindow = -time.Second
+		cfg.LogQueries = "verbose"
+		assert.Equal(t, []string{
+			"listen port", "trusted proxies", "auth token algorithm", "transaction idle timeout", "log level",
+			"log sample window", "query log",
+		}, failedChecks(RunChecks(cfg)))
+	})
+
+	t.Run("Data directory is a file", func(t *testing.T) {
+		cfg := validConfig(t)
+		cfg.DataDirectory = filepath.Join(cfg.DataDirectory, "file")
+		require.NoError(t, os.WriteFile(cfg.DataDirectory, nil, 0o600))
+		assert.Equal(t, []string{"data directory"}, failedChecks(RunChecks(cfg)))
+	})
+
+	t.Run("Data directory is not writable", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("the permissions are not enforced")
+		}
+		cfg := validConfig(t)
+		require.NoError(t, os.Chmod(cfg.DataDirectory, 0o500))
+		t.Cleanup(func() { _ = os.Chmod(cfg.DataDirectory, 0o700) })
+
+		checks := RunChecks(cfg)
+		assert.Equal(t, []string{"data directory"}, failedChecks(checks))
+		assert.ErrorContains(t, checks[len(checks)-1].Err, "is not writable")
+	})
+
+	t.Run("Database is not writable", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("the permissions are not enforced")
+		}
+		cfg := validConfig(t)
+		layout := datadir.NewLayout(cfg.DataDirectory)
+		require.NoError(t, layout.Create())
+		require.NoError(t, os.WriteFile(layout.Database, nil, 0o400))
+
+		checks := RunChecks(cfg)
+		assert.Equal(t, []string{"data directory"}, failedChecks(checks))
+		assert.ErrorContains(t, checks[len(checks)-1].Err, "cannot be opened for writing")
+	})
+}
+
+func TestPrintChecks(t *testing.T) {
+	buf := bytes.Buffer{}
+	assert.True(t, PrintChecks(&buf, []Check{{Name: "listen host"}}))
+	assert.Equal(t, "ok    listen host\n", buf.String())
+
+	buf.Reset()
+	cfg := validConfig(t)
+	cfg.ListenHost = "not a host"
+	assert.False(t, PrintChecks(&buf, RunChecks(cfg)))
+	assert.Contains(t, buf.String(), "FAIL  listen host: invalid listen address \"not a host\", must be an IPv4 address or unix:<path>\n")
+	assert.Contains(t, buf.String(), "ok    data directory\n")
+}
+
+func TestParseCheckConfig(t *testing.T) {
+	// The invalid values are reported by the checks instead of failing the
+	// parsing.
+	cfg, err := Parse([]string{
+		"nsqlited", "--check-config", "--listen-port", "70000", "--data-directory", t.TempDir(),
+	})
+	require.NoError(t, err)
+	assert.True(t, cfg.CheckConfig)
+	assert.Equal(t, []string{"listen port"}, failedChecks(RunChecks(cfg)))
+}
@@ -1,25 +1,68 @@
 package config
 
 import (
-	"errors"
 	"fmt"
 	"log"
+	"math"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alexflint/go-arg"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/pragmas"
 	"github.com/nsqlite/nsqlite/internal/validate"
 	"github.com/nsqlite/nsqlite/internal/version"
 )
 
 // Config represents the configuration for nsqlited.
+//
+// Every option can also be set in the configuration file given with
+// --config, under the same name as its flag. The flags take precedence over
+// the environment, which takes precedence over the variables of the
+// --env-file, which take precedence over the configuration file.
+//
+// The secrets, like the auth token, can also be given as "file:<path>" to
+// read them from a file or as "env:<variable>" to read them from another
+// environment variable, so they don't show in the process listings.
+//
+// The auth tokens and the logging options, except those of the log file,
+// are reloaded on SIGHUP or POST /admin/reload, the other options require
+// a restart.
+//
+// The auth tokens accepted besides --auth-token, each with its own label,
+// algorithm and expiration, can only be set in the configuration file, as
+// an array of tables under auth-tokens.
 type Config struct {
-	DataDirectory      string        `arg:"--data-directory,env:NSQLITE_DATA_DIRECTORY" help:"Directory for NSQLite database files" default:"./data"`
-	AuthTokenAlgorithm string        `arg:"--auth-token-algorithm,env:NSQLITE_AUTH_TOKEN_ALGORITHM" help:"Hash algorithm for the auth token (plaintext, argon2, bcrypt)" default:"plaintext"`
-	AuthToken          string        `arg:"--auth-token,env:NSQLITE_AUTH_TOKEN" help:"Pre-hashed auth token; leave empty to disable authentication"`
-	ListenHost         string        `arg:"--listen-host,env:NSQLITE_LISTEN_HOST" help:"Host for the server to listen on" default:"0.0.0.0"`
-	ListenPort         string        `arg:"--listen-port,env:NSQLITE_LISTEN_PORT" help:"Port for the server to listen on" default:"9876"`
-	TxIdleTimeout      time.Duration `arg:"--tx-idle-timeout,env:NSQLITE_TX_IDLE_TIMEOUT" help:"If a transaction is not active for this duration, it will be rolled back. Valid time units are ns, us (or µs), ms, s, m, h" default:"10s"`
+	ConfigFile         string           `arg:"--config,env:NSQLITE_CONFIG" help:"Path of a TOML or YAML configuration file" toml:"-" yaml:"-"`
+	EnvFile            string           `arg:"--env-file" help:"Path of a file with NSQLITE_* environment variables" toml:"-" yaml:"-"`
+	ForceAdopt         bool             `arg:"--force-adopt,env:NSQLITE_FORCE_ADOPT" help:"Use a database that has the application ID of another application, replacing it with the NSQLite one" toml:"-" yaml:"-"`
+	CheckConfig        bool             `arg:"--check-config" help:"Check the configuration and the data directory, print the result of each check and exit" toml:"-" yaml:"-"`
+	DataDirectory      string           `arg:"--data-directory,env:NSQLITE_DATA_DIRECTORY" help:"Directory for NSQLite database files" default:"./data" toml:"data-directory" yaml:"data-directory"`
+	AuthTokenAlgorithm string           `arg:"--auth-token-algorithm,env:NSQLITE_AUTH_TOKEN_ALGORITHM" help:"Hash algorithm for the auth token (plaintext, argon2, bcrypt, scrypt, pbkdf2), or hmac to accept the tokens signed with it by issue-token" default:"plaintext" toml:"auth-token-algorithm" yaml:"auth-token-algorithm"`
+	AuthToken          string           `arg:"--auth-token,env:NSQLITE_AUTH_TOKEN" help:"Pre-hashed auth token; leave empty to disable authentication" toml:"auth-token" yaml:"auth-token"`
+	AuthTokens         []AuthTokenEntry `arg:"-" toml:"auth-tokens" yaml:"auth-tokens"`
+	BusyTimeout        time.Duration    `arg:"--busy-timeout,env:NSQLITE_BUSY_TIMEOUT" help:"How long a query waits for the database locks held by another connection before failing with SQLITE_BUSY, 0 fails right away. Valid time units are ns, us (or µs), ms, s, m, h" default:"5s" toml:"busy-timeout" yaml:"busy-timeout"`
+	BusyBackoffMax     time.Duration    `arg:"--busy-backoff-max,env:NSQLITE_BUSY_BACKOFF_MAX" help:"Longest wait of the writes between two attempts to take the database locks held by another connection, the wait doubles on each attempt up to it. Valid time units are ns, us (or µs), ms, s, m, h" default:"100ms" toml:"busy-backoff-max" yaml:"busy-backoff-max"`
+	BootstrapAuth      bool             `arg:"--bootstrap-auth,env:NSQLITE_BOOTSTRAP_AUTH" help:"If no auth token is configured, generate one on a fresh data directory and print it once" toml:"bootstrap-auth" yaml:"bootstrap-auth"`
+	ListenHost         string           `arg:"--listen-host,env:NSQLITE_LISTEN_HOST" help:"Comma-separated hosts for the server to listen on, unix sockets as unix:<path>" default:"0.0.0.0" toml:"listen-host" yaml:"listen-host"`
+	ListenPort         string           `arg:"--listen-port,env:NSQLITE_LISTEN_PORT" help:"Port for the server to listen on" default:"9876" toml:"listen-port" yaml:"listen-port"`
+	LogLevel           string           `arg:"--log-level,env:NSQLITE_LOG_LEVEL" help:"Minimum level of the logs (debug, info, warn, error)" default:"info" toml:"log-level" yaml:"log-level"`
+	LogLevelOverrides  string           `arg:"--log-level-overrides,env:NSQLITE_LOG_LEVEL_OVERRIDES" help:"Levels of the log namespaces that override --log-level, like database=debug,server=warn" toml:"log-level-overrides" yaml:"log-level-overrides"`
+	LogSampleWindow    time.Duration    `arg:"--log-sample-window,env:NSQLITE_LOG_SAMPLE_WINDOW" help:"Log repeated messages once per window with the number of repetitions, 0 disables it. Valid time units are ns, us (or µs), ms, s, m, h" default:"0s" toml:"log-sample-window" yaml:"log-sample-window"`
+	LogQueries         string           `arg:"--log-queries,env:NSQLITE_LOG_QUERIES" help:"Log every query: off, metadata (SQL, duration, rows and transaction) or full (also the parameters)" default:"off" toml:"log-queries" yaml:"log-queries"`
+	LogQueriesRedact   string           `arg:"--log-queries-redact,env:NSQLITE_LOG_QUERIES_REDACT" help:"Comma-separated names of the parameters whose value is never logged by --log-queries full" default:"password,secret,token" toml:"log-queries-redact" yaml:"log-queries-redact"`
+	LogFile            string           `arg:"--log-file,env:NSQLITE_LOG_FILE" help:"File to also write the logs to, reopened on SIGHUP; leave empty to log only to stdout" toml:"log-file" yaml:"log-file"`
+	LogMaxSizeMB       int              `arg:"--log-max-size-mb,env:NSQLITE_LOG_MAX_SIZE_MB" help:"Size in megabytes at which the log file is rotated" default:"100" toml:"log-max-size-mb" yaml:"log-max-size-mb"`
+	LogMaxBackups      int              `arg:"--log-max-backups,env:NSQLITE_LOG_MAX_BACKUPS" help:"Number of rotated log files to keep, 0 keeps all of them" default:"5" toml:"log-max-backups" yaml:"log-max-backups"`
+	PIDFile            string           `arg:"--pid-file,env:NSQLITE_PID_FILE" help:"File to write the PID of the server to once it is ready, removed on shutdown" toml:"pid-file" yaml:"pid-file"`
+	Profile            string           `arg:"--profile,env:NSQLITE_PROFILE" help:"Profile of the SQLite pragmas (balanced, durability, throughput)" default:"balanced" toml:"profile" yaml:"profile"`
+	Pragmas            []string         `arg:"--pragma,separate,env:NSQLITE_PRAGMAS" help:"Pragma that overrides the profile as name=value, can be repeated: journal_mode, synchronous, wal_autocheckpoint, cache_size, mmap_size, temp_store" toml:"pragmas" yaml:"pragmas"`
+	WalAutoCheckpoint  int              `arg:"--wal-autocheckpoint-pages,env:NSQLITE_WAL_AUTOCHECKPOINT_PAGES" help:"Number of WAL pages after which the write connection checkpoints the WAL, 0 disables the automatic checkpoints and -1 keeps the wal_autocheckpoint of the profile and of --pragma" default:"-1" toml:"wal-autocheckpoint-pages" yaml:"wal-autocheckpoint-pages"`
+	TrustedProxies     string           `arg:"--trusted-proxies,env:NSQLITE_TRUSTED_PROXIES" help:"Comma-separated IP addresses or CIDR blocks of the reverse proxies whose X-Forwarded-For header gives the IP address of the clients; leave empty to use the address of the connection" toml:"trusted-proxies" yaml:"trusted-proxies"`
+	TxIdleTimeout      time.Duration    `arg:"--tx-idle-timeout,env:NSQLITE_TX_IDLE_TIMEOUT" help:"If a transaction is not active for this duration, it will be rolled back. Valid time units are ns, us (or µs), ms, s, m, h" default:"10s" toml:"tx-idle-timeout" yaml:"tx-idle-timeout"`
 }
 
 func (Config) Version() string {
@@ -30,56 +73,224 @@ func (Config) Version() string {
 // line arguments. It returns a Config struct or exits the program
 // with an error.
 func MustParse(args []string) Config {
-	cfg := Config{}
-
-	parser, err := arg.NewParser(
-		arg.Config{},
-		&cfg,
-	)
+	parser, cfg, lookupEnv, err := newParser(args)
 	if err != nil {
 		log.Fatal(err)
 	}
 	parser.MustParse(args[1:])
 
-	if !validate.ListenHost(cfg.ListenHost) {
-		log.Fatal("invalid listen address")
+	if err := cfg.resolveSecrets(lookupEnv); err != nil {
+		log.Fatal(err)
 	}
 
-	if !validate.Port(cfg.ListenPort) {
-		log.Fatal("invalid listen port, valid values are 1-65535")
+	// The checks are run and printed one by one by RunChecks instead.
+	if cfg.CheckConfig {
+		return *cfg
 	}
 
-	if err := validateAuthTokenAlgorithm(cfg.AuthTokenAlgorithm); err != nil {
+	if err := cfg.validate(); err != nil {
 		log.Fatal(err)
 	}
 
-	if err := validateTransactionTimeout(cfg.TxIdleTimeout); err != nil {
-		log.Fatal(err)
+	return *cfg
+}
+
+// Parse parses and validates the configuration from the command line
+// arguments like MustParse, but returns the errors instead of exiting.
+func Parse(args []string) (Config, error) {
+	parser, cfg, lookupEnv, err := newParser(args)
+	if err != nil {
+		return Config{}, err
+	}
+	if err := parser.Parse(args[1:]); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.resolveSecrets(lookupEnv); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.CheckConfig {
+		return *cfg, nil
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, err
 	}
 
-	return cfg
+	return *cfg, nil
 }
 
-// validateAuthTokenAlgorithm validates if algorithm is a valid auth algorithm.
-func validateAuthTokenAlgorithm(algorithm string) error {
-	valid := []string{"plaintext", "argon2", "bcrypt"}
+// newParser returns the parser of the arguments, the configuration it fills
+// and the function that looks up the environment variables, including those
+// of the --env-file. The configuration is pre-populated with the defaults,
+// then the configuration file and the env file, and go-arg only replaces
+// them with the environment and the flags. go-arg is told to ignore its
+// defaults, as it would otherwise reset the values of the files equal to
+// the zero value of their type to the default.
+func newParser(args []string) (*arg.Parser, *Config, func(string) (string, bool), error) {
+	envFile := map[string]string{}
+	if path := flagValue(args, "--env-file"); path != "" {
+		var err error
+		if envFile, err = loadEnvFile(path); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	lookupEnv := func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		value, ok := envFile[name]
+		return value, ok
+	}
 
-	for _, v := range valid {
-		if algorithm == v {
-			return nil
+	cfg := &Config{}
+	if err := cfg.applyDefaults(); err != nil {
+		return nil, nil, nil, err
+	}
+	path := flagValue(args, "--config")
+	if path == "" {
+		path, _ = lookupEnv("NSQLITE_CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, nil, nil, err
 		}
 	}
+	if err := cfg.applyEnvFile(envFile); err != nil {
+		return nil, nil, nil, err
+	}
+	// go-arg appends the flags and the environment to the pragmas already
+	// set, they replace the pragmas of the files instead.
+	if _, ok := os.LookupEnv("NSQLITE_PRAGMAS"); ok || flagValue(args, "--pragma") != "" {
+		cfg.Pragmas = nil
+	}
 
-	return fmt.Errorf(
-		"invalid auth algorithm, valid values are: %s",
-		strings.Join(valid, ", "),
+	parser, err := arg.NewParser(
+		arg.Config{IgnoreDefault: true},
+		cfg,
 	)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return parser, cfg, lookupEnv, nil
+}
+
+// flagValue returns the value of the flag with the given name in the
+// arguments, empty if it is not set.
+func flagValue(args []string, name string) string {
+	for i := 1; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		if args[i] == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(args[i], name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// validate validates the values of the configuration.
+func (cfg Config) validate() error {
+	for _, check := range cfg.valueChecks() {
+		if check.Err != nil {
+			return check.Err
+		}
+	}
+	return nil
+}
+
+// valueChecks returns the checks of the values of the configuration.
+func (cfg Config) valueChecks() []Check {
+	return []Check{
+		{Name: "listen host", Err: validateListenHost(cfg.ListenHost)},
+		{Name: "listen port", Err: validateListenPort(cfg.ListenPort)},
+		{Name: "trusted proxies", Err: validate.CIDRList("trusted proxies", cfg.TrustedProxies)},
+		{Name: "auth token algorithm", Err: validateAuthTokenAlgorithm(cfg.AuthTokenAlgorithm)},
+		{Name: "auth tokens", Err: validateAuthTokens(cfg.AuthTokens)},
+		{Name: "transaction idle timeout", Err: validateTransactionTimeout(cfg.TxIdleTimeout)},
+		{Name: "busy timeout", Err: validateBusyTimeout(cfg.BusyTimeout)},
+		{Name: "busy backoff max", Err: validateBusyBackoffMax(cfg.BusyBackoffMax)},
+		{Name: "pragmas", Err: validatePragmas(cfg.Profile, cfg.Pragmas)},
+		{Name: "WAL auto-checkpoint", Err: validateWalAutoCheckpoint(cfg.WalAutoCheckpoint)},
+		{Name: "log level", Err: validateLogLevels(cfg.LogLevel, cfg.LogLevelOverrides)},
+		{Name: "log rotation", Err: validateLogRotation(cfg.LogMaxSizeMB, cfg.LogMaxBackups)},
+		{Name: "log sample window", Err: validateLogSampleWindow(cfg.LogSampleWindow)},
+		{Name: "query log", Err: validateLogQueries(cfg.LogQueries)},
+	}
+}
+
+// validateListenHost validates if hosts are valid listen addresses, they
+// are comma-separated and each one can be a unix socket as "unix:<path>".
+func validateListenHost(hosts string) error {
+	for _, host := range strings.Split(hosts, ",") {
+		host = strings.TrimSpace(host)
+		if path, ok := strings.CutPrefix(host, "unix:"); ok && path != "" {
+			continue
+		}
+		if !validate.ListenHost(host) {
+			return validate.NewError("listen address", host, "must be an IPv4 address or unix:<path>")
+		}
+	}
+	return nil
+}
+
+// validateListenPort validates if port is a valid port.
+func validateListenPort(port string) error {
+	if !validate.Port(port) {
+		return validate.NewError("listen port", port, "valid values are 1-65535")
+	}
+	return nil
+}
+
+// validateAuthTokenAlgorithm validates if algorithm is a valid auth algorithm,
+// a hash algorithm or the one of the signed tokens.
+func validateAuthTokenAlgorithm(algorithm string) error {
+	valid := append(slices.Clone(authtoken.Algorithms), authtoken.HMACAlgorithm)
+	return validate.OneOf("auth algorithm", algorithm, valid)
 }
 
 // validateTransactionTimeout validates if timeout is greater than zero.
 func validateTransactionTimeout(timeout time.Duration) error {
-	if timeout <= 0 {
-		return errors.New("invalid transaction timeout, must be greater than zero")
+	return validate.DurationRange("transaction timeout", timeout, time.Nanosecond, 0)
+}
+
+// validateBusyTimeout validates if timeout is zero or greater, and fits in
+// the milliseconds of SQLite.
+func validateBusyTimeout(timeout time.Duration) error {
+	return validate.DurationRange("busy timeout", timeout, 0, math.MaxInt32*time.Millisecond)
+}
+
+// validateBusyBackoffMax validates if backoff is at least a millisecond, the
+// first wait of the busy handler.
+func validateBusyBackoffMax(backoff time.Duration) error {
+	return validate.DurationRange("busy backoff max", backoff, time.Millisecond, 0)
+}
+
+// validateWalAutoCheckpoint validates if pages is -1 or greater, and fits in
+// the pages of SQLite.
+func validateWalAutoCheckpoint(pages int) error {
+	if pages < -1 || pages > math.MaxInt32 {
+		return validate.NewError("WAL auto-checkpoint pages", strconv.Itoa(pages), "must be between -1 and 2147483647")
 	}
 	return nil
 }
+
+// WalAutoCheckpointPages returns the WAL auto-checkpoint threshold of the
+// write connection, nil if the one of the pragmas is kept.
+func (cfg Config) WalAutoCheckpointPages() *int {
+	if cfg.WalAutoCheckpoint < 0 {
+		return nil
+	}
+	pages := cfg.WalAutoCheckpoint
+	return &pages
+}
+
+// validatePragmas validates if profile is a valid profile and overrides are
+// valid overrides of its pragmas.
+func validatePragmas(profile string, overrides []string) error {
+	_, err := pragmas.Resolve(profile, overrides)
+	return err
+}
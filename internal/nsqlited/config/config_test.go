@@ -1,6 +1,7 @@
 package config
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -28,6 +29,21 @@ func Test_validateAuthAlgorithm(t *testing.T) {
 			algorithm: "bcrypt",
 			wantErr:   false,
 		},
+		{
+			name:      "valid - scrypt",
+			algorithm: "scrypt",
+			wantErr:   false,
+		},
+		{
+			name:      "valid - pbkdf2",
+			algorithm: "pbkdf2",
+			wantErr:   false,
+		},
+		{
+			name:      "valid - hmac",
+			algorithm: "hmac",
+			wantErr:   false,
+		},
 		{
 			name:      "invalid - empty string",
 			algorithm: "",
@@ -60,6 +76,62 @@ func Test_validateAuthAlgorithm(t *testing.T) {
 	}
 }
 
+func Test_validateAuthTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []AuthTokenEntry
+		wantErr string
+	}{
+		{
+			name:    "valid - none",
+			entries: nil,
+		},
+		{
+			name: "valid - several",
+			entries: []AuthTokenEntry{
+				{Label: "old", Algorithm: "bcrypt", Token: "hash", ExpiresAt: time.Now()},
+				{Label: "new", Token: "token"},
+			},
+		},
+		{
+			name:    "invalid - missing label",
+			entries: []AuthTokenEntry{{Token: "token"}},
+			wantErr: "invalid auth token 1, missing label",
+		},
+		{
+			name:    "invalid - duplicated label",
+			entries: []AuthTokenEntry{{Label: "a", Token: "token"}, {Label: "a", Token: "token"}},
+			wantErr: `invalid auth token "a", duplicated label`,
+		},
+		{
+			name:    "invalid - label of the auth token",
+			entries: []AuthTokenEntry{{Label: "authToken", Token: "token"}},
+			wantErr: `invalid auth token "authToken", duplicated label`,
+		},
+		{
+			name:    "invalid - unknown algorithm",
+			entries: []AuthTokenEntry{{Label: "a", Algorithm: "md5", Token: "token"}},
+			wantErr: `invalid auth token "a": invalid auth algorithm`,
+		},
+		{
+			name:    "invalid - missing token",
+			entries: []AuthTokenEntry{{Label: "a"}},
+			wantErr: `invalid auth token "a": missing token`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAuthTokens(tt.entries)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func Test_validateTransactionTimeout(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -94,3 +166,109 @@ func Test_validateTransactionTimeout(t *testing.T) {
 		})
 	}
 }
+
+func Test_validateBusyTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		wantErr  bool
+	}{
+		{name: "valid - disabled", duration: 0},
+		{name: "valid - 5 seconds", duration: 5 * time.Second},
+		{name: "invalid - negative", duration: -time.Second, wantErr: true},
+		{name: "invalid - too long", duration: 1000 * time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBusyTimeout(tt.duration)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_validateBusyBackoffMax(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		wantErr  bool
+	}{
+		{name: "valid - 1 millisecond", duration: time.Millisecond},
+		{name: "valid - 1 second", duration: time.Second},
+		{name: "invalid - zero", duration: 0, wantErr: true},
+		{name: "invalid - below a millisecond", duration: time.Microsecond, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBusyBackoffMax(tt.duration)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_validateWalAutoCheckpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		pages   int
+		wantErr bool
+	}{
+		{name: "valid - profile", pages: -1},
+		{name: "valid - disabled", pages: 0},
+		{name: "valid - 1000 pages", pages: 1000},
+		{name: "invalid - negative", pages: -2, wantErr: true},
+		{name: "invalid - too large", pages: math.MaxInt32 + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWalAutoCheckpoint(tt.pages)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWalAutoCheckpointPages(t *testing.T) {
+	assert.Nil(t, Config{WalAutoCheckpoint: -1}.WalAutoCheckpointPages())
+	assert.Equal(t, 0, *Config{WalAutoCheckpoint: 0}.WalAutoCheckpointPages())
+	assert.Equal(t, 100, *Config{WalAutoCheckpoint: 100}.WalAutoCheckpointPages())
+}
+
+func Test_validateListenHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosts   string
+		wantErr string
+	}{
+		{name: "single host", hosts: "0.0.0.0"},
+		{name: "several hosts", hosts: "127.0.0.1,10.0.0.1"},
+		{name: "spaces around hosts", hosts: "127.0.0.1, 10.0.0.1"},
+		{name: "unix socket", hosts: "127.0.0.1,unix:/run/nsqlite.sock"},
+		{name: "invalid host", hosts: "127.0.0.1,localhost", wantErr: `invalid listen address "localhost", must be an IPv4 address or unix:<path>`},
+		{name: "empty unix socket", hosts: "unix:", wantErr: `invalid listen address "unix:", must be an IPv4 address or unix:<path>`},
+		{name: "empty host", hosts: "127.0.0.1,", wantErr: `invalid listen address "", must be an IPv4 address or unix:<path>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateListenHost(tt.hosts)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/alexflint/go-scalar"
+)
+
+// envPrefix is the prefix of the environment variables of nsqlited, the
+// other variables of an env file are ignored.
+const envPrefix = "NSQLITE_"
+
+// loadEnvFile reads the NSQLITE_* variables of the env file at path. Each
+// line is a VARIABLE=value assignment, optionally prefixed with "export" and
+// with the value in quotes. Blank lines and lines starting with # are
+// ignored.
+func loadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading env file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		// The line is not printed in the errors, it may hold a secret.
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid line %d in env file %s, expected VARIABLE=value", lineNumber, path)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		if strings.HasPrefix(name, envPrefix) {
+			vars[name] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading env file %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// applyDefaults sets the options of the configuration to the default of
+// their go-arg tag.
+func (cfg *Config) applyDefaults() error {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		value, ok := field.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid default of %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyEnvFile sets the options of the configuration whose environment
+// variable is in the env file.
+func (cfg *Config) applyEnvFile(vars map[string]string) error {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := range v.NumField() {
+		name, ok := envVariable(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+		value, ok := vars[name]
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid value of %s in env file: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFromEnv sets the field to the value of its environment variable, the
+// values of slices are comma-separated like go-arg expects them.
+func setFromEnv(field reflect.Value, value string) error {
+	if field.Kind() != reflect.Slice {
+		return scalar.ParseValue(field, value)
+	}
+
+	values, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return err
+	}
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := scalar.ParseValue(slice.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// envVariable returns the environment variable of an option from the env
+// part of its go-arg tag.
+func envVariable(field reflect.StructField) (string, bool) {
+	for _, part := range strings.Split(field.Tag.Get("arg"), ",") {
+		if name, ok := strings.CutPrefix(part, "env:"); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// resolveSecrets replaces the secrets given as "file:<path>" with the
+// contents of the file without the surrounding whitespace, and those given
+// as "env:<variable>" with the value of the variable. The errors name the
+// option but never its secret.
+func (cfg *Config) resolveSecrets(lookupEnv func(string) (string, bool)) error {
+	type secret struct {
+		name  string
+		value *string
+	}
+	secrets := []secret{
+		{name: "auth-token", value: &cfg.AuthToken},
+	}
+	for i := range cfg.AuthTokens {
+		secrets = append(secrets, secret{
+			name:  fmt.Sprintf("auth token %q", cfg.AuthTokens[i].Label),
+			value: &cfg.AuthTokens[i].Token,
+		})
+	}
+
+	for _, secret := range secrets {
+		if path, ok := strings.CutPrefix(*secret.value, "file:"); ok {
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("error reading %s from file: %w", secret.name, err)
+			}
+			*secret.value = strings.TrimSpace(string(contents))
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(*secret.value, "env:"); ok {
+			value, ok := lookupEnv(name)
+			if !ok {
+				return fmt.Errorf("error reading %s: environment variable %s is not set", secret.name, name)
+			}
+			*secret.value = value
+		}
+	}
+	return nil
+}
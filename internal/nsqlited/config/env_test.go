@@ -0,0 +1,128 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	path := writeFile(t, ".env", `
+# nsqlited
+NSQLITE_LISTEN_PORT=7000
+export NSQLITE_DATA_DIRECTORY="/var/lib/nsqlite"
+NSQLITE_AUTH_TOKEN='token with spaces'
+OTHER_VARIABLE=ignored
+`)
+
+	vars, err := loadEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"NSQLITE_LISTEN_PORT":    "7000",
+		"NSQLITE_DATA_DIRECTORY": "/var/lib/nsqlite",
+		"NSQLITE_AUTH_TOKEN":     "token with spaces",
+	}, vars)
+
+	t.Run("Invalid line", func(t *testing.T) {
+		path := writeFile(t, ".env", "NSQLITE_LISTEN_PORT=7000\nsecret-value\n")
+		_, err := loadEnvFile(path)
+		assert.ErrorContains(t, err, "invalid line 2")
+		assert.NotContains(t, err.Error(), "secret-value")
+	})
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := writeFile(t, ".env", "NSQLITE_LISTEN_PORT=7000\nNSQLITE_LISTEN_HOST=127.0.0.1\nNSQLITE_TX_IDLE_TIMEOUT=1m\n")
+
+	t.Run("Variables of the file", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlited", "--env-file", path})
+		require.NoError(t, err)
+		assert.Equal(t, "7000", cfg.ListenPort)
+		assert.Equal(t, "127.0.0.1", cfg.ListenHost)
+		assert.Equal(t, "1m0s", cfg.TxIdleTimeout.String())
+	})
+
+	t.Run("Environment and flags over the file", func(t *testing.T) {
+		t.Setenv("NSQLITE_LISTEN_HOST", "10.0.0.1")
+
+		cfg, err := Parse([]string{"nsqlited", "--env-file=" + path, "--listen-port", "8000"})
+		require.NoError(t, err)
+		assert.Equal(t, "8000", cfg.ListenPort)
+		assert.Equal(t, "10.0.0.1", cfg.ListenHost)
+	})
+
+	t.Run("File over the configuration file", func(t *testing.T) {
+		configPath := writeFile(t, "nsqlited.toml", "listen-port = \"9000\"\ndata-directory = \"/from/file\"\n")
+
+		cfg, err := Parse([]string{"nsqlited", "--env-file", path, "--config", configPath})
+		require.NoError(t, err)
+		assert.Equal(t, "7000", cfg.ListenPort)
+		assert.Equal(t, "/from/file", cfg.DataDirectory)
+	})
+
+	t.Run("Zero values", func(t *testing.T) {
+		path := writeFile(t, ".env", "NSQLITE_BUSY_TIMEOUT=0s\nNSQLITE_LOG_MAX_BACKUPS=0\n")
+		configPath := writeFile(t, "nsqlited.toml", "busy-timeout = \"1s\"\nlog-max-backups = 2\n")
+
+		cfg, err := Parse([]string{"nsqlited", "--env-file", path})
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), cfg.BusyTimeout)
+		assert.Equal(t, 0, cfg.LogMaxBackups)
+
+		cfg, err = Parse([]string{"nsqlited", "--env-file", path, "--config", configPath})
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), cfg.BusyTimeout, "the zero values of the env file are over the configuration file")
+		assert.Equal(t, 0, cfg.LogMaxBackups)
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		path := writeFile(t, ".env", "NSQLITE_TX_IDLE_TIMEOUT=soon\n")
+		_, err := Parse([]string{"nsqlited", "--env-file", path})
+		assert.ErrorContains(t, err, "invalid value of NSQLITE_TX_IDLE_TIMEOUT")
+	})
+}
+
+func TestResolveSecrets(t *testing.T) {
+	t.Run("Plain value", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlited", "--auth-token", "token"})
+		require.NoError(t, err)
+		assert.Equal(t, "token", cfg.AuthToken)
+	})
+
+	t.Run("File", func(t *testing.T) {
+		path := writeFile(t, "token", "file-token\n")
+
+		cfg, err := Parse([]string{"nsqlited", "--auth-token", "file:" + path})
+		require.NoError(t, err)
+		assert.Equal(t, "file-token", cfg.AuthToken, "the trailing newline is trimmed")
+	})
+
+	t.Run("Environment variable", func(t *testing.T) {
+		t.Setenv("TOKEN_SECRET", "env-token")
+
+		cfg, err := Parse([]string{"nsqlited", "--auth-token", "env:TOKEN_SECRET"})
+		require.NoError(t, err)
+		assert.Equal(t, "env-token", cfg.AuthToken)
+	})
+
+	t.Run("Variable of the env file", func(t *testing.T) {
+		path := writeFile(t, ".env", "NSQLITE_AUTH_TOKEN=env:NSQLITE_TOKEN_SECRET\nNSQLITE_TOKEN_SECRET=env-file-token\n")
+
+		cfg, err := Parse([]string{"nsqlited", "--env-file", path})
+		require.NoError(t, err)
+		assert.Equal(t, "env-file-token", cfg.AuthToken)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlited", "--auth-token", "file:" + filepath.Join(t.TempDir(), "missing")})
+		assert.ErrorContains(t, err, "error reading auth-token from file")
+	})
+
+	t.Run("Missing environment variable", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlited", "--auth-token", "env:NSQLITE_MISSING_SECRET"})
+		assert.EqualError(t, err, "error reading auth-token: environment variable NSQLITE_MISSING_SECRET is not set")
+	})
+}
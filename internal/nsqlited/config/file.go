@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces the secrets in the printed configuration.
+const redacted = "REDACTED"
+
+// loadFile decodes the TOML or YAML configuration file at path into cfg,
+// the format is chosen by its extension. Keys that are not options of the
+// configuration are an error.
+func loadFile(path string, cfg *Config) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		md, err := toml.DecodeFile(path, cfg)
+		if err != nil {
+			return fmt.Errorf("error reading configuration file %s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return fmt.Errorf(
+				"unknown keys in configuration file %s: %s", path, strings.Join(keys, ", "),
+			)
+		}
+		return nil
+
+	case ".yaml", ".yml":
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("error reading configuration file %s: %w", path, err)
+		}
+		defer f.Close()
+
+		decoder := yaml.NewDecoder(f)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("error reading configuration file %s: %w", path, err)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf(
+			"unsupported configuration file %s, valid extensions are: .toml, .yaml, .yml", path,
+		)
+	}
+}
+
+// Print writes the configuration to w as a TOML configuration file, with
+// the auth tokens redacted.
+func Print(w io.Writer, cfg Config) error {
+	cfg.AuthToken = redact(cfg.AuthToken)
+	cfg.AuthTokens = slices.Clone(cfg.AuthTokens)
+	for i := range cfg.AuthTokens {
+		cfg.AuthTokens[i].Token = redact(cfg.AuthTokens[i].Token)
+	}
+	return toml.NewEncoder(w).Encode(cfg)
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes a configuration file with the given name and contents
+// in a temporary directory and returns its path.
+func writeFile(t *testing.T, name string, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestParseConfigFile(t *testing.T) {
+	const tomlFile = `
+data-directory = "/var/lib/nsqlite"
+listen-port = "7000"
+listen-host = "127.0.0.1"
+tx-idle-timeout = "30s"
+`
+	const yamlFile = `
+data-directory: /var/lib/nsqlite
+listen-port: "7000"
+listen-host: 127.0.0.1
+tx-idle-timeout: 30s
+`
+
+	for name, path := range map[string]string{
+		"TOML": writeFile(t, "nsqlited.toml", tomlFile),
+		"YAML": writeFile(t, "nsqlited.yaml", yamlFile),
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Parse([]string{"nsqlited", "--config", path})
+			require.NoError(t, err)
+
+			assert.Equal(t, "/var/lib/nsqlite", cfg.DataDirectory)
+			assert.Equal(t, "7000", cfg.ListenPort)
+			assert.Equal(t, "127.0.0.1", cfg.ListenHost)
+			assert.Equal(t, 30*time.Second, cfg.TxIdleTimeout)
+			assert.Equal(t, "plaintext", cfg.AuthTokenAlgorithm, "the options not in the file keep their default")
+		})
+	}
+}
+
+func TestParseConfigFileZeroValues(t *testing.T) {
+	const tomlFile = `
+busy-timeout = "0s"
+wal-autocheckpoint-pages = 0
+log-max-backups = 0
+`
+	const yamlFile = `
+busy-timeout: 0s
+wal-autocheckpoint-pages: 0
+log-max-backups: 0
+`
+
+	for name, path := range map[string]string{
+		"TOML": writeFile(t, "nsqlited.toml", tomlFile),
+		"YAML": writeFile(t, "nsqlited.yaml", yamlFile),
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Parse([]string{"nsqlited", "--config", path})
+			require.NoError(t, err)
+
+			assert.Equal(t, time.Duration(0), cfg.BusyTimeout)
+			assert.Equal(t, 0, cfg.WalAutoCheckpoint)
+			assert.Equal(t, 0, cfg.LogMaxBackups)
+			assert.Equal(t, 100, cfg.LogMaxSizeMB, "the options not in the file keep their default")
+		})
+	}
+
+	t.Run("Flags over the zero values", func(t *testing.T) {
+		path := writeFile(t, "nsqlited.toml", tomlFile)
+
+		cfg, err := Parse([]string{"nsqlited", "--config", path, "--log-max-backups", "3"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, cfg.LogMaxBackups)
+		assert.Equal(t, time.Duration(0), cfg.BusyTimeout)
+	})
+}
+
+func TestParsePrecedence(t *testing.T) {
+	path := writeFile(t, "nsqlited.toml", `
+data-directory = "/from/file"
+listen-port = "7000"
+listen-host = "127.0.0.1"
+`)
+
+	t.Run("Environment over file", func(t *testing.T) {
+		t.Setenv("NSQLITE_LISTEN_PORT", "8000")
+
+		cfg, err := Parse([]string{"nsqlited", "--config", path})
+		require.NoError(t, err)
+		assert.Equal(t, "8000", cfg.ListenPort)
+		assert.Equal(t, "/from/file", cfg.DataDirectory)
+	})
+
+	t.Run("Flags over environment", func(t *testing.T) {
+		t.Setenv("NSQLITE_LISTEN_PORT", "8000")
+
+		cfg, err := Parse([]string{"nsqlited", "--config=" + path, "--listen-port", "9000"})
+		require.NoError(t, err)
+		assert.Equal(t, "9000", cfg.ListenPort)
+		assert.Equal(t, "127.0.0.1", cfg.ListenHost)
+	})
+
+	t.Run("File from the environment", func(t *testing.T) {
+		t.Setenv("NSQLITE_CONFIG", path)
+
+		cfg, err := Parse([]string{"nsqlited"})
+		require.NoError(t, err)
+		assert.Equal(t, "/from/file", cfg.DataDirectory)
+		assert.Equal(t, path, cfg.ConfigFile)
+	})
+
+	t.Run("Defaults without a file", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlited"})
+		require.NoError(t, err)
+		assert.Equal(t, "./data", cfg.DataDirectory)
+		assert.Equal(t, "9876", cfg.ListenPort)
+		assert.Equal(t, 10*time.Second, cfg.TxIdleTimeout)
+	})
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown TOML key",
+			file:    "nsqlited.toml",
+			content: "listen-port = \"7000\"\nlisten-prot = \"7001\"\n",
+			wantErr: "unknown keys in configuration file",
+		},
+		{
+			name:    "unknown YAML key",
+			file:    "nsqlited.yaml",
+			content: "listen-port: \"7000\"\nlisten-prot: \"7001\"\n",
+			wantErr: "field listen-prot not found",
+		},
+		{
+			name:    "unsupported extension",
+			file:    "nsqlited.json",
+			content: "{}",
+			wantErr: "valid extensions are: .toml, .yaml, .yml",
+		},
+		{
+			name:    "invalid value",
+			file:    "nsqlited.toml",
+			content: "listen-port = \"70000\"\n",
+			wantErr: "invalid listen port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.content)
+			_, err := Parse([]string{"nsqlited", "--config", path})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("unknown TOML key is listed", func(t *testing.T) {
+		path := writeFile(t, "nsqlited.toml", "[server]\nlisten-port = \"7000\"\n")
+		_, err := Parse([]string{"nsqlited", "--config", path})
+		assert.ErrorContains(t, err, "server.listen-port")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlited", "--config", filepath.Join(t.TempDir(), "missing.toml")})
+		assert.ErrorContains(t, err, "error reading configuration file")
+	})
+}
+
+func TestPrint(t *testing.T) {
+	path := writeFile(t, "nsqlited.toml", "auth-token = \"secret-token\"\n")
+	cfg, err := Parse([]string{"nsqlited", "--config", path, "--listen-port", "7000"})
+	require.NoError(t, err)
+
+	buf := bytes.Buffer{}
+	require.NoError(t, Print(&buf, cfg))
+	printed := buf.String()
+
+	assert.NotContains(t, printed, "secret-token")
+	assert.Contains(t, printed, `auth-token = "REDACTED"`)
+	assert.Contains(t, printed, `listen-port = "7000"`)
+	assert.Contains(t, printed, `data-directory = "./data"`)
+	assert.Contains(t, printed, `tx-idle-timeout = "10s"`)
+	assert.NotContains(t, printed, path)
+
+	t.Run("Printed configuration is a valid file", func(t *testing.T) {
+		printedPath := writeFile(t, "printed.toml", printed)
+		reparsed, err := Parse([]string{"nsqlited", "--config", printedPath})
+		require.NoError(t, err)
+		assert.Equal(t, "7000", reparsed.ListenPort)
+		assert.Equal(t, cfg.TxIdleTimeout, reparsed.TxIdleTimeout)
+	})
+
+	t.Run("Empty auth token is not redacted", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		require.NoError(t, Print(&buf, Config{ListenPort: "7000"}))
+		assert.Contains(t, buf.String(), `auth-token = ""`)
+	})
+}
+
+func TestParsePragmas(t *testing.T) {
+	path := writeFile(t, "nsqlited.toml", `
+profile = "durability"
+pragmas = ["cache_size=20000", "temp_store=MEMORY"]
+`)
+
+	t.Run("Profile and pragmas of the file", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlited", "--config", path})
+		require.NoError(t, err)
+		assert.Equal(t, "durability", cfg.Profile)
+		assert.Equal(t, []string{"cache_size=20000", "temp_store=MEMORY"}, cfg.Pragmas)
+	})
+
+	t.Run("Flags over the file", func(t *testing.T) {
+		cfg, err := Parse([]string{
+			"nsqlited", "--config", path, "--profile", "throughput",
+			"--pragma", "synchronous=FULL", "--pragma", "mmap_size=0",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "throughput", cfg.Profile)
+		assert.Equal(t, []string{"synchronous=FULL", "mmap_size=0"}, cfg.Pragmas)
+	})
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Setenv("NSQLITE_PRAGMAS", "synchronous=FULL,cache_size=1")
+
+		cfg, err := Parse([]string{"nsqlited"})
+		require.NoError(t, err)
+		assert.Equal(t, "balanced", cfg.Profile)
+		assert.Equal(t, []string{"synchronous=FULL", "cache_size=1"}, cfg.Pragmas)
+
+		cfg, err = Parse([]string{"nsqlited", "--config", path})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"synchronous=FULL", "cache_size=1"}, cfg.Pragmas)
+	})
+
+	t.Run("Env file", func(t *testing.T) {
+		envPath := writeFile(t, ".env", "NSQLITE_PRAGMAS=synchronous=FULL,cache_size=1\n")
+
+		cfg, err := Parse([]string{"nsqlited", "--env-file", envPath})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"synchronous=FULL", "cache_size=1"}, cfg.Pragmas)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := Parse([]string{"nsqlited", "--pragma", "foreign_keys=OFF"})
+		assert.ErrorContains(t, err, `unsupported pragma "foreign_keys"`)
+
+		_, err = Parse([]string{"nsqlited", "--profile", "fastest"})
+		assert.ErrorContains(t, err, `invalid profile "fastest"`)
+	})
+}
+
+func TestParseAuthTokens(t *testing.T) {
+	tokenPath := writeFile(t, "new-token", "new-hash\n")
+	const tomlFile = `
+[[auth-tokens]]
+label = "old"
+algorithm = "bcrypt"
+token = "old-hash"
+expires-at = 2025-01-31T00:00:00Z
+
+[[auth-tokens]]
+label = "new"
+token = "file:%s"
+`
+	const yamlFile = `
+auth-tokens:
+  - label: old
+    algorithm: bcrypt
+    token: old-hash
+    expires-at: 2025-01-31T00:00:00Z
+  - label: new
+    token: file:%s
+`
+	want := []AuthTokenEntry{
+		{Label: "old", Algorithm: "bcrypt", Token: "old-hash", ExpiresAt: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{Label: "new", Token: "new-hash"},
+	}
+
+	for name, path := range map[string]string{
+		"TOML": writeFile(t, "nsqlited.toml", fmt.Sprintf(tomlFile, tokenPath)),
+		"YAML": writeFile(t, "nsqlited.yaml", fmt.Sprintf(yamlFile, tokenPath)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := Parse([]string{"nsqlited", "--config", path})
+			require.NoError(t, err)
+			require.Len(t, cfg.AuthTokens, 2)
+			for i := range want {
+				assert.Equal(t, want[i].Label, cfg.AuthTokens[i].Label)
+				assert.Equal(t, want[i].Algorithm, cfg.AuthTokens[i].Algorithm)
+				assert.Equal(t, want[i].Token, cfg.AuthTokens[i].Token)
+				assert.True(t, want[i].ExpiresAt.Equal(cfg.AuthTokens[i].ExpiresAt))
+			}
+		})
+	}
+
+	t.Run("Printed tokens are redacted", func(t *testing.T) {
+		cfg, err := Parse([]string{"nsqlited", "--config", writeFile(t, "nsqlited.toml", fmt.Sprintf(tomlFile, tokenPath))})
+		require.NoError(t, err)
+
+		buf := bytes.Buffer{}
+		require.NoError(t, Print(&buf, cfg))
+		assert.NotContains(t, buf.String(), "old-hash")
+		assert.NotContains(t, buf.String(), "new-hash")
+		assert.Equal(t, "old-hash", cfg.AuthTokens[0].Token, "the configuration is not modified")
+
+		reparsed, err := Parse([]string{"nsqlited", "--config", writeFile(t, "printed.toml", buf.String())})
+		require.NoError(t, err)
+		assert.Len(t, reparsed.AuthTokens, 2)
+	})
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/validate"
+)
+
+// LogLevels returns the minimum level of the logs and the levels of the
+// namespaces that override it.
+func (cfg Config) LogLevels() (slog.Level, map[string]slog.Level, error) {
+	level, err := log.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return 0, nil, err
+	}
+	overrides, err := log.ParseOverrides(cfg.LogLevelOverrides)
+	if err != nil {
+		return 0, nil, err
+	}
+	return level, overrides, nil
+}
+
+// validateLogLevels validates if level is a valid log level and overrides
+// are valid levels of the log namespaces.
+func validateLogLevels(level string, overrides string) error {
+	_, _, err := Config{LogLevel: level, LogLevelOverrides: overrides}.LogLevels()
+	return err
+}
+
+// validateLogRotation validates if the maximum size of the log file is
+// greater than zero and the number of backups is not negative.
+func validateLogRotation(maxSizeMB int, maxBackups int) error {
+	if maxSizeMB <= 0 {
+		return validate.NewError("maximum size of log file", strconv.Itoa(maxSizeMB), "must be greater than zero")
+	}
+	if maxBackups < 0 {
+		return validate.NewError("number of log file backups", strconv.Itoa(maxBackups), "must be zero or greater")
+	}
+	return nil
+}
+
+// validateLogSampleWindow validates if the sample window is not negative.
+func validateLogSampleWindow(window time.Duration) error {
+	return validate.DurationRange("log sample window", window, 0, 0)
+}
+
+// QueryLogRedact returns the names of the parameters whose value is never
+// logged by the query log.
+func (cfg Config) QueryLogRedact() []string {
+	names := []string{}
+	for _, name := range strings.Split(cfg.LogQueriesRedact, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validateLogQueries validates if mode is a valid mode of the query log.
+func validateLogQueries(mode string) error {
+	return validate.OneOf("query log mode", mode, db.QueryLogModes)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Change is an option whose value changed when the configuration was
+// reloaded.
+type Change struct {
+	// Name is the name of the option, the same as its flag.
+	Name string
+	// Old and New are the values of the option, secrets are redacted.
+	Old string
+	New string
+	// Reloadable reports whether the new value is applied without a
+	// restart.
+	Reloadable bool
+}
+
+// Diff returns the options whose value differs between the old and the new
+// configuration.
+func Diff(old Config, new Config) []Change {
+	options := []struct {
+		name       string
+		old, new   string
+		secret     bool
+		reloadable bool
+	}{
+		{name: "data-directory", old: old.DataDirectory, new: new.DataDirectory},
+		{name: "listen-host", old: old.ListenHost, new: new.ListenHost},
+		{name: "listen-port", old: old.ListenPort, new: new.ListenPort},
+		{name: "trusted-proxies", old: old.TrustedProxies, new: new.TrustedProxies},
+		{name: "pid-file", old: old.PIDFile, new: new.PIDFile},
+		{name: "log-file", old: old.LogFile, new: new.LogFile},
+		{
+			name: "log-max-size-mb",
+			old:  strconv.Itoa(old.LogMaxSizeMB), new: strconv.Itoa(new.LogMaxSizeMB),
+		},
+		{
+			name: "log-max-backups",
+			old:  strconv.Itoa(old.LogMaxBackups), new: strconv.Itoa(new.LogMaxBackups),
+		},
+		{name: "profile", old: old.Profile, new: new.Profile},
+		{name: "pragma", old: strings.Join(old.Pragmas, ","), new: strings.Join(new.Pragmas, ",")},
+		{
+			name: "tx-idle-timeout",
+			old:  old.TxIdleTimeout.String(), new: new.TxIdleTimeout.String(),
+		},
+		{
+			name: "wal-autocheckpoint-pages",
+			old:  strconv.Itoa(old.WalAutoCheckpoint), new: strconv.Itoa(new.WalAutoCheckpoint),
+		},
+		{
+			name: "busy-timeout",
+			old:  old.BusyTimeout.String(), new: new.BusyTimeout.String(),
+		},
+		{
+			name: "busy-backoff-max",
+			old:  old.BusyBackoffMax.String(), new: new.BusyBackoffMax.String(),
+		},
+		{name: "log-level", reloadable: true, old: old.LogLevel, new: new.LogLevel},
+		{
+			name: "log-level-overrides", reloadable: true,
+			old: old.LogLevelOverrides, new: new.LogLevelOverrides,
+		},
+		{
+			name: "log-sample-window", reloadable: true,
+			old: old.LogSampleWindow.String(), new: new.LogSampleWindow.String(),
+		},
+		{name: "log-queries", reloadable: true, old: old.LogQueries, new: new.LogQueries},
+		{
+			name: "log-queries-redact", reloadable: true,
+			old: old.LogQueriesRedact, new: new.LogQueriesRedact,
+		},
+		{
+			name: "auth-token-algorithm", reloadable: true,
+			old: old.AuthTokenAlgorithm, new: new.AuthTokenAlgorithm,
+		},
+		{
+			name: "auth-token", secret: true, reloadable: true,
+			old: old.AuthToken, new: new.AuthToken,
+		},
+		{
+			name: "auth-tokens", secret: true, reloadable: true,
+			old: formatAuthTokens(old.AuthTokens), new: formatAuthTokens(new.AuthTokens),
+		},
+	}
+
+	changes := []Change{}
+	for _, option := range options {
+		if option.old == option.new {
+			continue
+		}
+		change := Change{
+			Name: option.name, Old: option.old, New: option.new, Reloadable: option.reloadable,
+		}
+		if option.secret {
+			change.Old, change.New = redact(option.old), redact(option.new)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// WithReloadable returns the configuration with the options that can be
+// reloaded taken from the new one, the others are kept until a restart.
+func (cfg Config) WithReloadable(new Config) Config {
+	cfg.AuthTokenAlgorithm = new.AuthTokenAlgorithm
+	cfg.AuthToken = new.AuthToken
+	cfg.AuthTokens = new.AuthTokens
+	cfg.LogLevel = new.LogLevel
+	cfg.LogLevelOverrides = new.LogLevelOverrides
+	cfg.LogSampleWindow = new.LogSampleWindow
+	cfg.LogQueries = new.LogQueries
+	cfg.LogQueriesRedact = new.LogQueriesRedact
+	return cfg
+}
+
+// redact returns the value to print instead of a secret.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
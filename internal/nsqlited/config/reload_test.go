@@ -0,0 +1,84 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := Config{
+		DataDirectory:      "./data",
+		AuthTokenAlgorithm: "plaintext",
+		AuthToken:          "old-token",
+		ListenHost:         "0.0.0.0",
+		ListenPort:         "9876",
+		TxIdleTimeout:      10 * time.Second,
+	}
+
+	t.Run("No changes", func(t *testing.T) {
+		assert.Empty(t, Diff(old, old))
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		new := old
+		new.AuthToken = "new-token"
+		new.ListenPort = "7000"
+
+		assert.Equal(t, []Change{
+			{Name: "listen-port", Old: "9876", New: "7000"},
+			{Name: "auth-token", Old: "REDACTED", New: "REDACTED", Reloadable: true},
+		}, Diff(old, new))
+	})
+
+	t.Run("Log levels", func(t *testing.T) {
+		new := old
+		new.LogLevel = "debug"
+		new.LogLevelOverrides = "server=warn"
+
+		assert.Equal(t, []Change{
+			{Name: "log-level", Old: "", New: "debug", Reloadable: true},
+			{Name: "log-level-overrides", Old: "", New: "server=warn", Reloadable: true},
+		}, Diff(old, new))
+	})
+
+	t.Run("Auth token removed", func(t *testing.T) {
+		new := old
+		new.AuthToken = ""
+
+		assert.Equal(t, []Change{
+			{Name: "auth-token", Old: "REDACTED", New: "", Reloadable: true},
+		}, Diff(old, new))
+	})
+
+	t.Run("Auth tokens", func(t *testing.T) {
+		new := old
+		new.AuthTokens = []AuthTokenEntry{{Label: "new", Token: "new-token"}}
+		newer := new
+		newer.AuthTokens = []AuthTokenEntry{{Label: "new", Token: "newer-token"}}
+
+		assert.Equal(t, []Change{
+			{Name: "auth-tokens", Old: "", New: "REDACTED", Reloadable: true},
+		}, Diff(old, new))
+		assert.Equal(t, []Change{
+			{Name: "auth-tokens", Old: "REDACTED", New: "REDACTED", Reloadable: true},
+		}, Diff(new, newer), "a changed token is detected")
+	})
+}
+
+func TestWithReloadable(t *testing.T) {
+	old := Config{
+		AuthTokenAlgorithm: "plaintext", AuthToken: "old-token", ListenPort: "9876",
+		LogLevel: "info",
+	}
+	new := Config{
+		AuthTokenAlgorithm: "bcrypt", AuthToken: "new-token", ListenPort: "7000",
+		LogLevel: "debug", LogLevelOverrides: "database=warn",
+	}
+
+	assert.Equal(t, Config{
+		AuthTokenAlgorithm: "bcrypt", AuthToken: "new-token", ListenPort: "9876",
+		LogLevel: "debug", LogLevelOverrides: "database=warn",
+	}, old.WithReloadable(new))
+}
@@ -0,0 +1,69 @@
+// Package datadir manages the layout of the data directory of nsqlited and
+// the lock that prevents two processes from using it at once.
+package datadir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Layout are the paths of the data directory.
+type Layout struct {
+	// Root is the data directory.
+	Root string
+	// Database is the SQLite database file.
+	Database string
+	// LegacyDatabase is the SQLite database file in the data directories
+	// of layout revision 1, it is moved to Database by Migrate.
+	LegacyDatabase string
+	// Backups is the directory of the backups of the database.
+	Backups string
+	// Logs is the directory of the log files.
+	Logs string
+	// Lock is the lock file held by the nsqlited process using the data
+	// directory.
+	Lock string
+	// BootstrapAuth is the file with the hash of the auth token generated
+	// by --bootstrap-auth.
+	BootstrapAuth string
+	// Meta is the file with the version of nsqlited and the layout revision
+	// that last started with the data directory.
+	Meta string
+}
+
+// NewLayout returns the layout of the data directory at root.
+func NewLayout(root string) Layout {
+	return Layout{
+		Root:           root,
+		Database:       filepath.Join(root, "db", "database.sqlite"),
+		LegacyDatabase: filepath.Join(root, "database.sqlite"),
+		Backups:        filepath.Join(root, "backups"),
+		Logs:           filepath.Join(root, "logs"),
+		Lock:           filepath.Join(root, "nsqlited.lock"),
+		BootstrapAuth:  filepath.Join(root, "bootstrap-auth.json"),
+		Meta:           filepath.Join(root, "nsqlite_meta.json"),
+	}
+}
+
+// Create creates the data directory and its subdirectories if they don't
+// exist yet.
+func (l Layout) Create() error {
+	for _, dir := range []string{l.Root, filepath.Dir(l.Database), l.Backups, l.Logs} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// ExistingDatabase returns the path of the database of the data directory
+// in its current or legacy location, false if there is no database yet.
+func (l Layout) ExistingDatabase() (string, bool) {
+	for _, path := range []string{l.Database, l.LegacyDatabase} {
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
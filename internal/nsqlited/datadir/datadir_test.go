@@ -0,0 +1,76 @@
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "data")
+	layout := NewLayout(root)
+
+	assert.Equal(t, filepath.Join(root, "db", "database.sqlite"), layout.Database)
+	assert.Equal(t, filepath.Join(root, "nsqlited.lock"), layout.Lock)
+
+	require.NoError(t, layout.Create())
+	assert.DirExists(t, layout.Backups)
+	assert.DirExists(t, layout.Logs)
+	assert.DirExists(t, filepath.Dir(layout.Database))
+	assert.NoFileExists(t, layout.Database, "the database is created by SQLite")
+
+	require.NoError(t, layout.Create(), "the existing directories are kept")
+}
+
+func TestAcquireLock(t *testing.T) {
+	t.Run("Second instance", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nsqlited.lock")
+		lock, err := AcquireLock(path)
+		require.NoError(t, err)
+		assert.Zero(t, lock.StalePID)
+
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(os.Getpid()), strings.TrimSpace(string(contents)))
+
+		// The lock is held by the open file, so a second one fails like
+		// in another process.
+		_, err = AcquireLock(path)
+		var lockedErr *LockedError
+		require.ErrorAs(t, err, &lockedErr)
+		assert.Equal(t, os.Getpid(), lockedErr.PID)
+		assert.ErrorContains(t, err, "in use by another nsqlited process with PID "+strconv.Itoa(os.Getpid()))
+
+		require.NoError(t, lock.Release())
+		assert.NoFileExists(t, path)
+
+		lock, err = AcquireLock(path)
+		require.NoError(t, err, "the lock can be taken again once released")
+		require.NoError(t, lock.Release())
+	})
+
+	t.Run("Stale lock", func(t *testing.T) {
+		// A crashed process leaves the lock file, but not the lock.
+		path := filepath.Join(t.TempDir(), "nsqlited.lock")
+		require.NoError(t, os.WriteFile(path, []byte("999999\n"), 0644))
+
+		lock, err := AcquireLock(path)
+		require.NoError(t, err)
+		defer lock.Release()
+		assert.Equal(t, 999999, lock.StalePID)
+
+		contents, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(contents))
+	})
+
+	t.Run("Missing directory", func(t *testing.T) {
+		_, err := AcquireLock(filepath.Join(t.TempDir(), "missing", "nsqlited.lock"))
+		assert.ErrorContains(t, err, "failed to open lock file")
+	})
+}
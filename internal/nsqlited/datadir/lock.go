@@ -0,0 +1,123 @@
+package datadir
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// LockedError is returned by AcquireLock when another process holds the
+// lock of the data directory.
+type LockedError struct {
+	Path string
+	// PID is the process that holds the lock, zero if it is not known.
+	PID int
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("the data directory is in use by another nsqlited process, lock file %s", e.Path)
+	}
+	return fmt.Sprintf(
+		"the data directory is in use by another nsqlited process with PID %d, lock file %s", e.PID, e.Path,
+	)
+}
+
+// Lock is the exclusive lock of a data directory. The operating system
+// releases it when the process exits, even if it crashes, so the lock file
+// left by a crashed process is stale and taken over.
+type Lock struct {
+	file *os.File
+	path string
+	// StalePID is the process that left a stale lock file, zero if there
+	// was none.
+	StalePID int
+}
+
+// AcquireLock takes the lock of the lock file at path, creating it if
+// needed, and writes the PID of the process in it. It fails with a
+// *LockedError if another process holds it.
+func AcquireLock(path string) (*Lock, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open lock file: %w", err)
+		}
+
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if !locked {
+			pid := readPID(f)
+			f.Close()
+			return nil, &LockedError{Path: path, PID: pid}
+		}
+
+		// The file may have been removed by the process that released it
+		// between the open and the lock, then the lock is not seen by the
+		// next processes and the new file must be locked instead.
+		if !sameFile(f, path) {
+			f.Close()
+			continue
+		}
+
+		lock := &Lock{file: f, path: path}
+		if pid := readPID(f); pid != 0 && pid != os.Getpid() {
+			lock.StalePID = pid
+		}
+		if err := writePID(f); err != nil {
+			lock.Release()
+			return nil, fmt.Errorf("failed to write lock file: %w", err)
+		}
+		return lock, nil
+	}
+}
+
+// Release removes the lock file and releases the lock.
+func (l *Lock) Release() error {
+	// The file is removed while it is still locked, so no other process
+	// can lock it in between.
+	removeErr := os.Remove(l.path)
+	if errors.Is(removeErr, os.ErrNotExist) {
+		removeErr = nil
+	}
+	return errors.Join(removeErr, l.file.Close())
+}
+
+// readPID returns the PID written in the lock file, zero if there is none.
+func readPID(f *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(string(bytes.TrimSpace(buf[:n])))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// writePID replaces the contents of the lock file with the PID of the
+// process.
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
+
+// sameFile reports whether f is still the file at path.
+func sameFile(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
@@ -0,0 +1,172 @@
+package datadir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Revision is the revision of the layout of the data directory written by
+// this version of nsqlited.
+//
+//   - 1: the database is in the root of the data directory, the data
+//     directories of nsqlited v0.1.0 and older have no meta file.
+//   - 2: the database is in the db subdirectory.
+const Revision = 2
+
+// migrations are the migrations of the layout, the migration at index i
+// migrates a data directory from revision i+1 to revision i+2.
+var migrations = []func(l Layout) error{
+	moveDatabase,
+}
+
+// Meta is the version of nsqlited and the layout revision that last started
+// with the data directory.
+type Meta struct {
+	ServerVersion  string `json:"serverVersion"`
+	LayoutRevision int    `json:"layoutRevision"`
+}
+
+// NewerError is returned when the data directory was written by a newer
+// version of nsqlited.
+type NewerError struct {
+	Path string
+	Meta Meta
+}
+
+func (e *NewerError) Error() string {
+	return fmt.Sprintf(
+		"the data directory %s was written by nsqlited %s with layout revision %d, "+
+			"upgrade nsqlited to use it",
+		e.Path, e.Meta.ServerVersion, e.Meta.LayoutRevision,
+	)
+}
+
+// Migrate migrates the data directory to the current layout revision and
+// stamps it with serverVersion. It refuses data directories written by a
+// newer version of nsqlited or layout revision. The data directory must be
+// locked. It returns the revision the data directory had before.
+func (l Layout) Migrate(serverVersion string) (int, error) {
+	meta, err := l.readMeta()
+	if err != nil {
+		return 0, err
+	}
+	if meta.LayoutRevision > Revision || compareVersions(meta.ServerVersion, serverVersion) > 0 {
+		return 0, &NewerError{Path: l.Root, Meta: meta}
+	}
+
+	for revision := meta.LayoutRevision; revision < Revision; revision++ {
+		if err := migrations[revision-1](l); err != nil {
+			return 0, fmt.Errorf(
+				"failed to migrate data directory from layout revision %d to %d: %w",
+				revision, revision+1, err,
+			)
+		}
+	}
+
+	err = l.writeMeta(Meta{ServerVersion: serverVersion, LayoutRevision: Revision})
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", l.Meta, err)
+	}
+	return meta.LayoutRevision, nil
+}
+
+// readMeta reads the meta file of the data directory. Without one, the
+// revision is 1 if there is a database in its legacy location and the
+// current revision otherwise.
+func (l Layout) readMeta() (Meta, error) {
+	contents, err := os.ReadFile(l.Meta)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, err := os.Stat(l.LegacyDatabase); err == nil {
+			return Meta{LayoutRevision: 1}, nil
+		}
+		return Meta{LayoutRevision: Revision}, nil
+	}
+	if err != nil {
+		return Meta{}, fmt.Errorf("failed to read %s: %w", l.Meta, err)
+	}
+
+	meta := Meta{}
+	if err := json.Unmarshal(contents, &meta); err != nil {
+		return Meta{}, fmt.Errorf("failed to read %s: %w", l.Meta, err)
+	}
+	if meta.LayoutRevision < 1 {
+		return Meta{}, fmt.Errorf("invalid layout revision %d in %s", meta.LayoutRevision, l.Meta)
+	}
+	return meta, nil
+}
+
+// writeMeta writes the meta file through a temporary file so it is never
+// left half written.
+func (l Layout) writeMeta(meta Meta) error {
+	contents, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(l.Root, filepath.Base(l.Meta)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(contents, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), l.Meta)
+}
+
+// moveDatabase moves the database and its WAL and shared memory files from
+// the root of the data directory to the db subdirectory. The database is
+// moved last, so an interrupted migration is completed on the next start.
+func moveDatabase(l Layout) error {
+	if err := os.MkdirAll(filepath.Dir(l.Database), 0755); err != nil {
+		return err
+	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal", ""} {
+		err := os.Rename(l.LegacyDatabase+suffix, l.Database+suffix)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareVersions compares two versions in the vMAJOR.MINOR.PATCH format,
+// an empty or malformed version is older than any other.
+func compareVersions(a, b string) int {
+	pa, pb := parseVersion(a), parseVersion(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseVersion returns the numbers of a vMAJOR.MINOR.PATCH version, -1 for
+// those that are missing or malformed. Pre-release suffixes are ignored.
+func parseVersion(version string) [3]int {
+	numbers := [3]int{-1, -1, -1}
+	version, _, _ = strings.Cut(strings.TrimPrefix(version, "v"), "-")
+	for i, part := range strings.SplitN(version, ".", 3) {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		numbers[i] = n
+	}
+	return numbers
+}
@@ -0,0 +1,159 @@
+package datadir
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLayout returns the layout of a new data directory.
+func newTestLayout(t *testing.T) Layout {
+	t.Helper()
+
+	layout := NewLayout(filepath.Join(t.TempDir(), "data"))
+	require.NoError(t, layout.Create())
+	return layout
+}
+
+// readTestMeta reads the meta file of the data directory.
+func readTestMeta(t *testing.T, layout Layout) Meta {
+	t.Helper()
+
+	contents, err := os.ReadFile(layout.Meta)
+	require.NoError(t, err)
+	meta := Meta{}
+	require.NoError(t, json.Unmarshal(contents, &meta))
+	return meta
+}
+
+// writeTestMeta writes the meta file of the data directory.
+func writeTestMeta(t *testing.T, layout Layout, meta Meta) {
+	t.Helper()
+
+	contents, err := json.Marshal(meta)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(layout.Meta, contents, 0644))
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("New data directory", func(t *testing.T) {
+		layout := newTestLayout(t)
+
+		from, err := layout.Migrate("v0.2.0")
+		require.NoError(t, err)
+		assert.Equal(t, Revision, from)
+		assert.Equal(t, Meta{ServerVersion: "v0.2.0", LayoutRevision: Revision}, readTestMeta(t, layout))
+	})
+
+	t.Run("Legacy data directory", func(t *testing.T) {
+		layout := newTestLayout(t)
+		require.NoError(t, os.WriteFile(layout.LegacyDatabase, []byte("db"), 0644))
+		require.NoError(t, os.WriteFile(layout.LegacyDatabase+"-wal", []byte("wal"), 0644))
+
+		from, err := layout.Migrate("v0.2.0")
+		require.NoError(t, err)
+		assert.Equal(t, 1, from)
+		assert.Equal(t, Meta{ServerVersion: "v0.2.0", LayoutRevision: Revision}, readTestMeta(t, layout))
+
+		assert.NoFileExists(t, layout.LegacyDatabase)
+		assert.NoFileExists(t, layout.LegacyDatabase+"-wal")
+		contents, err := os.ReadFile(layout.Database)
+		require.NoError(t, err)
+		assert.Equal(t, "db", string(contents))
+		contents, err = os.ReadFile(layout.Database + "-wal")
+		require.NoError(t, err)
+		assert.Equal(t, "wal", string(contents))
+
+		path, ok := layout.ExistingDatabase()
+		assert.True(t, ok)
+		assert.Equal(t, layout.Database, path)
+	})
+
+	t.Run("Interrupted migration", func(t *testing.T) {
+		// The WAL was moved, but not the database nor the meta file.
+		layout := newTestLayout(t)
+		require.NoError(t, os.WriteFile(layout.LegacyDatabase, []byte("db"), 0644))
+		require.NoError(t, os.WriteFile(layout.Database+"-wal", []byte("wal"), 0644))
+
+		path, ok := layout.ExistingDatabase()
+		assert.True(t, ok)
+		assert.Equal(t, layout.LegacyDatabase, path)
+
+		from, err := layout.Migrate("v0.2.0")
+		require.NoError(t, err)
+		assert.Equal(t, 1, from)
+		assert.FileExists(t, layout.Database)
+		assert.FileExists(t, layout.Database+"-wal")
+		assert.NoFileExists(t, layout.LegacyDatabase)
+	})
+
+	t.Run("Older version is stamped", func(t *testing.T) {
+		layout := newTestLayout(t)
+		writeTestMeta(t, layout, Meta{ServerVersion: "v0.1.5", LayoutRevision: Revision})
+
+		from, err := layout.Migrate("v0.2.0")
+		require.NoError(t, err)
+		assert.Equal(t, Revision, from)
+		assert.Equal(t, "v0.2.0", readTestMeta(t, layout).ServerVersion)
+	})
+
+	t.Run("Newer layout revision", func(t *testing.T) {
+		layout := newTestLayout(t)
+		writeTestMeta(t, layout, Meta{ServerVersion: "v0.2.0", LayoutRevision: Revision + 1})
+
+		_, err := layout.Migrate("v0.2.0")
+		var newerErr *NewerError
+		require.ErrorAs(t, err, &newerErr)
+		assert.Equal(t, Revision+1, newerErr.Meta.LayoutRevision)
+		assert.ErrorContains(t, err, "upgrade nsqlited to use it")
+		assert.Equal(t, Revision+1, readTestMeta(t, layout).LayoutRevision, "the meta file is kept")
+	})
+
+	t.Run("Newer version", func(t *testing.T) {
+		layout := newTestLayout(t)
+		writeTestMeta(t, layout, Meta{ServerVersion: "v0.10.0", LayoutRevision: Revision})
+
+		_, err := layout.Migrate("v0.2.0")
+		var newerErr *NewerError
+		require.ErrorAs(t, err, &newerErr)
+		assert.ErrorContains(t, err, "was written by nsqlited v0.10.0")
+		assert.Equal(t, "v0.10.0", readTestMeta(t, layout).ServerVersion)
+	})
+
+	t.Run("Invalid meta file", func(t *testing.T) {
+		layout := newTestLayout(t)
+		require.NoError(t, os.WriteFile(layout.Meta, []byte("{"), 0644))
+
+		_, err := layout.Migrate("v0.2.0")
+		assert.ErrorContains(t, err, "failed to read")
+
+		writeTestMeta(t, layout, Meta{ServerVersion: "v0.2.0"})
+		_, err = layout.Migrate("v0.2.0")
+		assert.ErrorContains(t, err, "invalid layout revision 0")
+	})
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "v0.1.0", b: "v0.1.0", want: 0},
+		{a: "v0.1.0", b: "v0.2.0", want: -1},
+		{a: "v0.10.0", b: "v0.9.9", want: 1},
+		{a: "v1.0.0", b: "v0.99.99", want: 1},
+		{a: "v0.2.0-rc1", b: "v0.2.0", want: 0},
+		{a: "", b: "v0.1.0", want: -1},
+		{a: "dev", b: "v0.1.0", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, compareVersions(tt.a, tt.b))
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ApplicationID is the SQLite application_id of the databases of NSQLite,
+// "NSQL" in ASCII.
+const ApplicationID int32 = 0x4E53514C
+
+// checkApplicationID sets the application ID of NSQLite on the database if
+// it has none, like fresh databases and those created before it was set. A
+// database with the application ID of another application is refused
+// unless forceAdopt is true, then it is replaced.
+func checkApplicationID(conn *sql.DB, forceAdopt bool) error {
+	var id int32
+	if err := conn.QueryRow("PRAGMA application_id").Scan(&id); err != nil {
+		return fmt.Errorf("failed to read application ID: %w", err)
+	}
+	if id == ApplicationID {
+		return nil
+	}
+	if id != 0 && !forceAdopt {
+		return fmt.Errorf(
+			"the database has the application ID %d of another application, "+
+				"use --force-adopt to use it with NSQLite anyway", id,
+		)
+	}
+
+	if _, err := conn.Exec(fmt.Sprintf("PRAGMA application_id = %d", ApplicationID)); err != nil {
+		return fmt.Errorf("failed to set application ID: %w", err)
+	}
+	return nil
+}
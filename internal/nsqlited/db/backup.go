@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+)
+
+// ErrInvalidBackup is returned by Restore when the database to restore is
+// not a valid SQLite database of NSQLite.
+var ErrInvalidBackup = errors.New("the backup is not a valid NSQLite database")
+
+// Backup writes a consistent snapshot of the database to a new file in the
+// backups directory of the data directory and returns its path, the caller
+// must remove the file once it is done with it. The snapshot is copied from
+// a read connection, so the writes are not blocked while it is made.
+func (db *DB) Backup(ctx context.Context) (string, error) {
+	path, err := db.createBackupFile("backup-*.sqlite")
+	if err != nil {
+		return "", err
+	}
+
+	conn, returnConn, err := db.getReadOnlyRawConn(ctx)
+	if err != nil {
+		_ = os.Remove(path)
+		return "", fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() { _ = returnConn() }()
+
+	if err := conn.BackupToFile(ctx, path); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// Restore replaces the content of the database with the SQLite database
+// read from database, which must pass the quick check and have the
+// application ID of NSQLite or none. It fails with ErrTxOnlyOne if a
+// transaction is open, and the writes wait until it is done.
+//
+// The database is written to the backups directory of the data directory
+// first, then copied into the database with the write connection, so the
+// read connections see either the old or the new content.
+func (db *DB) Restore(ctx context.Context, database io.Reader) error {
+	path, err := db.createBackupFile("restore-*.sqlite")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(path) }()
+
+	if err := writeBackupFile(path, database); err != nil {
+		return err
+	}
+
+	src, err := sqlitec.OpenWithFlags(path, sqlitec.OpenReadWrite)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer func() { _ = src.Close() }()
+
+	id, err := checkBackup(src)
+	if err != nil {
+		return err
+	}
+
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	if !db.txId.CompareAndSwap("", txIdPending) {
+		return ErrTxOnlyOne
+	}
+	defer db.txId.Store("")
+
+	conn, returnConn, err := db.getReadWriteRawConn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get read-write connection from pool: %w", err)
+	}
+	defer func() { _ = returnConn() }()
+
+	if err := src.BackupTo(ctx, conn, 0, 0); err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	if id != ApplicationID {
+		query := fmt.Sprintf("PRAGMA application_id = %d", ApplicationID)
+		if _, err := conn.Query(query, nil); err != nil {
+			return fmt.Errorf("failed to set application ID: %w", err)
+		}
+	}
+
+	db.Logger.InfoNs(log.NsDatabase, "database restored from a backup")
+	return nil
+}
+
+// createBackupFile creates an empty file in the backups directory with a
+// name matching pattern, like os.CreateTemp, and returns its path.
+func (db *DB) createBackupFile(pattern string) (string, error) {
+	file, err := os.CreateTemp(db.layout.Backups, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// writeBackupFile writes the content of database to the file at path, it
+// fails with ErrInvalidBackup if there is none.
+func writeBackupFile(path string, database io.Reader) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	written, err := io.Copy(file, database)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if written == 0 {
+		return fmt.Errorf("%w: it is empty", ErrInvalidBackup)
+	}
+	return nil
+}
+
+// checkBackup returns the application ID of the database to restore, or
+// ErrInvalidBackup if it is not a valid SQLite database of NSQLite.
+func checkBackup(conn *sqlitec.Conn) (int32, error) {
+	res, err := conn.Query("PRAGMA quick_check", nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != "ok" {
+		return 0, fmt.Errorf("%w: the quick check failed", ErrInvalidBackup)
+	}
+
+	res, err = conn.Query("PRAGMA application_id", nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+	id, _ := res.Rows[0][0].(int)
+	if int32(id) != ApplicationID && id != 0 {
+		return 0, fmt.Errorf("%w: it has the application ID %d of another application", ErrInvalidBackup, id)
+	}
+	return int32(id), nil
+}
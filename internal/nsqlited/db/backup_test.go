@@ -0,0 +1,137 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	dataDirectory := t.TempDir()
+	db, err := NewDB(newTestConfig(t, dataDirectory))
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.Query(ctx, Query{Query: "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)"})
+	require.NoError(t, err)
+	_, err = db.Query(ctx, Query{Query: "INSERT INTO items (name) VALUES ('a'), ('b')"})
+	require.NoError(t, err)
+
+	path, err := db.Backup(ctx)
+	require.NoError(t, err)
+	defer os.Remove(path)
+	assert.Equal(t, datadir.NewLayout(dataDirectory).Backups, filepath.Dir(path))
+
+	conn, err := sqlitec.OpenWithFlags(path, sqlitec.OpenReadOnly)
+	require.NoError(t, err)
+	defer conn.Close()
+	res, err := conn.Query("SELECT name FROM items ORDER BY id", nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{"a"}, {"b"}}, res.Rows)
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := db.Backup(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		backups, err := filepath.Glob(filepath.Join(datadir.NewLayout(dataDirectory).Backups, "*.sqlite"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{path}, backups, "the file of the failed backup is removed")
+	})
+}
+
+func TestRestore(t *testing.T) {
+	// newBackup creates a database file with the items of names and the
+	// application ID, then returns its content.
+	newBackup := func(t *testing.T, id int32, names ...string) []byte {
+		path := filepath.Join(t.TempDir(), "backup.sqlite")
+		conn, err := sqlitec.Open(path)
+		require.NoError(t, err)
+		_, err = conn.Exec(fmt.Sprintf("PRAGMA application_id = %d; CREATE TABLE items (name TEXT)", id))
+		require.NoError(t, err)
+		for _, name := range names {
+			_, err = conn.Query("INSERT INTO items VALUES (?)", []sqlitec.QueryParam{{Value: name}})
+			require.NoError(t, err)
+		}
+		require.NoError(t, conn.Close())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return content
+	}
+
+	newDB := func(t *testing.T) *DB {
+		db, err := NewDB(newTestConfig(t, t.TempDir()))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		_, err = db.Query(context.Background(), Query{Query: "CREATE TABLE old (name TEXT)"})
+		require.NoError(t, err)
+		return db
+	}
+
+	t.Run("Replaces the database", func(t *testing.T) {
+		db := newDB(t)
+		ctx := context.Background()
+
+		require.NoError(t, db.Restore(ctx, bytes.NewReader(newBackup(t, 0, "a", "b"))))
+
+		res, err := db.Query(ctx, Query{Query: "SELECT name FROM items"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"a"}, {"b"}}, res.Rows)
+		_, err = db.Query(ctx, Query{Query: "SELECT * FROM old"})
+		assert.ErrorContains(t, err, "no such table: old")
+
+		res, err = db.Query(ctx, Query{Query: "PRAGMA application_id"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{int(ApplicationID)}}, res.Rows, "the application ID is set")
+		res, err = db.Query(ctx, Query{Query: "PRAGMA journal_mode"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"wal"}}, res.Rows)
+
+		backups, err := filepath.Glob(filepath.Join(db.layout.Backups, "*"))
+		require.NoError(t, err)
+		assert.Empty(t, backups, "the uploaded file is removed")
+	})
+
+	t.Run("Invalid backups", func(t *testing.T) {
+		db := newDB(t)
+
+		for name, content := range map[string][]byte{
+			"Empty":             nil,
+			"Not a database":    []byte("not a database, just some text long enough for a header"),
+			"Other application": newBackup(t, 42, "a"),
+		} {
+			err := db.Restore(context.Background(), bytes.NewReader(content))
+			assert.ErrorIs(t, err, ErrInvalidBackup, name)
+		}
+
+		_, err := db.Query(context.Background(), Query{Query: "SELECT * FROM old"})
+		assert.NoError(t, err, "the database is kept")
+	})
+
+	t.Run("Open transaction", func(t *testing.T) {
+		db := newDB(t)
+		ctx := context.Background()
+		res, err := db.Query(ctx, Query{Query: "BEGIN"})
+		require.NoError(t, err)
+
+		err = db.Restore(ctx, bytes.NewReader(newBackup(t, 0, "a")))
+		assert.ErrorIs(t, err, ErrTxOnlyOne)
+
+		_, err = db.Query(ctx, Query{TxId: res.TxId, Query: "ROLLBACK"})
+		require.NoError(t, err)
+		require.NoError(t, db.Restore(ctx, bytes.NewReader(newBackup(t, 0, "a"))))
+	})
+}
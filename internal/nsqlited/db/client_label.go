@@ -0,0 +1,19 @@
+package db
+
+import "context"
+
+// clientLabelKey is the context key for the client attribution label.
+type clientLabelKey struct{}
+
+// WithClientLabel returns a copy of ctx carrying the given client label, it
+// is used to attribute the queries executed with that context in the stats.
+func WithClientLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, clientLabelKey{}, label)
+}
+
+// ClientLabelFromContext returns the client label stored in ctx or an empty
+// string if there is none.
+func ClientLabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(clientLabelKey{}).(string)
+	return label
+}
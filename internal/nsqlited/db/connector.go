@@ -2,27 +2,85 @@ package db
 
 import (
 	"database/sql/driver"
+	"math/rand/v2"
+	"time"
 
+	"github.com/nsqlite/nsqlite/internal/nsqlited/pragmas"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitedrv"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
 )
 
-func newConnector(dbPath string, readOnly bool) driver.Connector {
+const (
+	// busyBackoffMin is the first wait of the busy handler of the write
+	// connection, it doubles on each retry.
+	busyBackoffMin = time.Millisecond
+	// defaultBusyBackoffMax is the longest wait of the busy handler of the
+	// write connection when Config.BusyBackoffMax is zero.
+	defaultBusyBackoffMax = 100 * time.Millisecond
+)
+
+// newConnector returns the connector of the database at dbPath, whose
+// connections wait up to busyTimeout for the locks and run the pragmas of
+// the profile after connecting. The read-only connections refuse any
+// statement that writes. If walAutoCheckpoint is not nil, it replaces the
+// wal_autocheckpoint of the profile, and if newBusyHandler is not nil, its
+// handlers replace the busy timeout.
+func newConnector(
+	dbPath string, readOnly bool, profile []pragmas.Pragma, busyTimeout time.Duration, walAutoCheckpoint *int,
+	newBusyHandler func() sqlitec.BusyHandler,
+) driver.Connector {
 	optimizations := []string{
-		"PRAGMA JOURNAL_MODE = WAL;",
-		"PRAGMA BUSY_TIMEOUT = 5000;",
-		"PRAGMA SYNCHRONOUS = NORMAL;",
-		"PRAGMA CACHE_SIZE = 10000;",
 		"PRAGMA FOREIGN_KEYS = true;",
-		"PRAGMA TEMP_STORE = MEMORY;",
-		"PRAGMA MMAP_SIZE = 536870912;", // 512MB
+	}
+	for _, pragma := range profile {
+		optimizations = append(optimizations, pragma.Statement())
 	}
 
+	openMode := sqlitedrv.WithOpenFlags(sqlitec.OpenReadWrite | sqlitec.OpenCreate)
 	if readOnly {
-		optimizations = append(optimizations, "PRAGMA QUERY_ONLY = true;")
+		openMode = sqlitedrv.WithReadOnly()
 	}
 
-	return sqlitedrv.NewConnector(
-		dbPath,
+	options := []sqlitedrv.ConnectorOption{
+		openMode,
+		sqlitedrv.WithBusyTimeout(busyTimeout),
 		sqlitedrv.WithPostConnectQueries(optimizations),
-	)
+	}
+	if walAutoCheckpoint != nil {
+		options = append(options, sqlitedrv.WithWalAutoCheckpoint(*walAutoCheckpoint))
+	}
+	if newBusyHandler != nil {
+		options = append(options, sqlitedrv.WithBusyHandler(newBusyHandler))
+	}
+
+	return sqlitedrv.NewConnector(dbPath, options...)
+}
+
+// newBusyHandler returns the constructor of the busy handlers of the write
+// connection. They retry with an exponential backoff, waiting at most
+// backoffMax between two retries, until the lock was waited for timeout,
+// and record each retry in dbStats.
+func newBusyHandler(timeout time.Duration, backoffMax time.Duration, dbStats *stats.DBStats) func() sqlitec.BusyHandler {
+	return func() sqlitec.BusyHandler {
+		var start time.Time
+		return func(attempts int) bool {
+			if attempts == 0 {
+				start = time.Now()
+			}
+			remaining := timeout - time.Since(start)
+			if remaining <= 0 {
+				return false
+			}
+
+			// The jitter keeps the writers waiting for the same lock from
+			// retrying all at once.
+			backoff := min(busyBackoffMin<<min(attempts, 30), backoffMax)
+			backoff = backoff/2 + rand.N(backoff/2+1)
+			time.Sleep(min(backoff, remaining))
+
+			dbStats.IncBusyRetries()
+			return true
+		}
+	}
 }
@@ -6,18 +6,20 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
-	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/pragmas"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitedrv"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
 	"github.com/nsqlite/nsqlite/internal/util/syncutil"
+	"github.com/nsqlite/nsqlite/internal/version"
 	"github.com/orsinium-labs/enum"
 )
 
@@ -39,12 +41,40 @@ type Config struct {
 	// TxIdleTimeout if a transaction is not active for this duration, it
 	// will be rolled back.
 	TxIdleTimeout time.Duration
+	// BusyTimeout is how long the connections wait for the locks held by
+	// other connections before failing with SQLITE_BUSY, zero disables the
+	// wait.
+	BusyTimeout time.Duration
+	// BusyBackoffMax is the longest wait of the write connection between two
+	// attempts to take the locks held by other connections, the wait doubles
+	// on each attempt up to it. 100ms if zero.
+	BusyBackoffMax time.Duration
+	// ForceAdopt starts with a database that has the application ID of
+	// another application, replacing it with the NSQLite one.
+	ForceAdopt bool
+	// Pragmas are run on each connection, the ones of the default profile
+	// if nil.
+	Pragmas []pragmas.Pragma
+	// WalAutoCheckpoint is the number of WAL pages after which the write
+	// connection checkpoints it, zero or less disables the automatic
+	// checkpoints. If nil, the wal_autocheckpoint of Pragmas is kept.
+	WalAutoCheckpoint *int
+	// QueryLog is the mode of the query log, one of QueryLogModes, off if
+	// empty. It can be changed later with SetQueryLog.
+	QueryLog string
+	// QueryLogRedact are the names of the parameters whose value is never
+	// logged by the query log.
+	QueryLogRedact []string
 }
 
 // DB represents the SQLite integration for NSQLite.
 type DB struct {
 	Config
 	isInitialized     bool
+	layout            datadir.Layout
+	lock              *datadir.Lock
+	effectivePragmas  []pragmas.Pragma
+	queryLog          atomic.Pointer[queryLog]
 	readWriteConn     *sql.DB
 	readOnlyConn      *sql.DB
 	txId              syncutil.AtomicString
@@ -82,21 +112,65 @@ func NewDB(config Config) (*DB, error) {
 	if config.DataDirectory == "" {
 		return nil, errors.New("database directory is required")
 	}
-	if err := os.MkdirAll(config.DataDirectory, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create database directory: %w", err)
-	}
 	if config.TxIdleTimeout <= 0 {
 		return nil, errors.New("transaction idle timeout must be provided")
 	}
 
-	databasePath := path.Join(config.DataDirectory, "database.sqlite")
-	readWriteConnector := newConnector(databasePath, false)
-	readOnlyConnector := newConnector(databasePath, true)
+	layout := datadir.NewLayout(config.DataDirectory)
+	if err := layout.Create(); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	lock, err := datadir.AcquireLock(layout.Lock)
+	if err != nil {
+		return nil, err
+	}
+	if lock.StalePID != 0 {
+		config.Logger.WarnNs(log.NsDatabase, "took over the stale lock of a process that did not shut down", log.KV{
+			"pid": lock.StalePID,
+		})
+	}
+	releaseLock := func() { _ = lock.Release() }
+
+	previousRevision, err := layout.Migrate(version.Version)
+	if err != nil {
+		releaseLock()
+		return nil, err
+	}
+	if previousRevision != datadir.Revision {
+		config.Logger.InfoNs(log.NsDatabase, "migrated the layout of the data directory", log.KV{
+			"fromRevision": previousRevision,
+			"toRevision":   datadir.Revision,
+		})
+	}
+
+	if config.Pragmas == nil {
+		config.Pragmas, _ = pragmas.Resolve(pragmas.DefaultProfile, nil)
+	}
+	if config.BusyBackoffMax <= 0 {
+		config.BusyBackoffMax = defaultBusyBackoffMax
+	}
+	readWriteConnector := newConnector(
+		layout.Database, false, config.Pragmas, config.BusyTimeout, config.WalAutoCheckpoint,
+		newBusyHandler(config.BusyTimeout, config.BusyBackoffMax, config.DBStats),
+	)
+	readOnlyConnector := newConnector(layout.Database, true, config.Pragmas, config.BusyTimeout, nil, nil)
 
 	readWriteConn := sql.OpenDB(readWriteConnector)
 	if err := readWriteConn.Ping(); err != nil {
+		releaseLock()
 		return nil, fmt.Errorf("failed to ping write connection: %w", err)
 	}
+	if err := checkApplicationID(readWriteConn, config.ForceAdopt); err != nil {
+		_ = readWriteConn.Close()
+		releaseLock()
+		return nil, err
+	}
+	effectivePragmas, err := readPragmas(readWriteConn)
+	if err != nil {
+		_ = readWriteConn.Close()
+		releaseLock()
+		return nil, err
+	}
 	readWriteConn.SetConnMaxIdleTime(0)
 	readWriteConn.SetConnMaxLifetime(0)
 	readWriteConn.SetMaxIdleConns(1)
@@ -104,6 +178,8 @@ func NewDB(config Config) (*DB, error) {
 
 	readOnlyConn := sql.OpenDB(readOnlyConnector)
 	if err := readOnlyConn.Ping(); err != nil {
+		_ = readWriteConn.Close()
+		releaseLock()
 		return nil, fmt.Errorf("failed to ping read connection: %w", err)
 	}
 	readOnlyConn.SetConnMaxIdleTime(0)
@@ -113,19 +189,27 @@ func NewDB(config Config) (*DB, error) {
 	db := &DB{
 		Config:            config,
 		isInitialized:     true,
+		layout:            layout,
+		lock:              lock,
+		effectivePragmas:  effectivePragmas,
 		readWriteConn:     readWriteConn,
 		readOnlyConn:      readOnlyConn,
-		txId:              *syncutil.NewAtomicString(""),
-		txIdLastUsed:      *syncutil.NewAtomicTime(time.Now()),
 		txIdleMonitorStop: make(chan any),
 		writeMu:           sync.Mutex{},
 		closeWg:           sync.WaitGroup{},
 	}
 
+	db.txIdLastUsed.Store(time.Now())
+	db.SetQueryLog(config.QueryLog, config.QueryLogRedact)
+
 	db.closeWg.Add(1)
 	go db.txIdleMonitor(config.TxIdleTimeout)
 
-	config.Logger.InfoNs(log.NsDatabase, "database started")
+	pragmasKV := log.KV{}
+	for _, pragma := range effectivePragmas {
+		pragmasKV[pragma.Name] = pragma.Value
+	}
+	config.Logger.InfoNs(log.NsDatabase, "database started", log.KV{"pragmas": pragmasKV})
 	return db, nil
 }
 
@@ -166,6 +250,25 @@ func (db *DB) getReadOnlyRawConn(ctx context.Context) (*sqlitec.Conn, func() err
 	return db.getRawConn(ctx, db.readOnlyConn)
 }
 
+// readPragmas returns the values of the pragmas of the profiles in the
+// connection, as reported by SQLite.
+func readPragmas(conn *sql.DB) ([]pragmas.Pragma, error) {
+	effective := make([]pragmas.Pragma, len(pragmas.Names))
+	for i, name := range pragmas.Names {
+		effective[i].Name = name
+		if err := conn.QueryRow("PRAGMA " + name).Scan(&effective[i].Value); err != nil {
+			return nil, fmt.Errorf("failed to read pragma %s: %w", name, err)
+		}
+	}
+	return effective, nil
+}
+
+// EffectivePragmas returns the values of the pragmas of the profiles as
+// reported by SQLite when the database was opened.
+func (db *DB) EffectivePragmas() []pragmas.Pragma {
+	return db.effectivePragmas
+}
+
 // IsInitialized returns whether the DB instance is initialized.
 func (db *DB) IsInitialized() bool {
 	return db.isInitialized
@@ -182,11 +285,14 @@ func (db *DB) txIdleMonitor(timeout time.Duration) {
 		case <-db.txIdleMonitorStop:
 			return
 		case <-ticker.C:
-			if db.txId.Load() == "" {
+			txId := db.txId.Load()
+			if !isTxId(txId) {
 				continue
 			}
 			if time.Since(db.txIdLastUsed.Load()) > timeout {
-				_, _ = db.executeRollbackQuery(context.Background(), db.txId.Load())
+				logger := db.logger(context.Background(), txId)
+				logger.DebugNs(log.NsDatabase, "rolling back idle transaction")
+				_, _ = db.executeRollbackQuery(context.Background(), txId)
 			}
 		}
 	}
@@ -197,8 +303,8 @@ func (db *DB) Close() error {
 	close(db.txIdleMonitorStop)
 	db.closeWg.Wait()
 
-	if db.txId.Load() != "" {
-		_, _ = db.executeRollbackQuery(context.Background(), db.txId.Load())
+	if txId := db.txId.Load(); isTxId(txId) {
+		_, _ = db.executeRollbackQuery(context.Background(), txId)
 	}
 
 	if db.readWriteConn != nil {
@@ -213,6 +319,12 @@ func (db *DB) Close() error {
 		}
 	}
 
+	if db.lock != nil {
+		if err := db.lock.Release(); err != nil {
+			return fmt.Errorf("failed to release data directory lock: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -231,29 +343,30 @@ var (
 // detectQueryType detects the type of query between read, write, begin, commit,
 // and rollback.
 func (db *DB) detectQueryType(ctx context.Context, query string) (queryType, error) {
-	trimmed := strings.ToLower(strings.TrimSpace(query))
-
-	switch {
-	case strings.HasPrefix(trimmed, "begin"):
-		return QueryTypeBegin, nil
-	case strings.HasPrefix(trimmed, "commit"):
-		return QueryTypeCommit, nil
-	case strings.HasPrefix(trimmed, "rollback"), strings.HasPrefix(trimmed, "end transaction"):
-		return QueryTypeRollback, nil
-	}
-
 	conn, returnConn, err := db.getReadOnlyRawConn(ctx)
 	if err != nil {
 		return QueryTypeUnknown, fmt.Errorf("failed to get connection: %w", err)
 	}
 	defer func() { _ = returnConn() }()
 
+	// The query is prepared even if it begins or ends a transaction, so the
+	// statements after the first one are not silently ignored.
 	stmt, err := conn.Prepare(query)
 	if err != nil {
 		return QueryTypeUnknown, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer func() { _ = stmt.Finalize() }()
 
+	trimmed := strings.ToLower(strings.TrimSpace(query))
+	switch {
+	case strings.HasPrefix(trimmed, "begin"):
+		return QueryTypeBegin, nil
+	case strings.HasPrefix(trimmed, "commit"):
+		return QueryTypeCommit, nil
+	case strings.HasPrefix(trimmed, "rollback"), strings.HasPrefix(trimmed, "end transaction"):
+		return QueryTypeRollback, nil
+	}
+
 	if stmt.ReadOnly() {
 		return QueryTypeRead, nil
 	}
@@ -263,20 +376,56 @@ func (db *DB) detectQueryType(ctx context.Context, query string) (queryType, err
 // Query executes an SQLite query.
 func (db *DB) Query(ctx context.Context, query Query) (QueryResult, error) {
 	res, err := db.query(ctx, query)
+	clientLabel := ClientLabelFromContext(ctx)
+
 	if err != nil {
 		db.DBStats.IncErrors()
+		db.DBStats.IncClientErrors(clientLabel)
+		logger := db.logger(ctx, query.TxId)
+		logger.DebugNs(log.NsDatabase, "query failed", log.KV{"error": err})
+		return res, err
 	}
 
-	return res, err
+	switch res.Type {
+	case QueryTypeRead:
+		db.DBStats.IncClientReads(clientLabel)
+	case QueryTypeWrite:
+		db.DBStats.IncClientWrites(clientLabel)
+	}
+
+	return res, nil
 }
 
-// query is the underlying logic for Query.
+// logger returns the logger of the request in ctx, or the one of the DB if
+// there is none, with the transaction ID if txId is not empty.
+func (db *DB) logger(ctx context.Context, txId string) log.Logger {
+	logger := log.FromContext(ctx, db.Logger)
+	if txId == "" {
+		return logger
+	}
+	return logger.WithKV(log.KV{"txId": txId})
+}
+
+// query is the underlying logic for Query, it logs the query according to
+// the mode of the query log.
 func (db *DB) query(ctx context.Context, query Query) (QueryResult, error) {
+	start := time.Now()
+	res, err := db.executeQuery(ctx, query)
+	db.logQuery(ctx, query, res, err, time.Since(start))
+	return res, err
+}
+
+// executeQuery executes the query on the connection of its type.
+func (db *DB) executeQuery(ctx context.Context, query Query) (QueryResult, error) {
 	typeOfQuery, err := db.detectQueryType(ctx, query.Query)
 	if err != nil {
 		return QueryResult{}, fmt.Errorf("failed to detect query type: %w", err)
 	}
 
+	if ReadOnlyFromContext(ctx) && (typeOfQuery == QueryTypeWrite || typeOfQuery == QueryTypeBegin) {
+		return QueryResult{}, ErrReadOnly
+	}
+
 	switch typeOfQuery {
 	case QueryTypeBegin:
 		return db.executeBeginQuery(ctx, query.TxId)
@@ -295,22 +444,27 @@ func (db *DB) query(ctx context.Context, query Query) (QueryResult, error) {
 
 // executeBeginQuery executes a begin query using the read-write connection.
 func (db *DB) executeBeginQuery(ctx context.Context, queryTxId string) (QueryResult, error) {
+	// A transaction being committed or rolled back frees the slot soon.
 	// TODO: Add support for queuing transactions when one is already active.
-	if db.txId.Load() != "" {
-		return QueryResult{}, ErrTxWithinTx
+	current, err := db.txId.WaitFor(ctx, func(txId string) bool {
+		return txId != txIdPending
+	})
+	if err != nil {
+		return QueryResult{}, err
 	}
-
-	if db.isCurrentTx(queryTxId) {
+	if current != "" || !db.txId.CompareAndSwap("", txIdPending) {
 		return QueryResult{}, ErrTxWithinTx
 	}
 
 	conn, returnConn, err := db.getReadWriteRawConn(ctx)
 	if err != nil {
+		db.txId.Store("")
 		return QueryResult{}, fmt.Errorf("failed to get read-write connection from pool: %w", err)
 	}
 	defer func() { _ = returnConn() }()
 
 	if _, err = conn.Query("BEGIN TRANSACTION", nil); err != nil {
+		db.txId.Store("")
 		return QueryResult{}, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
@@ -318,6 +472,8 @@ func (db *DB) executeBeginQuery(ctx context.Context, queryTxId string) (QueryRes
 	db.txId.Store(txId)
 	db.txIdLastUsed.Store(time.Now())
 	db.DBStats.IncBegins()
+	logger := db.logger(ctx, txId)
+	logger.DebugNs(log.NsDatabase, "transaction started")
 
 	return QueryResult{
 		Type: QueryTypeBegin,
@@ -327,23 +483,13 @@ func (db *DB) executeBeginQuery(ctx context.Context, queryTxId string) (QueryRes
 
 // executeCommitQuery commits the existing transaction with the given ID.
 func (db *DB) executeCommitQuery(ctx context.Context, queryTxId string) (QueryResult, error) {
-	if !db.isCurrentTx(queryTxId) {
-		return QueryResult{}, ErrTxNotFound
+	if err := db.endTx(ctx, queryTxId, "COMMIT"); err != nil {
+		return QueryResult{}, err
 	}
 
-	conn, returnConn, err := db.getReadWriteRawConn(ctx)
-	if err != nil {
-		return QueryResult{}, fmt.Errorf("failed to get read-write connection from pool: %w", err)
-	}
-	defer func() { _ = returnConn() }()
-
-	if _, err = conn.Query("COMMIT", nil); err != nil {
-		return QueryResult{}, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	db.txId.Store("")
-	db.txIdLastUsed.Store(time.Now())
 	db.DBStats.IncCommits()
+	logger := db.logger(ctx, queryTxId)
+	logger.DebugNs(log.NsDatabase, "transaction committed")
 
 	return QueryResult{
 		Type: QueryTypeCommit,
@@ -353,35 +499,65 @@ func (db *DB) executeCommitQuery(ctx context.Context, queryTxId string) (QueryRe
 
 // executeRollbackQuery rolls back an existing transaction.
 func (db *DB) executeRollbackQuery(ctx context.Context, queryTxId string) (QueryResult, error) {
-	if !db.isCurrentTx(queryTxId) {
-		return QueryResult{}, ErrTxNotFound
+	if err := db.endTx(ctx, queryTxId, "ROLLBACK"); err != nil {
+		return QueryResult{}, err
+	}
+
+	db.DBStats.IncRollbacks()
+	logger := db.logger(ctx, queryTxId)
+	logger.DebugNs(log.NsDatabase, "transaction rolled back")
+
+	return QueryResult{
+		Type: QueryTypeRollback,
+		TxId: queryTxId,
+	}, nil
+}
+
+// txIdPending is the transaction ID while a transaction is being begun,
+// committed or rolled back, so no other query can use it meanwhile.
+const txIdPending = "pending"
+
+// isTxId returns true if the provided ID can be the ID of a transaction,
+// that is, it is neither empty nor txIdPending.
+func isTxId(txId string) bool {
+	return txId != "" && txId != txIdPending
+}
+
+// endTx ends the transaction with the given ID with the COMMIT or ROLLBACK
+// statement. The transaction is claimed with a compare-and-swap before the
+// statement runs, so only one of a commit and a rollback racing for it, like
+// a rollback of the idle monitor, ends it, and a stale rollback cannot end
+// a transaction begun in the meantime.
+func (db *DB) endTx(ctx context.Context, txId string, statement string) error {
+	if !isTxId(txId) || !db.txId.CompareAndSwap(txId, txIdPending) {
+		return ErrTxNotFound
 	}
 
 	conn, returnConn, err := db.getReadWriteRawConn(ctx)
 	if err != nil {
-		return QueryResult{}, fmt.Errorf("failed to get read-write connection from pool: %w", err)
+		db.txId.Store(txId)
+		return fmt.Errorf("failed to get read-write connection from pool: %w", err)
 	}
 	defer func() { _ = returnConn() }()
 
-	if _, err = conn.Query("ROLLBACK", nil); err != nil {
-		return QueryResult{}, fmt.Errorf("failed to rollback transaction: %w", err)
+	if _, err = conn.Query(statement, nil); err != nil {
+		// The transaction can still be used unless SQLite rolled it back.
+		if conn.AutoCommit() {
+			txId = ""
+		}
+		db.txId.Store(txId)
+		return fmt.Errorf("failed to %s transaction: %w", strings.ToLower(statement), err)
 	}
 
 	db.txId.Store("")
 	db.txIdLastUsed.Store(time.Now())
-	db.DBStats.IncRollbacks()
-
-	return QueryResult{
-		Type: QueryTypeRollback,
-		TxId: queryTxId,
-	}, nil
+	return nil
 }
 
 // isCurrentTx returns true if the provided transaction ID is the current one.
 // it also updates the lastUsed time.
 func (db *DB) isCurrentTx(txId string) bool {
-	current := db.txId.Load()
-	if txId == "" || current == "" || txId != current {
+	if !isTxId(txId) || txId != db.txId.Load() {
 		return false
 	}
 
@@ -418,8 +594,15 @@ func (db *DB) executeWriteQuery(ctx context.Context, query Query) (QueryResult,
 	}
 	defer func() { _ = returnConn() }()
 
-	res, err := conn.Query(query.Query, query.Params)
+	res, err := conn.QueryContext(ctx, query.Query, query.Params)
 	if err != nil {
+		// SQLite rolls back the transaction after some errors, like an
+		// interrupted write, so it must not be used anymore.
+		if conn.AutoCommit() && isTxId(query.TxId) && db.txId.CompareAndSwap(query.TxId, "") {
+			db.DBStats.IncRollbacks()
+			logger := db.logger(ctx, query.TxId)
+			logger.DebugNs(log.NsDatabase, "transaction rolled back by SQLite", log.KV{"error": err})
+		}
 		return QueryResult{}, fmt.Errorf("failed to execute write query: %w", err)
 	}
 
@@ -447,7 +630,16 @@ func (db *DB) executeReadQuery(ctx context.Context, query Query) (QueryResult, e
 	}
 	defer func() { _ = returnConn() }()
 
-	res, err := conn.Query(query.Query, query.Params)
+	// The authorizer is removed before the connection goes back to the
+	// pool, so it only applies to the queries of this client.
+	if ReadOnlyFromContext(ctx) {
+		if err := conn.SetAuthorizer(readOnlyAuthorizer); err != nil {
+			return QueryResult{}, err
+		}
+		defer func() { _ = conn.SetAuthorizer(nil) }()
+	}
+
+	res, err := conn.QueryContext(ctx, query.Query, query.Params)
 	if err != nil {
 		return QueryResult{}, fmt.Errorf("failed to execute read query: %w", err)
 	}
@@ -0,0 +1,372 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/pragmas"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestConfig returns the configuration of a DB in dataDirectory.
+func newTestConfig(t *testing.T, dataDirectory string) Config {
+	dbStats := stats.NewDBStats()
+	t.Cleanup(dbStats.Close)
+
+	return Config{
+		Logger:        log.NewLogger(io.Discard),
+		DBStats:       dbStats,
+		DataDirectory: dataDirectory,
+		TxIdleTimeout: 10 * time.Second,
+		BusyTimeout:   5 * time.Second,
+	}
+}
+
+// readApplicationID returns the application ID of the database of the data
+// directory.
+func readApplicationID(t *testing.T, dataDirectory string) int32 {
+	t.Helper()
+
+	conn := sql.OpenDB(newConnector(datadir.NewLayout(dataDirectory).Database, false, nil, 5*time.Second, nil, nil))
+	defer conn.Close()
+
+	var id int32
+	require.NoError(t, conn.QueryRow("PRAGMA application_id").Scan(&id))
+	return id
+}
+
+// setApplicationID sets the application ID of the database of the data
+// directory, like another application would.
+func setApplicationID(t *testing.T, dataDirectory string, id int32) {
+	t.Helper()
+
+	layout := datadir.NewLayout(dataDirectory)
+	require.NoError(t, layout.Create())
+	conn := sql.OpenDB(newConnector(layout.Database, false, nil, 5*time.Second, nil, nil))
+	defer conn.Close()
+
+	_, err := conn.Exec(fmt.Sprintf("PRAGMA application_id = %d", id))
+	require.NoError(t, err)
+}
+
+func TestNewDBDataDirectory(t *testing.T) {
+	t.Run("Layout and application ID", func(t *testing.T) {
+		dir := t.TempDir()
+		db, err := NewDB(newTestConfig(t, dir))
+		require.NoError(t, err)
+
+		layout := datadir.NewLayout(dir)
+		assert.FileExists(t, layout.Database)
+		assert.FileExists(t, layout.Lock)
+		assert.DirExists(t, layout.Backups)
+		assert.DirExists(t, layout.Logs)
+
+		require.NoError(t, db.Close())
+		assert.NoFileExists(t, layout.Lock, "the lock is released")
+		assert.Equal(t, ApplicationID, readApplicationID(t, dir))
+	})
+
+	t.Run("Second instance", func(t *testing.T) {
+		dir := t.TempDir()
+		db, err := NewDB(newTestConfig(t, dir))
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = NewDB(newTestConfig(t, dir))
+		var lockedErr *datadir.LockedError
+		assert.ErrorAs(t, err, &lockedErr)
+	})
+
+	t.Run("Database of another application", func(t *testing.T) {
+		dir := t.TempDir()
+		setApplicationID(t, dir, 7)
+
+		_, err := NewDB(newTestConfig(t, dir))
+		assert.ErrorContains(t, err, "application ID 7 of another application")
+		assert.NoFileExists(t, datadir.NewLayout(dir).Lock, "the lock is released on errors")
+
+		config := newTestConfig(t, dir)
+		config.ForceAdopt = true
+		db, err := NewDB(config)
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+		assert.Equal(t, ApplicationID, readApplicationID(t, dir))
+	})
+
+	t.Run("Legacy data directory", func(t *testing.T) {
+		dir := t.TempDir()
+		layout := datadir.NewLayout(dir)
+		conn := sql.OpenDB(newConnector(layout.LegacyDatabase, false, nil, 5*time.Second, nil, nil))
+		_, err := conn.Exec("CREATE TABLE legacy (id INTEGER)")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		db, err := NewDB(newTestConfig(t, dir))
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+		assert.NoFileExists(t, layout.LegacyDatabase)
+		assert.FileExists(t, layout.Meta)
+
+		conn = sql.OpenDB(newConnector(layout.Database, false, nil, 5*time.Second, nil, nil))
+		defer conn.Close()
+		var name string
+		require.NoError(t, conn.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table'").Scan(&name))
+		assert.Equal(t, "legacy", name, "the database is kept")
+	})
+
+	t.Run("Data directory of a newer version", func(t *testing.T) {
+		dir := t.TempDir()
+		layout := datadir.NewLayout(dir)
+		require.NoError(t, os.WriteFile(layout.Meta, []byte(`{"serverVersion":"v99.0.0","layoutRevision":2}`), 0644))
+
+		_, err := NewDB(newTestConfig(t, dir))
+		var newerErr *datadir.NewerError
+		assert.ErrorAs(t, err, &newerErr)
+		assert.NoFileExists(t, layout.Lock, "the lock is released on errors")
+		assert.NoFileExists(t, layout.Database)
+	})
+}
+
+func TestNewDBPragmas(t *testing.T) {
+	config := newTestConfig(t, t.TempDir())
+	config.Pragmas = []pragmas.Pragma{
+		{Name: "journal_mode", Value: "WAL"}, {Name: "synchronous", Value: "FULL"},
+		{Name: "wal_autocheckpoint", Value: "500"}, {Name: "cache_size", Value: "-4096"},
+		{Name: "mmap_size", Value: "0"}, {Name: "temp_store", Value: "FILE"},
+	}
+	db, err := NewDB(config)
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, []pragmas.Pragma{
+		{Name: "journal_mode", Value: "wal"}, {Name: "synchronous", Value: "2"},
+		{Name: "wal_autocheckpoint", Value: "500"}, {Name: "cache_size", Value: "-4096"},
+		{Name: "mmap_size", Value: "0"}, {Name: "temp_store", Value: "1"},
+	}, db.EffectivePragmas())
+}
+
+func TestNewDBWalAutoCheckpoint(t *testing.T) {
+	// walSize inserts 300 rows, each in its own transaction, and returns
+	// the size of the WAL file.
+	walSize := func(t *testing.T, walAutoCheckpoint *int) (int64, []pragmas.Pragma) {
+		dataDirectory := t.TempDir()
+		config := newTestConfig(t, dataDirectory)
+		config.WalAutoCheckpoint = walAutoCheckpoint
+		db, err := NewDB(config)
+		require.NoError(t, err)
+		defer db.Close()
+
+		_, err = db.Query(context.Background(), Query{Query: "CREATE TABLE test (val BLOB)"})
+		require.NoError(t, err)
+		for range 300 {
+			_, err = db.Query(context.Background(), Query{Query: "INSERT INTO test VALUES (randomblob(2000))"})
+			require.NoError(t, err)
+		}
+
+		info, err := os.Stat(datadir.NewLayout(dataDirectory).Database + "-wal")
+		require.NoError(t, err)
+		return info.Size(), db.EffectivePragmas()
+	}
+	walAutoCheckpoint := func(effective []pragmas.Pragma) string {
+		for _, pragma := range effective {
+			if pragma.Name == "wal_autocheckpoint" {
+				return pragma.Value
+			}
+		}
+		return ""
+	}
+
+	small, disabled := 20, 0
+	smallSize, smallPragmas := walSize(t, &small)
+	disabledSize, disabledPragmas := walSize(t, &disabled)
+	_, profilePragmas := walSize(t, nil)
+
+	assert.Equal(t, "20", walAutoCheckpoint(smallPragmas))
+	assert.Equal(t, "0", walAutoCheckpoint(disabledPragmas))
+	assert.Equal(t, "1000", walAutoCheckpoint(profilePragmas), "the value of the profile is kept")
+	assert.Less(t, smallSize, int64(100*4096), "the WAL is checkpointed and reused")
+	assert.Greater(t, disabledSize, int64(600*4096), "the WAL grows without checkpoints")
+}
+
+func TestNewDBBusyHandler(t *testing.T) {
+	// lockDatabase opens another connection to the database of the data
+	// directory and takes its exclusive lock.
+	lockDatabase := func(t *testing.T, dataDirectory string) *sqlitec.Conn {
+		conn, err := sqlitec.Open(datadir.NewLayout(dataDirectory).Database)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		_, err = conn.Query("BEGIN EXCLUSIVE", nil)
+		require.NoError(t, err)
+		return conn
+	}
+
+	tests := []struct {
+		name        string
+		busyTimeout time.Duration
+		unlockAfter time.Duration
+		wantErr     bool
+	}{
+		{name: "Waits for the lock", busyTimeout: 5 * time.Second, unlockAfter: 100 * time.Millisecond},
+		{name: "Times out", busyTimeout: 50 * time.Millisecond, wantErr: true},
+		{name: "Disabled", busyTimeout: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDirectory := t.TempDir()
+			config := newTestConfig(t, dataDirectory)
+			config.BusyTimeout = tt.busyTimeout
+			config.BusyBackoffMax = 10 * time.Millisecond
+			db, err := NewDB(config)
+			require.NoError(t, err)
+			defer db.Close()
+			_, err = db.Query(context.Background(), Query{Query: "CREATE TABLE test (val TEXT)"})
+			require.NoError(t, err)
+
+			locker := lockDatabase(t, dataDirectory)
+			if tt.unlockAfter > 0 {
+				time.AfterFunc(tt.unlockAfter, func() { _, _ = locker.Query("COMMIT", nil) })
+			}
+
+			start := time.Now()
+			_, err = db.Query(context.Background(), Query{Query: "INSERT INTO test VALUES ('a')"})
+			elapsed := time.Since(start)
+			retries := db.DBStats.LoadStats().BusyRetries
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "database is locked")
+				assert.Less(t, elapsed, tt.busyTimeout+time.Second)
+			} else {
+				assert.NoError(t, err)
+				assert.GreaterOrEqual(t, elapsed, tt.unlockAfter/2, "waited for the commit")
+			}
+			if tt.busyTimeout > 0 {
+				assert.Greater(t, retries, int64(1), "the retries are recorded")
+			} else {
+				assert.Zero(t, retries)
+			}
+		})
+	}
+}
+
+func TestNewDBReadOnlyConnections(t *testing.T) {
+	for _, profile := range pragmas.ProfileNames() {
+		t.Run(profile, func(t *testing.T) {
+			config := newTestConfig(t, t.TempDir())
+			config.Pragmas, _ = pragmas.Resolve(profile, nil)
+			db, err := NewDB(config)
+			require.NoError(t, err)
+			defer db.Close()
+
+			_, err = db.Query(context.Background(), Query{Query: "CREATE TABLE test (val TEXT)"})
+			require.NoError(t, err)
+			res, err := db.Query(context.Background(), Query{Query: "SELECT count(*) FROM test"})
+			require.NoError(t, err)
+			assert.Equal(t, [][]any{{0}}, res.Rows)
+
+			_, err = db.readOnlyConn.Exec("INSERT INTO test VALUES ('a')")
+			assert.ErrorIs(t, err, sqlitec.ErrReadOnlyConn)
+		})
+	}
+}
+
+func TestExecuteReadQueryRefusesWrites(t *testing.T) {
+	dataDirectory := t.TempDir()
+	db, err := NewDB(newTestConfig(t, dataDirectory))
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"CREATE TABLE test (val TEXT)",
+		"INSERT INTO test VALUES ('a'), ('b')",
+	} {
+		_, err = db.Query(context.Background(), Query{Query: query})
+		require.NoError(t, err)
+	}
+
+	// A write misrouted to the read-only pool is refused.
+	_, err = db.executeReadQuery(context.Background(), Query{Query: "DELETE FROM test"})
+	assert.ErrorIs(t, err, sqlitec.ErrReadOnlyConn)
+	assert.EqualValues(t, 0, db.DBStats.LoadStats().Totals.Reads)
+	require.NoError(t, db.Close())
+
+	conn, err := sqlitec.Open(datadir.NewLayout(dataDirectory).Database)
+	require.NoError(t, err)
+	defer conn.Close()
+	res, err := conn.Query("SELECT count(*) FROM test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{2}}, res.Rows, "the database file is not modified")
+}
+
+func TestExecuteReadQueryReadOnlyAuthorizer(t *testing.T) {
+	db, err := NewDB(newTestConfig(t, t.TempDir()))
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Query(context.Background(), Query{Query: "CREATE TABLE test (val TEXT)"})
+	require.NoError(t, err)
+
+	readOnlyCtx := WithReadOnly(context.Background())
+	for _, query := range []string{
+		"SELECT * FROM test",
+		"WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 3) SELECT max(x) FROM c",
+		"PRAGMA user_version",
+		"PRAGMA table_info(test)",
+	} {
+		_, err := db.executeReadQuery(readOnlyCtx, Query{Query: query})
+		assert.NoError(t, err, query)
+	}
+	for _, query := range []string{
+		"ATTACH ':memory:' AS other",
+		"CREATE TEMP TABLE scratch (val TEXT)",
+		"PRAGMA cache_size = 10",
+	} {
+		_, err := db.executeReadQuery(readOnlyCtx, Query{Query: query})
+		assert.ErrorContains(t, err, "not authorized", query)
+	}
+
+	// The authorizer is removed once the read-only query is done.
+	for _, query := range []string{"ATTACH ':memory:' AS other", "DETACH other"} {
+		_, err := db.executeReadQuery(context.Background(), Query{Query: query})
+		assert.NoError(t, err, query)
+	}
+}
+
+func TestExecuteQueryCanceled(t *testing.T) {
+	db, err := NewDB(newTestConfig(t, t.TempDir()))
+	require.NoError(t, err)
+	defer db.Close()
+
+	slowQuery := Query{Query: `
+		WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c)
+		SELECT count(*) FROM c
+	`}
+	tests := []struct {
+		name    string
+		execute func(context.Context, Query) (QueryResult, error)
+	}{
+		{name: "Read", execute: db.executeReadQuery},
+		{name: "Write", execute: db.executeWriteQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			_, err := tt.execute(ctx, slowQuery)
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Less(t, time.Since(start), 5*time.Second)
+
+			res, err := tt.execute(context.Background(), Query{Query: "SELECT 1"})
+			require.NoError(t, err)
+			assert.Equal(t, [][]any{{1}}, res.Rows, "the connection is still usable")
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+)
+
+// The modes of the query log.
+const (
+	// QueryLogOff doesn't log the queries.
+	QueryLogOff = "off"
+	// QueryLogMetadata logs the SQL of each query with its type, duration,
+	// number of rows and transaction ID.
+	QueryLogMetadata = "metadata"
+	// QueryLogFull logs the metadata and the values of the parameters,
+	// except those whose name is redacted.
+	QueryLogFull = "full"
+)
+
+// QueryLogModes are the valid modes of the query log.
+var QueryLogModes = []string{QueryLogOff, QueryLogMetadata, QueryLogFull}
+
+// redactedParam replaces the values of the redacted parameters.
+const redactedParam = "REDACTED"
+
+// queryLog is the configuration of the query log.
+type queryLog struct {
+	mode string
+	// redact are the lowercased names of the parameters whose value is not
+	// logged, without their prefix.
+	redact []string
+}
+
+// SetQueryLog sets the mode of the query log, one of QueryLogModes, and the
+// names of the parameters whose value is never logged. It can be changed
+// while the DB is in use.
+func (db *DB) SetQueryLog(mode string, redact []string) {
+	names := make([]string, 0, len(redact))
+	for _, name := range redact {
+		if name = normalizeParamName(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	db.queryLog.Store(&queryLog{mode: mode, redact: names})
+}
+
+// logQuery logs the query with the logger of the request, according to the
+// mode of the query log.
+func (db *DB) logQuery(
+	ctx context.Context, query Query, res QueryResult, err error, duration time.Duration,
+) {
+	config := db.queryLog.Load()
+	if config == nil || config.mode == QueryLogOff || config.mode == "" {
+		return
+	}
+
+	txId := query.TxId
+	if res.TxId != "" {
+		txId = res.TxId
+	}
+	kv := log.KV{
+		"sql":      normalizeSQL(query.Query),
+		"type":     res.Type.Value,
+		"duration": duration.String(),
+		"rows":     len(res.Rows),
+	}
+	if res.Type == QueryTypeWrite {
+		kv["rowsAffected"] = res.RowsAffected
+	}
+	if err != nil {
+		kv["error"] = err.Error()
+	}
+	if config.mode == QueryLogFull {
+		kv["params"] = config.params(query)
+	}
+
+	logger := db.logger(ctx, txId)
+	logger.InfoNs(log.NsDatabase, "query executed", kv)
+}
+
+// params returns the parameters of the query to log, with the values of the
+// redacted ones replaced. The positional parameters are named by their
+// position, starting from 1.
+func (c *queryLog) params(query Query) map[string]any {
+	params := map[string]any{}
+	for i, param := range query.Params {
+		name := param.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+		if slices.Contains(c.redact, normalizeParamName(param.Name)) {
+			params[name] = redactedParam
+			continue
+		}
+		params[name] = param.Value
+	}
+	return params
+}
+
+// normalizeParamName returns the name of a parameter lowercased and without
+// its :, @ or $ prefix.
+func normalizeParamName(name string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimSpace(name), ":@$"))
+}
+
+// normalizeSQL returns the SQL of a query in a single line, with its runs of
+// whitespace collapsed to one space.
+func normalizeSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
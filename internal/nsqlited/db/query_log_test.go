@@ -0,0 +1,122 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryLogs returns the query logs written to buf and resets it.
+func queryLogs(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	records := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		if record["msg"] == "query executed" {
+			records = append(records, record)
+		}
+	}
+	buf.Reset()
+	return records
+}
+
+func TestQueryLog(t *testing.T) {
+	buf := bytes.Buffer{}
+	config := newTestConfig(t, t.TempDir())
+	config.Logger = log.NewLogger(&buf)
+	config.QueryLogRedact = []string{"password"}
+	db, err := NewDB(config)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.Query(ctx, Query{Query: "CREATE TABLE users (name TEXT, password TEXT)"})
+	require.NoError(t, err)
+
+	insert := Query{
+		Query: "INSERT INTO users (name, password)\n\t  VALUES (:name, :password)",
+		Params: []sqlitec.QueryParam{
+			{Name: ":name", Value: "alice"},
+			{Name: ":password", Value: "hunter2"},
+		},
+	}
+
+	t.Run("Off", func(t *testing.T) {
+		_, err := db.Query(ctx, insert)
+		require.NoError(t, err)
+		assert.Empty(t, queryLogs(t, &buf))
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		db.SetQueryLog(QueryLogMetadata, []string{"password"})
+		defer db.SetQueryLog(QueryLogOff, nil)
+
+		_, err := db.Query(ctx, insert)
+		require.NoError(t, err)
+		_, err = db.Query(ctx, Query{Query: "SELECT name FROM users"})
+		require.NoError(t, err)
+
+		logs := queryLogs(t, &buf)
+		require.Len(t, logs, 2)
+		assert.Equal(t, "INSERT INTO users (name, password) VALUES (:name, :password)", logs[0]["sql"])
+		assert.Equal(t, "write", logs[0]["type"])
+		assert.EqualValues(t, 1, logs[0]["rowsAffected"])
+		assert.Equal(t, log.NsDatabase, logs[0]["ns"])
+		assert.NotEmpty(t, logs[0]["duration"])
+		assert.NotContains(t, logs[0], "params")
+		assert.Equal(t, "read", logs[1]["type"])
+		assert.EqualValues(t, 2, logs[1]["rows"])
+	})
+
+	t.Run("Full", func(t *testing.T) {
+		db.SetQueryLog(QueryLogFull, []string{"Password"})
+		defer db.SetQueryLog(QueryLogOff, nil)
+
+		_, err := db.Query(ctx, insert)
+		require.NoError(t, err)
+		_, err = db.Query(ctx, Query{
+			Query:  "SELECT name FROM users WHERE name = ?",
+			Params: []sqlitec.QueryParam{{Value: "alice"}},
+		})
+		require.NoError(t, err)
+
+		assert.NotContains(t, buf.String(), "hunter2")
+		logs := queryLogs(t, &buf)
+		require.Len(t, logs, 2)
+		assert.Equal(t, map[string]any{":name": "alice", ":password": "REDACTED"}, logs[0]["params"])
+		assert.Equal(t, map[string]any{"1": "alice"}, logs[1]["params"])
+	})
+
+	t.Run("Transaction and errors", func(t *testing.T) {
+		db.SetQueryLog(QueryLogMetadata, nil)
+		defer db.SetQueryLog(QueryLogOff, nil)
+
+		res, err := db.Query(ctx, Query{Query: "BEGIN"})
+		require.NoError(t, err)
+		_, err = db.Query(ctx, Query{TxId: res.TxId, Query: "INSERT INTO missing VALUES (1)"})
+		require.Error(t, err)
+		_, err = db.Query(ctx, Query{TxId: res.TxId, Query: "ROLLBACK"})
+		require.NoError(t, err)
+
+		logs := queryLogs(t, &buf)
+		require.Len(t, logs, 3)
+		for _, record := range logs {
+			assert.Equal(t, res.TxId, record["txId"])
+		}
+		assert.Contains(t, logs[1]["error"], "no such table: missing")
+	})
+}
+
+func TestNormalizeSQL(t *testing.T) {
+	assert.Equal(t, "SELECT * FROM t WHERE a = 1", normalizeSQL("  SELECT *\n\tFROM t\r\n  WHERE a = 1 "))
+	assert.Equal(t, "", normalizeSQL(" \n "))
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+)
+
+// ErrReadOnly is returned for the queries that write, or start a
+// transaction, with a read-only context.
+var ErrReadOnly = errors.New("the client is only allowed to read")
+
+// readOnlyKey is the context key for the read-only restriction.
+type readOnlyKey struct{}
+
+// WithReadOnly returns a copy of ctx that only allows the queries executed
+// with it to read, like for the clients with a read-only role.
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// ReadOnlyFromContext reports whether ctx only allows reading.
+func ReadOnlyFromContext(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// readOnlyPragmas are the pragmas with an argument that the read-only
+// clients are allowed to run, they only read the schema.
+var readOnlyPragmas = map[string]bool{
+	"table_info":       true,
+	"table_xinfo":      true,
+	"index_list":       true,
+	"index_info":       true,
+	"index_xinfo":      true,
+	"foreign_key_list": true,
+}
+
+// readOnlyAuthorizer only allows the read-only clients to read, even if the
+// type of their query is detected wrongly. Unlike the read-only connections,
+// it also denies the changes that would outlive the query on the pooled
+// connection, like ATTACH, the temp schema or the pragmas that are set.
+func readOnlyAuthorizer(action sqlitec.AuthAction, arg1, arg2, _, _ string) sqlitec.AuthResult {
+	switch action {
+	case sqlitec.AuthSelect, sqlitec.AuthRead, sqlitec.AuthFunction, sqlitec.AuthRecursive:
+		return sqlitec.AuthOK
+	case sqlitec.AuthPragma:
+		if arg2 == "" || readOnlyPragmas[strings.ToLower(arg1)] {
+			return sqlitec.AuthOK
+		}
+	}
+	return sqlitec.AuthDeny
+}
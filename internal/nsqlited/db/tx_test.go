@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCommitRacingRollback(t *testing.T) {
+	db, err := NewDB(newTestConfig(t, t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	ctx := context.Background()
+
+	begin, err := db.Query(ctx, Query{Query: "BEGIN"})
+	require.NoError(t, err)
+
+	// The connection is held so the commit and the rollback both wait for
+	// it. Checking the transaction ID before running the statement let
+	// both wait, and then both end the transaction or a new one.
+	_, returnConn, err := db.getReadWriteRawConn(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = returnConn() })
+
+	errs := make(chan error, 2)
+	for _, statement := range []string{"COMMIT", "ROLLBACK"} {
+		go func() {
+			_, err := db.Query(ctx, Query{Query: statement, TxId: begin.TxId})
+			errs <- err
+		}()
+	}
+
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, ErrTxNotFound, "the transaction was claimed by the other statement")
+	case <-time.After(5 * time.Second):
+		t.Fatal("both statements wait to end the transaction")
+	}
+
+	require.NoError(t, returnConn())
+	assert.NoError(t, <-errs)
+	assert.Empty(t, db.txId.Load())
+}
+
+func TestTxConcurrentEnd(t *testing.T) {
+	db, err := NewDB(newTestConfig(t, t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	ctx := context.Background()
+
+	for range 50 {
+		begin, err := db.Query(ctx, Query{Query: "BEGIN"})
+		require.NoError(t, err)
+
+		// A commit and rollbacks, like the one of the idle monitor, race for
+		// the transaction while another client begins a new one. Checking
+		// the transaction ID before storing the new one let a stale
+		// rollback end the transaction begun in the meantime.
+		var wg sync.WaitGroup
+		ended := atomic.Int64{}
+		for _, statement := range []string{"COMMIT", "ROLLBACK", "ROLLBACK"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := db.Query(ctx, Query{Query: statement, TxId: begin.TxId}); err == nil {
+					ended.Add(1)
+				}
+			}()
+		}
+
+		next := atomic.Pointer[QueryResult]{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res, err := db.Query(ctx, Query{Query: "BEGIN"}); err == nil {
+				next.Store(&res)
+			}
+		}()
+		wg.Wait()
+
+		require.EqualValues(t, 1, ended.Load(), "only one statement ends the transaction")
+		if res := next.Load(); res != nil {
+			require.Equal(t, res.TxId, db.txId.Load(), "the new transaction is not ended")
+			_, err := db.Query(ctx, Query{Query: "ROLLBACK", TxId: res.TxId})
+			require.NoError(t, err)
+		}
+		require.Empty(t, db.txId.Load())
+	}
+}
+
+func TestTxPendingID(t *testing.T) {
+	db, err := NewDB(newTestConfig(t, t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	ctx := context.Background()
+
+	db.txId.Store(txIdPending)
+	_, err = db.Query(ctx, Query{Query: "ROLLBACK", TxId: txIdPending})
+	assert.ErrorIs(t, err, ErrTxNotFound, "the pending ID is not a transaction")
+	_, err = db.Query(ctx, Query{Query: "SELECT 1", TxId: txIdPending})
+	assert.ErrorIs(t, err, ErrTxNotMatch)
+
+	db.txId.Store("")
+	res, err := db.Query(ctx, Query{Query: "BEGIN"})
+	require.NoError(t, err)
+	_, err = db.Query(ctx, Query{Query: "COMMIT", TxId: res.TxId})
+	require.NoError(t, err)
+}
@@ -0,0 +1,102 @@
+package nsqlited
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+	"github.com/nsqlite/nsqlite/internal/util/cryptoutil"
+)
+
+// hashTokenArgs are the arguments of the hash-token subcommand.
+type hashTokenArgs struct {
+	Algorithm         string `arg:"--algorithm" help:"Hash algorithm (plaintext, argon2, bcrypt, scrypt, pbkdf2)" default:"argon2"`
+	Argon2MemoryKiB   uint32 `arg:"--argon2-memory-kib" help:"Memory of the argon2 hash in kibibytes" default:"65536"`
+	Argon2Iterations  uint32 `arg:"--argon2-iterations" help:"Iterations of the argon2 hash" default:"3"`
+	Argon2Parallelism uint8  `arg:"--argon2-parallelism" help:"Threads of the argon2 hash" default:"4"`
+	Upgrade           string `arg:"--upgrade" help:"Argon2 hash of the token to upgrade: it is printed again with the given parameters if they are stronger than its own, or unchanged otherwise"`
+	Token             string `arg:"positional" help:"Token to hash, read from the standard input if not given so it does not show in the process listings"`
+}
+
+func (hashTokenArgs) Description() string {
+	return "Prints the hash of an auth token, to configure it with --auth-token and --auth-token-algorithm."
+}
+
+// argon2Params returns the argon2 parameters of the arguments.
+func (a hashTokenArgs) argon2Params() cryptoutil.Argon2Params {
+	params := cryptoutil.DefaultArgon2Params()
+	params.Memory = a.Argon2MemoryKiB
+	params.Iterations = a.Argon2Iterations
+	params.Parallelism = a.Argon2Parallelism
+	return params
+}
+
+// runHashToken runs the "hash-token" subcommand with the arguments that
+// follow it.
+func runHashToken(args []string, stdin io.Reader, stdout io.Writer) error {
+	hashArgs := hashTokenArgs{}
+	parser, err := arg.NewParser(arg.Config{Program: "nsqlited hash-token", Out: stdout}, &hashArgs)
+	if err != nil {
+		return err
+	}
+	if err := parser.Parse(args); err != nil {
+		if errors.Is(err, arg.ErrHelp) {
+			parser.WriteHelp(stdout)
+			return nil
+		}
+		return err
+	}
+
+	params := hashArgs.argon2Params()
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	token := hashArgs.Token
+	if token == "" {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("error reading token: %w", err)
+		}
+		token = strings.TrimSpace(string(input))
+	}
+	if token == "" {
+		return errors.New("no token to hash, pass it as an argument or in the standard input")
+	}
+
+	if hashArgs.Upgrade != "" {
+		hash, err := upgradeArgon2Hash(token, hashArgs.Upgrade, params)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, hash)
+		return err
+	}
+
+	hash, err := authtoken.Hash(hashArgs.Algorithm, token, params)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, hash)
+	return err
+}
+
+// upgradeArgon2Hash returns the hash of the token generated again with the
+// params if those of the hash are weaker, or the hash unchanged. The token
+// must match the hash.
+func upgradeArgon2Hash(token string, hash string, params cryptoutil.Argon2Params) (string, error) {
+	needsRehash, err := cryptoutil.Argon2NeedsRehash(hash, params)
+	if err != nil {
+		return "", err
+	}
+	if !cryptoutil.Argon2CheckHash(token, hash) {
+		return "", errors.New("the token does not match the hash to upgrade")
+	}
+	if !needsRehash {
+		return hash, nil
+	}
+	return cryptoutil.Argon2GenerateHash(token, params)
+}
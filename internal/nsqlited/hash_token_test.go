@@ -0,0 +1,82 @@
+package nsqlited
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/util/cryptoutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunHashToken(t *testing.T) {
+	t.Run("Token from the standard input", func(t *testing.T) {
+		stdout := bytes.Buffer{}
+		require.NoError(t, runHashToken(nil, strings.NewReader("token\n"), &stdout))
+		assert.True(t, cryptoutil.Argon2CheckHash("token", strings.TrimSpace(stdout.String())))
+	})
+
+	t.Run("Token argument", func(t *testing.T) {
+		stdout := bytes.Buffer{}
+		require.NoError(t, runHashToken([]string{"--algorithm", "bcrypt", "token"}, strings.NewReader(""), &stdout))
+		assert.True(t, cryptoutil.BcryptCheckHash("token", strings.TrimSpace(stdout.String())))
+	})
+
+	t.Run("Scrypt and PBKDF2", func(t *testing.T) {
+		stdout := bytes.Buffer{}
+		require.NoError(t, runHashToken([]string{"--algorithm", "scrypt", "token"}, nil, &stdout))
+		assert.True(t, cryptoutil.ScryptCheckHash("token", strings.TrimSpace(stdout.String())))
+
+		stdout.Reset()
+		require.NoError(t, runHashToken([]string{"--algorithm", "pbkdf2", "token"}, nil, &stdout))
+		assert.True(t, cryptoutil.PBKDF2CheckHash("token", strings.TrimSpace(stdout.String())))
+	})
+
+	t.Run("Argon2 params", func(t *testing.T) {
+		stdout := bytes.Buffer{}
+		args := []string{"--argon2-memory-kib", "64", "--argon2-iterations", "2", "--argon2-parallelism", "1", "token"}
+		require.NoError(t, runHashToken(args, nil, &stdout))
+
+		hash := strings.TrimSpace(stdout.String())
+		assert.Contains(t, hash, "$m=64,t=2,p=1$")
+		assert.True(t, cryptoutil.Argon2CheckHash("token", hash))
+
+		err := runHashToken([]string{"--argon2-iterations", "0", "token"}, nil, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid argon2 iterations")
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		weakArgs := []string{"--argon2-memory-kib", "64", "--argon2-iterations", "1", "--argon2-parallelism", "1"}
+		strongArgs := []string{"--argon2-memory-kib", "64", "--argon2-iterations", "2", "--argon2-parallelism", "1"}
+
+		stdout := bytes.Buffer{}
+		require.NoError(t, runHashToken(append(weakArgs, "token"), nil, &stdout))
+		weakHash := strings.TrimSpace(stdout.String())
+
+		stdout.Reset()
+		require.NoError(t, runHashToken(append(strongArgs, "--upgrade", weakHash, "token"), nil, &stdout))
+		strongHash := strings.TrimSpace(stdout.String())
+		assert.NotEqual(t, weakHash, strongHash)
+		assert.Contains(t, strongHash, "$m=64,t=2,p=1$")
+		assert.True(t, cryptoutil.Argon2CheckHash("token", strongHash))
+
+		stdout.Reset()
+		require.NoError(t, runHashToken(append(weakArgs, "--upgrade", strongHash, "token"), nil, &stdout))
+		assert.Equal(t, strongHash, strings.TrimSpace(stdout.String()), "a stronger hash is kept")
+
+		err := runHashToken(append(strongArgs, "--upgrade", weakHash, "other-token"), nil, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "the token does not match")
+
+		err = runHashToken([]string{"--upgrade", "not-a-hash", "token"}, nil, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid argon2 hash")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		err := runHashToken(nil, strings.NewReader("  \n"), &bytes.Buffer{})
+		assert.ErrorContains(t, err, "no token to hash")
+
+		err = runHashToken([]string{"--algorithm", "md5", "token"}, nil, &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid auth algorithm")
+	})
+}
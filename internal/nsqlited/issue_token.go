@@ -0,0 +1,59 @@
+package nsqlited
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/alexflint/go-arg"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+)
+
+// issueTokenArgs are the arguments of the issue-token subcommand.
+type issueTokenArgs struct {
+	Role   string        `arg:"--role" help:"Role of the token (rw, ro)" default:"rw"`
+	TTL    time.Duration `arg:"--ttl" help:"Time the token is valid for. Valid time units are ns, us (or µs), ms, s, m, h" default:"24h"`
+	Secret string        `arg:"positional" help:"Secret configured as the auth token with the hmac algorithm, read from the standard input if not given so it does not show in the process listings"`
+}
+
+func (issueTokenArgs) Description() string {
+	return "Prints a short-lived token signed with the secret of a server configured with --auth-token-algorithm hmac."
+}
+
+// runIssueToken runs the "issue-token" subcommand with the arguments that
+// follow it.
+func runIssueToken(args []string, stdin io.Reader, stdout io.Writer, now time.Time) error {
+	issueArgs := issueTokenArgs{}
+	parser, err := arg.NewParser(arg.Config{Program: "nsqlited issue-token", Out: stdout}, &issueArgs)
+	if err != nil {
+		return err
+	}
+	if err := parser.Parse(args); err != nil {
+		if errors.Is(err, arg.ErrHelp) {
+			parser.WriteHelp(stdout)
+			return nil
+		}
+		return err
+	}
+
+	secret := issueArgs.Secret
+	if secret == "" {
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return fmt.Errorf("error reading secret: %w", err)
+		}
+		secret = strings.TrimSpace(string(input))
+	}
+	if secret == "" {
+		return errors.New("no secret to sign the token with, pass it as an argument or in the standard input")
+	}
+
+	token, err := authtoken.Issue(secret, issueArgs.Role, now, issueArgs.TTL)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, token)
+	return err
+}
@@ -0,0 +1,44 @@
+package nsqlited
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunIssueToken(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Secret from the standard input", func(t *testing.T) {
+		stdout := bytes.Buffer{}
+		require.NoError(t, runIssueToken([]string{"--role", "ro", "--ttl", "1h"}, strings.NewReader("secret\n"), &stdout, now))
+
+		claims, err := authtoken.Verify("secret", strings.TrimSpace(stdout.String()), now, 0)
+		require.NoError(t, err)
+		assert.Equal(t, authtoken.RoleReadOnly, claims.Role)
+		assert.Equal(t, now.Add(time.Hour).Unix(), claims.ExpiresAt)
+	})
+
+	t.Run("Secret argument", func(t *testing.T) {
+		stdout := bytes.Buffer{}
+		require.NoError(t, runIssueToken([]string{"secret"}, nil, &stdout, now))
+
+		claims, err := authtoken.Verify("secret", strings.TrimSpace(stdout.String()), now, 0)
+		require.NoError(t, err)
+		assert.Equal(t, authtoken.RoleReadWrite, claims.Role)
+		assert.Equal(t, now.Add(24*time.Hour).Unix(), claims.ExpiresAt)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		err := runIssueToken(nil, strings.NewReader(""), &bytes.Buffer{}, now)
+		assert.ErrorContains(t, err, "no secret")
+
+		err = runIssueToken([]string{"--role", "admin", "secret"}, nil, &bytes.Buffer{}, now)
+		assert.ErrorContains(t, err, "invalid role")
+	})
+}
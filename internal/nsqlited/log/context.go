@@ -0,0 +1,32 @@
+package log
+
+import "context"
+
+// contextKey is the key of the logger stored in a context.
+type contextKey struct{}
+
+// WithKV returns a logger derived from l that adds the key-value pairs to
+// every log, before those of each call.
+func (l *Logger) WithKV(kv KV) Logger {
+	return Logger{
+		isInitialized: l.isInitialized,
+		slogger:       l.slogger.With(kvToArgs(kv)...),
+		levels:        l.levels,
+		sampler:       l.sampler,
+	}
+}
+
+// NewContext returns a copy of ctx that carries the logger, usually one
+// derived with WithKV for the scope of a request.
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or fallback if
+// there is none.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if logger, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return logger
+	}
+	return fallback
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastRecord returns the last JSON log written to buf.
+func lastRecord(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	record := map[string]any{}
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &record))
+	return record
+}
+
+func TestWithKV(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := NewLogger(&buf)
+
+	request := logger.WithKV(KV{"requestId": "request-1"})
+	tx := request.WithKV(KV{"txId": "tx-1"})
+
+	tx.InfoNs(NsDatabase, "nested", KV{"rows": 3})
+	record := lastRecord(t, &buf)
+	assert.Equal(t, "request-1", record["requestId"])
+	assert.Equal(t, "tx-1", record["txId"])
+	assert.Equal(t, NsDatabase, record["ns"])
+	assert.EqualValues(t, 3, record["rows"])
+
+	request.Info("request")
+	record = lastRecord(t, &buf)
+	assert.Equal(t, "request-1", record["requestId"])
+	assert.NotContains(t, record, "txId", "the parent logger is not changed")
+
+	logger.Info("root")
+	assert.NotContains(t, lastRecord(t, &buf), "requestId")
+}
+
+func TestFromContext(t *testing.T) {
+	buf := bytes.Buffer{}
+	fallback := NewLogger(&buf)
+
+	t.Run("Without logger", func(t *testing.T) {
+		logger := FromContext(context.Background(), fallback)
+		logger.Info("fallback")
+		record := lastRecord(t, &buf)
+		assert.Equal(t, "fallback", record["msg"])
+		assert.NotContains(t, record, "requestId")
+	})
+
+	t.Run("With logger", func(t *testing.T) {
+		ctx := NewContext(context.Background(), fallback.WithKV(KV{"requestId": "request-1"}))
+		logger := FromContext(ctx, fallback)
+		logger.Info("request")
+		assert.Equal(t, "request-1", lastRecord(t, &buf)["requestId"])
+	})
+}
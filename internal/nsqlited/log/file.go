@@ -0,0 +1,179 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedSuffixLayout is the layout of the timestamp appended to the name
+// of the rotated log files, it sorts in the order they were rotated.
+const rotatedSuffixLayout = "20060102T150405.000000000"
+
+// RotatingFile is a log file that is rotated once it grows past a maximum
+// size: it is renamed with the time of the rotation as suffix and a new one
+// is created, the oldest rotated files beyond the backup count are deleted.
+//
+// It is safe for concurrent use.
+type RotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	now        func() time.Time
+}
+
+// OpenRotatingFile opens the log file at path for appending, creating it if
+// it doesn't exist. The file is rotated when a write would make it larger
+// than maxSize bytes, and at most maxBackups rotated files are kept, all of
+// them if zero.
+func OpenRotatingFile(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid maximum size of log file %d, must be greater than zero", maxSize)
+	}
+	if maxBackups < 0 {
+		return nil, fmt.Errorf("invalid number of log file backups %d, must be zero or greater", maxBackups)
+	}
+
+	f := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the log file, rotating it first if p doesn't fit.
+// A single write larger than the maximum size is written whole to a new
+// file.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Reopen closes and reopens the log file, so the logs go to a new file
+// once an external tool like logrotate has moved the current one.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		f.file = nil
+	}
+	return f.open()
+}
+
+// Close closes the log file, the writes after it fail.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// open opens the log file and reads its size. The caller must hold f.mu.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate renames the log file with the time as suffix, opens a new one and
+// deletes the oldest rotated files. The caller must hold f.mu.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	f.file = nil
+
+	rotated := f.path + "." + f.now().UTC().Format(rotatedSuffixLayout)
+	if err := os.Rename(f.path, rotated); err != nil {
+		// The logs keep going to the current file.
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.removeOldBackups()
+}
+
+// removeOldBackups deletes the oldest rotated files beyond the backup count.
+func (f *RotatingFile) removeOldBackups() error {
+	if f.maxBackups == 0 {
+		return nil
+	}
+	backups, err := rotatedFiles(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to list rotated log files: %w", err)
+	}
+	for len(backups) > f.maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("failed to remove rotated log file: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// rotatedFiles returns the rotated files of the log file at path, the
+// oldest first.
+func rotatedFiles(path string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(path) + "."
+	files := []string{}
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(rotatedSuffixLayout, suffix); err != nil {
+			continue
+		}
+		files = append(files, filepath.Join(filepath.Dir(path), entry.Name()))
+	}
+	slices.Sort(files)
+	return files, nil
+}
@@ -0,0 +1,149 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRotatingFile opens a rotating file in a temporary directory whose
+// rotations are one second apart.
+func newTestRotatingFile(t *testing.T, maxSize int64, maxBackups int) *RotatingFile {
+	t.Helper()
+
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "nsqlited.log"), maxSize, maxBackups)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = f.Close() })
+
+	clock := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	f.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return f
+}
+
+// readFile returns the contents of the file at path.
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(contents)
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("Rotation", func(t *testing.T) {
+		f := newTestRotatingFile(t, 10, 0)
+
+		for _, line := range []string{"line 1\n", "line 2\n", "line 3\n"} {
+			n, err := f.Write([]byte(line))
+			require.NoError(t, err)
+			assert.Equal(t, len(line), n)
+		}
+
+		rotated, err := rotatedFiles(f.path)
+		require.NoError(t, err)
+		require.Len(t, rotated, 2)
+		assert.Equal(t, f.path+".20250102T030406.000000000", rotated[0])
+		assert.Equal(t, "line 1\n", readFile(t, rotated[0]))
+		assert.Equal(t, "line 2\n", readFile(t, rotated[1]))
+		assert.Equal(t, "line 3\n", readFile(t, f.path))
+	})
+
+	t.Run("Oldest backups are removed", func(t *testing.T) {
+		f := newTestRotatingFile(t, 10, 2)
+
+		for i := range 5 {
+			_, err := fmt.Fprintf(f, "line %d\n", i)
+			require.NoError(t, err)
+		}
+
+		rotated, err := rotatedFiles(f.path)
+		require.NoError(t, err)
+		require.Len(t, rotated, 2)
+		assert.Equal(t, "line 2\n", readFile(t, rotated[0]))
+		assert.Equal(t, "line 3\n", readFile(t, rotated[1]))
+		assert.Equal(t, "line 4\n", readFile(t, f.path))
+	})
+
+	t.Run("Existing file is appended", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nsqlited.log")
+		require.NoError(t, os.WriteFile(path, []byte("previous\n"), 0644))
+
+		f, err := OpenRotatingFile(path, 12, 0)
+		require.NoError(t, err)
+		defer f.Close()
+
+		_, err = f.Write([]byte("next\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "next\n", readFile(t, path), "the size of the existing file counts")
+	})
+
+	t.Run("Reopen", func(t *testing.T) {
+		f := newTestRotatingFile(t, 1024, 0)
+		_, err := f.Write([]byte("before\n"))
+		require.NoError(t, err)
+
+		// Like logrotate, which moves the file and then sends SIGHUP.
+		moved := f.path + ".1"
+		require.NoError(t, os.Rename(f.path, moved))
+		require.NoError(t, f.Reopen())
+
+		_, err = f.Write([]byte("after\n"))
+		require.NoError(t, err)
+		assert.Equal(t, "before\n", readFile(t, moved))
+		assert.Equal(t, "after\n", readFile(t, f.path))
+	})
+
+	t.Run("Concurrent writes", func(t *testing.T) {
+		f := newTestRotatingFile(t, 100, 0)
+
+		wg := sync.WaitGroup{}
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 20 {
+					_, err := fmt.Fprintf(f, "writer %d line %02d\n", i, j)
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		rotated, err := rotatedFiles(f.path)
+		require.NoError(t, err)
+		lines := 0
+		for _, path := range append(rotated, f.path) {
+			contents := readFile(t, path)
+			assert.LessOrEqual(t, len(contents), 100)
+			lines += strings.Count(contents, "\n")
+		}
+		assert.Equal(t, 200, lines, "no line is lost")
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+		f := newTestRotatingFile(t, 10, 0)
+		require.NoError(t, f.Close())
+		require.NoError(t, f.Close())
+
+		_, err := f.Write([]byte("line\n"))
+		assert.ErrorIs(t, err, os.ErrClosed)
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nsqlited.log")
+		_, err := OpenRotatingFile(path, 0, 0)
+		assert.ErrorContains(t, err, "invalid maximum size")
+		_, err = OpenRotatingFile(path, 10, -1)
+		assert.ErrorContains(t, err, "invalid number of log file backups")
+	})
+}
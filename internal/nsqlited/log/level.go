@@ -0,0 +1,140 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// Levels are the minimum levels of the logs of a Logger, the global one and
+// those of the namespaces that override it. They can be changed while the
+// Logger is in use.
+type Levels struct {
+	level     slog.LevelVar
+	overrides atomic.Pointer[map[string]slog.Level]
+}
+
+// Set sets the global level and the levels of the namespaces that override
+// it.
+func (l *Levels) Set(level slog.Level, overrides map[string]slog.Level) {
+	overrides = maps.Clone(overrides)
+	l.overrides.Store(&overrides)
+	l.level.Set(level)
+}
+
+// levelOf returns the minimum level of the logs of the namespace, the
+// global one if it is not overridden.
+func (l *Levels) levelOf(namespace string) slog.Level {
+	if overrides := l.overrides.Load(); overrides != nil {
+		if level, ok := (*overrides)[namespace]; ok {
+			return level
+		}
+	}
+	return l.level.Level()
+}
+
+// minLevel returns the lowest of the global level and the overrides.
+func (l *Levels) minLevel() slog.Level {
+	level := l.level.Level()
+	if overrides := l.overrides.Load(); overrides != nil {
+		for _, override := range *overrides {
+			level = min(level, override)
+		}
+	}
+	return level
+}
+
+// levelNames are the names of the levels accepted by ParseLevel.
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel parses a level name: debug, info, warn or error.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf(
+		"invalid log level %q, valid values are: %s", name, strings.Join(levelNames, ", "),
+	)
+}
+
+// ParseOverrides parses the levels of namespaces given as comma-separated
+// namespace=level pairs, like "database=debug,server=warn".
+func ParseOverrides(overrides string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+	if strings.TrimSpace(overrides) == "" {
+		return levels, nil
+	}
+
+	for _, override := range strings.Split(overrides, ",") {
+		namespace, name, ok := strings.Cut(override, "=")
+		namespace = strings.TrimSpace(namespace)
+		if !ok {
+			return nil, fmt.Errorf("invalid log level override %q, expected namespace=level", override)
+		}
+		if !slices.Contains(Namespaces, namespace) {
+			return nil, fmt.Errorf(
+				"invalid log namespace %q, valid values are: %s",
+				namespace, strings.Join(Namespaces, ", "),
+			)
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels[namespace] = level
+	}
+	return levels, nil
+}
+
+// levelHandler filters the records by the level of their namespace, given
+// in their "ns" attribute, before passing them to the next handler.
+type levelHandler struct {
+	next   slog.Handler
+	levels *Levels
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	// The namespace of the record is not known yet, the record is filtered
+	// by Handle.
+	return level >= h.levels.minLevel()
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < h.levels.levelOf(recordNamespace(record)) {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{next: h.next.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), levels: h.levels}
+}
+
+// recordNamespace returns the namespace of the record, given in its "ns"
+// attribute, empty if it has none.
+func recordNamespace(record slog.Record) string {
+	namespace := ""
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == "ns" {
+			namespace = attr.Value.String()
+			return false
+		}
+		return true
+	})
+	return namespace
+}
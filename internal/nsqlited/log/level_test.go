@@ -0,0 +1,127 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loggedMessages returns the messages of the JSON logs in buf and resets it.
+func loggedMessages(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+
+	messages := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		messages = append(messages, record["msg"].(string))
+	}
+	buf.Reset()
+	return messages
+}
+
+// logAll logs a debug and a warning message without namespace and in each
+// namespace.
+func logAll(logger Logger) {
+	logger.Debug("debug")
+	logger.Warn("warn")
+	logger.DebugNs(NsDatabase, "database debug")
+	logger.WarnNs(NsDatabase, "database warn")
+	logger.DebugNs(NsServer, "server debug")
+	logger.WarnNs(NsServer, "server warn")
+}
+
+func TestLoggerLevels(t *testing.T) {
+	t.Run("Info by default", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger := NewLogger(&buf)
+
+		logAll(logger)
+		assert.Equal(t, []string{"warn", "database warn", "server warn"}, loggedMessages(t, &buf))
+	})
+
+	t.Run("Namespace overrides", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger := NewLogger(&buf)
+		logger.SetLevels(slog.LevelInfo, map[string]slog.Level{
+			NsDatabase: slog.LevelDebug,
+			NsServer:   slog.LevelError,
+		})
+
+		logAll(logger)
+		assert.Equal(t, []string{"warn", "database debug", "database warn"}, loggedMessages(t, &buf))
+	})
+
+	t.Run("Changed at runtime", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger := NewLogger(&buf)
+		copied := logger
+
+		logger.SetLevels(slog.LevelDebug, nil)
+		logAll(copied)
+		assert.Len(t, loggedMessages(t, &buf), 6, "the copies of the logger share the levels")
+
+		logger.SetLevels(slog.LevelError, nil)
+		logAll(copied)
+		assert.Empty(t, loggedMessages(t, &buf))
+
+		overrides := map[string]slog.Level{NsServer: slog.LevelDebug}
+		logger.SetLevels(slog.LevelError, overrides)
+		overrides[NsDatabase] = slog.LevelDebug
+		logAll(copied)
+		assert.Equal(t, []string{"server debug", "server warn"}, loggedMessages(t, &buf),
+			"the overrides are copied when set")
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("DEBUG")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	level, err = ParseLevel("warn")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.ErrorContains(t, err, `invalid log level "verbose", valid values are: debug, info, warn, error`)
+}
+
+func TestParseOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+		want      map[string]slog.Level
+		wantErr   string
+	}{
+		{name: "Empty", overrides: "", want: map[string]slog.Level{}},
+		{
+			name:      "Several namespaces",
+			overrides: "database=debug, server=warn",
+			want:      map[string]slog.Level{NsDatabase: slog.LevelDebug, NsServer: slog.LevelWarn},
+		},
+		{name: "Missing level", overrides: "database", wantErr: "expected namespace=level"},
+		{name: "Unknown namespace", overrides: "http=warn", wantErr: `invalid log namespace "http"`},
+		{name: "Invalid level", overrides: "server=loud", wantErr: `invalid log level "loud"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOverrides(tt.overrides)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
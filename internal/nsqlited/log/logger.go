@@ -3,6 +3,7 @@ package log
 import (
 	"io"
 	"log/slog"
+	"time"
 )
 
 // Logger is a custom structured logger on top of slog.Logger
@@ -10,18 +11,44 @@ import (
 type Logger struct {
 	isInitialized bool
 	slogger       *slog.Logger
+	levels        *Levels
+	sampler       *sampler
 }
 
 // NewLogger creates a new Logger that writes to the given writer.
 // The writer is typically os.Stdout but can be any io.Writer.
+//
+// It logs at the info level until SetLevels is called, and without
+// sampling until SetSampling is called.
 func NewLogger(writer io.Writer) Logger {
-	slogger := slog.New(slog.NewJSONHandler(writer, nil))
+	levels := &Levels{}
+	sampler := newSampler()
+	var handler slog.Handler = slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: slog.LevelDebug})
+	handler = &samplingHandler{next: handler, sampler: sampler}
+	slogger := slog.New(&levelHandler{next: handler, levels: levels})
 	return Logger{
 		isInitialized: true,
 		slogger:       slogger,
+		levels:        levels,
+		sampler:       sampler,
 	}
 }
 
+// SetLevels sets the minimum level of the logs and the levels of the
+// namespaces that override it. It applies to every copy of the logger.
+func (l *Logger) SetLevels(level slog.Level, overrides map[string]slog.Level) {
+	l.levels.Set(level, overrides)
+}
+
+// SetSampling enables the sampling of repetitive logs: after a log, the
+// ones with the same namespace and message are dropped until the window
+// closes, then a log tells how many times it was repeated. A zero window
+// disables it. The exempt messages are never sampled. It applies to every
+// copy of the logger.
+func (l *Logger) SetSampling(window time.Duration, exempt ...string) {
+	l.sampler.set(window, exempt)
+}
+
 // IsInitialized returns true if the logger is initialized using
 // NewLogger function.
 func (l *Logger) IsInitialized() bool {
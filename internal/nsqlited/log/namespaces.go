@@ -3,4 +3,8 @@ package log
 const (
 	NsDatabase = "database"
 	NsServer   = "server"
+	NsAuth     = "auth"
 )
+
+// Namespaces are the namespaces of the logs.
+var Namespaces = []string{NsDatabase, NsServer, NsAuth}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// sampleKey identifies the logs that are deduplicated together.
+type sampleKey struct {
+	namespace string
+	msg       string
+}
+
+// sampleWindow counts the logs dropped since the first one of a window.
+type sampleWindow struct {
+	level   slog.Level
+	next    slog.Handler
+	dropped int
+}
+
+// sampler deduplicates identical logs, those with the same namespace and
+// message, within a window: the first one is logged and the others are
+// counted and summarized in one log when the window closes. It is disabled
+// while the window is zero.
+//
+// It is safe for concurrent use.
+type sampler struct {
+	mu        sync.Mutex
+	window    time.Duration
+	exempt    []string
+	windows   map[sampleKey]*sampleWindow
+	afterFunc func(d time.Duration, f func())
+}
+
+// newSampler returns a disabled sampler.
+func newSampler() *sampler {
+	return &sampler{
+		windows: map[sampleKey]*sampleWindow{},
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// set sets the window of the sampler, zero disables it, and the messages
+// that are never sampled.
+func (s *sampler) set(window time.Duration, exempt []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.window = window
+	s.exempt = slices.Clone(exempt)
+}
+
+// allow reports whether the record must be logged now. Otherwise it is
+// counted in the window of its namespace and message.
+func (s *sampler) allow(record slog.Record, namespace string, next slog.Handler) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.window <= 0 || slices.Contains(s.exempt, record.Message) {
+		return true
+	}
+
+	key := sampleKey{namespace: namespace, msg: record.Message}
+	if window, ok := s.windows[key]; ok {
+		window.dropped++
+		return false
+	}
+
+	s.windows[key] = &sampleWindow{level: record.Level, next: next}
+	length := s.window
+	s.afterFunc(length, func() { s.closeWindow(key, length) })
+	return true
+}
+
+// closeWindow ends the window of the key and logs how many of its logs were
+// dropped, if any.
+func (s *sampler) closeWindow(key sampleKey, length time.Duration) {
+	s.mu.Lock()
+	window, ok := s.windows[key]
+	delete(s.windows, key)
+	s.mu.Unlock()
+
+	if !ok || window.dropped == 0 {
+		return
+	}
+
+	summary := slog.NewRecord(
+		time.Now(), window.level,
+		fmt.Sprintf("repeated %d times in last %s", window.dropped, length), 0,
+	)
+	if key.namespace != "" {
+		summary.AddAttrs(slog.String("ns", key.namespace))
+	}
+	summary.AddAttrs(
+		slog.String("message", key.msg),
+		slog.Int("repeated", window.dropped),
+	)
+	_ = window.next.Handle(context.Background(), summary)
+}
+
+// samplingHandler drops the logs deduplicated by the sampler before passing
+// them to the next handler.
+type samplingHandler struct {
+	next    slog.Handler
+	sampler *sampler
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.sampler.allow(record, recordNamespace(record), h.next) {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), sampler: h.sampler}
+}
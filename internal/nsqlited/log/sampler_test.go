@@ -0,0 +1,175 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSampledLogger returns a logger that samples with a 10s window and the
+// function that closes the windows opened so far.
+func newSampledLogger(t *testing.T, buf *bytes.Buffer, exempt ...string) (Logger, func()) {
+	t.Helper()
+
+	logger := NewLogger(buf)
+	mu := sync.Mutex{}
+	pending := []func(){}
+	logger.sampler.afterFunc = func(d time.Duration, f func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		pending = append(pending, f)
+	}
+	logger.SetSampling(10*time.Second, exempt...)
+
+	closeWindows := func() {
+		mu.Lock()
+		closing := pending
+		pending = nil
+		mu.Unlock()
+		for _, f := range closing {
+			f()
+		}
+	}
+	return logger, closeWindows
+}
+
+// readRecords returns the JSON logs written to buf and resets it.
+func readRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	records := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		record := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	buf.Reset()
+	return records
+}
+
+func TestSampling(t *testing.T) {
+	t.Run("Flood", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger, closeWindows := newSampledLogger(t, &buf)
+
+		for range 413 {
+			logger.ErrorNs(NsDatabase, "failed to execute write query", KV{"error": "locked"})
+		}
+		records := readRecords(t, &buf)
+		require.Len(t, records, 1, "only the first occurrence is logged")
+		assert.Equal(t, "failed to execute write query", records[0]["msg"])
+		assert.Equal(t, "locked", records[0]["error"])
+
+		closeWindows()
+		records = readRecords(t, &buf)
+		require.Len(t, records, 1)
+		assert.Equal(t, "repeated 412 times in last 10s", records[0]["msg"])
+		assert.Equal(t, "ERROR", records[0]["level"])
+		assert.Equal(t, NsDatabase, records[0]["ns"])
+		assert.Equal(t, "failed to execute write query", records[0]["message"])
+		assert.EqualValues(t, 412, records[0]["repeated"])
+
+		logger.ErrorNs(NsDatabase, "failed to execute write query")
+		assert.Len(t, readRecords(t, &buf), 1, "a new window starts with the next occurrence")
+	})
+
+	t.Run("Namespaces and messages are sampled apart", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger, closeWindows := newSampledLogger(t, &buf)
+
+		for range 3 {
+			logger.WarnNs(NsDatabase, "slow")
+			logger.WarnNs(NsServer, "slow")
+			logger.Warn("other")
+		}
+		assert.Len(t, readRecords(t, &buf), 3)
+
+		closeWindows()
+		summaries := readRecords(t, &buf)
+		require.Len(t, summaries, 3)
+		for _, summary := range summaries {
+			assert.Equal(t, "repeated 2 times in last 10s", summary["msg"])
+		}
+	})
+
+	t.Run("Without repetitions there is no summary", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger, closeWindows := newSampledLogger(t, &buf)
+
+		logger.Info("once")
+		closeWindows()
+		assert.Len(t, readRecords(t, &buf), 1)
+	})
+
+	t.Run("Exempt messages", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger, closeWindows := newSampledLogger(t, &buf, "server stopped with error:")
+
+		for range 5 {
+			logger.Error("server stopped with error:")
+		}
+		closeWindows()
+		assert.Len(t, readRecords(t, &buf), 5)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		logger, closeWindows := newSampledLogger(t, &buf)
+		logger.SetSampling(0)
+
+		for range 5 {
+			logger.Info("repeated")
+		}
+		closeWindows()
+		assert.Len(t, readRecords(t, &buf), 5)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		buf := syncBuffer{}
+		logger := NewLogger(&buf)
+		logger.SetSampling(time.Hour)
+
+		wg := sync.WaitGroup{}
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					logger.ErrorNs(NsDatabase, "failed to execute write query")
+				}
+			}()
+		}
+		wg.Wait()
+
+		window := logger.sampler.windows[sampleKey{namespace: NsDatabase, msg: "failed to execute write query"}]
+		assert.Equal(t, 999, window.dropped)
+		assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	})
+}
+
+// syncBuffer is a buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,119 @@
+// Package pragmas defines the profiles of the SQLite pragmas run on each
+// connection of nsqlited.
+package pragmas
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Pragma is a pragma run on each connection.
+type Pragma struct {
+	Name  string
+	Value string
+}
+
+// Statement returns the statement that sets the pragma.
+func (p Pragma) Statement() string {
+	return fmt.Sprintf("PRAGMA %s = %s;", p.Name, p.Value)
+}
+
+// Names are the pragmas set by the profiles, in the order they are run.
+var Names = []string{
+	"journal_mode", "synchronous", "wal_autocheckpoint", "cache_size", "mmap_size", "temp_store",
+}
+
+// DefaultProfile is the profile used when none is configured.
+const DefaultProfile = "balanced"
+
+// Profiles are the values of the pragmas of each profile:
+//
+//   - balanced: WAL with NORMAL synchronous, which can only lose the last
+//     commits on a power loss, a 10000 pages cache and 512 MiB of mmap.
+//   - durability: WAL with FULL synchronous, so every commit is synced to
+//     disk, and no mmap, so I/O errors are reported instead of crashing.
+//   - throughput: WAL with NORMAL synchronous and less frequent
+//     checkpoints, a 256 MiB cache and 1 GiB of mmap.
+var Profiles = map[string]map[string]string{
+	"balanced": {
+		"journal_mode":       "WAL",
+		"synchronous":        "NORMAL",
+		"wal_autocheckpoint": "1000",
+		"cache_size":         "10000",
+		"mmap_size":          "536870912",
+		"temp_store":         "MEMORY",
+	},
+	"durability": {
+		"journal_mode":       "WAL",
+		"synchronous":        "FULL",
+		"wal_autocheckpoint": "1000",
+		"cache_size":         "10000",
+		"mmap_size":          "0",
+		"temp_store":         "DEFAULT",
+	},
+	"throughput": {
+		"journal_mode":       "WAL",
+		"synchronous":        "NORMAL",
+		"wal_autocheckpoint": "10000",
+		"cache_size":         "-262144",
+		"mmap_size":          "1073741824",
+		"temp_store":         "MEMORY",
+	},
+}
+
+// ProfileNames returns the names of the profiles, sorted.
+func ProfileNames() []string {
+	return slices.Sorted(maps.Keys(Profiles))
+}
+
+// valueRegexp matches the values that can be set, the keywords and the
+// integers of the pragmas, so they can't inject other statements.
+var valueRegexp = regexp.MustCompile(`^-?[A-Za-z0-9_]+$`)
+
+// ParseOverride parses a "name=value" override of a pragma of the profile.
+func ParseOverride(override string) (Pragma, error) {
+	name, value, ok := strings.Cut(override, "=")
+	name = strings.ToLower(strings.TrimSpace(name))
+	value = strings.TrimSpace(value)
+	if !ok || name == "" || value == "" {
+		return Pragma{}, fmt.Errorf("invalid pragma %q, expected name=value", override)
+	}
+	if !slices.Contains(Names, name) {
+		return Pragma{}, fmt.Errorf(
+			"unsupported pragma %q, valid pragmas are: %s", name, strings.Join(Names, ", "),
+		)
+	}
+	if !valueRegexp.MatchString(value) {
+		return Pragma{}, fmt.Errorf("invalid value %q of pragma %s", value, name)
+	}
+	return Pragma{Name: name, Value: value}, nil
+}
+
+// Resolve returns the pragmas of the profile with the overrides applied
+// over it, in the order of Names.
+func Resolve(profile string, overrides []string) ([]Pragma, error) {
+	values, ok := Profiles[profile]
+	if !ok {
+		return nil, fmt.Errorf(
+			"invalid profile %q, valid profiles are: %s", profile, strings.Join(ProfileNames(), ", "),
+		)
+	}
+	values = maps.Clone(values)
+
+	for _, override := range overrides {
+		pragma, err := ParseOverride(override)
+		if err != nil {
+			return nil, err
+		}
+		values[pragma.Name] = pragma.Value
+	}
+
+	resolved := make([]Pragma, len(Names))
+	for i, name := range Names {
+		resolved[i] = Pragma{Name: name, Value: values[name]}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,78 @@
+package pragmas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	t.Run("Profiles", func(t *testing.T) {
+		tests := []struct {
+			profile string
+			want    []Pragma
+		}{
+			{
+				profile: "balanced",
+				want: []Pragma{
+					{"journal_mode", "WAL"}, {"synchronous", "NORMAL"}, {"wal_autocheckpoint", "1000"},
+					{"cache_size", "10000"}, {"mmap_size", "536870912"}, {"temp_store", "MEMORY"},
+				},
+			},
+			{
+				profile: "durability",
+				want: []Pragma{
+					{"journal_mode", "WAL"}, {"synchronous", "FULL"}, {"wal_autocheckpoint", "1000"},
+					{"cache_size", "10000"}, {"mmap_size", "0"}, {"temp_store", "DEFAULT"},
+				},
+			},
+			{
+				profile: "throughput",
+				want: []Pragma{
+					{"journal_mode", "WAL"}, {"synchronous", "NORMAL"}, {"wal_autocheckpoint", "10000"},
+					{"cache_size", "-262144"}, {"mmap_size", "1073741824"}, {"temp_store", "MEMORY"},
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.profile, func(t *testing.T) {
+				resolved, err := Resolve(tt.profile, nil)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, resolved)
+			})
+		}
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		resolved, err := Resolve("durability", []string{"MMAP_SIZE=268435456", "synchronous = EXTRA"})
+		require.NoError(t, err)
+		assert.Equal(t, []Pragma{
+			{"journal_mode", "WAL"}, {"synchronous", "EXTRA"}, {"wal_autocheckpoint", "1000"},
+			{"cache_size", "10000"}, {"mmap_size", "268435456"}, {"temp_store", "DEFAULT"},
+		}, resolved)
+
+		profile, err := Resolve("durability", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "FULL", profile[1].Value, "the profile is not changed")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Resolve("fastest", nil)
+		assert.EqualError(t, err, `invalid profile "fastest", valid profiles are: balanced, durability, throughput`)
+
+		_, err = Resolve("balanced", []string{"synchronous"})
+		assert.ErrorContains(t, err, "expected name=value")
+
+		_, err = Resolve("balanced", []string{"foreign_keys=OFF"})
+		assert.ErrorContains(t, err, `unsupported pragma "foreign_keys"`)
+
+		_, err = Resolve("balanced", []string{"cache_size=1; DROP TABLE users"})
+		assert.ErrorContains(t, err, "invalid value")
+	})
+}
+
+func TestPragmaStatement(t *testing.T) {
+	assert.Equal(t, "PRAGMA cache_size = -2000;", Pragma{Name: "cache_size", Value: "-2000"}.Statement())
+}
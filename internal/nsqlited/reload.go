@@ -0,0 +1,83 @@
+package nsqlited
+
+import (
+	"sync"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/server"
+)
+
+// reloader reloads the configuration of a running server on SIGHUP or on
+// POST /admin/reload. Only the auth tokens and the logging can be reloaded,
+// the other options are kept and a warning is logged that they require a
+// restart. The log file, if any, is reopened for logrotate.
+type reloader struct {
+	mu      sync.Mutex
+	args    []string
+	conf    config.Config
+	logger  log.Logger
+	logFile *log.RotatingFile
+	serv    *server.Server
+}
+
+// reload parses the configuration again from the arguments, the environment
+// and the configuration file, applies the options that can be reloaded and
+// returns the names of those that changed.
+func (r *reloader) reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.logFile != nil {
+		if err := r.logFile.Reopen(); err != nil {
+			return nil, err
+		}
+	}
+
+	conf, err := config.Parse(r.args)
+	if err != nil {
+		return nil, err
+	}
+	if conf, _, err = bootstrapAuth(conf, false); err != nil {
+		return nil, err
+	}
+
+	changes := config.Diff(r.conf, conf)
+	changed := make([]string, 0, len(changes))
+	for _, change := range changes {
+		changed = append(changed, change.Name)
+		kv := log.KV{"option": change.Name, "old": change.Old, "new": change.New}
+		if change.Reloadable {
+			r.logger.Info("configuration option reloaded", kv)
+		} else {
+			r.logger.Warn("configuration option changed, a restart is required to apply it", kv)
+		}
+	}
+
+	r.conf = r.conf.WithReloadable(conf)
+	r.serv.SetAuthTokens(r.conf.AuthTokenAlgorithm, r.conf.AuthToken, serverAuthTokens(r.conf))
+	level, overrides, err := r.conf.LogLevels()
+	if err != nil {
+		return nil, err
+	}
+	r.logger.SetLevels(level, overrides)
+	r.logger.SetSampling(r.conf.LogSampleWindow, unsampledLogs...)
+	r.serv.DB.SetQueryLog(r.conf.LogQueries, r.conf.QueryLogRedact())
+	r.logger.Info("configuration reloaded", log.KV{"changed": len(changes)})
+	return changed, nil
+}
+
+// serverAuthTokens returns the auth tokens of the configuration accepted
+// besides --auth-token.
+func serverAuthTokens(conf config.Config) []server.AuthToken {
+	tokens := make([]server.AuthToken, len(conf.AuthTokens))
+	for i, entry := range conf.AuthTokens {
+		tokens[i] = server.AuthToken{
+			Label:     entry.Label,
+			Algorithm: entry.Algorithm,
+			Token:     entry.Token,
+			ExpiresAt: entry.ExpiresAt,
+		}
+	}
+	return tokens
+}
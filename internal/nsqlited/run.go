@@ -2,43 +2,113 @@ package nsqlited
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/nsqlite/nsqlite/internal/nsqlited/config"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/pragmas"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/server"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/service"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
 	"github.com/nsqlite/nsqlite/internal/version"
 )
 
-// Run runs the NSQLite server.
+// unsampledLogs are the messages that are always logged, even when they are
+// repeated within the log sample window.
+var unsampledLogs = []string{
+	"configuration option reloaded",
+	"configuration option changed, a restart is required to apply it",
+	"error closing database:",
+	"error stopping server:",
+	"server stopped with error:",
+	"query executed",
+}
+
+// Run runs the NSQLite server, or one of its subcommands: "config print"
+// prints the resolved configuration, "hash-token" hashes an auth token and
+// "issue-token" signs a short-lived token.
 func Run(ctx context.Context) error {
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		args := append([]string{os.Args[0]}, os.Args[3:]...)
+		return config.Print(os.Stdout, config.MustParse(args))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "hash-token" {
+		return runHashToken(os.Args[2:], os.Stdin, os.Stdout)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "issue-token" {
+		return runIssueToken(os.Args[2:], os.Stdin, os.Stdout, time.Now())
+	}
+
 	conf := config.MustParse(os.Args)
+	if conf.CheckConfig {
+		if !config.PrintChecks(os.Stdout, config.RunChecks(conf)) {
+			return errors.New("the configuration check failed")
+		}
+		return nil
+	}
 
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	fmt.Println(version.ServerVersion())
-	logger := log.NewLogger(os.Stdout)
+	logOutput := io.Writer(os.Stdout)
+	var logFile *log.RotatingFile
+	if conf.LogFile != "" {
+		var err error
+		logFile, err = log.OpenRotatingFile(
+			conf.LogFile, int64(conf.LogMaxSizeMB)*1024*1024, conf.LogMaxBackups,
+		)
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		logOutput = io.MultiWriter(os.Stdout, logFile)
+	}
+	logger := log.NewLogger(logOutput)
+	level, overrides, err := conf.LogLevels()
+	if err != nil {
+		return err
+	}
+	logger.SetLevels(level, overrides)
+	logger.SetSampling(conf.LogSampleWindow, unsampledLogs...)
 	logger.Info("starting NSQLite server", log.KV{
 		"dataDirectory": conf.DataDirectory,
 		"listenHost":    conf.ListenHost,
 		"listenPort":    conf.ListenPort,
 		"txIdleTimeout": conf.TxIdleTimeout.String(),
+		"busyTimeout":   conf.BusyTimeout.String(),
 	})
 
 	dbStats := stats.NewDBStats()
 	defer dbStats.Close()
 
+	_, exists := datadir.NewLayout(conf.DataDirectory).ExistingDatabase()
+	fresh := !exists
+
+	profilePragmas, err := pragmas.Resolve(conf.Profile, conf.Pragmas)
+	if err != nil {
+		return err
+	}
 	dbInstance, err := db.NewDB(db.Config{
-		Logger:        logger,
-		DBStats:       dbStats,
-		DataDirectory: conf.DataDirectory,
-		TxIdleTimeout: conf.TxIdleTimeout,
+		Logger:            logger,
+		DBStats:           dbStats,
+		DataDirectory:     conf.DataDirectory,
+		TxIdleTimeout:     conf.TxIdleTimeout,
+		BusyTimeout:       conf.BusyTimeout,
+		BusyBackoffMax:    conf.BusyBackoffMax,
+		ForceAdopt:        conf.ForceAdopt,
+		Pragmas:           profilePragmas,
+		WalAutoCheckpoint: conf.WalAutoCheckpointPages(),
+		QueryLog:          conf.LogQueries,
+		QueryLogRedact:    conf.QueryLogRedact(),
 	})
 	if err != nil {
 		return fmt.Errorf("error starting database: %w", err)
@@ -49,31 +119,89 @@ func Run(ctx context.Context) error {
 		}
 	}()
 
+	conf, token, err := bootstrapAuth(conf, fresh)
+	if err != nil {
+		return fmt.Errorf("error bootstrapping auth token: %w", err)
+	}
+	if token != "" {
+		printBootstrapToken(os.Stdout, conf, token)
+	}
+
 	serv, err := server.NewServer(server.Config{
 		Logger:             logger,
 		DBStats:            dbStats,
 		DB:                 dbInstance,
 		ListenHost:         conf.ListenHost,
 		ListenPort:         conf.ListenPort,
+		TrustedProxies:     conf.TrustedProxies,
 		AuthTokenAlgorithm: conf.AuthTokenAlgorithm,
 		AuthToken:          conf.AuthToken,
+		AuthTokens:         serverAuthTokens(conf),
 	})
 	if err != nil {
 		return fmt.Errorf("error creating server: %w", err)
 	}
+	reloader := &reloader{
+		args: os.Args, conf: conf, logger: logger, logFile: logFile, serv: serv,
+	}
+	serv.Reload = reloader.reload
 	defer func() {
 		if err := serv.Stop(); err != nil {
 			logger.Error("error stopping server:", log.KV{"error": err})
 		}
 	}()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if _, err := reloader.reload(); err != nil {
+					logger.Error("error reloading configuration:", log.KV{"error": err})
+				}
+			}
+		}
+	}()
+
+	if err := serv.Listen(); err != nil {
+		return fmt.Errorf("error starting server: %w", err)
+	}
 	go func() {
-		if err := serv.Start(); err != nil {
+		if err := serv.Serve(); err != nil {
 			logger.Error("server stopped with error:", log.KV{"error": err})
 			stop()
 		}
 	}()
 
+	if conf.PIDFile != "" {
+		if err := service.WritePIDFile(conf.PIDFile); err != nil {
+			return fmt.Errorf("error writing PID file: %w", err)
+		}
+		defer func() {
+			if err := service.RemovePIDFile(conf.PIDFile); err != nil {
+				logger.Error("error removing PID file:", log.KV{"error": err})
+			}
+		}()
+	}
+	notify(logger, service.StateReady)
+	if interval, ok := service.WatchdogInterval(); ok {
+		go service.RunWatchdog(ctx, interval, func(err error) {
+			logger.Warn("error notifying the watchdog:", log.KV{"error": err})
+		})
+	}
+
 	<-ctx.Done()
+	notify(logger, service.StateStopping)
 	logger.Info("goodbye! gracefully shutting down NSQLite server")
 	return nil
 }
+
+// notify notifies the state to the service manager, if there is one.
+func notify(logger log.Logger, state string) {
+	if _, err := service.Notify(state); err != nil {
+		logger.Warn("error notifying the service manager:", log.KV{"error": err})
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+const (
+	// authLockoutThreshold is the number of failed authentications in a row
+	// that lock a client out.
+	authLockoutThreshold = 10
+	// authLockoutWindow is the duration of the first lockout of a client,
+	// doubled on each lockout that follows up to authLockoutMaxWindow.
+	authLockoutWindow    = 30 * time.Second
+	authLockoutMaxWindow = 15 * time.Minute
+	// authLockoutMaxEntries is the maximum number of clients tracked, once
+	// reached the client that failed the longest ago is forgotten.
+	authLockoutMaxEntries = 10_000
+	// authLockoutCleanupInterval is how often the clients that are no
+	// longer failing are forgotten.
+	authLockoutCleanupInterval = time.Minute
+)
+
+// authFailures are the failed authentications of a client.
+type authFailures struct {
+	key         string
+	failures    int
+	lockouts    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// authLockout tracks the failed authentications of the clients, by IP
+// address and by presented token, and locks them out for a while once they
+// fail too many times in a row.
+type authLockout struct {
+	mu  sync.Mutex
+	now func() time.Time
+	// entries are the elements of recent, by key.
+	entries map[string]*list.Element
+	// recent holds the *authFailures of the clients, the one that failed
+	// last at the front, so the oldest ones are evicted and cleaned up
+	// without scanning all of them.
+	recent      *list.List
+	lastCleanup time.Time
+}
+
+// newAuthLockout creates an empty authLockout.
+func newAuthLockout() *authLockout {
+	return &authLockout{
+		now:     time.Now,
+		entries: map[string]*list.Element{},
+		recent:  list.New(),
+	}
+}
+
+// authLockoutKeys returns the keys the failures of a request are tracked
+// by: its IP address and, if any, the token it presented. The token is
+// identified by a prefix of its SHA-256 so it is not kept in memory.
+func authLockoutKeys(ip string, token string) []string {
+	keys := []string{"ip:" + ip}
+	if token != "" {
+		keys = append(keys, authLockoutTokenKey(token))
+	}
+	return keys
+}
+
+// authLockoutTokenKey returns the key the failures of a presented token are
+// tracked by.
+func authLockoutTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "token:" + hex.EncodeToString(sum[:8])
+}
+
+// lockedFor returns how long the longest locked out of the keys is still
+// locked out, zero if none is.
+func (l *authLockout) lockedFor(keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var lockedFor time.Duration
+	for _, key := range keys {
+		if elem, ok := l.entries[key]; ok {
+			lockedFor = max(lockedFor, elem.Value.(*authFailures).lockedUntil.Sub(now))
+		}
+	}
+	return lockedFor
+}
+
+// fail records a failed authentication of each key and returns the longest
+// lockout it started, zero if none.
+func (l *authLockout) fail(keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastCleanup) >= authLockoutCleanupInterval {
+		l.cleanup(now)
+	}
+
+	var lockout time.Duration
+	for _, key := range keys {
+		elem, ok := l.entries[key]
+		if ok {
+			l.recent.MoveToFront(elem)
+		} else {
+			if len(l.entries) >= authLockoutMaxEntries {
+				l.remove(l.recent.Back())
+			}
+			elem = l.recent.PushFront(&authFailures{key: key})
+			l.entries[key] = elem
+		}
+		entry := elem.Value.(*authFailures)
+
+		entry.failures++
+		entry.lastFailure = now
+		if entry.failures < authLockoutThreshold {
+			continue
+		}
+
+		window := authLockoutWindow << min(entry.lockouts, 30)
+		if window <= 0 || window > authLockoutMaxWindow {
+			window = authLockoutMaxWindow
+		}
+		entry.failures = 0
+		entry.lockouts++
+		entry.lockedUntil = now.Add(window)
+		lockout = max(lockout, window)
+	}
+	return lockout
+}
+
+// succeed forgets the failed authentications of the keys.
+func (l *authLockout) succeed(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := l.entries[key]; ok {
+			l.remove(elem)
+		}
+	}
+}
+
+// cleanup forgets the clients that did not fail for authLockoutMaxWindow,
+// so their next lockout is the first again. A lockout never outlasts
+// authLockoutMaxWindow after the last failure, so none of them is locked
+// out. The caller must hold the lock.
+func (l *authLockout) cleanup(now time.Time) {
+	l.lastCleanup = now
+	for elem := l.recent.Back(); elem != nil; elem = l.recent.Back() {
+		entry := elem.Value.(*authFailures)
+		if now.Sub(entry.lastFailure) <= authLockoutMaxWindow || !now.After(entry.lockedUntil) {
+			return
+		}
+		l.remove(elem)
+	}
+}
+
+// remove forgets the client of elem. The caller must hold the lock.
+func (l *authLockout) remove(elem *list.Element) {
+	l.recent.Remove(elem)
+	delete(l.entries, elem.Value.(*authFailures).key)
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryHandlerAuthMiddlewareLockout(t *testing.T) {
+	// sendQuery sends a query with the token from the IP address and returns
+	// the status and the Retry-After header.
+	sendQuery := func(t *testing.T, handler http.Handler, ip string, token string) (int, string) {
+		rec := doRequestFrom(t, handler, ip, http.MethodGet, "/version", "", map[string]string{
+			"Authorization": "Bearer " + token,
+		})
+		return rec.Code, rec.Header().Get("Retry-After")
+	}
+
+	// newLockoutServer returns the handler of a server whose lockouts use a
+	// clock moved forward with the returned function.
+	newLockoutServer := func(t *testing.T, config Config) (*Server, http.Handler, func(time.Duration)) {
+		config.AuthToken = "token"
+		s, _ := newTestServer(t, config)
+		offset := atomic.Int64{}
+		s.authLockout.now = func() time.Time {
+			return time.Now().Add(time.Duration(offset.Load()))
+		}
+		return s, s.createMux(), func(d time.Duration) { offset.Add(int64(d)) }
+	}
+
+	// failTimes sends failed requests from the IP address, each with another
+	// wrong token.
+	failTimes := func(t *testing.T, handler http.Handler, ip string, times int) {
+		for i := range times {
+			status, _ := sendQuery(t, handler, ip, fmt.Sprintf("wrong-token-%d", i))
+			require.Equal(t, http.StatusUnauthorized, status)
+		}
+	}
+
+	t.Run("Lockout and recovery", func(t *testing.T) {
+		s, handler, advance := newLockoutServer(t, Config{})
+		failTimes(t, handler, "10.0.0.1", authLockoutThreshold)
+
+		status, retryAfter := sendQuery(t, handler, "10.0.0.1", "token")
+		assert.Equal(t, http.StatusTooManyRequests, status, "locked out even with the right token")
+		assert.Equal(t, strconv.Itoa(int(authLockoutWindow/time.Second)), retryAfter)
+
+		advance(authLockoutWindow + time.Second)
+		status, _ = sendQuery(t, handler, "10.0.0.1", "token")
+		assert.Equal(t, http.StatusOK, status)
+
+		auth := s.DBStats.LoadStats().Auth
+		assert.EqualValues(t, authLockoutThreshold, auth.Failures)
+		assert.EqualValues(t, 1, auth.Lockouts)
+		assert.EqualValues(t, 1, auth.Rejected)
+	})
+
+	t.Run("Other IP addresses are not affected", func(t *testing.T) {
+		_, handler, _ := newLockoutServer(t, Config{})
+		failTimes(t, handler, "10.0.0.1", authLockoutThreshold)
+
+		status, _ := sendQuery(t, handler, "10.0.0.2", "token")
+		assert.Equal(t, http.StatusOK, status)
+		status, _ = sendQuery(t, handler, "10.0.0.2", "other-token")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("Success keeps the failures of the IP address", func(t *testing.T) {
+		_, handler, _ := newLockoutServer(t, Config{})
+		failTimes(t, handler, "10.0.0.1", authLockoutThreshold-1)
+
+		status, _ := sendQuery(t, handler, "10.0.0.1", "token")
+		require.Equal(t, http.StatusOK, status)
+		failTimes(t, handler, "10.0.0.1", 1)
+
+		status, _ = sendQuery(t, handler, "10.0.0.1", "token")
+		assert.Equal(t, http.StatusTooManyRequests, status, "a valid token does not reset the guesses")
+	})
+
+	t.Run("Success clears the failures of the token", func(t *testing.T) {
+		s, handler, _ := newLockoutServer(t, Config{})
+		s.authLockout.fail(authLockoutTokenKey("token"))
+
+		status, _ := sendQuery(t, handler, "10.0.0.1", "token")
+		require.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, s.authLockout.entries, authLockoutTokenKey("token"))
+	})
+
+	t.Run("Proxy headers are ignored", func(t *testing.T) {
+		_, handler, _ := newLockoutServer(t, Config{TrustedProxies: "10.9.0.0/16"})
+		for i := range authLockoutThreshold {
+			rec := doRequestFrom(t, handler, "10.0.0.1", http.MethodGet, "/version", "", map[string]string{
+				"Authorization":   fmt.Sprintf("Bearer wrong-token-%d", i),
+				"X-Real-Ip":       fmt.Sprintf("10.1.0.%d", i),
+				"X-Forwarded-For": fmt.Sprintf("10.1.0.%d", i),
+			})
+			require.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+
+		status, _ := sendQuery(t, handler, "10.0.0.1", "token")
+		assert.Equal(t, http.StatusTooManyRequests, status, "rotating the header does not avoid the lockout")
+		status, _ = sendQuery(t, handler, "10.1.0.1", "token")
+		assert.Equal(t, http.StatusOK, status, "the IP addresses in the header are not locked out")
+	})
+
+	t.Run("Clients behind a trusted proxy", func(t *testing.T) {
+		_, handler, _ := newLockoutServer(t, Config{TrustedProxies: "10.9.0.1"})
+		sendForwarded := func(forwardedFor string, token string) int {
+			return doRequestFrom(t, handler, "10.9.0.1", http.MethodGet, "/version", "", map[string]string{
+				"Authorization":   "Bearer " + token,
+				"X-Forwarded-For": forwardedFor,
+			}).Code
+		}
+
+		for i := range authLockoutThreshold {
+			spoofed := fmt.Sprintf("10.1.0.%d, 203.0.113.1", i)
+			require.Equal(t, http.StatusUnauthorized, sendForwarded(spoofed, fmt.Sprintf("wrong-token-%d", i)))
+		}
+
+		assert.Equal(t, http.StatusTooManyRequests, sendForwarded("203.0.113.1", "token"),
+			"prepending addresses does not avoid the lockout")
+		assert.Equal(t, http.StatusOK, sendForwarded("203.0.113.2", "token"),
+			"the other clients of the proxy are not locked out")
+	})
+
+	t.Run("Lockout grows", func(t *testing.T) {
+		_, handler, advance := newLockoutServer(t, Config{})
+		failTimes(t, handler, "10.0.0.1", authLockoutThreshold)
+		advance(authLockoutWindow + time.Second)
+		failTimes(t, handler, "10.0.0.1", authLockoutThreshold)
+
+		status, retryAfter := sendQuery(t, handler, "10.0.0.1", "token")
+		assert.Equal(t, http.StatusTooManyRequests, status)
+		assert.Equal(t, strconv.Itoa(int(2*authLockoutWindow/time.Second)), retryAfter)
+	})
+}
+
+func TestAuthLockout(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	newLockout := func() *authLockout {
+		l := newAuthLockout()
+		l.now = func() time.Time { return now }
+		return l
+	}
+
+	t.Run("Windows", func(t *testing.T) {
+		l := newLockout()
+		windows := []time.Duration{}
+		for range 8 {
+			for range authLockoutThreshold - 1 {
+				require.Zero(t, l.fail("ip:a"))
+			}
+			windows = append(windows, l.fail("ip:a"))
+		}
+
+		assert.Equal(t, []time.Duration{
+			30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute,
+			8 * time.Minute, 15 * time.Minute, 15 * time.Minute, 15 * time.Minute,
+		}, windows)
+		assert.Equal(t, 15*time.Minute, l.lockedFor("ip:b", "ip:a"))
+	})
+
+	t.Run("Bounded", func(t *testing.T) {
+		l := newLockout()
+		for i := range authLockoutMaxEntries + 10 {
+			l.fail(fmt.Sprintf("ip:%d", i))
+		}
+		assert.Len(t, l.entries, authLockoutMaxEntries)
+		assert.Equal(t, authLockoutMaxEntries, l.recent.Len())
+		assert.NotContains(t, l.entries, "ip:9", "the oldest clients are evicted")
+		assert.Contains(t, l.entries, "ip:10")
+	})
+
+	t.Run("Failing again keeps the client", func(t *testing.T) {
+		l := newLockout()
+		for i := range authLockoutMaxEntries {
+			l.fail(fmt.Sprintf("ip:%d", i))
+		}
+		l.fail("ip:0")
+		l.fail("ip:new")
+		assert.Contains(t, l.entries, "ip:0")
+		assert.NotContains(t, l.entries, "ip:1")
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		l := newLockout()
+		for range authLockoutThreshold {
+			l.fail("ip:locked")
+		}
+		l.fail("ip:failed")
+
+		now = now.Add(authLockoutMaxWindow + time.Minute)
+		l.fail("ip:other")
+		assert.NotContains(t, l.entries, "ip:locked")
+		assert.NotContains(t, l.entries, "ip:failed")
+		assert.Contains(t, l.entries, "ip:other")
+	})
+
+	t.Run("Keys", func(t *testing.T) {
+		assert.Equal(t, []string{"ip:10.0.0.1"}, authLockoutKeys("10.0.0.1", ""))
+
+		keys := authLockoutKeys("10.0.0.1", "token")
+		require.Len(t, keys, 2)
+		assert.Regexp(t, `^token:[0-9a-f]{16}$`, keys[1], "the token is not kept")
+	})
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+)
+
+// backupHandler is the HTTP handler for GET /backup that serves a snapshot
+// of the database as a SQLite database file.
+func (s *Server) backupHandler(w http.ResponseWriter, r *http.Request) error {
+	path, err := s.DB.Backup(r.Context())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(path) }()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read backup info: %w", err)
+	}
+
+	name := "nsqlite-" + info.ModTime().UTC().Format("20060102-150405") + ".sqlite"
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	httputil.ServeStream(w, r, name, info.Size(), info.ModTime(), file)
+	return nil
+}
+
+// restoreHandler is the HTTP handler for POST /restore that replaces the
+// database with the SQLite database file of the request body.
+func (s *Server) restoreHandler(w http.ResponseWriter, r *http.Request) error {
+	if err := s.DB.Restore(r.Context(), r.Body); err != nil {
+		return err
+	}
+
+	return httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"restored": true,
+	})
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/datadir"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupHandler(t *testing.T) {
+	s, ts := newTestServer(t, Config{AuthToken: "secret"})
+	_, err := s.DB.Query(context.Background(), db.Query{Query: "CREATE TABLE items (name TEXT)"})
+	require.NoError(t, err)
+	_, err = s.DB.Query(context.Background(), db.Query{Query: "INSERT INTO items VALUES ('a')"})
+	require.NoError(t, err)
+
+	// getBackup requests a backup with the headers and returns the response
+	// with its body.
+	getBackup := func(t *testing.T, headers map[string]string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/backup", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		res, err := http.DefaultTransport.RoundTrip(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, body
+	}
+
+	t.Run("Snapshot of the database", func(t *testing.T) {
+		res, body := getBackup(t, nil)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/vnd.sqlite3", res.Header.Get("Content-Type"))
+		assert.Contains(t, res.Header.Get("Content-Disposition"), "attachment; filename=nsqlite-")
+		assert.NotEmpty(t, res.Header.Get("ETag"))
+
+		path := filepath.Join(t.TempDir(), "backup.sqlite")
+		require.NoError(t, os.WriteFile(path, body, 0o600))
+		conn, err := sqlitec.OpenWithFlags(path, sqlitec.OpenReadOnly)
+		require.NoError(t, err)
+		defer conn.Close()
+		rows, err := conn.Query("SELECT name FROM items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"a"}}, rows.Rows)
+	})
+
+	t.Run("Gzipped when accepted", func(t *testing.T) {
+		res, _ := getBackup(t, map[string]string{"Accept-Encoding": "gzip"})
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+	})
+
+	t.Run("Removes the snapshot", func(t *testing.T) {
+		backups, err := filepath.Glob(filepath.Join(datadir.NewLayout(s.DB.DataDirectory).Backups, "*"))
+		require.NoError(t, err)
+		assert.Empty(t, backups)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		status, _ := doRequest(t, http.MethodGet, ts.URL+"/backup", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
+
+func TestRestoreHandler(t *testing.T) {
+	s, ts := newTestServer(t, Config{AuthToken: "secret"})
+	headers := map[string]string{
+		"Authorization": "Bearer secret",
+		"Content-Type":  "application/vnd.sqlite3",
+	}
+
+	t.Run("Replaces the database", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "backup.sqlite")
+		conn, err := sqlitec.Open(path)
+		require.NoError(t, err)
+		_, err = conn.Exec("CREATE TABLE items (name TEXT); INSERT INTO items VALUES ('restored')")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		status, body := doRequest(t, http.MethodPost, ts.URL+"/restore", string(content), headers)
+		require.Equal(t, http.StatusOK, status, body)
+
+		res, err := s.DB.Query(context.Background(), db.Query{Query: "SELECT name FROM items"})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"restored"}}, res.Rows)
+	})
+
+	t.Run("Invalid backup", func(t *testing.T) {
+		status, body := doRequest(t, http.MethodPost, ts.URL+"/restore", "not a database", headers)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, body, CodeInvalidBackup)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		status, _ := doRequest(t, http.MethodPost, ts.URL+"/restore", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+	"github.com/nsqlite/nsqlite/internal/version"
+)
+
+// Capabilities are the optional features supported by the server, so
+// clients can check them before using a feature instead of failing with an
+// unexpected HTTP error:
+//
+//   - namedParams: the query params can be bound by name.
+//   - cancel: running queries can be canceled with DELETE /query/{requestId}.
+//   - backup: the database can be downloaded with GET /backup and replaced
+//     with POST /restore.
+var Capabilities = []string{"namedParams", "cancel", "backup"}
+
+// CapabilitiesResponse is the response of the /capabilities endpoint.
+type CapabilitiesResponse struct {
+	Version      string   `json:"version"`
+	Capabilities []string `json:"capabilities"`
+	// Pragmas are the values of the pragmas of the profile of the database,
+	// as reported by SQLite.
+	Pragmas map[string]string `json:"pragmas"`
+}
+
+func (s *Server) capabilitiesHandler(w http.ResponseWriter, r *http.Request) error {
+	pragmas := map[string]string{}
+	for _, pragma := range s.DB.EffectivePragmas() {
+		pragmas[pragma.Name] = pragma.Value
+	}
+
+	return httputil.WriteJSON(w, http.StatusOK, CapabilitiesResponse{
+		Version:      version.Version,
+		Capabilities: Capabilities,
+		Pragmas:      pragmas,
+	})
+}
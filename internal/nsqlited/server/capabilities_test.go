@@ -0,0 +1,35 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesHandler(t *testing.T) {
+	_, ts := newTestServer(t, Config{AuthToken: "secret"})
+
+	t.Run("Lists the capabilities", func(t *testing.T) {
+		status, body := doRequest(t, http.MethodGet, ts.URL+"/capabilities", "",
+			map[string]string{"Authorization": "Bearer secret"})
+		require.Equal(t, http.StatusOK, status)
+
+		res := CapabilitiesResponse{}
+		require.NoError(t, json.Unmarshal([]byte(body), &res))
+		assert.Equal(t, version.Version, res.Version)
+		assert.ElementsMatch(t, []string{"namedParams", "cancel", "backup"}, res.Capabilities)
+		assert.Equal(t, map[string]string{
+			"journal_mode": "wal", "synchronous": "1", "wal_autocheckpoint": "1000",
+			"cache_size": "10000", "mmap_size": "536870912", "temp_store": "2",
+		}, res.Pragmas)
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		status, _ := doRequest(t, http.MethodGet, ts.URL+"/capabilities", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+)
+
+// authTokenClientLabel is the client label used for requests authenticated
+// with the server's auth token, the other tokens have their own label.
+const authTokenClientLabel = "authToken"
+
+// clientLabelMiddleware attributes the request to the client IP address so
+// the stats can be split by client. The auth middleware overrides it with
+// the label of the auth token when authentication is enabled.
+func (s *Server) clientLabelMiddleware(
+	next httputil.HandlerFuncErr,
+) httputil.HandlerFuncErr {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		ctx := db.WithClientLabel(r.Context(), s.readClientIP(r))
+		return next(w, r.WithContext(ctx))
+	}
+}
+
+// readClientIP returns the IP address of the client of the request. It is
+// the IP address of the connection, without the port, unless the connection
+// comes from a trusted proxy: then it is the rightmost address of the
+// X-Forwarded-For header that is not a trusted proxy, the addresses on its
+// left could be set to anything by the client. The X-Real-Ip header is
+// always ignored.
+func (s *Server) readClientIP(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	if len(s.trustedProxies) == 0 || !s.isTrustedProxy(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			return ip
+		}
+		ip = addr.Unmap().String()
+		if !s.isTrustedProxy(ip) {
+			return ip
+		}
+	}
+	return ip
+}
+
+// isTrustedProxy reports whether ip is in one of the trusted proxies.
+func (s *Server) isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses the comma-separated IP addresses or CIDR
+// blocks of the trusted proxies, an address is the block of only itself.
+func parseTrustedProxies(list string) ([]netip.Prefix, error) {
+	prefixes := []netip.Prefix{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
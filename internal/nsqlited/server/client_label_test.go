@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientLabelAttribution(t *testing.T) {
+	t.Run("ByIPWhenAuthDisabled", func(t *testing.T) {
+		s, _ := newTestServer(t, Config{})
+		handler := s.createMux()
+
+		query := func(ip string, sql string) {
+			rec := doRequestFrom(t, handler, ip, http.MethodPost, "/query", `[{"query": "`+sql+`"}]`, nil)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		query("10.0.0.1", "CREATE TABLE t (id INTEGER)")
+		query("10.0.0.1", "SELECT * FROM t")
+		query("10.0.0.2", "SELECT * FROM t")
+		query("10.0.0.2", "SELECT * FROM missing")
+
+		byClient := s.DBStats.LoadStats().ByClient
+		assert.Equal(t, stats.ClientStat{Reads: 1, Writes: 1}, byClient["10.0.0.1"])
+		assert.Equal(t, stats.ClientStat{Reads: 1, Errors: 1}, byClient["10.0.0.2"])
+	})
+
+	t.Run("IgnoresProxyHeaders", func(t *testing.T) {
+		s, _ := newTestServer(t, Config{})
+		handler := s.createMux()
+
+		for _, header := range []string{"X-Real-Ip", "X-Forwarded-For"} {
+			rec := doRequestFrom(t, handler, "10.0.0.1", http.MethodPost, "/query",
+				`[{"query": "SELECT 1"}]`, map[string]string{header: "10.0.0.9"},
+			)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		byClient := s.DBStats.LoadStats().ByClient
+		assert.Equal(t, map[string]stats.ClientStat{"10.0.0.1": {Reads: 2}}, byClient)
+	})
+
+	t.Run("ByForwardedForFromTrustedProxy", func(t *testing.T) {
+		s, _ := newTestServer(t, Config{TrustedProxies: "10.0.0.0/24, 10.1.0.1"})
+		handler := s.createMux()
+
+		query := func(ip string, forwardedFor string) {
+			rec := doRequestFrom(t, handler, ip, http.MethodPost, "/query",
+				`[{"query": "SELECT 1"}]`, map[string]string{"X-Forwarded-For": forwardedFor},
+			)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		query("10.0.0.1", "203.0.113.1")
+		query("10.0.0.2", "203.0.113.9, 203.0.113.2")
+		query("10.0.0.1", "203.0.113.3, 10.1.0.1")
+		query("10.0.0.1", "not an ip, 203.0.113.4")
+		query("10.0.0.1", "not an ip")
+		query("10.2.0.1", "203.0.113.5")
+
+		byClient := s.DBStats.LoadStats().ByClient
+		assert.Equal(t, map[string]stats.ClientStat{
+			"203.0.113.1": {Reads: 1},
+			"203.0.113.2": {Reads: 1},
+			"203.0.113.3": {Reads: 1},
+			"203.0.113.4": {Reads: 1},
+			"10.0.0.1":    {Reads: 1},
+			"10.2.0.1":    {Reads: 1},
+		}, byClient)
+	})
+
+	t.Run("InvalidTrustedProxies", func(t *testing.T) {
+		_, err := NewServer(Config{TrustedProxies: "10.0.0.0/33"})
+		assert.Error(t, err)
+	})
+
+	t.Run("ByTokenWhenAuthEnabled", func(t *testing.T) {
+		s, ts := newTestServer(t, Config{AuthToken: "secret"})
+
+		status, _ := doRequest(t, http.MethodPost, ts.URL+"/query",
+			`[{"query": "SELECT 1"}]`, map[string]string{"Authorization": "Bearer secret"},
+		)
+		assert.Equal(t, http.StatusOK, status)
+
+		status, _ = doRequest(t, http.MethodPost, ts.URL+"/query",
+			`[{"query": "SELECT 1"}]`, map[string]string{"Authorization": "Bearer other"},
+		)
+		assert.Equal(t, http.StatusUnauthorized, status)
+
+		byClient := s.DBStats.LoadStats().ByClient
+		assert.Equal(t, map[string]stats.ClientStat{
+			authTokenClientLabel: {Reads: 1},
+		}, byClient)
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		s, ts := newTestServer(t, Config{})
+
+		doRequestFrom(t, s.createMux(), "10.0.0.3", http.MethodPost, "/query", `[{"query": "SELECT 1"}]`, nil)
+
+		status, body := doRequest(t, http.MethodGet, ts.URL+"/metrics", "", nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `nsqlite_client_reads_total{client="10.0.0.3"} 1`)
+	})
+}
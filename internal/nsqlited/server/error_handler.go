@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -8,49 +9,56 @@ import (
 	"github.com/nsqlite/nsqlite/internal/util/httputil"
 )
 
+// ErrorResponse is the response of the requests that failed.
+type ErrorResponse struct {
+	// ID identifies the error in the logs of the server.
+	ID    string `json:"id"`
+	Error string `json:"error"`
+	// Code is the code of the error, like NSQLITE_INTERNAL.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorHandler logs the error of a request and writes its response, with
+// the status and code of the *httputil.Error in its chain or those mapped
+// by mapDBError. The other errors are internal errors.
 func (s *Server) errorHandler(
 	w http.ResponseWriter, r *http.Request, err error,
 ) {
-	ip := httputil.ReadUserIP(r)
+	ip := s.readClientIP(r)
 	errorURL := r.URL.String()
 	errorId := uuid.NewString()
 
-	switch err := err.(type) {
-	case httputil.JSONError:
-		statusText := http.StatusText(err.HTTPStatus)
-		safeMessage := err.SafeMessage
-		if safeMessage == "" {
-			safeMessage = statusText
-		}
-
-		s.Logger.ErrorNs(
-			log.NsServer, "error while handling request", log.KV{
-				"id":      errorId,
-				"status":  err.HTTPStatus,
-				"error":   err.Error(),
-				"message": safeMessage,
-				"url":     errorURL,
-				"ip":      ip,
-			},
-		)
-
-		_ = httputil.WriteJSON(w, err.HTTPStatus, map[string]any{
-			"id":      errorId,
-			"error":   statusText,
-			"message": safeMessage,
+	// The status was already sent, the error can only be logged.
+	var writtenErr *httputil.ResponseWrittenError
+	if errors.As(err, &writtenErr) {
+		s.Logger.WarnNs(log.NsServer, "error while writing response", log.KV{
+			"error":     err.Error(),
+			"url":       errorURL,
+			"ip":        ip,
+			"requestId": r.Header.Get(RequestIDHeader),
 		})
-	default:
-		s.Logger.ErrorNs(
-			log.NsServer, "unknown error while handling request", log.KV{
-				"id":    errorId,
-				"error": err.Error(),
-				"url":   errorURL,
-				"ip":    ip,
-			},
-		)
-
-		_ = httputil.WriteString(
-			w, http.StatusInternalServerError, "Internal Server Error - "+errorId,
-		)
+		return
 	}
+
+	httpErr := httputil.ClassifyError(err, mapDBError)
+	s.Logger.ErrorNs(
+		log.NsServer, "error while handling request", log.KV{
+			"id":        errorId,
+			"status":    httpErr.Status,
+			"code":      httpErr.Code,
+			"error":     err.Error(),
+			"message":   httpErr.SafeMessage(),
+			"url":       errorURL,
+			"ip":        ip,
+			"requestId": r.Header.Get(RequestIDHeader),
+		},
+	)
+
+	_ = httputil.WriteJSON(w, httpErr.Status, ErrorResponse{
+		ID:      errorId,
+		Error:   http.StatusText(httpErr.Status),
+		Code:    httpErr.Code,
+		Message: httpErr.SafeMessage(),
+	})
 }
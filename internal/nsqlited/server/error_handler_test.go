@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	s := &Server{Config: Config{Logger: log.NewLogger(io.Discard)}}
+	cause := errors.New("cause")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name: "BadRequest", err: httputil.BadRequest(cause, "Bad body"),
+			wantStatus: http.StatusBadRequest, wantCode: "NSQLITE_BAD_REQUEST", wantMessage: "Bad body",
+		},
+		{
+			name: "Unauthorized", err: httputil.Unauthorized(cause, ""),
+			wantStatus: http.StatusUnauthorized, wantCode: "NSQLITE_UNAUTHORIZED", wantMessage: "Unauthorized",
+		},
+		{
+			name: "NotFound", err: httputil.NotFound(cause, "No query"),
+			wantStatus: http.StatusNotFound, wantCode: "NSQLITE_NOT_FOUND", wantMessage: "No query",
+		},
+		{
+			name: "Conflict", err: httputil.Conflict(cause, "Busy"),
+			wantStatus: http.StatusConflict, wantCode: "NSQLITE_CONFLICT", wantMessage: "Busy",
+		},
+		{
+			name: "Internal", err: httputil.Internal(cause, "Failed"),
+			wantStatus: http.StatusInternalServerError, wantCode: "NSQLITE_INTERNAL", wantMessage: "Failed",
+		},
+		{
+			name: "Wrapped database error", err: fmt.Errorf("failed to commit: %w", db.ErrTxNotFound),
+			wantStatus: http.StatusNotFound, wantCode: CodeTxNotFound, wantMessage: db.ErrTxNotFound.Error(),
+		},
+		{
+			name: "Unknown error", err: cause,
+			wantStatus: http.StatusInternalServerError, wantCode: "NSQLITE_INTERNAL",
+			wantMessage: "Internal Server Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.errorHandler(rec, httptest.NewRequest(http.MethodGet, "/query", nil), tt.err)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			res := ErrorResponse{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.NotEmpty(t, res.ID)
+			assert.Equal(t, http.StatusText(tt.wantStatus), res.Error)
+			assert.Equal(t, tt.wantCode, res.Code)
+			assert.Equal(t, tt.wantMessage, res.Message)
+			assert.NotContains(t, rec.Body.String(), "cause", "the cause is only logged")
+		})
+	}
+
+	t.Run("Response already written", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		rec.WriteHeader(http.StatusOK)
+		err := &httputil.ResponseWrittenError{Err: errors.New("connection reset")}
+		s.errorHandler(rec, httptest.NewRequest(http.MethodGet, "/query", nil), err)
+		assert.Zero(t, rec.Body.Len())
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+)
+
+// The codes of the errors of the database.
+const (
+	CodeTxNotFound    = "NSQLITE_TX_NOT_FOUND"
+	CodeTxWithinTx    = "NSQLITE_TX_WITHIN_TX"
+	CodeTxOnlyOne     = "NSQLITE_TX_ONLY_ONE"
+	CodeTxNotMatch    = "NSQLITE_TX_NOT_MATCH"
+	CodeReadOnly      = "NSQLITE_READ_ONLY"
+	CodeInvalidBackup = "NSQLITE_INVALID_BACKUP"
+)
+
+// dbErrors are the errors of the database with the status and the code of
+// their response.
+var dbErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{err: db.ErrTxNotFound, status: http.StatusNotFound, code: CodeTxNotFound},
+	{err: db.ErrTxWithinTx, status: http.StatusConflict, code: CodeTxWithinTx},
+	{err: db.ErrTxOnlyOne, status: http.StatusConflict, code: CodeTxOnlyOne},
+	{err: db.ErrTxNotMatch, status: http.StatusConflict, code: CodeTxNotMatch},
+	{err: db.ErrReadOnly, status: http.StatusForbidden, code: CodeReadOnly},
+	{err: db.ErrInvalidBackup, status: http.StatusBadRequest, code: CodeInvalidBackup},
+}
+
+// mapDBError translates the errors of the database into the status and the
+// code of their response, it is the only place where they are classified.
+func mapDBError(err error) *httputil.Error {
+	for _, dbErr := range dbErrors {
+		if errors.Is(err, dbErr.err) {
+			return httputil.NewError(dbErr.status, dbErr.code, err, dbErr.err.Error())
+		}
+	}
+	return nil
+}
@@ -12,9 +12,7 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) error {
 		Query: "SELECT 1",
 	})
 	if err != nil {
-		return httputil.NewJSONError(
-			http.StatusInternalServerError, err, "Failed to query the database",
-		)
+		return httputil.Internal(err, "Failed to query the database")
 	}
 
 	return httputil.WriteString(w, http.StatusOK, "OK")
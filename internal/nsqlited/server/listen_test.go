@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve serves the requests of the listeners of the server until the test
+// ends.
+func serve(t *testing.T, s *Server) {
+	t.Helper()
+
+	done := make(chan error)
+	go func() { done <- s.Serve() }()
+	t.Cleanup(func() {
+		require.NoError(t, s.Stop())
+		require.NoError(t, <-done)
+	})
+}
+
+func TestServerListen(t *testing.T) {
+	// 127.0.0.2 is a loopback alias on Linux, but not on every system.
+	if l, err := net.Listen("tcp", "127.0.0.2:0"); err != nil {
+		t.Skip("127.0.0.2 is not a loopback address")
+	} else {
+		require.NoError(t, l.Close())
+	}
+
+	t.Run("Several addresses", func(t *testing.T) {
+		s, _ := newTestServer(t, Config{ListenHost: "127.0.0.1, 127.0.0.2", ListenPort: "0"})
+		require.NoError(t, s.Listen())
+		serve(t, s)
+
+		addrs := s.Addrs()
+		require.Len(t, addrs, 2)
+		assert.True(t, strings.HasPrefix(addrs[0].String(), "127.0.0.1:"))
+		assert.True(t, strings.HasPrefix(addrs[1].String(), "127.0.0.2:"))
+
+		for _, addr := range addrs {
+			status, body := doRequest(t, http.MethodPost, "http://"+addr.String()+"/query", `[{"query": "SELECT 1"}]`, nil)
+			assert.Equal(t, http.StatusOK, status)
+			assert.Contains(t, body, `"rows":[[1]]`)
+		}
+	})
+
+	t.Run("Unix socket", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("unix sockets are not supported")
+		}
+		socket := filepath.Join(t.TempDir(), "nsqlite.sock")
+		s, _ := newTestServer(t, Config{ListenHost: "127.0.0.1,unix:" + socket, ListenPort: "0"})
+		require.NoError(t, s.Listen())
+		serve(t, s)
+
+		client := http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}}
+		res, err := client.Get("http://nsqlite/health")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Port conflict", func(t *testing.T) {
+		taken, err := net.Listen("tcp", "127.0.0.2:0")
+		require.NoError(t, err)
+		defer taken.Close()
+		_, port, err := net.SplitHostPort(taken.Addr().String())
+		require.NoError(t, err)
+
+		s, _ := newTestServer(t, Config{ListenHost: "127.0.0.1,127.0.0.2", ListenPort: port})
+		err = s.Listen()
+		assert.ErrorContains(t, err, "failed to listen on 127.0.0.2:"+port)
+
+		// The listener of the first address is closed.
+		listener, err := net.Listen("tcp", "127.0.0.1:"+port)
+		require.NoError(t, err)
+		require.NoError(t, listener.Close())
+	})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+)
+
+// metricsHandler is the HTTP handler for the /metrics endpoint that exposes
+// the stats in the Prometheus text exposition format.
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) error {
+	loaded := s.DBStats.LoadStats()
+	sb := strings.Builder{}
+
+	writeMetric := func(name, metricType, help string, value any) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(&sb, "# TYPE %s %s\n", name, metricType)
+		fmt.Fprintf(&sb, "%s %v\n", name, value)
+	}
+
+	writeMetric("nsqlite_queued_writes", "gauge", "Writes waiting for the write connection.", loaded.QueuedWrites)
+	writeMetric("nsqlite_queued_http_requests", "gauge", "HTTP requests being processed.", loaded.QueuedHTTPRequests)
+	writeMetric("nsqlite_reads_24h", "gauge", "Reads in the last 24 hours.", loaded.Totals.Reads)
+	writeMetric("nsqlite_writes_24h", "gauge", "Writes in the last 24 hours.", loaded.Totals.Writes)
+	writeMetric("nsqlite_begins_24h", "gauge", "Begins in the last 24 hours.", loaded.Totals.Begins)
+	writeMetric("nsqlite_commits_24h", "gauge", "Commits in the last 24 hours.", loaded.Totals.Commits)
+	writeMetric("nsqlite_rollbacks_24h", "gauge", "Rollbacks in the last 24 hours.", loaded.Totals.Rollbacks)
+	writeMetric("nsqlite_errors_24h", "gauge", "Errors in the last 24 hours.", loaded.Totals.Errors)
+	writeMetric("nsqlite_http_requests_24h", "gauge", "HTTP requests in the last 24 hours.", loaded.Totals.HTTPRequests)
+	writeMetric("nsqlite_auth_failures_total", "counter", "Failed authentications.", loaded.Auth.Failures)
+	writeMetric("nsqlite_auth_lockouts_total", "counter", "Clients locked out after failed authentications.", loaded.Auth.Lockouts)
+	writeMetric("nsqlite_auth_rejected_total", "counter", "Requests rejected while their client was locked out.", loaded.Auth.Rejected)
+
+	rateMetrics := []struct {
+		name  string
+		help  string
+		value func(rates stats.Rates) float64
+	}{
+		{"nsqlite_reads_per_second", "Reads per second over the window of full minutes.", func(r stats.Rates) float64 { return r.ReadsPerSecond }},
+		{"nsqlite_writes_per_second", "Writes per second over the window of full minutes.", func(r stats.Rates) float64 { return r.WritesPerSecond }},
+		{"nsqlite_errors_per_second", "Errors per second over the window of full minutes.", func(r stats.Rates) float64 { return r.ErrorsPerSecond }},
+		{"nsqlite_http_requests_per_second", "HTTP requests per second over the window of full minutes.", func(r stats.Rates) float64 { return r.HTTPRequestsPerSecond }},
+	}
+
+	for _, metric := range rateMetrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&sb, "# TYPE %s gauge\n", metric.name)
+		fmt.Fprintf(&sb, "%s{window=\"1m\"} %v\n", metric.name, metric.value(loaded.Rates.LastMinute))
+		fmt.Fprintf(&sb, "%s{window=\"5m\"} %v\n", metric.name, metric.value(loaded.Rates.Last5Minutes))
+	}
+
+	clients := slices.Sorted(maps.Keys(loaded.ByClient))
+
+	clientMetrics := []struct {
+		name  string
+		help  string
+		value func(client string) int64
+	}{
+		{"nsqlite_client_reads_total", "Reads by client.", func(c string) int64 { return loaded.ByClient[c].Reads }},
+		{"nsqlite_client_writes_total", "Writes by client.", func(c string) int64 { return loaded.ByClient[c].Writes }},
+		{"nsqlite_client_errors_total", "Errors by client.", func(c string) int64 { return loaded.ByClient[c].Errors }},
+	}
+
+	for _, metric := range clientMetrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&sb, "# TYPE %s counter\n", metric.name)
+		for _, client := range clients {
+			fmt.Fprintf(
+				&sb, "%s{client=\"%s\"} %d\n",
+				metric.name, escapeMetricLabel(client), metric.value(client),
+			)
+		}
+	}
+
+	return httputil.WriteString(w, http.StatusOK, sb.String())
+}
+
+// escapeMetricLabel escapes a Prometheus label value.
+func escapeMetricLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
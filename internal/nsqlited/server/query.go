@@ -1,7 +1,9 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -10,6 +12,9 @@ import (
 	"github.com/nsqlite/nsqlite/internal/util/httputil"
 )
 
+// maxQueryBodySize is the maximum size of the body of a query request.
+const maxQueryBodySize = 64 << 20
+
 // ResponseResult represents the structure of a query result.
 type ResponseResult struct {
 	Time  float64 `json:"time"`
@@ -30,6 +35,13 @@ type Response struct {
 	Results []ResponseResult `json:"results"`
 }
 
+// encodedResponse is the same as Response but with the results already
+// encoded, so the size of each one is known.
+type encodedResponse struct {
+	Time    float64           `json:"time"`
+	Results []json.RawMessage `json:"results"`
+}
+
 // Query represents a single query within a request.
 type Query struct {
 	TxId   string               `json:"txId"`
@@ -45,15 +57,25 @@ func (s *Server) queryHandler(w http.ResponseWriter, r *http.Request) error {
 	defer s.DBStats.DecQueuedHTTPRequests()
 	ctx := r.Context()
 
+	if requestId := r.Header.Get(RequestIDHeader); requestId != "" {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		remove, err := s.queries.add(ctx, requestId, cancel)
+		if err != nil {
+			return httputil.Conflict(err, "A query with request ID "+requestId+" is already running")
+		}
+		defer remove()
+	}
+
 	var queries []Query
-	if err := json.NewDecoder(r.Body).Decode(&queries); err != nil {
-		return httputil.NewJSONError(
-			http.StatusBadRequest, err, "Failed to read request body",
-		)
+	if err := httputil.DecodeJSON(r, maxQueryBodySize, &queries); err != nil {
+		return err
 	}
 
 	allStart := time.Now()
 	results := []ResponseResult{}
+	readRows := map[int]int{}
 
 	for _, q := range queries {
 		thisStart := time.Now()
@@ -79,6 +101,10 @@ func (s *Server) queryHandler(w http.ResponseWriter, r *http.Request) error {
 			continue
 		}
 
+		if res.Type == db.QueryTypeRead {
+			readRows[len(results)] = len(res.Rows)
+		}
+
 		results = append(results, ResponseResult{
 			Time: time.Since(thisStart).Seconds(),
 			TxId: res.TxId,
@@ -92,8 +118,26 @@ func (s *Server) queryHandler(w http.ResponseWriter, r *http.Request) error {
 		})
 	}
 
-	return httputil.WriteJSON(w, http.StatusOK, Response{
+	encodedResults := make([]json.RawMessage, len(results))
+	for i, result := range results {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode query result: %w", err)
+		}
+		encodedResults[i] = encoded
+	}
+
+	err := httputil.WriteJSON(w, http.StatusOK, encodedResponse{
 		Time:    time.Since(allStart).Seconds(),
-		Results: results,
+		Results: encodedResults,
 	})
+	if err != nil {
+		return err
+	}
+
+	for i, rows := range readRows {
+		s.DBStats.ObserveRead(int64(rows), int64(len(encodedResults[i])))
+	}
+
+	return nil
 }
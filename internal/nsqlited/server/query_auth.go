@@ -3,51 +3,91 @@ package server
 import (
 	"errors"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
 	"github.com/nsqlite/nsqlite/internal/util/cryptoutil"
 	"github.com/nsqlite/nsqlite/internal/util/httputil"
 )
 
 // queryHandlerAuthMiddleware is a middleware that checks the Authorization
-// header of the incoming request and compares it to the server's AuthToken
-// configuration. If the AuthToken is empty, the middleware does nothing.
+// header of the incoming request against the server's current auth tokens,
+// in order, skipping the expired ones. The client label of the request is
+// the label of the token that matched. If there is no auth token, the
+// middleware does nothing.
+//
+// The tokens with the hmac algorithm are secrets that sign short-lived
+// tokens, the requests with a signed token get its role.
+//
+// The clients that fail to authenticate too many times in a row, by IP
+// address or by presented token, are locked out with a 429 response for a
+// window that grows with each lockout. Authenticating clears the failures of
+// the presented token only, those of the IP address age out, so a client
+// with a valid token can't keep guessing the other tokens between two
+// authenticated requests.
 func (s *Server) queryHandlerAuthMiddleware(
 	next httputil.HandlerFuncErr,
 ) httputil.HandlerFuncErr {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		if s.AuthToken == "" {
+		auth := s.auth.Load()
+		if len(auth.tokens) == 0 {
 			return next(w, r)
 		}
 
-		unauthorized := func() error {
-			return httputil.NewJSONError(
-				http.StatusUnauthorized, errors.New("Unauthorized"), "Unauthorized",
+		ip := s.readClientIP(r)
+		clientAuthToken := httputil.BearerToken(r.Header.Get("Authorization"))
+		lockoutKeys := authLockoutKeys(ip, clientAuthToken)
+
+		if lockedFor := s.authLockout.lockedFor(lockoutKeys...); lockedFor > 0 {
+			s.DBStats.IncAuthRejected()
+			w.Header().Set("Retry-After", strconv.Itoa(int((lockedFor+time.Second-1)/time.Second)))
+			return httputil.TooManyRequests(
+				errors.New("client locked out after failed authentications"),
+				"Too many failed authentications, retry later",
 			)
 		}
 
-		clientAuthToken := r.Header.Get("Authorization")
-		clientAuthToken = strings.TrimPrefix(clientAuthToken, "Bearer ")
-		clientAuthToken = strings.TrimPrefix(clientAuthToken, "bearer ")
+		unauthorized := func() error {
+			s.DBStats.IncAuthFailures()
+			if lockout := s.authLockout.fail(lockoutKeys...); lockout > 0 {
+				s.DBStats.IncAuthLockouts()
+				s.Logger.WarnNs(log.NsAuth, "client locked out after failed authentications", log.KV{
+					"ip":      ip,
+					"lockout": lockout.String(),
+				})
+			}
+			return httputil.Unauthorized(errors.New("Unauthorized"), "Unauthorized")
+		}
+
 		if clientAuthToken == "" {
 			return unauthorized()
 		}
 
-		if s.AuthTokenAlgorithm == "plaintext" {
-			if checkPlaintextAuth(clientAuthToken, s.AuthToken) {
-				return next(w, r)
+		now := time.Now()
+		for _, token := range auth.tokens {
+			if token.expired(now) {
+				continue
 			}
-		}
 
-		if s.AuthTokenAlgorithm == "argon2" {
-			if checkArgon2Auth(clientAuthToken, s.AuthToken) {
-				return next(w, r)
+			ctx := db.WithClientLabel(r.Context(), token.Label)
+			if token.Algorithm == authtoken.HMACAlgorithm {
+				claims, err := authtoken.Verify(token.Token, clientAuthToken, now, authtoken.DefaultClockSkew)
+				if err != nil {
+					continue
+				}
+				if claims.Role == authtoken.RoleReadOnly {
+					ctx = db.WithReadOnly(ctx)
+				}
+				s.authLockout.succeed(authLockoutTokenKey(clientAuthToken))
+				return next(w, r.WithContext(ctx))
 			}
-		}
 
-		if s.AuthTokenAlgorithm == "bcrypt" {
-			if checkBcryptAuth(clientAuthToken, s.AuthToken) {
-				return next(w, r)
+			if checkAuth(token.Algorithm, clientAuthToken, token.Token) {
+				s.authLockout.succeed(authLockoutTokenKey(clientAuthToken))
+				return next(w, r.WithContext(ctx))
 			}
 		}
 
@@ -55,10 +95,44 @@ func (s *Server) queryHandlerAuthMiddleware(
 	}
 }
 
+// readWriteRoleMiddleware rejects the requests of the clients with a
+// read-only role with db.ErrReadOnly, for the routes that change the server
+// or replace the database rather than run queries on it. It must run after
+// queryHandlerAuthMiddleware.
+func (s *Server) readWriteRoleMiddleware(
+	next httputil.HandlerFuncErr,
+) httputil.HandlerFuncErr {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if db.ReadOnlyFromContext(r.Context()) {
+			return db.ErrReadOnly
+		}
+		return next(w, r)
+	}
+}
+
+// checkAuth checks if the client token matches the server token hashed
+// with the algorithm.
+func checkAuth(algorithm string, clientToken string, serverToken string) bool {
+	switch algorithm {
+	case "plaintext":
+		return checkPlaintextAuth(clientToken, serverToken)
+	case "argon2":
+		return checkArgon2Auth(clientToken, serverToken)
+	case "bcrypt":
+		return checkBcryptAuth(clientToken, serverToken)
+	case "scrypt":
+		return checkScryptAuth(clientToken, serverToken)
+	case "pbkdf2":
+		return checkPBKDF2Auth(clientToken, serverToken)
+	default:
+		return false
+	}
+}
+
 // checkPlaintextAuth checks if the client token matches the server token
-// in plaintext.
+// in plaintext, in constant time.
 func checkPlaintextAuth(clientToken string, serverToken string) bool {
-	return clientToken == serverToken
+	return cryptoutil.SecureCompare(clientToken, serverToken)
 }
 
 // checkArgon2Auth checks if the client token matches the server token
@@ -72,3 +146,15 @@ func checkArgon2Auth(clientToken string, serverToken string) bool {
 func checkBcryptAuth(clientToken string, serverToken string) bool {
 	return cryptoutil.BcryptCheckHash(clientToken, serverToken)
 }
+
+// checkScryptAuth checks if the client token matches the server token
+// using the scrypt algorithm.
+func checkScryptAuth(clientToken string, serverToken string) bool {
+	return cryptoutil.ScryptCheckHash(clientToken, serverToken)
+}
+
+// checkPBKDF2Auth checks if the client token matches the server token
+// using the PBKDF2-HMAC-SHA256 algorithm.
+func checkPBKDF2Auth(clientToken string, serverToken string) bool {
+	return cryptoutil.PBKDF2CheckHash(clientToken, serverToken)
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryHandlerAuthMiddlewareHashes(t *testing.T) {
+	// sendQuery sends a query with the token and returns the status.
+	sendQuery := func(t *testing.T, url string, token string) int {
+		status, _ := doRequest(t, http.MethodPost, url+"/query", `[{"query": "SELECT 1"}]`, map[string]string{
+			"Authorization": "Bearer " + token,
+		})
+		return status
+	}
+
+	for _, algorithm := range authtoken.Algorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			hash, err := authtoken.Hash(algorithm, "token")
+			require.NoError(t, err)
+			_, ts := newTestServer(t, Config{AuthTokenAlgorithm: algorithm, AuthToken: hash})
+
+			assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, "token"))
+			assert.Equal(t, http.StatusUnauthorized, sendQuery(t, ts.URL, "other-token"))
+		})
+	}
+
+	t.Run("bootstrap", func(t *testing.T) {
+		hash, token, err := authtoken.LoadOrCreateBootstrap(filepath.Join(t.TempDir(), "bootstrap-auth.json"), true)
+		require.NoError(t, err)
+		_, ts := newTestServer(t, Config{AuthTokenAlgorithm: authtoken.BootstrapAlgorithm, AuthToken: hash})
+
+		assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, token))
+		assert.Equal(t, http.StatusUnauthorized, sendQuery(t, ts.URL, hash), "the hash is not the token")
+	})
+}
+
+func TestQueryHandlerAuthMiddlewareBearerPrefix(t *testing.T) {
+	_, ts := newTestServer(t, Config{AuthTokenAlgorithm: "plaintext", AuthToken: "token"})
+
+	for _, authorization := range []string{"token", "Bearer token", "bearer token", "BEARER  token "} {
+		t.Run(authorization, func(t *testing.T) {
+			status, _ := doRequest(t, http.MethodPost, ts.URL+"/query", `[{"query": "SELECT 1"}]`, map[string]string{
+				"Authorization": authorization,
+			})
+			assert.Equal(t, http.StatusOK, status)
+		})
+	}
+}
+
+func TestQueryHandlerAuthMiddlewareRotation(t *testing.T) {
+	sendQuery := func(t *testing.T, url string, token string) int {
+		status, _ := doRequest(t, http.MethodPost, url+"/query", `[{"query": "SELECT 1"}]`, map[string]string{
+			"Authorization": "Bearer " + token,
+		})
+		return status
+	}
+
+	newHash, err := authtoken.Hash("bcrypt", "new-token")
+	require.NoError(t, err)
+	tokens := []AuthToken{
+		{Label: "old", Token: "old-token", ExpiresAt: time.Now().Add(time.Hour)},
+		{Label: "new", Algorithm: "bcrypt", Token: newHash},
+		{Label: "expired", Token: "expired-token", ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+
+	t.Run("Old and new tokens during the overlap", func(t *testing.T) {
+		s, ts := newTestServer(t, Config{AuthTokens: tokens})
+
+		assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, "old-token"))
+		assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, "new-token"))
+		assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, "new-token"))
+		assert.Equal(t, http.StatusUnauthorized, sendQuery(t, ts.URL, "other-token"))
+
+		byClient := s.DBStats.LoadStats().ByClient
+		assert.Equal(t, map[string]stats.ClientStat{
+			"old": {Reads: 1},
+			"new": {Reads: 2},
+		}, byClient, "the requests are labeled with the token that matched")
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		_, ts := newTestServer(t, Config{AuthTokens: tokens})
+		assert.Equal(t, http.StatusUnauthorized, sendQuery(t, ts.URL, "expired-token"))
+	})
+
+	t.Run("With the auth token", func(t *testing.T) {
+		_, ts := newTestServer(t, Config{AuthToken: "token", AuthTokens: tokens})
+		assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, "token"))
+		assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, "new-token"))
+	})
+
+	t.Run("Old token removed on reload", func(t *testing.T) {
+		s, ts := newTestServer(t, Config{AuthTokens: tokens})
+		s.SetAuthTokens("", "", tokens[1:])
+
+		assert.Equal(t, http.StatusUnauthorized, sendQuery(t, ts.URL, "old-token"))
+		assert.Equal(t, http.StatusOK, sendQuery(t, ts.URL, "new-token"))
+	})
+}
+
+func TestQueryHandlerAuthMiddlewareHMAC(t *testing.T) {
+	sendQuery := func(t *testing.T, url string, token string, query string) (int, string) {
+		return doRequest(t, http.MethodPost, url+"/query", `[{"query": "`+query+`"}]`, map[string]string{
+			"Authorization": "Bearer " + token,
+		})
+	}
+	issue := func(t *testing.T, secret string, role string, issuedAt time.Time, ttl time.Duration) string {
+		token, err := authtoken.Issue(secret, role, issuedAt, ttl)
+		require.NoError(t, err)
+		return token
+	}
+
+	_, ts := newTestServer(t, Config{AuthTokenAlgorithm: authtoken.HMACAlgorithm, AuthToken: "secret"})
+	now := time.Now()
+
+	t.Run("Valid token", func(t *testing.T) {
+		status, _ := sendQuery(t, ts.URL, issue(t, "secret", authtoken.RoleReadWrite, now, time.Hour), "SELECT 1")
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("Invalid tokens", func(t *testing.T) {
+		readWritePayload, _, _ := strings.Cut(issue(t, "secret", authtoken.RoleReadWrite, now, time.Hour), ".")
+		_, readOnlySignature, _ := strings.Cut(issue(t, "secret", authtoken.RoleReadOnly, now, time.Hour), ".")
+		tokens := map[string]string{
+			"Secret":    "secret",
+			"Expired":   issue(t, "secret", authtoken.RoleReadWrite, now.Add(-2*time.Hour), time.Hour),
+			"Tampered":  readWritePayload + "." + readOnlySignature,
+			"Wrong key": issue(t, "other-secret", authtoken.RoleReadWrite, now, time.Hour),
+		}
+		for name, token := range tokens {
+			t.Run(name, func(t *testing.T) {
+				status, _ := sendQuery(t, ts.URL, token, "SELECT 1")
+				assert.Equal(t, http.StatusUnauthorized, status)
+			})
+		}
+	})
+
+	t.Run("Read only token", func(t *testing.T) {
+		readWrite := issue(t, "secret", authtoken.RoleReadWrite, now, time.Hour)
+		readOnly := issue(t, "secret", authtoken.RoleReadOnly, now, time.Hour)
+
+		status, _ := sendQuery(t, ts.URL, readWrite, "CREATE TABLE t (id INTEGER)")
+		require.Equal(t, http.StatusOK, status)
+
+		status, _ = sendQuery(t, ts.URL, readOnly, "SELECT * FROM t")
+		assert.Equal(t, http.StatusOK, status)
+		status, body := sendQuery(t, ts.URL, readOnly, "INSERT INTO t VALUES (1)")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, db.ErrReadOnly.Error())
+	})
+
+	t.Run("Read only token on the admin and restore routes", func(t *testing.T) {
+		readWrite := issue(t, "secret", authtoken.RoleReadWrite, now, time.Hour)
+		readOnly := issue(t, "secret", authtoken.RoleReadOnly, now, time.Hour)
+
+		routes := []struct {
+			method string
+			path   string
+		}{
+			{method: http.MethodPost, path: "/admin/reload"},
+			{method: http.MethodPost, path: "/restore"},
+		}
+		for _, route := range routes {
+			t.Run(route.method+" "+route.path, func(t *testing.T) {
+				status, body := doRequest(t, route.method, ts.URL+route.path, "", map[string]string{
+					"Authorization": "Bearer " + readOnly,
+				})
+				assert.Equal(t, http.StatusForbidden, status)
+				assert.Contains(t, body, CodeReadOnly)
+
+				status, _ = doRequest(t, route.method, ts.URL+route.path, "", map[string]string{
+					"Authorization": "Bearer " + readWrite,
+				})
+				assert.NotEqual(t, http.StatusForbidden, status)
+			})
+		}
+	})
+}
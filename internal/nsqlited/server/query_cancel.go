@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+)
+
+// RequestIDHeader is the header with the ID generated by the client for a
+// query request, used to cancel it while it runs.
+const RequestIDHeader = "NSQLite-Request-Id"
+
+// errDuplicateRequestID is returned by runningQueries.add when the client
+// already has a running request with the same ID.
+var errDuplicateRequestID = errors.New("duplicate request ID")
+
+// runningQuery identifies a running query request by its client label, its
+// role and the request ID sent by the client, so the clients can only cancel
+// their own requests. The role is part of the key because the tokens signed
+// with the same secret share its label.
+type runningQuery struct {
+	client    string
+	readOnly  bool
+	requestId string
+}
+
+// newRunningQuery returns the key of the request with the given ID of the
+// client of ctx.
+func newRunningQuery(ctx context.Context, requestId string) runningQuery {
+	return runningQuery{
+		client:    db.ClientLabelFromContext(ctx),
+		readOnly:  db.ReadOnlyFromContext(ctx),
+		requestId: requestId,
+	}
+}
+
+// runningQueries tracks the query requests that are running, so they can be
+// canceled.
+type runningQueries struct {
+	mu      sync.Mutex
+	cancels map[runningQuery]context.CancelFunc
+}
+
+// newRunningQueries creates an empty runningQueries.
+func newRunningQueries() *runningQueries {
+	return &runningQueries{cancels: map[runningQuery]context.CancelFunc{}}
+}
+
+// add tracks the request of the client of ctx with the given ID until the
+// returned function is called. It returns errDuplicateRequestID if the
+// client already has a running request with that ID.
+func (q *runningQueries) add(ctx context.Context, requestId string, cancel context.CancelFunc) (remove func(), err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := newRunningQuery(ctx, requestId)
+	if _, ok := q.cancels[key]; ok {
+		return nil, errDuplicateRequestID
+	}
+	q.cancels[key] = cancel
+	return func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.cancels, key)
+	}, nil
+}
+
+// cancel cancels the request of the client of ctx with the given ID and
+// returns true if it was running.
+func (q *runningQueries) cancel(ctx context.Context, requestId string) bool {
+	q.mu.Lock()
+	cancel, ok := q.cancels[newRunningQuery(ctx, requestId)]
+	q.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// cancelQueryHandler is the HTTP handler for DELETE /query/{requestId} that
+// cancels a running query request of the same client, identified by the
+// label and the role of its auth token or by its IP address. The statement
+// being executed is interrupted and the remaining ones are not executed.
+func (s *Server) cancelQueryHandler(w http.ResponseWriter, r *http.Request) error {
+	requestId := r.PathValue("requestId")
+	if !s.queries.cancel(r.Context(), requestId) {
+		return httputil.NotFound(
+			errors.New("query not found"),
+			"No running query with request ID "+requestId,
+		)
+	}
+
+	return httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"requestId": requestId,
+		"canceled":  true,
+	})
+}
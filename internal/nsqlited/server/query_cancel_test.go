@@ -0,0 +1,167 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/authtoken"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endlessQuery is a query that never finishes unless it is interrupted.
+const endlessQuery = `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT COUNT(*) FROM c`
+
+func TestCancelQueryHandler(t *testing.T) {
+	s, ts := newTestServer(t, Config{})
+
+	// runEndless sends a request with the endless query, cancels it once it
+	// runs and returns the response body.
+	runEndless := func(t *testing.T, requestId string, body string) string {
+		t.Helper()
+
+		done := make(chan string)
+		go func() {
+			_, resBody := doRequest(t, http.MethodPost, ts.URL+"/query", body, map[string]string{
+				RequestIDHeader: requestId,
+			})
+			done <- resBody
+		}()
+
+		require.Eventually(t, func() bool {
+			status, _ := doRequest(t, http.MethodDelete, ts.URL+"/query/"+requestId, "", nil)
+			return status == http.StatusOK
+		}, 5*time.Second, 10*time.Millisecond)
+
+		select {
+		case resBody := <-done:
+			return resBody
+		case <-time.After(5 * time.Second):
+			t.Fatal("the query was not interrupted")
+			return ""
+		}
+	}
+
+	t.Run("Interrupts a running read", func(t *testing.T) {
+		body := runEndless(t, "read-1", `[{"query": "`+endlessQuery+`"}, {"query": "SELECT 1"}]`)
+
+		assert.Contains(t, body, "interrupted")
+		assert.Empty(t, s.queries.cancels, "the request is no longer tracked")
+
+		status, body := doRequest(t, http.MethodPost, ts.URL+"/query", `[{"query": "SELECT 1"}]`, nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, `"rows":[[1]]`, "the connection can be used again")
+	})
+
+	t.Run("Interrupts a write in a transaction", func(t *testing.T) {
+		status, body := doRequest(t, http.MethodPost, ts.URL+"/query",
+			`[{"query": "CREATE TABLE numbers (x INTEGER)"}, {"query": "BEGIN"}]`, nil,
+		)
+		require.Equal(t, http.StatusOK, status, body)
+		res := Response{}
+		require.NoError(t, json.Unmarshal([]byte(body), &res))
+		txId := res.Results[1].TxId
+		require.NotEmpty(t, txId)
+
+		body = runEndless(t, "write-1", `[{"txId": "`+txId+`", "query": "INSERT INTO numbers `+
+			`WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT x FROM c"}]`)
+		assert.Contains(t, body, "interrupted")
+
+		_, body = doRequest(t, http.MethodPost, ts.URL+"/query", `[{"txId": "`+txId+`", "query": "COMMIT"}]`, nil)
+		assert.Contains(t, body, db.ErrTxNotFound.Error(), "SQLite rolled back the transaction")
+
+		_, body = doRequest(t, http.MethodPost, ts.URL+"/query", `[{"query": "SELECT COUNT(*) FROM numbers"}]`, nil)
+		assert.Contains(t, body, `"rows":[[0]]`)
+	})
+
+	t.Run("Scoped by client", func(t *testing.T) {
+		handler := s.createMux()
+		body := `[{"query": "` + endlessQuery + `"}]`
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() {
+			done <- doRequestFrom(t, handler, "10.0.0.1", http.MethodPost, "/query", body, map[string]string{
+				RequestIDHeader: "shared",
+			})
+		}()
+		require.Eventually(t, func() bool {
+			s.queries.mu.Lock()
+			defer s.queries.mu.Unlock()
+			return len(s.queries.cancels) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		duplicate := doRequestFrom(t, handler, "10.0.0.1", http.MethodPost, "/query", `[{"query": "SELECT 1"}]`,
+			map[string]string{RequestIDHeader: "shared"},
+		)
+		assert.Equal(t, http.StatusConflict, duplicate.Code, "the running request is not replaced")
+		other := doRequestFrom(t, handler, "10.0.0.2", http.MethodPost, "/query", `[{"query": "SELECT 1"}]`,
+			map[string]string{RequestIDHeader: "shared"},
+		)
+		assert.Equal(t, http.StatusOK, other.Code, "the IDs of the other clients are independent")
+
+		rec := doRequestFrom(t, handler, "10.0.0.2", http.MethodDelete, "/query/shared", "", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code, "another client cannot cancel it")
+		rec = doRequestFrom(t, handler, "10.0.0.1", http.MethodDelete, "/query/shared", "", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		select {
+		case res := <-done:
+			assert.Contains(t, res.Body.String(), "interrupted")
+		case <-time.After(5 * time.Second):
+			t.Fatal("the query was not interrupted")
+		}
+	})
+
+	t.Run("Unknown request", func(t *testing.T) {
+		status, body := doRequest(t, http.MethodDelete, ts.URL+"/query/unknown", "", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Contains(t, body, "No running query with request ID unknown")
+	})
+}
+
+func TestCancelQueryHandlerRoles(t *testing.T) {
+	s, ts := newTestServer(t, Config{AuthTokenAlgorithm: authtoken.HMACAlgorithm, AuthToken: "secret"})
+	issue := func(t *testing.T, role string) string {
+		token, err := authtoken.Issue("secret", role, time.Now(), time.Hour)
+		require.NoError(t, err)
+		return token
+	}
+	readOnly := issue(t, authtoken.RoleReadOnly)
+	readWrite := issue(t, authtoken.RoleReadWrite)
+
+	cancelQuery := func(token string) int {
+		status, _ := doRequest(t, http.MethodDelete, ts.URL+"/query/endless", "", map[string]string{
+			"Authorization": "Bearer " + token,
+		})
+		return status
+	}
+
+	done := make(chan string)
+	go func() {
+		_, body := doRequest(t, http.MethodPost, ts.URL+"/query", `[{"query": "`+endlessQuery+`"}]`, map[string]string{
+			"Authorization": "Bearer " + readOnly,
+			RequestIDHeader: "endless",
+		})
+		done <- body
+	}()
+
+	require.Eventually(t, func() bool {
+		s.queries.mu.Lock()
+		defer s.queries.mu.Unlock()
+		return len(s.queries.cancels) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, http.StatusNotFound, cancelQuery(readWrite), "the same label with another role cannot cancel it")
+	assert.Equal(t, http.StatusOK, cancelQuery(issue(t, authtoken.RoleReadOnly)), "a read-only client cancels its own request")
+
+	select {
+	case body := <-done:
+		assert.Contains(t, body, "interrupted")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query was not interrupted")
+	}
+}
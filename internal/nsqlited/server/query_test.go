@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryHandlerObservesReads(t *testing.T) {
+	s, ts := newTestServer(t, Config{})
+
+	seriesQuery := func(rows int) string {
+		return fmt.Sprintf(
+			`WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < %d) `+
+				`SELECT x FROM c WHERE x <= %d`, max(rows, 1), rows,
+		)
+	}
+
+	for _, rows := range []int{0, 10, 10_000} {
+		status, _ := doRequest(t, http.MethodPost, ts.URL+"/query",
+			fmt.Sprintf(`[{"query": "%s"}]`, seriesQuery(rows)), nil,
+		)
+		assert.Equal(t, http.StatusOK, status)
+	}
+
+	rowsReturned := s.DBStats.LoadStats().Totals.RowsReturned
+	assert.Equal(t, int64(3), rowsReturned.Count)
+	assert.Equal(t, int64(10_010), rowsReturned.Sum)
+
+	counts := map[string]int64{}
+	for _, bucket := range rowsReturned.Buckets {
+		counts[bucket.LE] = bucket.Count
+	}
+	assert.Equal(t, map[string]int64{
+		"0": 1, "10": 1, "100": 0, "1000": 0, "10000": 1, "+Inf": 0,
+	}, counts)
+
+	responseBytes := s.DBStats.LoadStats().Totals.ResponseBytes
+	assert.Equal(t, int64(3), responseBytes.Count)
+	assert.Greater(t, responseBytes.Sum, int64(10_000))
+}
+
+func TestQueryHandlerRejectsInvalidBodies(t *testing.T) {
+	_, ts := newTestServer(t, Config{})
+
+	tests := []struct {
+		name        string
+		body        string
+		headers     map[string]string
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:        "Malformed JSON",
+			body:        `[{"query": "SELECT 1"`,
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Malformed JSON: unexpected end of request body",
+		},
+		{
+			name:        "Empty body",
+			body:        "",
+			wantStatus:  http.StatusBadRequest,
+			wantMessage: "Request body is empty",
+		},
+		{
+			name:        "Wrong content type",
+			body:        `[{"query": "SELECT 1"}]`,
+			headers:     map[string]string{"Content-Type": "text/plain"},
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantMessage: `Unsupported Content-Type \"text/plain\", expected application/json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := doRequest(t, http.MethodPost, ts.URL+"/query", tt.body, tt.headers)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Contains(t, body, tt.wantMessage)
+		})
+	}
+}
+
+func TestQueryHandlerRejectsMultipleStatements(t *testing.T) {
+	s, ts := newTestServer(t, Config{})
+
+	status, _ := doRequest(t, http.MethodPost, ts.URL+"/query", `[{"query": "CREATE TABLE t (x INTEGER)"}]`, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	status, body := doRequest(t, http.MethodPost, ts.URL+"/query", `[
+		{"query": "INSERT INTO t VALUES (1); DELETE FROM t"},
+		{"query": "BEGIN; INSERT INTO t VALUES (2)"},
+		{"query": "INSERT INTO t VALUES (3); -- the only statement"},
+		{"query": "SELECT x FROM t;"}
+	]`, nil)
+	require.Equal(t, http.StatusOK, status)
+
+	res := Response{}
+	require.NoError(t, json.Unmarshal([]byte(body), &res))
+	require.Len(t, res.Results, 4)
+	assert.Contains(t, res.Results[0].Error, sqlitec.ErrMultipleStatements.Error())
+	assert.Contains(t, res.Results[1].Error, sqlitec.ErrMultipleStatements.Error())
+	assert.Empty(t, res.Results[2].Error)
+	assert.Equal(t, [][]any{{float64(3)}}, res.Results[3].Rows)
+	assert.Zero(t, s.DBStats.LoadStats().Totals.Begins, "no transaction is begun")
+}
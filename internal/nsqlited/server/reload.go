@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+)
+
+// AuthToken is an auth token accepted by the server, besides the one of
+// Config.AuthToken.
+type AuthToken struct {
+	// Label identifies the token, it is the client label of the requests
+	// authorized with it.
+	Label string
+	// Algorithm is the hash algorithm of the token, plaintext if empty.
+	Algorithm string
+	// Token is the hashed token.
+	Token string
+	// ExpiresAt is when the token stops being accepted, never if zero.
+	ExpiresAt time.Time
+}
+
+// expired reports whether the token is no longer accepted at now.
+func (t AuthToken) expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// authConfig are the auth tokens of the server with the algorithms they are
+// hashed with, they are replaced together so a request never sees a new
+// token with an old algorithm.
+type authConfig struct {
+	// tokens are checked in order, authentication is disabled if empty.
+	tokens []AuthToken
+}
+
+// SetAuthTokens replaces the auth tokens of the server: the token hashed
+// with the algorithm, if not empty, and the other tokens. The requests
+// already authorized and the open transactions are not affected, the
+// following requests must use one of the new tokens.
+func (s *Server) SetAuthTokens(algorithm string, token string, tokens []AuthToken) {
+	auth := &authConfig{tokens: make([]AuthToken, 0, len(tokens)+1)}
+	if token != "" {
+		auth.tokens = append(auth.tokens, AuthToken{
+			Label: authTokenClientLabel, Algorithm: algorithm, Token: token,
+		})
+	}
+	for _, t := range tokens {
+		if t.Token != "" {
+			auth.tokens = append(auth.tokens, t)
+		}
+	}
+	for i := range auth.tokens {
+		if auth.tokens[i].Algorithm == "" {
+			auth.tokens[i].Algorithm = "plaintext"
+		}
+	}
+	s.auth.Store(auth)
+}
+
+// ReloadResponse is the response of the POST /admin/reload endpoint.
+type ReloadResponse struct {
+	// Changed are the names of the options that changed, including those
+	// that require a restart.
+	Changed []string `json:"changed"`
+}
+
+// reloadHandler is the HTTP handler for POST /admin/reload that reloads the
+// configuration with Config.Reload, like the SIGHUP signal.
+func (s *Server) reloadHandler(w http.ResponseWriter, r *http.Request) error {
+	if s.Reload == nil {
+		return httputil.NotFound(
+			errors.New("reload not supported"),
+			"The configuration of this server cannot be reloaded",
+		)
+	}
+
+	changed, err := s.Reload()
+	if err != nil {
+		return httputil.BadRequest(err, "Error reloading the configuration: "+err.Error())
+	}
+
+	return httputil.WriteJSON(w, http.StatusOK, ReloadResponse{Changed: changed})
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadAuthToken(t *testing.T) {
+	s, ts := newTestServer(t, Config{AuthToken: "old-token"})
+	s.Reload = func() ([]string, error) {
+		s.SetAuthTokens("plaintext", "new-token", nil)
+		return []string{"auth-token"}, nil
+	}
+	oldAuth := map[string]string{"Authorization": "Bearer old-token"}
+	newAuth := map[string]string{"Authorization": "Bearer new-token"}
+
+	status, body := doRequest(t, http.MethodPost, ts.URL+"/query",
+		`[{"query": "CREATE TABLE numbers (x INTEGER)"}, {"query": "BEGIN"}]`, oldAuth,
+	)
+	require.Equal(t, http.StatusOK, status, body)
+	var response Response
+	require.NoError(t, json.Unmarshal([]byte(body), &response))
+	txId := response.Results[1].TxId
+	require.NotEmpty(t, txId)
+
+	status, body = doRequest(t, http.MethodPost, ts.URL+"/query",
+		`[{"query": "INSERT INTO numbers VALUES (1)", "txId": "`+txId+`"}]`, oldAuth,
+	)
+	require.Equal(t, http.StatusOK, status, body)
+
+	status, body = doRequest(t, http.MethodPost, ts.URL+"/admin/reload", "", oldAuth)
+	require.Equal(t, http.StatusOK, status, body)
+	assert.JSONEq(t, `{"changed": ["auth-token"]}`, body)
+
+	status, _ = doRequest(t, http.MethodPost, ts.URL+"/query", `[{"query": "SELECT 1"}]`, oldAuth)
+	assert.Equal(t, http.StatusUnauthorized, status, "the old token stops working")
+
+	status, body = doRequest(t, http.MethodPost, ts.URL+"/query",
+		`[{"query": "INSERT INTO numbers VALUES (2)", "txId": "`+txId+`"}, {"query": "COMMIT", "txId": "`+txId+`"}]`,
+		newAuth,
+	)
+	require.Equal(t, http.StatusOK, status, body)
+	assert.NotContains(t, body, "error", "the open transaction is kept")
+
+	status, body = doRequest(t, http.MethodPost, ts.URL+"/query",
+		`[{"query": "SELECT COUNT(*) FROM numbers"}]`, newAuth,
+	)
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, `"rows":[[2]]`)
+}
+
+func TestReloadHandler(t *testing.T) {
+	t.Run("Not supported", func(t *testing.T) {
+		_, ts := newTestServer(t, Config{})
+
+		status, _ := doRequest(t, http.MethodPost, ts.URL+"/admin/reload", "", nil)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		s, ts := newTestServer(t, Config{})
+		s.Reload = func() ([]string, error) { return nil, errors.New("invalid listen port") }
+
+		status, body := doRequest(t, http.MethodPost, ts.URL+"/admin/reload", "", nil)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, body, "invalid listen port")
+	})
+
+	t.Run("Requires the auth token", func(t *testing.T) {
+		s, ts := newTestServer(t, Config{AuthToken: "token"})
+		s.Reload = func() ([]string, error) { return nil, nil }
+
+		status, _ := doRequest(t, http.MethodPost, ts.URL+"/admin/reload", "", nil)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+)
+
+// requestLoggerMiddleware stores in the request context a logger with the
+// request ID and the client label, so the logs of the database can be tied
+// back to the request. The request ID is the one sent by the client in the
+// RequestIDHeader or a generated one, and it is returned in the same header.
+//
+// It runs after the auth middleware, which sets the final client label.
+func (s *Server) requestLoggerMiddleware(
+	next httputil.HandlerFuncErr,
+) httputil.HandlerFuncErr {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		requestId := r.Header.Get(RequestIDHeader)
+		if requestId == "" {
+			requestId = uuid.NewString()
+			// The header map is shared with the error handler, which logs
+			// the request ID too.
+			r.Header.Set(RequestIDHeader, requestId)
+		}
+		w.Header().Set(RequestIDHeader, requestId)
+
+		logger := s.Logger.WithKV(log.KV{
+			"requestId": requestId,
+			"client":    db.ClientLabelFromContext(r.Context()),
+		})
+		ctx := log.NewContext(r.Context(), logger)
+		return next(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a buffer safe for the concurrent writes of the server.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the JSON logs written to the buffer.
+func (b *syncBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	records := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		record := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// findRecord returns the log with the given message.
+func findRecord(t *testing.T, records []map[string]any, msg string) map[string]any {
+	t.Helper()
+
+	for _, record := range records {
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	t.Fatalf("no log with message %q", msg)
+	return nil
+}
+
+func TestRequestLogger(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := log.NewLogger(buf)
+	logger.SetLevels(slog.LevelDebug, nil)
+	_, ts := newTestServer(t, Config{Logger: logger})
+
+	t.Run("Request and transaction in the database logs", func(t *testing.T) {
+		status, body := doRequest(t, http.MethodPost, ts.URL+"/query", `[{"query": "BEGIN"}]`, map[string]string{
+			RequestIDHeader: "request-1",
+		})
+		require.Equal(t, http.StatusOK, status, body)
+		res := Response{}
+		require.NoError(t, json.Unmarshal([]byte(body), &res))
+		txId := res.Results[0].TxId
+
+		_, _ = doRequest(t, http.MethodPost, ts.URL+"/query",
+			`[{"txId": "`+txId+`", "query": "ROLLBACK"}]`, map[string]string{RequestIDHeader: "request-2"},
+		)
+
+		records := buf.records(t)
+		started := findRecord(t, records, "transaction started")
+		assert.Equal(t, "request-1", started["requestId"])
+		assert.Equal(t, "127.0.0.1", started["client"])
+		assert.Equal(t, txId, started["txId"])
+		assert.Equal(t, log.NsDatabase, started["ns"])
+
+		rolledBack := findRecord(t, records, "transaction rolled back")
+		assert.Equal(t, "request-2", rolledBack["requestId"])
+		assert.Equal(t, txId, rolledBack["txId"])
+	})
+
+	t.Run("Generated request ID", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/query", strings.NewReader(`[{"query": "SELEC 1"}]`))
+		require.NoError(t, err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		requestId := res.Header.Get(RequestIDHeader)
+		require.NotEmpty(t, requestId)
+		failed := findRecord(t, buf.records(t), "query failed")
+		assert.Equal(t, requestId, failed["requestId"])
+		assert.NotContains(t, failed, "txId")
+	})
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
 
 	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
 	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
@@ -20,14 +24,24 @@ type Config struct {
 	DBStats *stats.DBStats
 	// DB is the NSQLite database instance to use.
 	DB *db.DB
-	// ListenHost is the host to listen on.
+	// ListenHost are the comma-separated hosts to listen on, each one can
+	// also be a unix socket as "unix:<path>".
 	ListenHost string
 	// ListenPort is the port to listen on.
 	ListenPort string
+	// TrustedProxies are the comma-separated IP addresses or CIDR blocks of
+	// the reverse proxies whose X-Forwarded-For header is honored.
+	TrustedProxies string
 	// AuthTokenAlgorithm is the algorithm to use for the auth token.
 	AuthTokenAlgorithm string
 	// AuthToken is the auth token to use.
 	AuthToken string
+	// AuthTokens are the other auth tokens accepted. All the tokens can be
+	// replaced while the server runs with SetAuthTokens.
+	AuthTokens []AuthToken
+	// Reload reloads the configuration of the server and returns the names
+	// of the options that changed, nil if it cannot be reloaded.
+	Reload func() ([]string, error)
 }
 
 // Server is the server for NSQLite.
@@ -35,6 +49,16 @@ type Server struct {
 	Config
 	isInitialized bool
 	server        http.Server
+	// listeners are the listeners of each address, opened by Listen.
+	listeners []net.Listener
+	// queries are the running query requests that can be canceled.
+	queries *runningQueries
+	// auth are the current auth tokens, initially those of the Config.
+	auth atomic.Pointer[authConfig]
+	// authLockout are the failed authentications of the clients.
+	authLockout *authLockout
+	// trustedProxies are the parsed TrustedProxies.
+	trustedProxies []netip.Prefix
 }
 
 // NewServer creates a new NSQLite server.
@@ -48,12 +72,20 @@ func NewServer(config Config) (*Server, error) {
 	if config.AuthTokenAlgorithm == "" {
 		config.AuthTokenAlgorithm = "plaintext"
 	}
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
 	s := Server{
-		Config:        config,
-		isInitialized: true,
-		server:        http.Server{},
+		Config:         config,
+		isInitialized:  true,
+		server:         http.Server{},
+		queries:        newRunningQueries(),
+		authLockout:    newAuthLockout(),
+		trustedProxies: trustedProxies,
 	}
+	s.SetAuthTokens(config.AuthTokenAlgorithm, config.AuthToken, config.AuthTokens)
 	return &s, nil
 }
 
@@ -62,6 +94,12 @@ func (s *Server) IsInitialized() bool {
 	return s.isInitialized
 }
 
+// Handler returns the HTTP handler of the server, so it can be served
+// without starting the server, e.g. with an httptest.Server.
+func (s *Server) Handler() http.Handler {
+	return s.createMux()
+}
+
 // createMux creates the HTTP mux for the server.
 func (s *Server) createMux() *http.ServeMux {
 	buildHandler := httputil.CreateHandlerFuncBuilder(s.errorHandler)
@@ -70,31 +108,76 @@ func (s *Server) createMux() *http.ServeMux {
 	headerAuthMws := []httputil.Middleware{
 		s.queryHandlerAuthMiddleware,
 	}
+	readWriteAuthMws := []httputil.Middleware{
+		s.queryHandlerAuthMiddleware,
+		s.readWriteRoleMiddleware,
+	}
 
 	routes := []struct {
 		pattern     string
+		methods     []string
 		handler     httputil.HandlerFuncErr
 		middlewares []httputil.Middleware
 	}{
 		{
 			pattern: "/health",
+			methods: []string{http.MethodGet},
 			handler: s.healthHandler,
 		},
 		{
 			pattern:     "/version",
+			methods:     []string{http.MethodGet},
 			handler:     s.versionHandler,
 			middlewares: headerAuthMws,
 		},
+		{
+			pattern:     "/capabilities",
+			methods:     []string{http.MethodGet},
+			handler:     s.capabilitiesHandler,
+			middlewares: headerAuthMws,
+		},
 		{
 			pattern:     "/stats",
+			methods:     []string{http.MethodGet},
 			handler:     s.statsHandler,
 			middlewares: headerAuthMws,
 		},
+		{
+			pattern:     "/metrics",
+			methods:     []string{http.MethodGet},
+			handler:     s.metricsHandler,
+			middlewares: headerAuthMws,
+		},
 		{
 			pattern:     "/query",
+			methods:     []string{http.MethodPost},
 			handler:     s.queryHandler,
 			middlewares: headerAuthMws,
 		},
+		{
+			pattern:     "/query/{requestId}",
+			methods:     []string{http.MethodDelete},
+			handler:     s.cancelQueryHandler,
+			middlewares: headerAuthMws,
+		},
+		{
+			pattern:     "/backup",
+			methods:     []string{http.MethodGet},
+			handler:     s.backupHandler,
+			middlewares: headerAuthMws,
+		},
+		{
+			pattern:     "/restore",
+			methods:     []string{http.MethodPost},
+			handler:     s.restoreHandler,
+			middlewares: readWriteAuthMws,
+		},
+		{
+			pattern:     "/admin/reload",
+			methods:     []string{http.MethodPost},
+			handler:     s.reloadHandler,
+			middlewares: readWriteAuthMws,
+		},
 	}
 
 	setResponseHeaders := func(next httputil.HandlerFuncErr) httputil.HandlerFuncErr {
@@ -105,39 +188,130 @@ func (s *Server) createMux() *http.ServeMux {
 	}
 
 	for _, route := range routes {
-		route.middlewares = append(route.middlewares, setResponseHeaders)
-		mux.HandleFunc(
-			route.pattern, buildHandler(route.handler, route.middlewares...),
+		// The methods are checked by a middleware rather than in the
+		// pattern, so the 405 responses have the body of the other errors.
+		route.middlewares = append([]httputil.Middleware{
+			httputil.AllowMethods(route.methods...), s.clientLabelMiddleware,
+		}, route.middlewares...)
+		route.middlewares = append(
+			route.middlewares, s.requestLoggerMiddleware, setResponseHeaders,
 		)
+		mux.Handle(route.pattern, httputil.RecordResponse(
+			buildHandler(route.handler, route.middlewares...),
+		))
 	}
 
 	return mux
 }
 
-// Start starts the server.
+// Start listens on every address and serves the requests until the server
+// is stopped.
 func (s *Server) Start() error {
-	mux := s.createMux()
-	addr := fmt.Sprintf("%s:%s", s.ListenHost, s.ListenPort)
-	localAddr := fmt.Sprintf("http://%s:%s", "localhost", s.ListenPort)
+	if err := s.Listen(); err != nil {
+		return err
+	}
+	return s.Serve()
+}
+
+// Listen opens a listener on each address of the server. If any address
+// cannot be listened on, the listeners already opened are closed and the
+// error names the address.
+func (s *Server) Listen() error {
+	listeners := []net.Listener{}
+	for _, addr := range s.listenAddresses() {
+		listener, err := net.Listen(addr.network, addr.address)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return errors.New("no address to listen on")
+	}
+
+	s.listeners = listeners
 	s.server = http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Handler: s.createMux(),
 	}
+	return nil
+}
 
-	s.Logger.InfoNs(log.NsServer, "server started at "+localAddr, log.KV{
-		"listenHost": s.ListenHost,
-		"listenPort": s.ListenPort,
-	})
+// Addrs returns the addresses the server listens on, once Listen returned.
+func (s *Server) Addrs() []net.Addr {
+	addrs := make([]net.Addr, len(s.listeners))
+	for i, l := range s.listeners {
+		addrs[i] = l.Addr()
+	}
+	return addrs
+}
 
-	err := s.server.ListenAndServe()
-	if err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return err
+// Serve serves the requests of the listeners opened by Listen, all with the
+// same handler, until the server is stopped or one of them fails.
+func (s *Server) Serve() error {
+	errs := make(chan error, len(s.listeners))
+	for _, listener := range s.listeners {
+		s.Logger.InfoNs(log.NsServer, "server listening on "+listenURL(listener.Addr()), log.KV{
+			"network": listener.Addr().Network(),
+			"address": listener.Addr().String(),
+		})
+		go func() {
+			errs <- s.server.Serve(listener)
+		}()
+	}
+
+	for range s.listeners {
+		err := <-errs
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			_ = s.server.Close()
+			return err
+		}
 	}
 
 	return nil
 }
 
-// Stop gracefully stops the server.
+// listenAddress is an address the server listens on.
+type listenAddress struct {
+	network string
+	address string
+}
+
+func (a listenAddress) String() string {
+	if a.network == "unix" {
+		return "unix:" + a.address
+	}
+	return a.address
+}
+
+// listenAddresses returns the addresses of the hosts of the server.
+func (s *Server) listenAddresses() []listenAddress {
+	addrs := []listenAddress{}
+	for _, host := range strings.Split(s.ListenHost, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if path, ok := strings.CutPrefix(host, "unix:"); ok {
+			addrs = append(addrs, listenAddress{network: "unix", address: path})
+			continue
+		}
+		addrs = append(addrs, listenAddress{network: "tcp", address: net.JoinHostPort(host, s.ListenPort)})
+	}
+	return addrs
+}
+
+// listenURL returns the URL of a listener to show in the logs.
+func listenURL(addr net.Addr) string {
+	if addr.Network() == "unix" {
+		return "unix:" + addr.String()
+	}
+	return "http://" + addr.String()
+}
+
+// Stop gracefully stops the server on every address.
 func (s *Server) Stop() error {
 	return s.server.Shutdown(context.TODO())
 }
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/nsqlite/nsqlite/internal/util/httputil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestServer creates a server backed by a database in a temporary
+// directory and returns an httptest.Server serving its mux. The logs are
+// discarded unless config has a logger.
+func newTestServer(t *testing.T, config Config) (*Server, *httptest.Server) {
+	t.Helper()
+
+	logger := config.Logger
+	if !logger.IsInitialized() {
+		logger = log.NewLogger(io.Discard)
+	}
+	dbStats := stats.NewDBStats()
+	t.Cleanup(dbStats.Close)
+
+	dbInstance, err := db.NewDB(db.Config{
+		Logger:        logger,
+		DBStats:       dbStats,
+		DataDirectory: t.TempDir(),
+		TxIdleTimeout: 10 * time.Second,
+		BusyTimeout:   5 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dbInstance.Close() })
+
+	config.Logger = logger
+	config.DBStats = dbStats
+	config.DB = dbInstance
+	s, err := NewServer(config)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(s.createMux())
+	t.Cleanup(ts.Close)
+
+	return s, ts
+}
+
+// doRequest sends a request to the test server and returns the response
+// status and body.
+func doRequest(
+	t *testing.T, method string, url string, body string, headers map[string]string,
+) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	return res.StatusCode, string(resBody)
+}
+
+// doRequestFrom serves a request from the IP address with handler, without
+// a network connection, and returns the recorded response.
+func doRequestFrom(
+	t *testing.T, handler http.Handler, ip string, method string, path string, body string, headers map[string]string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.RemoteAddr = ip + ":40000"
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRoutesRejectWrongMethods(t *testing.T) {
+	_, ts := newTestServer(t, Config{})
+
+	tests := []struct {
+		method    string
+		path      string
+		wantAllow string
+	}{
+		{method: http.MethodPost, path: "/health", wantAllow: "GET, HEAD"},
+		{method: http.MethodPost, path: "/version", wantAllow: "GET, HEAD"},
+		{method: http.MethodPut, path: "/capabilities", wantAllow: "GET, HEAD"},
+		{method: http.MethodDelete, path: "/stats", wantAllow: "GET, HEAD"},
+		{method: http.MethodPost, path: "/metrics", wantAllow: "GET, HEAD"},
+		{method: http.MethodGet, path: "/query", wantAllow: "POST"},
+		{method: http.MethodGet, path: "/query/some-id", wantAllow: "DELETE"},
+		{method: http.MethodGet, path: "/admin/reload", wantAllow: "POST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			require.NoError(t, err)
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+			assert.Equal(t, tt.wantAllow, res.Header.Get("Allow"))
+
+			var body ErrorResponse
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+			assert.Equal(t, httputil.CodeMethodNotAllowed, body.Code)
+			assert.Contains(t, body.Message, "Method "+tt.method+" not allowed")
+		})
+	}
+
+	t.Run("HEAD /health", func(t *testing.T) {
+		res, err := http.Head(ts.URL + "/health")
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, int64(2), res.ContentLength)
+	})
+}
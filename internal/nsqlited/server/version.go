@@ -3,10 +3,22 @@ package server
 import (
 	"net/http"
 
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
 	"github.com/nsqlite/nsqlite/internal/util/httputil"
 	"github.com/nsqlite/nsqlite/internal/version"
 )
 
+// versionHandler returns the version and the build metadata of the server
+// as JSON, or only the version as plain text if the client prefers it, like
+// the older clients.
 func (s *Server) versionHandler(w http.ResponseWriter, r *http.Request) error {
-	return httputil.WriteString(w, http.StatusOK, version.Version)
+	w.Header().Add("Vary", "Accept")
+	if httputil.Negotiate(r, "application/json", "text/plain") == "text/plain" {
+		return httputil.WriteString(w, http.StatusOK, version.Version)
+	}
+
+	info := version.Build()
+	info.SQLiteVersion = sqlitec.LibVersion()
+	info.SQLiteSourceID = sqlitec.SourceID()
+	return httputil.WriteJSON(w, http.StatusOK, info)
 }
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/nsqlite/nsqlite/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionHandler(t *testing.T) {
+	_, ts := newTestServer(t, Config{})
+
+	// getVersion requests the version with the Accept header and returns
+	// the Content-Type and the body of the response.
+	getVersion := func(t *testing.T, accept string) (string, string) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/version", nil)
+		require.NoError(t, err)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "Accept", res.Header.Get("Vary"))
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.Header.Get("Content-Type"), string(body)
+	}
+
+	for _, accept := range []string{"", "application/json", "*/*", "application/json, text/plain;q=0.9"} {
+		t.Run("JSON for "+accept, func(t *testing.T) {
+			contentType, body := getVersion(t, accept)
+			assert.Contains(t, contentType, "application/json")
+
+			info := version.Info{}
+			require.NoError(t, json.Unmarshal([]byte(body), &info))
+			assert.Equal(t, version.Version, info.Server)
+			assert.Equal(t, runtime.Version(), info.GoVersion)
+			assert.Equal(t, "3.48.0", info.SQLiteVersion)
+			assert.NotEmpty(t, info.SQLiteSourceID)
+		})
+	}
+
+	for _, accept := range []string{"text/plain", "text/*", "application/json;q=0.5, text/plain"} {
+		t.Run("Plain text for "+accept, func(t *testing.T) {
+			status, body := doRequest(t, http.MethodGet, ts.URL+"/version", "", map[string]string{"Accept": accept})
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, version.Version, body)
+		})
+	}
+}
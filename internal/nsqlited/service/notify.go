@@ -0,0 +1,77 @@
+// Package service integrates nsqlited with the service managers, like
+// systemd with Type=notify.
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// States of the sd_notify protocol.
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Notify sends the state to the service manager through the datagram unix
+// socket of $NOTIFY_SOCKET, following the sd_notify protocol. It reports
+// false without an error if the variable is not set, like when nsqlited is
+// not run by systemd.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// Sockets starting with @ are in the abstract namespace.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to the notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to notify %q: %w", state, err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the interval of the watchdog of the service
+// manager from $WATCHDOG_USEC, false if it is not enabled for this process.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// RunWatchdog sends WATCHDOG=1 every half of the interval, as recommended
+// by systemd, until ctx is done. The errors are passed to onError.
+func RunWatchdog(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := Notify(StateWatchdog); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// WritePIDFile writes the PID of the process to the file at path, through a
+// temporary file so it is never read half written.
+func WritePIDFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(strconv.Itoa(os.Getpid()) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// RemovePIDFile removes the PID file at path if it still has the PID of the
+// process, so the file of another process started since is kept.
+func RemovePIDFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if string(contents) != strconv.Itoa(os.Getpid())+"\n" {
+		return nil
+	}
+	return os.Remove(path)
+}
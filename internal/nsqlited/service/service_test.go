@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNotifySocket listens on a fake notify socket set as $NOTIFY_SOCKET and
+// returns it.
+func newNotifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("datagram unix sockets are not supported")
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+// readMessage returns the next message of the notify socket.
+func readMessage(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	t.Run("Messages", func(t *testing.T) {
+		conn := newNotifySocket(t)
+
+		for _, state := range []string{StateReady, StateStopping} {
+			sent, err := Notify(state)
+			require.NoError(t, err)
+			assert.True(t, sent)
+			assert.Equal(t, state, readMessage(t, conn))
+		}
+	})
+
+	t.Run("Without a service manager", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+
+		sent, err := Notify(StateReady)
+		require.NoError(t, err)
+		assert.False(t, sent)
+	})
+
+	t.Run("Missing socket", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+
+		_, err := Notify(StateReady)
+		assert.ErrorContains(t, err, "failed to connect to the notify socket")
+	})
+}
+
+func TestWatchdog(t *testing.T) {
+	t.Run("Interval", func(t *testing.T) {
+		t.Setenv("WATCHDOG_USEC", "30000000")
+		t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+
+		interval, ok := WatchdogInterval()
+		assert.True(t, ok)
+		assert.Equal(t, 30*time.Second, interval)
+	})
+
+	t.Run("Other process", func(t *testing.T) {
+		t.Setenv("WATCHDOG_USEC", "30000000")
+		t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+
+		_, ok := WatchdogInterval()
+		assert.False(t, ok)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Setenv("WATCHDOG_USEC", "")
+
+		_, ok := WatchdogInterval()
+		assert.False(t, ok)
+	})
+
+	t.Run("Messages", func(t *testing.T) {
+		conn := newNotifySocket(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			RunWatchdog(ctx, 20*time.Millisecond, func(err error) { t.Error(err) })
+		}()
+
+		assert.Equal(t, StateWatchdog, readMessage(t, conn))
+		assert.Equal(t, StateWatchdog, readMessage(t, conn))
+		cancel()
+		<-done
+	})
+}
+
+func TestPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nsqlited.pid")
+
+	require.NoError(t, WritePIDFile(path))
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(contents))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary file is left")
+
+	require.NoError(t, RemovePIDFile(path))
+	assert.NoFileExists(t, path)
+	require.NoError(t, RemovePIDFile(path), "a missing file is not an error")
+
+	t.Run("File of another process", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("1\n"), 0644))
+
+		require.NoError(t, RemovePIDFile(path))
+		assert.FileExists(t, path)
+	})
+}
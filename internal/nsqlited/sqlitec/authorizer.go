@@ -0,0 +1,165 @@
+package sqlitec
+
+// #include "sqlite3.c"
+// #include <stdint.h>
+//
+// // Implemented in Go in hook_export.go.
+// extern int goAuthorizer(void *handle, int action, char *arg1, char *arg2, char *dbName, char *trigger);
+//
+// // The exported Go functions can not have const parameters, so the
+// // authorizer goes through this wrapper.
+// static int cust_authorizer(void *handle, int action, const char *arg1, const char *arg2, const char *dbName, const char *trigger) {
+//   return goAuthorizer(handle, action, (char *)arg1, (char *)arg2, (char *)dbName, (char *)trigger);
+// }
+//
+// // The handle of the Go function is passed as the argument of the
+// // authorizer, a zero handle removes it.
+// static int cust_sqlite3_set_authorizer(sqlite3 *db, uintptr_t handle) {
+//   if (handle == 0) {
+//     return sqlite3_set_authorizer(db, 0, 0);
+//   }
+//   return sqlite3_set_authorizer(db, cust_authorizer, (void *)handle);
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+)
+
+// AuthAction is the action of a statement checked by the authorizer, the
+// meaning of the arguments of the authorizer depends on it.
+//
+// https://www.sqlite.org/c3ref/c_alter_table.html
+type AuthAction int
+
+const (
+	AuthCreateIndex       = AuthAction(C.SQLITE_CREATE_INDEX)        // Index name, table name.
+	AuthCreateTable       = AuthAction(C.SQLITE_CREATE_TABLE)        // Table name.
+	AuthCreateTempIndex   = AuthAction(C.SQLITE_CREATE_TEMP_INDEX)   // Index name, table name.
+	AuthCreateTempTable   = AuthAction(C.SQLITE_CREATE_TEMP_TABLE)   // Table name.
+	AuthCreateTempTrigger = AuthAction(C.SQLITE_CREATE_TEMP_TRIGGER) // Trigger name, table name.
+	AuthCreateTempView    = AuthAction(C.SQLITE_CREATE_TEMP_VIEW)    // View name.
+	AuthCreateTrigger     = AuthAction(C.SQLITE_CREATE_TRIGGER)      // Trigger name, table name.
+	AuthCreateView        = AuthAction(C.SQLITE_CREATE_VIEW)         // View name.
+	AuthDelete            = AuthAction(C.SQLITE_DELETE)              // Table name.
+	AuthDropIndex         = AuthAction(C.SQLITE_DROP_INDEX)          // Index name, table name.
+	AuthDropTable         = AuthAction(C.SQLITE_DROP_TABLE)          // Table name.
+	AuthDropTempIndex     = AuthAction(C.SQLITE_DROP_TEMP_INDEX)     // Index name, table name.
+	AuthDropTempTable     = AuthAction(C.SQLITE_DROP_TEMP_TABLE)     // Table name.
+	AuthDropTempTrigger   = AuthAction(C.SQLITE_DROP_TEMP_TRIGGER)   // Trigger name, table name.
+	AuthDropTempView      = AuthAction(C.SQLITE_DROP_TEMP_VIEW)      // View name.
+	AuthDropTrigger       = AuthAction(C.SQLITE_DROP_TRIGGER)        // Trigger name, table name.
+	AuthDropView          = AuthAction(C.SQLITE_DROP_VIEW)           // View name.
+	AuthInsert            = AuthAction(C.SQLITE_INSERT)              // Table name.
+	AuthPragma            = AuthAction(C.SQLITE_PRAGMA)              // Pragma name, its argument if any.
+	AuthRead              = AuthAction(C.SQLITE_READ)                // Table name, column name.
+	AuthSelect            = AuthAction(C.SQLITE_SELECT)              // No arguments.
+	AuthTransaction       = AuthAction(C.SQLITE_TRANSACTION)         // Operation, like "BEGIN".
+	AuthUpdate            = AuthAction(C.SQLITE_UPDATE)              // Table name, column name.
+	AuthAttach            = AuthAction(C.SQLITE_ATTACH)              // File name.
+	AuthDetach            = AuthAction(C.SQLITE_DETACH)              // Database name.
+	AuthAlterTable        = AuthAction(C.SQLITE_ALTER_TABLE)         // Database name, table name.
+	AuthReindex           = AuthAction(C.SQLITE_REINDEX)             // Index name.
+	AuthAnalyze           = AuthAction(C.SQLITE_ANALYZE)             // Table name.
+	AuthCreateVTable      = AuthAction(C.SQLITE_CREATE_VTABLE)       // Table name, module name.
+	AuthDropVTable        = AuthAction(C.SQLITE_DROP_VTABLE)         // Table name, module name.
+	AuthFunction          = AuthAction(C.SQLITE_FUNCTION)            // No argument, function name.
+	AuthSavepoint         = AuthAction(C.SQLITE_SAVEPOINT)           // Operation, savepoint name.
+	AuthRecursive         = AuthAction(C.SQLITE_RECURSIVE)           // No arguments.
+)
+
+// authActionNames are the names of the actions, without the SQLITE_ prefix
+// of their constant.
+var authActionNames = map[AuthAction]string{
+	AuthCreateIndex:       "CREATE_INDEX",
+	AuthCreateTable:       "CREATE_TABLE",
+	AuthCreateTempIndex:   "CREATE_TEMP_INDEX",
+	AuthCreateTempTable:   "CREATE_TEMP_TABLE",
+	AuthCreateTempTrigger: "CREATE_TEMP_TRIGGER",
+	AuthCreateTempView:    "CREATE_TEMP_VIEW",
+	AuthCreateTrigger:     "CREATE_TRIGGER",
+	AuthCreateView:        "CREATE_VIEW",
+	AuthDelete:            "DELETE",
+	AuthDropIndex:         "DROP_INDEX",
+	AuthDropTable:         "DROP_TABLE",
+	AuthDropTempIndex:     "DROP_TEMP_INDEX",
+	AuthDropTempTable:     "DROP_TEMP_TABLE",
+	AuthDropTempTrigger:   "DROP_TEMP_TRIGGER",
+	AuthDropTempView:      "DROP_TEMP_VIEW",
+	AuthDropTrigger:       "DROP_TRIGGER",
+	AuthDropView:          "DROP_VIEW",
+	AuthInsert:            "INSERT",
+	AuthPragma:            "PRAGMA",
+	AuthRead:              "READ",
+	AuthSelect:            "SELECT",
+	AuthTransaction:       "TRANSACTION",
+	AuthUpdate:            "UPDATE",
+	AuthAttach:            "ATTACH",
+	AuthDetach:            "DETACH",
+	AuthAlterTable:        "ALTER_TABLE",
+	AuthReindex:           "REINDEX",
+	AuthAnalyze:           "ANALYZE",
+	AuthCreateVTable:      "CREATE_VTABLE",
+	AuthDropVTable:        "DROP_VTABLE",
+	AuthFunction:          "FUNCTION",
+	AuthSavepoint:         "SAVEPOINT",
+	AuthRecursive:         "RECURSIVE",
+}
+
+// String returns the name of the action, like "CREATE_TABLE".
+func (action AuthAction) String() string {
+	if name, ok := authActionNames[action]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// AuthResult is the decision of the authorizer on an action.
+type AuthResult int
+
+const (
+	// AuthOK allows the action.
+	AuthOK = AuthResult(C.SQLITE_OK)
+	// AuthDeny fails the preparation of the statement with SQLITE_AUTH.
+	AuthDeny = AuthResult(C.SQLITE_DENY)
+	// AuthIgnore prepares the statement without the action, the columns
+	// whose AuthRead is ignored are read as NULL, the tables whose
+	// AuthDelete is ignored are deleted row by row, and the other actions
+	// ignored are skipped.
+	AuthIgnore = AuthResult(C.SQLITE_IGNORE)
+)
+
+// Authorizer decides whether the actions of the statements of a connection
+// are allowed, see SetAuthorizer.
+//
+// dbName is the name of the database of the action, like "main" or "temp",
+// and trigger is the name of the trigger or view that caused it, empty if
+// it is caused by the statement itself.
+type Authorizer func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult
+
+// SetAuthorizer sets the function that authorizes the actions of the
+// statements of the connection, replacing the previous one. A nil fn
+// removes the authorizer, then every action is allowed.
+//
+// fn is called while the statements are prepared, not while they run, so a
+// statement prepared before the authorizer changes is prepared again with
+// the new one when it runs next. It is safe to set a different authorizer
+// for each use of a pooled connection, as long as the connection is not
+// used concurrently. Like the update hook, it is never called concurrently
+// with itself and it must not use the connection.
+//
+// https://www.sqlite.org/c3ref/set_authorizer.html
+func (conn *Conn) SetAuthorizer(fn Authorizer) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set authorizer: database connection is nil")
+	}
+
+	var resCode C.int
+	replaceHook(&conn.authorizer, fn, fn != nil, func(handle C.uintptr_t) {
+		resCode = C.cust_sqlite3_set_authorizer(conn.cDB, handle)
+	})
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to set authorizer: %s: %s", getResCodeStr(resCode), conn.getLastError())
+	}
+	return nil
+}
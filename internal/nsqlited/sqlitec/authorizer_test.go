@@ -0,0 +1,122 @@
+package sqlitec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAuthorizer(t *testing.T) {
+	type call struct {
+		action     AuthAction
+		arg1, arg2 string
+		dbName     string
+		trigger    string
+	}
+
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = conn.Exec(`
+		CREATE TABLE users (name TEXT, password TEXT);
+		INSERT INTO users VALUES ('alice', 'secret');
+	`)
+	require.NoError(t, err)
+	defer conn.SetAuthorizer(nil)
+
+	t.Run("Arguments", func(t *testing.T) {
+		calls := []call{}
+		require.NoError(t, conn.SetAuthorizer(func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+			calls = append(calls, call{action, arg1, arg2, dbName, trigger})
+			return AuthOK
+		}))
+
+		_, err := conn.Query("UPDATE users SET name = 'bob'", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []call{{action: AuthUpdate, arg1: "users", arg2: "name", dbName: "main"}}, calls)
+	})
+
+	t.Run("Deny", func(t *testing.T) {
+		require.NoError(t, conn.SetAuthorizer(func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+			if action == AuthDelete || action == AuthAttach {
+				return AuthDeny
+			}
+			return AuthOK
+		}))
+
+		for _, query := range []string{"DELETE FROM users", "ATTACH ':memory:' AS other"} {
+			_, err := conn.Prepare(query)
+			assert.ErrorContains(t, err, "not authorized", query)
+		}
+		res, err := conn.Query("SELECT count(*) FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{1}}, res.Rows, "the row is not deleted")
+	})
+
+	t.Run("Ignore", func(t *testing.T) {
+		require.NoError(t, conn.SetAuthorizer(func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+			if action == AuthRead && arg1 == "users" && arg2 == "password" {
+				return AuthIgnore
+			}
+			return AuthOK
+		}))
+
+		res, err := conn.Query("SELECT name, password FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"bob", nil}}, res.Rows)
+	})
+
+	t.Run("Prepared before the change", func(t *testing.T) {
+		require.NoError(t, conn.SetAuthorizer(nil))
+		stmt, err := conn.Prepare("SELECT password FROM users")
+		require.NoError(t, err)
+		defer stmt.Finalize()
+
+		require.NoError(t, conn.SetAuthorizer(func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+			if action == AuthRead {
+				return AuthDeny
+			}
+			return AuthOK
+		}))
+		_, err = stmt.Step()
+		assert.ErrorContains(t, err, "23: authorization denied", "the statement is prepared again")
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		require.NoError(t, conn.SetAuthorizer(func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+			panic("boom")
+		}))
+
+		_, err := conn.Prepare("SELECT name FROM users")
+		assert.ErrorContains(t, err, "not authorized")
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		require.NoError(t, conn.SetAuthorizer(nil))
+		assert.Zero(t, conn.authorizer)
+
+		res, err := conn.Query("SELECT password FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"secret"}}, res.Rows)
+	})
+
+	t.Run("Released on close", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.SetAuthorizer(func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+			return AuthOK
+		}))
+		assert.NotZero(t, conn.authorizer)
+
+		require.NoError(t, conn.Close())
+		assert.Zero(t, conn.authorizer)
+		assert.ErrorContains(t, conn.SetAuthorizer(nil), "database connection is nil")
+	})
+
+	t.Run("Action names", func(t *testing.T) {
+		assert.Equal(t, "CREATE_INDEX", AuthCreateIndex.String())
+		assert.Equal(t, "RECURSIVE", AuthRecursive.String())
+		assert.Equal(t, "UNKNOWN", AuthAction(0).String())
+	})
+}
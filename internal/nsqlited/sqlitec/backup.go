@@ -0,0 +1,108 @@
+package sqlitec
+
+// #include "sqlite3.c"
+import "C"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+const (
+	// backupFilePages is the number of pages copied by each step of
+	// BackupToFile.
+	backupFilePages = 1000
+	// backupFilePause is the pause of BackupToFile between the steps.
+	backupFilePause = 10 * time.Millisecond
+	// backupBusyPause is the shortest pause of BackupTo before retrying a
+	// step that found the databases locked.
+	backupBusyPause = time.Millisecond
+)
+
+// BackupTo copies the main database of the connection into the main database
+// of dest, replacing its content, with the online backup API.
+//
+// The copy is made pages pages at a time, all of them in a single step if
+// pages is zero or less, pausing for pause between the steps. The source is
+// only locked while a step runs, so the writers are not blocked for the whole
+// copy. If the source is modified by another connection between two steps
+// the copy restarts, so the result is always a consistent snapshot.
+//
+// The steps that find the databases locked are retried after the pause, or
+// after backupBusyPause if it is shorter, until ctx is done.
+//
+// https://www.sqlite.org/backup.html
+func (conn *Conn) BackupTo(ctx context.Context, dest *Conn, pages int, pause time.Duration) error {
+	if conn.cDB == nil || dest == nil || dest.cDB == nil {
+		return errors.New("failed to backup database: database connection is nil")
+	}
+	if pages <= 0 {
+		pages = -1
+	}
+
+	cMain := C.CString("main")
+	defer C.free(unsafe.Pointer(cMain))
+
+	// The errors of sqlite3_backup_init and sqlite3_backup_finish are
+	// reported on the destination connection.
+	backup := C.sqlite3_backup_init(dest.cDB, cMain, conn.cDB, cMain)
+	if backup == nil {
+		return fmt.Errorf("failed to backup database: %s", dest.getLastError())
+	}
+
+	var stepErr error
+	for {
+		if err := ctx.Err(); err != nil {
+			stepErr = fmt.Errorf("failed to backup database: %w", err)
+			break
+		}
+
+		resCode := C.sqlite3_backup_step(backup, C.int(pages))
+		if resCode == C.SQLITE_DONE {
+			break
+		}
+
+		if resCode != C.SQLITE_OK && resCode != C.SQLITE_BUSY && resCode != C.SQLITE_LOCKED {
+			stepErr = fmt.Errorf("failed to backup database: %s", getResCodeStr(resCode))
+			break
+		}
+
+		wait := pause
+		if resCode != C.SQLITE_OK {
+			wait = max(wait, backupBusyPause)
+		}
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+	}
+
+	resCode := C.sqlite3_backup_finish(backup)
+	if stepErr != nil {
+		return stepErr
+	}
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to backup database: %s: %s", getResCodeStr(resCode), dest.getLastError())
+	}
+
+	return nil
+}
+
+// BackupToFile copies the main database of the connection into the database
+// file at path, creating it if it does not exist or replacing its content
+// otherwise. See BackupTo.
+func (conn *Conn) BackupToFile(ctx context.Context, path string) error {
+	dest, err := OpenWithFlags(path, OpenReadWrite|OpenCreate)
+	if err != nil {
+		return fmt.Errorf("failed to open backup destination: %w", err)
+	}
+
+	backupErr := conn.BackupTo(ctx, dest, backupFilePages, backupFilePause)
+	return errors.Join(backupErr, dest.Close())
+}
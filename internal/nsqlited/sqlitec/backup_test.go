@@ -0,0 +1,160 @@
+package sqlitec
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	// openSource opens a file database whose items table has as many rows as
+	// the counter, spread over many pages.
+	openSource := func(t *testing.T) (*Conn, string) {
+		path := filepath.Join(t.TempDir(), "source.sqlite")
+		conn, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		_, err = conn.Exec(`
+			CREATE TABLE items (id INTEGER PRIMARY KEY, data BLOB);
+			CREATE TABLE counter (n INTEGER);
+			WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 500)
+			INSERT INTO items (data) SELECT randomblob(200) FROM c;
+			INSERT INTO counter (n) VALUES (500);
+		`)
+		require.NoError(t, err)
+		return conn, path
+	}
+
+	// checkCopy checks that the copy is not corrupted and that the items
+	// match the counter, then returns the number of items.
+	checkCopy := func(t *testing.T, conn *Conn) int {
+		res, err := conn.Query("PRAGMA integrity_check", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"ok"}}, res.Rows)
+
+		res, err = conn.Query("SELECT (SELECT count(*) FROM items), (SELECT n FROM counter)", nil)
+		require.NoError(t, err)
+		assert.Equal(t, res.Rows[0][0], res.Rows[0][1], "the copy is a consistent snapshot")
+		return res.Rows[0][0].(int)
+	}
+
+	t.Run("Snapshot while writing", func(t *testing.T) {
+		source, path := openSource(t)
+		writer, err := Open(path)
+		require.NoError(t, err)
+		defer writer.Close()
+		require.NoError(t, writer.BusyTimeout(5*time.Second))
+		require.NoError(t, source.BusyTimeout(5*time.Second))
+
+		var writes atomic.Int64
+		stop := make(chan struct{})
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, err := writer.Exec(`
+					BEGIN IMMEDIATE;
+					INSERT INTO items (data) VALUES (randomblob(200));
+					UPDATE counter SET n = n + 1;
+					COMMIT;
+				`)
+				if !assert.NoError(t, err) {
+					return
+				}
+				writes.Add(1)
+				time.Sleep(time.Millisecond)
+			}
+		}()
+
+		for i := range 3 {
+			require.Eventually(t, func() bool { return writes.Load() > int64(i*10) }, 5*time.Second, time.Millisecond)
+
+			copyPath := filepath.Join(t.TempDir(), fmt.Sprintf("copy-%d.sqlite", i))
+			require.NoError(t, source.BackupToFile(context.Background(), copyPath))
+
+			copyConn, err := Open(copyPath)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, checkCopy(t, copyConn), 500)
+			require.NoError(t, copyConn.Close())
+		}
+
+		close(stop)
+		wg.Wait()
+	})
+
+	t.Run("Page by page", func(t *testing.T) {
+		source, _ := openSource(t)
+		dest, err := Open(":memory:")
+		require.NoError(t, err)
+		defer dest.Close()
+
+		require.NoError(t, source.BackupTo(context.Background(), dest, 1, 0))
+		assert.Equal(t, 500, checkCopy(t, dest))
+	})
+
+	t.Run("Replaces the destination", func(t *testing.T) {
+		source, _ := openSource(t)
+		copyPath := filepath.Join(t.TempDir(), "copy.sqlite")
+		dest, err := Open(copyPath)
+		require.NoError(t, err)
+		_, err = dest.Query("CREATE TABLE old (val TEXT)", nil)
+		require.NoError(t, err)
+		require.NoError(t, dest.Close())
+
+		require.NoError(t, source.BackupToFile(context.Background(), copyPath))
+
+		dest, err = Open(copyPath)
+		require.NoError(t, err)
+		defer dest.Close()
+		assert.Equal(t, 500, checkCopy(t, dest))
+		_, err = dest.Query("SELECT * FROM old", nil)
+		assert.ErrorContains(t, err, "no such table: old")
+	})
+
+	t.Run("Locked source until the deadline", func(t *testing.T) {
+		source, path := openSource(t)
+		writer, err := Open(path)
+		require.NoError(t, err)
+		defer writer.Close()
+		_, err = writer.Exec("BEGIN EXCLUSIVE; UPDATE counter SET n = n + 1;")
+		require.NoError(t, err)
+		defer func() { _, _ = writer.Exec("ROLLBACK") }()
+
+		dest, err := Open(":memory:")
+		require.NoError(t, err)
+		defer dest.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		err = source.BackupTo(ctx, dest, 0, 0)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Closed connection", func(t *testing.T) {
+		source, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, source.Close())
+		dest, err := Open(":memory:")
+		require.NoError(t, err)
+		defer dest.Close()
+
+		assert.ErrorContains(t, source.BackupTo(context.Background(), dest, 0, 0), "database connection is nil")
+		assert.ErrorContains(t, dest.BackupTo(context.Background(), source, 0, 0), "database connection is nil")
+	})
+}
@@ -0,0 +1,260 @@
+package sqlitec
+
+// #include "sqlite3.c"
+// #include <stdint.h>
+//
+// // Implemented in Go in function_export.go.
+// extern void goFunctionCall(sqlite3_context *ctx, int argc, sqlite3_value **argv);
+// extern void goAggregateStep(sqlite3_context *ctx, int argc, sqlite3_value **argv);
+// extern void goAggregateFinal(sqlite3_context *ctx);
+// extern void goAggregateValue(sqlite3_context *ctx);
+// extern void goAggregateInverse(sqlite3_context *ctx, int argc, sqlite3_value **argv);
+// extern void goFunctionDestroy(void *handle);
+//
+// // The handle of the Go function is passed as the user data of the SQL
+// // function, it is deleted by goFunctionDestroy when SQLite drops it.
+// static int cust_sqlite3_create_function(sqlite3 *db, const char *name, int nArgs, int flags, uintptr_t handle) {
+//   return sqlite3_create_function_v2(db, name, nArgs, flags, (void *)handle,
+//     goFunctionCall, 0, 0, goFunctionDestroy);
+// }
+//
+// // Same as cust_sqlite3_create_function, the handle is the one of the
+// // function creating the aggregators.
+// static int cust_sqlite3_create_aggregate(sqlite3 *db, const char *name, int nArgs, int flags, uintptr_t handle) {
+//   return sqlite3_create_function_v2(db, name, nArgs, flags, (void *)handle,
+//     0, goAggregateStep, goAggregateFinal, goFunctionDestroy);
+// }
+//
+// // Same as cust_sqlite3_create_aggregate, for the aggregators that can also
+// // be used as window functions.
+// static int cust_sqlite3_create_window_function(sqlite3 *db, const char *name, int nArgs, int flags, uintptr_t handle) {
+//   return sqlite3_create_window_function(db, name, nArgs, flags, (void *)handle,
+//     goAggregateStep, goAggregateFinal, goAggregateValue, goAggregateInverse, goFunctionDestroy);
+// }
+//
+// // SQLITE_TRANSIENT is not accessible from Go, so we create a wrapper here.
+// static void cust_sqlite3_result_text(sqlite3_context *ctx, char *p, int np) {
+//   sqlite3_result_text(ctx, p, np, SQLITE_TRANSIENT);
+// }
+//
+// // SQLITE_TRANSIENT is not accessible from Go, so we create a wrapper here.
+// static void cust_sqlite3_result_blob(sqlite3_context *ctx, void *p, int np) {
+//   sqlite3_result_blob(ctx, p, np, SQLITE_TRANSIENT);
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// Function is the Go implementation of a SQL scalar function, see
+// CreateFunction.
+type Function func(args []any) (any, error)
+
+// CreateFunction registers fn as the SQL scalar function name of the
+// connection, replacing the function with the same name and number of
+// arguments, if any. nArgs is the number of arguments of the function, any
+// number if it is -1. deterministic tells SQLite that fn always returns the
+// same result for the same arguments, so it can be used in indexes and its
+// calls can be optimized.
+//
+// The arguments are passed as int64, float64, string, []byte or nil, and
+// the result can be of any of the types supported by Stmt.BindDynamic. An
+// error returned or a panic of fn fails the statement with its message.
+//
+// fn is released when the function is replaced or the connection is closed.
+//
+// https://www.sqlite.org/c3ref/create_function.html
+func (conn *Conn) CreateFunction(name string, nArgs int, deterministic bool, fn Function) error {
+	if conn.cDB == nil {
+		return errors.New("failed to create function: database connection is nil")
+	}
+	if fn == nil {
+		return errors.New("failed to create function: the function is nil")
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	flags := C.SQLITE_UTF8
+	if deterministic {
+		flags |= C.SQLITE_DETERMINISTIC
+	}
+
+	// SQLite calls goFunctionDestroy when the creation fails, so the handle
+	// is never deleted here.
+	handle := cgo.NewHandle(fn)
+	resCode := C.cust_sqlite3_create_function(conn.cDB, cName, C.int(nArgs), C.int(flags), C.uintptr_t(handle))
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to create function %q: %s: %s", name, getResCodeStr(resCode), conn.getLastError())
+	}
+
+	return nil
+}
+
+// Aggregator computes the result of a SQL aggregate function over the rows of
+// a group, see CreateAggregate.
+type Aggregator interface {
+	// Step adds the arguments of a row to the aggregate.
+	Step(args []any) error
+	// Final returns the result of the aggregate, once all the rows of the
+	// group were added.
+	Final() (any, error)
+}
+
+// WindowAggregator is an Aggregator that can also be used as a window
+// function, with an OVER clause. The rows enter the frame with Step and
+// leave it with Inverse, in the order they were added.
+type WindowAggregator interface {
+	Aggregator
+	// Value returns the result of the aggregate over the rows of the current
+	// frame, without resetting it.
+	Value() (any, error)
+	// Inverse removes the arguments of the oldest row of the frame from the
+	// aggregate.
+	Inverse(args []any) error
+}
+
+// aggregateState is the state of an aggregate over a group, its handle is
+// kept in the aggregate context of SQLite.
+type aggregateState struct {
+	aggregator Aggregator
+	// failed is true if a call of the aggregator failed, then the statement
+	// is aborted and the result is not computed.
+	failed bool
+}
+
+// CreateAggregate registers the SQL aggregate function name of the
+// connection, replacing the function with the same name and number of
+// arguments, if any. nArgs is the number of arguments of the function, any
+// number if it is -1.
+//
+// newAggregator is called once for each group, even for the groups without
+// rows, so the state of the groups is never shared. The arguments and the
+// results are converted like in CreateFunction, and an error returned or a
+// panic of the aggregator fails the statement with its message.
+//
+// newAggregator is called once when registering the function, if the
+// aggregators it returns implement WindowAggregator the function can also be
+// used as a window function.
+//
+// newAggregator is released when the function is replaced or the connection
+// is closed.
+//
+// https://www.sqlite.org/c3ref/create_function.html
+func (conn *Conn) CreateAggregate(name string, nArgs int, newAggregator func() Aggregator) error {
+	if conn.cDB == nil {
+		return errors.New("failed to create aggregate: database connection is nil")
+	}
+	if newAggregator == nil {
+		return errors.New("failed to create aggregate: the aggregator constructor is nil")
+	}
+
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	_, isWindow := newAggregator().(WindowAggregator)
+
+	// SQLite calls goFunctionDestroy when the creation fails, so the handle
+	// is never deleted here.
+	handle := cgo.NewHandle(newAggregator)
+	var resCode C.int
+	if isWindow {
+		resCode = C.cust_sqlite3_create_window_function(conn.cDB, cName, C.int(nArgs), C.SQLITE_UTF8, C.uintptr_t(handle))
+	} else {
+		resCode = C.cust_sqlite3_create_aggregate(conn.cDB, cName, C.int(nArgs), C.SQLITE_UTF8, C.uintptr_t(handle))
+	}
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to create aggregate %q: %s: %s", name, getResCodeStr(resCode), conn.getLastError())
+	}
+
+	return nil
+}
+
+// functionArgs converts the arguments of a SQL function call to Go values.
+func functionArgs(argc C.int, argv **C.sqlite3_value) []any {
+	values := unsafe.Slice(argv, int(argc))
+	args := make([]any, len(values))
+	for i, value := range values {
+		switch C.sqlite3_value_type(value) {
+		case C.SQLITE_INTEGER:
+			args[i] = int64(C.sqlite3_value_int64(value))
+		case C.SQLITE_FLOAT:
+			args[i] = float64(C.sqlite3_value_double(value))
+		case C.SQLITE_TEXT:
+			size := C.sqlite3_value_bytes(value)
+			args[i] = C.GoStringN((*C.char)(unsafe.Pointer(C.sqlite3_value_text(value))), size)
+		case C.SQLITE_BLOB:
+			size := C.sqlite3_value_bytes(value)
+			args[i] = []byte{}
+			if size > 0 {
+				args[i] = C.GoBytes(C.sqlite3_value_blob(value), size)
+			}
+		default:
+			args[i] = nil
+		}
+	}
+	return args
+}
+
+// functionResult sets the result of a SQL function call from the values
+// returned by its Go implementation.
+func functionResult(ctx *C.sqlite3_context, result any, err error) {
+	if err != nil {
+		cMsg := C.CString(err.Error())
+		defer C.free(unsafe.Pointer(cMsg))
+		C.sqlite3_result_error(ctx, cMsg, -1)
+		return
+	}
+
+	switch v := result.(type) {
+	case bool:
+		if v {
+			C.sqlite3_result_int64(ctx, 1)
+		} else {
+			C.sqlite3_result_int64(ctx, 0)
+		}
+	case int8:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case uint8:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case int16:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case uint16:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case int32:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case uint32:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case int:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case uint:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case int64:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case uint64:
+		C.sqlite3_result_int64(ctx, C.sqlite3_int64(v))
+	case float64:
+		C.sqlite3_result_double(ctx, C.double(v))
+	case float32:
+		C.sqlite3_result_double(ctx, C.double(v))
+	case string:
+		cText := C.CString(v)
+		defer C.free(unsafe.Pointer(cText))
+		C.cust_sqlite3_result_text(ctx, cText, C.int(len(v)))
+	case []byte:
+		if len(v) == 0 {
+			C.sqlite3_result_zeroblob(ctx, 0)
+			return
+		}
+		cBlob := C.CBytes(v)
+		defer C.free(cBlob)
+		C.cust_sqlite3_result_blob(ctx, cBlob, C.int(len(v)))
+	case nil:
+		C.sqlite3_result_null(ctx)
+	default:
+		functionResult(ctx, nil, fmt.Errorf("unsupported function result %T type: %v", result, result))
+	}
+}
@@ -0,0 +1,187 @@
+package sqlitec
+
+// The preamble of a file with exported functions can only have declarations,
+// so the callbacks of CreateFunction are kept apart from function.go.
+
+// #include "sqlite3-v3.48.0.h"
+// #include <stdint.h>
+import "C"
+import (
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+)
+
+// goFunctionCall is the C entry point of the functions registered with
+// CreateFunction, it runs the Go function of the call.
+//
+//export goFunctionCall
+func goFunctionCall(ctx *C.sqlite3_context, argc C.int, argv **C.sqlite3_value) {
+	fn := cgo.Handle(uintptr(C.sqlite3_user_data(ctx))).Value().(Function)
+
+	var result any
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("function panicked: %v", r)
+			}
+		}()
+		result, err = fn(functionArgs(argc, argv))
+		return err
+	}()
+
+	functionResult(ctx, result, err)
+}
+
+// aggregateStateOf returns the state of the group of the call, stored in
+// its aggregate context. If create is false it returns nil when no row was
+// added to the group, and the state is released.
+func aggregateStateOf(ctx *C.sqlite3_context, create bool) *aggregateState {
+	size := C.int(0)
+	if create {
+		size = C.int(unsafe.Sizeof(C.uintptr_t(0)))
+	}
+	// The aggregate context is zeroed when it is allocated.
+	slot := (*C.uintptr_t)(C.sqlite3_aggregate_context(ctx, size))
+	if slot == nil {
+		return nil
+	}
+
+	if *slot == 0 {
+		if !create {
+			return nil
+		}
+		state := &aggregateState{}
+		*slot = C.uintptr_t(cgo.NewHandle(state))
+		return state
+	}
+
+	handle := cgo.Handle(*slot)
+	state := handle.Value().(*aggregateState)
+	if !create {
+		handle.Delete()
+		*slot = 0
+	}
+	return state
+}
+
+// call runs a method of the aggregator of the group, creating it on the
+// first call. A panic is returned as an error, and the state is marked as
+// failed on error.
+func (state *aggregateState) call(ctx *C.sqlite3_context, method func(Aggregator) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("aggregate panicked: %v", r)
+		}
+		if err != nil {
+			state.failed = true
+		}
+	}()
+
+	if state.aggregator == nil {
+		newAggregator := cgo.Handle(uintptr(C.sqlite3_user_data(ctx))).Value().(func() Aggregator)
+		state.aggregator = newAggregator()
+	}
+	return method(state.aggregator)
+}
+
+// goAggregateStep is the C entry point of the steps of the aggregates
+// registered with CreateAggregate, it adds a row to the aggregator of the
+// group.
+//
+//export goAggregateStep
+func goAggregateStep(ctx *C.sqlite3_context, argc C.int, argv **C.sqlite3_value) {
+	state := aggregateStateOf(ctx, true)
+	if state == nil {
+		C.sqlite3_result_error_nomem(ctx)
+		return
+	}
+	if state.failed {
+		return
+	}
+
+	args := functionArgs(argc, argv)
+	err := state.call(ctx, func(aggregator Aggregator) error {
+		return aggregator.Step(args)
+	})
+	if err != nil {
+		functionResult(ctx, nil, err)
+	}
+}
+
+// goAggregateInverse is the C entry point of the inverse steps of the
+// window aggregates registered with CreateAggregate, it removes a row from
+// the aggregator of the group.
+//
+//export goAggregateInverse
+func goAggregateInverse(ctx *C.sqlite3_context, argc C.int, argv **C.sqlite3_value) {
+	state := aggregateStateOf(ctx, true)
+	if state == nil {
+		C.sqlite3_result_error_nomem(ctx)
+		return
+	}
+	if state.failed {
+		return
+	}
+
+	args := functionArgs(argc, argv)
+	err := state.call(ctx, func(aggregator Aggregator) error {
+		return aggregator.(WindowAggregator).Inverse(args)
+	})
+	if err != nil {
+		functionResult(ctx, nil, err)
+	}
+}
+
+// goAggregateValue is the C entry point of the current values of the window
+// aggregates registered with CreateAggregate.
+//
+//export goAggregateValue
+func goAggregateValue(ctx *C.sqlite3_context) {
+	state := aggregateStateOf(ctx, true)
+	if state == nil {
+		C.sqlite3_result_error_nomem(ctx)
+		return
+	}
+	if state.failed {
+		return
+	}
+
+	var result any
+	err := state.call(ctx, func(aggregator Aggregator) (err error) {
+		result, err = aggregator.(WindowAggregator).Value()
+		return err
+	})
+	functionResult(ctx, result, err)
+}
+
+// goAggregateFinal is the C entry point of the results of the aggregates
+// registered with CreateAggregate. SQLite also calls it to release the
+// state of the groups when a statement is aborted.
+//
+//export goAggregateFinal
+func goAggregateFinal(ctx *C.sqlite3_context) {
+	// The groups without rows get a new aggregator.
+	state := aggregateStateOf(ctx, false)
+	if state == nil {
+		state = &aggregateState{}
+	}
+	if state.failed {
+		return
+	}
+
+	var result any
+	err := state.call(ctx, func(aggregator Aggregator) (err error) {
+		result, err = aggregator.Final()
+		return err
+	})
+	functionResult(ctx, result, err)
+}
+
+// goFunctionDestroy releases the Go function of a function registered with
+// CreateFunction or CreateAggregate, once SQLite drops it.
+//
+//export goFunctionDestroy
+func goFunctionDestroy(handle unsafe.Pointer) {
+	cgo.Handle(uintptr(handle)).Delete()
+}
@@ -0,0 +1,357 @@
+package sqlitec
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFunction(t *testing.T) {
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	t.Run("REGEXP", func(t *testing.T) {
+		// X REGEXP Y calls regexp(Y, X).
+		err := conn.CreateFunction("regexp", 2, true, func(args []any) (any, error) {
+			pattern, _ := args[0].(string)
+			value, _ := args[1].(string)
+			return regexp.MatchString(pattern, value)
+		})
+		require.NoError(t, err)
+
+		_, err = conn.Exec(`
+			CREATE TABLE t (col TEXT);
+			INSERT INTO t (col) VALUES ('apple'), ('banana'), ('avocado'), (NULL);
+		`)
+		require.NoError(t, err)
+
+		res, err := conn.Query("SELECT col FROM t WHERE col REGEXP ? ORDER BY col", []QueryParam{{Value: "^a"}})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"apple"}, {"avocado"}}, res.Rows)
+
+		_, err = conn.Query("SELECT col FROM t WHERE col REGEXP ?", []QueryParam{{Value: "("}})
+		assert.ErrorContains(t, err, "error parsing regexp")
+	})
+
+	t.Run("Argument and result types", func(t *testing.T) {
+		var got []any
+		err := conn.CreateFunction("echo", -1, false, func(args []any) (any, error) {
+			got = args
+			return args[0], nil
+		})
+		require.NoError(t, err)
+
+		_, err = conn.Query("SELECT echo(1, 1.5, 'text', x'0102', NULL, 9223372036854775807)", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []any{int64(1), 1.5, "text", []byte{1, 2}, nil, int64(9223372036854775807)}, got)
+
+		for _, tt := range []struct {
+			query string
+			want  any
+		}{
+			{query: "SELECT echo(42)", want: 42},
+			{query: "SELECT echo(0.25)", want: 0.25},
+			{query: "SELECT echo('héllo')", want: "héllo"},
+			{query: "SELECT echo(x'00ff')", want: []byte{0x00, 0xff}},
+			{query: "SELECT echo(NULL)", want: nil},
+		} {
+			res, err := conn.Query(tt.query, nil)
+			require.NoError(t, err, tt.query)
+			assert.Equal(t, tt.want, res.Rows[0][0], tt.query)
+		}
+	})
+
+	t.Run("Number of arguments", func(t *testing.T) {
+		err := conn.CreateFunction("one", 0, true, func(args []any) (any, error) { return 1, nil })
+		require.NoError(t, err)
+
+		_, err = conn.Query("SELECT one(1)", nil)
+		assert.ErrorContains(t, err, "wrong number of arguments to function one()")
+	})
+
+	t.Run("Errors and panics fail the query", func(t *testing.T) {
+		require.NoError(t, conn.CreateFunction("fail", 0, false, func(args []any) (any, error) {
+			return nil, errors.New("something went wrong")
+		}))
+		require.NoError(t, conn.CreateFunction("explode", 0, false, func(args []any) (any, error) {
+			panic("boom")
+		}))
+		require.NoError(t, conn.CreateFunction("channel", 0, false, func(args []any) (any, error) {
+			return make(chan int), nil
+		}))
+
+		_, err := conn.Query("SELECT fail()", nil)
+		assert.ErrorContains(t, err, "something went wrong")
+		_, err = conn.Query("SELECT explode()", nil)
+		assert.ErrorContains(t, err, "function panicked: boom")
+		_, err = conn.Query("SELECT channel()", nil)
+		assert.ErrorContains(t, err, "unsupported function result chan int type")
+	})
+
+	t.Run("Replaces the function", func(t *testing.T) {
+		for _, result := range []string{"first", "second"} {
+			require.NoError(t, conn.CreateFunction("version", 0, true, func(args []any) (any, error) {
+				return result, nil
+			}))
+		}
+
+		res, err := conn.Query("SELECT version()", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "second", res.Rows[0][0])
+	})
+
+	t.Run("Invalid function", func(t *testing.T) {
+		noop := func(args []any) (any, error) { return nil, nil }
+		assert.ErrorContains(t, conn.CreateFunction("noop", -2, true, noop), "failed to create function \"noop\"")
+		assert.ErrorContains(t, conn.CreateFunction("noop", 0, true, nil), "the function is nil")
+
+		closed, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, closed.Close())
+		assert.ErrorContains(t, closed.CreateFunction("noop", 0, true, noop), "database connection is nil")
+	})
+}
+
+// median is an aggregator returning the median of the numbers of a group,
+// NULL for a group without numbers.
+type median struct {
+	values []float64
+}
+
+func (m *median) Step(args []any) error {
+	switch v := args[0].(type) {
+	case int64:
+		m.values = append(m.values, float64(v))
+	case float64:
+		m.values = append(m.values, v)
+	case nil:
+	default:
+		return fmt.Errorf("median of a %T", v)
+	}
+	return nil
+}
+
+func (m *median) Final() (any, error) {
+	if len(m.values) == 0 {
+		return nil, nil
+	}
+	slices.Sort(m.values)
+	middle := len(m.values) / 2
+	if len(m.values)%2 == 0 {
+		return (m.values[middle-1] + m.values[middle]) / 2, nil
+	}
+	return m.values[middle], nil
+}
+
+// failingAggregator fails or panics in the given method.
+type failingAggregator struct {
+	method string
+	panics bool
+}
+
+func (f failingAggregator) fail() error {
+	if f.panics {
+		panic(f.method + " exploded")
+	}
+	return errors.New(f.method + " failed")
+}
+
+func (f failingAggregator) Step(args []any) error {
+	if f.method == "Step" {
+		return f.fail()
+	}
+	return nil
+}
+
+func (f failingAggregator) Final() (any, error) {
+	if f.method == "Final" {
+		return nil, f.fail()
+	}
+	return 0, nil
+}
+
+func TestCreateAggregate(t *testing.T) {
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	var created atomic.Int64
+	require.NoError(t, conn.CreateAggregate("median", 1, func() Aggregator {
+		created.Add(1)
+		return &median{}
+	}))
+
+	_, err = conn.Exec(`
+		CREATE TABLE scores (player TEXT, score REAL);
+		INSERT INTO scores (player, score) VALUES
+			('alice', 3), ('alice', 1), ('alice', 2),
+			('bob', 10), ('bob', 4), ('bob', 8), ('bob', 6),
+			('carol', NULL),
+			('dave', 7);
+	`)
+	require.NoError(t, err)
+
+	t.Run("Whole table", func(t *testing.T) {
+		res, err := conn.Query("SELECT median(score) FROM scores", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 5.0, res.Rows[0][0])
+	})
+
+	t.Run("GROUP BY", func(t *testing.T) {
+		created.Store(0)
+		res, err := conn.Query("SELECT player, median(score) FROM scores GROUP BY player ORDER BY player", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"alice", 2.0}, {"bob", 7.0}, {"carol", nil}, {"dave", 7.0}}, res.Rows)
+		assert.EqualValues(t, 4, created.Load(), "one aggregator for each group")
+	})
+
+	t.Run("No rows", func(t *testing.T) {
+		res, err := conn.Query("SELECT median(score) FROM scores WHERE player = 'nobody'", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{nil}}, res.Rows)
+	})
+
+	t.Run("Several aggregates in a statement", func(t *testing.T) {
+		res, err := conn.Query(`
+			SELECT median(score), median(score * 2), median(length(player))
+			FROM scores WHERE score IS NOT NULL
+		`, nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{5.0, 10.0, 3.5}}, res.Rows)
+	})
+
+	t.Run("Errors and panics fail the query", func(t *testing.T) {
+		res, err := conn.Query("SELECT median(player) FROM scores", nil)
+		assert.Nil(t, res)
+		assert.ErrorContains(t, err, "median of a string")
+
+		for _, f := range []failingAggregator{
+			{method: "Step"}, {method: "Step", panics: true},
+			{method: "Final"}, {method: "Final", panics: true},
+		} {
+			require.NoError(t, conn.CreateAggregate("failing", 1, func() Aggregator { return f }))
+			_, err := conn.Query("SELECT player, failing(score) FROM scores GROUP BY player", nil)
+			if f.panics {
+				assert.ErrorContains(t, err, "aggregate panicked: "+f.method+" exploded")
+			} else {
+				assert.ErrorContains(t, err, f.method+" failed")
+			}
+		}
+	})
+
+	t.Run("Invalid aggregate", func(t *testing.T) {
+		newMedian := func() Aggregator { return &median{} }
+		assert.ErrorContains(t, conn.CreateAggregate("median", -2, newMedian), "failed to create aggregate \"median\"")
+		assert.ErrorContains(t, conn.CreateAggregate("median", 1, nil), "the aggregator constructor is nil")
+
+		closed, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, closed.Close())
+		assert.ErrorContains(t, closed.CreateAggregate("median", 1, newMedian), "database connection is nil")
+	})
+}
+
+// movingAverage is a window aggregator returning the average of the numbers
+// of the frame.
+type movingAverage struct {
+	sum   float64
+	count int
+}
+
+func (m *movingAverage) Step(args []any) error {
+	m.sum += float64(args[0].(int64))
+	m.count++
+	return nil
+}
+
+func (m *movingAverage) Inverse(args []any) error {
+	m.sum -= float64(args[0].(int64))
+	m.count--
+	return nil
+}
+
+func (m *movingAverage) Value() (any, error) {
+	if m.count == 0 {
+		return nil, nil
+	}
+	return m.sum / float64(m.count), nil
+}
+
+func (m *movingAverage) Final() (any, error) {
+	return m.Value()
+}
+
+func TestCreateWindowAggregate(t *testing.T) {
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	require.NoError(t, conn.CreateAggregate("moving_avg", 1, func() Aggregator { return &movingAverage{} }))
+	require.NoError(t, conn.CreateAggregate("median", 1, func() Aggregator { return &median{} }))
+
+	values := map[string][]int64{
+		"a": {4, 8, 15, 16, 23, 42, 7, 1},
+		"b": {100, -50, 25},
+	}
+	_, err = conn.Query("CREATE TABLE series (id INTEGER PRIMARY KEY, name TEXT, value INTEGER)", nil)
+	require.NoError(t, err)
+	for _, name := range []string{"a", "b"} {
+		for _, value := range values[name] {
+			_, err = conn.Query("INSERT INTO series (name, value) VALUES (?, ?)", []QueryParam{{Value: name}, {Value: value}})
+			require.NoError(t, err)
+		}
+	}
+
+	// reference returns the moving averages of the values over a frame of
+	// the preceding rows and the current one.
+	reference := func(values []int64, preceding int) []any {
+		averages := []any{}
+		for i := range values {
+			window := values[max(0, i-preceding) : i+1]
+			sum := int64(0)
+			for _, value := range window {
+				sum += value
+			}
+			averages = append(averages, float64(sum)/float64(len(window)))
+		}
+		return averages
+	}
+
+	t.Run("Sliding frame", func(t *testing.T) {
+		for _, preceding := range []int{0, 2, 5} {
+			res, err := conn.Query(fmt.Sprintf(`
+				SELECT name, moving_avg(value) OVER (
+					PARTITION BY name ORDER BY id ROWS BETWEEN %d PRECEDING AND CURRENT ROW
+				)
+				FROM series ORDER BY id
+			`, preceding), nil)
+			require.NoError(t, err)
+
+			got := map[string][]any{}
+			for _, row := range res.Rows {
+				got[row[0].(string)] = append(got[row[0].(string)], row[1])
+			}
+			for name := range values {
+				assert.InDeltaSlice(t, reference(values[name], preceding), got[name], 1e-9, "%s, %d preceding", name, preceding)
+			}
+		}
+	})
+
+	t.Run("Still an aggregate", func(t *testing.T) {
+		res, err := conn.Query("SELECT name, moving_avg(value) FROM series GROUP BY name ORDER BY name", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"a", 14.5}, {"b", 25.0}}, res.Rows)
+	})
+
+	t.Run("Aggregate without Inverse", func(t *testing.T) {
+		_, err := conn.Query("SELECT median(value) OVER (ORDER BY id ROWS 1 PRECEDING) FROM series", nil)
+		assert.ErrorContains(t, err, "median() may not be used as a window function")
+	})
+}
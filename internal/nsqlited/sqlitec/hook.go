@@ -0,0 +1,244 @@
+package sqlitec
+
+// #include "sqlite3.c"
+// #include <stdint.h>
+//
+// // Implemented in Go in hook_export.go.
+// extern void goUpdateHook(void *handle, int op, char *dbName, char *table, sqlite3_int64 rowid);
+// extern int goCommitHook(void *handle);
+// extern void goRollbackHook(void *handle);
+// extern int goProgressHandler(void *handle);
+// extern int goBusyHandler(void *handle, int attempts);
+//
+// // The exported Go functions can not have const parameters, so the hook
+// // goes through this wrapper.
+// static void cust_update_hook(void *handle, int op, const char *dbName, const char *table, sqlite3_int64 rowid) {
+//   goUpdateHook(handle, op, (char *)dbName, (char *)table, rowid);
+// }
+//
+// // The handle of the Go function is passed as the argument of the hook, a
+// // zero handle removes the hook.
+// static void cust_sqlite3_update_hook(sqlite3 *db, uintptr_t handle) {
+//   if (handle == 0) {
+//     sqlite3_update_hook(db, 0, 0);
+//     return;
+//   }
+//   sqlite3_update_hook(db, cust_update_hook, (void *)handle);
+// }
+//
+// // Same as cust_sqlite3_update_hook, for the commit hook.
+// static void cust_sqlite3_commit_hook(sqlite3 *db, uintptr_t handle) {
+//   if (handle == 0) {
+//     sqlite3_commit_hook(db, 0, 0);
+//     return;
+//   }
+//   sqlite3_commit_hook(db, goCommitHook, (void *)handle);
+// }
+//
+// // Same as cust_sqlite3_update_hook, for the rollback hook.
+// static void cust_sqlite3_rollback_hook(sqlite3 *db, uintptr_t handle) {
+//   if (handle == 0) {
+//     sqlite3_rollback_hook(db, 0, 0);
+//     return;
+//   }
+//   sqlite3_rollback_hook(db, goRollbackHook, (void *)handle);
+// }
+//
+// // Same as cust_sqlite3_update_hook, for the progress handler called every
+// // nOps virtual machine instructions.
+// static void cust_sqlite3_progress_handler(sqlite3 *db, int nOps, uintptr_t handle) {
+//   if (handle == 0) {
+//     sqlite3_progress_handler(db, 0, 0, 0);
+//     return;
+//   }
+//   sqlite3_progress_handler(db, nOps, goProgressHandler, (void *)handle);
+// }
+//
+// // Same as cust_sqlite3_update_hook, for the busy handler.
+// static int cust_sqlite3_busy_handler(sqlite3 *db, uintptr_t handle) {
+//   if (handle == 0) {
+//     return sqlite3_busy_handler(db, 0, 0);
+//   }
+//   return sqlite3_busy_handler(db, goBusyHandler, (void *)handle);
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime/cgo"
+)
+
+// Op is the kind of change of a row reported to the update hook.
+type Op int
+
+const (
+	OpInsert = Op(C.SQLITE_INSERT)
+	OpUpdate = Op(C.SQLITE_UPDATE)
+	OpDelete = Op(C.SQLITE_DELETE)
+)
+
+// String returns the SQL statement of the change, like "INSERT".
+func (op Op) String() string {
+	switch op {
+	case OpInsert:
+		return "INSERT"
+	case OpUpdate:
+		return "UPDATE"
+	case OpDelete:
+		return "DELETE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// UpdateHook is called for each row inserted, updated or deleted through a
+// connection, see SetUpdateHook.
+type UpdateHook func(op Op, dbName string, table string, rowid int64)
+
+// SetUpdateHook sets the function called for each row inserted, updated or
+// deleted through the connection, replacing the previous one. A nil fn
+// removes the hook.
+//
+// The hook runs while the statement that changed the row is running, so it
+// is never called concurrently with itself, and it must not use the
+// connection. It is called as soon as the row changes, even if the
+// transaction that changed it is rolled back afterwards. The changes of the
+// WITHOUT ROWID tables, and the rows deleted by the truncate optimization
+// of a DELETE without WHERE clause, are not reported.
+//
+// https://www.sqlite.org/c3ref/update_hook.html
+func (conn *Conn) SetUpdateHook(fn UpdateHook) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set update hook: database connection is nil")
+	}
+
+	replaceHook(&conn.updateHook, fn, fn != nil, func(handle C.uintptr_t) {
+		C.cust_sqlite3_update_hook(conn.cDB, handle)
+	})
+	return nil
+}
+
+// CommitHook is called when a transaction of a connection is about to be
+// committed, see SetCommitHook.
+type CommitHook func() bool
+
+// SetCommitHook sets the function called when a transaction of the
+// connection is about to be committed, explicit or implicit, replacing the
+// previous one. A nil fn removes the hook.
+//
+// If fn returns false the commit is turned into a rollback, and the
+// statement that committed fails with SQLITE_CONSTRAINT. Like the update
+// hook, it is never called concurrently with itself and it must not use the
+// connection.
+//
+// https://www.sqlite.org/c3ref/commit_hook.html
+func (conn *Conn) SetCommitHook(fn CommitHook) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set commit hook: database connection is nil")
+	}
+
+	replaceHook(&conn.commitHook, fn, fn != nil, func(handle C.uintptr_t) {
+		C.cust_sqlite3_commit_hook(conn.cDB, handle)
+	})
+	return nil
+}
+
+// RollbackHook is called when a transaction of a connection is rolled back,
+// see SetRollbackHook.
+type RollbackHook func()
+
+// SetRollbackHook sets the function called when a transaction of the
+// connection is rolled back, replacing the previous one. A nil fn removes
+// the hook.
+//
+// It is called for the explicit rollbacks, the rollbacks caused by an
+// error and the commits refused by the commit hook, but not when the
+// connection is closed with a transaction open. Like the update hook, it is
+// never called concurrently with itself and it must not use the connection.
+//
+// https://www.sqlite.org/c3ref/commit_hook.html
+func (conn *Conn) SetRollbackHook(fn RollbackHook) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set rollback hook: database connection is nil")
+	}
+
+	replaceHook(&conn.rollbackHook, fn, fn != nil, func(handle C.uintptr_t) {
+		C.cust_sqlite3_rollback_hook(conn.cDB, handle)
+	})
+	return nil
+}
+
+// ProgressHandler is called periodically while the statements of a
+// connection run, see SetProgressHandler.
+type ProgressHandler func() bool
+
+// SetProgressHandler sets the function called every everyNOps virtual
+// machine instructions while the statements of the connection run,
+// replacing the previous one. A nil fn, or an everyNOps lower than one,
+// removes the handler.
+//
+// If fn returns false the running statement is aborted and fails with
+// SQLITE_INTERRUPT, which allows to enforce time budgets without another
+// goroutine. Like the update hook, it is never called concurrently with
+// itself and it must not use the connection.
+//
+// https://www.sqlite.org/c3ref/progress_handler.html
+func (conn *Conn) SetProgressHandler(everyNOps int, fn ProgressHandler) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set progress handler: database connection is nil")
+	}
+
+	set := fn != nil && everyNOps > 0
+	nOps := C.int(min(everyNOps, math.MaxInt32))
+	replaceHook(&conn.progressHandler, fn, set, func(handle C.uintptr_t) {
+		C.cust_sqlite3_progress_handler(conn.cDB, nOps, handle)
+	})
+	return nil
+}
+
+// BusyHandler is called when a statement of a connection finds the database
+// locked by another connection, see SetBusyHandler.
+type BusyHandler func(attempts int) bool
+
+// SetBusyHandler sets the function called when a statement of the connection
+// finds the database locked by another connection, replacing the previous
+// one and the busy timeout. A nil fn removes the handler, then the
+// statements fail right away with SQLITE_BUSY.
+//
+// attempts is the number of times fn was already called for the same lock,
+// zero the first time. If fn returns true the statement tries to take the
+// lock again, usually after fn waited for some time, and if it returns false
+// the statement fails with SQLITE_BUSY. Like the update hook, it is never
+// called concurrently with itself and it must not use the connection.
+//
+// https://www.sqlite.org/c3ref/busy_handler.html
+func (conn *Conn) SetBusyHandler(fn BusyHandler) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set busy handler: database connection is nil")
+	}
+
+	var resCode C.int
+	replaceHook(&conn.busyHandler, fn, fn != nil, func(handle C.uintptr_t) {
+		resCode = C.cust_sqlite3_busy_handler(conn.cDB, handle)
+	})
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to set busy handler: %s: %s", getResCodeStr(resCode), conn.getLastError())
+	}
+	return nil
+}
+
+// replaceHook replaces the hook whose handle is kept in slot with fn, none
+// if set is false. install registers the new handle in SQLite before the
+// previous one is released.
+func replaceHook(slot *cgo.Handle, fn any, set bool, install func(handle C.uintptr_t)) {
+	previous := *slot
+	*slot = 0
+	if set {
+		*slot = cgo.NewHandle(fn)
+	}
+	install(C.uintptr_t(*slot))
+	if previous != 0 {
+		previous.Delete()
+	}
+}
@@ -0,0 +1,117 @@
+package sqlitec
+
+// The preamble of a file with exported functions can only have declarations,
+// so the callbacks of the hooks are kept apart from hook.go.
+
+// #include "sqlite3-v3.48.0.h"
+import "C"
+import (
+	"runtime/cgo"
+	"unsafe"
+)
+
+// The Go hooks must not unwind through the C frames of SQLite, so a panic
+// is recovered in each entry point and turned into its safe result.
+
+// goUpdateHook is the C entry point of the hooks set with SetUpdateHook, a
+// panic is ignored.
+//
+//export goUpdateHook
+func goUpdateHook(handle unsafe.Pointer, op C.int, dbName *C.char, table *C.char, rowid C.sqlite3_int64) {
+	defer func() { _ = recover() }()
+	fn := cgo.Handle(uintptr(handle)).Value().(UpdateHook)
+	fn(Op(op), C.GoString(dbName), C.GoString(table), int64(rowid))
+}
+
+// goCommitHook is the C entry point of the hooks set with SetCommitHook, a
+// non-zero result turns the commit into a rollback, as a panic does.
+//
+//export goCommitHook
+func goCommitHook(handle unsafe.Pointer) (result C.int) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = 1
+		}
+	}()
+	fn := cgo.Handle(uintptr(handle)).Value().(CommitHook)
+	if fn() {
+		return 0
+	}
+	return 1
+}
+
+// goRollbackHook is the C entry point of the hooks set with SetRollbackHook,
+// a panic is ignored.
+//
+//export goRollbackHook
+func goRollbackHook(handle unsafe.Pointer) {
+	defer func() { _ = recover() }()
+	cgo.Handle(uintptr(handle)).Value().(RollbackHook)()
+}
+
+// goProgressHandler is the C entry point of the handlers set with
+// SetProgressHandler, a non-zero result aborts the running statement, as a
+// panic does.
+//
+//export goProgressHandler
+func goProgressHandler(handle unsafe.Pointer) (result C.int) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = 1
+		}
+	}()
+	fn := cgo.Handle(uintptr(handle)).Value().(ProgressHandler)
+	if fn() {
+		return 0
+	}
+	return 1
+}
+
+// goBusyHandler is the C entry point of the handlers set with
+// SetBusyHandler, a zero result makes the statement fail with SQLITE_BUSY,
+// as a panic does.
+//
+//export goBusyHandler
+func goBusyHandler(handle unsafe.Pointer, attempts C.int) (result C.int) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = 0
+		}
+	}()
+	fn := cgo.Handle(uintptr(handle)).Value().(BusyHandler)
+	if fn(int(attempts)) {
+		return 1
+	}
+	return 0
+}
+
+// goAuthorizer is the C entry point of the authorizers set with
+// SetAuthorizer, a panic denies the action.
+//
+//export goAuthorizer
+func goAuthorizer(handle unsafe.Pointer, action C.int, arg1, arg2, dbName, trigger *C.char) (result C.int) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = C.SQLITE_DENY
+		}
+	}()
+	fn := cgo.Handle(uintptr(handle)).Value().(Authorizer)
+	return C.int(fn(AuthAction(action), C.GoString(arg1), C.GoString(arg2), C.GoString(dbName), C.GoString(trigger)))
+}
+
+// goWalHook is the C entry point of the hooks set with SetWalHook, a panic
+// is reported as an error.
+//
+//export goWalHook
+func goWalHook(handle unsafe.Pointer, dbName *C.char, pages C.int) (result C.int) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = C.SQLITE_ERROR
+		}
+	}()
+	fn := cgo.Handle(uintptr(handle)).Value().(WalHook)
+	if err := fn(C.GoString(dbName), int(pages)); err != nil {
+		return C.SQLITE_ERROR
+	}
+	return C.SQLITE_OK
+}
@@ -0,0 +1,409 @@
+package sqlitec
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetUpdateHook(t *testing.T) {
+	type event struct {
+		op     Op
+		dbName string
+		table  string
+		rowid  int64
+	}
+
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = conn.Exec("CREATE TABLE users (name TEXT); CREATE TABLE logs (msg TEXT)")
+	require.NoError(t, err)
+
+	events := []event{}
+	require.NoError(t, conn.SetUpdateHook(func(op Op, dbName, table string, rowid int64) {
+		events = append(events, event{op, dbName, table, rowid})
+	}))
+
+	t.Run("Mixed writes", func(t *testing.T) {
+		events = events[:0]
+		_, err := conn.Exec(`
+			INSERT INTO users (name) VALUES ('alice'), ('bob');
+			UPDATE users SET name = 'carol' WHERE name = 'bob';
+			INSERT INTO logs (msg) VALUES ('renamed');
+			DELETE FROM users WHERE name = 'alice';
+			SELECT * FROM users;
+		`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []event{
+			{OpInsert, "main", "users", 1},
+			{OpInsert, "main", "users", 2},
+			{OpUpdate, "main", "users", 2},
+			{OpInsert, "main", "logs", 1},
+			{OpDelete, "main", "users", 1},
+		}, events)
+	})
+
+	t.Run("Rolled back transaction", func(t *testing.T) {
+		events = events[:0]
+		_, err := conn.Exec(`
+			BEGIN;
+			INSERT INTO users (name) VALUES ('dave');
+			ROLLBACK;
+		`)
+		require.NoError(t, err)
+
+		assert.Equal(t, []event{{OpInsert, "main", "users", 3}}, events, "the hook fires before the rollback")
+		res, err := conn.Query("SELECT count(*) FROM users WHERE name = 'dave'", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Rows[0][0])
+	})
+
+	t.Run("Replaced and removed", func(t *testing.T) {
+		events = events[:0]
+		replaced := 0
+		require.NoError(t, conn.SetUpdateHook(func(op Op, dbName, table string, rowid int64) { replaced++ }))
+		_, err := conn.Query("INSERT INTO logs (msg) VALUES ('replaced')", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, replaced)
+		assert.Empty(t, events)
+
+		require.NoError(t, conn.SetUpdateHook(nil))
+		assert.Zero(t, conn.updateHook)
+		_, err = conn.Query("INSERT INTO logs (msg) VALUES ('removed')", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, replaced)
+	})
+
+	t.Run("Released on close", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.SetUpdateHook(func(op Op, dbName, table string, rowid int64) {}))
+		assert.NotZero(t, conn.updateHook)
+
+		require.NoError(t, conn.Close())
+		assert.Zero(t, conn.updateHook)
+		assert.ErrorContains(t, conn.SetUpdateHook(nil), "database connection is nil")
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		require.NoError(t, conn.SetUpdateHook(func(op Op, dbName, table string, rowid int64) { panic("boom") }))
+
+		_, err = conn.Exec("CREATE TABLE users (name TEXT); INSERT INTO users (name) VALUES ('alice')")
+		require.NoError(t, err)
+		res, err := conn.Query("SELECT count(*) FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Rows[0][0], "the write is kept")
+	})
+
+	t.Run("Op names", func(t *testing.T) {
+		assert.Equal(t, "INSERT", OpInsert.String())
+		assert.Equal(t, "UPDATE", OpUpdate.String())
+		assert.Equal(t, "DELETE", OpDelete.String())
+		assert.Equal(t, "UNKNOWN", Op(0).String())
+	})
+}
+
+func TestCommitAndRollbackHooks(t *testing.T) {
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = conn.Query("CREATE TABLE users (name TEXT)", nil)
+	require.NoError(t, err)
+
+	commits, rollbacks, allowCommits := 0, 0, true
+	require.NoError(t, conn.SetCommitHook(func() bool {
+		commits++
+		return allowCommits
+	}))
+	require.NoError(t, conn.SetRollbackHook(func() { rollbacks++ }))
+	reset := func() { commits, rollbacks, allowCommits = 0, 0, true }
+
+	t.Run("Implicit transactions", func(t *testing.T) {
+		reset()
+		for _, name := range []string{"alice", "bob", "carol"} {
+			_, err := conn.Query("INSERT INTO users (name) VALUES (?)", []QueryParam{{Value: name}})
+			require.NoError(t, err)
+		}
+		_, err := conn.Query("SELECT * FROM users", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, commits, "one commit for each write")
+		assert.Equal(t, 0, rollbacks)
+	})
+
+	t.Run("Prepared statements", func(t *testing.T) {
+		reset()
+		for range 2 {
+			stmt, err := conn.Prepare("UPDATE users SET name = upper(name)")
+			require.NoError(t, err)
+			_, err = stmt.Step()
+			require.NoError(t, err)
+			require.NoError(t, stmt.Finalize())
+		}
+		assert.Equal(t, 2, commits)
+	})
+
+	t.Run("Explicit transactions", func(t *testing.T) {
+		reset()
+		_, err := conn.Exec(`
+			BEGIN; INSERT INTO users (name) VALUES ('dave'); INSERT INTO users (name) VALUES ('erin'); COMMIT;
+			BEGIN; INSERT INTO users (name) VALUES ('frank'); ROLLBACK;
+		`)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, commits)
+		assert.Equal(t, 1, rollbacks)
+	})
+
+	t.Run("Vetoed commit", func(t *testing.T) {
+		reset()
+		allowCommits = false
+		_, err := conn.Query("INSERT INTO users (name) VALUES ('mallory')", nil)
+		assert.ErrorContains(t, err, "19: constraint failed")
+		assert.Equal(t, 1, commits)
+		assert.Equal(t, 1, rollbacks, "the vetoed commit is rolled back")
+
+		_, err = conn.Exec("BEGIN; DELETE FROM users; COMMIT;")
+		assert.ErrorContains(t, err, "19: constraint failed")
+		assert.True(t, conn.AutoCommit(), "the transaction is over")
+
+		allowCommits = true
+		res, err := conn.Query("SELECT count(*) FROM users WHERE name = 'mallory'", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Rows[0][0], "the vetoed insert is gone")
+		res, err = conn.Query("SELECT count(*) FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 5, res.Rows[0][0], "the vetoed delete is gone")
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		reset()
+		require.NoError(t, conn.SetCommitHook(nil))
+		require.NoError(t, conn.SetRollbackHook(nil))
+		_, err := conn.Exec("BEGIN; INSERT INTO users (name) VALUES ('gina'); ROLLBACK;")
+		require.NoError(t, err)
+		_, err = conn.Query("INSERT INTO users (name) VALUES ('gina')", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, commits)
+		assert.Equal(t, 0, rollbacks)
+		assert.Zero(t, conn.commitHook)
+		assert.Zero(t, conn.rollbackHook)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		_, err = conn.Query("CREATE TABLE users (name TEXT)", nil)
+		require.NoError(t, err)
+		require.NoError(t, conn.SetCommitHook(func() bool { panic("boom") }))
+		require.NoError(t, conn.SetRollbackHook(func() { panic("boom") }))
+
+		_, err = conn.Query("INSERT INTO users (name) VALUES ('alice')", nil)
+		assert.ErrorContains(t, err, "19: constraint failed", "the commit is rolled back")
+		_, err = conn.Exec("BEGIN; INSERT INTO users (name) VALUES ('bob'); ROLLBACK;")
+		require.NoError(t, err)
+
+		require.NoError(t, conn.SetCommitHook(nil))
+		res, err := conn.Query("SELECT count(*) FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Rows[0][0])
+	})
+
+	t.Run("Released on close", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.SetCommitHook(func() bool { return true }))
+		require.NoError(t, conn.SetRollbackHook(func() {}))
+
+		require.NoError(t, conn.Close())
+		assert.Zero(t, conn.commitHook)
+		assert.Zero(t, conn.rollbackHook)
+		assert.ErrorContains(t, conn.SetCommitHook(nil), "database connection is nil")
+		assert.ErrorContains(t, conn.SetRollbackHook(nil), "database connection is nil")
+	})
+}
+
+func TestSetProgressHandler(t *testing.T) {
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	_, err = conn.Exec(`
+		CREATE TABLE items (n INTEGER);
+		WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 10000)
+		INSERT INTO items (n) SELECT x FROM c;
+	`)
+	require.NoError(t, err)
+
+	t.Run("Deadline", func(t *testing.T) {
+		deadline := time.Now().Add(50 * time.Millisecond)
+		require.NoError(t, conn.SetProgressHandler(1000, func() bool {
+			return time.Now().Before(deadline)
+		}))
+		defer conn.SetProgressHandler(0, nil)
+
+		start := time.Now()
+		_, err := conn.Query(`
+			WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c)
+			SELECT count(*) FROM c
+		`, nil)
+		assert.ErrorContains(t, err, "9: interrupted")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Full table scan", func(t *testing.T) {
+		calls := 0
+		require.NoError(t, conn.SetProgressHandler(100, func() bool {
+			calls++
+			return true
+		}))
+		res, err := conn.Query("SELECT sum(n) FROM items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{50005000}}, res.Rows)
+		assert.Greater(t, calls, 100, "every row takes several instructions")
+
+		require.NoError(t, conn.SetProgressHandler(0, nil))
+		assert.Zero(t, conn.progressHandler)
+		scanCalls := calls
+		_, err = conn.Query("SELECT sum(n) FROM items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, scanCalls, calls, "the handler is removed")
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		require.NoError(t, conn.SetProgressHandler(100, func() bool { panic("boom") }))
+		defer conn.SetProgressHandler(0, nil)
+
+		_, err := conn.Query("SELECT sum(n) FROM items", nil)
+		assert.ErrorContains(t, err, "9: interrupted")
+	})
+
+	t.Run("Not positive interval", func(t *testing.T) {
+		require.NoError(t, conn.SetProgressHandler(0, func() bool { return false }))
+		assert.Zero(t, conn.progressHandler)
+		_, err := conn.Query("SELECT sum(n) FROM items", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Released on close", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.SetProgressHandler(1, func() bool { return true }))
+		assert.NotZero(t, conn.progressHandler)
+
+		require.NoError(t, conn.Close())
+		assert.Zero(t, conn.progressHandler)
+		assert.ErrorContains(t, conn.SetProgressHandler(1, nil), "database connection is nil")
+	})
+}
+
+func TestSetBusyHandler(t *testing.T) {
+	// openConns opens two connections to the same file database, the first
+	// one holding an exclusive transaction.
+	openConns := func(t *testing.T) (*Conn, *Conn) {
+		path := filepath.Join(t.TempDir(), "test.sqlite")
+		first, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = first.Close() })
+		second, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = second.Close() })
+
+		_, err = first.Exec("CREATE TABLE test (val TEXT); BEGIN EXCLUSIVE;")
+		require.NoError(t, err)
+		return first, second
+	}
+
+	t.Run("Retries until the lock is released", func(t *testing.T) {
+		first, second := openConns(t)
+
+		attempts := []int{}
+		require.NoError(t, second.SetBusyHandler(func(attempt int) bool {
+			attempts = append(attempts, attempt)
+			if attempt == 3 {
+				_, err := first.Query("COMMIT", nil)
+				assert.NoError(t, err)
+			}
+			time.Sleep(time.Millisecond)
+			return true
+		}))
+
+		_, err := second.Query("INSERT INTO test (val) VALUES ('b')", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 1, 2, 3}, attempts)
+	})
+
+	t.Run("Gives up", func(t *testing.T) {
+		_, second := openConns(t)
+
+		calls := 0
+		require.NoError(t, second.SetBusyHandler(func(attempt int) bool {
+			calls++
+			return attempt < 2
+		}))
+
+		_, err := second.Query("INSERT INTO test (val) VALUES ('b')", nil)
+		assert.ErrorContains(t, err, "5: database is locked")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		_, second := openConns(t)
+
+		calls := 0
+		require.NoError(t, second.SetBusyHandler(func(attempt int) bool {
+			calls++
+			return false
+		}))
+		require.NoError(t, second.SetBusyHandler(nil))
+		assert.Zero(t, second.busyHandler)
+
+		_, err := second.Query("INSERT INTO test (val) VALUES ('b')", nil)
+		assert.ErrorContains(t, err, "5: database is locked")
+		assert.Zero(t, calls)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		_, second := openConns(t)
+		require.NoError(t, second.SetBusyHandler(func(attempt int) bool { panic("boom") }))
+
+		_, err := second.Query("INSERT INTO test (val) VALUES ('b')", nil)
+		assert.ErrorContains(t, err, "5: database is locked")
+	})
+
+	t.Run("Replaced by the busy timeout", func(t *testing.T) {
+		_, second := openConns(t)
+
+		calls := 0
+		require.NoError(t, second.SetBusyHandler(func(attempt int) bool {
+			calls++
+			return false
+		}))
+		require.NoError(t, second.BusyTimeout(10*time.Millisecond))
+		assert.Zero(t, second.busyHandler)
+
+		_, err := second.Query("INSERT INTO test (val) VALUES ('b')", nil)
+		assert.ErrorContains(t, err, "5: database is locked")
+		assert.Zero(t, calls)
+	})
+
+	t.Run("Released on close", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.SetBusyHandler(func(attempt int) bool { return false }))
+		assert.NotZero(t, conn.busyHandler)
+
+		require.NoError(t, conn.Close())
+		assert.Zero(t, conn.busyHandler)
+		assert.ErrorContains(t, conn.SetBusyHandler(nil), "database connection is nil")
+	})
+}
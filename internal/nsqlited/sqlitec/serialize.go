@@ -0,0 +1,104 @@
+package sqlitec
+
+// #include "sqlite3.c"
+//
+// // The serialize API is not declared when SQLite is built with
+// // SQLITE_OMIT_DESERIALIZE, so these wrappers report it with
+// // SQLITE_MISUSE instead of failing to compile.
+// static int cust_sqlite3_serialize(sqlite3 *db, unsigned char **out, sqlite3_int64 *size) {
+// #ifdef SQLITE_OMIT_DESERIALIZE
+//   return SQLITE_MISUSE;
+// #else
+//   *out = sqlite3_serialize(db, "main", size, 0);
+//   if (*out == 0 && *size != 0) {
+//     return SQLITE_NOMEM;
+//   }
+//   return SQLITE_OK;
+// #endif
+// }
+//
+// static int cust_sqlite3_deserialize(sqlite3 *db, unsigned char *data, sqlite3_int64 size) {
+// #ifdef SQLITE_OMIT_DESERIALIZE
+//   sqlite3_free(data);
+//   return SQLITE_MISUSE;
+// #else
+//   return sqlite3_deserialize(db, "main", data, size, size,
+//     SQLITE_DESERIALIZE_FREEONCLOSE | SQLITE_DESERIALIZE_RESIZEABLE);
+// #endif
+// }
+//
+// static int cust_sqlite3_has_deserialize(void) {
+// #ifdef SQLITE_OMIT_DESERIALIZE
+//   return 0;
+// #else
+//   return 1;
+// #endif
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ErrSerializeUnsupported is returned by Serialize and Deserialize when the
+// SQLite library is built with SQLITE_OMIT_DESERIALIZE.
+var ErrSerializeUnsupported = errors.New("serialize and deserialize are not supported, SQLite was built with SQLITE_OMIT_DESERIALIZE")
+
+// Serialize returns a copy of the main database of the connection, with the
+// same bytes as its database file.
+//
+// https://www.sqlite.org/c3ref/serialize.html
+func (conn *Conn) Serialize() ([]byte, error) {
+	if conn.cDB == nil {
+		return nil, errors.New("failed to serialize database: database connection is nil")
+	}
+	if C.cust_sqlite3_has_deserialize() == 0 {
+		return nil, ErrSerializeUnsupported
+	}
+
+	var cData *C.uchar
+	var size C.sqlite3_int64
+	resCode := C.cust_sqlite3_serialize(conn.cDB, &cData, &size)
+	if resCode != C.SQLITE_OK {
+		return nil, fmt.Errorf("failed to serialize database: %s", getResCodeStr(resCode))
+	}
+	// A database without pages is serialized without allocating a buffer.
+	if cData == nil {
+		return []byte{}, nil
+	}
+	defer C.sqlite3_free(unsafe.Pointer(cData))
+
+	return C.GoBytes(unsafe.Pointer(cData), C.int(size)), nil
+}
+
+// Deserialize replaces the main database of the connection with an in-memory
+// database holding a copy of data, as returned by Serialize. The changes made
+// afterwards stay in memory, they are never written to the original file.
+//
+// https://www.sqlite.org/c3ref/deserialize.html
+func (conn *Conn) Deserialize(data []byte) error {
+	if conn.cDB == nil {
+		return errors.New("failed to deserialize database: database connection is nil")
+	}
+	if C.cust_sqlite3_has_deserialize() == 0 {
+		return ErrSerializeUnsupported
+	}
+
+	// SQLite takes ownership of the buffer, it must be allocated with
+	// sqlite3_malloc64 so it can be resized and freed on close.
+	cData := (*C.uchar)(C.sqlite3_malloc64(C.sqlite3_uint64(max(len(data), 1))))
+	if cData == nil {
+		return errors.New("failed to deserialize database: out of memory")
+	}
+	if len(data) > 0 {
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(cData)), len(data)), data)
+	}
+
+	resCode := C.cust_sqlite3_deserialize(conn.cDB, cData, C.sqlite3_int64(len(data)))
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to deserialize database: %s: %s", getResCodeStr(resCode), conn.getLastError())
+	}
+
+	return nil
+}
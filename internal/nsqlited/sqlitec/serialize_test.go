@@ -0,0 +1,115 @@
+package sqlitec
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerialize(t *testing.T) {
+	const selectUsers = "SELECT id, name, avatar FROM users ORDER BY id"
+
+	// openSource opens a database at path with a users table.
+	openSource := func(t *testing.T, path string) *Conn {
+		conn, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		_, err = conn.Exec(`
+			CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, avatar BLOB);
+			CREATE INDEX users_name ON users (name);
+			INSERT INTO users (name, avatar) VALUES ('alice', x'01'), ('bob', NULL);
+		`)
+		require.NoError(t, err)
+		return conn
+	}
+
+	for _, path := range []string{":memory:", "file"} {
+		t.Run("Round trip from "+path, func(t *testing.T) {
+			if path == "file" {
+				path = filepath.Join(t.TempDir(), "test.sqlite")
+			}
+			source := openSource(t, path)
+			data, err := source.Serialize()
+			require.NoError(t, err)
+			assert.Equal(t, "SQLite format 3\x00", string(data[:16]))
+
+			dest, err := Open(":memory:")
+			require.NoError(t, err)
+			defer dest.Close()
+			require.NoError(t, dest.Deserialize(data))
+
+			want, err := source.Query(selectUsers, nil)
+			require.NoError(t, err)
+			got, err := dest.Query(selectUsers, nil)
+			require.NoError(t, err)
+			assert.Equal(t, want.Rows, got.Rows)
+
+			res, err := dest.Query("PRAGMA integrity_check", nil)
+			require.NoError(t, err)
+			assert.Equal(t, [][]any{{"ok"}}, res.Rows)
+		})
+	}
+
+	t.Run("Deserialized database is writable and detached", func(t *testing.T) {
+		source := openSource(t, ":memory:")
+		data, err := source.Serialize()
+		require.NoError(t, err)
+
+		dest, err := Open(":memory:")
+		require.NoError(t, err)
+		defer dest.Close()
+		require.NoError(t, dest.Deserialize(data))
+		data[100] ^= 0xff
+
+		for range 100 {
+			_, err = dest.Query("INSERT INTO users (name, avatar) VALUES ('carol', randomblob(1000))", nil)
+			require.NoError(t, err, "the buffer grows")
+		}
+		res, err := dest.Query("SELECT count(*) FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 102, res.Rows[0][0])
+
+		res, err = source.Query("SELECT count(*) FROM users", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, res.Rows[0][0], "the source is not modified")
+	})
+
+	t.Run("Empty database", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		data, err := conn.Serialize()
+		require.NoError(t, err)
+		require.NoError(t, conn.Deserialize(data))
+		_, err = conn.Query("CREATE TABLE test (val TEXT)", nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid data", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.Deserialize(nil))
+		_, err = conn.Query("CREATE TABLE test (val TEXT)", nil)
+		assert.NoError(t, err, "no data is an empty database")
+
+		require.NoError(t, conn.Deserialize([]byte("not a database")))
+		_, err = conn.Query("SELECT * FROM sqlite_master", nil)
+		assert.ErrorContains(t, err, "file is not a database")
+	})
+
+	t.Run("Closed connection", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		_, err = conn.Serialize()
+		assert.ErrorContains(t, err, "database connection is nil")
+		assert.ErrorContains(t, conn.Deserialize(nil), "database connection is nil")
+	})
+}
@@ -8,13 +8,24 @@ package sqlitec
 // #include "sqlite3.c"
 import "C"
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"runtime/cgo"
 	"strings"
 	"time"
 	"unsafe"
 )
 
+// ErrMultipleStatements is returned by Prepare and Query when the query has
+// more statements after the first one, use Exec to run all of them.
+var ErrMultipleStatements = errors.New("the query has more than one statement, send each one as a separate query")
+
+// ErrReadOnlyConn is returned by Query when a statement that writes is run on
+// a connection opened with OpenReadOnly.
+var ErrReadOnlyConn = errors.New("the connection is read-only, it cannot run statements that write")
+
 // getResCodeStr returns the string representation of the SQLite result code
 // in format "code: description".
 //
@@ -23,11 +34,44 @@ func getResCodeStr(resCode C.int) string {
 	return fmt.Sprintf("%v: %s", resCode, C.GoString(C.sqlite3_errstr(resCode)))
 }
 
+// LibVersion returns the version of the embedded SQLite library, like
+// "3.48.0".
+//
+// https://www.sqlite.org/c3ref/libversion.html
+func LibVersion() string {
+	return C.GoString(C.sqlite3_libversion())
+}
+
+// SourceID returns the check-in date, time and hash of the source of the
+// embedded SQLite library.
+//
+// https://www.sqlite.org/c3ref/libversion.html
+func SourceID() string {
+	return C.GoString(C.sqlite3_sourceid())
+}
+
 // Conn represents a high-level connection to a SQLite database.
 //
 // https://www.sqlite.org/c3ref/sqlite3.html
 type Conn struct {
 	cDB *C.sqlite3
+	// readOnly reports whether the connection was opened with OpenReadOnly.
+	readOnly bool
+	// updateHook, commitHook, rollbackHook, walHook, progressHandler,
+	// busyHandler and authorizer are the handles of the functions set with
+	// SetUpdateHook, SetCommitHook, SetRollbackHook, SetWalHook,
+	// SetProgressHandler, SetBusyHandler and SetAuthorizer, zero if none.
+	updateHook      cgo.Handle
+	commitHook      cgo.Handle
+	rollbackHook    cgo.Handle
+	walHook         cgo.Handle
+	progressHandler cgo.Handle
+	busyHandler     cgo.Handle
+	authorizer      cgo.Handle
+	// walAutoCheckpoint is the wal_autocheckpoint of the connection before
+	// the hook set with SetWalHook, or the one that replaced the hook,
+	// restored when the hook is removed.
+	walAutoCheckpoint int
 }
 
 // Stmt represents a prepared statement in SQLite.
@@ -46,22 +90,70 @@ func (conn *Conn) getLastError() error {
 	return errors.New(C.GoString(C.sqlite3_errmsg(conn.cDB)))
 }
 
-// Open opens a new SQLite database connection using the given path.
+// OpenFlag is a flag of OpenWithFlags, they are combined with |.
+//
+// https://www.sqlite.org/c3ref/c_open_autoproxy.html
+type OpenFlag int
+
+const (
+	// OpenReadOnly opens the database for reading only, it must exist.
+	OpenReadOnly OpenFlag = C.SQLITE_OPEN_READONLY
+	// OpenReadWrite opens the database for reading and writing, it must
+	// exist unless OpenCreate is also set.
+	OpenReadWrite OpenFlag = C.SQLITE_OPEN_READWRITE
+	// OpenCreate creates the database if it does not exist.
+	OpenCreate OpenFlag = C.SQLITE_OPEN_CREATE
+	// OpenURI interprets the path as a URI, like file:data.db?mode=ro.
+	OpenURI OpenFlag = C.SQLITE_OPEN_URI
+	// OpenMemory opens an in-memory database, named by the path.
+	OpenMemory OpenFlag = C.SQLITE_OPEN_MEMORY
+	// OpenNoMutex lets the connection be used by a single goroutine at a
+	// time without locking.
+	OpenNoMutex OpenFlag = C.SQLITE_OPEN_NOMUTEX
+	// OpenFullMutex serializes the use of the connection by several
+	// goroutines.
+	OpenFullMutex OpenFlag = C.SQLITE_OPEN_FULLMUTEX
+	// OpenSharedCache and OpenPrivateCache enable or disable the shared
+	// cache of the connection.
+	OpenSharedCache  OpenFlag = C.SQLITE_OPEN_SHAREDCACHE
+	OpenPrivateCache OpenFlag = C.SQLITE_OPEN_PRIVATECACHE
+	// OpenNoFollow fails to open the database if its path is a symbolic
+	// link.
+	OpenNoFollow OpenFlag = C.SQLITE_OPEN_NOFOLLOW
+)
+
+// Open opens a new SQLite database connection using the given path, it is
+// created if it does not exist.
 //
 // https://www.sqlite.org/c3ref/open.html
 func Open(filePath string) (*Conn, error) {
+	return OpenWithFlags(filePath, OpenReadWrite|OpenCreate)
+}
+
+// OpenWithFlags opens a new SQLite database connection using the given path
+// and flags, which must include one of OpenReadOnly and OpenReadWrite.
+//
+// https://www.sqlite.org/c3ref/open.html
+func OpenWithFlags(filePath string, flags OpenFlag) (*Conn, error) {
 	cFilePath := C.CString(filePath)
 	defer C.free(unsafe.Pointer(cFilePath))
 
 	var db *C.sqlite3
-	resCode := C.sqlite3_open(cFilePath, &db)
+	resCode := C.sqlite3_open_v2(cFilePath, &db, C.int(flags), nil)
 	if resCode != C.SQLITE_OK {
 		errMsg := (&Conn{cDB: db}).getLastError()
 		_ = C.sqlite3_close(db)
 		return nil, fmt.Errorf("failed to open database: %s: %s", getResCodeStr(resCode), errMsg)
 	}
 
-	return &Conn{cDB: db}, nil
+	return &Conn{cDB: db, readOnly: flags&OpenReadOnly != 0}, nil
+}
+
+// ReadOnly returns true if the connection was opened with OpenReadOnly, then
+// Query refuses the statements that write with ErrReadOnlyConn before
+// running them.
+func (conn *Conn) ReadOnly() bool {
+	return conn.readOnly
 }
 
 // Close finalizes the connection to the SQLite database.
@@ -80,6 +172,39 @@ func (conn *Conn) Close() error {
 		return fmt.Errorf("failed to close database: %s: %s", getResCodeStr(resCode), conn.getLastError())
 	}
 	conn.cDB = nil
+	for _, hook := range []*cgo.Handle{
+		&conn.updateHook, &conn.commitHook, &conn.rollbackHook, &conn.walHook,
+		&conn.progressHandler, &conn.busyHandler, &conn.authorizer,
+	} {
+		if *hook != 0 {
+			hook.Delete()
+			*hook = 0
+		}
+	}
+
+	return nil
+}
+
+// BusyTimeout makes the statements wait up to d for the locks held by other
+// connections, instead of failing right away with SQLITE_BUSY. A d of zero
+// or less disables the wait. It replaces the handler set with
+// SetBusyHandler, if any.
+//
+// https://www.sqlite.org/c3ref/busy_timeout.html
+func (conn *Conn) BusyTimeout(d time.Duration) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set busy timeout: database connection is nil")
+	}
+
+	ms := min(d.Milliseconds(), math.MaxInt32)
+	resCode := C.sqlite3_busy_timeout(conn.cDB, C.int(ms))
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to set busy timeout: %s: %s", getResCodeStr(resCode), conn.getLastError())
+	}
+	if conn.busyHandler != 0 {
+		conn.busyHandler.Delete()
+		conn.busyHandler = 0
+	}
 
 	return nil
 }
@@ -101,6 +226,26 @@ func (conn *Conn) RowsAffected() int64 {
 	return int64(C.sqlite3_changes(conn.cDB))
 }
 
+// Interrupt makes the statements running on the connection stop as soon as
+// possible and fail with SQLITE_INTERRUPT. It can be called from another
+// goroutine, and it is a no-op if no statement is running.
+//
+// https://www.sqlite.org/c3ref/interrupt.html
+func (conn *Conn) Interrupt() {
+	if conn.cDB == nil {
+		return
+	}
+	C.sqlite3_interrupt(conn.cDB)
+}
+
+// AutoCommit returns true if the connection is not in a transaction, either
+// because none was started or because SQLite rolled it back after an error.
+//
+// https://www.sqlite.org/c3ref/get_autocommit.html
+func (conn *Conn) AutoCommit() bool {
+	return C.sqlite3_get_autocommit(conn.cDB) != 0
+}
+
 // QueryParam represents a named (?NNN, :VVV, @VVV, $VVV) or nameless (?) parameter in a SQL query.
 type QueryParam struct {
 	Name  string `json:"name,omitempty"`
@@ -130,6 +275,9 @@ func (conn *Conn) Query(query string, parameters []QueryParam) (*QueryResult, er
 	defer func() {
 		_ = stmt.Finalize()
 	}()
+	if conn.readOnly && !stmt.ReadOnly() {
+		return nil, ErrReadOnlyConn
+	}
 
 	var lastInsertID, rowsAffected int64
 	var columns []string
@@ -222,7 +370,122 @@ func (conn *Conn) Query(query string, parameters []QueryParam) (*QueryResult, er
 	}, nil
 }
 
-// Prepare compiles the given SQL query into a prepared statement.
+// QueryContext is like Query, but the statement is interrupted when ctx is
+// done, e.g. when the client that sent it goes away. The error of an
+// interrupted statement wraps the error of ctx, so errors.Is(err,
+// context.Canceled) is true, and the interrupt never reaches the statements
+// run after QueryContext returns.
+func (conn *Conn) QueryContext(ctx context.Context, query string, parameters []QueryParam) (*QueryResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		conn.Interrupt()
+		close(interrupted)
+	})
+
+	res, err := conn.Query(query, parameters)
+	if stop() {
+		return res, err
+	}
+
+	// The interrupt is already running, wait for it so it doesn't
+	// interrupt the next statement.
+	<-interrupted
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+	return res, nil
+}
+
+// ExecError is the error of Exec, it reports the statement of the script
+// that failed and what the statements before it did.
+type ExecError struct {
+	// Statement is the position of the statement that failed in the
+	// script, starting at 1.
+	Statement int
+	// Offset is the byte offset of the statement that failed in the script.
+	Offset int
+	// RowsAffected is the number of rows modified by the statements that
+	// ran before the one that failed, which are not undone.
+	RowsAffected int64
+	Err          error
+}
+
+func (e *ExecError) Error() string {
+	return fmt.Sprintf(
+		"failed to execute statement %d at offset %d, %d rows affected before: %s",
+		e.Statement, e.Offset, e.RowsAffected, e.Err,
+	)
+}
+
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// Exec executes every statement of the script, separated by semicolons, and
+// returns the total number of rows they modified. The rows returned by the
+// statements are discarded. If a statement fails, the ones after it do not
+// run and the error is an *ExecError.
+//
+// https://www.sqlite.org/c3ref/prepare.html
+func (conn *Conn) Exec(script string) (int64, error) {
+	if conn.cDB == nil {
+		return 0, errors.New("failed to execute script: database connection is nil")
+	}
+
+	cScript := C.CString(script)
+	defer C.free(unsafe.Pointer(cScript))
+
+	totalChangesBefore := int64(C.sqlite3_total_changes64(conn.cDB))
+	rowsAffected := func() int64 {
+		return int64(C.sqlite3_total_changes64(conn.cDB)) - totalChangesBefore
+	}
+
+	tail := cScript
+	for statement := 1; *tail != 0; {
+		offset := int(uintptr(unsafe.Pointer(tail)) - uintptr(unsafe.Pointer(cScript)))
+		execErr := func(err error) error {
+			return &ExecError{Statement: statement, Offset: offset, RowsAffected: rowsAffected(), Err: err}
+		}
+
+		var cStmt *C.sqlite3_stmt
+		resCode := C.sqlite3_prepare_v2(conn.cDB, tail, C.int(-1), &cStmt, &tail)
+		if resCode != C.SQLITE_OK {
+			return rowsAffected(), execErr(fmt.Errorf(
+				"failed to prepare statement: %s: %s", getResCodeStr(resCode), conn.getLastError(),
+			))
+		}
+		// The rest of the script is only whitespace or comments.
+		if cStmt == nil {
+			continue
+		}
+
+		stmt := &Stmt{conn: conn, cStmt: cStmt}
+		for {
+			hasNext, err := stmt.Step()
+			if err != nil {
+				_ = stmt.Finalize()
+				return rowsAffected(), execErr(err)
+			}
+			if !hasNext {
+				break
+			}
+		}
+		if err := stmt.Finalize(); err != nil {
+			return rowsAffected(), execErr(err)
+		}
+		statement++
+	}
+
+	return rowsAffected(), nil
+}
+
+// Prepare compiles the given SQL query into a prepared statement. The query
+// must have a single statement, it can only be followed by whitespace and
+// comments, otherwise ErrMultipleStatements is returned.
 //
 // https://www.sqlite.org/c3ref/prepare.html
 func (conn *Conn) Prepare(query string) (*Stmt, error) {
@@ -230,12 +493,34 @@ func (conn *Conn) Prepare(query string) (*Stmt, error) {
 	defer C.free(unsafe.Pointer(cQuery))
 
 	var cStmt *C.sqlite3_stmt
-	resCode := C.sqlite3_prepare_v2(conn.cDB, cQuery, C.int(-1), &cStmt, nil)
+	var cTail *C.char
+	resCode := C.sqlite3_prepare_v2(conn.cDB, cQuery, C.int(-1), &cStmt, &cTail)
 	if resCode != C.SQLITE_OK {
 		return nil, fmt.Errorf("failed to prepare statement: %s: %s", getResCodeStr(resCode), conn.getLastError())
 	}
 
-	return &Stmt{conn: conn, cStmt: cStmt}, nil
+	stmt := &Stmt{conn: conn, cStmt: cStmt}
+	if conn.hasStatement(cTail) {
+		_ = stmt.Finalize()
+		return nil, ErrMultipleStatements
+	}
+
+	return stmt, nil
+}
+
+// hasStatement returns true if the SQL has something else than whitespace
+// and comments, even if it is not a valid statement.
+func (conn *Conn) hasStatement(cSQL *C.char) bool {
+	if *cSQL == 0 {
+		return false
+	}
+
+	var cStmt *C.sqlite3_stmt
+	resCode := C.sqlite3_prepare_v2(conn.cDB, cSQL, C.int(-1), &cStmt, nil)
+	if cStmt != nil {
+		_ = C.sqlite3_finalize(cStmt)
+	}
+	return resCode != C.SQLITE_OK || cStmt != nil
 }
 
 // ReadOnly returns true if the given SQL query is read-only.
@@ -446,7 +731,7 @@ func (stmt *Stmt) Step() (bool, error) {
 		return true, nil
 	}
 
-	return false, fmt.Errorf("failed to step statement: %s", getResCodeStr(resCode))
+	return false, fmt.Errorf("failed to step statement: %s: %s", getResCodeStr(resCode), stmt.conn.getLastError())
 }
 
 // ColumnCount returns the number of columns in the current result row.
@@ -1,14 +1,24 @@
 package sqlitec
 
 import (
+	"context"
 	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSQLiteC(t *testing.T) {
+	t.Run("LibVersion", func(t *testing.T) {
+		assert.Equal(t, "3.48.0", LibVersion())
+		assert.Regexp(t, `^2025-01-14 \S+ [0-9a-f]{64}$`, SourceID())
+	})
+
 	t.Run("OpenClose", func(t *testing.T) {
 		conn, err := Open(":memory:")
 		assert.NoError(t, err)
@@ -287,3 +297,319 @@ func TestSQLiteC(t *testing.T) {
 		})
 	})
 }
+
+func TestBusyTimeout(t *testing.T) {
+	// openConns opens two connections to the same file database.
+	openConns := func(t *testing.T) (*Conn, *Conn) {
+		path := filepath.Join(t.TempDir(), "test.sqlite")
+		first, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = first.Close() })
+		second, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = second.Close() })
+
+		_, err = first.Query("CREATE TABLE test (id INTEGER PRIMARY KEY, val TEXT)", nil)
+		require.NoError(t, err)
+		return first, second
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		first, second := openConns(t)
+		require.NoError(t, second.BusyTimeout(0))
+
+		_, err := first.Query("BEGIN IMMEDIATE", nil)
+		require.NoError(t, err)
+		defer first.Query("ROLLBACK", nil)
+
+		start := time.Now()
+		_, err = second.Query("INSERT INTO test (val) VALUES ('b')", nil)
+		assert.ErrorContains(t, err, "database is locked")
+		assert.Less(t, time.Since(start), time.Second, "fails right away")
+	})
+
+	t.Run("Waits for the lock", func(t *testing.T) {
+		first, second := openConns(t)
+		require.NoError(t, second.BusyTimeout(5*time.Second))
+
+		_, err := first.Query("BEGIN IMMEDIATE", nil)
+		require.NoError(t, err)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			_, _ = first.Query("COMMIT", nil)
+		}()
+
+		start := time.Now()
+		_, err = second.Query("INSERT INTO test (val) VALUES ('b')", nil)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond, "waited for the commit")
+	})
+
+	t.Run("Concurrent writers", func(t *testing.T) {
+		first, second := openConns(t)
+
+		wg := sync.WaitGroup{}
+		for _, conn := range []*Conn{first, second} {
+			require.NoError(t, conn.BusyTimeout(5*time.Second))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 100 {
+					_, err := conn.Query("INSERT INTO test (val) VALUES (?)", []QueryParam{{Value: uuid.NewString()}})
+					assert.NoError(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		res, err := first.Query("SELECT count(*) FROM test", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 200, res.Rows[0][0])
+	})
+
+	t.Run("Closed connection", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		assert.ErrorContains(t, conn.BusyTimeout(time.Second), "database connection is nil")
+	})
+}
+
+func TestExec(t *testing.T) {
+	t.Run("Script", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		rowsAffected, err := conn.Exec(`
+			CREATE TABLE test (id INTEGER PRIMARY KEY, val TEXT);
+			INSERT INTO test (val) VALUES ('a'), ('b'), ('c');
+			SELECT * FROM test;
+			UPDATE test SET val = upper(val) WHERE id > 1;
+			-- The end of the script.
+		`)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), rowsAffected)
+
+		res, err := conn.Query("SELECT val FROM test ORDER BY id", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"a"}, {"B"}, {"C"}}, res.Rows)
+	})
+
+	t.Run("Empty script", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		for _, script := range []string{"", "  ;\n", "-- nothing"} {
+			rowsAffected, err := conn.Exec(script)
+			assert.NoError(t, err, script)
+			assert.Zero(t, rowsAffected)
+		}
+	})
+
+	t.Run("Syntax error", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		script := "CREATE TABLE test (val TEXT); INSERT INTO test VALUES ('a'); INSERT INTO; INSERT INTO test VALUES ('b');"
+		rowsAffected, err := conn.Exec(script)
+		assert.Equal(t, int64(1), rowsAffected)
+
+		execErr := &ExecError{}
+		require.ErrorAs(t, err, &execErr)
+		assert.Equal(t, 3, execErr.Statement)
+		assert.Equal(t, " INSERT INTO;", script[execErr.Offset:execErr.Offset+13])
+		assert.Equal(t, int64(1), execErr.RowsAffected)
+		assert.ErrorContains(t, err, `near ";": syntax error`)
+
+		res, err := conn.Query("SELECT val FROM test", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{"a"}}, res.Rows, "the statements before the error ran, not the ones after")
+	})
+
+	t.Run("Runtime error", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Exec("CREATE TABLE test (val TEXT UNIQUE); INSERT INTO test VALUES ('a'); INSERT INTO test VALUES ('a');")
+		execErr := &ExecError{}
+		require.ErrorAs(t, err, &execErr)
+		assert.Equal(t, 3, execErr.Statement)
+		assert.ErrorContains(t, err, "UNIQUE constraint failed: test.val")
+	})
+
+	t.Run("Closed connection", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+
+		_, err = conn.Exec("SELECT 1")
+		assert.ErrorContains(t, err, "database connection is nil")
+	})
+}
+
+func TestPrepareTail(t *testing.T) {
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Query("CREATE TABLE test (val TEXT)", nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "Single statement", query: "INSERT INTO test VALUES ('a')"},
+		{name: "Followed by a semicolon", query: "INSERT INTO test VALUES ('a');"},
+		{name: "Trailing whitespace and comments", query: "INSERT INTO test VALUES ('a'); \n-- done\n/* really */ ;"},
+		{name: "Two statements", query: "INSERT INTO test VALUES ('a'); DELETE FROM test", wantErr: true},
+		{name: "Trailing invalid SQL", query: "INSERT INTO test VALUES ('a'); oops", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := conn.Query("DELETE FROM test", nil)
+			require.NoError(t, err)
+
+			stmt, err := conn.Prepare(tt.query)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrMultipleStatements)
+			} else {
+				require.NoError(t, err)
+				assert.NoError(t, stmt.Finalize())
+			}
+
+			_, err = conn.Query(tt.query, nil)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrMultipleStatements)
+
+			res, err := conn.Query("SELECT count(*) FROM test", nil)
+			require.NoError(t, err)
+			assert.Equal(t, 0, res.Rows[0][0], "no statement ran")
+		})
+	}
+}
+
+func TestOpenWithFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	conn, err := Open(path)
+	require.NoError(t, err)
+	_, err = conn.Query("CREATE TABLE test (val TEXT)", nil)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	t.Run("Read-only", func(t *testing.T) {
+		conn, err := OpenWithFlags(path, OpenReadOnly)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.True(t, conn.ReadOnly())
+
+		for _, query := range []string{"SELECT * FROM test", "BEGIN", "ROLLBACK"} {
+			_, err = conn.Query(query, nil)
+			assert.NoError(t, err, query)
+		}
+		for _, query := range []string{
+			"INSERT INTO test VALUES ('a')",
+			"DELETE FROM test",
+			"CREATE TABLE other (val TEXT)",
+		} {
+			_, err = conn.Query(query, nil)
+			assert.ErrorIs(t, err, ErrReadOnlyConn, query)
+		}
+
+		_, err = conn.Exec("INSERT INTO test VALUES ('a')")
+		assert.ErrorContains(t, err, "8: attempt to write a readonly database", "SQLite refuses the scripts that write")
+	})
+
+	t.Run("No create", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing.sqlite")
+		for _, flags := range []OpenFlag{OpenReadOnly, OpenReadWrite} {
+			_, err := OpenWithFlags(missing, flags)
+			assert.ErrorContains(t, err, "14: unable to open database file")
+		}
+		assert.NoFileExists(t, missing)
+	})
+
+	t.Run("URI", func(t *testing.T) {
+		conn, err := OpenWithFlags("file:"+path+"?mode=ro", OpenReadWrite|OpenURI)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.False(t, conn.ReadOnly())
+
+		_, err = conn.Query("INSERT INTO test VALUES ('a')", nil)
+		assert.ErrorContains(t, err, "attempt to write a readonly database")
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		conn, err := OpenWithFlags("memdb", OpenReadWrite|OpenCreate|OpenMemory|OpenFullMutex)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Query("CREATE TABLE test (val TEXT)", nil)
+		assert.NoError(t, err)
+		assert.NoFileExists(t, "memdb")
+	})
+}
+
+func TestQueryContext(t *testing.T) {
+	// slowQuery runs for far longer than the tests wait for it.
+	const slowQuery = `
+		WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c)
+		SELECT count(*) FROM c
+	`
+
+	conn, err := Open(":memory:")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := conn.QueryContext(ctx, slowQuery, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorContains(t, err, "interrupted")
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := conn.QueryContext(ctx, slowQuery, nil)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Already canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := conn.QueryContext(ctx, "CREATE TABLE canceled (val TEXT)", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = conn.Query("SELECT * FROM canceled", nil)
+		assert.ErrorContains(t, err, "no such table: canceled", "the query never ran")
+	})
+
+	t.Run("Not canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		res, err := conn.QueryContext(ctx, "SELECT 1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{1}}, res.Rows)
+
+		// Canceling afterwards doesn't interrupt the next queries.
+		cancel()
+		res, err = conn.Query("SELECT 2", nil)
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{2}}, res.Rows)
+	})
+}
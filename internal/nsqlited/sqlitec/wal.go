@@ -0,0 +1,164 @@
+package sqlitec
+
+// #include "sqlite3.c"
+// #include <stdint.h>
+//
+// // Implemented in Go in hook_export.go.
+// extern int goWalHook(void *handle, char *dbName, int pages);
+//
+// // The exported Go functions can not have const parameters, so the hook
+// // goes through this wrapper.
+// static int cust_wal_hook(void *handle, sqlite3 *db, const char *dbName, int pages) {
+//   return goWalHook(handle, (char *)dbName, pages);
+// }
+//
+// // The handle of the Go function is passed as the argument of the hook. A
+// // zero handle removes the hook and restores the automatic checkpoints
+// // after autoCheckpoint pages, that are also implemented with the WAL hook.
+// static void cust_sqlite3_wal_hook(sqlite3 *db, uintptr_t handle, int autoCheckpoint) {
+//   if (handle == 0) {
+//     sqlite3_wal_autocheckpoint(db, autoCheckpoint);
+//     return;
+//   }
+//   sqlite3_wal_hook(db, cust_wal_hook, (void *)handle);
+// }
+//
+// // Removes the hook if it is still the one of the handle, and reports
+// // whether it was. PRAGMA wal_autocheckpoint replaces it.
+// static int cust_sqlite3_wal_unhook(sqlite3 *db, uintptr_t handle) {
+//   return sqlite3_wal_hook(db, 0, 0) == (void *)handle;
+// }
+import "C"
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// WalHook is called after each commit of a connection in WAL mode, see
+// SetWalHook.
+type WalHook func(dbName string, pages int) error
+
+// SetWalHook sets the function called after each commit of the connection
+// to a database in WAL mode, with the number of pages in the WAL file,
+// replacing the previous one. A nil fn removes the hook.
+//
+// Setting a hook disables the automatic checkpoints (wal_autocheckpoint),
+// the hook can call WalCheckpoint once the WAL is too large. Removing it
+// restores them with the threshold the connection had before the hook was
+// set, or with the one of a later PRAGMA wal_autocheckpoint, which replaces
+// the hook. An error returned by fn fails the statement that committed, but
+// the commit is not undone.
+//
+// https://www.sqlite.org/c3ref/wal_hook.html
+func (conn *Conn) SetWalHook(fn WalHook) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set WAL hook: database connection is nil")
+	}
+	if fn == nil && conn.walHook == 0 {
+		return nil
+	}
+
+	// The pragma reads as zero while the hook is set, a threshold means it
+	// was replaced by the automatic checkpoints since.
+	res, err := conn.Query("PRAGMA wal_autocheckpoint", nil)
+	if err != nil {
+		return fmt.Errorf("failed to set WAL hook: %w", err)
+	}
+	pages, _ := res.Rows[0][0].(int)
+	switch {
+	case conn.walHook == 0 || pages != 0:
+		conn.walAutoCheckpoint = pages
+	case C.cust_sqlite3_wal_unhook(conn.cDB, C.uintptr_t(conn.walHook)) == 0:
+		// The hook was replaced by disabling the automatic checkpoints.
+		conn.walAutoCheckpoint = 0
+	}
+
+	replaceHook(&conn.walHook, fn, fn != nil, func(handle C.uintptr_t) {
+		C.cust_sqlite3_wal_hook(conn.cDB, handle, C.int(conn.walAutoCheckpoint))
+	})
+	return nil
+}
+
+// WalAutoCheckpoint makes the connection checkpoint the WAL in passive mode
+// after the commits that leave it with pages pages or more, zero or less
+// disables the automatic checkpoints. It is the same as the
+// wal_autocheckpoint pragma, and it removes the hook set with SetWalHook.
+//
+// https://www.sqlite.org/c3ref/wal_autocheckpoint.html
+func (conn *Conn) WalAutoCheckpoint(pages int) error {
+	if conn.cDB == nil {
+		return errors.New("failed to set WAL auto-checkpoint: database connection is nil")
+	}
+
+	pages = min(max(pages, 0), math.MaxInt32)
+	resCode := C.sqlite3_wal_autocheckpoint(conn.cDB, C.int(pages))
+	if resCode != C.SQLITE_OK {
+		return fmt.Errorf("failed to set WAL auto-checkpoint: %s: %s", getResCodeStr(resCode), conn.getLastError())
+	}
+	if conn.walHook != 0 {
+		conn.walHook.Delete()
+		conn.walHook = 0
+	}
+
+	return nil
+}
+
+// CheckpointMode is the mode of WalCheckpoint.
+//
+// https://www.sqlite.org/c3ref/wal_checkpoint_v2.html
+type CheckpointMode int
+
+const (
+	// CheckpointPassive copies as many frames as possible without waiting
+	// for the readers or the writers.
+	CheckpointPassive = CheckpointMode(C.SQLITE_CHECKPOINT_PASSIVE)
+	// CheckpointFull waits for the writers, then for the readers of the
+	// older frames, and copies all the frames.
+	CheckpointFull = CheckpointMode(C.SQLITE_CHECKPOINT_FULL)
+	// CheckpointRestart is like CheckpointFull, then waits for all the
+	// readers so the next writer restarts the WAL from the beginning.
+	CheckpointRestart = CheckpointMode(C.SQLITE_CHECKPOINT_RESTART)
+	// CheckpointTruncate is like CheckpointRestart, then truncates the WAL
+	// file to zero bytes.
+	CheckpointTruncate = CheckpointMode(C.SQLITE_CHECKPOINT_TRUNCATE)
+)
+
+// String returns the name of the mode, like "PASSIVE".
+func (mode CheckpointMode) String() string {
+	switch mode {
+	case CheckpointPassive:
+		return "PASSIVE"
+	case CheckpointFull:
+		return "FULL"
+	case CheckpointRestart:
+		return "RESTART"
+	case CheckpointTruncate:
+		return "TRUNCATE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// WalCheckpoint copies the frames of the WAL of the attached databases into
+// their database files. It returns the number of frames in the WAL and the
+// number of frames copied, both -1 if the databases are not in WAL mode.
+//
+// The modes other than CheckpointPassive wait for the other connections with
+// the busy handler, they fail with SQLITE_BUSY if it gives up, but the
+// frames copied until then are still returned.
+//
+// https://www.sqlite.org/c3ref/wal_checkpoint_v2.html
+func (conn *Conn) WalCheckpoint(mode CheckpointMode) (logFrames int, checkpointed int, err error) {
+	if conn.cDB == nil {
+		return 0, 0, errors.New("failed to checkpoint WAL: database connection is nil")
+	}
+
+	var cLogFrames, cCheckpointed C.int
+	resCode := C.sqlite3_wal_checkpoint_v2(conn.cDB, nil, C.int(mode), &cLogFrames, &cCheckpointed)
+	if resCode != C.SQLITE_OK {
+		err = fmt.Errorf("failed to checkpoint WAL in %s mode: %s: %s", mode, getResCodeStr(resCode), conn.getLastError())
+	}
+
+	return int(cLogFrames), int(cCheckpointed), err
+}
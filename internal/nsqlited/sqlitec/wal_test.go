@@ -0,0 +1,244 @@
+package sqlitec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWal(t *testing.T) {
+	// openWal opens a writer and a reader of a database in WAL mode without
+	// automatic checkpoints, and returns the path of the WAL file.
+	openWal := func(t *testing.T) (*Conn, *Conn, string) {
+		path := filepath.Join(t.TempDir(), "test.sqlite")
+		writer, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = writer.Close() })
+		_, err = writer.Exec(`
+			PRAGMA journal_mode = WAL;
+			PRAGMA wal_autocheckpoint = 0;
+			CREATE TABLE items (data BLOB);
+		`)
+		require.NoError(t, err)
+
+		reader, err := Open(path)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = reader.Close() })
+		return writer, reader, path + "-wal"
+	}
+
+	// insert inserts n rows, each in its own transaction.
+	insert := func(t *testing.T, conn *Conn, n int) {
+		for range n {
+			_, err := conn.Query("INSERT INTO items (data) VALUES (randomblob(1000))", nil)
+			require.NoError(t, err)
+		}
+	}
+
+	walSize := func(t *testing.T, path string) int64 {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		return info.Size()
+	}
+
+	t.Run("Checkpoint with a reader", func(t *testing.T) {
+		writer, reader, walPath := openWal(t)
+		insert(t, writer, 200)
+
+		// The reader holds a snapshot from before the next writes.
+		_, err := reader.Exec("BEGIN; SELECT count(*) FROM items;")
+		require.NoError(t, err)
+		insert(t, writer, 200)
+		sizeBefore := walSize(t, walPath)
+
+		logFrames, checkpointed, err := writer.WalCheckpoint(CheckpointPassive)
+		require.NoError(t, err)
+		assert.Greater(t, logFrames, 400)
+		assert.Less(t, checkpointed, logFrames, "the frames after the snapshot of the reader are kept")
+
+		require.NoError(t, writer.BusyTimeout(50*time.Millisecond))
+		_, _, err = writer.WalCheckpoint(CheckpointTruncate)
+		assert.ErrorContains(t, err, "failed to checkpoint WAL in TRUNCATE mode: 5: database is locked")
+		assert.Equal(t, sizeBefore, walSize(t, walPath))
+
+		_, err = reader.Query("COMMIT", nil)
+		require.NoError(t, err)
+		logFrames, checkpointed, err = writer.WalCheckpoint(CheckpointTruncate)
+		require.NoError(t, err)
+		assert.Zero(t, logFrames)
+		assert.Zero(t, checkpointed)
+		assert.Zero(t, walSize(t, walPath), "the WAL file is truncated")
+
+		res, err := reader.Query("SELECT count(*) FROM items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 400, res.Rows[0][0])
+	})
+
+	t.Run("Checkpoint from the hook", func(t *testing.T) {
+		const threshold = 50
+		writer, _, walPath := openWal(t)
+
+		reported := []int{}
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error {
+			assert.Equal(t, "main", dbName)
+			reported = append(reported, pages)
+			if pages < threshold {
+				return nil
+			}
+			_, _, err := writer.WalCheckpoint(CheckpointPassive)
+			return err
+		}))
+		insert(t, writer, 200)
+
+		require.Len(t, reported, 200, "called after each commit")
+		assert.LessOrEqual(t, slices.Max(reported), threshold+2, "the WAL is restarted after the checkpoints")
+		assert.Less(t, walSize(t, walPath), int64(2*threshold*4096))
+	})
+
+	t.Run("Hook error", func(t *testing.T) {
+		writer, reader, _ := openWal(t)
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error {
+			return errors.New("too large")
+		}))
+
+		_, err := writer.Query("INSERT INTO items (data) VALUES (x'01')", nil)
+		assert.ErrorContains(t, err, "1: SQL logic error")
+		res, err := reader.Query("SELECT count(*) FROM items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Rows[0][0], "the commit is not undone")
+	})
+
+	t.Run("Hook panic", func(t *testing.T) {
+		writer, reader, _ := openWal(t)
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error { panic("boom") }))
+
+		_, err := writer.Query("INSERT INTO items (data) VALUES (x'01')", nil)
+		assert.ErrorContains(t, err, "1: SQL logic error")
+		res, err := reader.Query("SELECT count(*) FROM items", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Rows[0][0], "the commit is not undone")
+	})
+
+	t.Run("Removed hook restores automatic checkpoints", func(t *testing.T) {
+		writer, _, _ := openWal(t)
+		_, err := writer.Query("PRAGMA wal_autocheckpoint = 250", nil)
+		require.NoError(t, err)
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error { return nil }))
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error { return nil }))
+		res, err := writer.Query("PRAGMA wal_autocheckpoint", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Rows[0][0])
+
+		require.NoError(t, writer.SetWalHook(nil))
+		assert.Zero(t, writer.walHook)
+		res, err = writer.Query("PRAGMA wal_autocheckpoint", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 250, res.Rows[0][0], "the threshold before the hook is restored")
+
+		require.NoError(t, writer.WalAutoCheckpoint(0))
+		require.NoError(t, writer.SetWalHook(nil))
+		res, err = writer.Query("PRAGMA wal_autocheckpoint", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Rows[0][0], "removing no hook keeps the threshold")
+	})
+
+	t.Run("Removed hook keeps the pragma that replaced it", func(t *testing.T) {
+		writer, _, _ := openWal(t)
+		for _, pages := range []int{300, 0} {
+			_, err := writer.Query("PRAGMA wal_autocheckpoint = 250", nil)
+			require.NoError(t, err)
+			require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error { return nil }))
+			_, err = writer.Query(fmt.Sprintf("PRAGMA wal_autocheckpoint = %d", pages), nil)
+			require.NoError(t, err)
+
+			require.NoError(t, writer.SetWalHook(nil))
+			res, err := writer.Query("PRAGMA wal_autocheckpoint", nil)
+			require.NoError(t, err)
+			assert.Equal(t, pages, res.Rows[0][0], "the pragma is not undone")
+		}
+
+		_, err := writer.Query("PRAGMA wal_autocheckpoint = 250", nil)
+		require.NoError(t, err)
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error { return nil }))
+		_, err = writer.Query("PRAGMA wal_autocheckpoint = 0", nil)
+		require.NoError(t, err)
+		calls := 0
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error {
+			calls++
+			return nil
+		}))
+		insert(t, writer, 1)
+		assert.Equal(t, 1, calls, "the hook is set again")
+		require.NoError(t, writer.SetWalHook(nil))
+		res, err := writer.Query("PRAGMA wal_autocheckpoint", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Rows[0][0], "the threshold of the pragma is restored, not the one before the first hook")
+	})
+
+	t.Run("Auto-checkpoint", func(t *testing.T) {
+		writer, _, walPath := openWal(t)
+		require.NoError(t, writer.SetWalHook(func(dbName string, pages int) error { return nil }))
+
+		require.NoError(t, writer.WalAutoCheckpoint(20))
+		assert.Zero(t, writer.walHook, "the hook is removed")
+		res, err := writer.Query("PRAGMA wal_autocheckpoint", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 20, res.Rows[0][0])
+
+		insert(t, writer, 200)
+		logFrames, _, err := writer.WalCheckpoint(CheckpointPassive)
+		require.NoError(t, err)
+		assert.Less(t, logFrames, 20, "the WAL is checkpointed after the burst")
+		assert.Less(t, walSize(t, walPath), int64(40*4096))
+
+		require.NoError(t, writer.WalAutoCheckpoint(0))
+		insert(t, writer, 200)
+		logFrames, _, err = writer.WalCheckpoint(CheckpointPassive)
+		require.NoError(t, err)
+		assert.Greater(t, logFrames, 200, "no automatic checkpoints once disabled")
+
+		require.NoError(t, writer.WalAutoCheckpoint(-1))
+		res, err = writer.Query("PRAGMA wal_autocheckpoint", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 0, res.Rows[0][0], "negative values disable it")
+	})
+
+	t.Run("Not in WAL mode", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		logFrames, checkpointed, err := conn.WalCheckpoint(CheckpointFull)
+		require.NoError(t, err)
+		assert.Equal(t, -1, logFrames)
+		assert.Equal(t, -1, checkpointed)
+	})
+
+	t.Run("Closed connection", func(t *testing.T) {
+		conn, err := Open(":memory:")
+		require.NoError(t, err)
+		require.NoError(t, conn.SetWalHook(func(dbName string, pages int) error { return nil }))
+		require.NoError(t, conn.Close())
+
+		assert.Zero(t, conn.walHook)
+		assert.ErrorContains(t, conn.SetWalHook(nil), "database connection is nil")
+		assert.ErrorContains(t, conn.WalAutoCheckpoint(0), "database connection is nil")
+		_, _, err = conn.WalCheckpoint(CheckpointPassive)
+		assert.ErrorContains(t, err, "database connection is nil")
+	})
+
+	t.Run("Mode names", func(t *testing.T) {
+		assert.Equal(t, "PASSIVE", CheckpointPassive.String())
+		assert.Equal(t, "FULL", CheckpointFull.String())
+		assert.Equal(t, "RESTART", CheckpointRestart.String())
+		assert.Equal(t, "TRUNCATE", CheckpointTruncate.String())
+		assert.Equal(t, "UNKNOWN", CheckpointMode(-1).String())
+	})
+}
@@ -3,13 +3,16 @@
 //
 // This package is used to take advantage of the internal connection pooling
 // that is provided by the database/sql and it should provide a way to access
-// the underlying SQLite C API wrapper and should not be used directly.
+// the underlying SQLite C API wrapper. The queries executed through the
+// database/sql API are fully read before returning, like with sqlitec.Query,
+// which is enough to benchmark the wrapper.
 package sqlitedrv
 
 import (
 	"context"
 	"database/sql/driver"
 	"fmt"
+	"time"
 
 	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
 )
@@ -20,6 +23,11 @@ var (
 	_ driver.Validator       = (*Conn)(nil)
 	_ driver.SessionResetter = (*Conn)(nil)
 	_ driver.Connector       = (*Connector)(nil)
+	_ driver.ExecerContext   = (*Conn)(nil)
+	_ driver.QueryerContext  = (*Conn)(nil)
+	_ driver.Stmt            = (*Stmt)(nil)
+	_ driver.Tx              = (*Tx)(nil)
+	_ driver.Rows            = (*Rows)(nil)
 )
 
 // Driver implements the database/sql/driver interface
@@ -31,26 +39,78 @@ func (driver *Driver) Open(dsn string) (driver.Conn, error) {
 	return connector.Connect(context.Background())
 }
 
-type connectorOption func(*Connector)
+// ConnectorOption is an option of NewConnector.
+type ConnectorOption func(*Connector)
 
 // WithPostConnectQueries sets a slice of queries to be executed after a
-// connection is established
-func WithPostConnectQueries(queries []string) connectorOption {
+// connection is established, each one can have several statements
+func WithPostConnectQueries(queries []string) ConnectorOption {
 	return func(connector *Connector) {
 		connector.postConnectQueries = queries
 	}
 }
 
+// WithBusyTimeout sets how long the connections wait for the locks held by
+// other connections before failing with SQLITE_BUSY, zero disables the
+// wait. It is set before the post-connect queries run.
+func WithBusyTimeout(timeout time.Duration) ConnectorOption {
+	return func(connector *Connector) {
+		connector.busyTimeout = timeout
+	}
+}
+
+// WithOpenFlags sets the flags the connections are opened with, by default
+// they are opened for reading and writing, creating the database if needed.
+func WithOpenFlags(flags sqlitec.OpenFlag) ConnectorOption {
+	return func(connector *Connector) {
+		connector.openFlags = flags
+	}
+}
+
+// WithWalAutoCheckpoint makes the connections checkpoint the WAL once it has
+// pages pages, zero or less disables the automatic checkpoints. It is set
+// after the post-connect queries run, so it takes precedence over their
+// wal_autocheckpoint pragma.
+func WithWalAutoCheckpoint(pages int) ConnectorOption {
+	return func(connector *Connector) {
+		connector.walAutoCheckpoint = &pages
+	}
+}
+
+// WithBusyHandler sets the busy handler of the connections, each one gets its
+// own handler from newHandler. It is set after the post-connect queries run,
+// so it takes precedence over the busy timeout.
+func WithBusyHandler(newHandler func() sqlitec.BusyHandler) ConnectorOption {
+	return func(connector *Connector) {
+		connector.newBusyHandler = newHandler
+	}
+}
+
+// WithReadOnly opens the connections read-only, the statements that write
+// fail with sqlitec.ErrReadOnlyConn without running.
+func WithReadOnly() ConnectorOption {
+	return WithOpenFlags(sqlitec.OpenReadOnly)
+}
+
 // Connector implements the database/sql/driver.Connector interface
 type Connector struct {
 	dsn                string
+	openFlags          sqlitec.OpenFlag
+	busyTimeout        time.Duration
 	postConnectQueries []string
+	// walAutoCheckpoint is the WAL auto-checkpoint threshold in pages, the
+	// default of SQLite or of the post-connect queries if nil.
+	walAutoCheckpoint *int
+	// newBusyHandler returns the busy handler of each connection, the busy
+	// timeout is used if nil.
+	newBusyHandler func() sqlitec.BusyHandler
 }
 
 // NewConnector creates a new connector to the SQLite database
-func NewConnector(dsn string, options ...connectorOption) driver.Connector {
+func NewConnector(dsn string, options ...ConnectorOption) driver.Connector {
 	connector := &Connector{
-		dsn: dsn,
+		dsn:       dsn,
+		openFlags: sqlitec.OpenReadWrite | sqlitec.OpenCreate,
 	}
 
 	for _, option := range options {
@@ -62,7 +122,7 @@ func NewConnector(dsn string, options ...connectorOption) driver.Connector {
 
 // Connect creates a new connection to the SQLite database
 func (connector *Connector) Connect(_ context.Context) (driver.Conn, error) {
-	return newConn(connector.dsn, connector.postConnectQueries)
+	return newConn(connector)
 }
 
 // Driver returns the driver
@@ -75,19 +135,39 @@ type Conn struct {
 	conn *sqlitec.Conn
 }
 
-// newConn creates a new connection to the SQLite database
-func newConn(dsn string, postConnectQueries []string) (driver.Conn, error) {
-	conn, err := sqlitec.Open(dsn)
+// newConn creates a new connection to the SQLite database of the connector
+func newConn(connector *Connector) (driver.Conn, error) {
+	conn, err := sqlitec.OpenWithFlags(connector.dsn, connector.openFlags)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open connection: %w", err)
 	}
 
-	for _, query := range postConnectQueries {
-		if _, err := conn.Query(query, nil); err != nil {
+	if err := conn.BusyTimeout(connector.busyTimeout); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	for _, query := range connector.postConnectQueries {
+		if _, err := conn.Exec(query); err != nil {
+			_ = conn.Close()
 			return nil, fmt.Errorf(`failed to execute "%s" post-connect query: %w`, query, err)
 		}
 	}
 
+	if connector.walAutoCheckpoint != nil {
+		if err := conn.WalAutoCheckpoint(*connector.walAutoCheckpoint); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	if connector.newBusyHandler != nil {
+		if err := conn.SetBusyHandler(connector.newBusyHandler()); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
 	return &Conn{
 		conn: conn,
 	}, nil
@@ -106,14 +186,17 @@ func (conn *Conn) Close() error {
 	return nil
 }
 
-// Prepare is no-op
+// Prepare returns a statement that executes the query on the connection
 func (conn *Conn) Prepare(query string) (driver.Stmt, error) {
-	return nil, nil
+	return &Stmt{conn: conn, query: query}, nil
 }
 
-// Begin is no-op
+// Begin starts a deferred transaction
 func (conn *Conn) Begin() (driver.Tx, error) {
-	return nil, nil
+	if _, err := conn.conn.Query("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return &Tx{conn: conn}, nil
 }
 
 // TODO: Correctly implement the SessionResetter and Validator interfaces
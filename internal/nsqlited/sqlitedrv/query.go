@@ -0,0 +1,147 @@
+package sqlitedrv
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+)
+
+// ExecContext executes a query that doesn't return rows
+func (conn *Conn) ExecContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Result, error) {
+	res, err := conn.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return result{lastInsertID: res.LastInsertID, rowsAffected: res.RowsAffected}, nil
+}
+
+// QueryContext executes a query that returns rows
+func (conn *Conn) QueryContext(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (driver.Rows, error) {
+	res, err := conn.query(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{columns: res.Columns, rows: res.Rows}, nil
+}
+
+// query executes the query with the given arguments, interrupting it if the
+// context is canceled
+func (conn *Conn) query(
+	ctx context.Context, query string, args []driver.NamedValue,
+) (*sqlitec.QueryResult, error) {
+	params := make([]sqlitec.QueryParam, len(args))
+	for i, arg := range args {
+		params[i] = sqlitec.QueryParam{Name: arg.Name, Value: arg.Value}
+	}
+	return conn.conn.QueryContext(ctx, query, params)
+}
+
+// result implements the database/sql/driver.Result interface
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+// LastInsertId returns the rowid of the last inserted row
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+// RowsAffected returns the number of rows changed by the query
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+// Stmt implements the database/sql/driver.Stmt interface, the query is
+// compiled again on every execution
+type Stmt struct {
+	conn  *Conn
+	query string
+}
+
+// Close is no-op
+func (stmt *Stmt) Close() error {
+	return nil
+}
+
+// NumInput returns -1 because the number of parameters is not checked
+func (stmt *Stmt) NumInput() int {
+	return -1
+}
+
+// Exec executes the statement with the given arguments
+func (stmt *Stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return stmt.conn.ExecContext(context.Background(), stmt.query, namedValues(args))
+}
+
+// Query executes the statement with the given arguments
+func (stmt *Stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stmt.conn.QueryContext(context.Background(), stmt.query, namedValues(args))
+}
+
+// namedValues converts the positional arguments to nameless named values
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// Tx implements the database/sql/driver.Tx interface
+type Tx struct {
+	conn *Conn
+}
+
+// Commit commits the transaction
+func (tx *Tx) Commit() error {
+	_, err := tx.conn.conn.Query("COMMIT", nil)
+	return err
+}
+
+// Rollback rolls back the transaction
+func (tx *Tx) Rollback() error {
+	_, err := tx.conn.conn.Query("ROLLBACK", nil)
+	return err
+}
+
+// Rows implements the database/sql/driver.Rows interface over the rows
+// already read by sqlitec.Query
+type Rows struct {
+	columns []string
+	rows    [][]any
+}
+
+// Columns returns the names of the columns
+func (rows *Rows) Columns() []string {
+	return rows.columns
+}
+
+// Close is no-op
+func (rows *Rows) Close() error {
+	return nil
+}
+
+// Next copies the next row to dest, converting the integers to int64 as
+// required by database/sql
+func (rows *Rows) Next(dest []driver.Value) error {
+	if len(rows.rows) == 0 {
+		return io.EOF
+	}
+
+	row := rows.rows[0]
+	rows.rows = rows.rows[1:]
+	for i, value := range row {
+		if v, ok := value.(int); ok {
+			value = int64(v)
+		}
+		dest[i] = value
+	}
+	return nil
+}
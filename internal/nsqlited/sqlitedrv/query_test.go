@@ -0,0 +1,167 @@
+package sqlitedrv
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlited/sqlitec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseSQL(t *testing.T) {
+	db := sql.OpenDB(NewConnector(
+		filepath.Join(t.TempDir(), "test.sqlite"),
+		WithBusyTimeout(5*time.Second),
+	))
+	t.Cleanup(func() { db.Close() })
+
+	_, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, avatar BLOB)`)
+	require.NoError(t, err)
+
+	t.Run("Busy timeout", func(t *testing.T) {
+		var timeout int
+		require.NoError(t, db.QueryRow(`PRAGMA busy_timeout`).Scan(&timeout))
+		assert.Equal(t, 5000, timeout)
+	})
+
+	t.Run("Exec returns the result", func(t *testing.T) {
+		res, err := db.Exec(`INSERT INTO users (name, avatar) VALUES (?, ?)`, "alice", []byte{0x01})
+		require.NoError(t, err)
+
+		id, err := res.LastInsertId()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), id)
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+	})
+
+	t.Run("Query scans the rows", func(t *testing.T) {
+		var id int64
+		var name string
+		var avatar []byte
+		err := db.QueryRow(`SELECT id, name, avatar FROM users WHERE name = :name`, sql.Named("name", "alice")).
+			Scan(&id, &name, &avatar)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), id)
+		assert.Equal(t, "alice", name)
+		assert.Equal(t, []byte{0x01}, avatar)
+	})
+
+	t.Run("Transactions with prepared statements", func(t *testing.T) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		stmt, err := tx.Prepare(`INSERT INTO users (name) VALUES (?)`)
+		require.NoError(t, err)
+		for _, name := range []string{"bob", "carol"} {
+			_, err := stmt.Exec(name)
+			require.NoError(t, err)
+		}
+		require.NoError(t, stmt.Close())
+		require.NoError(t, tx.Rollback())
+
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+		assert.Equal(t, 1, count, "the rolled back rows are not inserted")
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := db.ExecContext(ctx, `INSERT INTO users (name) VALUES ('dave')`)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestPostConnectQueries(t *testing.T) {
+	t.Run("Several statements", func(t *testing.T) {
+		db := sql.OpenDB(NewConnector(
+			filepath.Join(t.TempDir(), "test.sqlite"),
+			WithPostConnectQueries([]string{"PRAGMA foreign_keys = true; PRAGMA query_only = true;"}),
+		))
+		t.Cleanup(func() { db.Close() })
+
+		var foreignKeys, queryOnly bool
+		require.NoError(t, db.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys))
+		require.NoError(t, db.QueryRow(`PRAGMA query_only`).Scan(&queryOnly))
+		assert.True(t, foreignKeys)
+		assert.True(t, queryOnly)
+	})
+
+	t.Run("Failed statement", func(t *testing.T) {
+		db := sql.OpenDB(NewConnector(
+			filepath.Join(t.TempDir(), "test.sqlite"),
+			WithPostConnectQueries([]string{"PRAGMA foreign_keys = true; NOT SQL;"}),
+		))
+		t.Cleanup(func() { db.Close() })
+
+		err := db.Ping()
+		assert.ErrorContains(t, err, "post-connect query")
+		assert.ErrorContains(t, err, "statement 2")
+	})
+}
+
+func TestReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	rw := sql.OpenDB(NewConnector(path))
+	t.Cleanup(func() { rw.Close() })
+	for _, query := range []string{`CREATE TABLE users (name TEXT)`, `INSERT INTO users (name) VALUES ('alice')`} {
+		_, err := rw.Exec(query)
+		require.NoError(t, err)
+	}
+
+	ro := sql.OpenDB(NewConnector(path, WithReadOnly()))
+	t.Cleanup(func() { ro.Close() })
+
+	var name string
+	require.NoError(t, ro.QueryRow(`SELECT name FROM users`).Scan(&name))
+	assert.Equal(t, "alice", name)
+
+	_, err := ro.Exec(`DELETE FROM users`)
+	assert.ErrorIs(t, err, sqlitec.ErrReadOnlyConn)
+
+	var count int
+	require.NoError(t, rw.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestWalAutoCheckpoint(t *testing.T) {
+	db := sql.OpenDB(NewConnector(
+		filepath.Join(t.TempDir(), "test.sqlite"),
+		WithPostConnectQueries([]string{"PRAGMA journal_mode = WAL; PRAGMA wal_autocheckpoint = 500;"}),
+		WithWalAutoCheckpoint(20),
+	))
+	t.Cleanup(func() { db.Close() })
+
+	var pages int
+	require.NoError(t, db.QueryRow(`PRAGMA wal_autocheckpoint`).Scan(&pages))
+	assert.Equal(t, 20, pages, "it takes precedence over the post-connect queries")
+}
+
+func TestBusyHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.sqlite")
+	handlers := 0
+	db := sql.OpenDB(NewConnector(path, WithBusyHandler(func() sqlitec.BusyHandler {
+		handlers++
+		return func(attempts int) bool { return false }
+	})))
+	t.Cleanup(func() { db.Close() })
+	_, err := db.Exec(`CREATE TABLE users (name TEXT)`)
+	require.NoError(t, err)
+	assert.Equal(t, 1, handlers, "each connection gets its own handler")
+
+	locker, err := sqlitec.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = locker.Close() })
+	_, err = locker.Query(`BEGIN EXCLUSIVE`, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = db.Exec(`INSERT INTO users (name) VALUES ('alice')`)
+	assert.ErrorContains(t, err, "database is locked")
+	assert.Less(t, time.Since(start), time.Second, "the busy timeout is replaced")
+}
@@ -0,0 +1,45 @@
+package stats
+
+import "sync/atomic"
+
+// authData holds the counters of the authentication since the start.
+type authData struct {
+	failures atomic.Int64
+	lockouts atomic.Int64
+	rejected atomic.Int64
+}
+
+// AuthStat are the counters of the authentication since the start.
+type AuthStat struct {
+	// Failures are the requests that failed to authenticate.
+	Failures int64 `json:"failures"`
+	// Lockouts are the times a client was locked out after failing.
+	Lockouts int64 `json:"lockouts"`
+	// Rejected are the requests rejected while their client was locked out.
+	Rejected int64 `json:"rejected"`
+}
+
+// load returns a snapshot of the counters.
+func (a *authData) load() AuthStat {
+	return AuthStat{
+		Failures: a.failures.Load(),
+		Lockouts: a.lockouts.Load(),
+		Rejected: a.rejected.Load(),
+	}
+}
+
+// IncAuthFailures increments the failed authentications counter.
+func (db *DBStats) IncAuthFailures() {
+	db.auth.failures.Add(1)
+}
+
+// IncAuthLockouts increments the lockouts counter.
+func (db *DBStats) IncAuthLockouts() {
+	db.auth.lockouts.Add(1)
+}
+
+// IncAuthRejected increments the counter of the requests rejected while
+// their client was locked out.
+func (db *DBStats) IncAuthRejected() {
+	db.auth.rejected.Add(1)
+}
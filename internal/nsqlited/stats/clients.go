@@ -0,0 +1,85 @@
+package stats
+
+import "sync/atomic"
+
+const (
+	// MaxClientLabels is the maximum number of distinct client labels tracked,
+	// once reached, new labels are aggregated under ClientLabelOther.
+	MaxClientLabels = 100
+	// ClientLabelOther is the label used for clients exceeding MaxClientLabels.
+	ClientLabelOther = "other"
+)
+
+// clientData holds the counters for a specific client label.
+type clientData struct {
+	reads  atomic.Int64
+	writes atomic.Int64
+	errors atomic.Int64
+}
+
+// getOrCreateClientData returns the *clientData for the given label. If the
+// label is not tracked yet and the MaxClientLabels cap was reached, the data
+// for ClientLabelOther is returned instead.
+//
+// The new labels are checked against the cap and stored under clientsMu, so
+// concurrent requests never track more than MaxClientLabels of them.
+func (db *DBStats) getOrCreateClientData(label string) *clientData {
+	if val, ok := db.clients.Load(label); ok {
+		return val.(*clientData)
+	}
+
+	db.clientsMu.Lock()
+	defer db.clientsMu.Unlock()
+
+	if val, ok := db.clients.Load(label); ok {
+		return val.(*clientData)
+	}
+	if label != ClientLabelOther {
+		if db.clientsCount >= MaxClientLabels {
+			return db.otherClientData()
+		}
+		db.clientsCount++
+	}
+
+	cd := &clientData{}
+	db.clients.Store(label, cd)
+	return cd
+}
+
+// otherClientData returns the *clientData for ClientLabelOther, creating it
+// if needed. The caller must hold clientsMu.
+func (db *DBStats) otherClientData() *clientData {
+	if val, ok := db.clients.Load(ClientLabelOther); ok {
+		return val.(*clientData)
+	}
+	cd := &clientData{}
+	db.clients.Store(ClientLabelOther, cd)
+	return cd
+}
+
+// IncClientReads increments the read counter for the given client label.
+// Empty labels are ignored.
+func (db *DBStats) IncClientReads(label string) {
+	if label == "" {
+		return
+	}
+	db.getOrCreateClientData(label).reads.Add(1)
+}
+
+// IncClientWrites increments the write counter for the given client label.
+// Empty labels are ignored.
+func (db *DBStats) IncClientWrites(label string) {
+	if label == "" {
+		return
+	}
+	db.getOrCreateClientData(label).writes.Add(1)
+}
+
+// IncClientErrors increments the error counter for the given client label.
+// Empty labels are ignored.
+func (db *DBStats) IncClientErrors(label string) {
+	if label == "" {
+		return
+	}
+	db.getOrCreateClientData(label).errors.Add(1)
+}
@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientStats(t *testing.T) {
+	t.Run("SplitByLabel", func(t *testing.T) {
+		dbStats := NewDBStats()
+		defer dbStats.Close()
+
+		dbStats.IncClientReads("a")
+		dbStats.IncClientReads("a")
+		dbStats.IncClientWrites("b")
+		dbStats.IncClientErrors("b")
+		dbStats.IncClientReads("")
+
+		loaded := dbStats.LoadStats()
+		assert.Equal(t, map[string]ClientStat{
+			"a": {Reads: 2},
+			"b": {Writes: 1, Errors: 1},
+		}, loaded.ByClient)
+	})
+
+	t.Run("CapDistinctLabels", func(t *testing.T) {
+		dbStats := NewDBStats()
+		defer dbStats.Close()
+
+		for i := range MaxClientLabels + 10 {
+			dbStats.IncClientReads(fmt.Sprintf("client%d", i))
+		}
+
+		loaded := dbStats.LoadStats()
+		assert.Len(t, loaded.ByClient, MaxClientLabels+1)
+		assert.Equal(t, int64(10), loaded.ByClient[ClientLabelOther].Reads)
+	})
+	t.Run("CapConcurrentLabels", func(t *testing.T) {
+		dbStats := NewDBStats()
+		defer dbStats.Close()
+
+		wg := sync.WaitGroup{}
+		for i := range 4 * MaxClientLabels {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				dbStats.IncClientReads(fmt.Sprintf("client%d", i))
+			}()
+		}
+		wg.Wait()
+
+		loaded := dbStats.LoadStats()
+		assert.Len(t, loaded.ByClient, MaxClientLabels+1)
+		assert.Equal(t, int64(3*MaxClientLabels), loaded.ByClient[ClientLabelOther].Reads)
+	})
+}
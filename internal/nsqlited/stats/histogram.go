@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+var (
+	// rowsReturnedBounds are the upper bounds of the rows returned histogram.
+	rowsReturnedBounds = []int64{0, 10, 100, 1_000, 10_000}
+	// responseBytesBounds are the upper bounds of the response bytes histogram.
+	responseBytesBounds = []int64{1 << 10, 10 << 10, 100 << 10, 1 << 20, 10 << 20}
+)
+
+// Histogram is a summary of the distribution of observed values.
+type Histogram struct {
+	Count   int64             `json:"count"`
+	Sum     int64             `json:"sum"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+// HistogramBucket holds the count of observations lower or equal than LE,
+// the last bucket LE is "+Inf".
+type HistogramBucket struct {
+	LE    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+// histogramData holds the non-cumulative bucket counters of a histogram, it
+// has one more bucket than bounds for the values greater than the last bound.
+type histogramData struct {
+	bounds  []int64
+	buckets []atomic.Int64
+	count   atomic.Int64
+	sum     atomic.Int64
+}
+
+// newHistogramData creates a histogramData with the given upper bounds.
+func newHistogramData(bounds []int64) *histogramData {
+	return &histogramData{
+		bounds:  bounds,
+		buckets: make([]atomic.Int64, len(bounds)+1),
+	}
+}
+
+// observe records the given value.
+func (h *histogramData) observe(value int64) {
+	idx := len(h.bounds)
+	for i, bound := range h.bounds {
+		if value <= bound {
+			idx = i
+			break
+		}
+	}
+
+	h.buckets[idx].Add(1)
+	h.count.Add(1)
+	h.sum.Add(value)
+}
+
+// load returns the Histogram for the current counters.
+func (h *histogramData) load() Histogram {
+	hist := Histogram{
+		Count:   h.count.Load(),
+		Sum:     h.sum.Load(),
+		Buckets: make([]HistogramBucket, len(h.buckets)),
+	}
+
+	for i := range h.buckets {
+		hist.Buckets[i] = HistogramBucket{
+			LE:    histogramBucketLE(h.bounds, i),
+			Count: h.buckets[i].Load(),
+		}
+	}
+
+	return hist
+}
+
+// histogramBucketLE returns the label of the bucket at the given index.
+func histogramBucketLE(bounds []int64, index int) string {
+	if index >= len(bounds) {
+		return "+Inf"
+	}
+	return strconv.FormatInt(bounds[index], 10)
+}
+
+// add merges other into h, both must have the same bounds.
+func (h *Histogram) add(other Histogram) {
+	for i := range other.Buckets {
+		h.Buckets[i].Count += other.Buckets[i].Count
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+}
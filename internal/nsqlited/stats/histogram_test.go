@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	hd := newHistogramData([]int64{0, 10, 100})
+	for _, value := range []int64{0, 1, 10, 11, 100, 101, 5000} {
+		hd.observe(value)
+	}
+
+	hist := hd.load()
+	assert.Equal(t, int64(7), hist.Count)
+	assert.Equal(t, int64(5223), hist.Sum)
+	assert.Equal(t, []HistogramBucket{
+		{LE: "0", Count: 1},
+		{LE: "10", Count: 2},
+		{LE: "100", Count: 2},
+		{LE: "+Inf", Count: 2},
+	}, hist.Buckets)
+
+	hist.add(hd.load())
+	assert.Equal(t, int64(14), hist.Count)
+	assert.Equal(t, int64(4), hist.Buckets[3].Count)
+}
@@ -6,37 +6,60 @@ import (
 )
 
 type LoadedStats struct {
-	StartedAt          string `json:"startedAt"`
-	Uptime             string `json:"uptime"`
-	QueuedWrites       int64  `json:"queuedWrites"`
-	QueuedHTTPRequests int64  `json:"queuedHttpRequests"`
-	Totals             Totals `json:"totals"`
-	Stats              []Stat `json:"stats"`
+	StartedAt          string                `json:"startedAt"`
+	Uptime             string                `json:"uptime"`
+	QueuedWrites       int64                 `json:"queuedWrites"`
+	QueuedHTTPRequests int64                 `json:"queuedHttpRequests"`
+	Totals             Totals                `json:"totals"`
+	Stats              []Stat                `json:"stats"`
+	Rates              LoadedRates           `json:"rates"`
+	ByClient           map[string]ClientStat `json:"byClient"`
+	Auth               AuthStat              `json:"auth"`
+	BusyRetries        int64                 `json:"busyRetries"`
 }
 
 type Totals struct {
-	Reads        int64 `json:"reads"`
-	Writes       int64 `json:"writes"`
-	Begins       int64 `json:"begins"`
-	Commits      int64 `json:"commits"`
-	Rollbacks    int64 `json:"rollbacks"`
-	Errors       int64 `json:"errors"`
-	HTTPRequests int64 `json:"httpRequests"`
+	Reads         int64     `json:"reads"`
+	Writes        int64     `json:"writes"`
+	Begins        int64     `json:"begins"`
+	Commits       int64     `json:"commits"`
+	Rollbacks     int64     `json:"rollbacks"`
+	Errors        int64     `json:"errors"`
+	HTTPRequests  int64     `json:"httpRequests"`
+	RowsReturned  Histogram `json:"rowsReturned"`
+	ResponseBytes Histogram `json:"responseBytes"`
+}
+
+type ClientStat struct {
+	Reads  int64 `json:"reads"`
+	Writes int64 `json:"writes"`
+	Errors int64 `json:"errors"`
 }
 
 type Stat struct {
-	Minute       string `json:"minute"`
-	Reads        int64  `json:"reads"`
-	Writes       int64  `json:"writes"`
-	Begins       int64  `json:"begins"`
-	Commits      int64  `json:"commits"`
-	Rollbacks    int64  `json:"rollbacks"`
-	Errors       int64  `json:"errors"`
-	HTTPRequests int64  `json:"httpRequests"`
+	Minute        string    `json:"minute"`
+	Reads         int64     `json:"reads"`
+	Writes        int64     `json:"writes"`
+	Begins        int64     `json:"begins"`
+	Commits       int64     `json:"commits"`
+	Rollbacks     int64     `json:"rollbacks"`
+	Errors        int64     `json:"errors"`
+	HTTPRequests  int64     `json:"httpRequests"`
+	RowsReturned  Histogram `json:"rowsReturned"`
+	ResponseBytes Histogram `json:"responseBytes"`
 }
 
-// LoadStats loads all internal stats into a LoadedStats struct.
+// LoadStats loads all internal stats into a LoadedStats struct. If the
+// DBStats is closed, it returns the final snapshot taken by Close.
 func (db *DBStats) LoadStats() LoadedStats {
+	if finalStats := db.finalStats.Load(); finalStats != nil {
+		return *finalStats
+	}
+	return db.loadStats()
+}
+
+// loadStats is the underlying logic for LoadStats.
+func (db *DBStats) loadStats() LoadedStats {
 	var (
 		allStats          []Stat = []Stat{}
 		totalReads        int64
@@ -46,6 +69,8 @@ func (db *DBStats) LoadStats() LoadedStats {
 		totalRollbacks    int64
 		totalErrors       int64
 		totalHTTPRequests int64
+		rowsReturned      Histogram = newHistogramData(rowsReturnedBounds).load()
+		responseBytes     Histogram = newHistogramData(responseBytesBounds).load()
 	)
 
 	db.minutes.Range(func(key, value any) bool {
@@ -59,6 +84,8 @@ func (db *DBStats) LoadStats() LoadedStats {
 		rb := md.rollbacks.Load()
 		er := md.errors.Load()
 		hr := md.httpRequests.Load()
+		rr := md.rowsReturned.load()
+		rs := md.responseBytes.load()
 
 		totalReads += r
 		totalWrites += w
@@ -67,16 +94,20 @@ func (db *DBStats) LoadStats() LoadedStats {
 		totalRollbacks += rb
 		totalErrors += er
 		totalHTTPRequests += hr
+		rowsReturned.add(rr)
+		responseBytes.add(rs)
 
 		allStats = append(allStats, Stat{
-			Minute:       minuteKey,
-			Reads:        r,
-			Writes:       w,
-			Begins:       b,
-			Commits:      c,
-			Rollbacks:    rb,
-			Errors:       er,
-			HTTPRequests: hr,
+			Minute:        minuteKey,
+			Reads:         r,
+			Writes:        w,
+			Begins:        b,
+			Commits:       c,
+			Rollbacks:     rb,
+			Errors:        er,
+			HTTPRequests:  hr,
+			RowsReturned:  rr,
+			ResponseBytes: rs,
 		})
 
 		return true
@@ -88,20 +119,37 @@ func (db *DBStats) LoadStats() LoadedStats {
 		return tj.Before(ti)
 	})
 
+	byClient := map[string]ClientStat{}
+	db.clients.Range(func(key, value any) bool {
+		cd := value.(*clientData)
+		byClient[key.(string)] = ClientStat{
+			Reads:  cd.reads.Load(),
+			Writes: cd.writes.Load(),
+			Errors: cd.errors.Load(),
+		}
+		return true
+	})
+
 	return LoadedStats{
 		Totals: Totals{
-			Reads:        totalReads,
-			Writes:       totalWrites,
-			Begins:       totalBegins,
-			Commits:      totalCommits,
-			Rollbacks:    totalRollbacks,
-			Errors:       totalErrors,
-			HTTPRequests: totalHTTPRequests,
+			Reads:         totalReads,
+			Writes:        totalWrites,
+			Begins:        totalBegins,
+			Commits:       totalCommits,
+			Rollbacks:     totalRollbacks,
+			Errors:        totalErrors,
+			HTTPRequests:  totalHTTPRequests,
+			RowsReturned:  rowsReturned,
+			ResponseBytes: responseBytes,
 		},
 		Stats:              allStats,
+		Rates:              db.loadRates(db.now()),
+		ByClient:           byClient,
+		Auth:               db.auth.load(),
+		BusyRetries:        db.busyRetries.Load(),
 		QueuedWrites:       db.queuedWrites.Load(),
 		QueuedHTTPRequests: db.queuedHTTPRequests.Load(),
 		StartedAt:          db.startedAt.Format(time.RFC3339),
-		Uptime:             time.Since(db.startedAt).Round(time.Second).String(),
+		Uptime:             db.now().Sub(db.startedAt).Round(time.Second).String(),
 	}
 }
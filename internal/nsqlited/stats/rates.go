@@ -0,0 +1,60 @@
+package stats
+
+import "time"
+
+// Rates holds per-second rates computed over a window of full minutes.
+type Rates struct {
+	ReadsPerSecond        float64 `json:"readsPerSecond"`
+	WritesPerSecond       float64 `json:"writesPerSecond"`
+	ErrorsPerSecond       float64 `json:"errorsPerSecond"`
+	HTTPRequestsPerSecond float64 `json:"httpRequestsPerSecond"`
+}
+
+// LoadedRates holds the rates for the most recent full minute and the rolling
+// average of the last five full minutes.
+type LoadedRates struct {
+	LastMinute   Rates `json:"lastMinute"`
+	Last5Minutes Rates `json:"last5Minutes"`
+}
+
+// loadRates computes the per-second rates relative to now.
+//
+// The current minute is still being filled, so it is always excluded and the
+// windows end at the start of the current minute. Minutes without a bucket
+// (no activity or before the server started) count as zero.
+func (db *DBStats) loadRates(now time.Time) LoadedRates {
+	currentMinute := now.UTC().Truncate(time.Minute)
+
+	return LoadedRates{
+		LastMinute:   db.loadRatesWindow(currentMinute, 1),
+		Last5Minutes: db.loadRatesWindow(currentMinute, 5),
+	}
+}
+
+// loadRatesWindow computes the per-second rates of the given amount of full
+// minutes before currentMinute.
+func (db *DBStats) loadRatesWindow(currentMinute time.Time, minutes int) Rates {
+	var reads, writes, errors, httpRequests int64
+
+	for i := 1; i <= minutes; i++ {
+		minuteKey := currentMinute.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339)
+		val, ok := db.minutes.Load(minuteKey)
+		if !ok {
+			continue
+		}
+
+		md := val.(*minuteData)
+		reads += md.reads.Load()
+		writes += md.writes.Load()
+		errors += md.errors.Load()
+		httpRequests += md.httpRequests.Load()
+	}
+
+	seconds := float64(minutes * 60)
+	return Rates{
+		ReadsPerSecond:        float64(reads) / seconds,
+		WritesPerSecond:       float64(writes) / seconds,
+		ErrorsPerSecond:       float64(errors) / seconds,
+		HTTPRequestsPerSecond: float64(httpRequests) / seconds,
+	}
+}
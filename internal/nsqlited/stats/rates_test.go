@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seedMinute stores a bucket for the given minute with the given reads and
+// writes.
+func seedMinute(db *DBStats, minute time.Time, reads int64, writes int64) {
+	md := newMinuteData()
+	md.reads.Store(reads)
+	md.writes.Store(writes)
+	db.minutes.Store(minute.UTC().Truncate(time.Minute).Format(time.RFC3339), md)
+}
+
+func TestLoadRates(t *testing.T) {
+	currentMinute := time.Date(2025, 1, 1, 12, 10, 0, 0, time.UTC)
+	now := currentMinute.Add(30 * time.Second)
+
+	t.Run("FullMinutes", func(t *testing.T) {
+		dbStats := NewDBStats()
+		defer dbStats.Close()
+		dbStats.now = func() time.Time { return now }
+
+		seedMinute(dbStats, currentMinute.Add(-1*time.Minute), 120, 60)
+		seedMinute(dbStats, currentMinute.Add(-2*time.Minute), 60, 0)
+		seedMinute(dbStats, currentMinute.Add(-5*time.Minute), 120, 0)
+		seedMinute(dbStats, currentMinute.Add(-6*time.Minute), 6000, 6000)
+
+		rates := dbStats.LoadStats().Rates
+		assert.Equal(t, 2.0, rates.LastMinute.ReadsPerSecond)
+		assert.Equal(t, 1.0, rates.LastMinute.WritesPerSecond)
+		assert.Equal(t, 1.0, rates.Last5Minutes.ReadsPerSecond)
+		assert.Equal(t, 0.2, rates.Last5Minutes.WritesPerSecond)
+	})
+
+	t.Run("PartialCurrentMinuteExcluded", func(t *testing.T) {
+		dbStats := NewDBStats()
+		defer dbStats.Close()
+		dbStats.now = func() time.Time { return now }
+
+		seedMinute(dbStats, currentMinute, 3000, 3000)
+
+		rates := dbStats.LoadStats().Rates
+		assert.Equal(t, Rates{}, rates.LastMinute)
+		assert.Equal(t, Rates{}, rates.Last5Minutes)
+	})
+
+	t.Run("CountersUseClock", func(t *testing.T) {
+		dbStats := NewDBStats()
+		defer dbStats.Close()
+		dbStats.now = func() time.Time { return currentMinute.Add(-30 * time.Second) }
+
+		for range 30 {
+			dbStats.IncReads()
+		}
+
+		dbStats.now = func() time.Time { return now }
+		rates := dbStats.LoadStats().Rates
+		assert.Equal(t, 0.5, rates.LastMinute.ReadsPerSecond)
+		assert.Equal(t, 0.1, rates.Last5Minutes.ReadsPerSecond)
+	})
+}
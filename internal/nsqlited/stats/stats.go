@@ -15,34 +15,66 @@ type minuteData struct {
 	rollbacks    atomic.Int64
 	errors       atomic.Int64
 	httpRequests atomic.Int64
+
+	rowsReturned  *histogramData
+	responseBytes *histogramData
+}
+
+// newMinuteData creates an empty minuteData.
+func newMinuteData() *minuteData {
+	return &minuteData{
+		rowsReturned:  newHistogramData(rowsReturnedBounds),
+		responseBytes: newHistogramData(responseBytesBounds),
+	}
 }
 
 // DBStats holds the stats for the database.
 type DBStats struct {
+	now                func() time.Time
 	startedAt          time.Time
 	minutes            sync.Map // key: string (minute RFC3339) -> value: *minuteData
 	queuedWrites       atomic.Int64
 	queuedHTTPRequests atomic.Int64
+	clients            sync.Map // key: string (client label) -> value: *clientData
+	clientsMu          sync.Mutex
+	clientsCount       int // guarded by clientsMu
+	auth               authData
+	busyRetries        atomic.Int64
 	stopChan           chan bool
+	closeOnce          sync.Once
+	closeWg            sync.WaitGroup
+	finalStats         atomic.Pointer[LoadedStats]
 }
 
 // NewDBStats creates a DBStats instance.
 func NewDBStats() *DBStats {
 	db := &DBStats{
+		now:       time.Now,
 		startedAt: time.Now().UTC(),
 		stopChan:  make(chan bool),
 	}
+	db.closeWg.Add(1)
 	go db.runCleanupWorker()
 	return db
 }
 
-// Close stops the background cleanup worker.
+// Close stops all the background workers and waits for them to exit. After
+// Close, LoadStats returns the snapshot taken when closing.
+//
+// It is safe to call Close multiple times and from multiple goroutines.
 func (db *DBStats) Close() {
-	close(db.stopChan)
+	db.closeOnce.Do(func() {
+		close(db.stopChan)
+		db.closeWg.Wait()
+
+		finalStats := db.loadStats()
+		db.finalStats.Store(&finalStats)
+	})
 }
 
 // runCleanupWorker removes stats older than 24 hours every 10 seconds.
 func (db *DBStats) runCleanupWorker() {
+	defer db.closeWg.Done()
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
@@ -70,10 +102,10 @@ func (db *DBStats) runCleanupWorker() {
 // getOrCreateMinuteData returns a *minuteData for the current minute (UTC).
 // If none exists, it creates one.
 func (db *DBStats) getOrCreateMinuteData() *minuteData {
-	minuteKey := time.Now().UTC().Truncate(time.Minute).Format(time.RFC3339)
+	minuteKey := db.now().UTC().Truncate(time.Minute).Format(time.RFC3339)
 	val, ok := db.minutes.Load(minuteKey)
 	if !ok {
-		md := &minuteData{}
+		md := newMinuteData()
 		actual, loaded := db.minutes.LoadOrStore(minuteKey, md)
 		if loaded {
 			return actual.(*minuteData)
@@ -125,6 +157,20 @@ func (db *DBStats) IncHTTPRequests() {
 	md.httpRequests.Add(1)
 }
 
+// ObserveRead records the rows returned by a read query and the approximate
+// size in bytes of its serialized result for the current minute.
+func (db *DBStats) ObserveRead(rows int64, bytes int64) {
+	md := db.getOrCreateMinuteData()
+	md.rowsReturned.observe(rows)
+	md.responseBytes.observe(bytes)
+}
+
+// IncBusyRetries increments the counter of the retries of the statements
+// that found the database locked by another connection.
+func (db *DBStats) IncBusyRetries() {
+	db.busyRetries.Add(1)
+}
+
 // IncQueuedWrites increments the queued writes counter atomically.
 func (db *DBStats) IncQueuedWrites() {
 	db.queuedWrites.Add(1)
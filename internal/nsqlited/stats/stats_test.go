@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBStatsClose(t *testing.T) {
+	t.Run("Idempotent", func(t *testing.T) {
+		dbStats := NewDBStats()
+		assert.NotPanics(t, func() {
+			dbStats.Close()
+			dbStats.Close()
+		})
+	})
+
+	t.Run("ConcurrentCloseAndLoad", func(t *testing.T) {
+		dbStats := NewDBStats()
+		dbStats.IncReads()
+		dbStats.IncWrites()
+
+		wg := sync.WaitGroup{}
+		for range 10 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				dbStats.Close()
+			}()
+			go func() {
+				defer wg.Done()
+				_ = dbStats.LoadStats()
+			}()
+		}
+		wg.Wait()
+
+		loaded := dbStats.LoadStats()
+		assert.Equal(t, int64(1), loaded.Totals.Reads)
+		assert.Equal(t, int64(1), loaded.Totals.Writes)
+	})
+
+	t.Run("FinalSnapshotAfterClose", func(t *testing.T) {
+		dbStats := NewDBStats()
+		dbStats.IncReads()
+		dbStats.Close()
+
+		dbStats.IncReads()
+		assert.Equal(t, int64(1), dbStats.LoadStats().Totals.Reads)
+	})
+}
@@ -0,0 +1,187 @@
+package testutil_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/testutil"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countRows returns the number of rows of the table.
+func countRows(t *testing.T, client *apiclient.Client, table string) json.Number {
+	t.Helper()
+	return testutil.Query(t, client, "", "SELECT count(*) FROM "+table).Rows[0][0].(json.Number)
+}
+
+func TestIntegrationTransaction(t *testing.T) {
+	s := testutil.StartServer(t, testutil.ServerConfig{})
+	testutil.Query(t, s.Client, "", "CREATE TABLE users (name TEXT)")
+
+	t.Run("Commit", func(t *testing.T) {
+		txId := testutil.Query(t, s.Client, "", "BEGIN").TxId
+		require.NotEmpty(t, txId)
+
+		rows := testutil.Query(t, s.Client, txId, "INSERT INTO users (name) VALUES ('alice') RETURNING name").Rows
+		assert.Equal(t, [][]any{{"alice"}}, rows)
+		assert.Equal(t, json.Number("0"), countRows(t, s.Client, "users"), "not visible before the commit")
+
+		assert.Equal(t, txId, testutil.Query(t, s.Client, txId, "COMMIT").TxId)
+		assert.Equal(t, json.Number("1"), countRows(t, s.Client, "users"))
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		txId := testutil.Query(t, s.Client, "", "BEGIN").TxId
+		testutil.Query(t, s.Client, txId, "INSERT INTO users (name) VALUES ('bob')")
+		testutil.Query(t, s.Client, txId, "ROLLBACK")
+
+		assert.Equal(t, json.Number("1"), countRows(t, s.Client, "users"))
+	})
+
+	t.Run("Ended transaction", func(t *testing.T) {
+		txId := testutil.Query(t, s.Client, "", "BEGIN").TxId
+		testutil.Query(t, s.Client, txId, "COMMIT")
+
+		results, err := s.Client.SendQueries(context.Background(), []nsqlitehttp.Query{
+			{Query: "INSERT INTO users (name) VALUES ('carol')", TxId: txId},
+			{Query: "COMMIT", TxId: txId},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, results[0].Error, db.ErrTxNotMatch.Error())
+		assert.Contains(t, results[1].Error, db.ErrTxNotFound.Error())
+	})
+
+	t.Run("Only one transaction at a time", func(t *testing.T) {
+		txId := testutil.Query(t, s.Client, "", "BEGIN").TxId
+		defer testutil.Query(t, s.Client, txId, "ROLLBACK")
+
+		results, err := s.Client.SendQueries(context.Background(), []nsqlitehttp.Query{{Query: "BEGIN"}})
+		require.NoError(t, err)
+		assert.Contains(t, results[0].Error, db.ErrTxWithinTx.Error())
+	})
+
+	stats := s.DBStats.LoadStats().Totals
+	assert.EqualValues(t, 4, stats.Begins)
+	assert.EqualValues(t, 2, stats.Commits)
+	assert.EqualValues(t, 2, stats.Rollbacks)
+}
+
+func TestIntegrationAuth(t *testing.T) {
+	t.Run("Auth enabled", func(t *testing.T) {
+		s := testutil.StartServer(t, testutil.ServerConfig{AuthToken: "secret"})
+		testutil.Query(t, s.Client, "", "SELECT 1")
+
+		for _, token := range []string{"", "wrong"} {
+			_, err := s.NewClient(t, token).SendQueries(context.Background(), []nsqlitehttp.Query{{Query: "SELECT 1"}})
+			serverErr := &apiclient.ServerError{}
+			require.ErrorAs(t, err, &serverErr, "token %q", token)
+			assert.Equal(t, http.StatusUnauthorized, serverErr.Status)
+		}
+
+		res, err := http.Get(s.URL + "/health")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode, "the health checks are not authenticated")
+	})
+
+	t.Run("Auth disabled", func(t *testing.T) {
+		s := testutil.StartServer(t, testutil.ServerConfig{})
+		testutil.Query(t, s.NewClient(t, ""), "", "SELECT 1")
+		testutil.Query(t, s.NewClient(t, "any"), "", "SELECT 1")
+	})
+}
+
+func TestIntegrationConcurrentWriters(t *testing.T) {
+	const writers, inserts = 8, 25
+
+	s := testutil.StartServer(t, testutil.ServerConfig{})
+	testutil.Query(t, s.Client, "", "CREATE TABLE events (writer INTEGER, n INTEGER)")
+
+	wg := sync.WaitGroup{}
+	for writer := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := s.NewClient(t, "")
+			for n := range inserts {
+				_, err := client.SendQueries(context.Background(), []nsqlitehttp.Query{
+					{Query: fmt.Sprintf("INSERT INTO events (writer, n) VALUES (%d, %d)", writer, n)},
+				})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, json.Number(fmt.Sprint(writers*inserts)), countRows(t, s.Client, "events"))
+	rows := testutil.Query(t, s.Client, "", "SELECT count(DISTINCT writer || ':' || n) FROM events").Rows
+	assert.Equal(t, json.Number(fmt.Sprint(writers*inserts)), rows[0][0], "every insert is applied once")
+}
+
+func TestIntegrationIdleTimeout(t *testing.T) {
+	s := testutil.StartServer(t, testutil.ServerConfig{TxIdleTimeout: 100 * time.Millisecond})
+	testutil.Query(t, s.Client, "", "CREATE TABLE users (name TEXT)")
+
+	txId := testutil.Query(t, s.Client, "", "BEGIN").TxId
+	testutil.Query(t, s.Client, txId, "INSERT INTO users (name) VALUES ('alice')")
+
+	require.Eventually(t, func() bool {
+		return s.DBStats.LoadStats().Totals.Rollbacks == 1
+	}, 5*time.Second, 10*time.Millisecond, "the idle transaction is rolled back")
+
+	results, err := s.Client.SendQueries(context.Background(), []nsqlitehttp.Query{
+		{Query: "SELECT name FROM users", TxId: txId},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, results[0].Error, db.ErrTxNotMatch.Error(), "the client sees the rollback")
+	assert.Equal(t, json.Number("0"), countRows(t, s.Client, "users"))
+
+	txId = testutil.Query(t, s.Client, "", "BEGIN").TxId
+	testutil.Query(t, s.Client, txId, "COMMIT")
+}
+
+func TestIntegrationGracefulShutdown(t *testing.T) {
+	s := testutil.StartServer(t, testutil.ServerConfig{})
+	testutil.Query(t, s.Client, "", "CREATE TABLE users (name TEXT)")
+	testutil.Query(t, s.Client, "", "INSERT INTO users (name) VALUES ('alice')")
+	txId := testutil.Query(t, s.Client, "", "BEGIN").TxId
+	testutil.Query(t, s.Client, txId, "INSERT INTO users (name) VALUES ('bob')")
+
+	// A slow query is in flight while the server stops.
+	type result struct {
+		results []nsqlitehttp.QueryResponse
+		err     error
+	}
+	slow := make(chan result, 1)
+	go func() {
+		results, err := s.NewClient(t, "").SendQueries(context.Background(), []nsqlitehttp.Query{{
+			Query: "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 2000000) " +
+				"SELECT count(*) FROM c",
+		}})
+		slow <- result{results, err}
+	}()
+	require.Eventually(t, func() bool {
+		return s.DBStats.LoadStats().QueuedHTTPRequests == 1
+	}, 5*time.Second, time.Millisecond)
+
+	require.NoError(t, s.Stop())
+	res := <-slow
+	require.NoError(t, res.err, "the requests in flight are answered")
+	assert.Equal(t, [][]any{{json.Number("2000000")}}, res.results[0].Rows)
+
+	_, err := s.Client.SendQueries(context.Background(), []nsqlitehttp.Query{{Query: "SELECT 1"}})
+	assert.Error(t, err, "no request is accepted once stopped")
+
+	restarted := testutil.StartServer(t, testutil.ServerConfig{DataDirectory: s.DataDirectory})
+	rows := testutil.Query(t, restarted.Client, "", "SELECT name FROM users").Rows
+	assert.Equal(t, [][]any{{"alice"}}, rows, "the open transaction is rolled back")
+}
@@ -0,0 +1,174 @@
+// Package testutil boots a real NSQLite server, with its database and stats
+// in a temporary data directory, for the tests that go through the HTTP
+// server and the client like the users do.
+package testutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nsqlite/nsqlite/internal/nsqlite/apiclient"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/db"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/log"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/server"
+	"github.com/nsqlite/nsqlite/internal/nsqlited/stats"
+	"github.com/nsqlite/nsqlitego/nsqlitedsn"
+	"github.com/nsqlite/nsqlitego/nsqlitehttp"
+	"github.com/stretchr/testify/require"
+)
+
+// ServerConfig is the configuration of a test server, the zero value is a
+// server without authentication in a new temporary data directory.
+type ServerConfig struct {
+	// DataDirectory is the data directory of the server, a new temporary
+	// directory if empty. Reuse the one of a stopped server to restart it.
+	DataDirectory string
+	// AuthToken is the plaintext auth token of the server, no
+	// authentication is required if empty.
+	AuthToken string
+	// TxIdleTimeout is the transaction idle timeout of the database, 10
+	// seconds if zero.
+	TxIdleTimeout time.Duration
+	// Logger is the logger of the server, the logs are discarded if it is
+	// not initialized.
+	Logger log.Logger
+}
+
+// Server is a NSQLite server listening on an ephemeral port of the
+// loopback interface.
+type Server struct {
+	// URL is the base URL of the server, like http://127.0.0.1:41234.
+	URL string
+	// DataDirectory is the data directory of the server.
+	DataDirectory string
+	// Client is a client of the server that sends the auth token of the
+	// server, if any.
+	Client *apiclient.Client
+
+	DB      *db.DB
+	DBStats *stats.DBStats
+	Server  *server.Server
+
+	// transport is the transport of the clients of the server, its idle
+	// connections are closed before stopping the server because it waits
+	// for the connections that were dialed but never used.
+	transport *http.Transport
+	stopOnce  sync.Once
+	stopErr   error
+	served    chan error
+}
+
+// StartServer starts a server and waits until it answers the health
+// checks. The server is stopped when the test ends, if it was not already
+// stopped with Stop.
+func StartServer(t testing.TB, config ServerConfig) *Server {
+	t.Helper()
+
+	if config.DataDirectory == "" {
+		config.DataDirectory = t.TempDir()
+	}
+	if config.TxIdleTimeout == 0 {
+		config.TxIdleTimeout = 10 * time.Second
+	}
+	if !config.Logger.IsInitialized() {
+		config.Logger = log.NewLogger(io.Discard)
+	}
+
+	dbStats := stats.NewDBStats()
+	dbInstance, err := db.NewDB(db.Config{
+		Logger:        config.Logger,
+		DBStats:       dbStats,
+		DataDirectory: config.DataDirectory,
+		TxIdleTimeout: config.TxIdleTimeout,
+		BusyTimeout:   5 * time.Second,
+	})
+	if err != nil {
+		dbStats.Close()
+		require.NoError(t, err)
+	}
+
+	serv, err := server.NewServer(server.Config{
+		Logger:     config.Logger,
+		DBStats:    dbStats,
+		DB:         dbInstance,
+		ListenHost: "127.0.0.1",
+		ListenPort: "0",
+		AuthToken:  config.AuthToken,
+	})
+	if err == nil {
+		err = serv.Listen()
+	}
+	if err != nil {
+		_ = dbInstance.Close()
+		dbStats.Close()
+		require.NoError(t, err)
+	}
+
+	s := &Server{
+		URL:           "http://" + serv.Addrs()[0].String(),
+		DataDirectory: config.DataDirectory,
+		DB:            dbInstance,
+		DBStats:       dbStats,
+		Server:        serv,
+		transport:     http.DefaultTransport.(*http.Transport).Clone(),
+		served:        make(chan error, 1),
+	}
+	go func() { s.served <- serv.Serve() }()
+	t.Cleanup(func() { require.NoError(t, s.Stop()) })
+
+	s.Client = s.NewClient(t, config.AuthToken)
+	healthClient := http.Client{Transport: s.transport}
+	require.Eventually(t, func() bool {
+		res, err := healthClient.Get(s.URL + "/health")
+		if err != nil {
+			return false
+		}
+		defer res.Body.Close()
+		return res.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond, "the server is not healthy")
+
+	return s
+}
+
+// NewClient returns a client of the server that sends the given auth
+// token, none if empty.
+func (s *Server) NewClient(t testing.TB, authToken string) *apiclient.Client {
+	t.Helper()
+
+	connStr, err := nsqlitedsn.NewConnStrFromText(s.URL + "?authToken=" + authToken)
+	require.NoError(t, err)
+	return apiclient.NewClient(connStr, apiclient.WithTransport(s.transport))
+}
+
+// Stop gracefully stops the server, waiting for the requests in flight,
+// then closes the database and the stats. It can be called more than once.
+func (s *Server) Stop() error {
+	s.stopOnce.Do(func() {
+		s.transport.CloseIdleConnections()
+		stopErr := s.Server.Stop()
+		serveErr := <-s.served
+		closeErr := s.DB.Close()
+		s.DBStats.Close()
+		s.stopErr = errors.Join(stopErr, serveErr, closeErr)
+	})
+	return s.stopErr
+}
+
+// Query sends the query with the client, within the transaction if txId is
+// not empty, and returns its result. The test fails if the request or the
+// query fails.
+func Query(t testing.TB, client *apiclient.Client, txId string, query string) nsqlitehttp.QueryResponse {
+	t.Helper()
+
+	results, err := client.SendQueries(context.Background(), []nsqlitehttp.Query{
+		{Query: query, TxId: txId},
+	})
+	require.NoError(t, err)
+	require.Empty(t, results[0].Error, "query %q failed", query)
+	return results[0]
+}
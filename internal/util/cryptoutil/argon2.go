@@ -1,10 +1,98 @@
 package cryptoutil
 
-import "github.com/matthewhartstonge/argon2"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/matthewhartstonge/argon2"
+)
+
+// Argon2Params are the parameters of the argon2id hashes.
+type Argon2Params struct {
+	// Memory is the memory used by the hash in kibibytes.
+	Memory uint32
+	// Iterations is the number of passes over the memory.
+	Iterations uint32
+	// Parallelism is the number of threads.
+	Parallelism uint8
+	// SaltLen is the length of the random salt in bytes.
+	SaltLen uint32
+	// KeyLen is the length of the hash in bytes.
+	KeyLen uint32
+}
+
+// The limits of the Argon2Params, out of them the parameters are either too
+// weak or so expensive that checking a hash could exhaust the server.
+const (
+	argon2MaxMemory      = 4 * 1024 * 1024
+	argon2MaxIterations  = 100
+	argon2MaxParallelism = 64
+	argon2MinSaltLen     = 8
+	argon2MaxSaltLen     = 64
+	argon2MinKeyLen      = 16
+	argon2MaxKeyLen      = 64
+)
+
+// ErrArgon2InvalidHash is returned for the hashes that are not argon2
+// hashes.
+var ErrArgon2InvalidHash = errors.New("invalid argon2 hash")
+
+// DefaultArgon2Params returns the parameters of the hashes when none are
+// given: 64 MiB of memory, 3 iterations and 4 threads, as recommended by
+// RFC 9106 for memory constrained environments.
+func DefaultArgon2Params() Argon2Params {
+	config := argon2.DefaultConfig()
+	return Argon2Params{
+		Memory:      config.MemoryCost,
+		Iterations:  config.TimeCost,
+		Parallelism: config.Parallelism,
+		SaltLen:     config.SaltLength,
+		KeyLen:      config.HashLength,
+	}
+}
+
+// Validate returns an error if the parameters are out of their limits.
+func (p Argon2Params) Validate() error {
+	switch {
+	case p.Parallelism < 1 || p.Parallelism > argon2MaxParallelism:
+		return fmt.Errorf("invalid argon2 parallelism %d, valid values are 1-%d", p.Parallelism, argon2MaxParallelism)
+	case p.Memory < 8*uint32(p.Parallelism) || p.Memory > argon2MaxMemory:
+		return fmt.Errorf(
+			"invalid argon2 memory %d KiB, valid values are %d-%d KiB",
+			p.Memory, 8*uint32(p.Parallelism), argon2MaxMemory,
+		)
+	case p.Iterations < 1 || p.Iterations > argon2MaxIterations:
+		return fmt.Errorf("invalid argon2 iterations %d, valid values are 1-%d", p.Iterations, argon2MaxIterations)
+	case p.SaltLen < argon2MinSaltLen || p.SaltLen > argon2MaxSaltLen:
+		return fmt.Errorf(
+			"invalid argon2 salt length %d, valid values are %d-%d", p.SaltLen, argon2MinSaltLen, argon2MaxSaltLen,
+		)
+	case p.KeyLen < argon2MinKeyLen || p.KeyLen > argon2MaxKeyLen:
+		return fmt.Errorf(
+			"invalid argon2 key length %d, valid values are %d-%d", p.KeyLen, argon2MinKeyLen, argon2MaxKeyLen,
+		)
+	}
+	return nil
+}
+
+// Argon2GenerateHash generates an argon2id hash of the given password.
+//
+// The params are optional. If not provided, DefaultArgon2Params are used.
+func Argon2GenerateHash(password string, params ...Argon2Params) (string, error) {
+	picked := DefaultArgon2Params()
+	if len(params) > 0 {
+		picked = params[0]
+	}
+	if err := picked.Validate(); err != nil {
+		return "", err
+	}
 
-// Argon2GenerateHash generates an argon2 hash of the given password.
-func Argon2GenerateHash(password string) (string, error) {
 	argon := argon2.DefaultConfig()
+	argon.MemoryCost = picked.Memory
+	argon.TimeCost = picked.Iterations
+	argon.Parallelism = picked.Parallelism
+	argon.SaltLength = picked.SaltLen
+	argon.HashLength = picked.KeyLen
 	hash, err := argon.HashEncoded([]byte(password))
 	if err != nil {
 		return "", err
@@ -12,11 +100,58 @@ func Argon2GenerateHash(password string) (string, error) {
 	return string(hash), nil
 }
 
-// Argon2CheckHash checks if the given password matches the given argon2 hash.
+// Argon2ParseParams returns the parameters of an encoded argon2 hash.
+func Argon2ParseParams(hash string) (Argon2Params, error) {
+	raw, err := argon2.Decode([]byte(hash))
+	if err != nil {
+		return Argon2Params{}, fmt.Errorf("%w: %w", ErrArgon2InvalidHash, err)
+	}
+	return rawParams(raw), nil
+}
+
+// rawParams returns the parameters of a decoded argon2 hash.
+func rawParams(raw argon2.Raw) Argon2Params {
+	return Argon2Params{
+		Memory:      raw.Config.MemoryCost,
+		Iterations:  raw.Config.TimeCost,
+		Parallelism: raw.Config.Parallelism,
+		SaltLen:     uint32(len(raw.Salt)),
+		KeyLen:      uint32(len(raw.Hash)),
+	}
+}
+
+// Argon2CheckHash checks if the given password matches the given argon2
+// hash, with the parameters encoded in the hash. The hashes with parameters
+// out of the limits of Argon2Params never match.
 func Argon2CheckHash(password string, hash string) bool {
-	ok, err := argon2.VerifyEncoded([]byte(password), []byte(hash))
+	raw, err := argon2.Decode([]byte(hash))
+	if err != nil {
+		return false
+	}
+	if rawParams(raw).Validate() != nil {
+		return false
+	}
+
+	ok, err := raw.Verify([]byte(password))
 	if err != nil {
 		return false
 	}
 	return ok
 }
+
+// Argon2NeedsRehash reports whether the hash must be generated again with
+// the params: it is not an argon2id hash or one of its parameters is weaker.
+func Argon2NeedsRehash(hash string, params Argon2Params) (bool, error) {
+	raw, err := argon2.Decode([]byte(hash))
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrArgon2InvalidHash, err)
+	}
+
+	current := rawParams(raw)
+	return raw.Config.Mode != argon2.ModeArgon2id ||
+		current.Memory < params.Memory ||
+		current.Iterations < params.Iterations ||
+		current.Parallelism < params.Parallelism ||
+		current.SaltLen < params.SaltLen ||
+		current.KeyLen < params.KeyLen, nil
+}
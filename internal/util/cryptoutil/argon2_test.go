@@ -87,3 +87,103 @@ func TestArgon2CheckHash(t *testing.T) {
 		})
 	}
 }
+
+func TestArgon2Params(t *testing.T) {
+	// cheap are parameters fast enough for the tests.
+	cheap := Argon2Params{Memory: 64, Iterations: 2, Parallelism: 1, SaltLen: 16, KeyLen: 32}
+
+	t.Run("Generate with custom params", func(t *testing.T) {
+		hash, err := Argon2GenerateHash("SecureP@ssw0rd!", cheap)
+		assert.NoError(t, err)
+		assert.Contains(t, hash, "$argon2id$v=19$m=64,t=2,p=1$")
+		assert.True(t, Argon2CheckHash("SecureP@ssw0rd!", hash))
+		assert.False(t, Argon2CheckHash("WrongPassword", hash))
+
+		params, err := Argon2ParseParams(hash)
+		assert.NoError(t, err)
+		assert.Equal(t, cheap, params)
+	})
+
+	t.Run("Parse params of hardcoded hash", func(t *testing.T) {
+		params, err := Argon2ParseParams("$argon2i$v=19$m=16,t=2,p=1$YmdnaGIzcjQyMzU0d2VyZ2Y$Bi7u7sDIGW2enDW/y4ZhmQ")
+		assert.NoError(t, err)
+		assert.Equal(t, Argon2Params{Memory: 16, Iterations: 2, Parallelism: 1, SaltLen: 17, KeyLen: 16}, params)
+
+		_, err = Argon2ParseParams("invalidhash")
+		assert.ErrorIs(t, err, ErrArgon2InvalidHash)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		assert.NoError(t, DefaultArgon2Params().Validate())
+		assert.NoError(t, cheap.Validate())
+
+		invalid := map[string]func(p *Argon2Params){
+			"zero memory":        func(p *Argon2Params) { p.Memory = 0 },
+			"memory of 8 GiB":    func(p *Argon2Params) { p.Memory = 8 * 1024 * 1024 },
+			"zero iterations":    func(p *Argon2Params) { p.Iterations = 0 },
+			"10000 iterations":   func(p *Argon2Params) { p.Iterations = 10_000 },
+			"zero parallelism":   func(p *Argon2Params) { p.Parallelism = 0 },
+			"memory per thread":  func(p *Argon2Params) { p.Parallelism = 16 },
+			"short salt":         func(p *Argon2Params) { p.SaltLen = 4 },
+			"short key":          func(p *Argon2Params) { p.KeyLen = 8 },
+			"key of 1024 bytes":  func(p *Argon2Params) { p.KeyLen = 1024 },
+			"salt of 1024 bytes": func(p *Argon2Params) { p.SaltLen = 1024 },
+		}
+		for name, modify := range invalid {
+			t.Run(name, func(t *testing.T) {
+				params := cheap
+				modify(&params)
+				assert.Error(t, params.Validate())
+
+				_, err := Argon2GenerateHash("password", params)
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("Absurd params of hash are rejected", func(t *testing.T) {
+		// m=8388608 is 8 GiB, the hash is never computed.
+		hash := "$argon2id$v=19$m=8388608,t=3,p=4$YmdnaGIzcjQyMzU0d2VyZ2Y$Bi7u7sDIGW2enDW/y4ZhmQ"
+		assert.False(t, Argon2CheckHash("password", hash))
+	})
+
+	t.Run("NeedsRehash", func(t *testing.T) {
+		hash, err := Argon2GenerateHash("password", cheap)
+		assert.NoError(t, err)
+
+		stronger := cheap
+		stronger.Iterations = 3
+		moreMemory := cheap
+		moreMemory.Memory = 128
+		weaker := cheap
+		weaker.Iterations = 1
+
+		tests := []struct {
+			name   string
+			hash   string
+			params Argon2Params
+			want   bool
+		}{
+			{name: "Same params", hash: hash, params: cheap, want: false},
+			{name: "Weaker params", hash: hash, params: weaker, want: false},
+			{name: "More iterations", hash: hash, params: stronger, want: true},
+			{name: "More memory", hash: hash, params: moreMemory, want: true},
+			{
+				name:   "Argon2i hash",
+				hash:   "$argon2i$v=19$m=16,t=2,p=1$YmdnaGIzcjQyMzU0d2VyZ2Y$Bi7u7sDIGW2enDW/y4ZhmQ",
+				params: Argon2Params{Memory: 16, Iterations: 1, Parallelism: 1, SaltLen: 8, KeyLen: 16},
+				want:   true,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := Argon2NeedsRehash(tt.hash, tt.params)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			})
+		}
+
+		_, err = Argon2NeedsRehash("invalidhash", cheap)
+		assert.ErrorIs(t, err, ErrArgon2InvalidHash)
+	})
+}
@@ -0,0 +1,112 @@
+package cryptoutil
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// The iterations of the PBKDF2 hashes.
+const (
+	// PBKDF2DefaultIterations is the number of iterations recommended by
+	// OWASP for PBKDF2-HMAC-SHA256.
+	PBKDF2DefaultIterations = 600_000
+	// pbkdf2MinIterations and pbkdf2MaxIterations are the limits of the
+	// iterations, out of them the hash is either too weak or so expensive
+	// that checking it could exhaust the server.
+	pbkdf2MinIterations = 1_000
+	pbkdf2MaxIterations = 10_000_000
+)
+
+// The lengths of the salt and the key of the generated PBKDF2 hashes.
+const (
+	pbkdf2SaltLen = 16
+	pbkdf2KeyLen  = 32
+)
+
+// ErrPBKDF2InvalidHash is returned for the hashes that are not
+// PBKDF2-HMAC-SHA256 hashes.
+var ErrPBKDF2InvalidHash = errors.New("invalid pbkdf2 hash")
+
+// PBKDF2GenerateHash generates a PBKDF2-HMAC-SHA256 hash of the given
+// password, encoded as $pbkdf2-sha256$i=<iterations>$<salt>$<hash> with the
+// salt and the hash in unpadded base64.
+//
+// The iterations parameter is optional. If not provided,
+// PBKDF2DefaultIterations is used.
+func PBKDF2GenerateHash(password string, iterations ...int) (string, error) {
+	picked := PBKDF2DefaultIterations
+	if len(iterations) > 0 {
+		picked = iterations[0]
+	}
+	if err := validatePBKDF2Iterations(picked); err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, pbkdf2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2.Key([]byte(password), salt, picked, pbkdf2KeyLen, sha256.New)
+
+	return fmt.Sprintf(
+		"$pbkdf2-sha256$i=%d$%s$%s", picked,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// PBKDF2CheckHash checks if the given password matches the given
+// PBKDF2-HMAC-SHA256 hash, with the iterations encoded in the hash. The
+// hashes with iterations out of the limits never match.
+func PBKDF2CheckHash(password string, hash string) bool {
+	iterations, salt, key, err := pbkdf2Decode(hash)
+	if err != nil {
+		return false
+	}
+
+	got := pbkdf2.Key([]byte(password), salt, iterations, len(key), sha256.New)
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
+
+// validatePBKDF2Iterations returns an error if the iterations are out of
+// their limits.
+func validatePBKDF2Iterations(iterations int) error {
+	if iterations < pbkdf2MinIterations || iterations > pbkdf2MaxIterations {
+		return fmt.Errorf(
+			"invalid pbkdf2 iterations %d, valid values are %d-%d",
+			iterations, pbkdf2MinIterations, pbkdf2MaxIterations,
+		)
+	}
+	return nil
+}
+
+// pbkdf2Decode returns the iterations, the salt and the hash of an encoded
+// PBKDF2-HMAC-SHA256 hash.
+func pbkdf2Decode(hash string) (int, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "pbkdf2-sha256" {
+		return 0, nil, nil, ErrPBKDF2InvalidHash
+	}
+
+	var iterations int
+	if _, err := fmt.Sscanf(parts[2], "i=%d", &iterations); err != nil {
+		return 0, nil, nil, fmt.Errorf("%w: %w", ErrPBKDF2InvalidHash, err)
+	}
+	if err := validatePBKDF2Iterations(iterations); err != nil {
+		return 0, nil, nil, fmt.Errorf("%w: %w", ErrPBKDF2InvalidHash, err)
+	}
+	salt, key, err := decodeSaltAndKey(parts[3], parts[4])
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("%w: %w", ErrPBKDF2InvalidHash, err)
+	}
+	if len(salt) < 8 || len(key) < 16 || len(key) > 64 {
+		return 0, nil, nil, fmt.Errorf("%w: invalid salt or hash length", ErrPBKDF2InvalidHash)
+	}
+	return iterations, salt, key, nil
+}
@@ -0,0 +1,82 @@
+package cryptoutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPBKDF2Hardcoded(t *testing.T) {
+	password := "SecureP@ssw0rd!"
+	hash := "$pbkdf2-sha256$i=1000$bnNxbGl0ZS1zYWx0LTEyMw$fyUzDj7sJUl9aKnOwRtTfDDbWAErx8uFfj5MTe1l4Yk"
+
+	t.Run("Check Hash", func(t *testing.T) {
+		assert.True(t, PBKDF2CheckHash(password, hash))
+	})
+
+	t.Run("Generate And Check Hash", func(t *testing.T) {
+		newHash, err := PBKDF2GenerateHash(password)
+		assert.NoError(t, err)
+		assert.Contains(t, newHash, "$pbkdf2-sha256$i=600000$")
+		assert.True(t, PBKDF2CheckHash(password, newHash))
+	})
+}
+
+func TestPBKDF2GenerateHash(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		iterations []int
+		wantErr    bool
+	}{
+		{"EmptyPassword", "", []int{1000}, false},
+		{"SimplePassword", "password123", []int{1000}, false},
+		{"SpecialChars", "P@$$w0rd!", []int{1000}, false},
+		{"LongPassword", "aVeryLongPasswordThatExceedsNormalLength1234567890", []int{1000}, false},
+		{"InvalidIterationsTooLow", "password", []int{10}, true},
+		{"InvalidIterationsTooHigh", "password", []int{100_000_000}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := PBKDF2GenerateHash(tt.password, tt.iterations...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, PBKDF2CheckHash(tt.password, hash))
+			}
+		})
+	}
+}
+
+func TestPBKDF2CheckHash(t *testing.T) {
+	password := "SecureP@ssw0rd!"
+	hash, err := PBKDF2GenerateHash(password, 1000)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"CorrectPassword", password, hash, true},
+		{"IncorrectPassword", "WrongPassword", hash, false},
+		{"EmptyPassword", "", hash, false},
+		{"EmptyHash", password, "", false},
+		{"InvalidHashFormat", password, "invalidhash", false},
+		{"OtherAlgorithm", password, "$scrypt$ln=10,r=8,p=1$bnNxbGl0ZS1zYWx0LTEyMw$OfM7dMp8KZSlmSfAGHxVehFsm1CWFrGtOFlVuxUXy7Q", false},
+		{"InvalidIterations", password, "$pbkdf2-sha256$i=x$bnNxbGl0ZS1zYWx0LTEyMw$fyUzDj7sJUl9aKnOwRtTfDDbWAErx8uFfj5MTe1l4Yk", false},
+		{"AbsurdIterations", password, "$pbkdf2-sha256$i=999999999$bnNxbGl0ZS1zYWx0LTEyMw$fyUzDj7sJUl9aKnOwRtTfDDbWAErx8uFfj5MTe1l4Yk", false},
+		{"InvalidHash", password, "$pbkdf2-sha256$i=1000$bnNxbGl0ZS1zYWx0LTEyMw$!!!", false},
+		{"ShortHash", password, "$pbkdf2-sha256$i=1000$bnNxbGl0ZS1zYWx0LTEyMw$fyUzDj7s", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := PBKDF2CheckHash(tt.password, tt.hash)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
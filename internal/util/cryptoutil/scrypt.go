@@ -0,0 +1,144 @@
+package cryptoutil
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ScryptParams are the parameters of the scrypt hashes.
+type ScryptParams struct {
+	// LogN is the base 2 logarithm of the CPU and memory cost N.
+	LogN uint8
+	// R is the block size.
+	R int
+	// P is the parallelization.
+	P int
+	// SaltLen is the length of the random salt in bytes.
+	SaltLen int
+	// KeyLen is the length of the hash in bytes.
+	KeyLen int
+}
+
+// The limits of the ScryptParams, out of them checking a hash could
+// exhaust the server.
+const (
+	scryptMaxLogN = 20
+	scryptMaxR    = 32
+	scryptMaxP    = 16
+)
+
+// ErrScryptInvalidHash is returned for the hashes that are not scrypt
+// hashes.
+var ErrScryptInvalidHash = errors.New("invalid scrypt hash")
+
+// DefaultScryptParams returns the parameters of the hashes when none are
+// given: N=2^15, r=8 and p=1, 32 MiB of memory.
+func DefaultScryptParams() ScryptParams {
+	return ScryptParams{LogN: 15, R: 8, P: 1, SaltLen: 16, KeyLen: 32}
+}
+
+// Validate returns an error if the parameters are out of their limits.
+func (p ScryptParams) Validate() error {
+	switch {
+	case p.LogN < 1 || p.LogN > scryptMaxLogN:
+		return fmt.Errorf("invalid scrypt ln %d, valid values are 1-%d", p.LogN, scryptMaxLogN)
+	case p.R < 1 || p.R > scryptMaxR:
+		return fmt.Errorf("invalid scrypt r %d, valid values are 1-%d", p.R, scryptMaxR)
+	case p.P < 1 || p.P > scryptMaxP:
+		return fmt.Errorf("invalid scrypt p %d, valid values are 1-%d", p.P, scryptMaxP)
+	case p.SaltLen < 8 || p.SaltLen > 64:
+		return fmt.Errorf("invalid scrypt salt length %d, valid values are 8-64", p.SaltLen)
+	case p.KeyLen < 16 || p.KeyLen > 64:
+		return fmt.Errorf("invalid scrypt key length %d, valid values are 16-64", p.KeyLen)
+	}
+	return nil
+}
+
+// ScryptGenerateHash generates a scrypt hash of the given password, encoded
+// as $scrypt$ln=<logN>,r=<r>,p=<p>$<salt>$<hash> with the salt and the hash
+// in unpadded base64.
+//
+// The params are optional. If not provided, DefaultScryptParams are used.
+func ScryptGenerateHash(password string, params ...ScryptParams) (string, error) {
+	picked := DefaultScryptParams()
+	if len(params) > 0 {
+		picked = params[0]
+	}
+	if err := picked.Validate(); err != nil {
+		return "", err
+	}
+
+	salt := make([]byte, picked.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<picked.LogN, picked.R, picked.P, picked.KeyLen)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(
+		"$scrypt$ln=%d,r=%d,p=%d$%s$%s", picked.LogN, picked.R, picked.P,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// ScryptCheckHash checks if the given password matches the given scrypt
+// hash, with the parameters encoded in the hash. The hashes with parameters
+// out of the limits of ScryptParams never match.
+func ScryptCheckHash(password string, hash string) bool {
+	params, salt, key, err := scryptDecode(hash)
+	if err != nil {
+		return false
+	}
+
+	got, err := scrypt.Key([]byte(password), salt, 1<<params.LogN, params.R, params.P, len(key))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, key) == 1
+}
+
+// scryptDecode returns the parameters, the salt and the hash of an encoded
+// scrypt hash.
+func scryptDecode(hash string) (ScryptParams, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "scrypt" {
+		return ScryptParams{}, nil, nil, ErrScryptInvalidHash
+	}
+
+	params := ScryptParams{}
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &params.LogN, &params.R, &params.P); err != nil {
+		return ScryptParams{}, nil, nil, fmt.Errorf("%w: %w", ErrScryptInvalidHash, err)
+	}
+	salt, key, err := decodeSaltAndKey(parts[3], parts[4])
+	if err != nil {
+		return ScryptParams{}, nil, nil, fmt.Errorf("%w: %w", ErrScryptInvalidHash, err)
+	}
+	params.SaltLen, params.KeyLen = len(salt), len(key)
+
+	if err := params.Validate(); err != nil {
+		return ScryptParams{}, nil, nil, fmt.Errorf("%w: %w", ErrScryptInvalidHash, err)
+	}
+	return params, salt, key, nil
+}
+
+// decodeSaltAndKey decodes the unpadded base64 salt and key of an encoded
+// hash.
+func decodeSaltAndKey(encodedSalt string, encodedKey string) ([]byte, []byte, error) {
+	salt, err := base64.RawStdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid hash: %w", err)
+	}
+	return salt, key, nil
+}
@@ -0,0 +1,84 @@
+package cryptoutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScryptHardcoded(t *testing.T) {
+	password := "SecureP@ssw0rd!"
+	hash := "$scrypt$ln=10,r=8,p=1$bnNxbGl0ZS1zYWx0LTEyMw$OfM7dMp8KZSlmSfAGHxVehFsm1CWFrGtOFlVuxUXy7Q"
+
+	t.Run("Check Hash", func(t *testing.T) {
+		assert.True(t, ScryptCheckHash(password, hash))
+	})
+
+	t.Run("Generate And Check Hash", func(t *testing.T) {
+		newHash, err := ScryptGenerateHash(password)
+		assert.NoError(t, err)
+		assert.True(t, ScryptCheckHash(password, newHash))
+	})
+}
+
+func TestScryptGenerateHash(t *testing.T) {
+	cheap := ScryptParams{LogN: 10, R: 8, P: 1, SaltLen: 16, KeyLen: 32}
+
+	tests := []struct {
+		name     string
+		password string
+		params   []ScryptParams
+		wantErr  bool
+	}{
+		{"EmptyPassword", "", nil, false},
+		{"SimplePassword", "password123", nil, false},
+		{"SpecialChars", "P@$$w0rd!", nil, false},
+		{"LongPassword", "aVeryLongPasswordThatExceedsNormalLength1234567890", nil, false},
+		{"CustomParams", "password", []ScryptParams{cheap}, false},
+		{"InvalidLogN", "password", []ScryptParams{{LogN: 30, R: 8, P: 1, SaltLen: 16, KeyLen: 32}}, true},
+		{"InvalidSaltLen", "password", []ScryptParams{{LogN: 10, R: 8, P: 1, SaltLen: 2, KeyLen: 32}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := ScryptGenerateHash(tt.password, tt.params...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, ScryptCheckHash(tt.password, hash))
+			}
+		})
+	}
+}
+
+func TestScryptCheckHash(t *testing.T) {
+	password := "SecureP@ssw0rd!"
+	hash, err := ScryptGenerateHash(password, ScryptParams{LogN: 10, R: 8, P: 1, SaltLen: 16, KeyLen: 32})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+	}{
+		{"CorrectPassword", password, hash, true},
+		{"IncorrectPassword", "WrongPassword", hash, false},
+		{"EmptyPassword", "", hash, false},
+		{"EmptyHash", password, "", false},
+		{"InvalidHashFormat", password, "invalidhash", false},
+		{"OtherAlgorithm", password, "$pbkdf2-sha256$i=1000$bnNxbGl0ZS1zYWx0LTEyMw$fyUzDj7sJUl9aKnOwRtTfDDbWAErx8uFfj5MTe1l4Yk", false},
+		{"InvalidParams", password, "$scrypt$ln=x,r=8,p=1$bnNxbGl0ZS1zYWx0LTEyMw$OfM7dMp8KZSlmSfAGHxVehFsm1CWFrGtOFlVuxUXy7Q", false},
+		{"AbsurdParams", password, "$scrypt$ln=40,r=8,p=1$bnNxbGl0ZS1zYWx0LTEyMw$OfM7dMp8KZSlmSfAGHxVehFsm1CWFrGtOFlVuxUXy7Q", false},
+		{"InvalidSalt", password, "$scrypt$ln=10,r=8,p=1$!!!$OfM7dMp8KZSlmSfAGHxVehFsm1CWFrGtOFlVuxUXy7Q", false},
+		{"TruncatedHash", password, "$scrypt$ln=10,r=8,p=1$bnNxbGl0ZS1zYWx0LTEyMw", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ScryptCheckHash(tt.password, tt.hash)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package cryptoutil
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+)
+
+// SecureCompare reports whether a and b are equal in a time that depends
+// neither on their contents nor on their lengths, because their fixed-length
+// SHA-256 digests are compared instead of the values.
+func SecureCompare(a string, b string) bool {
+	aDigest := sha256.Sum256([]byte(a))
+	bDigest := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(aDigest[:], bDigest[:]) == 1
+}
@@ -0,0 +1,30 @@
+package cryptoutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want bool
+	}{
+		{name: "Equal", a: "SecureP@ssw0rd!", b: "SecureP@ssw0rd!", want: true},
+		{name: "Both empty", a: "", b: "", want: true},
+		{name: "Different", a: "SecureP@ssw0rd!", b: "SecureP@ssw0rd?", want: false},
+		{name: "Prefix", a: "SecureP@ssw0rd!", b: "SecureP@ss", want: false},
+		{name: "One empty", a: "SecureP@ssw0rd!", b: "", want: false},
+		{name: "Different case", a: "token", b: "TOKEN", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SecureCompare(tt.a, tt.b))
+			assert.Equal(t, tt.want, SecureCompare(tt.b, tt.a))
+		})
+	}
+}
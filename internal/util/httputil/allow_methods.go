@@ -0,0 +1,32 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// AllowMethods returns a Middleware that rejects the requests whose method
+// is not one of methods with a 405 *Error, after setting the Allow header
+// of the response. Like in http.ServeMux, HEAD is allowed with GET.
+func AllowMethods(methods ...string) Middleware {
+	allowed := slices.Clone(methods)
+	if slices.Contains(allowed, http.MethodGet) && !slices.Contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	allowHeader := strings.Join(allowed, ", ")
+
+	return func(next HandlerFuncErr) HandlerFuncErr {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			if !slices.Contains(allowed, r.Method) {
+				w.Header().Set("Allow", allowHeader)
+				return MethodNotAllowed(
+					fmt.Errorf("method %s not allowed on %s", r.Method, r.URL.Path),
+					fmt.Sprintf("Method %s not allowed, allowed methods: %s", r.Method, allowHeader),
+				)
+			}
+			return next(w, r)
+		}
+	}
+}
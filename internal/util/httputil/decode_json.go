@@ -0,0 +1,156 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// The codes of the errors of the request bodies.
+const (
+	CodeBodyTooLarge         = "NSQLITE_BODY_TOO_LARGE"
+	CodeUnsupportedMediaType = "NSQLITE_UNSUPPORTED_MEDIA_TYPE"
+)
+
+// DecodeOption is an option of DecodeJSON.
+type DecodeOption func(decoder *json.Decoder)
+
+// DisallowUnknownFields makes DecodeJSON fail when the body has fields that
+// are not in the destination.
+func DisallowUnknownFields(decoder *json.Decoder) {
+	decoder.DisallowUnknownFields()
+}
+
+// DecodeJSON decodes the JSON body of the request into dst. The body must
+// be a single JSON value of at most maxBytes, and the Content-Type of the
+// request, if any, must be JSON.
+//
+// The errors are *Error values with a message for the client, like the
+// offset of a syntax error.
+func DecodeJSON(r *http.Request, maxBytes int64, dst any, options ...DecodeOption) error {
+	if err := checkContentType(r, isJSONMediaType, "application/json"); err != nil {
+		return err
+	}
+	if r.Body == nil {
+		return BadRequest(errors.New("empty request body"), "Request body is empty")
+	}
+	defer r.Body.Close()
+
+	body := &limitedReader{r: r.Body, remaining: maxBytes}
+	decoder := json.NewDecoder(body)
+	for _, option := range options {
+		option(decoder)
+	}
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err, body, maxBytes)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		if err != nil && body.exceeded {
+			return bodyTooLarge(maxBytes)
+		}
+		return BadRequest(
+			errors.New("trailing data after JSON value"),
+			"Request body must contain a single JSON value",
+		)
+	}
+	return nil
+}
+
+// checkContentType returns an error if the request has a Content-Type that
+// is not accepted. A request without Content-Type is accepted.
+func checkContentType(r *http.Request, accept func(mediaType string) bool, want string) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !accept(mediaType) {
+		return NewError(
+			http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+			fmt.Errorf("unsupported content type %q", contentType),
+			fmt.Sprintf("Unsupported Content-Type %q, expected %s", contentType, want),
+		)
+	}
+	return nil
+}
+
+// isJSONMediaType reports whether the media type is application/json or a
+// JSON based one, like application/problem+json.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// decodeError returns the *Error of an error of json.Decoder.Decode.
+func decodeError(err error, body *limitedReader, maxBytes int64) error {
+	if body.exceeded {
+		return bodyTooLarge(maxBytes)
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return BadRequest(err, "Request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return BadRequest(err, "Malformed JSON: unexpected end of request body")
+	case errors.As(err, &syntaxErr):
+		return BadRequest(err, fmt.Sprintf(
+			"Malformed JSON at offset %d: %s", syntaxErr.Offset, syntaxErr.Error(),
+		))
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "(root)"
+		}
+		return BadRequest(err, fmt.Sprintf(
+			"Invalid JSON value for %s at offset %d: expected %s, got %s",
+			field, typeErr.Offset, typeErr.Type, typeErr.Value,
+		))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return BadRequest(err, "Unknown JSON field "+strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+	return BadRequest(err, "Failed to read request body")
+}
+
+// bodyTooLarge returns the *Error of a body larger than maxBytes.
+func bodyTooLarge(maxBytes int64) *Error {
+	return NewError(
+		http.StatusRequestEntityTooLarge, CodeBodyTooLarge,
+		errors.New("request body too large"),
+		fmt.Sprintf("Request body is larger than %d bytes", maxBytes),
+	)
+}
+
+// errBodyTooLarge is returned by limitedReader past its limit.
+var errBodyTooLarge = errors.New("request body too large")
+
+// limitedReader reads at most remaining bytes, then fails and records that
+// the limit was exceeded.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		return int(l.remaining), errBodyTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,176 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		maxBytes    int64
+		options     []DecodeOption
+		want        payload
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:     "Valid body",
+			body:     `{"name":"a","count":2}`,
+			maxBytes: 1024,
+			want:     payload{Name: "a", Count: 2},
+		},
+		{
+			name:        "JSON content type with charset",
+			body:        `{"name":"a"}`,
+			contentType: "application/json; charset=utf-8",
+			maxBytes:    1024,
+			want:        payload{Name: "a"},
+		},
+		{
+			name:        "Wrong content type",
+			body:        `{"name":"a"}`,
+			contentType: "text/plain",
+			maxBytes:    1024,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    CodeUnsupportedMediaType,
+			wantMessage: `Unsupported Content-Type "text/plain", expected application/json`,
+		},
+		{
+			name:        "Empty body",
+			body:        "",
+			maxBytes:    1024,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeBadRequest,
+			wantMessage: "Request body is empty",
+		},
+		{
+			name:        "Malformed JSON",
+			body:        `{"name":"a",}`,
+			maxBytes:    1024,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeBadRequest,
+			wantMessage: "Malformed JSON at offset 13: invalid character '}' looking for beginning of object key string",
+		},
+		{
+			name:        "Truncated JSON",
+			body:        `{"name":`,
+			maxBytes:    1024,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeBadRequest,
+			wantMessage: "Malformed JSON: unexpected end of request body",
+		},
+		{
+			name:        "Wrong type",
+			body:        `{"count":"two"}`,
+			maxBytes:    1024,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeBadRequest,
+			wantMessage: "Invalid JSON value for count at offset 14: expected int, got string",
+		},
+		{
+			name:     "Unknown field allowed",
+			body:     `{"name":"a","other":1}`,
+			maxBytes: 1024,
+			want:     payload{Name: "a"},
+		},
+		{
+			name:        "Unknown field disallowed",
+			body:        `{"name":"a","other":1}`,
+			maxBytes:    1024,
+			options:     []DecodeOption{DisallowUnknownFields},
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeBadRequest,
+			wantMessage: `Unknown JSON field "other"`,
+		},
+		{
+			name:        "Trailing data",
+			body:        `{"name":"a"} {"name":"b"}`,
+			maxBytes:    1024,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    CodeBadRequest,
+			wantMessage: "Request body must contain a single JSON value",
+		},
+		{
+			name:        "Oversized body",
+			body:        `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			maxBytes:    50,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantCode:    CodeBodyTooLarge,
+			wantMessage: "Request body is larger than 50 bytes",
+		},
+		{
+			name:     "Body of exactly the limit",
+			body:     `{"name":"a"}`,
+			maxBytes: 12,
+			want:     payload{Name: "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var got payload
+			err := DecodeJSON(r, tt.maxBytes, &got, tt.options...)
+			if tt.wantStatus == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+
+			httpErr, ok := err.(*Error)
+			require.True(t, ok, "expected *Error, got %T", err)
+			assert.Equal(t, tt.wantStatus, httpErr.Status)
+			assert.Equal(t, tt.wantCode, httpErr.Code)
+			assert.Equal(t, tt.wantMessage, httpErr.SafeMessage())
+		})
+	}
+}
+
+func TestReadBodyString(t *testing.T) {
+	t.Run("Valid body", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("SELECT 1"))
+		r.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+		got, err := ReadBodyString(r, 1024)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT 1", got)
+	})
+
+	t.Run("Wrong content type", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{}"))
+		r.Header.Set("Content-Type", "application/json")
+
+		_, err := ReadBodyString(r, 1024)
+		httpErr, ok := err.(*Error)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusUnsupportedMediaType, httpErr.Status)
+	})
+
+	t.Run("Oversized body", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("SELECT 1"))
+
+		_, err := ReadBodyString(r, 4)
+		httpErr, ok := err.(*Error)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, httpErr.Status)
+		assert.Equal(t, CodeBodyTooLarge, httpErr.Code)
+	})
+}
@@ -1,29 +1,109 @@
 package httputil
 
-// JSONError represents an error that can be safely marshaled to JSON.
-type JSONError struct {
-	error
-	HTTPStatus  int
-	SafeMessage string
-}
+import (
+	"errors"
+	"net/http"
+)
 
-// NewJSONError creates a new JSONError.
-//
-// The err is intended to be a detailed error to be internally logged, while
-// safeMessage is a message that can be safely shown to the client without
-// revealing too much information.
+// The codes of the errors of the helpers.
+const (
+	CodeBadRequest       = "NSQLITE_BAD_REQUEST"
+	CodeUnauthorized     = "NSQLITE_UNAUTHORIZED"
+	CodeNotFound         = "NSQLITE_NOT_FOUND"
+	CodeMethodNotAllowed = "NSQLITE_METHOD_NOT_ALLOWED"
+	CodeConflict         = "NSQLITE_CONFLICT"
+	CodeTooManyRequests  = "NSQLITE_TOO_MANY_REQUESTS"
+	CodeInternal         = "NSQLITE_INTERNAL"
+)
+
+// Error is an error of a request with the HTTP status and the code of its
+// response.
 //
-// If no safeMessage is provided, the textual representation of the status
-// code will be used.
-func NewJSONError(status int, err error, safeMessage ...string) JSONError {
-	pickedSafeMessage := ""
-	if len(safeMessage) > 0 {
-		pickedSafeMessage = safeMessage[0]
+// Err is the detailed cause, intended to be internally logged, while
+// Message can be safely shown to the client without revealing too much
+// information. If Message is empty, the textual representation of the
+// status is used.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error
+}
+
+// NewError creates a new Error.
+func NewError(status int, code string, err error, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message, Err: err}
+}
+
+// BadRequest returns an Error with the 400 status.
+func BadRequest(err error, message string) *Error {
+	return NewError(http.StatusBadRequest, CodeBadRequest, err, message)
+}
+
+// Unauthorized returns an Error with the 401 status.
+func Unauthorized(err error, message string) *Error {
+	return NewError(http.StatusUnauthorized, CodeUnauthorized, err, message)
+}
+
+// NotFound returns an Error with the 404 status.
+func NotFound(err error, message string) *Error {
+	return NewError(http.StatusNotFound, CodeNotFound, err, message)
+}
+
+// MethodNotAllowed returns an Error with the 405 status.
+func MethodNotAllowed(err error, message string) *Error {
+	return NewError(http.StatusMethodNotAllowed, CodeMethodNotAllowed, err, message)
+}
+
+// Conflict returns an Error with the 409 status.
+func Conflict(err error, message string) *Error {
+	return NewError(http.StatusConflict, CodeConflict, err, message)
+}
+
+// TooManyRequests returns an Error with the 429 status.
+func TooManyRequests(err error, message string) *Error {
+	return NewError(http.StatusTooManyRequests, CodeTooManyRequests, err, message)
+}
+
+// Internal returns an Error with the 500 status.
+func Internal(err error, message string) *Error {
+	return NewError(http.StatusInternalServerError, CodeInternal, err, message)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.SafeMessage()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// SafeMessage returns the message that can be shown to the client.
+func (e *Error) SafeMessage() string {
+	if e.Message != "" {
+		return e.Message
 	}
+	return http.StatusText(e.Status)
+}
+
+// ErrorMapper translates the errors it knows into an *Error, and returns
+// nil for the others.
+type ErrorMapper func(err error) *Error
 
-	return JSONError{
-		error:       err,
-		HTTPStatus:  status,
-		SafeMessage: pickedSafeMessage,
+// ClassifyError returns the *Error in the chain of err, or the one the
+// first mapper translates it into. The other errors are internal errors.
+func ClassifyError(err error, mappers ...ErrorMapper) *Error {
+	var httpErr *Error
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	for _, mapper := range mappers {
+		if httpErr := mapper(err); httpErr != nil {
+			return httpErr
+		}
 	}
+	return Internal(err, "")
 }
@@ -0,0 +1,74 @@
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHelpers(t *testing.T) {
+	cause := errors.New("cause")
+
+	tests := []struct {
+		name       string
+		err        *Error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "BadRequest", err: BadRequest(cause, "bad"), wantStatus: http.StatusBadRequest, wantCode: CodeBadRequest},
+		{name: "Unauthorized", err: Unauthorized(cause, "bad"), wantStatus: http.StatusUnauthorized, wantCode: CodeUnauthorized},
+		{name: "NotFound", err: NotFound(cause, "bad"), wantStatus: http.StatusNotFound, wantCode: CodeNotFound},
+		{name: "MethodNotAllowed", err: MethodNotAllowed(cause, "bad"), wantStatus: http.StatusMethodNotAllowed, wantCode: CodeMethodNotAllowed},
+		{name: "Conflict", err: Conflict(cause, "bad"), wantStatus: http.StatusConflict, wantCode: CodeConflict},
+		{name: "Internal", err: Internal(cause, "bad"), wantStatus: http.StatusInternalServerError, wantCode: CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, tt.err.Status)
+			assert.Equal(t, tt.wantCode, tt.err.Code)
+			assert.Equal(t, "bad", tt.err.SafeMessage())
+			assert.Equal(t, "cause", tt.err.Error())
+			assert.ErrorIs(t, tt.err, cause)
+		})
+	}
+
+	t.Run("Default message", func(t *testing.T) {
+		err := NotFound(nil, "")
+		assert.Equal(t, "Not Found", err.SafeMessage())
+		assert.Equal(t, "Not Found", err.Error())
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	errKnown := errors.New("known")
+	mapper := func(err error) *Error {
+		if errors.Is(err, errKnown) {
+			return Conflict(err, "mapped")
+		}
+		return nil
+	}
+
+	t.Run("Error in the chain", func(t *testing.T) {
+		err := fmt.Errorf("handler: %w", BadRequest(errKnown, "bad"))
+		assert.Equal(t, CodeBadRequest, ClassifyError(err, mapper).Code, "the error takes precedence over the mappers")
+	})
+
+	t.Run("Mapped error", func(t *testing.T) {
+		httpErr := ClassifyError(fmt.Errorf("db: %w", errKnown), mapper)
+		assert.Equal(t, CodeConflict, httpErr.Code)
+		assert.Equal(t, "mapped", httpErr.Message)
+	})
+
+	t.Run("Internal error", func(t *testing.T) {
+		cause := errors.New("unknown")
+		httpErr := ClassifyError(cause, mapper)
+		assert.Equal(t, http.StatusInternalServerError, httpErr.Status)
+		assert.Equal(t, CodeInternal, httpErr.Code)
+		assert.Equal(t, "Internal Server Error", httpErr.SafeMessage())
+		assert.ErrorIs(t, httpErr, cause)
+	})
+}
@@ -0,0 +1,84 @@
+package httputil
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Negotiate returns the offered media type preferred by the Accept header
+// of the request, following the weights and the specificity of its media
+// ranges. The first offer wins the ties and is returned if the request has
+// no Accept header. If no offer is acceptable, it returns an empty string.
+func Negotiate(r *http.Request, offers ...string) string {
+	ranges := parseAccept(r.Header.Values("Accept"))
+	if len(ranges) == 0 && len(offers) > 0 {
+		return offers[0]
+	}
+
+	best, bestWeight := "", 0.0
+	for _, offer := range offers {
+		if weight := acceptWeight(ranges, offer); weight > bestWeight {
+			best, bestWeight = offer, weight
+		}
+	}
+	return best
+}
+
+// acceptRange is a media range of an Accept header with its weight.
+type acceptRange struct {
+	mediaType string
+	weight    float64
+}
+
+// parseAccept returns the media ranges of the Accept headers, ignoring the
+// ones that cannot be parsed.
+func parseAccept(headers []string) []acceptRange {
+	ranges := []acceptRange{}
+	for _, header := range headers {
+		for _, part := range strings.Split(header, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			mediaType, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+
+			weight := 1.0
+			if q, ok := params["q"]; ok {
+				parsed, err := strconv.ParseFloat(q, 64)
+				if err != nil {
+					continue
+				}
+				weight = parsed
+			}
+			ranges = append(ranges, acceptRange{mediaType: mediaType, weight: weight})
+		}
+	}
+	return ranges
+}
+
+// acceptWeight returns the weight of the most specific media range that
+// matches the media type, zero if none does.
+func acceptWeight(ranges []acceptRange, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	weight, specificity := 0.0, 0
+	for _, r := range ranges {
+		rangeSpecificity := 0
+		switch r.mediaType {
+		case mediaType:
+			rangeSpecificity = 3
+		case mainType + "/*":
+			rangeSpecificity = 2
+		case "*/*":
+			rangeSpecificity = 1
+		}
+		if rangeSpecificity > specificity {
+			weight, specificity = r.weight, rangeSpecificity
+		}
+	}
+	return weight
+}
@@ -0,0 +1,44 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/plain"}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{name: "no accept header", accept: "", want: "application/json"},
+		{name: "any", accept: "*/*", want: "application/json"},
+		{name: "text plain", accept: "text/plain", want: "text/plain"},
+		{name: "json", accept: "application/json", want: "application/json"},
+		{name: "text range", accept: "text/*", want: "text/plain"},
+		{name: "weights", accept: "application/json;q=0.5, text/plain", want: "text/plain"},
+		{name: "tie", accept: "text/plain, application/json", want: "application/json"},
+		{name: "specific range wins", accept: "*/*;q=0.1, text/plain;q=0.8", want: "text/plain"},
+		{name: "excluded", accept: "application/json;q=0, */*", want: "text/plain"},
+		{name: "parameters", accept: "text/plain; charset=utf-8", want: "text/plain"},
+		{name: "case insensitive", accept: "Text/Plain", want: "text/plain"},
+		{name: "none acceptable", accept: "text/html", want: ""},
+		{name: "invalid range ignored", accept: "invalid, text/plain", want: "text/plain"},
+		{name: "invalid weight ignored", accept: "application/json;q=high, text/plain", want: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			assert.Equal(t, tt.want, Negotiate(r, offers...))
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package httputil
+
+import (
+	"io"
+	"net/http"
+)
+
+// ReadBodyString reads the body of the request as text of at most maxBytes.
+// The Content-Type of the request, if any, must be text/plain.
+func ReadBodyString(r *http.Request, maxBytes int64) (string, error) {
+	isText := func(mediaType string) bool { return mediaType == "text/plain" }
+	if err := checkContentType(r, isText, "text/plain"); err != nil {
+		return "", err
+	}
+	if r.Body == nil {
+		return "", nil
+	}
+	defer r.Body.Close()
+
+	body := &limitedReader{r: r.Body, remaining: maxBytes}
+	contents, err := io.ReadAll(body)
+	if body.exceeded {
+		return "", bodyTooLarge(maxBytes)
+	}
+	if err != nil {
+		return "", BadRequest(err, "Failed to read request body")
+	}
+	return string(contents), nil
+}
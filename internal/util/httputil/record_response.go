@@ -0,0 +1,143 @@
+package httputil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseRecord is what RecordResponse captured of a response.
+//
+// It is updated while the response is written, so it is only complete in
+// the functions registered with OnFinish.
+type ResponseRecord struct {
+	// Start is when the request reached the middleware.
+	Start time.Time
+	// Status is the status of the response, zero until it is sent.
+	Status int
+	// Bytes is the number of bytes of the body written.
+	Bytes int64
+	// FirstByte is the time from Start to the sending of the status, zero
+	// until it is sent.
+	FirstByte time.Duration
+	// Duration is the time from Start to the end of the handler.
+	Duration time.Duration
+	// Hijacked is true if the connection was hijacked, so the status and
+	// the bytes written through it are unknown.
+	Hijacked bool
+
+	onFinish []func(rec *ResponseRecord)
+}
+
+// OnFinish registers a function called with the complete record once the
+// handler returned, including any error response. The functions are called
+// in reverse order of registration, like deferred functions.
+func (rec *ResponseRecord) OnFinish(f func(rec *ResponseRecord)) {
+	rec.onFinish = append(rec.onFinish, f)
+}
+
+type responseRecordKey struct{}
+
+// ResponseRecordFromContext returns the record stored by RecordResponse in
+// the context, if any.
+func ResponseRecordFromContext(ctx context.Context) (*ResponseRecord, bool) {
+	rec, ok := ctx.Value(responseRecordKey{}).(*ResponseRecord)
+	return rec, ok
+}
+
+// RecordResponse wraps the http.ResponseWriter of the requests to record the
+// status, the size and the latency of the responses, and stores the record
+// in the request context for the middlewares that need them, like the
+// access log and the metrics.
+//
+// It must wrap the whole handler, error handler included, so it is an
+// http.Handler middleware rather than a Middleware.
+func RecordResponse(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &ResponseRecord{Start: time.Now()}
+		recorder := &responseRecorder{w: w, rec: rec}
+		ctx := context.WithValue(r.Context(), responseRecordKey{}, rec)
+
+		defer func() {
+			rec.Duration = time.Since(rec.Start)
+			// Without any write net/http sends a 200 response.
+			if rec.Status == 0 && !rec.Hijacked {
+				rec.Status = http.StatusOK
+			}
+			for i := len(rec.onFinish) - 1; i >= 0; i-- {
+				rec.onFinish[i](rec)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+	})
+}
+
+// responseRecorder is the http.ResponseWriter that fills a ResponseRecord.
+// It passes the flushes and the hijacks through to the wrapped writer, so
+// it can be used by the streamed responses.
+type responseRecorder struct {
+	w   http.ResponseWriter
+	rec *ResponseRecord
+}
+
+func (rr *responseRecorder) Header() http.Header {
+	return rr.w.Header()
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	// The informational responses are followed by the final one.
+	if rr.rec.Status == 0 && status >= http.StatusOK {
+		rr.sent(status)
+	}
+	rr.w.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(p []byte) (int, error) {
+	if rr.rec.Status == 0 {
+		rr.sent(http.StatusOK)
+	}
+	n, err := rr.w.Write(p)
+	rr.rec.Bytes += int64(n)
+	return n, err
+}
+
+// Flush sends the buffered data to the client, if the wrapped writer
+// supports it.
+func (rr *responseRecorder) Flush() {
+	flusher, ok := rr.w.(http.Flusher)
+	if !ok {
+		return
+	}
+	if rr.rec.Status == 0 {
+		rr.sent(http.StatusOK)
+	}
+	flusher.Flush()
+}
+
+// Hijack takes over the connection, if the wrapped writer supports it.
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rr.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("hijack: %w", http.ErrNotSupported)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		rr.rec.Hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.w
+}
+
+// sent records that the status was sent.
+func (rr *responseRecorder) sent(status int) {
+	rr.rec.Status = status
+	rr.rec.FirstByte = time.Since(rr.rec.Start)
+}
@@ -0,0 +1,119 @@
+package httputil
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveRecorded serves a request with the handler wrapped by RecordResponse
+// and returns the finished record.
+func serveRecorded(t *testing.T, handler http.HandlerFunc) *ResponseRecord {
+	t.Helper()
+
+	records := make(chan *ResponseRecord, 1)
+	ts := httptest.NewServer(RecordResponse(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			rec, ok := ResponseRecordFromContext(r.Context())
+			require.True(t, ok)
+			rec.OnFinish(func(rec *ResponseRecord) { records <- rec })
+			handler(w, r)
+		},
+	)))
+	defer ts.Close()
+
+	conn, err := ts.Client().Get(ts.URL)
+	if err == nil {
+		_, _ = io.Copy(io.Discard, conn.Body)
+		_ = conn.Body.Close()
+	}
+
+	return <-records
+}
+
+func TestRecordResponse(t *testing.T) {
+	t.Run("Normal response", func(t *testing.T) {
+		rec := serveRecorded(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("hello"))
+		})
+
+		assert.Equal(t, http.StatusCreated, rec.Status)
+		assert.Equal(t, int64(5), rec.Bytes)
+		assert.Positive(t, rec.FirstByte)
+		assert.GreaterOrEqual(t, rec.Duration, rec.FirstByte)
+		assert.False(t, rec.Hijacked)
+	})
+
+	t.Run("Implicit status", func(t *testing.T) {
+		rec := serveRecorded(t, func(w http.ResponseWriter, r *http.Request) {})
+
+		assert.Equal(t, http.StatusOK, rec.Status)
+		assert.Zero(t, rec.Bytes)
+		assert.Zero(t, rec.FirstByte)
+	})
+
+	t.Run("Streamed response", func(t *testing.T) {
+		rec := serveRecorded(t, func(w http.ResponseWriter, r *http.Request) {
+			flusher, ok := w.(http.Flusher)
+			require.True(t, ok)
+			for range 3 {
+				_, _ = w.Write([]byte("line\n"))
+				flusher.Flush()
+			}
+		})
+
+		assert.Equal(t, http.StatusOK, rec.Status)
+		assert.Equal(t, int64(15), rec.Bytes)
+	})
+
+	t.Run("Streamed response with WriteJSONStream", func(t *testing.T) {
+		rec := serveRecorded(t, func(w http.ResponseWriter, r *http.Request) {
+			items := func(yield func(any) bool) {
+				_ = yield(1) && yield(2)
+			}
+			require.NoError(t, WriteJSONStream(w, http.StatusAccepted, items))
+		})
+
+		assert.Equal(t, http.StatusAccepted, rec.Status)
+		assert.Equal(t, int64(4), rec.Bytes)
+	})
+
+	t.Run("Hijacked connection", func(t *testing.T) {
+		rec := serveRecorded(t, func(w http.ResponseWriter, r *http.Request) {
+			hijacker, ok := w.(http.Hijacker)
+			require.True(t, ok)
+			conn, rw, err := hijacker.Hijack()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n")
+			_ = rw.Flush()
+		})
+
+		assert.True(t, rec.Hijacked)
+		assert.Zero(t, rec.Status)
+		assert.Zero(t, rec.Bytes)
+	})
+
+	t.Run("Hijack not supported", func(t *testing.T) {
+		recorder := &responseRecorder{w: httptest.NewRecorder(), rec: &ResponseRecord{}}
+		_, _, err := recorder.Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+		assert.False(t, recorder.rec.Hijacked)
+	})
+}
+
+// Make sure the recorder can still be used with http.ResponseController.
+func TestRecordResponseController(t *testing.T) {
+	inner := httptest.NewRecorder()
+	recorder := &responseRecorder{w: inner, rec: &ResponseRecord{}}
+
+	require.NoError(t, http.NewResponseController(recorder).Flush())
+	assert.True(t, inner.Flushed)
+	assert.Equal(t, http.StatusOK, recorder.rec.Status)
+}
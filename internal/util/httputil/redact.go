@@ -0,0 +1,65 @@
+package httputil
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Redacted replaces the credentials in the logs.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are the headers whose values are credentials.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// IsSensitiveHeader reports whether the values of the header are
+// credentials that must never be logged.
+func IsSensitiveHeader(name string) bool {
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)]
+}
+
+// RedactHeaderValue returns the value of the header to log: the values of
+// the sensitive headers are replaced with Redacted, keeping the scheme of
+// the authorization ones, like "Bearer [REDACTED]".
+func RedactHeaderValue(name string, value string) string {
+	if !IsSensitiveHeader(name) {
+		return value
+	}
+
+	canonical := http.CanonicalHeaderKey(name)
+	if canonical == "Authorization" || canonical == "Proxy-Authorization" {
+		scheme, _, found := strings.Cut(strings.TrimSpace(value), " ")
+		if found && scheme != "" {
+			return scheme + " " + Redacted
+		}
+	}
+	return Redacted
+}
+
+// RedactHeader returns a copy of the header with the values of the
+// sensitive headers redacted.
+func RedactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name, values := range redacted {
+		for i, value := range values {
+			values[i] = RedactHeaderValue(name, value)
+		}
+	}
+	return redacted
+}
+
+// BearerToken returns the token of an Authorization header value, without
+// its Bearer scheme, matched case-insensitively, and the surrounding
+// whitespace. A value without scheme is returned as is, trimmed.
+func BearerToken(value string) string {
+	value = strings.TrimSpace(value)
+	scheme, token, found := strings.Cut(value, " ")
+	if found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return value
+}
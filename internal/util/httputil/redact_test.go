@@ -0,0 +1,64 @@
+package httputil
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHeaderValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   string
+	}{
+		{name: "Bearer token", header: "Authorization", value: "Bearer secret", want: "Bearer [REDACTED]"},
+		{name: "Raw token", header: "Authorization", value: "secret", want: "[REDACTED]"},
+		{name: "Lowercase header", header: "authorization", value: "Basic c2VjcmV0", want: "Basic [REDACTED]"},
+		{name: "Proxy authorization", header: "Proxy-Authorization", value: "Bearer secret", want: "Bearer [REDACTED]"},
+		{name: "Cookie", header: "Cookie", value: "session=secret", want: "[REDACTED]"},
+		{name: "Other header", header: "Content-Type", value: "application/json", want: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RedactHeaderValue(tt.header, tt.value))
+		})
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	header := http.Header{
+		"Authorization": []string{"Bearer secret"},
+		"Accept":        []string{"application/json"},
+	}
+
+	redacted := RedactHeader(header)
+	assert.Equal(t, "Bearer [REDACTED]", redacted.Get("Authorization"))
+	assert.Equal(t, "application/json", redacted.Get("Accept"))
+	assert.Equal(t, "Bearer secret", header.Get("Authorization"), "the original header is not modified")
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: ""},
+		{value: "secret", want: "secret"},
+		{value: "Bearer secret", want: "secret"},
+		{value: "bearer secret", want: "secret"},
+		{value: "BEARER secret", want: "secret"},
+		{value: "  Bearer    secret  ", want: "secret"},
+		{value: "Bearer", want: "Bearer"},
+		{value: "Basic c2VjcmV0", want: "Basic c2VjcmV0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, BearerToken(tt.value))
+		})
+	}
+}
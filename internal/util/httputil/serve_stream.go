@@ -0,0 +1,184 @@
+package httputil
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// compressedExtensions are the extensions of the files that are already
+// compressed, so they are never gzipped again.
+var compressedExtensions = []string{
+	".gz", ".tgz", ".zip", ".zst", ".bz2", ".xz", ".br", ".lz4", ".7z",
+}
+
+// compressedContentTypes are the content types that are already compressed.
+var compressedContentTypes = []string{
+	"application/gzip", "application/x-gzip", "application/zip",
+	"application/zstd", "application/x-bzip2", "application/x-xz",
+}
+
+// gzipPool are the writers of the gzipped responses.
+var gzipPool = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// ServeStream serves the content with http.ServeContent, so it supports the
+// range and conditional requests, with an ETag generated from the size and
+// the modification time of the content unless the response already has one.
+//
+// The content is gzipped on the fly when the client accepts it, it is not a
+// range request, and the content is not already compressed according to
+// its name or Content-Type. The gzipped responses have their own ETag.
+//
+// The name is used for the Content-Type and, if not empty, the
+// Content-Disposition of the response.
+func ServeStream(
+	w http.ResponseWriter, r *http.Request,
+	name string, size int64, modTime time.Time, content io.ReadSeeker,
+) {
+	if name != "" {
+		SetContentDisposition(w, "attachment", name)
+	}
+	if w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", ETag(size, modTime))
+	}
+
+	if isCompressed(name, w.Header().Get("Content-Type")) {
+		http.ServeContent(w, r, name, modTime, content)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	if r.Header.Get("Range") != "" || !acceptsGzip(r) {
+		http.ServeContent(w, r, name, modTime, content)
+		return
+	}
+
+	etag := w.Header().Get("ETag")
+	if strings.HasSuffix(etag, `"`) {
+		w.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+`-gzip"`)
+	}
+
+	gw := &gzipResponseWriter{ResponseWriter: w}
+	defer gw.close()
+	http.ServeContent(gw, r, name, modTime, content)
+}
+
+// ETag returns a strong ETag generated from the size and the modification
+// time of a content.
+func ETag(size int64, modTime time.Time) string {
+	return fmt.Sprintf(`"%x-%x"`, size, modTime.UnixNano())
+}
+
+// SetContentDisposition sets the Content-Disposition of the response, with
+// a disposition like attachment or inline and the name of the file.
+func SetContentDisposition(w http.ResponseWriter, disposition string, name string) {
+	value := mime.FormatMediaType(disposition, map[string]string{
+		"filename": filepath.Base(name),
+	})
+	if value == "" {
+		value = disposition
+	}
+	w.Header().Set("Content-Disposition", value)
+}
+
+// isCompressed reports whether a content is already compressed according to
+// its name or its content type.
+func isCompressed(name string, contentType string) bool {
+	if slices.Contains(compressedExtensions, strings.ToLower(filepath.Ext(name))) {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return slices.Contains(compressedContentTypes, mediaType)
+}
+
+// acceptsGzip reports whether the Accept-Encoding of the request accepts
+// gzip, with a weight other than zero.
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, part := range strings.Split(header, ",") {
+			coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != "gzip" && coding != "*" {
+				continue
+			}
+
+			weight := 1.0
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				parsed, err := strconv.ParseFloat(q, 64)
+				if err != nil {
+					continue
+				}
+				weight = parsed
+			}
+			return weight > 0
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter gzips the body of the successful responses. The other
+// ones, like 304 or 412, are written as is.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	passthrough bool
+}
+
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	if gw.wroteHeader {
+		return
+	}
+	gw.wroteHeader = true
+
+	if status != http.StatusOK {
+		gw.passthrough = true
+		gw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	gw.Header().Del("Content-Length")
+	gw.Header().Set("Content-Encoding", "gzip")
+	gw.ResponseWriter.WriteHeader(status)
+}
+
+func (gw *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !gw.wroteHeader {
+		gw.WriteHeader(http.StatusOK)
+	}
+	if gw.passthrough {
+		return gw.ResponseWriter.Write(p)
+	}
+
+	if gw.gz == nil {
+		gw.gz = gzipPool.Get().(*gzip.Writer)
+		gw.gz.Reset(gw.ResponseWriter)
+	}
+	return gw.gz.Write(p)
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (gw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// close writes the end of the gzipped body, if any.
+func (gw *gzipResponseWriter) close() {
+	if gw.gz == nil {
+		return
+	}
+	_ = gw.gz.Close()
+	gw.gz.Reset(nil)
+	gzipPool.Put(gw.gz)
+	gw.gz = nil
+}
@@ -0,0 +1,140 @@
+package httputil
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeStream(t *testing.T) {
+	content := strings.Repeat("nsqlite backup ", 100)
+	modTime := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	serve := func(method string, headers map[string]string, name string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/backup", nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		ServeStream(w, r, name, int64(len(content)), modTime, strings.NewReader(content))
+		return w
+	}
+
+	t.Run("Full content", func(t *testing.T) {
+		w := serve(http.MethodGet, nil, "database.sqlite")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, content, w.Body.String())
+		assert.Equal(t, ETag(int64(len(content)), modTime), w.Header().Get("ETag"))
+		assert.Equal(t, `attachment; filename=database.sqlite`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("Range request", func(t *testing.T) {
+		w := serve(http.MethodGet, map[string]string{
+			"Range":           "bytes=0-6",
+			"Accept-Encoding": "gzip",
+		}, "database.sqlite")
+
+		assert.Equal(t, http.StatusPartialContent, w.Code)
+		assert.Equal(t, "nsqlite", w.Body.String())
+		assert.Equal(t, "bytes 0-6/1500", w.Header().Get("Content-Range"))
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("HEAD request", func(t *testing.T) {
+		w := serve(http.MethodHead, nil, "database.sqlite")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, "1500", w.Header().Get("Content-Length"))
+	})
+
+	t.Run("Not modified", func(t *testing.T) {
+		w := serve(http.MethodGet, map[string]string{
+			"If-None-Match": ETag(int64(len(content)), modTime),
+		}, "database.sqlite")
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Gzip", func(t *testing.T) {
+		w := serve(http.MethodGet, map[string]string{
+			"Accept-Encoding": "br;q=1.0, gzip;q=0.8",
+		}, "database.sqlite")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		assert.Empty(t, w.Header().Get("Content-Length"))
+		assert.True(t, strings.HasSuffix(w.Header().Get("ETag"), `-gzip"`))
+		assert.Less(t, w.Body.Len(), len(content))
+
+		gz, err := gzip.NewReader(w.Body)
+		require.NoError(t, err)
+		got, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(got))
+	})
+
+	t.Run("Gzip refused", func(t *testing.T) {
+		w := serve(http.MethodGet, map[string]string{
+			"Accept-Encoding": "gzip;q=0",
+		}, "database.sqlite")
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, content, w.Body.String())
+	})
+
+	t.Run("Gzip of not modified", func(t *testing.T) {
+		first := serve(http.MethodGet, map[string]string{"Accept-Encoding": "gzip"}, "database.sqlite")
+		w := serve(http.MethodGet, map[string]string{
+			"Accept-Encoding": "gzip",
+			"If-None-Match":   first.Header().Get("ETag"),
+		}, "database.sqlite")
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Already compressed", func(t *testing.T) {
+		w := serve(http.MethodGet, map[string]string{"Accept-Encoding": "gzip"}, "database.sqlite.gz")
+
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Empty(t, w.Header().Get("Vary"))
+		assert.Equal(t, content, w.Body.String())
+	})
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{acceptEncoding: "", want: false},
+		{acceptEncoding: "gzip", want: true},
+		{acceptEncoding: "deflate, GZIP", want: true},
+		{acceptEncoding: "gzip;q=0.5", want: true},
+		{acceptEncoding: "gzip;q=0", want: false},
+		{acceptEncoding: "*", want: true},
+		{acceptEncoding: "br", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			assert.Equal(t, tt.want, acceptsGzip(r))
+		})
+	}
+}
@@ -1,31 +1,129 @@
 package httputil
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
+	"strconv"
+	"sync"
 )
 
+// maxPooledBufferSize is the capacity above which the buffers are not put
+// back in the pool, so a few large responses don't keep their memory.
+const maxPooledBufferSize = 4 << 20
+
+// bufferPool are the buffers the JSON responses are encoded into.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// bufioPool are the writers of the streamed responses.
+var bufioPool = sync.Pool{
+	New: func() any { return bufio.NewWriterSize(nil, 32*1024) },
+}
+
+// ResponseWrittenError is returned when writing a response failed after
+// its status was sent, so it cannot be replaced by an error response. The
+// error handlers should only log it.
+type ResponseWrittenError struct {
+	Err error
+}
+
+func (e *ResponseWrittenError) Error() string {
+	return "failed to write response: " + e.Err.Error()
+}
+
+func (e *ResponseWrittenError) Unwrap() error {
+	return e.Err
+}
+
 // WriteJSON writes a JSON response to the given http.ResponseWriter.
+//
+// The value is encoded into a pooled buffer before anything is written, so
+// if it cannot be encoded the error is returned and an error response can
+// still be written.
 func WriteJSON(w http.ResponseWriter, status int, v any) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}
+	}()
 
-	if err := json.NewEncoder(w).Encode(v); err != nil {
-		return fmt.Errorf("failed to write JSON response: %w", err)
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON response: %w", err)
 	}
-
-	return nil
+	return WriteJSONBytes(w, status, buf.Bytes())
 }
 
 // WriteJSONBytes writes a byte slice as a JSON response to the given http.ResponseWriter.
 func WriteJSONBytes(w http.ResponseWriter, status int, b []byte) error {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
 	w.WriteHeader(status)
 
 	if _, err := w.Write(b); err != nil {
-		return fmt.Errorf("failed to write JSON response: %w", err)
+		return &ResponseWrittenError{Err: err}
 	}
 
 	return nil
 }
+
+// WriteJSONStream writes the items as a newline-delimited JSON response to
+// the given http.ResponseWriter, through a pooled buffered writer.
+//
+// The status is sent with the first bytes of the response: if an item
+// cannot be encoded before, the error is returned and an error response can
+// still be written, otherwise the error is a *ResponseWrittenError.
+func WriteJSONStream(w http.ResponseWriter, status int, items iter.Seq[any]) error {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	sw := &statusWriter{w: w, status: status}
+
+	bw := bufioPool.Get().(*bufio.Writer)
+	bw.Reset(sw)
+	defer func() {
+		bw.Reset(nil)
+		bufioPool.Put(bw)
+	}()
+
+	encoder := json.NewEncoder(bw)
+	for item := range items {
+		if err := encoder.Encode(item); err != nil {
+			err = fmt.Errorf("failed to encode JSON response: %w", err)
+			if sw.written {
+				return &ResponseWrittenError{Err: err}
+			}
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return &ResponseWrittenError{Err: err}
+	}
+	if !sw.written {
+		w.WriteHeader(status)
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// statusWriter sends the status of the response before its first bytes.
+type statusWriter struct {
+	w       http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (sw *statusWriter) Write(p []byte) (int, error) {
+	if !sw.written {
+		sw.written = true
+		sw.w.WriteHeader(sw.status)
+	}
+	return sw.w.Write(p)
+}
@@ -0,0 +1,156 @@
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"iter"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingWriter is a response writer whose writes fail.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+// testPayload is a response like the one of a query.
+func testPayload(rows int) map[string]any {
+	values := make([][]any, rows)
+	for i := range values {
+		values[i] = []any{i, "name " + strings.Repeat("x", i%20), 1.5 * float64(i), nil}
+	}
+	return map[string]any{
+		"time":    0.25,
+		"columns": []string{"id", "name", "score", "deleted"},
+		"rows":    values,
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Run("Same output as json.Marshal", func(t *testing.T) {
+		payload := testPayload(100)
+		want, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		for range 3 {
+			rec := httptest.NewRecorder()
+			require.NoError(t, WriteJSON(rec, http.StatusCreated, payload))
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.Equal(t, string(want)+"\n", rec.Body.String(), "the pooled buffers are reset")
+			assert.Equal(t, rec.Body.Len(), int(rec.Result().ContentLength))
+		}
+	})
+
+	t.Run("Encoding error writes nothing", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		err := WriteJSON(rec, http.StatusOK, map[string]any{"score": math.Inf(1)})
+		assert.ErrorContains(t, err, "failed to encode JSON response")
+
+		var writtenErr *ResponseWrittenError
+		assert.False(t, errors.As(err, &writtenErr), "an error response can still be written")
+		assert.Empty(t, rec.Header().Get("Content-Type"))
+		assert.Zero(t, rec.Body.Len())
+	})
+
+	t.Run("Write error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		err := WriteJSON(failingWriter{rec}, http.StatusOK, testPayload(1))
+
+		var writtenErr *ResponseWrittenError
+		require.ErrorAs(t, err, &writtenErr)
+		assert.ErrorContains(t, err, "connection reset")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestWriteJSONStream(t *testing.T) {
+	t.Run("Newline-delimited items", func(t *testing.T) {
+		items := []any{testPayload(1), "text", 3, nil}
+		rec := httptest.NewRecorder()
+		require.NoError(t, WriteJSONStream(rec, http.StatusAccepted, slices.Values(items)))
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+		assert.True(t, rec.Flushed)
+
+		lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+		require.Len(t, lines, len(items))
+		for i, item := range items {
+			want, err := json.Marshal(item)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), lines[i])
+		}
+	})
+
+	t.Run("No items", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		require.NoError(t, WriteJSONStream(rec, http.StatusNoContent, slices.Values([]any{})))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Zero(t, rec.Body.Len())
+	})
+
+	t.Run("Encoding error before the first byte", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		err := WriteJSONStream(rec, http.StatusOK, slices.Values([]any{"ok", math.NaN()}))
+		assert.ErrorContains(t, err, "failed to encode JSON response")
+
+		var writtenErr *ResponseWrittenError
+		assert.False(t, errors.As(err, &writtenErr))
+		assert.Zero(t, rec.Body.Len(), "the buffered items are discarded")
+	})
+
+	t.Run("Encoding error after the first byte", func(t *testing.T) {
+		// The items fill the buffer, so the first ones are already sent.
+		items := func(yield func(any) bool) {
+			for range 1000 {
+				if !yield(testPayload(10)) {
+					return
+				}
+			}
+			yield(math.NaN())
+		}
+
+		rec := httptest.NewRecorder()
+		err := WriteJSONStream(rec, http.StatusOK, iter.Seq[any](items))
+
+		var writtenErr *ResponseWrittenError
+		require.ErrorAs(t, err, &writtenErr)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotZero(t, rec.Body.Len())
+	})
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	payload := testPayload(1000)
+
+	b.Run("WriteJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			rec := httptest.NewRecorder()
+			_ = WriteJSON(rec, http.StatusOK, payload)
+		}
+	})
+
+	b.Run("Marshal", func(b *testing.B) {
+		// The response written without the pooled buffer.
+		b.ReportAllocs()
+		for range b.N {
+			rec := httptest.NewRecorder()
+			encoded, _ := json.Marshal(payload)
+			_, _ = rec.Write(encoded)
+		}
+	})
+}
@@ -1,17 +1,18 @@
 package httputil
 
 import (
-	"fmt"
 	"net/http"
+	"strconv"
 )
 
 // WriteString writes a string response to the given http.ResponseWriter.
 func WriteString(w http.ResponseWriter, status int, str string) error {
 	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Length", strconv.Itoa(len(str)))
 	w.WriteHeader(status)
 
 	if _, err := w.Write([]byte(str)); err != nil {
-		return fmt.Errorf("failed to write response: %w", err)
+		return &ResponseWrittenError{Err: err}
 	}
 
 	return nil
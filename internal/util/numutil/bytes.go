@@ -0,0 +1,59 @@
+package numutil
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Bytes returns a human readable representation of a size in bytes using
+// binary units.
+//
+// Example:
+//
+//	1536 -> "1.5 KiB"
+func Bytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit && exp < 5; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// ParseBytes parses a size in bytes with an optional binary unit. The units
+// are case insensitive and their "i" and "B" are optional.
+//
+// Example:
+//
+//	"64KB" -> 65536
+//	"1 MiB" -> 1048576
+func ParseBytes(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "B")
+	str = strings.TrimSuffix(str, "I")
+
+	shift := 0
+	if str != "" {
+		if idx := strings.IndexByte("KMGT", str[len(str)-1]); idx >= 0 {
+			shift = (idx + 1) * 10
+			str = str[:len(str)-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if n > math.MaxInt64>>shift {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n << shift, nil
+}
@@ -0,0 +1,64 @@
+package numutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int64
+		expected string
+	}{
+		{name: "zero", input: 0, expected: "0 B"},
+		{name: "bytes", input: 1023, expected: "1023 B"},
+		{name: "one kibibyte", input: 1024, expected: "1.0 KiB"},
+		{name: "kibibytes", input: 1536, expected: "1.5 KiB"},
+		{name: "mebibytes", input: 5 << 20, expected: "5.0 MiB"},
+		{name: "gibibytes", input: 3 << 30, expected: "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Bytes(tt.input))
+		})
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{input: "512", expected: 512},
+		{input: "512B", expected: 512},
+		{input: "1KB", expected: 1024},
+		{input: "64kb", expected: 64 << 10},
+		{input: "1 MiB", expected: 1 << 20},
+		{input: "8M", expected: 8 << 20},
+		{input: " 2GiB ", expected: 2 << 30},
+		{input: "1T", expected: 1 << 40},
+		{input: "", wantErr: true},
+		{input: "KB", wantErr: true},
+		{input: "-1KB", wantErr: true},
+		{input: "1.5MB", wantErr: true},
+		{input: "10XB", wantErr: true},
+		{input: "99999999999TB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBytes(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -1,11 +1,22 @@
 package syncutil
 
 import (
+	"context"
+	"sync"
 	"sync/atomic"
 )
 
+// Atomic is a value of type T that can be atomically loaded and stored by
+// multiple goroutines safely, and waited for with WaitFor.
+//
+// An Atomic must not be copied after first use.
 type Atomic[T any] struct {
 	value atomic.Value
+
+	// mu guards changed, which is closed to wake up the goroutines in
+	// WaitFor when the value changes. It is nil if nobody waits.
+	mu      sync.Mutex
+	changed chan struct{}
 }
 
 // NewAtomic creates a new Atomic instance initialized with the given value.
@@ -31,4 +42,75 @@ func (a *Atomic[T]) Load() T {
 // Store sets the value of the Atomic instance.
 func (a *Atomic[T]) Store(value T) {
 	a.value.Store(value)
+	a.broadcast()
+}
+
+// Swap sets the value of the Atomic instance and returns the previous one.
+func (a *Atomic[T]) Swap(value T) T {
+	old := a.value.Swap(value)
+	a.broadcast()
+
+	switch v := old.(type) {
+	case T:
+		return v
+	default:
+		var zero T
+		return zero
+	}
+}
+
+// CompareAndSwap sets the value of the Atomic instance to new only if it is
+// old, and reports whether it did. T must be comparable, otherwise it
+// panics.
+func (a *Atomic[T]) CompareAndSwap(old T, new T) bool {
+	swapped := a.value.CompareAndSwap(old, new)
+
+	// An Atomic that was never stored holds the zero value.
+	var zero T
+	if !swapped && any(old) == any(zero) {
+		swapped = a.value.CompareAndSwap(nil, new)
+	}
+
+	if swapped {
+		a.broadcast()
+	}
+	return swapped
+}
+
+// WaitFor waits until the value of the Atomic instance satisfies the
+// predicate, without polling, and returns it. It returns the error of the
+// context if it is done first.
+func (a *Atomic[T]) WaitFor(ctx context.Context, predicate func(T) bool) (T, error) {
+	for {
+		a.mu.Lock()
+		value := a.Load()
+		if predicate(value) {
+			a.mu.Unlock()
+			return value, nil
+		}
+		if a.changed == nil {
+			a.changed = make(chan struct{})
+		}
+		changed := a.changed
+		a.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// broadcast wakes up the goroutines waiting in WaitFor so they check the
+// value again.
+func (a *Atomic[T]) broadcast() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.changed != nil {
+		close(a.changed)
+		a.changed = nil
+	}
 }
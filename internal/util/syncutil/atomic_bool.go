@@ -0,0 +1,12 @@
+package syncutil
+
+// AtomicBool is a bool type that can be atomically loaded and stored by
+// multiple goroutines safely, and waited for with WaitFor.
+type AtomicBool = Atomic[bool]
+
+// NewAtomicBool creates a new AtomicBool with an initial value.
+func NewAtomicBool(initial bool) *AtomicBool {
+	atomicInst := &AtomicBool{}
+	atomicInst.Store(initial)
+	return atomicInst
+}
@@ -1,8 +1,10 @@
 package syncutil
 
 import (
+	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -89,4 +91,76 @@ func TestAtomic(t *testing.T) {
 		atomic.Store(100)
 		assert.Equal(t, 100, atomic.Load(), "Updated value should match the loaded value")
 	})
+
+	t.Run("Swap", func(t *testing.T) {
+		atomic := &Atomic[int]{}
+
+		// Swapping an empty Atomic returns the zero value
+		assert.Equal(t, 0, atomic.Swap(1), "Swap should return the zero value when empty")
+		assert.Equal(t, 1, atomic.Swap(2), "Swap should return the previous value")
+		assert.Equal(t, 2, atomic.Load(), "Swap should store the new value")
+	})
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		atomic := &Atomic[string]{}
+
+		// An empty Atomic holds the zero value
+		assert.False(t, atomic.CompareAndSwap("a", "b"), "CompareAndSwap should fail if the value is not old")
+		assert.True(t, atomic.CompareAndSwap("", "a"), "CompareAndSwap should succeed from the zero value")
+		assert.False(t, atomic.CompareAndSwap("", "b"), "CompareAndSwap should fail once the value changed")
+		assert.True(t, atomic.CompareAndSwap("a", "b"), "CompareAndSwap should succeed if the value is old")
+		assert.Equal(t, "b", atomic.Load(), "CompareAndSwap should store the new value")
+	})
+
+	t.Run("CompareAndSwap_Concurrent", func(t *testing.T) {
+		atomic := NewAtomic("tx")
+
+		const goroutines = 100
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		winners := 0
+
+		for range goroutines {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if atomic.CompareAndSwap("tx", "") {
+					mu.Lock()
+					winners++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, winners, "Only one goroutine should swap the value")
+	})
+
+	t.Run("WaitFor", func(t *testing.T) {
+		atomic := NewAtomicBool(false)
+
+		done := make(chan bool)
+		go func() {
+			value, err := atomic.WaitFor(context.Background(), func(v bool) bool { return v })
+			assert.NoError(t, err)
+			done <- value
+		}()
+
+		atomic.Store(false)
+		atomic.Store(true)
+		assert.True(t, <-done, "WaitFor should return the value that satisfies the predicate")
+
+		value, err := atomic.WaitFor(context.Background(), func(v bool) bool { return v })
+		assert.NoError(t, err)
+		assert.True(t, value, "WaitFor should return at once if the predicate is satisfied")
+	})
+
+	t.Run("WaitFor_Context", func(t *testing.T) {
+		atomic := NewAtomicBool(false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := atomic.WaitFor(ctx, func(v bool) bool { return v })
+		assert.ErrorIs(t, err, context.DeadlineExceeded, "WaitFor should return the error of the context")
+	})
 }
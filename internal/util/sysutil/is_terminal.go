@@ -0,0 +1,19 @@
+package sysutil
+
+import "os"
+
+// IsTerminal reports whether the given stream is a terminal. Streams that
+// are not files, like buffers used in tests, are never terminals.
+func IsTerminal(stream any) bool {
+	file, ok := stream.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,31 @@
+package sysutil
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// TerminalSize returns the width and height of the terminal of the given
+// stream, or an error if the stream is not a terminal.
+func TerminalSize(stream any) (width int, height int, err error) {
+	file, ok := stream.(*os.File)
+	if !ok || !IsTerminal(file) {
+		return 0, 0, errors.New("stream is not a terminal")
+	}
+
+	return term.GetSize(int(file.Fd()))
+}
+
+// MakeRaw puts the terminal of the given file into raw mode, so the keys
+// are read one by one without echo, and returns a function to restore its
+// previous state.
+func MakeRaw(file *os.File) (restore func(), err error) {
+	state, err := term.MakeRaw(int(file.Fd()))
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { _ = term.Restore(int(file.Fd()), state) }, nil
+}
@@ -0,0 +1,33 @@
+package validate
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// CIDRList validates if list is a comma-separated list of IPv4 or IPv6
+// CIDR blocks, like "10.0.0.0/8, fd00::/8". An address without prefix
+// length is the block of that only address. An empty list is valid.
+func CIDRList(field string, list string) error {
+	if strings.TrimSpace(list) == "" {
+		return nil
+	}
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if !isCIDR(item) {
+			return NewError(field, item, "must be an IP address or a CIDR block like 10.0.0.0/8")
+		}
+	}
+	return nil
+}
+
+// isCIDR reports whether s is a CIDR block or an IP address without zone.
+func isCIDR(s string) bool {
+	if strings.Contains(s, "/") {
+		_, err := netip.ParsePrefix(s)
+		return err == nil
+	}
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Zone() == ""
+}
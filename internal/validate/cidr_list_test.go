@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIDRList(t *testing.T) {
+	tests := []struct {
+		name      string
+		list      string
+		wantErr   bool
+		wantValue string
+	}{
+		{name: "empty", list: ""},
+		{name: "blank", list: "  "},
+		{name: "ipv4 block", list: "10.0.0.0/8"},
+		{name: "ipv4 address", list: "192.168.1.10"},
+		{name: "ipv6 block", list: "fd00::/8"},
+		{name: "ipv6 address", list: "::1"},
+		{name: "ipv4 mapped ipv6", list: "::ffff:10.0.0.0/104"},
+		{name: "several with spaces", list: "10.0.0.0/8, 172.16.0.0/12 ,2001:db8::/32"},
+		{name: "host bits set", list: "10.0.0.1/8"},
+		{name: "invalid ipv4 prefix length", list: "10.0.0.0/33", wantErr: true, wantValue: "10.0.0.0/33"},
+		{name: "invalid ipv6 prefix length", list: "fd00::/129", wantErr: true, wantValue: "fd00::/129"},
+		{name: "hostname", list: "10.0.0.0/8,localhost", wantErr: true, wantValue: "localhost"},
+		{name: "ipv6 zone", list: "fe80::1%eth0", wantErr: true, wantValue: "fe80::1%eth0"},
+		{name: "missing prefix length", list: "10.0.0.0/", wantErr: true, wantValue: "10.0.0.0/"},
+		{name: "empty item", list: "10.0.0.0/8,", wantErr: true, wantValue: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CIDRList("trusted proxies", tt.list)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, &Error{
+				Field:  "trusted proxies",
+				Value:  tt.wantValue,
+				Reason: "must be an IP address or a CIDR block like 10.0.0.0/8",
+			}, err)
+		})
+	}
+}
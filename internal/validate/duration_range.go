@@ -0,0 +1,27 @@
+package validate
+
+import (
+	"fmt"
+	"time"
+)
+
+// DurationRange validates if d is between lower and upper, both included.
+// An upper of zero or less means there is no maximum.
+func DurationRange(field string, d time.Duration, lower time.Duration, upper time.Duration) error {
+	if d >= lower && (upper <= 0 || d <= upper) {
+		return nil
+	}
+
+	reason := ""
+	switch {
+	case upper > 0:
+		reason = fmt.Sprintf("must be between %s and %s", lower, upper)
+	case lower == 0:
+		reason = "must be zero or greater"
+	case lower == time.Nanosecond:
+		reason = "must be greater than zero"
+	default:
+		reason = fmt.Sprintf("must be at least %s", lower)
+	}
+	return NewError(field, d.String(), reason)
+}
@@ -0,0 +1,55 @@
+package validate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       time.Duration
+		lower   time.Duration
+		upper   time.Duration
+		wantErr string
+	}{
+		{name: "within range", d: time.Minute, lower: time.Second, upper: time.Hour},
+		{name: "lower bound included", d: time.Second, lower: time.Second, upper: time.Hour},
+		{name: "upper bound included", d: time.Hour, lower: time.Second, upper: time.Hour},
+		{name: "no maximum", d: 1000 * time.Hour, lower: time.Second},
+		{name: "zero allowed", d: 0, lower: 0},
+		{
+			name: "below range", d: 500 * time.Millisecond, lower: time.Second, upper: time.Hour,
+			wantErr: `invalid timeout "500ms", must be between 1s and 1h0m0s`,
+		},
+		{
+			name: "above range", d: 2 * time.Hour, lower: time.Second, upper: time.Hour,
+			wantErr: `invalid timeout "2h0m0s", must be between 1s and 1h0m0s`,
+		},
+		{
+			name: "negative", d: -time.Second, lower: 0,
+			wantErr: `invalid timeout "-1s", must be zero or greater`,
+		},
+		{
+			name: "zero", d: 0, lower: time.Nanosecond,
+			wantErr: `invalid timeout "0s", must be greater than zero`,
+		},
+		{
+			name: "below minimum", d: time.Second, lower: time.Minute,
+			wantErr: `invalid timeout "1s", must be at least 1m0s`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DurationRange("timeout", tt.d, tt.lower, tt.upper)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
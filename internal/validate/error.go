@@ -0,0 +1,28 @@
+package validate
+
+import "fmt"
+
+// Error is the error of a value that is not valid, it names the field and
+// the offending value so the messages of every validation are alike.
+type Error struct {
+	// Field is the name of the field, like "listen port".
+	Field string
+	// Value is the offending value.
+	Value string
+	// Reason explains what a valid value is, like "must be greater than
+	// zero".
+	Reason string
+}
+
+// NewError creates a new Error.
+func NewError(field string, value string, reason string) *Error {
+	return &Error{Field: field, Value: value, Reason: reason}
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("invalid %s %q", e.Field, e.Value)
+	if e.Reason != "" {
+		msg += ", " + e.Reason
+	}
+	return msg
+}
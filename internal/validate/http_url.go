@@ -0,0 +1,13 @@
+package validate
+
+import "net/url"
+
+// HTTPURL validates if raw is an absolute http or https URL with a host,
+// like the URL of a webhook.
+func HTTPURL(field string, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return NewError(field, raw, "must be an absolute http or https URL")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		wantValid bool
+	}{
+		{name: "http", url: "http://example.com", wantValid: true},
+		{name: "https with path and query", url: "https://example.com/hooks/nsqlite?token=1", wantValid: true},
+		{name: "port", url: "https://example.com:8443/hook", wantValid: true},
+		{name: "ipv6 host", url: "http://[::1]:9876/hook", wantValid: true},
+		{name: "empty", url: "", wantValid: false},
+		{name: "relative", url: "/hooks/nsqlite", wantValid: false},
+		{name: "missing host", url: "https://", wantValid: false},
+		{name: "other scheme", url: "ftp://example.com", wantValid: false},
+		{name: "uppercase scheme is normalized", url: "HTTPS://example.com", wantValid: true},
+		{name: "missing scheme", url: "example.com/hook", wantValid: false},
+		{name: "invalid", url: "http://exa mple.com", wantValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := HTTPURL("webhook URL", tt.url)
+			if tt.wantValid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, NewError("webhook URL", tt.url, "must be an absolute http or https URL"), err)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxIdentifierLength is the maximum length in characters of an identifier.
+const MaxIdentifierLength = 128
+
+// Identifier validates if name is an SQLite identifier, like the name of a
+// schema or a table, that is safe to use without quoting: a letter or an
+// underscore followed by letters, digits or underscores, of any script.
+// The names starting with sqlite_ are reserved by SQLite.
+func Identifier(field string, name string) error {
+	if name == "" {
+		return NewError(field, name, "must not be empty")
+	}
+	if utf8.RuneCountInString(name) > MaxIdentifierLength {
+		return NewError(field, name, fmt.Sprintf("must be at most %d characters", MaxIdentifierLength))
+	}
+
+	for i, r := range name {
+		valid := r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))
+		if !valid {
+			return NewError(
+				field, name,
+				"must start with a letter or an underscore and contain only letters, digits and underscores",
+			)
+		}
+	}
+
+	if strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+		return NewError(field, name, "the names starting with sqlite_ are reserved")
+	}
+	return nil
+}
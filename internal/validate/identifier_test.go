@@ -0,0 +1,87 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "lowercase", input: "users"},
+		{name: "mixed case with digits", input: "Users2024"},
+		{name: "leading underscore", input: "_archive"},
+		{name: "underscores", input: "order_items"},
+		{name: "unicode letters", input: "clientes_año"},
+		{name: "non latin script", input: "пользователи"},
+		{name: "cjk", input: "用户表"},
+		{name: "maximum length", input: strings.Repeat("a", MaxIdentifierLength)},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: `invalid table name "", must not be empty`,
+		},
+		{
+			name:    "too long",
+			input:   strings.Repeat("é", MaxIdentifierLength+1),
+			wantErr: "must be at most 128 characters",
+		},
+		{
+			name:    "leading digit",
+			input:   "1users",
+			wantErr: `invalid table name "1users", must start with a letter or an underscore`,
+		},
+		{
+			name:    "space",
+			input:   "my table",
+			wantErr: "must start with a letter or an underscore",
+		},
+		{
+			name:    "quote",
+			input:   `users"; DROP TABLE users; --`,
+			wantErr: "must start with a letter or an underscore",
+		},
+		{
+			name:    "dot",
+			input:   "main.users",
+			wantErr: "must start with a letter or an underscore",
+		},
+		{
+			name:    "emoji",
+			input:   "users_🚀",
+			wantErr: "must start with a letter or an underscore",
+		},
+		{
+			name:    "invalid utf-8",
+			input:   "users\xff",
+			wantErr: "must start with a letter or an underscore",
+		},
+		{
+			name:    "reserved",
+			input:   "SQLite_master",
+			wantErr: "the names starting with sqlite_ are reserved",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Identifier("table name", tt.input)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+
+			var validateErr *Error
+			if assert.ErrorAs(t, err, &validateErr) {
+				assert.Equal(t, "table name", validateErr.Field)
+				assert.Equal(t, tt.input, validateErr.Value)
+			}
+		})
+	}
+}
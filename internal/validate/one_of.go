@@ -0,0 +1,14 @@
+package validate
+
+import (
+	"slices"
+	"strings"
+)
+
+// OneOf validates if value is one of the valid values.
+func OneOf(field string, value string, valid []string) error {
+	if slices.Contains(valid, value) {
+		return nil
+	}
+	return NewError(field, value, "valid values are: "+strings.Join(valid, ", "))
+}
@@ -0,0 +1,74 @@
+package version
+
+import (
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Commit and BuiltAt are the git commit and the build date of the binary.
+// They are read from the build info embedded by the Go toolchain unless
+// they are set at build time with:
+//
+//	-ldflags "-X github.com/nsqlite/nsqlite/internal/version.Commit=<commit>"
+var (
+	Commit  string
+	BuiltAt string
+)
+
+// Info is the version and the build metadata of a binary, as returned by
+// the /version endpoint of the server.
+type Info struct {
+	// Server is the NSQLite version, like v0.1.0.
+	Server string `json:"server"`
+	// Commit is the git commit the binary was built from, if known.
+	Commit string `json:"commit,omitempty"`
+	// GoVersion is the version of Go the binary was built with.
+	GoVersion string `json:"goVersion,omitempty"`
+	// SQLiteVersion and SQLiteSourceID identify the embedded SQLite
+	// library, they are only known by the server.
+	SQLiteVersion  string `json:"sqliteVersion,omitempty"`
+	SQLiteSourceID string `json:"sqliteSourceId,omitempty"`
+	// BuiltAt is the date of the commit or of the build, in RFC 3339.
+	BuiltAt string `json:"builtAt,omitempty"`
+}
+
+// Build returns the version and the build metadata of the running binary.
+func Build() Info {
+	info := Info{
+		Server:    Version,
+		Commit:    Commit,
+		GoVersion: runtime.Version(),
+		BuiltAt:   BuiltAt,
+	}
+
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuiltAt == "" {
+				info.BuiltAt = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// ParseInfo parses the body of a /version response. The older servers
+// answer the version as plain text, it is returned as the Server field of
+// the Info.
+func ParseInfo(body []byte) Info {
+	info := Info{}
+	if err := json.Unmarshal(body, &info); err == nil && info.Server != "" {
+		return info
+	}
+	return Info{Server: strings.TrimSpace(string(body))}
+}